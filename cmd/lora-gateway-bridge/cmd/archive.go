@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/archive"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+var archiveQueryGatewayID string
+var archiveQueryEvent string
+var archiveQuerySince string
+var archiveQueryLimit int
+
+var archiveCmd = &cobra.Command{
+	Use:   "archive",
+	Short: "Inspect the LoRa Gateway Bridge event archive",
+}
+
+var archiveQueryCmd = &cobra.Command{
+	Use:   "query",
+	Short: "Print the archived events matching the given filters (as JSON, one event per line)",
+	RunE:  archiveQuery,
+}
+
+func init() {
+	archiveQueryCmd.Flags().StringVar(&archiveQueryGatewayID, "gateway", "", "gateway ID (HEX encoded)")
+	archiveQueryCmd.Flags().StringVar(&archiveQueryEvent, "event", "", "event type (e.g. up, stats or ack)")
+	archiveQueryCmd.Flags().StringVar(&archiveQuerySince, "since", "", "duration (e.g. 24h) or RFC3339 timestamp")
+	archiveQueryCmd.Flags().IntVar(&archiveQueryLimit, "limit", 0, "max. number of events to return (0 = no limit)")
+
+	archiveCmd.AddCommand(archiveQueryCmd)
+}
+
+type archivedEvent struct {
+	Time      time.Time       `json:"time"`
+	GatewayID lorawan.EUI64   `json:"gatewayID"`
+	Event     string          `json:"event"`
+	ID        uuid.UUID       `json:"id"`
+	Payload   json.RawMessage `json:"payload"`
+}
+
+func archiveQuery(cmd *cobra.Command, args []string) error {
+	filters := archive.Filters{
+		EventType: archiveQueryEvent,
+		Limit:     archiveQueryLimit,
+	}
+
+	if archiveQueryGatewayID != "" {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(archiveQueryGatewayID)); err != nil {
+			return errors.Wrap(err, "parse gateway id error")
+		}
+		filters.GatewayID = &gatewayID
+	}
+
+	if archiveQuerySince != "" {
+		if d, err := time.ParseDuration(archiveQuerySince); err == nil {
+			filters.Since = time.Now().Add(-d)
+		} else {
+			filters.Since, err = time.Parse(time.RFC3339, archiveQuerySince)
+			if err != nil {
+				return errors.Wrap(err, "parse since error")
+			}
+		}
+	}
+
+	if err := archive.Open(config.C.Archive.Path); err != nil {
+		return errors.Wrap(err, "open archive error")
+	}
+	defer archive.Close()
+
+	events, err := archive.Query(filters)
+	if err != nil {
+		return errors.Wrap(err, "query archive error")
+	}
+
+	marshaler := jsonpb.Marshaler{
+		EmitDefaults: true,
+	}
+	enc := json.NewEncoder(os.Stdout)
+
+	for _, e := range events {
+		msg, err := e.Message()
+		if err != nil {
+			return errors.Wrap(err, "get event message error")
+		}
+
+		pl, err := marshaler.MarshalToString(msg)
+		if err != nil {
+			return errors.Wrap(err, "marshal json error")
+		}
+
+		if err := enc.Encode(archivedEvent{
+			Time:      e.CreatedAt,
+			GatewayID: e.GatewayID,
+			Event:     e.EventType,
+			ID:        e.EventID,
+			Payload:   json.RawMessage(pl),
+		}); err != nil {
+			return errors.Wrap(err, "encode event error")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/configcheck"
+)
+
+var checkJSON bool
+
+var checkCmd = &cobra.Command{
+	Use:          "check",
+	Short:        "Validate the configuration file and print a report (exits non-zero on errors)",
+	RunE:         check,
+	SilenceUsage: true,
+}
+
+func init() {
+	checkCmd.Flags().BoolVar(&checkJSON, "json", false, "print the report as JSON")
+}
+
+func check(cmd *cobra.Command, args []string) error {
+	var report configcheck.Report
+	if err := setupSecrets(); err != nil {
+		report.Results = append(report.Results, configcheck.Result{
+			Section: "secrets",
+			Check:   "resolve",
+			Status:  configcheck.StatusError,
+			Error:   err.Error(),
+		})
+		report.Errors++
+	} else {
+		report = configcheck.Check(config.C)
+	}
+
+	if checkJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "SECTION\tCHECK\tSTATUS\tERROR")
+		for _, res := range report.Results {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", res.Section, res.Check, res.Status, res.Error)
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if report.Errors != 0 {
+		return fmt.Errorf("configuration contains %d error(s)", report.Errors)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+var genConfigRegion string
+var genConfigSubBand int
+var genConfigConcentrator string
+var genConfigGatewayID string
+var genConfigServer string
+var genConfigPort int
+var genConfigOutput string
+
+var genConfigCmd = &cobra.Command{
+	Use:   "genconfig",
+	Short: "Generate a packet-forwarder configuration file (global_conf.json) for the given region and concentrator model",
+	RunE:  genConfig,
+}
+
+func init() {
+	genConfigCmd.Flags().StringVar(&genConfigRegion, "region", "", "region (AS923, AU915, CN470, EU868 or US915)")
+	genConfigCmd.Flags().IntVar(&genConfigSubBand, "sub-band", 2, "sub-band (AU915, CN470 and US915 only)")
+	genConfigCmd.Flags().StringVar(&genConfigConcentrator, "concentrator", "sx1301-sx1257", "concentrator model (sx1301-sx1255 or sx1301-sx1257)")
+	genConfigCmd.Flags().StringVar(&genConfigGatewayID, "gateway", "", "gateway ID (HEX encoded)")
+	genConfigCmd.Flags().StringVar(&genConfigServer, "server", "localhost", "LoRa Gateway Bridge hostname or IP address")
+	genConfigCmd.Flags().IntVar(&genConfigPort, "port", 0, "LoRa Gateway Bridge UDP port (default: port of the configured udp_bind)")
+	genConfigCmd.Flags().StringVar(&genConfigOutput, "output", "", "output file (default: stdout)")
+	genConfigCmd.MarkFlagRequired("region")
+}
+
+func genConfig(cmd *cobra.Command, args []string) error {
+	opts := semtechudp.GenConfigOptions{
+		Region:        band.Name(genConfigRegion),
+		SubBand:       genConfigSubBand,
+		Concentrator:  genConfigConcentrator,
+		ServerAddress: genConfigServer,
+		ServerPort:    genConfigPort,
+	}
+
+	if genConfigGatewayID != "" {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(genConfigGatewayID)); err != nil {
+			return errors.Wrap(err, "parse gateway id error")
+		}
+		opts.GatewayID = gatewayID
+	}
+
+	if opts.ServerPort == 0 {
+		_, port, err := net.SplitHostPort(config.C.Backend.SemtechUDP.UDPBind)
+		if err != nil {
+			return errors.Wrap(err, "parse udp_bind error")
+		}
+		opts.ServerPort, err = strconv.Atoi(port)
+		if err != nil {
+			return errors.Wrap(err, "parse udp_bind port error")
+		}
+	}
+
+	b, err := semtechudp.GenConfig(opts)
+	if err != nil {
+		return errors.Wrap(err, "generate config error")
+	}
+
+	if genConfigOutput == "" {
+		_, err = os.Stdout.Write(b)
+		return err
+	}
+
+	if err := ioutil.WriteFile(genConfigOutput, b, 0644); err != nil {
+		return errors.Wrap(err, "write file error")
+	}
+
+	return nil
+}
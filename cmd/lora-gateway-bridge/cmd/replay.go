@@ -0,0 +1,180 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/archive"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/marshaler"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/mqtt"
+	"github.com/brocaar/lora-gateway-bridge/internal/replay"
+	"github.com/brocaar/lorawan"
+)
+
+var replaySource string
+var replayDeadLetterFile string
+var replayGatewayID string
+var replayEvent string
+var replaySince string
+var replayLimit int
+var replayRate float64
+var replayTopicTemplate string
+
+var replayCmd = &cobra.Command{
+	Use:   "replay",
+	Short: "Replay archived or dead-lettered events into the MQTT integration",
+	RunE:  replayEvents,
+}
+
+func init() {
+	replayCmd.Flags().StringVar(&replaySource, "source", "archive", "event source (archive or dead-letter)")
+	replayCmd.Flags().StringVar(&replayDeadLetterFile, "dead-letter-file", "", "dead-letter file (default: configured dead_letter_file)")
+	replayCmd.Flags().StringVar(&replayGatewayID, "gateway", "", "gateway ID (HEX encoded)")
+	replayCmd.Flags().StringVar(&replayEvent, "event", "", "event type (up, stats or ack)")
+	replayCmd.Flags().StringVar(&replaySince, "since", "", "duration (e.g. 24h) or RFC3339 timestamp")
+	replayCmd.Flags().IntVar(&replayLimit, "limit", 0, "max. number of events to replay (0 = no limit)")
+	replayCmd.Flags().Float64Var(&replayRate, "rate", 10, "max. number of events to replay per second (0 = no limit)")
+	replayCmd.Flags().StringVar(&replayTopicTemplate, "topic-template", "", "event topic template used for the replayed events (default: configured event_topic_template)")
+}
+
+func replayEvents(cmd *cobra.Command, args []string) error {
+	if err := setLogLevel(); err != nil {
+		return err
+	}
+
+	if err := setupSecrets(); err != nil {
+		return err
+	}
+
+	if err := setupProxy(); err != nil {
+		return err
+	}
+
+	// the other authentication types allow only a single connection per
+	// gateway (device), which is used by the running LoRa Gateway Bridge
+	if config.C.Integration.Type != "mqtt" || config.C.Integration.MQTT.Auth.Type != "generic" {
+		return errors.New("replay requires the mqtt integration using generic authentication")
+	}
+
+	filters := archive.Filters{
+		EventType: replayEvent,
+		Limit:     replayLimit,
+	}
+
+	if replayGatewayID != "" {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(replayGatewayID)); err != nil {
+			return errors.Wrap(err, "parse gateway id error")
+		}
+		filters.GatewayID = &gatewayID
+	}
+
+	if replaySince != "" {
+		if d, err := time.ParseDuration(replaySince); err == nil {
+			filters.Since = time.Now().Add(-d)
+		} else {
+			filters.Since, err = time.Parse(time.RFC3339, replaySince)
+			if err != nil {
+				return errors.Wrap(err, "parse since error")
+			}
+		}
+	}
+
+	var events []replay.Event
+	var err error
+
+	switch replaySource {
+	case "archive":
+		if err := archive.Open(config.C.Archive.Path); err != nil {
+			return errors.Wrap(err, "open archive error")
+		}
+		defer archive.Close()
+
+		events, err = replay.FromArchive(filters)
+	case "dead-letter":
+		path := replayDeadLetterFile
+		if path == "" {
+			path = config.C.Integration.MQTT.PublishRetry.DeadLetterFile
+		}
+		if path == "" {
+			return errors.New("dead-letter file must be set")
+		}
+
+		events, err = replay.FromDeadLetterFile(path, filters, deadLetterUnmarshaler(config.C))
+	default:
+		return errors.Errorf("unknown source: %s", replaySource)
+	}
+	if err != nil {
+		return errors.Wrap(err, "read events error")
+	}
+
+	if len(events) == 0 {
+		log.Info("replay: no events to replay")
+		return nil
+	}
+
+	publisher, err := mqtt.NewBackend(replayConfig(config.C))
+	if err != nil {
+		return errors.Wrap(err, "setup mqtt integration error")
+	}
+	defer publisher.Close()
+
+	log.WithFields(log.Fields{
+		"source": replaySource,
+		"events": len(events),
+		"rate":   replayRate,
+	}).Info("replay: replaying events")
+
+	count, err := replay.Replay(publisher, events, replayRate)
+	if err != nil {
+		return errors.Wrapf(err, "replay error after %d of %d events", count, len(events))
+	}
+
+	log.WithField("events", count).Info("replay: events replayed")
+
+	return nil
+}
+
+// replayConfig returns the configuration of the MQTT integration used for
+// the replay. The subscriptions are disabled, so that the commands for the
+// running LoRa Gateway Bridge instance are not consumed, and the
+// dead-letters are disabled, so that a failed replay does not append to
+// the replayed dead-letter file.
+func replayConfig(conf config.Config) config.Config {
+	if replayTopicTemplate != "" {
+		conf.Integration.MQTT.EventTopicTemplate = replayTopicTemplate
+	}
+
+	conf.Integration.MQTT.SharedCommandTopic = ""
+	conf.Integration.MQTT.SharedSubscriptionGroup = ""
+	conf.Integration.MQTT.BridgeCommandTopic = ""
+	conf.Integration.MQTT.ReconcileInterval = 0
+	conf.Integration.MQTT.PublishRetry.DeadLetterFile = ""
+	conf.Integration.MQTT.PublishRetry.DeadLetterTopic = ""
+
+	// a client ID can only be used by a single connection
+	if conf.Integration.MQTT.Auth.Generic.ClientID != "" {
+		conf.Integration.MQTT.Auth.Generic.ClientID += "-replay"
+	}
+
+	return conf
+}
+
+// deadLetterUnmarshaler returns the function returning the unmarshal
+// function for the dead-lettered events, which must match the (event)
+// marshaler used for publishing these events.
+func deadLetterUnmarshaler(conf config.Config) func(string) (marshaler.UnmarshalFunc, error) {
+	return func(eventType string) (marshaler.UnmarshalFunc, error) {
+		name, ok := conf.Integration.EventMarshalers[eventType]
+		if !ok {
+			name = conf.Integration.Marshaler
+		}
+
+		_, unmarshal, err := marshaler.Get(name)
+		return unmarshal, err
+	}
+}
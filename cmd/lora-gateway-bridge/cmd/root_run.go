@@ -9,42 +9,141 @@ import (
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/accounting"
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/allowlist"
+	"github.com/brocaar/lora-gateway-bridge/internal/antennagain"
+	"github.com/brocaar/lora-gateway-bridge/internal/antennamap"
+	"github.com/brocaar/lora-gateway-bridge/internal/archive"
 	"github.com/brocaar/lora-gateway-bridge/internal/backend"
+	"github.com/brocaar/lora-gateway-bridge/internal/beacon"
 	"github.com/brocaar/lora-gateway-bridge/internal/commands"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/configdrift"
+	"github.com/brocaar/lora-gateway-bridge/internal/configfanout"
+	"github.com/brocaar/lora-gateway-bridge/internal/debug"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkpolicy"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/lora-gateway-bridge/internal/errorreporting"
 	"github.com/brocaar/lora-gateway-bridge/internal/filters"
 	"github.com/brocaar/lora-gateway-bridge/internal/forwarder"
+	"github.com/brocaar/lora-gateway-bridge/internal/frequencycheck"
+	"github.com/brocaar/lora-gateway-bridge/internal/gpstime"
 	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/keepalive"
+	"github.com/brocaar/lora-gateway-bridge/internal/latency"
+	"github.com/brocaar/lora-gateway-bridge/internal/locations"
+	"github.com/brocaar/lora-gateway-bridge/internal/logcontext"
 	"github.com/brocaar/lora-gateway-bridge/internal/metadata"
 	"github.com/brocaar/lora-gateway-bridge/internal/metrics"
+	"github.com/brocaar/lora-gateway-bridge/internal/multicast"
+	"github.com/brocaar/lora-gateway-bridge/internal/overlay"
+	"github.com/brocaar/lora-gateway-bridge/internal/overload"
+	"github.com/brocaar/lora-gateway-bridge/internal/pipeline"
+	"github.com/brocaar/lora-gateway-bridge/internal/pktfwdlog"
+	"github.com/brocaar/lora-gateway-bridge/internal/privacy"
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
+	"github.com/brocaar/lora-gateway-bridge/internal/quarantine"
+	"github.com/brocaar/lora-gateway-bridge/internal/registry"
+	"github.com/brocaar/lora-gateway-bridge/internal/reorder"
+	"github.com/brocaar/lora-gateway-bridge/internal/rxtime"
+	"github.com/brocaar/lora-gateway-bridge/internal/sampling"
+	"github.com/brocaar/lora-gateway-bridge/internal/secrets"
+	"github.com/brocaar/lora-gateway-bridge/internal/selfupdate"
+	"github.com/brocaar/lora-gateway-bridge/internal/statsdelta"
+	"github.com/brocaar/lora-gateway-bridge/internal/statshistory"
+	"github.com/brocaar/lora-gateway-bridge/internal/status"
+	"github.com/brocaar/lora-gateway-bridge/internal/systemd"
+	"github.com/brocaar/lora-gateway-bridge/internal/testdownlink"
+	"github.com/brocaar/lora-gateway-bridge/internal/uplinkset"
 )
 
 func run(cmd *cobra.Command, args []string) error {
+	defer errorreporting.Recover()
 
+	if err := start(); err != nil {
+		log.Fatal(err)
+	}
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	log.WithField("signal", <-sigChan).Info("signal received")
+
+	stop()
+
+	return nil
+}
+
+// start executes the setup tasks. It is used by the run command and by the
+// Windows service handler.
+func start() error {
 	tasks := []func() error{
 		setLogLevel,
+		setupLogContext,
+		setupProxy,
+		setupSecrets,
+		setupErrorReporting,
 		printStartMessage,
 		setupFilters,
+		setupLocations,
+		setupAntennaMap,
+		setupAntennaGain,
+		setupOverlay,
+		setupSampling,
+		setupOverload,
+		setupPrivacy,
+		setupBeacon,
+		setupBackhaulLatency,
+		setupReorder,
 		setupBackend,
 		setupIntegration,
+		setupArchive,
+		setupAccounting,
+		setupStatsHistory,
+		setupFrequencyCheck,
+		setupConfigDrift,
+		setupQuarantine,
+		setupAllowlist,
+		setupRXTime,
+		setupStatsDelta,
+		setupGPSTime,
+		setupMulticast,
+		setupConfigurationFanOut,
+		setupTestDownlink,
+		setupPacketForwarderLog,
+		setupDownlinkSwitch,
+		setupDownlinkPolicy,
+		setupUplinkSet,
+		setupRegistry,
+		setupPipeline,
 		setupForwarder,
 		setupMetrics,
+		setupDebug,
+		setupAdmin,
+		setupSelfUpdate,
 		setupMetaData,
 		setupCommands,
+		setupKeepalive,
+		setupSystemd,
+		setupStatus,
 	}
 
 	for _, t := range tasks {
 		if err := t(); err != nil {
-			log.Fatal(err)
+			return err
 		}
 	}
 
-	sigChan := make(chan os.Signal)
-	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
-	log.WithField("signal", <-sigChan).Info("signal received")
+	return nil
+}
+
+// stop is called when the LoRa Gateway Bridge is shutting down.
+func stop() {
 	log.Warning("shutting down server")
 
-	return nil
+	if _, err := systemd.Notify(systemd.StateStopping); err != nil {
+		log.WithError(err).Error("systemd notify error")
+	}
 }
 
 func setLogLevel() error {
@@ -52,6 +151,33 @@ func setLogLevel() error {
 	return nil
 }
 
+// setupLogContext registers the log context hook. This must be done before
+// setting up the error reporting, so that the reported errors contain the
+// gateway context.
+func setupLogContext() error {
+	if err := logcontext.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup log context error")
+	}
+	return nil
+}
+
+// setupSecrets resolves the secret references of the configuration. This is
+// not done when loading the configuration, so that the configfile command
+// does not print the resolved secrets.
+func setupProxy() error {
+	if err := proxy.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup proxy error")
+	}
+	return nil
+}
+
+func setupSecrets() error {
+	if err := secrets.Resolve(&config.C); err != nil {
+		return errors.Wrap(err, "setup secrets error")
+	}
+	return nil
+}
+
 func printStartMessage() error {
 	log.WithFields(log.Fields{
 		"version": version,
@@ -74,6 +200,27 @@ func setupIntegration() error {
 	return nil
 }
 
+func setupArchive() error {
+	if err := archive.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup archive error")
+	}
+	return nil
+}
+
+func setupUplinkSet() error {
+	if err := uplinkset.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup uplink set error")
+	}
+	return nil
+}
+
+func setupPipeline() error {
+	if err := pipeline.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup pipeline error")
+	}
+	return nil
+}
+
 func setupForwarder() error {
 	if err := forwarder.Setup(config.C); err != nil {
 		return errors.Wrap(err, "setup forwarder error")
@@ -88,6 +235,20 @@ func setupMetrics() error {
 	return nil
 }
 
+func setupAdmin() error {
+	if err := admin.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup admin api error")
+	}
+	return nil
+}
+
+func setupSelfUpdate() error {
+	if err := selfupdate.Setup(config.C, version); err != nil {
+		return errors.Wrap(err, "setup self-update error")
+	}
+	return nil
+}
+
 func setupMetaData() error {
 	if err := metadata.Setup(config.C); err != nil {
 		return errors.Wrap(err, "setup meta-data error")
@@ -95,6 +256,13 @@ func setupMetaData() error {
 	return nil
 }
 
+func setupErrorReporting() error {
+	if err := errorreporting.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup error reporting error")
+	}
+	return nil
+}
+
 func setupFilters() error {
 	if err := filters.Setup(config.C); err != nil {
 		return errors.Wrap(err, "setup filters error")
@@ -102,9 +270,224 @@ func setupFilters() error {
 	return nil
 }
 
+func setupLocations() error {
+	if err := locations.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup locations error")
+	}
+	return nil
+}
+
+func setupAntennaMap() error {
+	if err := antennamap.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup antenna map error")
+	}
+	return nil
+}
+
+func setupAntennaGain() error {
+	if err := antennagain.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup antenna gain error")
+	}
+	return nil
+}
+
+func setupOverlay() error {
+	if err := overlay.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup overlay error")
+	}
+	return nil
+}
+
+func setupSampling() error {
+	if err := sampling.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup sampling error")
+	}
+	return nil
+}
+
+func setupOverload() error {
+	if err := overload.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup overload error")
+	}
+	return nil
+}
+
+func setupPrivacy() error {
+	if err := privacy.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup privacy error")
+	}
+	return nil
+}
+
 func setupCommands() error {
+	// e.g. the Basic Station backend runs the spectral scan on the gateway
+	if rc, ok := backend.GetBackend().(commands.RemoteCommander); ok {
+		commands.SetRemoteCommander(rc)
+	}
+
 	if err := commands.Setup(config.C); err != nil {
 		return errors.Wrap(err, "setup commands error")
 	}
 	return nil
 }
+
+func setupAccounting() error {
+	if err := accounting.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup accounting error")
+	}
+	return nil
+}
+
+func setupStatsHistory() error {
+	if err := statshistory.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup stats history error")
+	}
+	return nil
+}
+
+func setupFrequencyCheck() error {
+	if err := frequencycheck.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup frequency check error")
+	}
+	return nil
+}
+
+func setupConfigDrift() error {
+	if err := configdrift.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup config drift error")
+	}
+	return nil
+}
+
+func setupQuarantine() error {
+	if err := quarantine.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup quarantine error")
+	}
+	return nil
+}
+
+func setupAllowlist() error {
+	if err := allowlist.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup allowlist error")
+	}
+	return nil
+}
+
+func setupRXTime() error {
+	if err := rxtime.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup rx time error")
+	}
+	return nil
+}
+
+func setupStatsDelta() error {
+	if err := statsdelta.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup stats delta error")
+	}
+	return nil
+}
+
+func setupGPSTime() error {
+	if err := gpstime.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup gps time error")
+	}
+	return nil
+}
+
+func setupDownlinkSwitch() error {
+	if err := downlinkswitch.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup downlink switch error")
+	}
+	return nil
+}
+
+func setupDownlinkPolicy() error {
+	if err := downlinkpolicy.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup downlink policy error")
+	}
+	return nil
+}
+
+func setupRegistry() error {
+	if err := registry.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup registry error")
+	}
+	return nil
+}
+
+func setupMulticast() error {
+	if err := multicast.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup multicast error")
+	}
+	return nil
+}
+
+func setupBackhaulLatency() error {
+	if err := latency.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup backhaul latency error")
+	}
+	return nil
+}
+
+func setupReorder() error {
+	if err := reorder.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup reorder error")
+	}
+	return nil
+}
+
+func setupConfigurationFanOut() error {
+	if err := configfanout.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup configuration fan-out error")
+	}
+	return nil
+}
+
+func setupTestDownlink() error {
+	if err := testdownlink.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup test downlink error")
+	}
+	return nil
+}
+
+func setupPacketForwarderLog() error {
+	if err := pktfwdlog.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup packet-forwarder log error")
+	}
+	return nil
+}
+
+func setupBeacon() error {
+	if err := beacon.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup beacon error")
+	}
+	return nil
+}
+
+func setupDebug() error {
+	if err := debug.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup debug error")
+	}
+	return nil
+}
+
+func setupKeepalive() error {
+	if err := keepalive.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup keepalive error")
+	}
+	return nil
+}
+
+func setupSystemd() error {
+	if err := systemd.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup systemd error")
+	}
+	return nil
+}
+
+func setupStatus() error {
+	if err := status.Setup(config.C); err != nil {
+		return errors.Wrap(err, "setup status error")
+	}
+	return nil
+}
@@ -0,0 +1,57 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func TestSetEventQOSFallback(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Config   string
+		Expected [4]uint8
+	}{
+		{
+			Name: "generic qos only",
+			Config: `
+[integration.mqtt.auth.generic]
+qos=1
+`,
+			Expected: [4]uint8{1, 1, 1, 1},
+		},
+		{
+			Name: "explicit event qos",
+			Config: `
+[integration.mqtt.auth.generic]
+qos=1
+
+[integration.mqtt.event_qos]
+up=0
+ack=2
+`,
+			Expected: [4]uint8{0, 1, 2, 1},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			v := viper.New()
+			v.SetConfigType("toml")
+			assert.NoError(v.ReadConfig(strings.NewReader(tst.Config)))
+
+			var c config.Config
+			assert.NoError(v.Unmarshal(&c))
+			setEventQOSFallback(v, &c)
+
+			q := c.Integration.MQTT.EventQOS
+			assert.Equal(tst.Expected, [4]uint8{q.Up, q.Stats, q.Ack, q.Exec})
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	serviceName        = "lora-gateway-bridge"
+	serviceDisplayName = "LoRa Gateway Bridge"
+	serviceDescription = "LoRa Gateway Bridge abstracts the packet-forwarder protocol into MQTT."
+)
+
+var serviceLogFile string
+
+var serviceCmd = &cobra.Command{
+	Use:   "service",
+	Short: "Manage the LoRa Gateway Bridge system service (Windows service or launchd daemon)",
+}
+
+var serviceInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Install the LoRa Gateway Bridge as system service, using the given configuration file",
+	RunE:  serviceInstall,
+}
+
+var serviceUninstallCmd = &cobra.Command{
+	Use:   "uninstall",
+	Short: "Uninstall the LoRa Gateway Bridge system service",
+	RunE:  serviceUninstall,
+}
+
+var serviceRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run the LoRa Gateway Bridge as system service (this is invoked by the service manager)",
+	RunE:  serviceRun,
+}
+
+func init() {
+	serviceCmd.PersistentFlags().StringVar(&serviceLogFile, "log-file", "", "path to the log file (optional)")
+
+	serviceCmd.AddCommand(serviceInstallCmd)
+	serviceCmd.AddCommand(serviceUninstallCmd)
+	serviceCmd.AddCommand(serviceRunCmd)
+}
+
+// serviceArgs returns the absolute path of the executable and the arguments
+// with which the service manager must start the service.
+func serviceArgs() (string, []string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "get executable path error")
+	}
+
+	args := []string{"service", "run"}
+
+	if cfgFile != "" {
+		p, err := filepath.Abs(cfgFile)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "get config file path error")
+		}
+		args = append(args, "--config", p)
+	}
+
+	if serviceLogFile != "" {
+		p, err := filepath.Abs(serviceLogFile)
+		if err != nil {
+			return "", nil, errors.Wrap(err, "get log file path error")
+		}
+		args = append(args, "--log-file", p)
+	}
+
+	return exe, args, nil
+}
+
+// setServiceLogFile writes the log output to the log file, when set. A
+// service manager does not always capture the stdout / stderr output.
+func setServiceLogFile() error {
+	if serviceLogFile == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(serviceLogFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return errors.Wrap(err, "open log file error")
+	}
+
+	log.SetOutput(f)
+	return nil
+}
@@ -0,0 +1,125 @@
+// +build darwin
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"text/template"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const (
+	launchdLabel = "io.loraserver.lora-gateway-bridge"
+	launchdPlist = "/Library/LaunchDaemons/" + launchdLabel + ".plist"
+)
+
+var launchdPlistTemplate = template.Must(template.New("plist").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{ .Label }}</string>
+	<key>ProgramArguments</key>
+	<array>
+		<string>{{ html .Executable }}</string>{{ range .Args }}
+		<string>{{ html . }}</string>{{ end }}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+</dict>
+</plist>
+`))
+
+func serviceInstall(cmd *cobra.Command, args []string) error {
+	exe, serviceArgs, err := serviceArgs()
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(launchdPlist); err == nil {
+		return fmt.Errorf("launchd daemon %s already exists", launchdPlist)
+	}
+
+	f, err := os.OpenFile(launchdPlist, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return errors.Wrap(err, "create plist file error")
+	}
+
+	err = writeLaunchdPlist(f, exe, serviceArgs)
+	f.Close()
+	if err != nil {
+		return err
+	}
+
+	if err := launchctl("load", "-w", launchdPlist); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"plist": launchdPlist,
+		"exe":   exe,
+		"args":  serviceArgs,
+	}).Info("launchd daemon installed")
+
+	return nil
+}
+
+func serviceUninstall(cmd *cobra.Command, args []string) error {
+	if err := launchctl("unload", "-w", launchdPlist); err != nil {
+		return err
+	}
+
+	if err := os.Remove(launchdPlist); err != nil {
+		return errors.Wrap(err, "remove plist file error")
+	}
+
+	log.WithField("plist", launchdPlist).Info("launchd daemon uninstalled")
+
+	return nil
+}
+
+// serviceRun runs the LoRa Gateway Bridge in the foreground, launchd sends
+// SIGTERM to stop the daemon.
+func serviceRun(cmd *cobra.Command, args []string) error {
+	if err := setServiceLogFile(); err != nil {
+		return err
+	}
+
+	return run(cmd, args)
+}
+
+// writeLaunchdPlist writes the launchd daemon plist, starting the given
+// executable with the given arguments.
+func writeLaunchdPlist(w io.Writer, exe string, args []string) error {
+	err := launchdPlistTemplate.Execute(w, struct {
+		Label      string
+		Executable string
+		Args       []string
+	}{launchdLabel, exe, args})
+	if err != nil {
+		return errors.Wrap(err, "write plist file error")
+	}
+
+	return nil
+}
+
+func launchctl(args ...string) error {
+	out, err := exec.Command("launchctl", args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "launchctl %s error: %s", args[0], out)
+	}
+
+	if len(out) != 0 {
+		log.WithField("output", string(out)).Debug("launchctl output")
+	}
+
+	return nil
+}
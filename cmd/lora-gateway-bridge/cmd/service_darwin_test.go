@@ -0,0 +1,57 @@
+// +build darwin
+
+package cmd
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteLaunchdPlist(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Exe      string
+		Args     []string
+		Expected string
+	}{
+		{
+			Name: "no arguments",
+			Exe:  "/usr/local/bin/lora-gateway-bridge",
+			Expected: `		<string>/usr/local/bin/lora-gateway-bridge</string>
+	</array>`,
+		},
+		{
+			Name: "arguments",
+			Exe:  "/usr/local/bin/lora-gateway-bridge",
+			Args: []string{"service", "run", "--config", "/etc/lora-gateway-bridge/lora-gateway-bridge.toml"},
+			Expected: `		<string>/usr/local/bin/lora-gateway-bridge</string>
+		<string>service</string>
+		<string>run</string>
+		<string>--config</string>
+		<string>/etc/lora-gateway-bridge/lora-gateway-bridge.toml</string>
+	</array>`,
+		},
+		{
+			Name: "escaped arguments",
+			Exe:  "/Applications/R&D/lora-gateway-bridge",
+			Args: []string{"--config", "/Users/<user>/lora-gateway-bridge.toml"},
+			Expected: `		<string>/Applications/R&amp;D/lora-gateway-bridge</string>
+		<string>--config</string>
+		<string>/Users/&lt;user&gt;/lora-gateway-bridge.toml</string>
+	</array>`,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var b bytes.Buffer
+			assert.NoError(writeLaunchdPlist(&b, tst.Exe, tst.Args))
+			assert.Contains(b.String(), "<string>"+launchdLabel+"</string>")
+			assert.Contains(b.String(), tst.Expected)
+		})
+	}
+}
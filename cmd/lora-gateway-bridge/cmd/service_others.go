@@ -0,0 +1,26 @@
+// +build !windows,!darwin
+
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var errServiceNotSupported = errors.New("the service command is not supported on this platform, use the systemd unit or init script instead")
+
+func serviceInstall(cmd *cobra.Command, args []string) error {
+	return errServiceNotSupported
+}
+
+func serviceUninstall(cmd *cobra.Command, args []string) error {
+	return errServiceNotSupported
+}
+
+func serviceRun(cmd *cobra.Command, args []string) error {
+	if err := setServiceLogFile(); err != nil {
+		return err
+	}
+
+	return run(cmd, args)
+}
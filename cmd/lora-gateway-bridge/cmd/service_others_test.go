@@ -0,0 +1,33 @@
+// +build !windows,!darwin
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceNotSupported(t *testing.T) {
+	tests := []struct {
+		Name string
+		Func func(*cobra.Command, []string) error
+	}{
+		{
+			Name: "install",
+			Func: serviceInstall,
+		},
+		{
+			Name: "uninstall",
+			Func: serviceUninstall,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			assert.Equal(errServiceNotSupported, tst.Func(serviceCmd, nil))
+		})
+	}
+}
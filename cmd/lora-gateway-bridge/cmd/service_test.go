@@ -0,0 +1,125 @@
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServiceArgs(t *testing.T) {
+	wd, err := os.Getwd()
+	require.NoError(t, err)
+
+	tests := []struct {
+		Name         string
+		ConfigFile   string
+		LogFile      string
+		ExpectedArgs []string
+	}{
+		{
+			Name:         "no config and log file",
+			ExpectedArgs: []string{"service", "run"},
+		},
+		{
+			Name:         "relative config file",
+			ConfigFile:   "lora-gateway-bridge.toml",
+			ExpectedArgs: []string{"service", "run", "--config", filepath.Join(wd, "lora-gateway-bridge.toml")},
+		},
+		{
+			Name:         "absolute config and log file",
+			ConfigFile:   "/etc/lora-gateway-bridge/lora-gateway-bridge.toml",
+			LogFile:      "/var/log/lora-gateway-bridge.log",
+			ExpectedArgs: []string{"service", "run", "--config", "/etc/lora-gateway-bridge/lora-gateway-bridge.toml", "--log-file", "/var/log/lora-gateway-bridge.log"},
+		},
+		{
+			Name:         "relative log file",
+			LogFile:      "lora-gateway-bridge.log",
+			ExpectedArgs: []string{"service", "run", "--log-file", filepath.Join(wd, "lora-gateway-bridge.log")},
+		},
+	}
+
+	defer func() {
+		cfgFile = ""
+		serviceLogFile = ""
+	}()
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			cfgFile = tst.ConfigFile
+			serviceLogFile = tst.LogFile
+
+			exe, args, err := serviceArgs()
+			assert.NoError(err)
+			assert.True(filepath.IsAbs(exe))
+			assert.Equal(tst.ExpectedArgs, args)
+		})
+	}
+}
+
+func TestSetServiceLogFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "service")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		Name          string
+		LogFile       string
+		ExpectedLog   bool
+		ExpectedError string
+	}{
+		{
+			Name: "no log file",
+		},
+		{
+			Name:        "log file",
+			LogFile:     filepath.Join(dir, "lora-gateway-bridge.log"),
+			ExpectedLog: true,
+		},
+		{
+			Name:          "invalid log file",
+			LogFile:       filepath.Join(dir, "missing", "lora-gateway-bridge.log"),
+			ExpectedError: "open log file error",
+		},
+	}
+
+	out := log.StandardLogger().Out
+	defer func() {
+		log.SetOutput(out)
+		serviceLogFile = ""
+	}()
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			log.SetOutput(out)
+			serviceLogFile = tst.LogFile
+
+			err := setServiceLogFile()
+			if tst.ExpectedError != "" {
+				assert.Error(err)
+				assert.Contains(err.Error(), tst.ExpectedError)
+				assert.Equal(out, log.StandardLogger().Out)
+				return
+			}
+			assert.NoError(err)
+
+			if !tst.ExpectedLog {
+				assert.Equal(out, log.StandardLogger().Out)
+				return
+			}
+
+			log.Warning("service log test")
+
+			b, err := ioutil.ReadFile(tst.LogFile)
+			assert.NoError(err)
+			assert.Contains(string(b), "service log test")
+		})
+	}
+}
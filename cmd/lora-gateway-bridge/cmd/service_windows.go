@@ -0,0 +1,131 @@
+// +build windows
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/mgr"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/errorreporting"
+)
+
+func serviceInstall(cmd *cobra.Command, args []string) error {
+	exe, serviceArgs, err := serviceArgs()
+	if err != nil {
+		return err
+	}
+
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "connect to service manager error")
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err == nil {
+		s.Close()
+		return fmt.Errorf("service %s already exists", serviceName)
+	}
+
+	s, err = m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: serviceDisplayName,
+		Description: serviceDescription,
+		StartType:   mgr.StartAutomatic,
+	}, serviceArgs...)
+	if err != nil {
+		return errors.Wrap(err, "create service error")
+	}
+	defer s.Close()
+
+	log.WithFields(log.Fields{
+		"service": serviceName,
+		"exe":     exe,
+		"args":    serviceArgs,
+	}).Info("windows service installed")
+
+	return nil
+}
+
+func serviceUninstall(cmd *cobra.Command, args []string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return errors.Wrap(err, "connect to service manager error")
+	}
+	defer m.Disconnect()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return errors.Wrapf(err, "open service %s error", serviceName)
+	}
+	defer s.Close()
+
+	if err := s.Delete(); err != nil {
+		return errors.Wrap(err, "delete service error")
+	}
+
+	log.WithField("service", serviceName).Info("windows service uninstalled")
+
+	return nil
+}
+
+func serviceRun(cmd *cobra.Command, args []string) error {
+	interactive, err := svc.IsAnInteractiveSession()
+	if err != nil {
+		return errors.Wrap(err, "determine session type error")
+	}
+
+	// not started by the service manager
+	if interactive {
+		return run(cmd, args)
+	}
+
+	if err := setServiceLogFile(); err != nil {
+		return err
+	}
+
+	if err := svc.Run(serviceName, &windowsService{start: start, stop: stop}); err != nil {
+		return errors.Wrap(err, "run service error")
+	}
+
+	return nil
+}
+
+// windowsService implements the svc.Handler interface.
+type windowsService struct {
+	start func() error
+	stop  func()
+}
+
+// Execute starts the LoRa Gateway Bridge and blocks until the service
+// manager requests the service to stop.
+func (s *windowsService) Execute(args []string, r <-chan svc.ChangeRequest, changes chan<- svc.Status) (bool, uint32) {
+	defer errorreporting.Recover()
+
+	changes <- svc.Status{State: svc.StartPending}
+
+	if err := s.start(); err != nil {
+		log.WithError(err).Error("start error")
+		return false, 1
+	}
+
+	changes <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for c := range r {
+		switch c.Cmd {
+		case svc.Interrogate:
+			changes <- c.CurrentStatus
+		case svc.Stop, svc.Shutdown:
+			log.WithField("command", c.Cmd).Info("service stop requested")
+			changes <- svc.Status{State: svc.StopPending}
+			s.stop()
+			return false, 0
+		}
+	}
+
+	return false, 0
+}
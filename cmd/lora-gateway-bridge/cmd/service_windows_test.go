@@ -0,0 +1,79 @@
+// +build windows
+
+package cmd
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows/svc"
+)
+
+func TestWindowsServiceExecute(t *testing.T) {
+	running := svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	tests := []struct {
+		Name             string
+		StartError       error
+		Requests         []svc.ChangeRequest
+		ExpectedStatus   []svc.Status
+		ExpectedStopped  bool
+		ExpectedExitCode uint32
+	}{
+		{
+			Name:             "start error",
+			StartError:       errors.New("setup backend error"),
+			ExpectedStatus:   []svc.Status{{State: svc.StartPending}},
+			ExpectedExitCode: 1,
+		},
+		{
+			Name:            "stop",
+			Requests:        []svc.ChangeRequest{{Cmd: svc.Stop}},
+			ExpectedStatus:  []svc.Status{{State: svc.StartPending}, running, {State: svc.StopPending}},
+			ExpectedStopped: true,
+		},
+		{
+			Name:            "shutdown",
+			Requests:        []svc.ChangeRequest{{Cmd: svc.Shutdown}},
+			ExpectedStatus:  []svc.Status{{State: svc.StartPending}, running, {State: svc.StopPending}},
+			ExpectedStopped: true,
+		},
+		{
+			Name:            "interrogate and stop",
+			Requests:        []svc.ChangeRequest{{Cmd: svc.Interrogate, CurrentStatus: running}, {Cmd: svc.Stop}},
+			ExpectedStatus:  []svc.Status{{State: svc.StartPending}, running, running, {State: svc.StopPending}},
+			ExpectedStopped: true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var stopped bool
+			s := windowsService{
+				start: func() error { return tst.StartError },
+				stop:  func() { stopped = true },
+			}
+
+			r := make(chan svc.ChangeRequest, len(tst.Requests))
+			for _, req := range tst.Requests {
+				r <- req
+			}
+			changes := make(chan svc.Status, len(tst.ExpectedStatus))
+
+			_, exitCode := s.Execute(nil, r, changes)
+			close(changes)
+
+			var status []svc.Status
+			for st := range changes {
+				status = append(status, st)
+			}
+
+			assert.Equal(tst.ExpectedExitCode, exitCode)
+			assert.Equal(tst.ExpectedStopped, stopped)
+			assert.Equal(tst.ExpectedStatus, status)
+		})
+	}
+}
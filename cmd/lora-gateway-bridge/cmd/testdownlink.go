@@ -0,0 +1,149 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/testdownlink"
+)
+
+var testDownlinkGatewayID string
+var testDownlinkFrequency uint32
+var testDownlinkDataRate int
+var testDownlinkPower int
+var testDownlinkPayload string
+var testDownlinkURL string
+var testDownlinkToken string
+
+var testDownlinkCmd = &cobra.Command{
+	Use:   "test-downlink",
+	Short: "Send a test downlink to a gateway using the admin API of the running LoRa Gateway Bridge and print the ack",
+	RunE:  testDownlink,
+}
+
+func init() {
+	testDownlinkCmd.Flags().StringVar(&testDownlinkGatewayID, "gateway", "", "gateway ID (HEX encoded)")
+	testDownlinkCmd.Flags().Uint32Var(&testDownlinkFrequency, "frequency", 0, "frequency (Hz)")
+	testDownlinkCmd.Flags().IntVar(&testDownlinkDataRate, "dr", 0, "data-rate of the configured region")
+	testDownlinkCmd.Flags().IntVar(&testDownlinkPower, "power", 0, "TX power (dBm) (default: configured power)")
+	testDownlinkCmd.Flags().StringVar(&testDownlinkPayload, "payload", "", "payload following the proprietary MHDR (HEX encoded)")
+	testDownlinkCmd.Flags().StringVar(&testDownlinkURL, "url", "", "admin API URL (default: derived from the admin bind)")
+	testDownlinkCmd.Flags().StringVar(&testDownlinkToken, "token", "", "admin API bearer token (default: configured bearer_token)")
+}
+
+func testDownlink(cmd *cobra.Command, args []string) error {
+	if err := setupSecrets(); err != nil {
+		return err
+	}
+
+	req := testdownlink.Request{
+		Frequency: testDownlinkFrequency,
+		DataRate:  testDownlinkDataRate,
+		Payload:   testDownlinkPayload,
+	}
+
+	if err := req.GatewayID.UnmarshalText([]byte(testDownlinkGatewayID)); err != nil {
+		return errors.Wrap(err, "parse gateway id error")
+	}
+
+	if cmd.Flags().Changed("power") {
+		req.Power = &testDownlinkPower
+	}
+
+	url := testDownlinkURL
+	if url == "" {
+		var err error
+		url, err = adminURL(config.C)
+		if err != nil {
+			return err
+		}
+	}
+
+	token := testDownlinkToken
+	if token == "" {
+		token = config.C.Admin.BearerToken
+	}
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return errors.Wrap(err, "marshal request error")
+	}
+
+	httpReq, err := http.NewRequest("POST", strings.TrimRight(url, "/")+"/api/test-downlink", bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "new request error")
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	client := http.Client{
+		Timeout: config.C.TestDownlink.AckTimeout + 10*time.Second,
+	}
+
+	resp, err := client.Do(httpReq)
+	if err != nil {
+		return errors.Wrap(err, "admin api request error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		json.NewDecoder(resp.Body).Decode(&apiErr)
+		return fmt.Errorf("admin api error: %s: %s", resp.Status, apiErr.Error)
+	}
+
+	var res testdownlink.Result
+	if err := json.NewDecoder(resp.Body).Decode(&res); err != nil {
+		return errors.Wrap(err, "decode response error")
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(res); err != nil {
+		return errors.Wrap(err, "encode result error")
+	}
+
+	if res.Error != "" {
+		return fmt.Errorf("test downlink was not transmitted: %s", res.Error)
+	}
+
+	return nil
+}
+
+// adminURL returns the URL of the admin API, using the configured bind. An
+// unspecified bind address is replaced by localhost.
+func adminURL(conf config.Config) (string, error) {
+	if conf.Admin.Bind == "" {
+		return "", errors.New("admin api is not configured")
+	}
+
+	host, port, err := net.SplitHostPort(conf.Admin.Bind)
+	if err != nil {
+		return "", errors.Wrap(err, "parse admin bind error")
+	}
+
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "localhost"
+	}
+
+	scheme := "http"
+	if conf.Admin.TLSCert != "" {
+		scheme = "https"
+	}
+
+	return scheme + "://" + net.JoinHostPort(host, port), nil
+}
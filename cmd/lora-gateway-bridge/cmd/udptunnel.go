@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/udptunnel"
+)
+
+var udpTunnelBind string
+var udpTunnelServer string
+var udpTunnelCACert string
+var udpTunnelReconnectInterval time.Duration
+
+var udpTunnelCmd = &cobra.Command{
+	Use:   "udp-tunnel",
+	Short: "Tunnel the UDP datagrams of a local packet-forwarder over a websocket connection to the LoRa Gateway Bridge",
+	RunE:  udpTunnel,
+}
+
+func init() {
+	udpTunnelCmd.Flags().StringVar(&udpTunnelBind, "bind", "127.0.0.1:1700", "ip:port of the udp listener (serv_port_up and serv_port_down of the packet-forwarder)")
+	udpTunnelCmd.Flags().StringVar(&udpTunnelServer, "server", "", "websocket url of the LoRa Gateway Bridge (e.g. wss://example.com:1701)")
+	udpTunnelCmd.Flags().StringVar(&udpTunnelCACert, "ca-cert", "", "ca certificate used to validate the server certificate (optional)")
+	udpTunnelCmd.Flags().DurationVar(&udpTunnelReconnectInterval, "reconnect-interval", 5*time.Second, "interval between the connection attempts")
+}
+
+func udpTunnel(cmd *cobra.Command, args []string) error {
+	if err := setLogLevel(); err != nil {
+		return err
+	}
+
+	if udpTunnelServer == "" {
+		return errors.New("server must be set")
+	}
+
+	agent, err := udptunnel.NewAgent(udptunnel.Config{
+		Bind:              udpTunnelBind,
+		Server:            udpTunnelServer,
+		CACert:            udpTunnelCACert,
+		ReconnectInterval: udpTunnelReconnectInterval,
+	})
+	if err != nil {
+		return errors.Wrap(err, "new udp-tunnel agent error")
+	}
+
+	go func() {
+		if err := agent.Run(); err != nil {
+			log.WithError(err).Fatal("udp-tunnel agent error")
+		}
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+	log.WithField("signal", <-sigChan).Info("signal received")
+
+	return agent.Close()
+}
@@ -8,6 +8,7 @@ Usage:
   lora-gateway-bridge [command]
 
 Available Commands:
+  archive     Inspect the LoRa Gateway Bridge event archive
   configfile  Print the LoRa Gateway Bridge configuration file
   help        Help about any command
   version     Print the LoRa Gateway Bridge version
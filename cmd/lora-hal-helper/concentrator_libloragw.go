@@ -0,0 +1,363 @@
+// +build libloragw,cgo
+
+package main
+
+// #cgo LDFLAGS: -lloragw -lrt -lm
+// #include <stdlib.h>
+// #include <string.h>
+// #include <loragw_hal.h>
+import "C"
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+)
+
+// maxRXPackets defines the maximum number of packets fetched per receive.
+const maxRXPackets = 8
+
+// preamble sizes used when the downlink does not set the preamble size
+const (
+	loRaPreamble = 8
+	fskPreamble  = 5
+)
+
+var radioTypes = map[string]C.enum_lgw_radio_type_e{
+	"SX1255": C.LGW_RADIO_TYPE_SX1255,
+	"SX1257": C.LGW_RADIO_TYPE_SX1257,
+	"SX1272": C.LGW_RADIO_TYPE_SX1272,
+	"SX1276": C.LGW_RADIO_TYPE_SX1276,
+}
+
+var loRaBandwidths = map[uint32]C.uint8_t{
+	125: C.BW_125KHZ,
+	250: C.BW_250KHZ,
+	500: C.BW_500KHZ,
+}
+
+var loRaDataRates = map[uint32]C.uint32_t{
+	7:  C.DR_LORA_SF7,
+	8:  C.DR_LORA_SF8,
+	9:  C.DR_LORA_SF9,
+	10: C.DR_LORA_SF10,
+	11: C.DR_LORA_SF11,
+	12: C.DR_LORA_SF12,
+}
+
+var loRaCodeRates = map[string]C.uint8_t{
+	"4/5": C.CR_LORA_4_5,
+	"4/6": C.CR_LORA_4_6,
+	"4/7": C.CR_LORA_4_7,
+	"4/8": C.CR_LORA_4_8,
+}
+
+// libloragw implements the concentrator interface using the Semtech
+// libloragw (SX1301 HAL).
+type libloragw struct{}
+
+func newConcentrator(conf sx1301Conf) (concentrator, error) {
+	boardConf := C.struct_lgw_conf_board_s{
+		lorawan_public: C.bool(conf.LoRaWANPublic),
+		clksrc:         C.uint8_t(conf.ClkSrc),
+	}
+	if C.lgw_board_setconf(boardConf) != C.LGW_HAL_SUCCESS {
+		return nil, errors.New("configure board error")
+	}
+
+	for i, r := range conf.Radios {
+		radioConf := C.struct_lgw_conf_rxrf_s{
+			enable: C.bool(r.Enable),
+		}
+
+		if r.Enable {
+			radioType, ok := radioTypes[strings.ToUpper(r.Type)]
+			if !ok {
+				return nil, fmt.Errorf("radio_%d: invalid radio type: %s", i, r.Type)
+			}
+
+			radioConf.freq_hz = C.uint32_t(r.Freq)
+			radioConf.rssi_offset = C.float(r.RSSIOffset)
+			radioConf._type = radioType
+			radioConf.tx_enable = C.bool(r.TXEnable)
+			radioConf.tx_notch_freq = C.uint32_t(r.TXNotchFreq)
+		}
+
+		if C.lgw_rxrf_setconf(C.uint8_t(i), radioConf) != C.LGW_HAL_SUCCESS {
+			return nil, fmt.Errorf("configure radio_%d error", i)
+		}
+	}
+
+	for i, c := range conf.MultiSFChannels {
+		ifConf := C.struct_lgw_conf_rxif_s{
+			enable:   C.bool(c.Enable),
+			rf_chain: C.uint8_t(c.Radio),
+			freq_hz:  C.int32_t(c.IF),
+		}
+		if C.lgw_rxif_setconf(C.uint8_t(i), ifConf) != C.LGW_HAL_SUCCESS {
+			return nil, fmt.Errorf("configure chan_multiSF_%d error", i)
+		}
+	}
+
+	if c := conf.LoRaSTDChannel; c.Enable {
+		bw, ok := loRaBandwidths[c.Bandwidth/1000]
+		if !ok {
+			return nil, fmt.Errorf("chan_Lora_std: invalid bandwidth: %d", c.Bandwidth)
+		}
+		dr, ok := loRaDataRates[c.SpreadFactor]
+		if !ok {
+			return nil, fmt.Errorf("chan_Lora_std: invalid spread_factor: %d", c.SpreadFactor)
+		}
+
+		ifConf := C.struct_lgw_conf_rxif_s{
+			enable:    true,
+			rf_chain:  C.uint8_t(c.Radio),
+			freq_hz:   C.int32_t(c.IF),
+			bandwidth: bw,
+			datarate:  dr,
+		}
+		if C.lgw_rxif_setconf(C.LGW_MULTI_NB, ifConf) != C.LGW_HAL_SUCCESS {
+			return nil, errors.New("configure chan_Lora_std error")
+		}
+	}
+
+	if c := conf.FSKChannel; c.Enable {
+		var bw C.uint8_t
+		switch {
+		case c.Bandwidth <= 7800:
+			bw = C.BW_7K8HZ
+		case c.Bandwidth <= 15600:
+			bw = C.BW_15K6HZ
+		case c.Bandwidth <= 31200:
+			bw = C.BW_31K2HZ
+		case c.Bandwidth <= 62500:
+			bw = C.BW_62K5HZ
+		case c.Bandwidth <= 125000:
+			bw = C.BW_125KHZ
+		case c.Bandwidth <= 250000:
+			bw = C.BW_250KHZ
+		case c.Bandwidth <= 500000:
+			bw = C.BW_500KHZ
+		default:
+			return nil, fmt.Errorf("chan_FSK: invalid bandwidth: %d", c.Bandwidth)
+		}
+
+		ifConf := C.struct_lgw_conf_rxif_s{
+			enable:    true,
+			rf_chain:  C.uint8_t(c.Radio),
+			freq_hz:   C.int32_t(c.IF),
+			bandwidth: bw,
+			datarate:  C.uint32_t(c.DataRate),
+		}
+		if C.lgw_rxif_setconf(C.LGW_MULTI_NB+1, ifConf) != C.LGW_HAL_SUCCESS {
+			return nil, errors.New("configure chan_FSK error")
+		}
+	}
+
+	if len(conf.TXLUT) != 0 {
+		var lut C.struct_lgw_tx_gain_lut_s
+		for i, l := range conf.TXLUT {
+			lut.lut[i] = C.struct_lgw_tx_gain_s{
+				pa_gain:  C.uint8_t(l.PAGain),
+				mix_gain: C.uint8_t(l.MixGain),
+				rf_power: C.int8_t(l.RFPower),
+				dig_gain: C.uint8_t(l.DigGain),
+				dac_gain: C.uint8_t(l.DACGain),
+			}
+		}
+		lut.size = C.uint8_t(len(conf.TXLUT))
+
+		if C.lgw_txgain_setconf(&lut) != C.LGW_HAL_SUCCESS {
+			return nil, errors.New("configure tx gain lut error")
+		}
+	}
+
+	if C.lgw_start() != C.LGW_HAL_SUCCESS {
+		return nil, errors.New("start concentrator error")
+	}
+
+	return &libloragw{}, nil
+}
+
+func (l *libloragw) receive() ([]packets.RXPK, error) {
+	var pkts [maxRXPackets]C.struct_lgw_pkt_rx_s
+
+	n := C.lgw_receive(maxRXPackets, &pkts[0])
+	if n == C.LGW_HAL_ERROR {
+		return nil, errors.New("lgw_receive error")
+	}
+
+	now := packets.CompactTime(time.Now().UTC())
+
+	var out []packets.RXPK
+	for _, p := range pkts[:int(n)] {
+		rxpk := packets.RXPK{
+			Time: &now,
+			Tmst: uint32(p.count_us),
+			Chan: uint8(p.if_chain),
+			RFCh: uint8(p.rf_chain),
+			Freq: float64(p.freq_hz) / 1000000,
+			RSSI: int16(math.Round(float64(p.rssi))),
+			Size: uint16(p.size),
+			Data: C.GoBytes(unsafe.Pointer(&p.payload[0]), C.int(p.size)),
+		}
+
+		switch p.status {
+		case C.STAT_CRC_OK:
+			rxpk.Stat = 1
+		case C.STAT_CRC_BAD:
+			rxpk.Stat = -1
+		case C.STAT_NO_CRC:
+			rxpk.Stat = 0
+		default:
+			continue
+		}
+
+		switch p.modulation {
+		case C.MOD_LORA:
+			sf, err := loRaSpreadingFactor(p.datarate)
+			if err != nil {
+				return nil, err
+			}
+			bw, err := loRaBandwidth(p.bandwidth)
+			if err != nil {
+				return nil, err
+			}
+
+			rxpk.Modu = "LORA"
+			rxpk.DatR.LoRa = fmt.Sprintf("SF%dBW%d", sf, bw)
+			rxpk.CodR = loRaCodeRate(p.coderate)
+			rxpk.LSNR = float64(p.snr)
+		case C.MOD_FSK:
+			rxpk.Modu = "FSK"
+			rxpk.DatR.FSK = uint32(p.datarate)
+		default:
+			continue
+		}
+
+		out = append(out, rxpk)
+	}
+
+	return out, nil
+}
+
+func (l *libloragw) counter() (uint32, error) {
+	var cnt C.uint32_t
+	if C.lgw_get_trigcnt(&cnt) != C.LGW_HAL_SUCCESS {
+		return 0, errors.New("lgw_get_trigcnt error")
+	}
+	return uint32(cnt), nil
+}
+
+func (l *libloragw) txBusy() (bool, error) {
+	var status C.uint8_t
+	if C.lgw_status(C.TX_STATUS, &status) != C.LGW_HAL_SUCCESS {
+		return false, errors.New("lgw_status error")
+	}
+	return status == C.TX_SCHEDULED || status == C.TX_EMITTING, nil
+}
+
+func (l *libloragw) send(txpk packets.TXPK) error {
+	if int(txpk.Size) != len(txpk.Data) || len(txpk.Data) > 256 {
+		return fmt.Errorf("invalid payload size: %d", len(txpk.Data))
+	}
+
+	pkt := C.struct_lgw_pkt_tx_s{
+		freq_hz:    C.uint32_t(math.Round(txpk.Freq * 1000000)),
+		rf_chain:   C.uint8_t(txpk.RFCh),
+		rf_power:   C.int8_t(txpk.Powe),
+		invert_pol: C.bool(txpk.IPol),
+		no_crc:     C.bool(txpk.NCRC),
+		preamble:   C.uint16_t(txpk.Prea),
+		size:       C.uint16_t(len(txpk.Data)),
+	}
+
+	if txpk.Imme {
+		pkt.tx_mode = C.IMMEDIATE
+	} else {
+		pkt.tx_mode = C.TIMESTAMPED
+		pkt.count_us = C.uint32_t(*txpk.Tmst)
+	}
+
+	switch txpk.Modu {
+	case "LORA":
+		sf, bw, err := parseLoRaDataRate(txpk.DatR.LoRa)
+		if err != nil {
+			return err
+		}
+
+		var ok bool
+		pkt.modulation = C.MOD_LORA
+		if pkt.datarate, ok = loRaDataRates[sf]; !ok {
+			return fmt.Errorf("invalid spreading-factor: %d", sf)
+		}
+		if pkt.bandwidth, ok = loRaBandwidths[bw]; !ok {
+			return fmt.Errorf("invalid bandwidth: %d", bw)
+		}
+		if pkt.coderate, ok = loRaCodeRates[txpk.CodR]; !ok {
+			return fmt.Errorf("invalid code-rate: %s", txpk.CodR)
+		}
+		if pkt.preamble == 0 {
+			pkt.preamble = loRaPreamble
+		}
+	case "FSK":
+		pkt.modulation = C.MOD_FSK
+		pkt.datarate = C.uint32_t(txpk.DatR.FSK)
+		pkt.f_dev = C.uint8_t(txpk.FDev / 1000)
+		if pkt.preamble == 0 {
+			pkt.preamble = fskPreamble
+		}
+	default:
+		return fmt.Errorf("invalid modulation: %s", txpk.Modu)
+	}
+
+	if len(txpk.Data) != 0 {
+		C.memcpy(unsafe.Pointer(&pkt.payload[0]), unsafe.Pointer(&txpk.Data[0]), C.size_t(len(txpk.Data)))
+	}
+
+	if C.lgw_send(pkt) != C.LGW_HAL_SUCCESS {
+		return errors.New("lgw_send error")
+	}
+
+	return nil
+}
+
+func (l *libloragw) stop() error {
+	if C.lgw_stop() != C.LGW_HAL_SUCCESS {
+		return errors.New("lgw_stop error")
+	}
+	return nil
+}
+
+func loRaSpreadingFactor(dr C.uint32_t) (uint32, error) {
+	for sf, v := range loRaDataRates {
+		if v == dr {
+			return sf, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid lora datarate: %d", dr)
+}
+
+func loRaBandwidth(bw C.uint8_t) (uint32, error) {
+	for k, v := range loRaBandwidths {
+		if v == bw {
+			return k, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid lora bandwidth: %d", bw)
+}
+
+func loRaCodeRate(cr C.uint8_t) string {
+	for k, v := range loRaCodeRates {
+		if v == cr {
+			return k
+		}
+	}
+	return "OFF"
+}
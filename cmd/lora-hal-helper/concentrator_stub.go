@@ -0,0 +1,9 @@
+// +build !libloragw !cgo
+
+package main
+
+import "github.com/pkg/errors"
+
+func newConcentrator(conf sx1301Conf) (concentrator, error) {
+	return nil, errors.New("built without libloragw support, build with cgo enabled and the libloragw tag")
+}
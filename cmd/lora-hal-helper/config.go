@@ -0,0 +1,174 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+
+	"github.com/pkg/errors"
+)
+
+// radioCount defines the number of radios available
+const radioCount = 2
+
+// channelCount defines the number of available multi-SF channels
+const channelCount = 8
+
+// txLUTCount defines the maximum number of TX gain LUT entries
+const txLUTCount = 16
+
+// jsonCommentRegexp matches json comments
+var jsonCommentRegexp = regexp.MustCompile(`/\*.*\*/`)
+
+// configFile represents the packet-forwarder JSON config file (e.g. as
+// generated by the genconfig command of the LoRa Gateway Bridge). Only the
+// keys used by the helper are read, the others are ignored.
+type configFile struct {
+	SX1301Conf  sx1301Conf  `json:"SX1301_conf"`
+	GatewayConf gatewayConf `json:"gateway_conf"`
+}
+
+// sx1301Conf contains the concentrator configuration.
+type sx1301Conf struct {
+	LoRaWANPublic   bool
+	ClkSrc          uint8
+	Radios          [radioCount]radioConf
+	MultiSFChannels [channelCount]channelConf
+	LoRaSTDChannel  channelConf
+	FSKChannel      channelConf
+	TXLUT           []txGainConf
+}
+
+// radioConf contains the configuration of a radio.
+type radioConf struct {
+	Enable      bool    `json:"enable"`
+	Type        string  `json:"type"`
+	Freq        uint32  `json:"freq"`
+	RSSIOffset  float32 `json:"rssi_offset"`
+	TXEnable    bool    `json:"tx_enable"`
+	TXNotchFreq uint32  `json:"tx_notch_freq"`
+	TXFreqMin   uint32  `json:"tx_freq_min"`
+	TXFreqMax   uint32  `json:"tx_freq_max"`
+}
+
+// channelConf contains the configuration of an IF channel. The bandwidth,
+// spread_factor and datarate keys are only used by the LoRa STD and FSK
+// channels.
+type channelConf struct {
+	Enable       bool   `json:"enable"`
+	Radio        uint8  `json:"radio"`
+	IF           int32  `json:"if"`
+	Bandwidth    uint32 `json:"bandwidth"`
+	SpreadFactor uint32 `json:"spread_factor"`
+	DataRate     uint32 `json:"datarate"`
+}
+
+// txGainConf contains a TX gain LUT entry.
+type txGainConf struct {
+	PAGain  uint8 `json:"pa_gain"`
+	MixGain uint8 `json:"mix_gain"`
+	RFPower int8  `json:"rf_power"`
+	DigGain uint8 `json:"dig_gain"`
+	DACGain uint8 `json:"dac_gain"`
+}
+
+// gatewayConf contains the gateway configuration.
+type gatewayConf struct {
+	StatInterval       int  `json:"stat_interval"`
+	ForwardCRCValid    bool `json:"forward_crc_valid"`
+	ForwardCRCError    bool `json:"forward_crc_error"`
+	ForwardCRCDisabled bool `json:"forward_crc_disabled"`
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface.
+func (c *sx1301Conf) UnmarshalJSON(data []byte) error {
+	var conf struct {
+		LoRaWANPublic bool  `json:"lorawan_public"`
+		ClkSrc        uint8 `json:"clksrc"`
+	}
+	if err := json.Unmarshal(data, &conf); err != nil {
+		return err
+	}
+	c.LoRaWANPublic = conf.LoRaWANPublic
+	c.ClkSrc = conf.ClkSrc
+
+	var keys map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keys); err != nil {
+		return err
+	}
+
+	for i := range c.Radios {
+		if err := unmarshalKey(keys, fmt.Sprintf("radio_%d", i), &c.Radios[i]); err != nil {
+			return err
+		}
+	}
+
+	for i := range c.MultiSFChannels {
+		if err := unmarshalKey(keys, fmt.Sprintf("chan_multiSF_%d", i), &c.MultiSFChannels[i]); err != nil {
+			return err
+		}
+	}
+
+	if err := unmarshalKey(keys, "chan_Lora_std", &c.LoRaSTDChannel); err != nil {
+		return err
+	}
+
+	if err := unmarshalKey(keys, "chan_FSK", &c.FSKChannel); err != nil {
+		return err
+	}
+
+	c.TXLUT = nil
+	for i := 0; i < txLUTCount; i++ {
+		key := fmt.Sprintf("tx_lut_%d", i)
+		if _, ok := keys[key]; !ok {
+			break
+		}
+
+		// the packet-forwarder uses a DAC gain of 3 when not set
+		lut := txGainConf{DACGain: 3}
+		if err := unmarshalKey(keys, key, &lut); err != nil {
+			return err
+		}
+		c.TXLUT = append(c.TXLUT, lut)
+	}
+
+	return nil
+}
+
+func unmarshalKey(keys map[string]json.RawMessage, key string, v interface{}) error {
+	data, ok := keys[key]
+	if !ok {
+		return nil
+	}
+
+	if err := json.Unmarshal(data, v); err != nil {
+		return errors.Wrapf(err, "unmarshal %s error", key)
+	}
+
+	return nil
+}
+
+func loadConfigFile(filePath string) (configFile, error) {
+	// the defaults of the packet-forwarder, used for the keys which are not set
+	out := configFile{
+		GatewayConf: gatewayConf{
+			StatInterval:    30,
+			ForwardCRCValid: true,
+		},
+	}
+
+	b, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return out, errors.Wrap(err, "read file error")
+	}
+
+	// remove comments from json
+	b = jsonCommentRegexp.ReplaceAll(b, []byte{})
+
+	if err = json.Unmarshal(b, &out); err != nil {
+		return out, errors.Wrap(err, "unmarshal config json error")
+	}
+
+	return out, nil
+}
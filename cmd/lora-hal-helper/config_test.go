@@ -0,0 +1,59 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfigFile(t *testing.T) {
+	assert := require.New(t)
+
+	tempDir, err := ioutil.TempDir("", "lora-hal-helper")
+	assert.NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	confFile := filepath.Join(tempDir, "global_conf.json")
+	assert.NoError(ioutil.WriteFile(confFile, []byte(`{
+	"SX1301_conf": {
+		"lorawan_public": true,
+		"clksrc": 1, /* radio_1 provides the clock */
+		"radio_0": {"enable": true, "type": "SX1257", "freq": 867500000, "rssi_offset": -166.0, "tx_enable": true, "tx_freq_min": 863000000, "tx_freq_max": 870000000},
+		"radio_1": {"enable": true, "type": "SX1257", "freq": 868500000, "rssi_offset": -166.0, "tx_enable": false},
+		"chan_multiSF_0": {"enable": true, "radio": 1, "if": -400000},
+		"chan_Lora_std": {"enable": true, "radio": 1, "if": -200000, "bandwidth": 250000, "spread_factor": 7},
+		"chan_FSK": {"enable": true, "radio": 1, "if": 300000, "bandwidth": 125000, "datarate": 50000},
+		"tx_lut_0": {"pa_gain": 0, "mix_gain": 8, "rf_power": -6, "dig_gain": 0},
+		"tx_lut_1": {"pa_gain": 3, "mix_gain": 14, "rf_power": 27, "dig_gain": 0, "dac_gain": 0}
+	},
+	"gateway_conf": {
+		"forward_crc_error": true
+	}
+}`), 0644))
+
+	conf, err := loadConfigFile(confFile)
+	assert.NoError(err)
+
+	assert.True(conf.SX1301Conf.LoRaWANPublic)
+	assert.EqualValues(1, conf.SX1301Conf.ClkSrc)
+	assert.Equal([radioCount]radioConf{
+		{Enable: true, Type: "SX1257", Freq: 867500000, RSSIOffset: -166.0, TXEnable: true, TXFreqMin: 863000000, TXFreqMax: 870000000},
+		{Enable: true, Type: "SX1257", Freq: 868500000, RSSIOffset: -166.0},
+	}, conf.SX1301Conf.Radios)
+	assert.Equal(channelConf{Enable: true, Radio: 1, IF: -400000}, conf.SX1301Conf.MultiSFChannels[0])
+	assert.Equal(channelConf{}, conf.SX1301Conf.MultiSFChannels[1])
+	assert.Equal(channelConf{Enable: true, Radio: 1, IF: -200000, Bandwidth: 250000, SpreadFactor: 7}, conf.SX1301Conf.LoRaSTDChannel)
+	assert.Equal(channelConf{Enable: true, Radio: 1, IF: 300000, Bandwidth: 125000, DataRate: 50000}, conf.SX1301Conf.FSKChannel)
+	assert.Equal([]txGainConf{
+		{PAGain: 0, MixGain: 8, RFPower: -6, DigGain: 0, DACGain: 3},
+		{PAGain: 3, MixGain: 14, RFPower: 27, DigGain: 0, DACGain: 0},
+	}, conf.SX1301Conf.TXLUT)
+	assert.Equal(gatewayConf{
+		StatInterval:    30,
+		ForwardCRCValid: true,
+		ForwardCRCError: true,
+	}, conf.GatewayConf)
+}
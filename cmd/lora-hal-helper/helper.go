@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+)
+
+// TX ack error codes (see the TX_ACK error values of the Semtech UDP
+// protocol).
+const (
+	txAckNone            = "NONE"
+	txAckTooLate         = "TOO_LATE"
+	txAckTooEarly        = "TOO_EARLY"
+	txAckCollisionPacket = "COLLISION_PACKET"
+	txAckTXFreq          = "TX_FREQ"
+	txAckGPSUnlocked     = "GPS_UNLOCKED"
+	txAckInternalError   = "INTERNAL_ERROR"
+)
+
+// txMinAdvance defines the minimum time between programming a timestamped
+// downlink and its transmission (the TX start delay of the concentrator plus
+// a margin).
+const txMinAdvance = 5 * time.Millisecond
+
+// txMaxAdvance defines the maximum time between programming a timestamped
+// downlink and its transmission. This must cover the join-accept delays.
+const txMaxAdvance = 10 * time.Second
+
+// concentrator defines the interface of the concentrator HAL.
+type concentrator interface {
+	// receive returns the received packets (if any).
+	receive() ([]packets.RXPK, error)
+
+	// counter returns the value of the internal concentrator counter (us).
+	counter() (uint32, error)
+
+	// txBusy returns true when a downlink is scheduled or being emitted.
+	txBusy() (bool, error)
+
+	// send programs the given downlink.
+	send(txpk packets.TXPK) error
+
+	// stop stops the concentrator.
+	stop() error
+}
+
+// upMessage contains a message sent to the LoRa Gateway Bridge.
+type upMessage struct {
+	RXPK []packets.RXPK `json:"rxpk,omitempty"`
+	Stat *packets.Stat  `json:"stat,omitempty"`
+
+	Token   *uint16          `json:"token,omitempty"`
+	TXPKACK *packets.TXPKACK `json:"txpk_ack,omitempty"`
+}
+
+// downMessage contains a downlink received from the LoRa Gateway Bridge.
+type downMessage struct {
+	Token uint16       `json:"token"`
+	TXPK  packets.TXPK `json:"txpk"`
+}
+
+// stats contains the counters for the stat message.
+type stats struct {
+	rxNb uint32
+	rxOK uint32
+	rxFW uint32
+	dwNb uint32
+	txNb uint32
+}
+
+// helper implements the protocol between the LoRa Gateway Bridge and the
+// concentrator.
+type helper struct {
+	// concentratorMux serializes the access to the concentrator as the HAL
+	// is not thread-safe.
+	concentratorMux sync.Mutex
+	concentrator    concentrator
+
+	radios [radioCount]radioConf
+	conf   gatewayConf
+
+	// writeMux serializes the writes to the output.
+	writeMux sync.Mutex
+	enc      *json.Encoder
+
+	statsMux sync.Mutex
+	stats    stats
+}
+
+func newHelper(c concentrator, conf configFile, w io.Writer) *helper {
+	return &helper{
+		concentrator: c,
+		radios:       conf.SX1301Conf.Radios,
+		conf:         conf.GatewayConf,
+		enc:          json.NewEncoder(w),
+	}
+}
+
+// handleDownlinks reads the downlinks from the given reader until it is
+// closed.
+func (h *helper) handleDownlinks(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := h.handleDownlink(scanner.Bytes()); err != nil {
+			log.WithError(err).Error("handle downlink error")
+		}
+	}
+
+	return scanner.Err()
+}
+
+func (h *helper) handleDownlink(b []byte) error {
+	var msg downMessage
+	if err := json.Unmarshal(b, &msg); err != nil {
+		return errors.Wrap(err, "unmarshal json error")
+	}
+
+	h.statsMux.Lock()
+	h.stats.dwNb++
+	h.statsMux.Unlock()
+
+	ackErr := h.send(msg.TXPK)
+	if ackErr == txAckNone {
+		h.statsMux.Lock()
+		h.stats.txNb++
+		h.statsMux.Unlock()
+	} else {
+		log.WithFields(log.Fields{
+			"token": msg.Token,
+			"error": ackErr,
+		}).Warning("downlink rejected")
+	}
+
+	return h.write(upMessage{
+		Token:   &msg.Token,
+		TXPKACK: &packets.TXPKACK{Error: ackErr},
+	})
+}
+
+// send programs the given downlink and returns the TX ack error code.
+func (h *helper) send(txpk packets.TXPK) string {
+	if txpk.Tmms != nil {
+		// GPS synchronization is not implemented
+		return txAckGPSUnlocked
+	}
+
+	if !txpk.Imme && txpk.Tmst == nil {
+		log.Error("downlink without imme or tmst")
+		return txAckInternalError
+	}
+
+	if int(txpk.RFCh) >= len(h.radios) || !h.radios[txpk.RFCh].TXEnable {
+		return txAckTXFreq
+	}
+
+	radio := h.radios[txpk.RFCh]
+	freq := uint32(txpk.Freq*1000000 + 0.5)
+	if (radio.TXFreqMin != 0 && freq < radio.TXFreqMin) || (radio.TXFreqMax != 0 && freq > radio.TXFreqMax) {
+		return txAckTXFreq
+	}
+
+	h.concentratorMux.Lock()
+	defer h.concentratorMux.Unlock()
+
+	// the concentrator holds a single downlink
+	busy, err := h.concentrator.txBusy()
+	if err != nil {
+		log.WithError(err).Error("get tx status error")
+		return txAckInternalError
+	}
+	if busy {
+		return txAckCollisionPacket
+	}
+
+	if !txpk.Imme {
+		now, err := h.concentrator.counter()
+		if err != nil {
+			log.WithError(err).Error("get concentrator counter error")
+			return txAckInternalError
+		}
+
+		if ackErr := checkTiming(*txpk.Tmst, now); ackErr != txAckNone {
+			return ackErr
+		}
+	}
+
+	if err := h.concentrator.send(txpk); err != nil {
+		log.WithError(err).Error("send downlink error")
+		return txAckInternalError
+	}
+
+	return txAckNone
+}
+
+// receive fetches the received packets from the concentrator and writes
+// the packets which must be forwarded.
+func (h *helper) receive() error {
+	h.concentratorMux.Lock()
+	rxpks, err := h.concentrator.receive()
+	h.concentratorMux.Unlock()
+	if err != nil {
+		return errors.Wrap(err, "receive error")
+	}
+
+	var forward []packets.RXPK
+	h.statsMux.Lock()
+	for _, rxpk := range rxpks {
+		h.stats.rxNb++
+
+		switch rxpk.Stat {
+		case 1:
+			h.stats.rxOK++
+			if !h.conf.ForwardCRCValid {
+				continue
+			}
+		case -1:
+			if !h.conf.ForwardCRCError {
+				continue
+			}
+		default:
+			if !h.conf.ForwardCRCDisabled {
+				continue
+			}
+		}
+
+		h.stats.rxFW++
+		forward = append(forward, rxpk)
+	}
+	h.statsMux.Unlock()
+
+	if len(forward) == 0 {
+		return nil
+	}
+
+	return h.write(upMessage{RXPK: forward})
+}
+
+// writeStats writes the stat message and resets the counters.
+func (h *helper) writeStats(now time.Time) error {
+	h.statsMux.Lock()
+	s := h.stats
+	h.stats = stats{}
+	h.statsMux.Unlock()
+
+	stat := packets.Stat{
+		Time: packets.ExpandedTime(now),
+		RXNb: s.rxNb,
+		RXOK: s.rxOK,
+		RXFW: s.rxFW,
+		DWNb: s.dwNb,
+		TXNb: s.txNb,
+	}
+
+	return h.write(upMessage{Stat: &stat})
+}
+
+func (h *helper) write(msg upMessage) error {
+	h.writeMux.Lock()
+	defer h.writeMux.Unlock()
+
+	if err := h.enc.Encode(msg); err != nil {
+		return errors.Wrap(err, "write message error")
+	}
+	return nil
+}
+
+// checkTiming returns the TX ack error code for a downlink which must be
+// emitted at the given concentrator counter value.
+func checkTiming(tmst, now uint32) string {
+	// the counter wraps around every 2^32 us
+	delay := time.Duration(int32(tmst-now)) * time.Microsecond
+
+	if delay < txMinAdvance {
+		return txAckTooLate
+	}
+	if delay > txMaxAdvance {
+		return txAckTooEarly
+	}
+	return txAckNone
+}
+
+// parseLoRaDataRate parses the given LoRa data-rate identifier (e.g.
+// SF7BW125) into the spreading-factor and the bandwidth (kHz).
+func parseLoRaDataRate(datr string) (uint32, uint32, error) {
+	var sf, bw uint32
+	if _, err := fmt.Sscanf(datr, "SF%dBW%d", &sf, &bw); err != nil {
+		return 0, 0, errors.Wrapf(err, "parse datr %s error", datr)
+	}
+	return sf, bw, nil
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+)
+
+type testConcentrator struct {
+	rxpks   []packets.RXPK
+	count   uint32
+	busy    bool
+	sendErr error
+	sent    []packets.TXPK
+}
+
+func (c *testConcentrator) receive() ([]packets.RXPK, error) {
+	out := c.rxpks
+	c.rxpks = nil
+	return out, nil
+}
+
+func (c *testConcentrator) counter() (uint32, error) {
+	return c.count, nil
+}
+
+func (c *testConcentrator) txBusy() (bool, error) {
+	return c.busy, nil
+}
+
+func (c *testConcentrator) send(txpk packets.TXPK) error {
+	if c.sendErr != nil {
+		return c.sendErr
+	}
+	c.sent = append(c.sent, txpk)
+	return nil
+}
+
+func (c *testConcentrator) stop() error {
+	return nil
+}
+
+func testConfig() configFile {
+	var conf configFile
+	conf.SX1301Conf.Radios[0] = radioConf{
+		Enable:    true,
+		TXEnable:  true,
+		TXFreqMin: 863000000,
+		TXFreqMax: 870000000,
+	}
+	conf.GatewayConf = gatewayConf{
+		StatInterval:    30,
+		ForwardCRCValid: true,
+	}
+	return conf
+}
+
+func TestHandleDownlinks(t *testing.T) {
+	tmst := uint32(1000000)
+
+	tests := []struct {
+		Name         string
+		Concentrator testConcentrator
+		Down         string
+		ExpectedAck  string
+		ExpectedSent int
+	}{
+		{
+			Name:         "immediately",
+			Down:         `{"token":1234,"txpk":{"imme":true,"rfch":0,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck:  `{"token":1234,"txpk_ack":{"error":"NONE"}}`,
+			ExpectedSent: 1,
+		},
+		{
+			Name:         "timestamped",
+			Concentrator: testConcentrator{count: tmst - 1000000},
+			Down:         `{"token":1234,"txpk":{"tmst":1000000,"rfch":0,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck:  `{"token":1234,"txpk_ack":{"error":"NONE"}}`,
+			ExpectedSent: 1,
+		},
+		{
+			Name:         "timestamped counter wrap",
+			Concentrator: testConcentrator{count: 4294967295 - 999999},
+			Down:         `{"token":1234,"txpk":{"tmst":1000000,"rfch":0,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck:  `{"token":1234,"txpk_ack":{"error":"NONE"}}`,
+			ExpectedSent: 1,
+		},
+		{
+			Name:         "too late",
+			Concentrator: testConcentrator{count: tmst - 1000},
+			Down:         `{"token":1234,"txpk":{"tmst":1000000,"rfch":0,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck:  `{"token":1234,"txpk_ack":{"error":"TOO_LATE"}}`,
+		},
+		{
+			Name:         "too early",
+			Concentrator: testConcentrator{count: tmst - 20000000},
+			Down:         `{"token":1234,"txpk":{"tmst":1000000,"rfch":0,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck:  `{"token":1234,"txpk_ack":{"error":"TOO_EARLY"}}`,
+		},
+		{
+			Name:         "collision",
+			Concentrator: testConcentrator{busy: true},
+			Down:         `{"token":1234,"txpk":{"imme":true,"rfch":0,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck:  `{"token":1234,"txpk_ack":{"error":"COLLISION_PACKET"}}`,
+		},
+		{
+			Name:        "invalid frequency",
+			Down:        `{"token":1234,"txpk":{"imme":true,"rfch":0,"powe":14,"freq":902.3,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck: `{"token":1234,"txpk_ack":{"error":"TX_FREQ"}}`,
+		},
+		{
+			Name:        "tx disabled radio",
+			Down:        `{"token":1234,"txpk":{"imme":true,"rfch":1,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck: `{"token":1234,"txpk_ack":{"error":"TX_FREQ"}}`,
+		},
+		{
+			Name:        "gps time",
+			Down:        `{"token":1234,"txpk":{"tmms":1234,"rfch":0,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck: `{"token":1234,"txpk_ack":{"error":"GPS_UNLOCKED"}}`,
+		},
+		{
+			Name:         "send error",
+			Concentrator: testConcentrator{sendErr: errors.New("boom")},
+			Down:         `{"token":1234,"txpk":{"imme":true,"rfch":0,"powe":14,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`,
+			ExpectedAck:  `{"token":1234,"txpk_ack":{"error":"INTERNAL_ERROR"}}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var out bytes.Buffer
+			h := newHelper(&test.Concentrator, testConfig(), &out)
+
+			assert.NoError(h.handleDownlinks(strings.NewReader(test.Down + "\n")))
+			assert.Equal(test.ExpectedAck+"\n", out.String())
+			assert.Len(test.Concentrator.sent, test.ExpectedSent)
+
+			// the downlink is counted as emitted when it was programmed
+			out.Reset()
+			assert.NoError(h.writeStats(time.Now()))
+
+			var msg upMessage
+			assert.NoError(json.Unmarshal(out.Bytes(), &msg))
+			assert.EqualValues(1, msg.Stat.DWNb)
+			assert.EqualValues(test.ExpectedSent, msg.Stat.TXNb)
+		})
+	}
+}
+
+func TestReceive(t *testing.T) {
+	assert := require.New(t)
+
+	c := testConcentrator{
+		rxpks: []packets.RXPK{
+			{Tmst: 1, Stat: 1, Size: 1, Data: []byte{1}},
+			{Tmst: 2, Stat: -1, Size: 1, Data: []byte{2}},
+			{Tmst: 3, Stat: 0, Size: 1, Data: []byte{3}},
+		},
+	}
+
+	var out bytes.Buffer
+	h := newHelper(&c, testConfig(), &out)
+
+	assert.NoError(h.receive())
+	assert.Equal(`{"rxpk":[{"time":null,"tmms":null,"tmst":1,"aesk":0,"chan":0,"rfch":0,"stat":1,"freq":0,"brd":0,"rssi":0,"size":1,"datr":0,"modu":"","codr":"","lsnr":0,"data":"AQ==","rsig":null}]}`+"\n", out.String())
+
+	// nothing received, nothing written
+	out.Reset()
+	assert.NoError(h.receive())
+	assert.Equal("", out.String())
+
+	assert.NoError(h.writeStats(time.Date(2019, 1, 2, 3, 4, 5, 0, time.UTC)))
+	assert.Equal(`{"stat":{"time":"2019-01-02 03:04:05 UTC","lati":0,"long":0,"alti":0,"rxnb":3,"rxok":1,"rxfw":1,"ackr":0,"dwnb":0,"txnb":0}}`+"\n", out.String())
+}
+
+func TestParseLoRaDataRate(t *testing.T) {
+	tests := []struct {
+		DatR          string
+		SF            uint32
+		BW            uint32
+		ExpectedError bool
+	}{
+		{DatR: "SF7BW125", SF: 7, BW: 125},
+		{DatR: "SF12BW500", SF: 12, BW: 500},
+		{DatR: "50000", ExpectedError: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.DatR, func(t *testing.T) {
+			assert := require.New(t)
+
+			sf, bw, err := parseLoRaDataRate(test.DatR)
+			if test.ExpectedError {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(test.SF, sf)
+			assert.Equal(test.BW, bw)
+		})
+	}
+}
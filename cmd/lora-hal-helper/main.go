@@ -0,0 +1,113 @@
+// Command lora-hal-helper implements the HAL helper of the native backend of
+// the LoRa Gateway Bridge. It configures and starts the concentrator using
+// the Semtech libloragw (SX1301 HAL) and exchanges the Semtech UDP protocol
+// JSON objects (one per line) with the LoRa Gateway Bridge over its stdin
+// and stdout. See docs/content/backends/native.md for the protocol.
+//
+// The libloragw binding requires cgo and the libloragw build tag, e.g.:
+//
+//	CGO_CFLAGS="-I/opt/lora_gateway/libloragw/inc" CGO_LDFLAGS="-L/opt/lora_gateway/libloragw" \
+//	    go build -tags libloragw ./cmd/lora-hal-helper
+package main
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+// fetchInterval defines the interval in which received packets are fetched
+// from the concentrator.
+const fetchInterval = 10 * time.Millisecond
+
+var cfgFile string // packet-forwarder config file
+var logLevel int
+
+var rootCmd = &cobra.Command{
+	Use:   "lora-hal-helper",
+	Short: "HAL helper for the native backend of the LoRa Gateway Bridge",
+	RunE:  run,
+}
+
+func init() {
+	rootCmd.Flags().StringVarP(&cfgFile, "config", "c", "global_conf.json", "path to packet-forwarder configuration file (global_conf.json)")
+	rootCmd.Flags().IntVar(&logLevel, "log-level", 4, "debug=5, info=4, error=2, fatal=1, panic=0")
+}
+
+func main() {
+	if err := rootCmd.Execute(); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(cmd *cobra.Command, args []string) error {
+	// stdout is used by the protocol, log to stderr
+	log.SetOutput(os.Stderr)
+	log.SetLevel(log.Level(uint8(logLevel)))
+
+	conf, err := loadConfigFile(cfgFile)
+	if err != nil {
+		return errors.Wrap(err, "load config file error")
+	}
+
+	if conf.GatewayConf.StatInterval <= 0 {
+		return errors.New("stat_interval must be greater than 0")
+	}
+
+	c, err := newConcentrator(conf.SX1301Conf)
+	if err != nil {
+		return errors.Wrap(err, "start concentrator error")
+	}
+	defer func() {
+		if err := c.stop(); err != nil {
+			log.WithError(err).Error("stop concentrator error")
+		}
+	}()
+
+	log.Info("concentrator started")
+
+	h := newHelper(c, conf, os.Stdout)
+
+	// the helper exits when the LoRa Gateway Bridge closes stdin or on a
+	// signal
+	done := make(chan error, 1)
+	go func() {
+		done <- h.handleDownlinks(os.Stdin)
+	}()
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
+
+	fetchTicker := time.NewTicker(fetchInterval)
+	defer fetchTicker.Stop()
+
+	statTicker := time.NewTicker(time.Duration(conf.GatewayConf.StatInterval) * time.Second)
+	defer statTicker.Stop()
+
+	for {
+		select {
+		case <-fetchTicker.C:
+			if err := h.receive(); err != nil {
+				return errors.Wrap(err, "receive error")
+			}
+		case t := <-statTicker.C:
+			if err := h.writeStats(t); err != nil {
+				return errors.Wrap(err, "write stats error")
+			}
+		case err := <-done:
+			if err != nil {
+				return errors.Wrap(err, "read downlinks error")
+			}
+			log.Info("stdin closed, stopping")
+			return nil
+		case sig := <-sigChan:
+			log.WithField("signal", sig).Info("signal received, stopping")
+			return nil
+		}
+	}
+}
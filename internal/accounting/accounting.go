@@ -0,0 +1,244 @@
+// Package accounting keeps track of the uplink and downlink traffic (count
+// and bytes) per gateway over a rolling window. Optionally, a per-gateway
+// uplink quota is enforced to contain runaway or malicious gateways.
+package accounting
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+// Counters contains the traffic counters.
+type Counters struct {
+	UplinkCount        int `json:"uplinkCount"`
+	UplinkBytes        int `json:"uplinkBytes"`
+	DroppedUplinkCount int `json:"droppedUplinkCount"`
+	DownlinkCount      int `json:"downlinkCount"`
+	DownlinkBytes      int `json:"downlinkBytes"`
+}
+
+func (c *Counters) add(other Counters) {
+	c.UplinkCount += other.UplinkCount
+	c.UplinkBytes += other.UplinkBytes
+	c.DroppedUplinkCount += other.DroppedUplinkCount
+	c.DownlinkCount += other.DownlinkCount
+	c.DownlinkBytes += other.DownlinkBytes
+}
+
+// GatewayCounters contains the traffic counters of a single gateway.
+type GatewayCounters struct {
+	GatewayID lorawan.EUI64 `json:"gatewayID"`
+	Counters
+}
+
+// bucket contains the counters of a single minute.
+type bucket struct {
+	minute time.Time
+	Counters
+}
+
+// gateway contains the buckets of a gateway, used as a ring buffer.
+type gateway struct {
+	buckets []bucket
+}
+
+var (
+	mux sync.RWMutex
+
+	enabled             bool
+	window              time.Duration
+	maxUplinksPerMinute int
+
+	gateways = make(map[lorawan.EUI64]*gateway)
+)
+
+// Setup configures the traffic accounting.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !conf.Accounting.Enabled {
+		return nil
+	}
+
+	if conf.Accounting.Window < time.Minute {
+		return errors.New("accounting window must be at least 1 minute")
+	}
+
+	enabled = true
+	window = conf.Accounting.Window.Truncate(time.Minute)
+	maxUplinksPerMinute = conf.Accounting.MaxUplinksPerMinute
+
+	log.WithFields(log.Fields{
+		"window":                 window,
+		"max_uplinks_per_minute": maxUplinksPerMinute,
+	}).Info("accounting: traffic accounting enabled")
+
+	admin.HandleFunc("/api/accounting", handleHTTP)
+
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+			cleanup(time.Now())
+		}
+	}()
+
+	return nil
+}
+
+// Uplink registers an uplink of the given size (bytes). It returns false
+// when the gateway exceeded its uplink quota, in which case the uplink must
+// be dropped.
+func Uplink(gatewayID lorawan.EUI64, size int) bool {
+	return uplink(gatewayID, size, time.Now())
+}
+
+// Downlink registers a downlink of the given size (bytes).
+func Downlink(gatewayID lorawan.EUI64, size int) {
+	downlink(gatewayID, size, time.Now())
+}
+
+// Get returns the counters of all gateways over the rolling window.
+func Get() []GatewayCounters {
+	return get(time.Now())
+}
+
+func uplink(gatewayID lorawan.EUI64, size int, now time.Time) bool {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return true
+	}
+
+	b := getBucket(gatewayID, now)
+
+	if maxUplinksPerMinute > 0 && b.UplinkCount >= maxUplinksPerMinute {
+		b.DroppedUplinkCount++
+		uplinkDroppedCounter(gatewayID).Inc()
+		return false
+	}
+
+	b.UplinkCount++
+	b.UplinkBytes += size
+	uplinkCounter(gatewayID).Inc()
+	uplinkBytesCounter(gatewayID).Add(float64(size))
+
+	return true
+}
+
+func downlink(gatewayID lorawan.EUI64, size int, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	b := getBucket(gatewayID, now)
+	b.DownlinkCount++
+	b.DownlinkBytes += size
+	downlinkCounter(gatewayID).Inc()
+	downlinkBytesCounter(gatewayID).Add(float64(size))
+}
+
+// getBucket returns the bucket of the given gateway for the given time.
+// When the bucket contains the counters of an expired minute, it is reset.
+// This must be called with the mutex locked.
+func getBucket(gatewayID lorawan.EUI64, now time.Time) *bucket {
+	gw, ok := gateways[gatewayID]
+	if !ok {
+		gw = &gateway{
+			buckets: make([]bucket, int(window/time.Minute)),
+		}
+		gateways[gatewayID] = gw
+	}
+
+	minute := now.Truncate(time.Minute)
+	i := int((minute.Unix() / 60) % int64(len(gw.buckets)))
+
+	if !gw.buckets[i].minute.Equal(minute) {
+		gw.buckets[i] = bucket{minute: minute}
+	}
+
+	return &gw.buckets[i]
+}
+
+func get(now time.Time) []GatewayCounters {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	var out []GatewayCounters
+
+	for gatewayID, gw := range gateways {
+		gc := GatewayCounters{
+			GatewayID: gatewayID,
+		}
+
+		for _, b := range gw.buckets {
+			if inWindow(b.minute, now) {
+				gc.add(b.Counters)
+			}
+		}
+
+		out = append(out, gc)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].GatewayID.String() < out[j].GatewayID.String()
+	})
+
+	return out
+}
+
+// cleanup removes the gateways without traffic within the window.
+func cleanup(now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	for gatewayID, gw := range gateways {
+		var active bool
+		for _, b := range gw.buckets {
+			if inWindow(b.minute, now) {
+				active = true
+				break
+			}
+		}
+
+		if !active {
+			delete(gateways, gatewayID)
+		}
+	}
+}
+
+func inWindow(minute, now time.Time) bool {
+	return !minute.IsZero() && now.Sub(minute) < window
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// counters of all gateways over the rolling window.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin.WriteJSON(w, struct {
+		Window              string            `json:"window"`
+		MaxUplinksPerMinute int               `json:"maxUplinksPerMinute"`
+		Gateways            []GatewayCounters `json:"gateways"`
+	}{
+		Window:              window.String(),
+		MaxUplinksPerMinute: maxUplinksPerMinute,
+		Gateways:            Get(),
+	})
+}
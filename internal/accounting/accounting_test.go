@@ -0,0 +1,130 @@
+package accounting
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func setup(maxUplinks int) {
+	enabled = true
+	window = 5 * time.Minute
+	maxUplinksPerMinute = maxUplinks
+	gateways = make(map[lorawan.EUI64]*gateway)
+}
+
+func TestAccounting(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Date(2019, 9, 10, 12, 0, 30, 0, time.UTC)
+
+	t.Run("Counters", func(t *testing.T) {
+		assert := require.New(t)
+		setup(0)
+
+		assert.True(uplink(gatewayID, 10, now))
+		assert.True(uplink(gatewayID, 20, now.Add(time.Minute)))
+		downlink(gatewayID, 15, now.Add(2*time.Minute))
+
+		assert.Equal([]GatewayCounters{
+			{
+				GatewayID: gatewayID,
+				Counters: Counters{
+					UplinkCount:   2,
+					UplinkBytes:   30,
+					DownlinkCount: 1,
+					DownlinkBytes: 15,
+				},
+			},
+		}, get(now.Add(2*time.Minute)))
+	})
+
+	t.Run("Rolling window", func(t *testing.T) {
+		assert := require.New(t)
+		setup(0)
+
+		assert.True(uplink(gatewayID, 10, now))
+		assert.True(uplink(gatewayID, 20, now.Add(3*time.Minute)))
+
+		// the first minute has left the window
+		out := get(now.Add(5 * time.Minute))
+		assert.Len(out, 1)
+		assert.Equal(1, out[0].UplinkCount)
+		assert.Equal(20, out[0].UplinkBytes)
+
+		// the bucket of the first minute is re-used
+		assert.True(uplink(gatewayID, 5, now.Add(5*time.Minute)))
+		out = get(now.Add(5 * time.Minute))
+		assert.Equal(2, out[0].UplinkCount)
+		assert.Equal(25, out[0].UplinkBytes)
+	})
+
+	t.Run("Quota", func(t *testing.T) {
+		assert := require.New(t)
+		setup(2)
+
+		assert.True(uplink(gatewayID, 10, now))
+		assert.True(uplink(gatewayID, 10, now))
+		assert.False(uplink(gatewayID, 10, now))
+
+		// the next minute, uplinks are accepted again
+		assert.True(uplink(gatewayID, 10, now.Add(time.Minute)))
+
+		out := get(now.Add(time.Minute))
+		assert.Equal(3, out[0].UplinkCount)
+		assert.Equal(1, out[0].DroppedUplinkCount)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		assert := require.New(t)
+		setup(0)
+
+		assert.True(uplink(gatewayID, 10, now))
+
+		cleanup(now.Add(4 * time.Minute))
+		assert.Len(gateways, 1)
+
+		cleanup(now.Add(5 * time.Minute))
+		assert.Len(gateways, 0)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+		setup(1)
+		enabled = false
+
+		assert.True(uplink(gatewayID, 10, now))
+		assert.True(uplink(gatewayID, 10, now))
+		assert.Len(gateways, 0)
+	})
+}
+
+func TestHandleHTTP(t *testing.T) {
+	assert := require.New(t)
+	setup(100)
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	assert.True(Uplink(gatewayID, 10))
+
+	r := httptest.NewRequest(http.MethodGet, "/api/accounting", nil)
+	w := httptest.NewRecorder()
+	handleHTTP(w, r)
+	assert.Equal(http.StatusOK, w.Code)
+
+	var resp struct {
+		Window              string            `json:"window"`
+		MaxUplinksPerMinute int               `json:"maxUplinksPerMinute"`
+		Gateways            []GatewayCounters `json:"gateways"`
+	}
+	assert.NoError(json.NewDecoder(w.Body).Decode(&resp))
+	assert.Equal("5m0s", resp.Window)
+	assert.Equal(100, resp.MaxUplinksPerMinute)
+	assert.Len(resp.Gateways, 1)
+	assert.Equal(gatewayID, resp.Gateways[0].GatewayID)
+	assert.Equal(1, resp.Gateways[0].UplinkCount)
+}
@@ -0,0 +1,55 @@
+package accounting
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	uc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "accounting_uplink_count",
+		Help: "The number of uplinks received (per gateway).",
+	}, []string{"gateway_id"})
+
+	ub = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "accounting_uplink_bytes",
+		Help: "The number of uplink PHYPayload bytes received (per gateway).",
+	}, []string{"gateway_id"})
+
+	ud = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "accounting_uplink_dropped_count",
+		Help: "The number of uplinks dropped because the uplink quota was exceeded (per gateway).",
+	}, []string{"gateway_id"})
+
+	dc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "accounting_downlink_count",
+		Help: "The number of downlinks sent (per gateway).",
+	}, []string{"gateway_id"})
+
+	db = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "accounting_downlink_bytes",
+		Help: "The number of downlink PHYPayload bytes sent (per gateway).",
+	}, []string{"gateway_id"})
+)
+
+func uplinkCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return uc.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
+
+func uplinkBytesCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return ub.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
+
+func uplinkDroppedCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return ud.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
+
+func downlinkCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return dc.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
+
+func downlinkBytesCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return db.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
@@ -0,0 +1,116 @@
+// Package admin implements the admin HTTP API. Other packages register their
+// handlers using Handle, the API server itself is started by Setup.
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/httpserver"
+)
+
+var (
+	mux      sync.RWMutex
+	handlers = make(map[string]http.Handler)
+)
+
+// Handle registers the handler for the given path. When the path ends with a
+// slash, the handler is used for all paths with the given prefix (unless
+// a more specific handler has been registered). Registering a handler for an
+// existing path replaces the previous handler.
+func Handle(path string, handler http.Handler) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	handlers[path] = handler
+}
+
+// HandleFunc registers the handler function for the given path.
+func HandleFunc(path string, handler func(http.ResponseWriter, *http.Request)) {
+	Handle(path, http.HandlerFunc(handler))
+}
+
+// Setup configures and starts the admin API server.
+func Setup(conf config.Config) error {
+	if conf.Admin.Bind == "" {
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"bind": conf.Admin.Bind,
+	}).Info("admin: starting admin api server")
+
+	opts := httpserver.Options{
+		Bind:        conf.Admin.Bind,
+		TLSCert:     conf.Admin.TLSCert,
+		TLSKey:      conf.Admin.TLSKey,
+		BearerToken: conf.Admin.BearerToken,
+		JWTSecret:   conf.Admin.JWTSecret,
+	}
+
+	go func() {
+		err := httpserver.ListenAndServe(opts, Handler())
+		log.WithError(err).Error("admin: admin api server error")
+	}()
+
+	return nil
+}
+
+// Handler returns the http.Handler dispatching the requests to the
+// registered handlers.
+func Handler() http.Handler {
+	return http.HandlerFunc(serveHTTP)
+}
+
+// WriteJSON writes the given value as JSON response.
+func WriteJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.WithError(err).Error("admin: write json response error")
+	}
+}
+
+// WriteError writes the given error as JSON response, using the given
+// HTTP status code.
+func WriteError(w http.ResponseWriter, code int, err error) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+}
+
+func serveHTTP(w http.ResponseWriter, r *http.Request) {
+	handler := getHandler(r.URL.Path)
+	if handler == nil {
+		http.NotFound(w, r)
+		return
+	}
+
+	handler.ServeHTTP(w, r)
+}
+
+func getHandler(path string) http.Handler {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if h, ok := handlers[path]; ok {
+		return h
+	}
+
+	var handler http.Handler
+	var prefixLen int
+	for p, h := range handlers {
+		if strings.HasSuffix(p, "/") && strings.HasPrefix(path, p) && len(p) > prefixLen {
+			handler = h
+			prefixLen = len(p)
+		}
+	}
+
+	return handler
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandler(t *testing.T) {
+	handle := func(name string) func(http.ResponseWriter, *http.Request) {
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, name)
+		}
+	}
+
+	HandleFunc("/api/foo", handle("foo"))
+	HandleFunc("/api/bar/", handle("bar"))
+	HandleFunc("/api/bar/baz/", handle("baz"))
+
+	server := httptest.NewServer(Handler())
+	defer server.Close()
+
+	tests := []struct {
+		Path       string
+		StatusCode int
+		Body       string
+	}{
+		{"/api/foo", http.StatusOK, "foo"},
+		{"/api/foo/", http.StatusNotFound, "404 page not found\n"},
+		{"/api/bar/", http.StatusOK, "bar"},
+		{"/api/bar/test", http.StatusOK, "bar"},
+		{"/api/bar/baz/test", http.StatusOK, "baz"},
+		{"/api/unknown", http.StatusNotFound, "404 page not found\n"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Path, func(t *testing.T) {
+			assert := require.New(t)
+
+			resp, err := http.Get(server.URL + tst.Path)
+			assert.NoError(err)
+			defer resp.Body.Close()
+
+			b, err := ioutil.ReadAll(resp.Body)
+			assert.NoError(err)
+
+			assert.Equal(tst.StatusCode, resp.StatusCode)
+			assert.Equal(tst.Body, string(b))
+		})
+	}
+}
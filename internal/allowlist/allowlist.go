@@ -0,0 +1,374 @@
+// Package allowlist implements the gateway allowlist. In learning mode, the
+// IDs of all gateways seen are recorded to the allowlist file. In enforcing
+// mode, the events of gateways which are not in the allowlist are dropped and
+// these gateways are kept as pending, so that they can be approved or denied
+// using the admin API.
+package allowlist
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+// Allowlist modes.
+const (
+	ModeLearning  = "learning"
+	ModeEnforcing = "enforcing"
+)
+
+// Pending contains a gateway which is not in the allowlist, seen while
+// enforcing.
+type Pending struct {
+	GatewayID  lorawan.EUI64 `json:"gatewayID"`
+	FirstSeen  time.Time     `json:"firstSeen"`
+	LastSeen   time.Time     `json:"lastSeen"`
+	EventCount int           `json:"eventCount"`
+}
+
+type modeRequest struct {
+	Mode string `json:"mode"`
+}
+
+type pendingRequest struct {
+	GatewayID lorawan.EUI64 `json:"gatewayID"`
+	Approve   bool          `json:"approve"`
+}
+
+var (
+	mux sync.RWMutex
+
+	mode string
+	file string
+
+	allowed = make(map[lorawan.EUI64]struct{})
+	denied  = make(map[lorawan.EUI64]struct{})
+	pending = make(map[lorawan.EUI64]*Pending)
+)
+
+// Setup configures the gateway allowlist.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	switch conf.Allowlist.Mode {
+	case "":
+		return nil
+	case ModeLearning, ModeEnforcing:
+	default:
+		return fmt.Errorf("unknown allowlist mode: %s", conf.Allowlist.Mode)
+	}
+
+	if conf.Allowlist.File == "" {
+		return errors.New("allowlist file must be set")
+	}
+
+	ids, err := readFile(conf.Allowlist.File)
+	if err != nil {
+		// in learning mode, the file is created when the first gateway is seen
+		if !(os.IsNotExist(errors.Cause(err)) && conf.Allowlist.Mode == ModeLearning) {
+			return err
+		}
+	}
+
+	mode = conf.Allowlist.Mode
+	file = conf.Allowlist.File
+	allowed = make(map[lorawan.EUI64]struct{})
+	for _, id := range ids {
+		allowed[id] = struct{}{}
+	}
+
+	log.WithFields(log.Fields{
+		"mode":     mode,
+		"file":     file,
+		"gateways": len(allowed),
+	}).Info("allowlist: gateway allowlist enabled")
+
+	admin.HandleFunc("/api/allowlist", handleHTTP)
+	admin.HandleFunc("/api/allowlist/pending", handlePendingHTTP)
+
+	return nil
+}
+
+// Allowed returns true when the events of the given gateway are allowed.
+// When false is returned, the event (of the given type) must be dropped.
+func Allowed(gatewayID lorawan.EUI64, event string) bool {
+	if allow(gatewayID, time.Now()) {
+		return true
+	}
+
+	droppedCounter(event).Inc()
+	return false
+}
+
+// GetPending returns the pending gateways.
+func GetPending() []Pending {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	var out []Pending
+	for _, p := range pending {
+		out = append(out, *p)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].GatewayID.String() < out[j].GatewayID.String()
+	})
+
+	return out
+}
+
+// Approve adds the given gateway to the allowlist.
+func Approve(gatewayID lorawan.EUI64) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	delete(pending, gatewayID)
+	delete(denied, gatewayID)
+	allowed[gatewayID] = struct{}{}
+
+	log.WithField("gateway_id", gatewayID).Info("allowlist: gateway approved")
+
+	return writeFile(file, allowed)
+}
+
+// Deny removes the given gateway from the allowlist. Denied gateways are no
+// longer reported as pending (until restart).
+func Deny(gatewayID lorawan.EUI64) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	delete(pending, gatewayID)
+	denied[gatewayID] = struct{}{}
+
+	log.WithField("gateway_id", gatewayID).Info("allowlist: gateway denied")
+
+	if _, ok := allowed[gatewayID]; !ok {
+		return nil
+	}
+	delete(allowed, gatewayID)
+
+	return writeFile(file, allowed)
+}
+
+// SetMode switches the allowlist mode. Note that the configured mode is used
+// again after a restart.
+func SetMode(m string) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if mode == "" {
+		return errors.New("allowlist is not enabled")
+	}
+
+	switch m {
+	case ModeLearning, ModeEnforcing:
+	default:
+		return fmt.Errorf("unknown allowlist mode: %s", m)
+	}
+
+	log.WithFields(log.Fields{
+		"mode": m,
+	}).Info("allowlist: mode changed")
+
+	mode = m
+	if mode == ModeLearning {
+		pending = make(map[lorawan.EUI64]*Pending)
+	}
+
+	return nil
+}
+
+func allow(gatewayID lorawan.EUI64, now time.Time) bool {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if mode == "" {
+		return true
+	}
+
+	if _, ok := allowed[gatewayID]; ok {
+		return true
+	}
+
+	switch mode {
+	case ModeLearning:
+		allowed[gatewayID] = struct{}{}
+
+		log.WithField("gateway_id", gatewayID).Info("allowlist: gateway learned")
+
+		if err := writeFile(file, allowed); err != nil {
+			log.WithError(err).Error("allowlist: write allowlist file error")
+		}
+
+		return true
+	default:
+		if _, ok := denied[gatewayID]; ok {
+			return false
+		}
+
+		p, ok := pending[gatewayID]
+		if !ok {
+			p = &Pending{
+				GatewayID: gatewayID,
+				FirstSeen: now,
+			}
+			pending[gatewayID] = p
+
+			log.WithField("gateway_id", gatewayID).Warning("allowlist: gateway is not allowed, gateway is pending")
+		}
+		p.LastSeen = now
+		p.EventCount++
+
+		return false
+	}
+}
+
+// readFile reads the gateway IDs from the given file, one gateway ID per
+// line. Empty lines and lines starting with # are ignored.
+func readFile(path string) ([]lorawan.EUI64, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read allowlist file error")
+	}
+
+	var out []lorawan.EUI64
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var id lorawan.EUI64
+		if err := id.UnmarshalText([]byte(line)); err != nil {
+			return nil, errors.Wrapf(err, "parse gateway id %s error", line)
+		}
+		out = append(out, id)
+	}
+
+	return out, scanner.Err()
+}
+
+// writeFile atomically writes the given gateway IDs to the given file.
+func writeFile(path string, ids map[lorawan.EUI64]struct{}) error {
+	var lines []string
+	for id := range ids {
+		lines = append(lines, id.String())
+	}
+	sort.Strings(lines)
+
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, []byte(strings.Join(lines, "\n")+"\n"), 0640); err != nil {
+		return errors.Wrap(err, "write allowlist file error")
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		return errors.Wrap(err, "rename allowlist file error")
+	}
+
+	return nil
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// allowlist state, a POST request changes the mode.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req modeRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+			return
+		}
+
+		if err := SetMode(req.Mode); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, err)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeStatus(w)
+}
+
+// handlePendingHTTP implements the admin API handler for approving or
+// denying (pending) gateways.
+func handlePendingHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req pendingRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+			return
+		}
+
+		var err error
+		if req.Approve {
+			err = Approve(req.GatewayID)
+		} else {
+			err = Deny(req.GatewayID)
+		}
+		if err != nil {
+			admin.WriteError(w, http.StatusInternalServerError, err)
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	writeStatus(w)
+}
+
+func writeStatus(w http.ResponseWriter) {
+	mux.RLock()
+	currentMode := mode
+	var allowedIDs, deniedIDs []lorawan.EUI64
+	for id := range allowed {
+		allowedIDs = append(allowedIDs, id)
+	}
+	for id := range denied {
+		deniedIDs = append(deniedIDs, id)
+	}
+	mux.RUnlock()
+
+	sortIDs(allowedIDs)
+	sortIDs(deniedIDs)
+
+	admin.WriteJSON(w, struct {
+		Mode    string          `json:"mode"`
+		Allowed []lorawan.EUI64 `json:"allowed"`
+		Denied  []lorawan.EUI64 `json:"denied"`
+		Pending []Pending       `json:"pending"`
+	}{
+		Mode:    currentMode,
+		Allowed: allowedIDs,
+		Denied:  deniedIDs,
+		Pending: GetPending(),
+	})
+}
+
+func sortIDs(ids []lorawan.EUI64) {
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+}
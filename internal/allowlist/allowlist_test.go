@@ -0,0 +1,130 @@
+package allowlist
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func setup(assert *require.Assertions, m string) func() {
+	dir, err := ioutil.TempDir("", "allowlist")
+	assert.NoError(err)
+
+	mode = m
+	file = filepath.Join(dir, "allowlist")
+	allowed = make(map[lorawan.EUI64]struct{})
+	denied = make(map[lorawan.EUI64]struct{})
+	pending = make(map[lorawan.EUI64]*Pending)
+
+	return func() {
+		mode = ""
+		os.RemoveAll(dir)
+	}
+}
+
+func TestReadWriteFile(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "allowlist")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "allowlist")
+	assert.NoError(ioutil.WriteFile(path, []byte("# gateways\n0202020202020202\n\n0101010101010101\n"), 0640))
+
+	ids, err := readFile(path)
+	assert.NoError(err)
+	assert.Equal([]lorawan.EUI64{{2, 2, 2, 2, 2, 2, 2, 2}, {1, 1, 1, 1, 1, 1, 1, 1}}, ids)
+
+	assert.NoError(writeFile(path, map[lorawan.EUI64]struct{}{
+		{2, 2, 2, 2, 2, 2, 2, 2}: {},
+		{1, 1, 1, 1, 1, 1, 1, 1}: {},
+	}))
+
+	b, err := ioutil.ReadFile(path)
+	assert.NoError(err)
+	assert.Equal("0101010101010101\n0202020202020202\n", string(b))
+
+	assert.NoError(ioutil.WriteFile(path, []byte("foo\n"), 0640))
+	_, err = readFile(path)
+	assert.Error(err)
+}
+
+func TestLearning(t *testing.T) {
+	assert := require.New(t)
+	defer setup(assert, ModeLearning)()
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	assert.True(allow(gatewayID, time.Now()))
+	assert.Len(GetPending(), 0)
+
+	ids, err := readFile(file)
+	assert.NoError(err)
+	assert.Equal([]lorawan.EUI64{gatewayID}, ids)
+}
+
+func TestEnforcing(t *testing.T) {
+	assert := require.New(t)
+	defer setup(assert, ModeEnforcing)()
+
+	now := time.Now()
+	knownID := lorawan.EUI64{1, 1, 1, 1, 1, 1, 1, 1}
+	unknownID := lorawan.EUI64{2, 2, 2, 2, 2, 2, 2, 2}
+	allowed[knownID] = struct{}{}
+
+	t.Run("known gateway", func(t *testing.T) {
+		assert := require.New(t)
+		assert.True(allow(knownID, now))
+	})
+
+	t.Run("unknown gateway", func(t *testing.T) {
+		assert := require.New(t)
+		assert.False(allow(unknownID, now))
+		assert.False(allow(unknownID, now.Add(time.Second)))
+
+		assert.Equal([]Pending{
+			{
+				GatewayID:  unknownID,
+				FirstSeen:  now,
+				LastSeen:   now.Add(time.Second),
+				EventCount: 2,
+			},
+		}, GetPending())
+	})
+
+	t.Run("approve", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(Approve(unknownID))
+		assert.Len(GetPending(), 0)
+		assert.True(allow(unknownID, now))
+
+		ids, err := readFile(file)
+		assert.NoError(err)
+		assert.Equal([]lorawan.EUI64{knownID, unknownID}, ids)
+	})
+
+	t.Run("deny", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(Deny(unknownID))
+		assert.False(allow(unknownID, now))
+		assert.Len(GetPending(), 0)
+
+		ids, err := readFile(file)
+		assert.NoError(err)
+		assert.Equal([]lorawan.EUI64{knownID}, ids)
+	})
+
+	t.Run("switch to learning", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(SetMode(ModeLearning))
+		assert.True(allow(unknownID, now))
+		assert.EqualError(SetMode("foo"), "unknown allowlist mode: foo")
+	})
+}
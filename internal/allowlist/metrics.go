@@ -0,0 +1,17 @@
+package allowlist
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	dc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "allowlist_dropped_count",
+		Help: "The number of events dropped because the gateway is not in the allowlist (per event type).",
+	}, []string{"type"})
+)
+
+func droppedCounter(typ string) prometheus.Counter {
+	return dc.With(prometheus.Labels{"type": typ})
+}
@@ -0,0 +1,103 @@
+// Package antennagain implements the per gateway antenna gain and cable loss
+// compensation. The TX power of a downlink is handled as EIRP and is converted
+// to the conducted TX power of the concentrator, so that the transmitted EIRP
+// respects the regulations regardless of the antenna installed at the gateway.
+// Likewise, the RSSI of an uplink is normalized to an isotropic antenna.
+package antennagain
+
+import (
+	"math"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	mux sync.RWMutex
+
+	// offsets contains per gateway the antenna gain minus the cable loss
+	// (dB), which is the difference between the EIRP and the conducted
+	// power.
+	offsets map[lorawan.EUI64]float64
+)
+
+// Setup configures the antenna gain compensation.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	offsets = make(map[lorawan.EUI64]float64)
+
+	for _, gc := range conf.AntennaGain.Gateways {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(gc.GatewayID)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+
+		if _, ok := offsets[gatewayID]; ok {
+			return errors.Errorf("duplicate gateway_id: %s", gatewayID)
+		}
+
+		if gc.CableLoss < 0 {
+			return errors.Errorf("cable_loss must not be negative for gateway_id: %s", gatewayID)
+		}
+
+		offsets[gatewayID] = gc.AntennaGain - gc.CableLoss
+
+		log.WithFields(log.Fields{
+			"gateway_id":   gatewayID,
+			"antenna_gain": gc.AntennaGain,
+			"cable_loss":   gc.CableLoss,
+		}).Info("antennagain: gateway antenna gain compensation configured")
+	}
+
+	return nil
+}
+
+// ApplyToUplinkFrame normalizes the RSSI of the uplink rx-info to an
+// isotropic antenna, by subtracting the antenna gain and adding the cable
+// loss of the gateway.
+func ApplyToUplinkFrame(frame *gw.UplinkFrame) {
+	if frame.RxInfo == nil {
+		return
+	}
+
+	offset, ok := getOffset(frame.RxInfo.GetGatewayId())
+	if !ok {
+		return
+	}
+
+	frame.RxInfo.Rssi = int32(math.Round(float64(frame.RxInfo.Rssi) - offset))
+}
+
+// ApplyToDownlinkFrame converts the TX power (EIRP) of the downlink tx-info
+// to the conducted TX power, by subtracting the antenna gain and adding the
+// cable loss of the gateway.
+func ApplyToDownlinkFrame(frame *gw.DownlinkFrame) {
+	if frame.TxInfo == nil {
+		return
+	}
+
+	offset, ok := getOffset(frame.TxInfo.GetGatewayId())
+	if !ok {
+		return
+	}
+
+	frame.TxInfo.Power = int32(math.Round(float64(frame.TxInfo.Power) - offset))
+}
+
+func getOffset(b []byte) (float64, bool) {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], b)
+
+	mux.RLock()
+	defer mux.RUnlock()
+
+	offset, ok := offsets[gatewayID]
+	return offset, ok
+}
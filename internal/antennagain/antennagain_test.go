@@ -0,0 +1,121 @@
+package antennagain
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestAntennaGain(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.AntennaGain.Gateways = []config.AntennaGainGateway{
+		{GatewayID: "0102030405060708", AntennaGain: 6, CableLoss: 1.5},
+		{GatewayID: "0202030405060708", AntennaGain: 2, CableLoss: 3},
+	}
+	assert.NoError(Setup(conf))
+
+	tests := []struct {
+		Name          string
+		GatewayID     []byte
+		Value         int32
+		ExpectedRSSI  int32
+		ExpectedPower int32
+	}{
+		{
+			Name:          "antenna gain exceeds cable loss",
+			GatewayID:     []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Value:         14,
+			ExpectedRSSI:  10,
+			ExpectedPower: 10,
+		},
+		{
+			Name:          "negative rssi",
+			GatewayID:     []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Value:         -100,
+			ExpectedRSSI:  -105,
+			ExpectedPower: -105,
+		},
+		{
+			Name:          "cable loss exceeds antenna gain",
+			GatewayID:     []byte{2, 2, 3, 4, 5, 6, 7, 8},
+			Value:         14,
+			ExpectedRSSI:  15,
+			ExpectedPower: 15,
+		},
+		{
+			Name:          "unconfigured gateway",
+			GatewayID:     []byte{8, 7, 6, 5, 4, 3, 2, 1},
+			Value:         14,
+			ExpectedRSSI:  14,
+			ExpectedPower: 14,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			uf := gw.UplinkFrame{
+				RxInfo: &gw.UplinkRXInfo{
+					GatewayId: tst.GatewayID,
+					Rssi:      tst.Value,
+				},
+			}
+			ApplyToUplinkFrame(&uf)
+			assert.Equal(tst.ExpectedRSSI, uf.RxInfo.Rssi)
+
+			df := gw.DownlinkFrame{
+				TxInfo: &gw.DownlinkTXInfo{
+					GatewayId: tst.GatewayID,
+					Power:     tst.Value,
+				},
+			}
+			ApplyToDownlinkFrame(&df)
+			assert.Equal(tst.ExpectedPower, df.TxInfo.Power)
+		})
+	}
+
+	t.Run("missing rx-info and tx-info", func(t *testing.T) {
+		ApplyToUplinkFrame(&gw.UplinkFrame{})
+		ApplyToDownlinkFrame(&gw.DownlinkFrame{})
+	})
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Gateways      []config.AntennaGainGateway
+		ExpectedError string
+	}{
+		{
+			Name: "duplicate gateway",
+			Gateways: []config.AntennaGainGateway{
+				{GatewayID: "0102030405060708", AntennaGain: 6},
+				{GatewayID: "0102030405060708", AntennaGain: 3},
+			},
+			ExpectedError: "duplicate gateway_id: 0102030405060708",
+		},
+		{
+			Name: "negative cable loss",
+			Gateways: []config.AntennaGainGateway{
+				{GatewayID: "0102030405060708", CableLoss: -1},
+			},
+			ExpectedError: "cable_loss must not be negative for gateway_id: 0102030405060708",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.AntennaGain.Gateways = tst.Gateways
+			assert.EqualError(Setup(conf), tst.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,99 @@
+// Package antennamap implements the mapping of the packet-forwarder RF chain
+// and channel indexes to logical board and antenna numbers, so that gateways
+// with multiple concentrator boards (e.g. 16 channel gateways) report
+// consistent antenna meta-data.
+package antennamap
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// channel identifies a packet-forwarder RF chain and channel.
+type channel struct {
+	rfChain uint32
+	channel uint32
+}
+
+// antenna holds the logical board and antenna.
+type antenna struct {
+	board   uint32
+	antenna uint32
+}
+
+var (
+	mux      sync.RWMutex
+	gateways map[lorawan.EUI64]map[channel]antenna
+)
+
+// Setup configures the antenna mapping.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	gateways = make(map[lorawan.EUI64]map[channel]antenna)
+
+	for _, gc := range conf.AntennaMap.Gateways {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(gc.GatewayID)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+
+		if _, ok := gateways[gatewayID]; ok {
+			return errors.Errorf("duplicate gateway_id: %s", gatewayID)
+		}
+
+		channels := make(map[channel]antenna)
+		for _, c := range gc.Channels {
+			ch := channel{rfChain: c.RFChain, channel: c.Channel}
+			if _, ok := channels[ch]; ok {
+				return errors.Errorf("duplicate rf_chain %d and channel %d for gateway_id: %s", c.RFChain, c.Channel, gatewayID)
+			}
+
+			channels[ch] = antenna{board: c.Board, antenna: c.Antenna}
+		}
+
+		gateways[gatewayID] = channels
+
+		log.WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"channels":   len(channels),
+		}).Info("antennamap: gateway antenna mapping configured")
+	}
+
+	return nil
+}
+
+// ApplyToUplinkFrame sets the board and antenna of the uplink rx-info, using
+// the configured mapping of the RF chain and channel of the gateway. The
+// reported board and antenna are kept when there is no mapping.
+func ApplyToUplinkFrame(frame *gw.UplinkFrame) {
+	if frame.RxInfo == nil {
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.RxInfo.GetGatewayId())
+
+	mux.RLock()
+	defer mux.RUnlock()
+
+	channels, ok := gateways[gatewayID]
+	if !ok {
+		return
+	}
+
+	ant, ok := channels[channel{rfChain: frame.RxInfo.RfChain, channel: frame.RxInfo.Channel}]
+	if !ok {
+		return
+	}
+
+	frame.RxInfo.Board = ant.board
+	frame.RxInfo.Antenna = ant.antenna
+}
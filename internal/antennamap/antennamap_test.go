@@ -0,0 +1,104 @@
+package antennamap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestAntennaMap(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.AntennaMap.Gateways = []config.AntennaMapGateway{
+		{
+			GatewayID: "0102030405060708",
+			Channels: []config.AntennaMapChannel{
+				{RFChain: 0, Channel: 0, Board: 0, Antenna: 0},
+				{RFChain: 1, Channel: 8, Board: 1, Antenna: 1},
+			},
+		},
+	}
+	assert.NoError(Setup(conf))
+
+	tests := []struct {
+		Name     string
+		RxInfo   *gw.UplinkRXInfo
+		Expected *gw.UplinkRXInfo
+	}{
+		{
+			Name: "mapped channel",
+			RxInfo: &gw.UplinkRXInfo{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				RfChain:   1,
+				Channel:   8,
+			},
+			Expected: &gw.UplinkRXInfo{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				RfChain:   1,
+				Channel:   8,
+				Board:     1,
+				Antenna:   1,
+			},
+		},
+		{
+			Name: "mapped channel overrides reported values",
+			RxInfo: &gw.UplinkRXInfo{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				Board:     3,
+				Antenna:   2,
+			},
+			Expected: &gw.UplinkRXInfo{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+		},
+		{
+			Name: "unmapped channel",
+			RxInfo: &gw.UplinkRXInfo{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				RfChain:   0,
+				Channel:   8,
+				Board:     3,
+			},
+			Expected: &gw.UplinkRXInfo{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				RfChain:   0,
+				Channel:   8,
+				Board:     3,
+			},
+		},
+		{
+			Name: "unmapped gateway",
+			RxInfo: &gw.UplinkRXInfo{
+				GatewayId: []byte{8, 7, 6, 5, 4, 3, 2, 1},
+				RfChain:   1,
+				Channel:   8,
+			},
+			Expected: &gw.UplinkRXInfo{
+				GatewayId: []byte{8, 7, 6, 5, 4, 3, 2, 1},
+				RfChain:   1,
+				Channel:   8,
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			frame := gw.UplinkFrame{RxInfo: tst.RxInfo}
+			ApplyToUplinkFrame(&frame)
+			assert.Equal(tst.Expected, frame.RxInfo)
+		})
+	}
+
+	t.Run("duplicate channel", func(t *testing.T) {
+		assert := require.New(t)
+
+		conf.AntennaMap.Gateways[0].Channels = append(conf.AntennaMap.Gateways[0].Channels, config.AntennaMapChannel{RFChain: 1, Channel: 8})
+		assert.EqualError(Setup(conf), "duplicate rf_chain 1 and channel 8 for gateway_id: 0102030405060708")
+	})
+}
@@ -0,0 +1,303 @@
+// Package archive implements a local (SQLite) archive of the events
+// published by the LoRa Gateway Bridge.
+package archive
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// cleanupInterval defines the interval in which events older than the
+// configured retention are removed from the archive.
+const cleanupInterval = time.Hour
+
+const schema = `
+create table if not exists event (
+	id integer primary key autoincrement,
+	created_at integer not null,
+	gateway_id blob not null,
+	event_type text not null,
+	event_id blob not null,
+	payload blob not null
+);
+
+create index if not exists idx_event_created_at on event(created_at);
+create index if not exists idx_event_gateway_id_created_at on event(gateway_id, created_at);
+`
+
+var (
+	mux sync.RWMutex
+	db  *sql.DB
+
+	retention time.Duration
+)
+
+// Event represents an archived event.
+type Event struct {
+	ID        int64
+	CreatedAt time.Time
+	GatewayID lorawan.EUI64
+	EventType string
+	EventID   uuid.UUID
+	Payload   []byte
+}
+
+// Message returns the protobuf message of the archived event.
+func (e Event) Message() (proto.Message, error) {
+	msg, err := NewMessage(e.EventType)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := proto.Unmarshal(e.Payload, msg); err != nil {
+		return nil, errors.Wrap(err, "unmarshal protobuf error")
+	}
+
+	return msg, nil
+}
+
+// NewMessage returns an empty protobuf message for the given event type.
+// Only the archived event types (up, stats and ack) are supported.
+func NewMessage(eventType string) (proto.Message, error) {
+	switch eventType {
+	case "up":
+		return &gw.UplinkFrame{}, nil
+	case "stats":
+		return &gw.GatewayStats{}, nil
+	case "ack":
+		return &gw.DownlinkTXAck{}, nil
+	default:
+		return nil, fmt.Errorf("unexpected event type: %s", eventType)
+	}
+}
+
+// Filters contains the filters that can be used to query the archive.
+type Filters struct {
+	GatewayID *lorawan.EUI64
+	EventType string
+	Since     time.Time
+	Limit     int
+}
+
+// Setup configures the archive package.
+func Setup(conf config.Config) error {
+	if !conf.Archive.Enabled {
+		return nil
+	}
+
+	log.WithFields(log.Fields{
+		"path":      conf.Archive.Path,
+		"retention": conf.Archive.Retention,
+	}).Info("archive: opening event archive")
+
+	if err := Open(conf.Archive.Path); err != nil {
+		return errors.Wrap(err, "open archive error")
+	}
+
+	mux.Lock()
+	retention = conf.Archive.Retention
+	mux.Unlock()
+
+	if retention > 0 {
+		go func() {
+			for {
+				if err := cleanup(); err != nil {
+					log.WithError(err).Error("archive: cleanup error")
+				}
+				time.Sleep(cleanupInterval)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// Open opens the archive database at the given path. The schema will be
+// created when it does not yet exist.
+func Open(path string) error {
+	var driverAvailable bool
+	for _, d := range sql.Drivers() {
+		if d == "sqlite3" {
+			driverAvailable = true
+		}
+	}
+	if !driverAvailable {
+		return errors.New("sqlite3 driver is not available, the archive requires a cgo enabled build")
+	}
+
+	d, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return errors.Wrap(err, "open database error")
+	}
+
+	// sqlite does not support concurrent writes
+	d.SetMaxOpenConns(1)
+
+	if _, err := d.Exec(schema); err != nil {
+		d.Close()
+		return errors.Wrap(err, "create schema error")
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	db = d
+
+	return nil
+}
+
+// Close closes the archive database.
+func Close() error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if db == nil {
+		return nil
+	}
+
+	err := db.Close()
+	db = nil
+	return err
+}
+
+// Store stores the given event in the archive. When the archive has not been
+// enabled, this is a no-op.
+func Store(gatewayID lorawan.EUI64, eventType string, id uuid.UUID, msg proto.Message) error {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if db == nil {
+		return nil
+	}
+
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "marshal protobuf error")
+	}
+
+	_, err = db.Exec(`
+		insert into event (
+			created_at,
+			gateway_id,
+			event_type,
+			event_id,
+			payload
+		) values (?, ?, ?, ?, ?)`,
+		time.Now().UnixNano(),
+		gatewayID[:],
+		eventType,
+		id[:],
+		b,
+	)
+	if err != nil {
+		return errors.Wrap(err, "insert event error")
+	}
+
+	return nil
+}
+
+// Query returns the archived events matching the given filters, ordered by
+// the time they were archived.
+func Query(filters Filters) ([]Event, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if db == nil {
+		return nil, errors.New("archive is not opened")
+	}
+
+	var where []string
+	var args []interface{}
+
+	if filters.GatewayID != nil {
+		where = append(where, "gateway_id = ?")
+		args = append(args, filters.GatewayID[:])
+	}
+
+	if filters.EventType != "" {
+		where = append(where, "event_type = ?")
+		args = append(args, filters.EventType)
+	}
+
+	if !filters.Since.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, filters.Since.UnixNano())
+	}
+
+	query := "select id, created_at, gateway_id, event_type, event_id, payload from event"
+	if len(where) != 0 {
+		query += " where " + strings.Join(where, " and ")
+	}
+	query += " order by created_at"
+	if filters.Limit > 0 {
+		query += " limit ?"
+		args = append(args, filters.Limit)
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, errors.Wrap(err, "select events error")
+	}
+	defer rows.Close()
+
+	var out []Event
+	for rows.Next() {
+		var e Event
+		var createdAt int64
+		var gatewayID, eventID []byte
+
+		if err := rows.Scan(&e.ID, &createdAt, &gatewayID, &e.EventType, &eventID, &e.Payload); err != nil {
+			return nil, errors.Wrap(err, "scan event error")
+		}
+
+		e.CreatedAt = time.Unix(0, createdAt)
+		copy(e.GatewayID[:], gatewayID)
+		copy(e.EventID[:], eventID)
+
+		out = append(out, e)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, errors.Wrap(err, "read events error")
+	}
+
+	return out, nil
+}
+
+// cleanup removes the events older than the configured retention.
+func cleanup() error {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if db == nil || retention == 0 {
+		return nil
+	}
+
+	res, err := db.Exec("delete from event where created_at < ?", time.Now().Add(-retention).UnixNano())
+	if err != nil {
+		return errors.Wrap(err, "delete events error")
+	}
+
+	count, err := res.RowsAffected()
+	if err != nil {
+		return errors.Wrap(err, "get rows affected error")
+	}
+
+	log.WithFields(log.Fields{
+		"count":     count,
+		"retention": retention,
+	}).Debug("archive: removed expired events")
+
+	return nil
+}
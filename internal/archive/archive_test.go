@@ -0,0 +1,111 @@
+// +build cgo
+
+package archive
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestArchive(t *testing.T) {
+	assert := require.New(t)
+
+	tempDir, err := ioutil.TempDir("", "archive")
+	assert.NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	assert.NoError(Open(filepath.Join(tempDir, "archive.sqlite")))
+	defer Close()
+
+	gatewayID1 := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	gatewayID2 := lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}
+
+	uplinkID, err := uuid.NewV4()
+	assert.NoError(err)
+	statsID, err := uuid.NewV4()
+	assert.NoError(err)
+
+	uplink := gw.UplinkFrame{
+		PhyPayload: []byte{1, 2, 3, 4},
+		RxInfo: &gw.UplinkRXInfo{
+			GatewayId: gatewayID1[:],
+			UplinkId:  uplinkID[:],
+		},
+	}
+	stats := gw.GatewayStats{
+		GatewayId: gatewayID2[:],
+		StatsId:   statsID[:],
+	}
+
+	assert.NoError(Store(gatewayID1, "up", uplinkID, &uplink))
+	assert.NoError(Store(gatewayID2, "stats", statsID, &stats))
+
+	t.Run("Query all", func(t *testing.T) {
+		assert := require.New(t)
+
+		events, err := Query(Filters{})
+		assert.NoError(err)
+		assert.Len(events, 2)
+
+		assert.Equal(gatewayID1, events[0].GatewayID)
+		assert.Equal("up", events[0].EventType)
+		assert.Equal(uplinkID, events[0].EventID)
+
+		msg, err := events[0].Message()
+		assert.NoError(err)
+		assert.True(proto.Equal(&uplink, msg))
+	})
+
+	t.Run("Query by gateway", func(t *testing.T) {
+		assert := require.New(t)
+
+		events, err := Query(Filters{GatewayID: &gatewayID2})
+		assert.NoError(err)
+		assert.Len(events, 1)
+		assert.Equal("stats", events[0].EventType)
+
+		msg, err := events[0].Message()
+		assert.NoError(err)
+		assert.True(proto.Equal(&stats, msg))
+	})
+
+	t.Run("Query by event type and limit", func(t *testing.T) {
+		assert := require.New(t)
+
+		events, err := Query(Filters{EventType: "up", Limit: 1})
+		assert.NoError(err)
+		assert.Len(events, 1)
+		assert.Equal(uplinkID, events[0].EventID)
+	})
+
+	t.Run("Query since", func(t *testing.T) {
+		assert := require.New(t)
+
+		events, err := Query(Filters{Since: time.Now().Add(time.Minute)})
+		assert.NoError(err)
+		assert.Len(events, 0)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		assert := require.New(t)
+
+		retention = time.Nanosecond
+		defer func() { retention = 0 }()
+
+		assert.NoError(cleanup())
+
+		events, err := Query(Filters{})
+		assert.NoError(err)
+		assert.Len(events, 0)
+	})
+}
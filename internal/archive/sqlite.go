@@ -0,0 +1,8 @@
+// +build cgo
+
+package archive
+
+import (
+	// sqlite3 driver, this requires cgo
+	_ "github.com/mattn/go-sqlite3"
+)
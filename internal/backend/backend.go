@@ -6,10 +6,12 @@ import (
 	"github.com/pkg/errors"
 
 	"github.com/brocaar/lora-gateway-bridge/internal/backend/basicstation"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/native"
 	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/ttnconnector"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
 	"github.com/brocaar/loraserver/api/gw"
-	"github.com/brocaar/lorawan"
 )
 
 var backend Backend
@@ -23,6 +25,10 @@ func Setup(conf config.Config) error {
 		backend, err = semtechudp.NewBackend(conf)
 	case "basic_station":
 		backend, err = basicstation.NewBackend(conf)
+	case "ttn_connector":
+		backend, err = ttnconnector.NewBackend(conf)
+	case "native":
+		backend, err = native.NewBackend(conf)
 	default:
 		return fmt.Errorf("unknown backend type: %s", conf.Backend.Type)
 	}
@@ -54,14 +60,36 @@ type Backend interface {
 	GetUplinkFrameChan() chan gw.UplinkFrame
 
 	// GetConnectChan returns the channel for received gateway connections.
-	GetConnectChan() chan lorawan.EUI64
+	GetConnectChan() chan events.Connection
 
 	// GetDisconnectChan returns the channel for disconnected gateway connections.
-	GetDisconnectChan() chan lorawan.EUI64
+	GetDisconnectChan() chan events.Connection
+
+	// GetUploadChan returns the channel for received uploads.
+	GetUploadChan() chan events.Upload
+
+	// GetConfigurationDiffChan returns the channel for gateway configuration
+	// diffs (dry-run).
+	GetConfigurationDiffChan() chan events.ConfigurationDiff
+
+	// GetProtocolErrorChan returns the channel for rejected gateway messages
+	// (strict mode).
+	GetProtocolErrorChan() chan events.ProtocolError
+
+	// GetCertExpiryChan returns the channel for client certificates which
+	// are about to expire (Basic Station only).
+	GetCertExpiryChan() chan events.CertExpiry
+
+	// GetNotifyChan returns the channel for gateway notifications.
+	GetNotifyChan() chan events.Notify
 
 	// SendDownlinkFrame sends the given downlink frame.
 	SendDownlinkFrame(gw.DownlinkFrame) error
 
 	// ApplyConfiguration applies the given configuration to the gateway.
 	ApplyConfiguration(gw.GatewayConfiguration) error
+
+	// HealthCheck returns an error when the backend is not healthy. Note that
+	// a deadlocked backend might block this call.
+	HealthCheck() error
 }
@@ -0,0 +1,486 @@
+package basicstation
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
+)
+
+// ACME challenge types.
+const (
+	acmeChallengeTLSALPN01 = "tls-alpn-01"
+	acmeChallengeHTTP01    = "http-01"
+	acmeChallengeDNS01     = "dns-01"
+)
+
+const (
+	// acmeAccountKeyFile is the name of the account key file in the cache
+	// directory (the same name as used by autocert).
+	acmeAccountKeyFile = "acme_account+key"
+
+	// acmeTimeout defines the max. duration of obtaining a certificate using
+	// the dns-01 challenge.
+	acmeTimeout = 10 * time.Minute
+
+	// acmeCheckInterval defines the interval in which the expiry of the
+	// dns-01 certificate is checked.
+	acmeCheckInterval = time.Hour
+
+	// acmeRetryInterval defines the interval in which obtaining a dns-01
+	// certificate is retried after a failure.
+	acmeRetryInterval = time.Minute
+)
+
+// acmeManager obtains and renews the TLS certificate of the websocket
+// listener using the ACME protocol (e.g. Let's Encrypt). The tls-alpn-01 and
+// http-01 challenges are handled by autocert, which obtains the certificate
+// on the first TLS handshake. For the dns-01 challenge, the certificate is
+// obtained in the background and the TXT records are published by the
+// configured hook command.
+type acmeManager struct {
+	challenge string
+	domains   []string
+
+	autocert   *autocert.Manager
+	httpServer *http.Server
+
+	client      *acme.Client
+	email       string
+	cacheDir    string
+	hookCommand string
+	renewBefore time.Duration
+
+	mux  sync.RWMutex
+	cert *tls.Certificate
+	done chan struct{}
+}
+
+func newACMEManager(conf config.Config) (*acmeManager, error) {
+	ac := conf.Backend.BasicStation.ACME
+
+	if len(ac.Domains) == 0 {
+		return nil, errors.New("acme requires at least one domain")
+	}
+	if conf.Backend.BasicStation.TLSCert != "" || conf.Backend.BasicStation.TLSKey != "" {
+		return nil, errors.New("acme can not be combined with tls_cert and tls_key")
+	}
+	if ac.CacheDir == "" {
+		return nil, errors.New("acme requires a cache_dir")
+	}
+
+	m := acmeManager{
+		challenge:   ac.Challenge,
+		domains:     ac.Domains,
+		email:       ac.Email,
+		cacheDir:    ac.CacheDir,
+		hookCommand: ac.DNSHookCommand,
+		renewBefore: ac.RenewBefore,
+		done:        make(chan struct{}),
+		client: &acme.Client{
+			DirectoryURL: ac.DirectoryURL,
+			HTTPClient: &http.Client{
+				Transport: proxy.Transport(),
+			},
+		},
+	}
+
+	switch m.challenge {
+	case acmeChallengeTLSALPN01, acmeChallengeHTTP01:
+		m.autocert = &autocert.Manager{
+			Prompt:      autocert.AcceptTOS,
+			Cache:       autocert.DirCache(m.cacheDir),
+			HostPolicy:  autocert.HostWhitelist(m.domains...),
+			Client:      m.client,
+			Email:       m.email,
+			RenewBefore: m.renewBefore,
+		}
+
+		if m.challenge == acmeChallengeHTTP01 {
+			ln, err := net.Listen("tcp", ac.HTTPBind)
+			if err != nil {
+				return nil, errors.Wrap(err, "create acme http-01 listener error")
+			}
+
+			m.httpServer = &http.Server{
+				Handler: m.autocert.HTTPHandler(nil),
+			}
+
+			go func() {
+				log.WithField("bind", ln.Addr()).Info("backend/basicstation: starting acme http-01 listener")
+				if err := m.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+					log.WithError(err).Error("backend/basicstation: acme http-01 server error")
+				}
+			}()
+		}
+	case acmeChallengeDNS01:
+		if m.hookCommand == "" {
+			return nil, errors.New("the acme dns-01 challenge requires a dns_hook_command")
+		}
+
+		if err := os.MkdirAll(m.cacheDir, 0700); err != nil {
+			return nil, errors.Wrap(err, "create acme cache directory error")
+		}
+
+		key, err := m.accountKey()
+		if err != nil {
+			return nil, errors.Wrap(err, "get acme account key error")
+		}
+		m.client.Key = key
+
+		cert, err := m.loadCertificate()
+		if err != nil {
+			return nil, errors.Wrap(err, "load acme certificate error")
+		}
+		m.cert = cert
+
+		go m.renewLoop()
+	default:
+		return nil, errors.Errorf("invalid acme challenge: %s", m.challenge)
+	}
+
+	log.WithFields(log.Fields{
+		"domains":       m.domains,
+		"challenge":     m.challenge,
+		"directory_url": ac.DirectoryURL,
+	}).Info("backend/basicstation: acme certificate management enabled")
+
+	return &m, nil
+}
+
+// configureTLS configures the given TLS config to use the ACME certificate.
+func (m *acmeManager) configureTLS(c *tls.Config) {
+	if m.autocert != nil {
+		c.GetCertificate = m.autocert.GetCertificate
+		c.NextProtos = []string{"http/1.1", acme.ALPNProto}
+		return
+	}
+
+	c.GetCertificate = m.getCertificate
+}
+
+// getCertificate returns the certificate obtained using the dns-01
+// challenge.
+func (m *acmeManager) getCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	if m.cert == nil {
+		return nil, errors.New("acme certificate has not yet been obtained")
+	}
+
+	return m.cert, nil
+}
+
+// close stops the renewal and the http-01 listener.
+func (m *acmeManager) close() error {
+	close(m.done)
+
+	if m.httpServer != nil {
+		return m.httpServer.Close()
+	}
+
+	return nil
+}
+
+// renewLoop obtains the dns-01 certificate when it is missing or when it
+// expires within the configured renewal period.
+func (m *acmeManager) renewLoop() {
+	for {
+		interval := acmeCheckInterval
+
+		if m.needsRenewal(time.Now()) {
+			if err := m.obtainCertificate(); err != nil {
+				log.WithError(err).WithField("domains", m.domains).Error("backend/basicstation: obtain acme certificate error")
+				interval = acmeRetryInterval
+			}
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// needsRenewal returns true when no certificate has been obtained or when
+// the certificate expires within the renewal period.
+func (m *acmeManager) needsRenewal(now time.Time) bool {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+
+	if m.cert == nil || m.cert.Leaf == nil {
+		return true
+	}
+
+	return m.cert.Leaf.NotAfter.Sub(now) < m.renewBefore
+}
+
+// obtainCertificate obtains a new certificate using the dns-01 challenge.
+func (m *acmeManager) obtainCertificate() error {
+	ctx, cancel := context.WithTimeout(context.Background(), acmeTimeout)
+	defer cancel()
+
+	acct := acme.Account{}
+	if m.email != "" {
+		acct.Contact = []string{"mailto:" + m.email}
+	}
+	if _, err := m.client.Register(ctx, &acct, acme.AcceptTOS); err != nil && err != acme.ErrAccountAlreadyExists {
+		return errors.Wrap(err, "register acme account error")
+	}
+
+	order, err := m.client.AuthorizeOrder(ctx, acme.DomainIDs(m.domains...))
+	if err != nil {
+		return errors.Wrap(err, "authorize order error")
+	}
+
+	for _, u := range order.AuthzURLs {
+		if err := m.authorize(ctx, u); err != nil {
+			return err
+		}
+	}
+
+	order, err = m.client.WaitOrder(ctx, order.URI)
+	if err != nil {
+		return errors.Wrap(err, "wait order error")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return errors.Wrap(err, "generate key error")
+	}
+
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:  pkix.Name{CommonName: m.domains[0]},
+		DNSNames: m.domains,
+	}, key)
+	if err != nil {
+		return errors.Wrap(err, "create certificate request error")
+	}
+
+	der, _, err := m.client.CreateOrderCert(ctx, order.FinalizeURL, csr, true)
+	if err != nil {
+		return errors.Wrap(err, "create certificate error")
+	}
+
+	cert, err := newACMECertificate(der, key)
+	if err != nil {
+		return err
+	}
+
+	if err := m.storeCertificate(der, key); err != nil {
+		return errors.Wrap(err, "store acme certificate error")
+	}
+
+	m.mux.Lock()
+	m.cert = cert
+	m.mux.Unlock()
+
+	log.WithFields(log.Fields{
+		"domains":   m.domains,
+		"not_after": cert.Leaf.NotAfter,
+	}).Info("backend/basicstation: acme certificate obtained")
+
+	return nil
+}
+
+// authorize fulfills the dns-01 challenge of the given authorization.
+func (m *acmeManager) authorize(ctx context.Context, authzURL string) error {
+	authz, err := m.client.GetAuthorization(ctx, authzURL)
+	if err != nil {
+		return errors.Wrap(err, "get authorization error")
+	}
+	if authz.Status == acme.StatusValid {
+		return nil
+	}
+
+	var chal *acme.Challenge
+	for _, c := range authz.Challenges {
+		if c.Type == acmeChallengeDNS01 {
+			chal = c
+			break
+		}
+	}
+	if chal == nil {
+		return errors.Errorf("no dns-01 challenge offered for %s", authz.Identifier.Value)
+	}
+
+	record, err := m.client.DNS01ChallengeRecord(chal.Token)
+	if err != nil {
+		return errors.Wrap(err, "dns-01 challenge record error")
+	}
+	name := "_acme-challenge." + authz.Identifier.Value + "."
+
+	if err := m.runHook(ctx, "present", name, record); err != nil {
+		return errors.Wrap(err, "present dns record error")
+	}
+	defer func() {
+		if err := m.runHook(ctx, "cleanup", name, record); err != nil {
+			log.WithError(err).WithField("name", name).Error("backend/basicstation: cleanup acme dns record error")
+		}
+	}()
+
+	if _, err := m.client.Accept(ctx, chal); err != nil {
+		return errors.Wrap(err, "accept challenge error")
+	}
+
+	if _, err := m.client.WaitAuthorization(ctx, authz.URI); err != nil {
+		return errors.Wrapf(err, "wait authorization error (domain: %s)", authz.Identifier.Value)
+	}
+
+	return nil
+}
+
+// runHook executes the dns hook command with the given action ("present"
+// or "cleanup"), record name and record value as arguments.
+func (m *acmeManager) runHook(ctx context.Context, action, name, value string) error {
+	cmdArgs := strings.Fields(m.hookCommand)
+	if len(cmdArgs) == 0 {
+		return errors.New("no command is given")
+	}
+	cmdArgs = append(cmdArgs, action, name, value)
+
+	out, err := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "execution error (output: %s)", out)
+	}
+
+	return nil
+}
+
+// accountKey returns the account key from the cache directory. A new key is
+// generated when it does not exist.
+func (m *acmeManager) accountKey() (crypto.Signer, error) {
+	path := filepath.Join(m.cacheDir, acmeAccountKeyFile)
+
+	b, err := ioutil.ReadFile(path)
+	if err == nil {
+		block, _ := pem.Decode(b)
+		if block == nil {
+			return nil, errors.New("invalid account key pem")
+		}
+		return x509.ParseECPrivateKey(block.Bytes)
+	}
+	if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "read account key error")
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "generate key error")
+	}
+
+	der, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal key error")
+	}
+
+	if err := ioutil.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der}), 0600); err != nil {
+		return nil, errors.Wrap(err, "write account key error")
+	}
+
+	return key, nil
+}
+
+// certificateFile returns the path of the dns-01 certificate in the cache
+// directory.
+func (m *acmeManager) certificateFile() string {
+	return filepath.Join(m.cacheDir, m.domains[0]+"+dns01")
+}
+
+// loadCertificate loads the dns-01 certificate from the cache directory. It
+// returns nil when the certificate does not exist.
+func (m *acmeManager) loadCertificate() (*tls.Certificate, error) {
+	b, err := ioutil.ReadFile(m.certificateFile())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read certificate error")
+	}
+
+	var key crypto.Signer
+	var der [][]byte
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+
+		switch block.Type {
+		case "EC PRIVATE KEY":
+			key, err = x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse private key error")
+			}
+		case "CERTIFICATE":
+			der = append(der, block.Bytes)
+		}
+	}
+
+	if key == nil || len(der) == 0 {
+		return nil, errors.New("certificate file must contain a private key and certificate")
+	}
+
+	return newACMECertificate(der, key)
+}
+
+// storeCertificate stores the given certificate chain and key in the cache
+// directory.
+func (m *acmeManager) storeCertificate(der [][]byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return errors.Wrap(err, "marshal key error")
+	}
+
+	b := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	for _, d := range der {
+		b = append(b, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: d})...)
+	}
+
+	// write to a temporary file first, so that the certificate file is never
+	// partially written
+	tmp := m.certificateFile() + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0600); err != nil {
+		return errors.Wrap(err, "write certificate error")
+	}
+
+	return os.Rename(tmp, m.certificateFile())
+}
+
+func newACMECertificate(der [][]byte, key crypto.Signer) (*tls.Certificate, error) {
+	leaf, err := x509.ParseCertificate(der[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "parse certificate error")
+	}
+
+	return &tls.Certificate{
+		Certificate: der,
+		PrivateKey:  key,
+		Leaf:        leaf,
+	}, nil
+}
@@ -0,0 +1,140 @@
+package basicstation
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func TestNewACMEManagerValidation(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Config        func(*config.Config)
+		ExpectedError string
+	}{
+		{
+			Name:          "no domains",
+			Config:        func(c *config.Config) {},
+			ExpectedError: "acme requires at least one domain",
+		},
+		{
+			Name: "tls cert is set",
+			Config: func(c *config.Config) {
+				c.Backend.BasicStation.ACME.Domains = []string{"lns.example.com"}
+				c.Backend.BasicStation.TLSCert = "cert.pem"
+			},
+			ExpectedError: "acme can not be combined with tls_cert and tls_key",
+		},
+		{
+			Name: "invalid challenge",
+			Config: func(c *config.Config) {
+				c.Backend.BasicStation.ACME.Domains = []string{"lns.example.com"}
+				c.Backend.BasicStation.ACME.Challenge = "foo"
+			},
+			ExpectedError: "invalid acme challenge: foo",
+		},
+		{
+			Name: "dns-01 without hook",
+			Config: func(c *config.Config) {
+				c.Backend.BasicStation.ACME.Domains = []string{"lns.example.com"}
+				c.Backend.BasicStation.ACME.Challenge = "dns-01"
+			},
+			ExpectedError: "the acme dns-01 challenge requires a dns_hook_command",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Backend.BasicStation.ACME.CacheDir = "/tmp"
+			tst.Config(&conf)
+
+			_, err := newACMEManager(conf)
+			assert.EqualError(err, tst.ExpectedError)
+		})
+	}
+}
+
+func TestACMECertificateCache(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "acme")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	m := acmeManager{
+		domains:     []string{"lns.example.com"},
+		cacheDir:    dir,
+		renewBefore: 24 * time.Hour,
+	}
+
+	t.Run("no certificate", func(t *testing.T) {
+		assert := require.New(t)
+
+		cert, err := m.loadCertificate()
+		assert.NoError(err)
+		assert.Nil(cert)
+		assert.True(m.needsRenewal(time.Now()))
+
+		_, err = m.getCertificate(nil)
+		assert.Error(err)
+	})
+
+	t.Run("store and load", func(t *testing.T) {
+		assert := require.New(t)
+
+		notAfter := time.Now().Add(48 * time.Hour).Truncate(time.Second)
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(err)
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "lns.example.com"},
+			DNSNames:     []string{"lns.example.com"},
+			NotBefore:    time.Now(),
+			NotAfter:     notAfter,
+		}, &x509.Certificate{
+			SerialNumber: big.NewInt(1),
+			Subject:      pkix.Name{CommonName: "lns.example.com"},
+		}, &key.PublicKey, key)
+		assert.NoError(err)
+
+		assert.NoError(m.storeCertificate([][]byte{der}, key))
+
+		cert, err := m.loadCertificate()
+		assert.NoError(err)
+		assert.Equal([][]byte{der}, cert.Certificate)
+		assert.Equal("lns.example.com", cert.Leaf.Subject.CommonName)
+		assert.True(notAfter.Equal(cert.Leaf.NotAfter))
+
+		m.cert = cert
+		assert.False(m.needsRenewal(time.Now()))
+		assert.True(m.needsRenewal(time.Now().Add(25 * time.Hour)))
+
+		c, err := m.getCertificate(nil)
+		assert.NoError(err)
+		assert.Equal(cert, c)
+	})
+
+	t.Run("account key", func(t *testing.T) {
+		assert := require.New(t)
+
+		key1, err := m.accountKey()
+		assert.NoError(err)
+		key2, err := m.accountKey()
+		assert.NoError(err)
+		assert.Equal(key1.Public(), key2.Public())
+	})
+}
@@ -1,6 +1,7 @@
 package basicstation
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
@@ -10,6 +11,8 @@ import (
 	"io/ioutil"
 	"net"
 	"net/http"
+	"os"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
@@ -20,27 +23,53 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
 	"github.com/brocaar/lora-gateway-bridge/internal/backend/basicstation/structs"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/configversion"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/downlinkid"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/ringbuffer"
+	"github.com/brocaar/lora-gateway-bridge/internal/beacon"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinktrace"
+	"github.com/brocaar/lora-gateway-bridge/internal/gpstime"
+	"github.com/brocaar/lora-gateway-bridge/internal/keepalive"
+	"github.com/brocaar/lora-gateway-bridge/internal/latency"
+	"github.com/brocaar/lora-gateway-bridge/internal/logcontext"
+	"github.com/brocaar/lora-gateway-bridge/internal/overload"
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
+	"github.com/brocaar/lora-gateway-bridge/internal/quarantine"
+	"github.com/brocaar/lora-gateway-bridge/internal/regional"
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
 	"github.com/brocaar/lorawan/band"
 )
 
-// websocket upgrade parameters
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin:     func(*http.Request) bool { return true },
-}
+// Defaults used when the ping interval or write timeout is not configured.
+const (
+	defaultPingInterval = time.Minute
+	defaultWriteTimeout = time.Second
+)
 
 // Backend implements a Basic Station backend.
 type Backend struct {
 	sync.RWMutex
 
-	ln       net.Listener
-	scheme   string
-	isClosed bool
+	ln           net.Listener
+	routerInfoLn net.Listener
+	scheme       string
+	isClosed     bool
+
+	// upgrader holds the websocket upgrade parameters.
+	upgrader websocket.Upgrader
+
+	// policy holds the origin, host and path policy of the websocket
+	// requests.
+	policy requestPolicy
+
+	// routerURI is the (public) URI of the data endpoint, returned by the
+	// router-info endpoint.
+	routerURI string
 
 	pingInterval time.Duration
 	readTimeout  time.Duration
@@ -51,45 +80,162 @@ type Backend struct {
 	downlinkTXAckChan chan gw.DownlinkTXAck
 	uplinkFrameChan   chan gw.UplinkFrame
 	gatewayStatsChan  chan gw.GatewayStats
+	uploadChan        chan events.Upload
+
+	configurationDiffChan chan events.ConfigurationDiff
+	protocolErrorChan     chan events.ProtocolError
+	certExpiryChan        chan events.CertExpiry
+	notifyChan            chan events.Notify
+
+	uploads uploadHandler
+	muxs    muxsPool
+
+	// routerInfoAuth is set when router-info requests are authorized by an
+	// external authorization service.
+	routerInfoAuth routerInfoAuthorizer
+
+	band          structs.DataRates
+	region        band.Name
+	netIDs        []lorawan.NetID
+	joinEUIs      [][2]lorawan.EUI64
+	frequencyMin  uint32
+	frequencyMax  uint32
+	concentrators []config.BasicStationConcentrator
+	routerConfig  *structs.RouterConfig
+
+	// strict enables the validation of the received messages against the
+	// protocol specification.
+	strict bool
+
+	// duplicateConnection defines the policy for a new connection of an
+	// already connected gateway (reject or takeover).
+	duplicateConnection string
+
+	// certExpiryWarning defines the duration before the expiry of a client
+	// certificate from which a warning is published.
+	certExpiryWarning time.Duration
+
+	// regionalParameters holds the (optional) external regional parameters
+	// file. The configured region and concentrators are kept so that they
+	// are used again when these are removed from the file.
+	regionalParameters struct {
+		file           string
+		reloadInterval time.Duration
+		modTime        time.Time
+		region         band.Name
+		concentrators  []config.BasicStationConcentrator
+	}
 
-	band         band.Band
-	region       band.Name
-	netIDs       []lorawan.NetID
-	joinEUIs     [][2]lorawan.EUI64
-	frequencyMin uint32
-	frequencyMax uint32
-	routerConfig *structs.RouterConfig
+	// downlinkIDs stores the mapping of diid to downlink ID (UUID).
+	downlinkIDs *downlinkid.Store
 
-	// diidMap stores the mapping of diid to UUIDs. This should take ~ 1MB of
-	// memory. Optionaly this could be optimized by letting keys expire after
-	// a given time.
-	diidMap map[uint16][]byte
+	// configVersions stores the last configuration applied per gateway.
+	configVersions *configversion.Store
+
+	// acme manages the TLS certificate of the websocket listener when ACME
+	// is enabled.
+	acme *acmeManager
+
+	// ringBuffer keeps the last raw messages per gateway.
+	ringBuffer *ringbuffer.Buffer
 }
 
 // NewBackend creates a new Backend.
 func NewBackend(conf config.Config) (*Backend, error) {
 	b := Backend{
-		scheme: "ws",
+		scheme:    "ws",
+		routerURI: conf.Backend.BasicStation.RouterInfo.URI,
 
-		gateways: gateways{
-			gateways:       make(map[lorawan.EUI64]gateway),
-			connectChan:    make(chan lorawan.EUI64),
-			disconnectChan: make(chan lorawan.EUI64),
-		},
+		gateways: newGateways(conf.Backend.BasicStation.Shards),
 
 		downlinkTXAckChan: make(chan gw.DownlinkTXAck),
 		uplinkFrameChan:   make(chan gw.UplinkFrame),
 		gatewayStatsChan:  make(chan gw.GatewayStats),
+		uploadChan:        make(chan events.Upload),
+
+		configurationDiffChan: make(chan events.ConfigurationDiff),
+		protocolErrorChan:     make(chan events.ProtocolError),
+		certExpiryChan:        make(chan events.CertExpiry),
+		notifyChan:            make(chan events.Notify),
+
+		uploads: uploadHandler{
+			directory: conf.Backend.BasicStation.Uploads.Directory,
+			url:       conf.Backend.BasicStation.Uploads.URL,
+			client: http.Client{
+				Timeout:   conf.Backend.BasicStation.Uploads.Timeout,
+				Transport: proxy.Transport(),
+			},
+		},
+
+		muxs: muxsPool{
+			muxs:      conf.Backend.BasicStation.RouterInfo.Muxs,
+			dnsSRV:    conf.Backend.BasicStation.RouterInfo.DNSSRV,
+			selection: conf.Backend.BasicStation.RouterInfo.Selection,
+			lookupSRV: net.LookupSRV,
+		},
 
 		pingInterval: conf.Backend.BasicStation.PingInterval,
 		readTimeout:  conf.Backend.BasicStation.ReadTimeout,
 		writeTimeout: conf.Backend.BasicStation.WriteTimeout,
 
-		region:       band.Name(conf.Backend.BasicStation.Region),
-		frequencyMin: conf.Backend.BasicStation.FrequencyMin,
-		frequencyMax: conf.Backend.BasicStation.FrequencyMax,
+		region:        band.Name(conf.Backend.BasicStation.Region),
+		frequencyMin:  conf.Backend.BasicStation.FrequencyMin,
+		frequencyMax:  conf.Backend.BasicStation.FrequencyMax,
+		concentrators: conf.Backend.BasicStation.Concentrators,
+
+		strict:              conf.Backend.BasicStation.Strict,
+		duplicateConnection: conf.Backend.BasicStation.DuplicateConnection,
+		certExpiryWarning:   conf.Backend.BasicStation.CertExpiryWarning,
+
+		policy: newRequestPolicy(
+			conf.Backend.BasicStation.Websocket.PathPrefix,
+			conf.Backend.BasicStation.Websocket.AllowedOrigins,
+			conf.Backend.BasicStation.Websocket.AllowedHosts,
+		),
+
+		ringBuffer: ringbuffer.New(conf.Backend.RingBuffer.Size, conf.Backend.RingBuffer.DumpDirectory),
+	}
+
+	b.upgrader = websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		CheckOrigin:     b.policy.checkOrigin,
+	}
+
+	admin.HandleFunc("/api/backend/ring_buffer", b.ringBuffer.HandleHTTP)
+
+	if b.muxs.enabled() {
+		if err := b.muxs.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if url := conf.Backend.BasicStation.RouterInfo.Authorization.URL; url != "" {
+		b.routerInfoAuth = &httpRouterInfoAuthorizer{
+			url: url,
+			client: http.Client{
+				Timeout:   conf.Backend.BasicStation.RouterInfo.Authorization.Timeout,
+				Transport: proxy.Transport(),
+			},
+		}
+	}
+
+	// a zero ping interval would panic the websocket handler and a zero
+	// read or write timeout would expire immediately
+	if b.pingInterval <= 0 {
+		b.pingInterval = defaultPingInterval
+	}
+	if b.readTimeout <= 0 {
+		b.readTimeout = b.pingInterval + 5*time.Second
+	}
+	if b.writeTimeout <= 0 {
+		b.writeTimeout = defaultWriteTimeout
+	}
 
-		diidMap: make(map[uint16][]byte),
+	switch b.duplicateConnection {
+	case "", duplicateConnectionReject, duplicateConnectionTakeover:
+	default:
+		return nil, fmt.Errorf("unknown duplicate_connection policy: %s", b.duplicateConnection)
 	}
 
 	for _, n := range conf.Filters.NetIDs {
@@ -113,30 +259,71 @@ func NewBackend(conf config.Config) (*Backend, error) {
 	}
 
 	var err error
-	b.band, err = band.GetConfig(b.region, false, lorawan.DwellTimeNoLimit)
+	b.downlinkIDs, err = downlinkid.NewStore(conf.Backend.DownlinkIDs.File, conf.Backend.DownlinkIDs.TTL)
 	if err != nil {
-		return nil, errors.Wrap(err, "get band config error")
+		return nil, errors.Wrap(err, "new downlink id store error")
 	}
 
-	if len(conf.Backend.BasicStation.Concentrators) != 0 {
-		conf, err := structs.GetRouterConfig(b.region, b.netIDs, b.joinEUIs, b.frequencyMin, b.frequencyMax, conf.Backend.BasicStation.Concentrators)
+	b.configVersions, err = configversion.NewStore(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "new configuration version store error")
+	}
+
+	if conf.Backend.BasicStation.RegionalParameters.File != "" {
+		b.regionalParameters.file = conf.Backend.BasicStation.RegionalParameters.File
+		b.regionalParameters.reloadInterval = conf.Backend.BasicStation.RegionalParameters.ReloadInterval
+		b.regionalParameters.region = b.region
+		b.regionalParameters.concentrators = b.concentrators
+
+		if err := b.loadRegionalParameters(); err != nil {
+			return nil, errors.Wrap(err, "load regional parameters error")
+		}
+
+		if b.regionalParameters.reloadInterval != 0 {
+			go b.reloadRegionalParametersLoop()
+		}
+	} else {
+		b.band, err = band.GetConfig(b.region, false, lorawan.DwellTimeNoLimit)
 		if err != nil {
-			return nil, errors.Wrap(err, "get router config error")
+			return nil, errors.Wrap(err, "get band config error")
 		}
 
-		b.routerConfig = &conf
+		if len(b.concentrators) != 0 {
+			conf, err := structs.GetRouterConfig(b.region, b.band, b.netIDs, b.joinEUIs, b.frequencyMin, b.frequencyMax, b.concentrators)
+			if err != nil {
+				return nil, errors.Wrap(err, "get router config error")
+			}
+
+			b.routerConfig = &conf
+		}
 	}
 
 	mux := http.NewServeMux()
 	mux.HandleFunc("/router-info", func(w http.ResponseWriter, r *http.Request) {
 		b.websocketWrap(b.handleRouterInfo, w, r)
 	})
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc(b.policy.gatewayPattern(), func(w http.ResponseWriter, r *http.Request) {
+		if _, err := b.policy.gatewayID(r.URL.Path); err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"url":         r.URL.Path,
+				"remote_addr": r.RemoteAddr,
+			}).Error("backend/basicstation: unable to read gateway id from url")
+			http.NotFound(w, r)
+			return
+		}
+
 		connectCounter().Inc()
 		b.websocketWrap(b.handleGateway, w, r)
 		disconnectCounter().Inc()
 	})
 
+	if conf.Backend.BasicStation.ACME.Enabled {
+		b.acme, err = newACMEManager(conf)
+		if err != nil {
+			return nil, errors.Wrap(err, "setup acme error")
+		}
+	}
+
 	// using net.Listen makes it easier to test as we can bind to ":0" and
 	// then read back the Addr to find the assigned (random) port.
 	b.ln, err = net.Listen("tcp", conf.Backend.BasicStation.Bind)
@@ -144,48 +331,31 @@ func NewBackend(conf config.Config) (*Backend, error) {
 		return nil, errors.Wrap(err, "create listener error")
 	}
 
-	// init HTTP server
-	server := &http.Server{
-		Handler: mux,
+	if conf.Backend.BasicStation.TLSCert != "" || conf.Backend.BasicStation.TLSKey != "" || conf.Backend.BasicStation.CACert != "" || b.acme != nil {
+		b.scheme = "wss"
 	}
 
-	// if the CA cert is configured, setup client certificate verification.
-	if conf.Backend.BasicStation.CACert != "" {
-		rawCACert, err := ioutil.ReadFile(conf.Backend.BasicStation.CACert)
-		if err != nil {
-			return nil, errors.Wrap(err, "read ca cert error")
-		}
+	if err := b.serve("websocket", b.ln, mux, conf.Backend.BasicStation.TLSCert, conf.Backend.BasicStation.TLSKey, conf.Backend.BasicStation.CACert, b.acme); err != nil {
+		return nil, err
+	}
 
-		caCertPool := x509.NewCertPool()
-		caCertPool.AppendCertsFromPEM(rawCACert)
+	// the router-info endpoint can be served by a separate listener, e.g. to
+	// expose it publicly while the data endpoint is kept private
+	if conf.Backend.BasicStation.RouterInfo.Bind != "" {
+		routerInfoMux := http.NewServeMux()
+		routerInfoMux.HandleFunc("/router-info", func(w http.ResponseWriter, r *http.Request) {
+			b.websocketWrap(b.handleRouterInfo, w, r)
+		})
 
-		server.TLSConfig = &tls.Config{
-			ClientCAs:  caCertPool,
-			ClientAuth: tls.RequireAndVerifyClientCert,
+		b.routerInfoLn, err = net.Listen("tcp", conf.Backend.BasicStation.RouterInfo.Bind)
+		if err != nil {
+			return nil, errors.Wrap(err, "create router-info listener error")
 		}
-	}
-
-	go func() {
-		log.WithFields(log.Fields{
-			"bind":     b.ln.Addr(),
-			"tls_cert": conf.Backend.BasicStation.TLSCert,
-			"tls_key":  conf.Backend.BasicStation.TLSKey,
-			"ca_cert":  conf.Backend.BasicStation.CACert,
-		}).Info("backend/basicstation: starting websocket listener")
 
-		if conf.Backend.BasicStation.TLSCert == "" && conf.Backend.BasicStation.TLSKey == "" && conf.Backend.BasicStation.CACert == "" {
-			// no tls
-			if err := server.Serve(b.ln); err != nil && !b.isClosed {
-				log.WithError(err).Fatal("backend/basicstation: server error")
-			}
-		} else {
-			// tls
-			b.scheme = "wss"
-			if err := server.ServeTLS(b.ln, conf.Backend.BasicStation.TLSCert, conf.Backend.BasicStation.TLSKey); err != nil && !b.isClosed {
-				log.WithError(err).Fatal("backend/basicstation: server error")
-			}
+		if err := b.serve("router-info", b.routerInfoLn, routerInfoMux, conf.Backend.BasicStation.RouterInfo.TLSCert, conf.Backend.BasicStation.RouterInfo.TLSKey, conf.Backend.BasicStation.RouterInfo.CACert, nil); err != nil {
+			return nil, err
 		}
-	}()
+	}
 
 	return &b, nil
 }
@@ -202,14 +372,43 @@ func (b *Backend) GetUplinkFrameChan() chan gw.UplinkFrame {
 	return b.uplinkFrameChan
 }
 
-func (b *Backend) GetConnectChan() chan lorawan.EUI64 {
+func (b *Backend) GetConnectChan() chan events.Connection {
 	return b.gateways.connectChan
 }
 
-func (b *Backend) GetDisconnectChan() chan lorawan.EUI64 {
+func (b *Backend) GetDisconnectChan() chan events.Connection {
 	return b.gateways.disconnectChan
 }
 
+// GetUploadChan returns the channel for received uploads.
+func (b *Backend) GetUploadChan() chan events.Upload {
+	return b.uploadChan
+}
+
+// GetConfigurationDiffChan returns the channel for gateway configuration
+// diffs. Dry-run is not supported by this backend, thus nothing is sent to
+// this channel.
+func (b *Backend) GetConfigurationDiffChan() chan events.ConfigurationDiff {
+	return b.configurationDiffChan
+}
+
+// GetProtocolErrorChan returns the channel for the messages rejected in
+// strict mode.
+func (b *Backend) GetProtocolErrorChan() chan events.ProtocolError {
+	return b.protocolErrorChan
+}
+
+// GetCertExpiryChan returns the channel for client certificates which are
+// about to expire.
+func (b *Backend) GetCertExpiryChan() chan events.CertExpiry {
+	return b.certExpiryChan
+}
+
+// GetNotifyChan returns the channel for gateway notifications.
+func (b *Backend) GetNotifyChan() chan events.Notify {
+	return b.notifyChan
+}
+
 func (b *Backend) SendDownlinkFrame(df gw.DownlinkFrame) error {
 	b.Lock()
 	defer b.Unlock()
@@ -234,14 +433,37 @@ func (b *Backend) SendDownlinkFrame(df gw.DownlinkFrame) error {
 	copy(gatewayID[:], df.GetTxInfo().GetGatewayId())
 	copy(downID[:], df.GetDownlinkId())
 
+	// The context holds the rctx and xtime of the uplink, of which the xtime
+	// encodes the radio unit and session of the concentrator board that
+	// received it. These are used as-is. When there is no context (e.g.
+	// class-C), the board is used to select the radio unit.
+	if pl.XTime != nil {
+		radioUnit := structs.XTimeRadioUnit(*pl.XTime)
+		session, err := b.gateways.getXTimeSession(gatewayID, radioUnit)
+		if err == nil && session != structs.XTimeSession(*pl.XTime) {
+			log.WithFields(log.Fields{
+				"gateway_id": gatewayID,
+				"radio_unit": radioUnit,
+				"session":    structs.XTimeSession(*pl.XTime),
+			}).Warning("backend/basicstation: downlink context xtime session is outdated")
+		}
+	} else if board := df.GetTxInfo().GetBoard(); board != 0 {
+		rctx := uint64(board)
+		pl.RCtx = &rctx
+	}
+
 	// store token to UUID mapping
-	b.diidMap[uint16(df.Token)] = df.GetDownlinkId()
+	b.downlinkIDs.Set(gatewayID, uint16(df.Token), df.GetDownlinkId())
+
+	downlinktrace.Stage(gatewayID[:], df.Token, downlinktrace.StageConvert)
 
 	websocketSendCounter("dnmsg").Inc()
 	if err := b.sendToGateway(gatewayID, pl); err != nil {
 		return errors.Wrap(err, "send to gateway error")
 	}
 
+	downlinktrace.Stage(gatewayID[:], df.Token, downlinktrace.StageWrite)
+
 	log.WithFields(log.Fields{
 		"gateway_id":  gatewayID,
 		"downlink_id": downID,
@@ -251,11 +473,17 @@ func (b *Backend) SendDownlinkFrame(df gw.DownlinkFrame) error {
 }
 
 func (b *Backend) ApplyConfiguration(gwConfig gw.GatewayConfiguration) error {
-	rc, err := structs.GetRouterConfigOld(b.region, b.netIDs, b.joinEUIs, b.frequencyMin, b.frequencyMax, gwConfig)
+	b.RLock()
+	rc, err := structs.GetRouterConfigOld(b.region, b.band, b.netIDs, b.joinEUIs, b.frequencyMin, b.frequencyMax, gwConfig)
+	b.RUnlock()
 	if err != nil {
 		return errors.Wrap(err, "get router config error")
 	}
 
+	if beacon.Enabled() {
+		rc.SetBeaconing(beacon.GetConfiguration())
+	}
+
 	var gatewayID lorawan.EUI64
 	copy(gatewayID[:], gwConfig.GetGatewayId())
 
@@ -266,15 +494,137 @@ func (b *Backend) ApplyConfiguration(gwConfig gw.GatewayConfiguration) error {
 
 	log.WithField("gateway_id", gatewayID).Info("backend/basicstation: router-config message sent to gateway")
 
+	if err := b.configVersions.Set(gwConfig); err != nil {
+		return errors.Wrap(err, "store configuration version error")
+	}
+
+	return nil
+}
+
+// RunCommand instructs the gateway to run the given command in the
+// background, using the runcmd message.
+func (b *Backend) RunCommand(gatewayID lorawan.EUI64, command string, args []string) error {
+	pl := structs.RunCommand{
+		MessageType: structs.RunCommandMessage,
+		Command:     command,
+		Arguments:   args,
+	}
+
+	websocketSendCounter("runcmd").Inc()
+	if err := b.sendToGateway(gatewayID, pl); err != nil {
+		return errors.Wrap(err, "send runcmd to gateway error")
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"command":    command,
+		"arguments":  args,
+	}).Info("backend/basicstation: runcmd message sent to gateway")
+
+	return nil
+}
+
+// serve serves the given handler on the given listener. TLS is used when
+// the TLS certificate or CA certificate is set, or when the certificate is
+// managed using ACME. When the CA certificate is set, client certificates
+// are verified using this CA certificate.
+func (b *Backend) serve(name string, ln net.Listener, handler http.Handler, tlsCert, tlsKey, caCert string, certs *acmeManager) error {
+	// init HTTP server
+	server := &http.Server{
+		Handler: handler,
+	}
+
+	// if the CA cert is configured, setup client certificate verification.
+	if caCert != "" {
+		rawCACert, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return errors.Wrap(err, "read ca cert error")
+		}
+
+		caCertPool := x509.NewCertPool()
+		caCertPool.AppendCertsFromPEM(rawCACert)
+
+		server.TLSConfig = &tls.Config{
+			ClientCAs:  caCertPool,
+			ClientAuth: tls.RequireAndVerifyClientCert,
+		}
+	}
+
+	if certs != nil {
+		if server.TLSConfig == nil {
+			server.TLSConfig = &tls.Config{}
+		}
+		certs.configureTLS(server.TLSConfig)
+	}
+
+	go func() {
+		log.WithFields(log.Fields{
+			"bind":     ln.Addr(),
+			"tls_cert": tlsCert,
+			"tls_key":  tlsKey,
+			"ca_cert":  caCert,
+			"acme":     certs != nil,
+		}).Infof("backend/basicstation: starting %s listener", name)
+
+		if tlsCert == "" && tlsKey == "" && caCert == "" && certs == nil {
+			// no tls
+			if err := server.Serve(ln); err != nil && !b.isClosed {
+				log.WithError(err).Fatal("backend/basicstation: server error")
+			}
+		} else {
+			// tls
+			if err := server.ServeTLS(ln, tlsCert, tlsKey); err != nil && !b.isClosed {
+				log.WithError(err).Fatal("backend/basicstation: server error")
+			}
+		}
+	}()
+
 	return nil
 }
 
 // Close closes the backend.
 func (b *Backend) Close() error {
 	b.isClosed = true
+
+	if b.routerInfoLn != nil {
+		if err := b.routerInfoLn.Close(); err != nil {
+			return errors.Wrap(err, "close router-info listener error")
+		}
+	}
+
+	if err := b.downlinkIDs.Close(); err != nil {
+		return errors.Wrap(err, "close downlink id store error")
+	}
+
+	if err := b.configVersions.Close(); err != nil {
+		return errors.Wrap(err, "close configuration version store error")
+	}
+
+	if b.acme != nil {
+		if err := b.acme.close(); err != nil {
+			return errors.Wrap(err, "close acme error")
+		}
+	}
+
 	return b.ln.Close()
 }
 
+// HealthCheck returns an error when the backend is closed. It acquires the
+// backend and gateway registry locks, thus it blocks when one of the
+// websocket handlers is deadlocked.
+func (b *Backend) HealthCheck() error {
+	if b.isClosed {
+		return errors.New("backend is closed")
+	}
+
+	b.RLock()
+	b.RUnlock()
+
+	b.gateways.lock()
+
+	return nil
+}
+
 func (b *Backend) handleRouterInfo(r *http.Request, c *websocket.Conn) {
 	websocketReceiveCounter("router_info").Inc()
 	var req structs.RouterInfoRequest
@@ -289,19 +639,29 @@ func (b *Backend) handleRouterInfo(r *http.Request, c *websocket.Conn) {
 	resp := structs.RouterInfoResponse{
 		Router: req.Router,
 		Muxs:   req.Router,
-		URI:    fmt.Sprintf("%s://%s/gateway/%s", b.scheme, r.Host, lorawan.EUI64(req.Router)),
+		URI:    b.policy.gatewayURI(b.getRouterURI(r), lorawan.EUI64(req.Router)),
 	}
 
-	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
-		var cn lorawan.EUI64
+	if b.muxs.enabled() {
+		muxs, err := b.muxs.get(b.scheme, lorawan.EUI64(req.Router))
+		if err != nil {
+			log.WithError(err).WithField("gateway_id", lorawan.EUI64(req.Router)).Error("backend/basicstation: get muxs from pool error, returning own uri")
+		} else {
+			resp.URI = b.policy.gatewayURI(muxs, lorawan.EUI64(req.Router))
+		}
+	}
 
-		if err := cn.UnmarshalText([]byte(r.TLS.PeerCertificates[0].Subject.CommonName)); err != nil || cn != lorawan.EUI64(req.Router) {
+	if cert := peerCertificate(r); cert != nil {
+		if err := verifyClientCertificate(cert, lorawan.EUI64(req.Router)); err != nil {
 			resp.URI = ""
-			resp.Error = fmt.Sprintf("certificate CommonName %s does not match router %s",
-				r.TLS.PeerCertificates[0].Subject.CommonName, lorawan.EUI64(req.Router))
+			resp.Error = err.Error()
 		}
 	}
 
+	if b.routerInfoAuth != nil && resp.Error == "" {
+		b.authorizeRouterInfo(r, &resp)
+	}
+
 	c.SetWriteDeadline(time.Now().Add(b.writeTimeout))
 	if err := c.WriteJSON(resp); err != nil {
 		log.WithError(err).Error("backend/basicstation: websocket send message error")
@@ -315,97 +675,206 @@ func (b *Backend) handleRouterInfo(r *http.Request, c *websocket.Conn) {
 	}).Info("backend/basicstation: router-info request received")
 }
 
+// authorizeRouterInfo consults the authorization service and updates the
+// given response accordingly. When the authorization service can't be
+// reached, the request is rejected.
+func (b *Backend) authorizeRouterInfo(r *http.Request, resp *structs.RouterInfoResponse) {
+	gatewayID := lorawan.EUI64(resp.Router)
+
+	authResp, err := b.routerInfoAuth.authorize(routerInfoAuthRequest{
+		GatewayID:         gatewayID,
+		RemoteAddr:        r.RemoteAddr,
+		URI:               resp.URI,
+		ClientCertificate: newRouterInfoAuthCertificate(peerCertificate(r)),
+	})
+	if err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/basicstation: router-info authorization error")
+		routerInfoAuthCounter("error").Inc()
+		resp.URI = ""
+		resp.Error = "authorization error"
+		return
+	}
+
+	if authResp.Error != "" {
+		routerInfoAuthCounter("rejected").Inc()
+		resp.URI = ""
+		resp.Error = authResp.Error
+		return
+	}
+
+	routerInfoAuthCounter("authorized").Inc()
+	if authResp.URI != "" {
+		resp.URI = authResp.URI
+	}
+}
+
+// getRouterURI returns the base URI of the data endpoint. When not
+// configured, the host of the router-info request is used. In case the
+// router-info request was received by the separate router-info listener,
+// the port of the data endpoint is used.
+func (b *Backend) getRouterURI(r *http.Request) string {
+	if b.routerURI != "" {
+		return strings.TrimSuffix(b.routerURI, "/")
+	}
+
+	host := r.Host
+	if b.routerInfoLn != nil {
+		if h, _, err := net.SplitHostPort(r.Host); err == nil {
+			host = h
+		}
+
+		if addr, ok := b.ln.Addr().(*net.TCPAddr); ok {
+			host = net.JoinHostPort(host, strconv.Itoa(addr.Port))
+		}
+	}
+
+	return fmt.Sprintf("%s://%s", b.scheme, host)
+}
+
 func (b *Backend) handleGateway(r *http.Request, c *websocket.Conn) {
 	// get the gateway id from the url
-	urlParts := strings.Split(r.URL.Path, "/")
-	if len(urlParts) < 2 {
-		log.WithField("url", r.URL.Path).Error("backend/basicstation: unable to read gateway id from url")
+	ctx := logcontext.WithBackend(context.Background(), "basic_station")
+	ctx = logcontext.WithRemoteAddr(ctx, r.RemoteAddr)
+
+	gatewayID, err := b.policy.gatewayID(r.URL.Path)
+	if err != nil {
+		log.WithContext(ctx).WithError(err).WithField("url", r.URL.Path).Error("backend/basicstation: unable to read gateway id from url")
 		return
 	}
-
-	var gatewayID lorawan.EUI64
-	if err := gatewayID.UnmarshalText([]byte(urlParts[len(urlParts)-1])); err != nil {
-		log.WithError(err).Error("backend/basicstation: parse gateway id error")
+	ctx = logcontext.WithGatewayID(ctx, gatewayID)
+
+	// reject the connection with a retry-after hint when overloaded
+	if overload.RejectConnection() {
+		log.WithContext(ctx).Warning("backend/basicstation: overload mode active, rejecting connection")
+		msg := websocket.FormatCloseMessage(websocket.CloseTryAgainLater, overload.RetryAfterReason())
+		if err := c.WriteControl(websocket.CloseMessage, msg, time.Now().Add(b.writeTimeout)); err != nil {
+			log.WithContext(ctx).WithError(err).Debug("backend/basicstation: send close message error")
+		}
 		return
 	}
 
-	if r.TLS != nil && len(r.TLS.PeerCertificates) > 0 {
-		var cn lorawan.EUI64
-		if err := cn.UnmarshalText([]byte(r.TLS.PeerCertificates[0].Subject.CommonName)); err != nil || cn != gatewayID {
-			log.WithFields(log.Fields{
-				"gateway_id":  gatewayID,
-				"common_name": r.TLS.PeerCertificates[0].Subject.CommonName,
-			}).Error("backend/basicstation: CommonName verification failed")
+	var clientCert *clientCertificate
+	if cert := peerCertificate(r); cert != nil {
+		if err := verifyClientCertificate(cert, gatewayID); err != nil {
+			log.WithContext(ctx).WithError(err).WithFields(log.Fields{
+				"common_name": cert.Subject.CommonName,
+				"dns_names":   cert.DNSNames,
+			}).Error("backend/basicstation: client certificate verification failed")
 			return
 		}
+
+		c := newClientCertificate(cert)
+		clientCert = &c
 	}
 
-	// make sure we're not overwriting an existing connection
-	_, err := b.gateways.get(gatewayID)
-	if err == nil {
-		log.WithField("gateway_id", gatewayID).Error("backend/basicstation: connection with same gateway id already exists")
+	// set the gateway connection, making sure we're not overwriting an
+	// existing connection unless it must be taken over
+	conn := gateway{conn: c, clientCert: clientCert, configVersion: b.configVersions.Version(gatewayID)}
+	if b.duplicateConnection == duplicateConnectionTakeover {
+		if old := b.gateways.swap(gatewayID, conn); old != nil {
+			b.takeoverSession(ctx, gatewayID, old)
+		}
+	} else if err := b.gateways.setIfAbsent(gatewayID, conn); err != nil {
+		log.WithContext(ctx).Error("backend/basicstation: connection with same gateway id already exists")
 		return
 	}
+	log.WithContext(ctx).Info("backend/basicstation: gateway connected")
 
-	// set the gateway connection
-	if err := b.gateways.set(gatewayID, gateway{conn: c}); err != nil {
-		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/basicstation: set gateway error")
+	if clientCert != nil {
+		b.checkCertExpiry(gatewayID, *clientCert)
 	}
-	log.WithFields(log.Fields{
-		"gateway_id":  gatewayID,
-		"remote_addr": r.RemoteAddr,
-	}).Info("backend/basicstation: gateway connected")
 
 	// remove the gateway on return
+	disconnectReason := events.ReasonClose
+	var closeCode int
 	defer func() {
-		b.gateways.remove(gatewayID)
-		log.WithFields(log.Fields{
-			"gateway_id":  gatewayID,
-			"remote_addr": r.RemoteAddr,
+		b.gateways.remove(gatewayID, c, disconnectReason, closeCode)
+		log.WithContext(ctx).WithFields(log.Fields{
+			"reason":     disconnectReason,
+			"close_code": closeCode,
 		}).Info("backend/basicstation: gateway disconnected")
 	}()
 
+	// register the pong messages as keepalives of this gateway
+	c.SetPongHandler(func(data string) error {
+		websocketPingPongCounter("pong").Inc()
+		c.SetReadDeadline(time.Now().Add(b.readTimeout))
+		keepalive.Seen(gatewayID)
+
+		rtt, err := pingPongRTT([]byte(data), time.Now())
+		if err != nil {
+			log.WithContext(ctx).WithError(err).Debug("backend/basicstation: get ping/pong round-trip time error")
+			return nil
+		}
+		websocketPingPongRTTHistogram(gatewayID.String()).Observe(rtt.Seconds())
+		latency.Observe(gatewayID, rtt)
+
+		return nil
+	})
+
 	// receive data
 	for {
-		_, msg, err := c.ReadMessage()
+		wsMsgType, msg, err := c.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				log.WithField("gateway_id", gatewayID).WithError(err).Error("backend/basicstation: read message error")
+				log.WithContext(ctx).WithError(err).Error("backend/basicstation: read message error")
+			}
+
+			if closeErr, ok := err.(*websocket.CloseError); ok {
+				closeCode = closeErr.Code
+			} else if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				disconnectReason = events.ReasonTimeout
 			}
 			return
 		}
 
 		// reset the read deadline as the Basic Station doesn't respond to PONG messages (yet)
 		c.SetReadDeadline(time.Now().Add(b.readTimeout))
+		keepalive.Seen(gatewayID)
 
-		log.WithFields(log.Fields{
-			"gateway_id": gatewayID,
-			"message":    string(msg),
+		// binary messages are used for uploads (e.g. logs or diagnostics)
+		if wsMsgType == websocket.BinaryMessage {
+			websocketReceiveCounter("binary").Inc()
+			b.handleUpload(gatewayID, msg)
+			continue
+		}
+
+		b.ringBuffer.Add(gatewayID, ringbuffer.DirectionUp, msg, time.Now())
+
+		log.WithContext(ctx).WithFields(log.Fields{
+			"message": string(msg),
 		}).Debug("backend/basicstation: message received")
 
 		// get message-type
 		msgType, err := structs.GetMessageType(msg)
 		if err != nil {
-			log.WithFields(log.Fields{
-				"gateway_id": gatewayID,
-				"payload":    string(msg),
+			log.WithContext(ctx).WithFields(log.Fields{
+				"payload": string(msg),
 			}).WithError(err).Error("backend/basicstation: get message-type error")
 			continue
 		}
+		msgCtx := logcontext.WithMessageType(ctx, string(msgType))
 
 		websocketReceiveCounter(string(msgType)).Inc()
 
+		if b.strict {
+			if err := b.validateMessage(gatewayID, msgType, msg); err != nil {
+				b.rejectMessage(gatewayID, msgType, msg, err)
+				continue
+			}
+		}
+
 		// handle message-type
 		switch msgType {
 		case structs.VersionMessage:
 			// handle version
 			var pl structs.Version
 			if err := json.Unmarshal(msg, &pl); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"message_type": msgType,
-					"gateway_id":   gatewayID,
-					"payload":      string(msg),
+				log.WithContext(msgCtx).WithError(err).WithFields(log.Fields{
+					"payload": string(msg),
 				}).Error("backend/basicstation: unmarshal json message error")
+				b.dumpRingBuffer(gatewayID)
+				quarantine.Error(gatewayID)
 				continue
 			}
 			b.handleVersion(gatewayID, pl)
@@ -413,11 +882,11 @@ func (b *Backend) handleGateway(r *http.Request, c *websocket.Conn) {
 			// handle uplink
 			var pl structs.UplinkDataFrame
 			if err := json.Unmarshal(msg, &pl); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"message_type": msgType,
-					"gateway_id":   gatewayID,
-					"payload":      string(msg),
+				log.WithContext(msgCtx).WithError(err).WithFields(log.Fields{
+					"payload": string(msg),
 				}).Error("backend/basicstation: unmarshal json message error")
+				b.dumpRingBuffer(gatewayID)
+				quarantine.Error(gatewayID)
 				continue
 			}
 			b.handleUplinkDataFrame(gatewayID, pl)
@@ -425,11 +894,11 @@ func (b *Backend) handleGateway(r *http.Request, c *websocket.Conn) {
 			// handle join-request
 			var pl structs.JoinRequest
 			if err := json.Unmarshal(msg, &pl); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"message_type": msgType,
-					"gateway_id":   gatewayID,
-					"payload":      string(msg),
+				log.WithContext(msgCtx).WithError(err).WithFields(log.Fields{
+					"payload": string(msg),
 				}).Error("backend/basicstation: unmarshal json message error")
+				b.dumpRingBuffer(gatewayID)
+				quarantine.Error(gatewayID)
 				continue
 			}
 			b.handleJoinRequest(gatewayID, pl)
@@ -437,11 +906,11 @@ func (b *Backend) handleGateway(r *http.Request, c *websocket.Conn) {
 			// handle proprietary uplink
 			var pl structs.UplinkProprietaryFrame
 			if err := json.Unmarshal(msg, &pl); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"message_type": msgType,
-					"gateway_id":   gatewayID,
-					"payload":      string(msg),
+				log.WithContext(msgCtx).WithError(err).WithFields(log.Fields{
+					"payload": string(msg),
 				}).Error("backend/basicstation: unmarshal json message error")
+				b.dumpRingBuffer(gatewayID)
+				quarantine.Error(gatewayID)
 				continue
 			}
 			b.handleProprietaryDataFrame(gatewayID, pl)
@@ -449,24 +918,84 @@ func (b *Backend) handleGateway(r *http.Request, c *websocket.Conn) {
 			// handle downlink transmitted
 			var pl structs.DownlinkTransmitted
 			if err := json.Unmarshal(msg, &pl); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"message_type": msgType,
-					"gateway_id":   gatewayID,
-					"payload":      string(msg),
+				log.WithContext(msgCtx).WithError(err).WithFields(log.Fields{
+					"payload": string(msg),
 				}).Error("backend/basicstation: unmarshal json message error")
+				b.dumpRingBuffer(gatewayID)
+				quarantine.Error(gatewayID)
 				continue
 			}
 			b.handleDownlinkTransmittedMessage(gatewayID, pl)
+		case structs.TimeSyncMessage:
+			// handle timesync
+			var pl structs.TimeSyncRequest
+			if err := json.Unmarshal(msg, &pl); err != nil {
+				log.WithContext(msgCtx).WithError(err).WithFields(log.Fields{
+					"payload": string(msg),
+				}).Error("backend/basicstation: unmarshal json message error")
+				b.dumpRingBuffer(gatewayID)
+				quarantine.Error(gatewayID)
+				continue
+			}
+			b.handleTimeSync(gatewayID, pl)
+		case structs.RemoteShellMessage:
+			// remote shell sessions are not initiated by the bridge, the
+			// (binary) output is handled as upload
+			log.WithContext(msgCtx).WithFields(log.Fields{
+				"payload": string(msg),
+			}).Info("backend/basicstation: remote shell message received")
 		default:
-			log.WithFields(log.Fields{
-				"message_type": msgType,
-				"gateway_id":   gatewayID,
-				"payload":      string(msg),
+			log.WithContext(msgCtx).WithFields(log.Fields{
+				"payload": string(msg),
 			}).Warning("backend/basicstation: unexpected message-type")
 		}
 	}
 }
 
+// validateMessage validates the given message against the protocol
+// specification. For uplinks, it also validates that the xtime is monotonic.
+func (b *Backend) validateMessage(gatewayID lorawan.EUI64, msgType structs.MessageType, msg []byte) error {
+	if err := structs.Validate(msgType, msg); err != nil {
+		return err
+	}
+
+	switch msgType {
+	case structs.UplinkDataFrameMessage, structs.JoinRequestMessage, structs.ProprietaryDataFrameMessage:
+		var rmd structs.RadioMetaData
+		if err := json.Unmarshal(msg, &rmd); err != nil {
+			return errors.Wrap(err, "unmarshal json error")
+		}
+
+		if err := b.gateways.checkXTime(gatewayID, rmd.UpInfo.XTime); err != nil {
+			return errors.Wrap(err, "upinfo.xtime")
+		}
+	}
+
+	return nil
+}
+
+// rejectMessage rejects the given message and publishes it as protocol
+// error. The Basic Station protocol does not define a message to NACK a
+// received message.
+func (b *Backend) rejectMessage(gatewayID lorawan.EUI64, msgType structs.MessageType, msg []byte, err error) {
+	websocketRejectCounter(string(msgType)).Inc()
+	b.dumpRingBuffer(gatewayID)
+	quarantine.Error(gatewayID)
+
+	log.WithError(err).WithFields(log.Fields{
+		"message_type": msgType,
+		"gateway_id":   gatewayID,
+		"payload":      string(msg),
+	}).Warning("backend/basicstation: message rejected")
+
+	b.protocolErrorChan <- events.ProtocolError{
+		GatewayID:   gatewayID,
+		MessageType: string(msgType),
+		Error:       err.Error(),
+		Payload:     msg,
+	}
+}
+
 func (b *Backend) handleVersion(gatewayID lorawan.EUI64, pl structs.Version) {
 	log.WithFields(log.Fields{
 		"gateway_id": gatewayID,
@@ -490,20 +1019,45 @@ func (b *Backend) handleVersion(gatewayID lorawan.EUI64, pl structs.Version) {
 		return
 	}
 
+	b.RLock()
+	routerConfig := b.routerConfig
+	b.RUnlock()
+
 	// TODO: remove this in the next major release
-	if b.routerConfig == nil {
-		b.gatewayStatsChan <- gw.GatewayStats{
+	if routerConfig == nil {
+		// re-apply the last configuration, as the gateway does not persist it
+		gwConfig, err := b.configVersions.Configuration(gatewayID)
+		if err != nil {
+			log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/basicstation: get last configuration error")
+		} else if gwConfig != nil {
+			if err := b.ApplyConfiguration(*gwConfig); err != nil {
+				log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/basicstation: re-apply last configuration error")
+			}
+		}
+
+		stats := gw.GatewayStats{
 			GatewayId:     gatewayID[:],
 			Ip:            g.conn.RemoteAddr().String(),
 			Time:          ts,
 			ConfigVersion: g.configVersion,
 		}
 
+		if g.clientCert != nil {
+			stats.MetaData = g.clientCert.metaData()
+		}
+
+		b.gatewayStatsChan <- stats
+
 		return
 	}
 
+	rc := *routerConfig
+	if beacon.Enabled() {
+		rc.SetBeaconing(beacon.GetConfiguration())
+	}
+
 	websocketSendCounter("router_config").Inc()
-	if err := b.sendToGateway(gatewayID, *b.routerConfig); err != nil {
+	if err := b.sendToGateway(gatewayID, rc); err != nil {
 		log.WithError(err).Error("backend/basicstation: send to gateway error")
 		return
 	}
@@ -512,57 +1066,35 @@ func (b *Backend) handleVersion(gatewayID lorawan.EUI64, pl structs.Version) {
 }
 
 func (b *Backend) handleJoinRequest(gatewayID lorawan.EUI64, v structs.JoinRequest) {
-	uplinkFrame, err := structs.JoinRequestToProto(b.band, gatewayID, v)
-	if err != nil {
-		log.WithError(err).WithFields(log.Fields{
-			"gateway_id": gatewayID,
-		}).Error("backend/basicstation: error converting join-request to protobuf message")
-		return
-	}
+	b.storeXTimeSession(gatewayID, v.RadioMetaData)
 
-	// set uplink id
-	uplinkID, err := uuid.NewV4()
+	uplinkFrame, err := structs.JoinRequestToProto(b.getBand(), gatewayID, v)
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
 			"gateway_id": gatewayID,
-		}).Error("backend/basicstation: get random uplink id error")
+		}).Error("backend/basicstation: error converting join-request to protobuf message")
+		b.dumpRingBuffer(gatewayID)
+		quarantine.Error(gatewayID)
 		return
 	}
-	uplinkFrame.RxInfo.UplinkId = uplinkID[:]
-
-	log.WithFields(log.Fields{
-		"gateway_id": gatewayID,
-		"uplink_id":  uplinkID,
-	}).Info("backend/basicstation: join-request received")
 
-	b.uplinkFrameChan <- uplinkFrame
+	b.forwardUplinkFrames(gatewayID, uplinkFrame, v.RadioMetaData, "join-request")
 }
 
 func (b *Backend) handleProprietaryDataFrame(gatewayID lorawan.EUI64, v structs.UplinkProprietaryFrame) {
-	uplinkFrame, err := structs.UplinkProprietaryFrameToProto(b.band, gatewayID, v)
-	if err != nil {
-		log.WithError(err).WithFields(log.Fields{
-			"gateway_id": gatewayID,
-		}).Error("backend/basicstation: error converting proprietary uplink to protobuf message")
-		return
-	}
+	b.storeXTimeSession(gatewayID, v.RadioMetaData)
 
-	// set uplink id
-	uplinkID, err := uuid.NewV4()
+	uplinkFrame, err := structs.UplinkProprietaryFrameToProto(b.getBand(), gatewayID, v)
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
 			"gateway_id": gatewayID,
-		}).Error("backend/basicstation: get random uplink id error")
+		}).Error("backend/basicstation: error converting proprietary uplink to protobuf message")
+		b.dumpRingBuffer(gatewayID)
+		quarantine.Error(gatewayID)
 		return
 	}
-	uplinkFrame.RxInfo.UplinkId = uplinkID[:]
-
-	log.WithFields(log.Fields{
-		"gateway_id": gatewayID,
-		"uplink_id":  uplinkID,
-	}).Info("backend/basicstation: proprietary uplink frame received")
 
-	b.uplinkFrameChan <- uplinkFrame
+	b.forwardUplinkFrames(gatewayID, uplinkFrame, v.RadioMetaData, "proprietary uplink frame")
 }
 
 func (b *Backend) handleDownlinkTransmittedMessage(gatewayID lorawan.EUI64, v structs.DownlinkTransmitted) {
@@ -574,9 +1106,11 @@ func (b *Backend) handleDownlinkTransmittedMessage(gatewayID lorawan.EUI64, v st
 		log.WithError(err).WithFields(log.Fields{
 			"gateway_id": gatewayID,
 		}).Error("backend/basicstation: error converting downlink transmitted to protobuf message")
+		b.dumpRingBuffer(gatewayID)
+		quarantine.Error(gatewayID)
 		return
 	}
-	txack.DownlinkId = b.diidMap[uint16(v.DIID)]
+	txack.DownlinkId = b.downlinkIDs.Get(gatewayID, uint16(v.DIID))
 
 	var downID uuid.UUID
 	copy(downID[:], txack.GetDownlinkId())
@@ -590,48 +1124,248 @@ func (b *Backend) handleDownlinkTransmittedMessage(gatewayID lorawan.EUI64, v st
 }
 
 func (b *Backend) handleUplinkDataFrame(gatewayID lorawan.EUI64, v structs.UplinkDataFrame) {
-	uplinkFrame, err := structs.UplinkDataFrameToProto(b.band, gatewayID, v)
+	b.storeXTimeSession(gatewayID, v.RadioMetaData)
+
+	uplinkFrame, err := structs.UplinkDataFrameToProto(b.getBand(), gatewayID, v)
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
 			"gateway_id": gatewayID,
 		}).Error("backend/basicstation: error converting uplink frame to protobuf message")
+		b.dumpRingBuffer(gatewayID)
+		quarantine.Error(gatewayID)
 		return
 	}
 
-	// set uplink id
-	uplinkID, err := uuid.NewV4()
+	b.forwardUplinkFrames(gatewayID, uplinkFrame, v.RadioMetaData, "uplink frame")
+}
+
+func (b *Backend) handleTimeSync(gatewayID lorawan.EUI64, v structs.TimeSyncRequest) {
+	now := time.Now()
+
+	// correct the local time when the GPS time is known through GPS-equipped
+	// gateways
+	if offset, ok := gpstime.Offset(); ok {
+		now = now.Add(offset)
+	}
+
+	resp := structs.TimeSyncResponse{
+		MessageType: structs.TimeSyncMessage,
+		TxTime:      v.TxTime,
+		GPSTime:     timeSinceGPSEpoch(now),
+	}
+
+	websocketSendCounter("timesync").Inc()
+	if err := b.sendToGateway(gatewayID, resp); err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/basicstation: send timesync response error")
+	}
+}
+
+func (b *Backend) handleUpload(gatewayID lorawan.EUI64, msg []byte) {
+	if !b.uploads.enabled() {
+		log.WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"size":       len(msg),
+		}).Warning("backend/basicstation: binary message received but uploads are not configured")
+		return
+	}
+
+	uploadID, err := uuid.NewV4()
+	if err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/basicstation: get random upload id error")
+		return
+	}
+
+	location, err := b.uploads.store(gatewayID, uploadID, msg, time.Now())
 	if err != nil {
 		log.WithError(err).WithFields(log.Fields{
 			"gateway_id": gatewayID,
-		}).Error("backend/basicstation: get random uplink id error")
+			"upload_id":  uploadID,
+		}).Error("backend/basicstation: store upload error")
 		return
 	}
-	uplinkFrame.RxInfo.UplinkId = uplinkID[:]
 
 	log.WithFields(log.Fields{
 		"gateway_id": gatewayID,
-		"uplink_id":  uplinkID,
-	}).Info("backend/basicstation: uplink frame received")
+		"upload_id":  uploadID,
+		"location":   location,
+		"size":       len(msg),
+	}).Info("backend/basicstation: upload stored")
+
+	b.uploadChan <- events.Upload{
+		GatewayID: gatewayID,
+		UploadID:  uploadID,
+		Location:  location,
+		Size:      len(msg),
+	}
+}
+
+// forwardUplinkFrames forwards the given uplink frame, once for each antenna
+// in case the gateway reported the signal information per antenna.
+func (b *Backend) forwardUplinkFrames(gatewayID lorawan.EUI64, uplinkFrame gw.UplinkFrame, rmd structs.RadioMetaData, frameType string) {
+	uplinkFrames, err := structs.GetUplinkFramesPerAntenna(uplinkFrame, rmd)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"gateway_id": gatewayID,
+		}).Error("backend/basicstation: get uplink frames per antenna error")
+		return
+	}
+
+	for _, uplinkFrame := range uplinkFrames {
+		if _, drop := overload.Drop(uplinkFrame); drop {
+			continue
+		}
+
+		// set uplink id
+		uplinkID, err := uuid.NewV4()
+		if err != nil {
+			log.WithError(err).WithFields(log.Fields{
+				"gateway_id": gatewayID,
+			}).Error("backend/basicstation: get random uplink id error")
+			return
+		}
+		uplinkFrame.RxInfo.UplinkId = uplinkID[:]
+
+		log.WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"uplink_id":  uplinkID,
+			"antenna":    uplinkFrame.RxInfo.Antenna,
+		}).Infof("backend/basicstation: %s received", frameType)
 
-	b.uplinkFrameChan <- uplinkFrame
+		overload.Enqueue()
+		b.uplinkFrameChan <- uplinkFrame
+		overload.Dequeue()
+	}
 }
 
-func (b *Backend) sendToGateway(gatewayID lorawan.EUI64, v interface{}) error {
-	gw, err := b.gateways.get(gatewayID)
+// getBand returns the data-rates used for converting the uplink frames.
+func (b *Backend) getBand() structs.DataRates {
+	b.RLock()
+	defer b.RUnlock()
+
+	return b.band
+}
+
+// loadRegionalParameters loads the regional parameters file and updates the
+// data-rates, region and router-config.
+func (b *Backend) loadRegionalParameters() error {
+	p, err := regional.Load(b.regionalParameters.file)
 	if err != nil {
-		return errors.Wrap(err, "get gateway error")
+		return err
 	}
 
-	gw.conn.SetWriteDeadline(time.Now().Add(b.writeTimeout))
-	if err := gw.conn.WriteJSON(v); err != nil {
+	region := b.regionalParameters.region
+	if p.Region != "" {
+		region = p.Region
+	}
+
+	concentrators := b.regionalParameters.concentrators
+	if len(p.Concentrators) != 0 {
+		concentrators = p.Concentrators
+	}
+
+	var routerConfig *structs.RouterConfig
+	if len(concentrators) != 0 {
+		rc, err := structs.GetRouterConfig(region, p, b.netIDs, b.joinEUIs, b.frequencyMin, b.frequencyMax, concentrators)
+		if err != nil {
+			return errors.Wrap(err, "get router config error")
+		}
+		routerConfig = &rc
+	}
+
+	b.Lock()
+	b.band = p
+	b.region = region
+	b.concentrators = concentrators
+	b.routerConfig = routerConfig
+	b.regionalParameters.modTime = p.ModTime
+	b.Unlock()
+
+	log.WithFields(log.Fields{
+		"file":          b.regionalParameters.file,
+		"region":        region,
+		"mod_time":      p.ModTime,
+		"data_rates":    len(p.DataRates),
+		"concentrators": len(concentrators),
+	}).Info("backend/basicstation: regional parameters loaded")
+
+	return nil
+}
+
+// reloadRegionalParametersLoop re-loads the regional parameters file when its
+// modification time has changed.
+func (b *Backend) reloadRegionalParametersLoop() {
+	ticker := time.NewTicker(b.regionalParameters.reloadInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		if b.isClosed {
+			return
+		}
+
+		fi, err := os.Stat(b.regionalParameters.file)
+		if err != nil {
+			log.WithError(err).WithField("file", b.regionalParameters.file).Error("backend/basicstation: stat regional parameters file error")
+			continue
+		}
+
+		b.RLock()
+		modTime := b.regionalParameters.modTime
+		b.RUnlock()
+
+		if fi.ModTime().Equal(modTime) {
+			continue
+		}
+
+		if err := b.loadRegionalParameters(); err != nil {
+			log.WithError(err).WithField("file", b.regionalParameters.file).Error("backend/basicstation: reload regional parameters error")
+		}
+	}
+}
+
+func (b *Backend) storeXTimeSession(gatewayID lorawan.EUI64, rmd structs.RadioMetaData) {
+	if err := b.gateways.setXTimeSession(gatewayID, rmd.UpInfo.XTime); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"gateway_id": gatewayID,
+		}).Error("backend/basicstation: store xtime session error")
+	}
+}
+
+func (b *Backend) sendToGateway(gatewayID lorawan.EUI64, v interface{}) error {
+	if b.ringBuffer.Enabled() {
+		if msg, err := json.Marshal(v); err == nil {
+			b.ringBuffer.Add(gatewayID, ringbuffer.DirectionDown, msg, time.Now())
+		}
+	}
+
+	if err := b.gateways.writeJSON(gatewayID, v, time.Now().Add(b.writeTimeout)); err != nil {
+		if err == errGatewayDoesNotExist {
+			return errors.Wrap(err, "get gateway error")
+		}
 		return errors.Wrap(err, "send message to gateway error")
 	}
 
 	return nil
 }
 
+// dumpRingBuffer dumps the last raw messages of the given gateway, e.g. on
+// a conversion error.
+func (b *Backend) dumpRingBuffer(gatewayID lorawan.EUI64) {
+	if _, err := b.ringBuffer.Dump(gatewayID, time.Now()); err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/basicstation: dump ring buffer error")
+	}
+}
+
 func (b *Backend) websocketWrap(handler func(*http.Request, *websocket.Conn), w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	if !b.policy.checkHost(r) {
+		log.WithFields(log.Fields{
+			"host":        r.Host,
+			"remote_addr": r.RemoteAddr,
+		}).Error("backend/basicstation: host is not allowed")
+		http.Error(w, http.StatusText(http.StatusMisdirectedRequest), http.StatusMisdirectedRequest)
+		return
+	}
+
+	conn, err := b.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.WithError(err).Error("backend/basicstation: websocket upgrade error")
 		return
@@ -648,16 +1382,22 @@ func (b *Backend) websocketWrap(handler func(*http.Request, *websocket.Conn), w
 	ticker := time.NewTicker(b.pingInterval)
 	defer ticker.Stop()
 
+	done := make(chan struct{})
+	defer close(done)
+
+	// the ping messages are sent as control messages, which can be written
+	// concurrently with the (JSON) messages written by the gateway writer
 	go func() {
 		for {
 			select {
 			case <-ticker.C:
 				websocketPingPongCounter("ping").Inc()
-				conn.SetWriteDeadline(time.Now().Add(b.writeTimeout))
-				if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				if err := conn.WriteControl(websocket.PingMessage, marshalPingPayload(time.Now()), time.Now().Add(b.writeTimeout)); err != nil {
 					log.WithError(err).Error("backend/basicstation: send ping message error")
 					conn.Close()
 				}
+			case <-done:
+				return
 			}
 		}
 	}()
@@ -1,7 +1,12 @@
 package basicstation
 
 import (
+	"encoding/binary"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sync"
 	"testing"
 	"time"
 
@@ -13,6 +18,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/brocaar/lora-gateway-bridge/internal/backend/basicstation/structs"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
 	"github.com/brocaar/loraserver/api/common"
 	"github.com/brocaar/loraserver/api/gw"
@@ -46,6 +52,7 @@ func (ts *BackendTestSuite) SetupTest() {
 	conf.Backend.BasicStation.PingInterval = time.Minute
 	conf.Backend.BasicStation.ReadTimeout = 2 * time.Minute
 	conf.Backend.BasicStation.WriteTimeout = time.Second
+	conf.Backend.BasicStation.Websocket.PathPrefix = "/gateway"
 
 	ts.backend, err = NewBackend(conf)
 	assert.NoError(err)
@@ -57,20 +64,95 @@ func (ts *BackendTestSuite) SetupTest() {
 	ts.wsClient, _, err = d.Dial(fmt.Sprintf("ws://%s/gateway/0102030405060708", ts.wsAddr), nil)
 	assert.NoError(err)
 
-	eui := <-ts.backend.GetConnectChan()
-	assert.Equal(lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, eui)
+	conn := <-ts.backend.GetConnectChan()
+	assert.Equal(events.Connection{
+		GatewayID: lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+		Reason:    events.ReasonFirstSeen,
+	}, conn)
 }
 
 func (ts *BackendTestSuite) TearDownTest() {
 	assert := require.New(ts.T())
 	assert.NoError(ts.wsClient.Close())
 
-	eui := <-ts.backend.GetDisconnectChan()
-	assert.Equal(lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, eui)
+	conn := <-ts.backend.GetDisconnectChan()
+	assert.Equal(lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, conn.GatewayID)
+	assert.Equal(events.ReasonClose, conn.Reason)
 
 	assert.NoError(ts.backend.Close())
 }
 
+func (ts *BackendTestSuite) TestSessionTakeover() {
+	assert := require.New(ts.T())
+	ts.backend.duplicateConnection = duplicateConnectionTakeover
+
+	d := &websocket.Dialer{}
+	wsClient, _, err := d.Dial(fmt.Sprintf("ws://%s/gateway/0102030405060708", ts.wsAddr), nil)
+	assert.NoError(err)
+
+	// the old connection is closed using a close message
+	_, _, err = ts.wsClient.ReadMessage()
+	closeErr, ok := err.(*websocket.CloseError)
+	assert.True(ok)
+	assert.Equal(websocket.CloseNormalClosure, closeErr.Code)
+
+	// a notification is emitted
+	n := <-ts.backend.GetNotifyChan()
+	assert.Equal(lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, n.GatewayID)
+	assert.Equal("warning", n.Level)
+	assert.Equal(sessionTakeoverCode, n.Code)
+
+	// the new connection is used
+	ts.wsClient = wsClient
+	gw, err := ts.backend.gateways.get(lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08})
+	assert.NoError(err)
+	assert.NotNil(gw.conn)
+}
+
+func (ts *BackendTestSuite) TestDuplicateConnectionRejectConcurrent() {
+	assert := require.New(ts.T())
+	ts.backend.duplicateConnection = duplicateConnectionReject
+	gatewayID := lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}
+
+	var wg sync.WaitGroup
+	clients := make([]*websocket.Conn, 2)
+	errs := make([]error, 2)
+	for i := range clients {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			d := &websocket.Dialer{}
+			clients[i], _, errs[i] = d.Dial(fmt.Sprintf("ws://%s/gateway/%s", ts.wsAddr, gatewayID), nil)
+		}(i)
+	}
+	wg.Wait()
+	assert.NoError(errs[0])
+	assert.NoError(errs[1])
+
+	// only one of the connections is registered
+	conn := <-ts.backend.GetConnectChan()
+	assert.Equal(gatewayID, conn.GatewayID)
+
+	// the other connection is closed, the registered connection is not
+	var closed, open []*websocket.Conn
+	for _, c := range clients {
+		c.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		_, _, err := c.ReadMessage()
+		if nerr, ok := err.(net.Error); ok && nerr.Timeout() {
+			open = append(open, c)
+		} else {
+			closed = append(closed, c)
+		}
+	}
+	assert.Len(closed, 1)
+	assert.Len(open, 1)
+	closed[0].Close()
+
+	assert.NoError(open[0].Close())
+	conn = <-ts.backend.GetDisconnectChan()
+	assert.Equal(gatewayID, conn.GatewayID)
+}
+
 func (ts *BackendTestSuite) TestRouterInfo() {
 	assert := require.New(ts.T())
 
@@ -96,6 +178,75 @@ func (ts *BackendTestSuite) TestRouterInfo() {
 	}, resp)
 }
 
+type testRouterInfoAuthorizer struct {
+	request  routerInfoAuthRequest
+	response routerInfoAuthResponse
+}
+
+func (a *testRouterInfoAuthorizer) authorize(req routerInfoAuthRequest) (routerInfoAuthResponse, error) {
+	a.request = req
+	return a.response, nil
+}
+
+func (ts *BackendTestSuite) TestRouterInfoAuthorization() {
+	auth := testRouterInfoAuthorizer{}
+	ts.backend.routerInfoAuth = &auth
+	defer func() { ts.backend.routerInfoAuth = nil }()
+
+	tests := []struct {
+		Name     string
+		Response routerInfoAuthResponse
+		Expected structs.RouterInfoResponse
+	}{
+		{
+			Name: "authorized",
+			Expected: structs.RouterInfoResponse{
+				URI: fmt.Sprintf("ws://%s/gateway/0102030405060708", ts.wsAddr),
+			},
+		},
+		{
+			Name:     "authorized with uri",
+			Response: routerInfoAuthResponse{URI: "wss://lns.example.com/gateway/0102030405060708"},
+			Expected: structs.RouterInfoResponse{
+				URI: "wss://lns.example.com/gateway/0102030405060708",
+			},
+		},
+		{
+			Name:     "rejected",
+			Response: routerInfoAuthResponse{Error: "unknown gateway"},
+			Expected: structs.RouterInfoResponse{
+				Error: "unknown gateway",
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		ts.T().Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			auth.response = tst.Response
+
+			ws, _, err := (&websocket.Dialer{}).Dial(fmt.Sprintf("ws://%s/router-info", ts.wsAddr), nil)
+			assert.NoError(err)
+			defer ws.Close()
+
+			assert.NoError(ws.WriteJSON(structs.RouterInfoRequest{
+				Router: structs.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			}))
+
+			var resp structs.RouterInfoResponse
+			assert.NoError(ws.ReadJSON(&resp))
+
+			tst.Expected.Router = structs.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}
+			tst.Expected.Muxs = tst.Expected.Router
+			assert.Equal(tst.Expected, resp)
+
+			assert.Equal(lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, auth.request.GatewayID)
+			assert.Equal(fmt.Sprintf("ws://%s/gateway/0102030405060708", ts.wsAddr), auth.request.URI)
+			assert.Nil(auth.request.ClientCertificate)
+		})
+	}
+}
+
 func (ts *BackendTestSuite) TestVersionOld() {
 	assert := require.New(ts.T())
 	ts.backend.routerConfig = nil
@@ -287,7 +438,7 @@ func (ts *BackendTestSuite) TestDownlinkTransmitted() {
 	id, err := uuid.NewV4()
 	assert.NoError(err)
 
-	ts.backend.diidMap[12345] = id[:]
+	ts.backend.downlinkIDs.Set(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, 12345, id[:])
 
 	dtx := structs.DownlinkTransmitted{
 		MessageType: structs.DownlinkTransmittedMessage,
@@ -305,6 +456,55 @@ func (ts *BackendTestSuite) TestDownlinkTransmitted() {
 	}, txAck)
 }
 
+func (ts *BackendTestSuite) TestTimeSync() {
+	assert := require.New(ts.T())
+
+	assert.NoError(ts.wsClient.WriteJSON(structs.TimeSyncRequest{
+		MessageType: structs.TimeSyncMessage,
+		TxTime:      123456789,
+	}))
+
+	var resp structs.TimeSyncResponse
+	assert.NoError(ts.wsClient.ReadJSON(&resp))
+	assert.Equal(structs.TimeSyncMessage, resp.MessageType)
+	assert.EqualValues(123456789, resp.TxTime)
+	assert.True(resp.GPSTime > 0)
+}
+
+func (ts *BackendTestSuite) TestUpload() {
+	assert := require.New(ts.T())
+
+	tempDir, err := ioutil.TempDir("", "test")
+	assert.NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	ts.backend.uploads = uploadHandler{directory: tempDir}
+
+	assert.NoError(ts.wsClient.WriteMessage(websocket.BinaryMessage, []byte{1, 2, 3, 4}))
+
+	upload := <-ts.backend.GetUploadChan()
+	assert.Equal(lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, upload.GatewayID)
+	assert.Equal(4, upload.Size)
+
+	b, err := ioutil.ReadFile(upload.Location)
+	assert.NoError(err)
+	assert.Equal([]byte{1, 2, 3, 4}, b)
+}
+
+func (ts *BackendTestSuite) TestRunCommand() {
+	assert := require.New(ts.T())
+
+	assert.NoError(ts.backend.RunCommand(lorawan.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08}, "spectral-scan", []string{"-b", "863000000"}))
+
+	var runCmd structs.RunCommand
+	assert.NoError(ts.wsClient.ReadJSON(&runCmd))
+	assert.Equal(structs.RunCommand{
+		MessageType: structs.RunCommandMessage,
+		Command:     "spectral-scan",
+		Arguments:   []string{"-b", "863000000"},
+	}, runCmd)
+}
+
 func (ts *BackendTestSuite) TestApplyConfiguration() {
 	assert := require.New(ts.T())
 
@@ -429,7 +629,7 @@ func (ts *BackendTestSuite) TestSendDownlinkFrame() {
 	})
 	assert.NoError(err)
 
-	assert.Equal(id[:], ts.backend.diidMap[1234])
+	assert.Equal(id[:], ts.backend.downlinkIDs.Get(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, 1234))
 
 	var df structs.DownlinkFrame
 	assert.NoError(ts.wsClient.ReadJSON(&df))
@@ -455,6 +655,192 @@ func (ts *BackendTestSuite) TestSendDownlinkFrame() {
 	}, df)
 }
 
+func (ts *BackendTestSuite) TestSendDownlinkFrameRadioUnit() {
+	xtime := structs.XTimeSet(4, 2, 3)
+	context := make([]byte, 16)
+	binary.BigEndian.PutUint64(context[0:8], 3)
+	binary.BigEndian.PutUint64(context[8:16], xtime)
+	rCtx := uint64(3)
+	board := uint64(1)
+
+	tests := []struct {
+		Name          string
+		TxInfo        gw.DownlinkTXInfo
+		ExpectedRCtx  *uint64
+		ExpectedXTime *uint64
+	}{
+		{
+			Name: "context xtime is used as-is",
+			TxInfo: gw.DownlinkTXInfo{
+				Board:   1,
+				Timing:  gw.DownlinkTiming_DELAY,
+				Context: context,
+				TimingInfo: &gw.DownlinkTXInfo_DelayTimingInfo{
+					DelayTimingInfo: &gw.DelayTimingInfo{
+						Delay: ptypes.DurationProto(time.Second),
+					},
+				},
+			},
+			ExpectedRCtx:  &rCtx,
+			ExpectedXTime: &xtime,
+		},
+		{
+			Name: "no context, board is used as rctx",
+			TxInfo: gw.DownlinkTXInfo{
+				Board:  1,
+				Timing: gw.DownlinkTiming_IMMEDIATELY,
+			},
+			ExpectedRCtx: &board,
+		},
+		{
+			Name: "no context, no board",
+			TxInfo: gw.DownlinkTXInfo{
+				Timing: gw.DownlinkTiming_IMMEDIATELY,
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		ts.T().Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			// the gateway reported an other session for the radio unit
+			assert.NoError(ts.backend.gateways.setXTimeSession(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, structs.XTimeSet(1, 2, 5)))
+
+			txInfo := tst.TxInfo
+			txInfo.GatewayId = []byte{1, 2, 3, 4, 5, 6, 7, 8}
+			txInfo.Frequency = 868100000
+			txInfo.Modulation = common.Modulation_LORA
+			txInfo.ModulationInfo = &gw.DownlinkTXInfo_LoraModulationInfo{
+				LoraModulationInfo: &gw.LoRaModulationInfo{
+					Bandwidth:       125,
+					SpreadingFactor: 10,
+				},
+			}
+
+			assert.NoError(ts.backend.SendDownlinkFrame(gw.DownlinkFrame{
+				PhyPayload: []byte{1, 2, 3, 4},
+				TxInfo:     &txInfo,
+				Token:      1234,
+			}))
+
+			var df structs.DownlinkFrame
+			assert.NoError(ts.wsClient.ReadJSON(&df))
+			assert.Equal(tst.ExpectedRCtx, df.RCtx)
+			assert.Equal(tst.ExpectedXTime, df.XTime)
+		})
+	}
+}
+
+func TestRouterInfoBind(t *testing.T) {
+	tests := []struct {
+		Name string
+		URI  string
+	}{
+		{
+			Name: "websocket bind port",
+		},
+		{
+			Name: "configured uri",
+			URI:  "wss://lns.example.com:3001/",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Backend.BasicStation.Bind = "127.0.0.1:0"
+			conf.Backend.BasicStation.Region = "EU868"
+			conf.Backend.BasicStation.RouterInfo.Bind = "127.0.0.1:0"
+			conf.Backend.BasicStation.RouterInfo.URI = tst.URI
+			conf.Backend.BasicStation.PingInterval = time.Minute
+			conf.Backend.BasicStation.ReadTimeout = 2 * time.Minute
+			conf.Backend.BasicStation.WriteTimeout = time.Second
+			conf.Backend.BasicStation.Websocket.PathPrefix = "/gateway"
+
+			backend, err := NewBackend(conf)
+			assert.NoError(err)
+			defer backend.Close()
+
+			d := &websocket.Dialer{}
+			ws, _, err := d.Dial(fmt.Sprintf("ws://%s/router-info", backend.routerInfoLn.Addr()), nil)
+			assert.NoError(err)
+			defer ws.Close()
+
+			assert.NoError(ws.WriteJSON(structs.RouterInfoRequest{
+				Router: structs.EUI64{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			}))
+
+			var resp structs.RouterInfoResponse
+			assert.NoError(ws.ReadJSON(&resp))
+
+			if tst.URI == "" {
+				assert.Equal(fmt.Sprintf("ws://%s/gateway/0102030405060708", backend.ln.Addr()), resp.URI)
+			} else {
+				assert.Equal("wss://lns.example.com:3001/gateway/0102030405060708", resp.URI)
+			}
+		})
+	}
+}
+
 func TestBackend(t *testing.T) {
 	suite.Run(t, new(BackendTestSuite))
 }
+
+func TestNewBackendTimeouts(t *testing.T) {
+	tests := []struct {
+		Name                 string
+		PingInterval         time.Duration
+		ReadTimeout          time.Duration
+		WriteTimeout         time.Duration
+		ExpectedPingInterval time.Duration
+		ExpectedReadTimeout  time.Duration
+		ExpectedWriteTimeout time.Duration
+	}{
+		{
+			Name:                 "not configured",
+			ExpectedPingInterval: time.Minute,
+			ExpectedReadTimeout:  time.Minute + 5*time.Second,
+			ExpectedWriteTimeout: time.Second,
+		},
+		{
+			Name:                 "ping interval configured",
+			PingInterval:         30 * time.Second,
+			ExpectedPingInterval: 30 * time.Second,
+			ExpectedReadTimeout:  35 * time.Second,
+			ExpectedWriteTimeout: time.Second,
+		},
+		{
+			Name:                 "configured",
+			PingInterval:         30 * time.Second,
+			ReadTimeout:          time.Minute,
+			WriteTimeout:         2 * time.Second,
+			ExpectedPingInterval: 30 * time.Second,
+			ExpectedReadTimeout:  time.Minute,
+			ExpectedWriteTimeout: 2 * time.Second,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Backend.BasicStation.Bind = "127.0.0.1:0"
+			conf.Backend.BasicStation.Region = "EU868"
+			conf.Backend.BasicStation.PingInterval = tst.PingInterval
+			conf.Backend.BasicStation.ReadTimeout = tst.ReadTimeout
+			conf.Backend.BasicStation.WriteTimeout = tst.WriteTimeout
+
+			backend, err := NewBackend(conf)
+			assert.NoError(err)
+			defer backend.Close()
+
+			assert.Equal(tst.ExpectedPingInterval, backend.pingInterval)
+			assert.Equal(tst.ExpectedReadTimeout, backend.readTimeout)
+			assert.Equal(tst.ExpectedWriteTimeout, backend.writeTimeout)
+		})
+	}
+}
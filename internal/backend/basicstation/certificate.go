@@ -0,0 +1,101 @@
+package basicstation
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lorawan"
+)
+
+// Gateway stats meta-data keys of the client certificate.
+const (
+	MetaDataCertFingerprint = "client_cert_fingerprint"
+	MetaDataCertExpiry      = "client_cert_expiry"
+)
+
+// clientCertificate contains the metadata of the client certificate used by
+// the gateway.
+type clientCertificate struct {
+	commonName  string
+	fingerprint string
+	notAfter    time.Time
+}
+
+// peerCertificate returns the client certificate of the given request, or
+// nil when no client certificate was used.
+func peerCertificate(r *http.Request) *x509.Certificate {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return r.TLS.PeerCertificates[0]
+}
+
+func newClientCertificate(cert *x509.Certificate) clientCertificate {
+	fp := sha256.Sum256(cert.Raw)
+
+	return clientCertificate{
+		commonName:  cert.Subject.CommonName,
+		fingerprint: hex.EncodeToString(fp[:]),
+		notAfter:    cert.NotAfter,
+	}
+}
+
+// verifyClientCertificate returns an error when neither the CommonName nor
+// one of the DNS Subject Alternative Names of the given certificate contains
+// the given gateway ID.
+func verifyClientCertificate(cert *x509.Certificate, gatewayID lorawan.EUI64) error {
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+
+	for _, name := range names {
+		var id lorawan.EUI64
+		if err := id.UnmarshalText([]byte(name)); err == nil && id == gatewayID {
+			return nil
+		}
+	}
+
+	return errors.Errorf("certificate CommonName %s and DNS names do not match router %s", cert.Subject.CommonName, gatewayID)
+}
+
+// metaData returns the gateway stats meta-data of the client certificate.
+func (c clientCertificate) metaData() map[string]string {
+	return map[string]string{
+		MetaDataCertFingerprint: c.fingerprint,
+		MetaDataCertExpiry:      c.notAfter.UTC().Format(time.RFC3339),
+	}
+}
+
+// expiresWithin returns true when the certificate expires within the given
+// duration.
+func (c clientCertificate) expiresWithin(d time.Duration, now time.Time) bool {
+	return d > 0 && c.notAfter.Sub(now) < d
+}
+
+// checkCertExpiry logs a warning and emits the cert expiry event when the
+// client certificate of the gateway expires within the configured warning
+// period.
+func (b *Backend) checkCertExpiry(gatewayID lorawan.EUI64, cert clientCertificate) {
+	if !cert.expiresWithin(b.certExpiryWarning, time.Now()) {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id":  gatewayID,
+		"common_name": cert.commonName,
+		"fingerprint": cert.fingerprint,
+		"not_after":   cert.notAfter,
+	}).Warning("backend/basicstation: client certificate is about to expire")
+
+	b.certExpiryChan <- events.CertExpiry{
+		GatewayID:   gatewayID,
+		CommonName:  cert.commonName,
+		Fingerprint: cert.fingerprint,
+		NotAfter:    cert.notAfter,
+	}
+}
@@ -0,0 +1,140 @@
+package basicstation
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lorawan"
+)
+
+func TestVerifyClientCertificate(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	tests := []struct {
+		Name          string
+		CommonName    string
+		DNSNames      []string
+		ExpectedError string
+	}{
+		{
+			Name:       "common name matches",
+			CommonName: "0102030405060708",
+		},
+		{
+			Name:       "dns name matches",
+			CommonName: "gateway.example.com",
+			DNSNames:   []string{"gateway.example.com", "0102030405060708"},
+		},
+		{
+			Name:          "common name does not match",
+			CommonName:    "0807060504030201",
+			ExpectedError: "certificate CommonName 0807060504030201 and DNS names do not match router 0102030405060708",
+		},
+		{
+			Name:          "no gateway id",
+			CommonName:    "gateway.example.com",
+			DNSNames:      []string{"gateway.example.com"},
+			ExpectedError: "certificate CommonName gateway.example.com and DNS names do not match router 0102030405060708",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			cert := x509.Certificate{
+				Subject:  pkix.Name{CommonName: tst.CommonName},
+				DNSNames: tst.DNSNames,
+			}
+
+			err := verifyClientCertificate(&cert, gatewayID)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestClientCertificate(t *testing.T) {
+	assert := require.New(t)
+
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	cert := newClientCertificate(&x509.Certificate{
+		Raw:      []byte{1, 2, 3},
+		Subject:  pkix.Name{CommonName: "0102030405060708"},
+		NotAfter: now.Add(10 * 24 * time.Hour),
+	})
+
+	assert.Equal(clientCertificate{
+		commonName:  "0102030405060708",
+		fingerprint: "039058c6f2c0cb492c533b0a4d14ef77cc0f78abccced5287d84a1a2011cfb81",
+		notAfter:    now.Add(10 * 24 * time.Hour),
+	}, cert)
+
+	assert.Equal(map[string]string{
+		"client_cert_fingerprint": "039058c6f2c0cb492c533b0a4d14ef77cc0f78abccced5287d84a1a2011cfb81",
+		"client_cert_expiry":      "2019-09-20T12:00:00Z",
+	}, cert.metaData())
+
+	assert.False(cert.expiresWithin(0, now))
+	assert.False(cert.expiresWithin(7*24*time.Hour, now))
+	assert.True(cert.expiresWithin(30*24*time.Hour, now))
+}
+
+func TestCheckCertExpiry(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	cert := clientCertificate{
+		commonName:  "0102030405060708",
+		fingerprint: "039058c6f2c0cb492c533b0a4d14ef77cc0f78abccced5287d84a1a2011cfb81",
+		notAfter:    time.Now().Add(10 * 24 * time.Hour),
+	}
+
+	tests := []struct {
+		Name     string
+		Warning  time.Duration
+		Expected *events.CertExpiry
+	}{
+		{
+			Name: "warning disabled",
+		},
+		{
+			Name:    "not expiring within warning period",
+			Warning: 7 * 24 * time.Hour,
+		},
+		{
+			Name:    "expiring within warning period",
+			Warning: 30 * 24 * time.Hour,
+			Expected: &events.CertExpiry{
+				GatewayID:   gatewayID,
+				CommonName:  cert.commonName,
+				Fingerprint: cert.fingerprint,
+				NotAfter:    cert.notAfter,
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b := Backend{
+				certExpiryWarning: tst.Warning,
+				certExpiryChan:    make(chan events.CertExpiry, 1),
+			}
+			b.checkCertExpiry(gatewayID, cert)
+
+			if tst.Expected == nil {
+				assert.Len(b.certExpiryChan, 0)
+				return
+			}
+			assert.Equal(*tst.Expected, <-b.certExpiryChan)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package basicstation
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// routerInfoAuthorizer authorizes router-info (discovery) requests using an
+// external authorization service, so that the access of a fleet of gateways
+// can be controlled centrally.
+type routerInfoAuthorizer interface {
+	// authorize returns the authorization response for the given request.
+	authorize(req routerInfoAuthRequest) (routerInfoAuthResponse, error)
+}
+
+// routerInfoAuthRequest is sent to the authorization service.
+type routerInfoAuthRequest struct {
+	GatewayID  lorawan.EUI64 `json:"gatewayID"`
+	RemoteAddr string        `json:"remoteAddr"`
+
+	// URI contains the URI that would be returned to the gateway.
+	URI string `json:"uri"`
+
+	// ClientCertificate is only set when the gateway used a client
+	// certificate.
+	ClientCertificate *routerInfoAuthCertificate `json:"clientCertificate,omitempty"`
+}
+
+// routerInfoAuthCertificate contains the client certificate details.
+type routerInfoAuthCertificate struct {
+	CommonName  string    `json:"commonName"`
+	DNSNames    []string  `json:"dnsNames"`
+	Fingerprint string    `json:"fingerprint"`
+	NotAfter    time.Time `json:"notAfter"`
+}
+
+// routerInfoAuthResponse is returned by the authorization service. When the
+// Error is set, the request is rejected. Else the URI (when set) is returned
+// to the gateway.
+type routerInfoAuthResponse struct {
+	URI   string `json:"uri"`
+	Error string `json:"error"`
+}
+
+func newRouterInfoAuthCertificate(cert *x509.Certificate) *routerInfoAuthCertificate {
+	if cert == nil {
+		return nil
+	}
+
+	fp := sha256.Sum256(cert.Raw)
+
+	return &routerInfoAuthCertificate{
+		CommonName:  cert.Subject.CommonName,
+		DNSNames:    cert.DNSNames,
+		Fingerprint: hex.EncodeToString(fp[:]),
+		NotAfter:    cert.NotAfter,
+	}
+}
+
+// httpRouterInfoAuthorizer posts the authorization request as JSON to the
+// configured URL and expects a JSON response.
+type httpRouterInfoAuthorizer struct {
+	url    string
+	client http.Client
+}
+
+func (a *httpRouterInfoAuthorizer) authorize(req routerInfoAuthRequest) (routerInfoAuthResponse, error) {
+	var out routerInfoAuthResponse
+
+	b, err := json.Marshal(req)
+	if err != nil {
+		return out, errors.Wrap(err, "marshal json error")
+	}
+
+	resp, err := a.client.Post(a.url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return out, errors.Wrap(err, "http post error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return out, fmt.Errorf("expected 2xx response, got: %d", resp.StatusCode)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return out, errors.Wrap(err, "unmarshal json error")
+	}
+
+	return out, nil
+}
@@ -0,0 +1,81 @@
+package basicstation
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestHTTPRouterInfoAuthorizer(t *testing.T) {
+	req := routerInfoAuthRequest{
+		GatewayID:  lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		RemoteAddr: "127.0.0.1:1234",
+		URI:        "ws://localhost:3001/gateway/0102030405060708",
+	}
+
+	tests := []struct {
+		Name             string
+		StatusCode       int
+		Body             string
+		ExpectedResponse routerInfoAuthResponse
+		ExpectedError    bool
+	}{
+		{
+			Name:       "authorized",
+			StatusCode: http.StatusOK,
+			Body:       `{"uri": "wss://lns.example.com/gateway/0102030405060708"}`,
+			ExpectedResponse: routerInfoAuthResponse{
+				URI: "wss://lns.example.com/gateway/0102030405060708",
+			},
+		},
+		{
+			Name:       "rejected",
+			StatusCode: http.StatusOK,
+			Body:       `{"error": "unknown gateway"}`,
+			ExpectedResponse: routerInfoAuthResponse{
+				Error: "unknown gateway",
+			},
+		},
+		{
+			Name:          "error response",
+			StatusCode:    http.StatusInternalServerError,
+			ExpectedError: true,
+		},
+		{
+			Name:          "invalid json",
+			StatusCode:    http.StatusOK,
+			Body:          `foo`,
+			ExpectedError: true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var received routerInfoAuthRequest
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&received)
+				w.WriteHeader(tst.StatusCode)
+				w.Write([]byte(tst.Body))
+			}))
+			defer server.Close()
+
+			a := httpRouterInfoAuthorizer{url: server.URL}
+			resp, err := a.authorize(req)
+			assert.Equal(req, received)
+			if tst.ExpectedError {
+				assert.Error(err)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedResponse, resp)
+		})
+	}
+}
@@ -2,34 +2,104 @@ package basicstation
 
 import (
 	"errors"
+	"hash/fnv"
 	"sync"
+	"time"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/basicstation/structs"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
 	"github.com/brocaar/lorawan"
 	"github.com/gorilla/websocket"
 )
 
 var (
-	errGatewayDoesNotExist = errors.New("gateway does not exist")
+	errGatewayDoesNotExist  = errors.New("gateway does not exist")
+	errGatewayAlreadyExists = errors.New("gateway already exists")
+	errSessionDoesNotExist  = errors.New("xtime session does not exist")
+	errXTimeNotMonotonic    = errors.New("xtime is not monotonic")
 )
 
 type gateway struct {
 	conn          *websocket.Conn
 	configVersion string
+
+	// writeMux serializes the writes to the websocket connection, as a
+	// websocket connection supports only one concurrent writer. Each
+	// connection has its own writer, so that a slow gateway does not block
+	// the writes to other gateways.
+	writeMux *sync.Mutex
+
+	// clientCert is set when the gateway connected using a client
+	// certificate.
+	clientCert *clientCertificate
+
+	// xtimeSessions contains the last seen xtime session ID per radio unit.
+	xtimeSessions map[uint8]uint8
+
+	// xtimes contains the last seen xtime per radio unit (strict mode).
+	xtimes map[uint8]uint64
 }
 
+// gateways implements the gateway registry. The gateways are distributed
+// over independent shards by the hash of their EUI, so that the connection
+// handlers of different gateways do not contend on a single lock.
 type gateways struct {
+	shards []*gatewayShard
+
+	connectChan    chan events.Connection
+	disconnectChan chan events.Connection
+}
+
+// gatewayShard contains a subset of the gateways.
+type gatewayShard struct {
 	sync.RWMutex
 	gateways map[lorawan.EUI64]gateway
 
-	connectChan    chan lorawan.EUI64
-	disconnectChan chan lorawan.EUI64
+	// events contains the queued connect and disconnect events. These are
+	// queued while holding the shard lock and sent after releasing it, so
+	// that a slow consumer does not block the registry.
+	events   []connectionEvent
+	eventMux sync.Mutex
+}
+
+type connectionEvent struct {
+	connected bool
+	conn      events.Connection
+}
+
+func newGateways(shards int) gateways {
+	if shards < 1 {
+		shards = 1
+	}
+
+	g := gateways{
+		shards:         make([]*gatewayShard, shards),
+		connectChan:    make(chan events.Connection),
+		disconnectChan: make(chan events.Connection),
+	}
+
+	for i := range g.shards {
+		g.shards[i] = &gatewayShard{
+			gateways: make(map[lorawan.EUI64]gateway),
+		}
+	}
+
+	return g
+}
+
+// shard returns the shard of the given gateway.
+func (g *gateways) shard(id lorawan.EUI64) *gatewayShard {
+	h := fnv.New32a()
+	h.Write(id[:])
+	return g.shards[h.Sum32()%uint32(len(g.shards))]
 }
 
 func (g *gateways) get(id lorawan.EUI64) (gateway, error) {
-	g.RLock()
-	defer g.RUnlock()
+	s := g.shard(id)
+	s.RLock()
+	defer s.RUnlock()
 
-	gw, ok := g.gateways[id]
+	gw, ok := s.gateways[id]
 	if !ok {
 		return gw, errGatewayDoesNotExist
 	}
@@ -37,22 +107,200 @@ func (g *gateways) get(id lorawan.EUI64) (gateway, error) {
 }
 
 func (g *gateways) set(id lorawan.EUI64, gw gateway) error {
-	g.Lock()
-	defer g.Unlock()
+	g.swap(id, gw)
+	return nil
+}
+
+// swap sets the gateway and returns the websocket connection it replaces,
+// which is nil when the gateway was not connected.
+func (g *gateways) swap(id lorawan.EUI64, gw gateway) *websocket.Conn {
+	old, _ := g.insert(id, gw, true)
+	return old
+}
+
+// setIfAbsent sets the gateway when it is not connected, else it returns
+// errGatewayAlreadyExists. The check and the insert happen under the same
+// shard lock, so that only one of concurrent connections succeeds.
+func (g *gateways) setIfAbsent(id lorawan.EUI64, gw gateway) error {
+	if _, existed := g.insert(id, gw, false); existed {
+		return errGatewayAlreadyExists
+	}
+	return nil
+}
+
+// insert inserts the gateway. When the gateway is already connected, it is
+// only replaced when replace is true. It returns the websocket connection of
+// the existing gateway and whether it existed.
+func (g *gateways) insert(id lorawan.EUI64, gw gateway, replace bool) (*websocket.Conn, bool) {
+	if gw.writeMux == nil {
+		gw.writeMux = &sync.Mutex{}
+	}
+
+	s := g.shard(id)
+	s.Lock()
+
+	old, ok := s.gateways[id]
+	if ok {
+		if replace {
+			s.gateways[id] = gw
+		}
+		s.Unlock()
+		return old.conn, true
+	}
+	s.gateways[id] = gw
+
+	s.events = append(s.events, connectionEvent{
+		connected: true,
+		conn: events.Connection{
+			GatewayID: id,
+			Reason:    events.ReasonFirstSeen,
+		},
+	})
+	s.Unlock()
+
+	g.sendEvents(s)
+	return nil, false
+}
+
+// remove removes the gateway. Nothing is removed when the gateway is
+// connected using an other connection (e.g. after a session takeover).
+func (g *gateways) remove(id lorawan.EUI64, conn *websocket.Conn, reason string, closeCode int) error {
+	s := g.shard(id)
+	s.Lock()
+
+	gw, ok := s.gateways[id]
+	if !ok || gw.conn != conn {
+		s.Unlock()
+		return nil
+	}
+	delete(s.gateways, id)
 
-	_, ok := g.gateways[id]
-	g.gateways[id] = gw
+	s.events = append(s.events, connectionEvent{
+		conn: events.Connection{
+			GatewayID: id,
+			Reason:    reason,
+			CloseCode: closeCode,
+		},
+	})
+	s.Unlock()
+
+	g.sendEvents(s)
+	return nil
+}
+
+// sendEvents sends the queued events of the given shard in order. The shard
+// lock must not be held by the caller.
+func (g *gateways) sendEvents(s *gatewayShard) {
+	s.eventMux.Lock()
+	defer s.eventMux.Unlock()
+
+	for {
+		s.Lock()
+		if len(s.events) == 0 {
+			s.Unlock()
+			return
+		}
+		e := s.events[0]
+		s.events = s.events[1:]
+		s.Unlock()
+
+		if e.connected {
+			g.connectChan <- e.conn
+		} else {
+			g.disconnectChan <- e.conn
+		}
+	}
+}
+
+// setXTimeSession stores the session ID of the given xtime for the radio unit
+// encoded in the xtime.
+func (g *gateways) setXTimeSession(id lorawan.EUI64, xtime uint64) error {
+	s := g.shard(id)
+	s.Lock()
+	defer s.Unlock()
+
+	gw, ok := s.gateways[id]
 	if !ok {
-		g.connectChan <- id
+		return errGatewayDoesNotExist
 	}
+
+	if gw.xtimeSessions == nil {
+		gw.xtimeSessions = make(map[uint8]uint8)
+	}
+	gw.xtimeSessions[structs.XTimeRadioUnit(xtime)] = structs.XTimeSession(xtime)
+	s.gateways[id] = gw
+
 	return nil
 }
 
-func (g *gateways) remove(id lorawan.EUI64) error {
-	g.Lock()
-	defer g.Unlock()
+// checkXTime returns an error when the given xtime is before the last seen
+// xtime of the same radio unit and session. Otherwise it stores the xtime as
+// the last seen xtime of the radio unit.
+func (g *gateways) checkXTime(id lorawan.EUI64, xtime uint64) error {
+	s := g.shard(id)
+	s.Lock()
+	defer s.Unlock()
+
+	gw, ok := s.gateways[id]
+	if !ok {
+		return errGatewayDoesNotExist
+	}
+
+	if gw.xtimes == nil {
+		gw.xtimes = make(map[uint8]uint64)
+	}
+
+	radioUnit := structs.XTimeRadioUnit(xtime)
+	if last, ok := gw.xtimes[radioUnit]; ok && structs.XTimeSession(last) == structs.XTimeSession(xtime) && xtime < last {
+		return errXTimeNotMonotonic
+	}
+
+	gw.xtimes[radioUnit] = xtime
+	s.gateways[id] = gw
 
-	g.disconnectChan <- id
-	delete(g.gateways, id)
 	return nil
 }
+
+// getXTimeSession returns the last seen xtime session ID for the given radio
+// unit.
+func (g *gateways) getXTimeSession(id lorawan.EUI64, radioUnit uint8) (uint8, error) {
+	s := g.shard(id)
+	s.RLock()
+	defer s.RUnlock()
+
+	gw, ok := s.gateways[id]
+	if !ok {
+		return 0, errGatewayDoesNotExist
+	}
+
+	session, ok := gw.xtimeSessions[radioUnit]
+	if !ok {
+		return 0, errSessionDoesNotExist
+	}
+
+	return session, nil
+}
+
+// writeJSON writes the given value as JSON to the websocket connection of
+// the gateway, using the writer of this connection.
+func (g *gateways) writeJSON(id lorawan.EUI64, v interface{}, deadline time.Time) error {
+	gw, err := g.get(id)
+	if err != nil {
+		return err
+	}
+
+	gw.writeMux.Lock()
+	defer gw.writeMux.Unlock()
+
+	gw.conn.SetWriteDeadline(deadline)
+	return gw.conn.WriteJSON(v)
+}
+
+// lock acquires and releases the lock of each shard. It blocks when one of
+// the shards is deadlocked.
+func (g *gateways) lock() {
+	for _, s := range g.shards {
+		s.RLock()
+		s.RUnlock()
+	}
+}
@@ -0,0 +1,200 @@
+package basicstation
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lorawan"
+)
+
+func TestGateways(t *testing.T) {
+	t.Run("Shards", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.Len(newGateways(0).shards, 1)
+
+		g := newGateways(4)
+		assert.Len(g.shards, 4)
+
+		used := make(map[*gatewayShard]struct{})
+		for i := 0; i < 64; i++ {
+			id := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, byte(i)}
+			assert.True(g.shard(id) == g.shard(id))
+			used[g.shard(id)] = struct{}{}
+		}
+		assert.Len(used, 4)
+	})
+
+	t.Run("Registry", func(t *testing.T) {
+		assert := require.New(t)
+
+		g := newGateways(4)
+		g.connectChan = make(chan events.Connection, 1)
+		g.disconnectChan = make(chan events.Connection, 1)
+
+		id := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+		_, err := g.get(id)
+		assert.Equal(errGatewayDoesNotExist, err)
+
+		assert.NoError(g.set(id, gateway{configVersion: "v1"}))
+		assert.Equal(events.Connection{GatewayID: id, Reason: events.ReasonFirstSeen}, <-g.connectChan)
+
+		gw, err := g.get(id)
+		assert.NoError(err)
+		assert.Equal("v1", gw.configVersion)
+
+		assert.NoError(g.setXTimeSession(id, 0x0102000000000000))
+		session, err := g.getXTimeSession(id, 0x02)
+		assert.NoError(err)
+		assert.EqualValues(0x01, session)
+
+		// the gateway is not removed by the handler of a taken over
+		// connection
+		conn := &websocket.Conn{}
+		assert.Nil(g.swap(id, gateway{conn: conn}))
+		assert.NoError(g.remove(id, nil, events.ReasonClose, 0))
+		_, err = g.get(id)
+		assert.NoError(err)
+
+		assert.NoError(g.remove(id, conn, events.ReasonClose, 0))
+		assert.Equal(id, (<-g.disconnectChan).GatewayID)
+
+		_, err = g.get(id)
+		assert.Equal(errGatewayDoesNotExist, err)
+	})
+
+	t.Run("SetIfAbsent", func(t *testing.T) {
+		assert := require.New(t)
+
+		g := newGateways(4)
+		g.connectChan = make(chan events.Connection, 1)
+		id := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+		// only one of the concurrent inserts succeeds
+		var wg sync.WaitGroup
+		errs := make(chan error, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- g.setIfAbsent(id, gateway{conn: &websocket.Conn{}})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		var ok int
+		for err := range errs {
+			if err == nil {
+				ok++
+			} else {
+				assert.Equal(errGatewayAlreadyExists, err)
+			}
+		}
+		assert.Equal(1, ok)
+		assert.Equal(events.Connection{GatewayID: id, Reason: events.ReasonFirstSeen}, <-g.connectChan)
+	})
+
+	t.Run("XTime", func(t *testing.T) {
+		assert := require.New(t)
+
+		g := newGateways(1)
+		g.connectChan = make(chan events.Connection, 1)
+
+		id := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+		assert.Equal(errGatewayDoesNotExist, g.checkXTime(id, 1))
+		assert.NoError(g.set(id, gateway{}))
+
+		assert.NoError(g.checkXTime(id, 0x0100000000000200))
+		assert.NoError(g.checkXTime(id, 0x0100000000000200))
+		assert.Equal(errXTimeNotMonotonic, g.checkXTime(id, 0x0100000000000100))
+
+		// other radio unit
+		assert.NoError(g.checkXTime(id, 0x0101000000000100))
+
+		// new session
+		assert.NoError(g.checkXTime(id, 0x0200000000000100))
+	})
+
+	t.Run("Events", func(t *testing.T) {
+		assert := require.New(t)
+
+		g := newGateways(1)
+		id := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+		conn := &websocket.Conn{}
+
+		go g.swap(id, gateway{conn: conn})
+
+		// the registry is not blocked while the connect event is pending
+		for {
+			if _, err := g.get(id); err == nil {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		_, err := g.get(lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1})
+		assert.Equal(errGatewayDoesNotExist, err)
+
+		go g.remove(id, conn, events.ReasonClose, 0)
+
+		assert.Equal(events.Connection{GatewayID: id, Reason: events.ReasonFirstSeen}, <-g.connectChan)
+		assert.Equal(events.Connection{GatewayID: id, Reason: events.ReasonClose}, <-g.disconnectChan)
+	})
+
+	t.Run("WriteJSON", func(t *testing.T) {
+		assert := require.New(t)
+
+		g := newGateways(1)
+		g.connectChan = make(chan events.Connection, 1)
+		id := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+		assert.Equal(errGatewayDoesNotExist, g.writeJSON(id, nil, time.Now().Add(time.Second)))
+
+		var upgrader websocket.Upgrader
+		connChan := make(chan *websocket.Conn, 1)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				return
+			}
+			connChan <- conn
+		}))
+		defer server.Close()
+
+		client, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+		assert.NoError(err)
+		defer client.Close()
+
+		conn := <-connChan
+		defer conn.Close()
+		assert.NoError(g.set(id, gateway{conn: conn}))
+
+		// the writes to the same connection are serialized
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				assert.NoError(g.writeJSON(id, i, time.Now().Add(time.Second)))
+			}(i)
+		}
+
+		received := make(map[int]struct{})
+		for i := 0; i < 10; i++ {
+			var v int
+			assert.NoError(client.ReadJSON(&v))
+			received[v] = struct{}{}
+		}
+		wg.Wait()
+		assert.Len(received, 10)
+	})
+}
@@ -11,11 +11,22 @@ var (
 		Help: "The number of WebSocket Ping/Pong requests sent and received (per event type).",
 	}, []string{"type"})
 
+	rtt = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "backend_basicstation_websocket_ping_pong_rtt_seconds",
+		Help:    "The round-trip time of the WebSocket Ping/Pong requests (per gateway).",
+		Buckets: []float64{0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"gateway_id"})
+
 	wsr = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "backend_basicstation_websocket_received_count",
 		Help: "The number of WebSocket messages received by the backend (per msgtype).",
 	}, []string{"msgtype"})
 
+	wsj = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_basicstation_websocket_rejected_count",
+		Help: "The number of WebSocket messages rejected by the backend in strict mode (per msgtype).",
+	}, []string{"msgtype"})
+
 	wss = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "backend_basicstation_websocket_sent_count",
 		Help: "The number of WebSocket messages sent by the backend (per msgtype).",
@@ -30,16 +41,34 @@ var (
 		Name: "backend_basicstation_gateway_disconnect_count",
 		Help: "The number of gateways that disconnected from the backend.",
 	})
+
+	gwt = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "backend_basicstation_session_takeover_count",
+		Help: "The number of gateway connections taken over by a new connection of the same gateway.",
+	})
+
+	ria = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_basicstation_router_info_authorization_count",
+		Help: "The number of router-info requests authorized by the authorization service (per result).",
+	}, []string{"result"})
 )
 
 func websocketPingPongCounter(typ string) prometheus.Counter {
 	return ppc.With(prometheus.Labels{"type": typ})
 }
 
+func websocketPingPongRTTHistogram(gatewayID string) prometheus.Observer {
+	return rtt.With(prometheus.Labels{"gateway_id": gatewayID})
+}
+
 func websocketReceiveCounter(msgtype string) prometheus.Counter {
 	return wsr.With(prometheus.Labels{"msgtype": msgtype})
 }
 
+func websocketRejectCounter(msgtype string) prometheus.Counter {
+	return wsj.With(prometheus.Labels{"msgtype": msgtype})
+}
+
 func websocketSendCounter(msgtype string) prometheus.Counter {
 	return wss.With(prometheus.Labels{"msgtype": msgtype})
 }
@@ -51,3 +80,11 @@ func connectCounter() prometheus.Counter {
 func disconnectCounter() prometheus.Counter {
 	return gwd
 }
+
+func sessionTakeoverCounter() prometheus.Counter {
+	return gwt
+}
+
+func routerInfoAuthCounter(result string) prometheus.Counter {
+	return ria.With(prometheus.Labels{"result": result})
+}
@@ -0,0 +1,94 @@
+package basicstation
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// Muxs selection methods.
+const (
+	muxsSelectionConsistentHashing = "consistent_hashing"
+	muxsSelectionRandom            = "random"
+)
+
+// muxsPool selects the muxs URI returned by the router-info endpoint from a
+// static list or a DNS SRV record, so that gateways can be steered across
+// multiple LoRa Gateway Bridge instances.
+type muxsPool struct {
+	muxs      []string
+	dnsSRV    string
+	selection string
+
+	// lookupSRV is used for resolving the DNS SRV record.
+	lookupSRV func(service, proto, name string) (string, []*net.SRV, error)
+}
+
+// enabled returns true when a muxs pool is configured.
+func (p *muxsPool) enabled() bool {
+	return len(p.muxs) != 0 || p.dnsSRV != ""
+}
+
+// validate validates the muxs pool configuration.
+func (p *muxsPool) validate() error {
+	switch p.selection {
+	case muxsSelectionConsistentHashing, muxsSelectionRandom:
+		return nil
+	default:
+		return fmt.Errorf("invalid router_info selection: %s", p.selection)
+	}
+}
+
+// get returns the muxs base URI for the given gateway.
+func (p *muxsPool) get(scheme string, gatewayID lorawan.EUI64) (string, error) {
+	muxs := p.muxs
+
+	if p.dnsSRV != "" {
+		_, records, err := p.lookupSRV("", "", p.dnsSRV)
+		if err != nil {
+			return "", errors.Wrap(err, "lookup srv error")
+		}
+
+		muxs = nil
+		for _, r := range records {
+			muxs = append(muxs, fmt.Sprintf("%s://%s:%d", scheme, strings.TrimSuffix(r.Target, "."), r.Port))
+		}
+	}
+
+	if len(muxs) == 0 {
+		return "", errors.New("muxs pool is empty")
+	}
+
+	if p.selection == muxsSelectionRandom {
+		return muxs[rand.Intn(len(muxs))], nil
+	}
+
+	return selectMuxs(muxs, gatewayID), nil
+}
+
+// selectMuxs selects the muxs for the given gateway using rendezvous
+// (highest random weight) hashing. Adding or removing a muxs only affects
+// the gateways assigned to that muxs.
+func selectMuxs(muxs []string, gatewayID lorawan.EUI64) string {
+	var out string
+	var max uint64
+
+	for _, m := range muxs {
+		h := fnv.New64a()
+		h.Write(gatewayID[:])
+		h.Write([]byte(m))
+
+		if w := h.Sum64(); out == "" || w > max {
+			out = m
+			max = w
+		}
+	}
+
+	return out
+}
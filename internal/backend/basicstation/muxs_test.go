@@ -0,0 +1,112 @@
+package basicstation
+
+import (
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestMuxsPool(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	muxs := []string{
+		"wss://bridge-1.example.com:3001",
+		"wss://bridge-2.example.com:3001",
+		"wss://bridge-3.example.com:3001",
+	}
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+
+		var p muxsPool
+		assert.False(p.enabled())
+	})
+
+	t.Run("Invalid selection", func(t *testing.T) {
+		assert := require.New(t)
+
+		p := muxsPool{muxs: muxs, selection: "foo"}
+		assert.EqualError(p.validate(), "invalid router_info selection: foo")
+	})
+
+	t.Run("Static list", func(t *testing.T) {
+		assert := require.New(t)
+
+		p := muxsPool{muxs: muxs, selection: muxsSelectionConsistentHashing}
+		assert.True(p.enabled())
+		assert.NoError(p.validate())
+
+		uri, err := p.get("ws", gatewayID)
+		assert.NoError(err)
+		assert.Contains(muxs, uri)
+
+		// the same gateway is always steered to the same muxs
+		for i := 0; i < 10; i++ {
+			u, err := p.get("ws", gatewayID)
+			assert.NoError(err)
+			assert.Equal(uri, u)
+		}
+	})
+
+	t.Run("DNS SRV", func(t *testing.T) {
+		assert := require.New(t)
+
+		p := muxsPool{
+			dnsSRV:    "_lora-gateway-bridge._tcp.example.com",
+			selection: muxsSelectionRandom,
+			lookupSRV: func(service, proto, name string) (string, []*net.SRV, error) {
+				assert.Equal("_lora-gateway-bridge._tcp.example.com", name)
+				return "", []*net.SRV{
+					{Target: "bridge-1.example.com.", Port: 3001},
+				}, nil
+			},
+		}
+		assert.True(p.enabled())
+
+		uri, err := p.get("wss", gatewayID)
+		assert.NoError(err)
+		assert.Equal("wss://bridge-1.example.com:3001", uri)
+	})
+
+	t.Run("DNS SRV error", func(t *testing.T) {
+		assert := require.New(t)
+
+		p := muxsPool{
+			dnsSRV:    "_lora-gateway-bridge._tcp.example.com",
+			selection: muxsSelectionConsistentHashing,
+			lookupSRV: func(service, proto, name string) (string, []*net.SRV, error) {
+				return "", nil, errors.New("no such host")
+			},
+		}
+
+		_, err := p.get("ws", gatewayID)
+		assert.EqualError(err, "lookup srv error: no such host")
+	})
+}
+
+func TestSelectMuxs(t *testing.T) {
+	assert := require.New(t)
+
+	muxs := []string{
+		"wss://bridge-1.example.com:3001",
+		"wss://bridge-2.example.com:3001",
+		"wss://bridge-3.example.com:3001",
+	}
+
+	assignments := make(map[lorawan.EUI64]string)
+	for i := 0; i < 100; i++ {
+		gatewayID := lorawan.EUI64{0, 0, 0, 0, 0, 0, 0, byte(i)}
+		assignments[gatewayID] = selectMuxs(muxs, gatewayID)
+	}
+
+	// removing a muxs only moves the gateways assigned to that muxs
+	for gatewayID, m := range assignments {
+		if m == muxs[2] {
+			continue
+		}
+		assert.Equal(m, selectMuxs(muxs[:2], gatewayID))
+	}
+}
@@ -0,0 +1,112 @@
+package basicstation
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// defaultPathPrefix is the path prefix of the gateway endpoint used when no
+// path prefix is configured.
+const defaultPathPrefix = "/gateway"
+
+// requestPolicy implements the origin, host and path policy of the
+// websocket requests.
+type requestPolicy struct {
+	// pathPrefix of the gateway endpoint, the gateway ID is appended to it
+	// (e.g. /gateway/0102030405060708).
+	pathPrefix string
+
+	// allowedOrigins contains the allowed Origin header values. When empty,
+	// all origins are allowed.
+	allowedOrigins []string
+
+	// allowedHosts contains the allowed Host header values. When empty, all
+	// hosts are allowed.
+	allowedHosts []string
+}
+
+func newRequestPolicy(pathPrefix string, allowedOrigins, allowedHosts []string) requestPolicy {
+	if pathPrefix == "" {
+		pathPrefix = defaultPathPrefix
+	}
+
+	return requestPolicy{
+		pathPrefix:     "/" + strings.Trim(pathPrefix, "/"),
+		allowedOrigins: allowedOrigins,
+		allowedHosts:   allowedHosts,
+	}
+}
+
+// gatewayPattern returns the pattern of the gateway endpoint, to register
+// with the http.ServeMux.
+func (p requestPolicy) gatewayPattern() string {
+	return strings.TrimSuffix(p.pathPrefix, "/") + "/"
+}
+
+// gatewayURI returns the URI of the gateway endpoint for the given base URI
+// (e.g. wss://lns.example.com:3001) and gateway ID.
+func (p requestPolicy) gatewayURI(base string, gatewayID lorawan.EUI64) string {
+	return fmt.Sprintf("%s%s%s", strings.TrimSuffix(base, "/"), p.gatewayPattern(), gatewayID)
+}
+
+// gatewayID returns the gateway ID from the given URL path. It returns an
+// error when the path does not match the configured path prefix.
+func (p requestPolicy) gatewayID(path string) (lorawan.EUI64, error) {
+	var gatewayID lorawan.EUI64
+
+	if !strings.HasPrefix(path, p.gatewayPattern()) {
+		return gatewayID, errors.Errorf("path does not match prefix %s", p.pathPrefix)
+	}
+
+	s := strings.TrimPrefix(path, p.gatewayPattern())
+	if err := gatewayID.UnmarshalText([]byte(s)); err != nil {
+		return gatewayID, errors.Wrap(err, "parse gateway id error")
+	}
+
+	return gatewayID, nil
+}
+
+// checkOrigin returns true when the Origin header of the request is allowed.
+// Requests without Origin header (e.g. from the Basic Station) are always
+// allowed, as this header is only set by browsers.
+func (p requestPolicy) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || len(p.allowedOrigins) == 0 {
+		return true
+	}
+
+	for _, allowed := range p.allowedOrigins {
+		if allowed == "*" || strings.EqualFold(strings.TrimSuffix(allowed, "/"), origin) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkHost returns true when the Host header of the request is allowed.
+// An allowed host without port matches the host on any port.
+func (p requestPolicy) checkHost(r *http.Request) bool {
+	if len(p.allowedHosts) == 0 {
+		return true
+	}
+
+	hostname := r.Host
+	if h, _, err := net.SplitHostPort(r.Host); err == nil {
+		hostname = h
+	}
+
+	for _, allowed := range p.allowedHosts {
+		if strings.EqualFold(allowed, r.Host) || strings.EqualFold(allowed, hostname) {
+			return true
+		}
+	}
+
+	return false
+}
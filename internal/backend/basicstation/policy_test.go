@@ -0,0 +1,179 @@
+package basicstation
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestRequestPolicyGatewayID(t *testing.T) {
+	tests := []struct {
+		Name              string
+		PathPrefix        string
+		Path              string
+		ExpectedGatewayID lorawan.EUI64
+		ExpectedURI       string
+		ExpectedError     string
+	}{
+		{
+			Name:              "default prefix",
+			PathPrefix:        "/gateway",
+			Path:              "/gateway/0102030405060708",
+			ExpectedGatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			ExpectedURI:       "wss://lns.example.com:3001/gateway/0102030405060708",
+		},
+		{
+			Name:              "custom prefix",
+			PathPrefix:        "lns/gateway/",
+			Path:              "/lns/gateway/0102030405060708",
+			ExpectedGatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			ExpectedURI:       "wss://lns.example.com:3001/lns/gateway/0102030405060708",
+		},
+		{
+			Name:              "empty prefix",
+			Path:              "/gateway/0102030405060708",
+			ExpectedGatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			ExpectedURI:       "wss://lns.example.com:3001/gateway/0102030405060708",
+		},
+		{
+			Name:              "root prefix",
+			PathPrefix:        "/",
+			Path:              "/0102030405060708",
+			ExpectedGatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			ExpectedURI:       "wss://lns.example.com:3001/0102030405060708",
+		},
+		{
+			Name:          "prefix mismatch",
+			PathPrefix:    "/lns/gateway",
+			Path:          "/gateway/0102030405060708",
+			ExpectedError: "path does not match prefix /lns/gateway",
+		},
+		{
+			Name:          "nested path",
+			PathPrefix:    "/gateway",
+			Path:          "/gateway/foo/0102030405060708",
+			ExpectedError: "parse gateway id error",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			p := newRequestPolicy(tst.PathPrefix, nil, nil)
+			gatewayID, err := p.gatewayID(tst.Path)
+			if tst.ExpectedError != "" {
+				assert.Error(err)
+				assert.Contains(err.Error(), tst.ExpectedError)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedGatewayID, gatewayID)
+			assert.Equal(tst.ExpectedURI, p.gatewayURI("wss://lns.example.com:3001/", gatewayID))
+		})
+	}
+}
+
+func TestRequestPolicyCheckOrigin(t *testing.T) {
+	tests := []struct {
+		Name           string
+		AllowedOrigins []string
+		Origin         string
+		Expected       bool
+	}{
+		{
+			Name:     "no allowed origins",
+			Origin:   "https://evil.example.com",
+			Expected: true,
+		},
+		{
+			Name:           "no origin header",
+			AllowedOrigins: []string{"https://console.example.com"},
+			Expected:       true,
+		},
+		{
+			Name:           "allowed origin",
+			AllowedOrigins: []string{"https://console.example.com/"},
+			Origin:         "https://Console.example.com",
+			Expected:       true,
+		},
+		{
+			Name:           "wildcard",
+			AllowedOrigins: []string{"*"},
+			Origin:         "https://evil.example.com",
+			Expected:       true,
+		},
+		{
+			Name:           "not allowed origin",
+			AllowedOrigins: []string{"https://console.example.com"},
+			Origin:         "https://evil.example.com",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			r := httptest.NewRequest("GET", "/gateway/0102030405060708", nil)
+			if tst.Origin != "" {
+				r.Header.Set("Origin", tst.Origin)
+			}
+
+			p := newRequestPolicy("/gateway", tst.AllowedOrigins, nil)
+			assert.Equal(tst.Expected, p.checkOrigin(r))
+		})
+	}
+}
+
+func TestRequestPolicyCheckHost(t *testing.T) {
+	tests := []struct {
+		Name         string
+		AllowedHosts []string
+		Host         string
+		Expected     bool
+	}{
+		{
+			Name:     "no allowed hosts",
+			Host:     "10.0.0.1:3001",
+			Expected: true,
+		},
+		{
+			Name:         "allowed host any port",
+			AllowedHosts: []string{"lns.example.com"},
+			Host:         "LNS.example.com:3001",
+			Expected:     true,
+		},
+		{
+			Name:         "allowed host and port",
+			AllowedHosts: []string{"lns.example.com:3001"},
+			Host:         "lns.example.com:3001",
+			Expected:     true,
+		},
+		{
+			Name:         "port mismatch",
+			AllowedHosts: []string{"lns.example.com:3001"},
+			Host:         "lns.example.com:3002",
+		},
+		{
+			Name:         "host mismatch",
+			AllowedHosts: []string{"lns.example.com"},
+			Host:         "10.0.0.1:3001",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			r := httptest.NewRequest("GET", "/gateway/0102030405060708", nil)
+			r.Host = tst.Host
+
+			p := newRequestPolicy("/gateway", nil, tst.AllowedHosts)
+			assert.Equal(tst.Expected, p.checkHost(r))
+		})
+	}
+}
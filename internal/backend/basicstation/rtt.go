@@ -0,0 +1,40 @@
+package basicstation
+
+import (
+	"encoding/binary"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan/gps"
+)
+
+// marshalPingPayload returns the payload of the WebSocket Ping message,
+// containing the time at which the Ping was sent. The gateway echoes this
+// payload in the Pong message.
+func marshalPingPayload(now time.Time) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(now.UnixNano()))
+	return b
+}
+
+// pingPongRTT returns the round-trip time given the payload of the received
+// Pong message.
+func pingPongRTT(payload []byte, now time.Time) (time.Duration, error) {
+	if len(payload) != 8 {
+		return 0, errors.New("invalid pong payload length")
+	}
+
+	sentAt := time.Unix(0, int64(binary.BigEndian.Uint64(payload)))
+	rtt := now.Sub(sentAt)
+	if rtt < 0 {
+		return 0, errors.New("pong received before ping was sent")
+	}
+
+	return rtt, nil
+}
+
+// timeSinceGPSEpoch returns the GPS time in microseconds for the given time.
+func timeSinceGPSEpoch(t time.Time) int64 {
+	return int64(gps.Time(t).TimeSinceGPSEpoch() / time.Microsecond)
+}
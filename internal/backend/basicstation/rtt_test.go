@@ -0,0 +1,54 @@
+package basicstation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPingPongRTT(t *testing.T) {
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		Name     string
+		Payload  []byte
+		Now      time.Time
+		Expected time.Duration
+		Error    bool
+	}{
+		{
+			Name:     "valid payload",
+			Payload:  marshalPingPayload(now),
+			Now:      now.Add(35 * time.Millisecond),
+			Expected: 35 * time.Millisecond,
+		},
+		{
+			Name:    "empty payload",
+			Payload: nil,
+			Now:     now,
+			Error:   true,
+		},
+		{
+			Name:    "pong before ping",
+			Payload: marshalPingPayload(now),
+			Now:     now.Add(-time.Second),
+			Error:   true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			rtt, err := pingPongRTT(tst.Payload, tst.Now)
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.Expected, rtt)
+		})
+	}
+}
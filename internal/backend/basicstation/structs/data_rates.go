@@ -0,0 +1,17 @@
+package structs
+
+import (
+	"github.com/brocaar/lorawan/band"
+)
+
+// DataRates defines the data-rate lookups used when converting between the
+// Basic Station messages and the LoRa Gateway Bridge messages. It is
+// implemented by band.Band and by the externally loaded regional parameters.
+type DataRates interface {
+	// GetDataRate returns the data-rate for the given index.
+	GetDataRate(dr int) (band.DataRate, error)
+
+	// GetDataRateIndex returns the index of the given uplink (or downlink)
+	// data-rate.
+	GetDataRateIndex(uplink bool, dataRate band.DataRate) (int, error)
+}
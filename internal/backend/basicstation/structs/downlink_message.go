@@ -36,7 +36,7 @@ type DownlinkFrame struct {
 }
 
 // DownlinkFrameFromProto convers the given protobuf message to a DownlinkFrame.
-func DownlinkFrameFromProto(loraBand band.Band, pb gw.DownlinkFrame) (DownlinkFrame, error) {
+func DownlinkFrameFromProto(loraBand DataRates, pb gw.DownlinkFrame) (DownlinkFrame, error) {
 
 	if pb.TxInfo == nil {
 		return DownlinkFrame{}, errors.New("tx_info must not be nil")
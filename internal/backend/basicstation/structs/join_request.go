@@ -7,7 +7,6 @@ import (
 
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
-	"github.com/brocaar/lorawan/band"
 )
 
 // JoinRequest implements the join-request message.
@@ -23,7 +22,7 @@ type JoinRequest struct {
 }
 
 // JoinRequestToProto converts the JoinRequest to the protobuf struct.
-func JoinRequestToProto(loraBand band.Band, gatewayID lorawan.EUI64, jr JoinRequest) (gw.UplinkFrame, error) {
+func JoinRequestToProto(loraBand DataRates, gatewayID lorawan.EUI64, jr JoinRequest) (gw.UplinkFrame, error) {
 	var pb gw.UplinkFrame
 	if err := SetRadioMetaDataToProto(loraBand, gatewayID, jr.RadioMetaData, &pb); err != nil {
 		return pb, errors.Wrap(err, "set radio meta-data error")
@@ -18,6 +18,9 @@ const (
 	ProprietaryDataFrameMessage MessageType = "propdf"
 	DownlinkMessage             MessageType = "dnmsg"
 	DownlinkTransmittedMessage  MessageType = "dntxed"
+	RemoteShellMessage          MessageType = "rmtsh"
+	RunCommandMessage           MessageType = "runcmd"
+	TimeSyncMessage             MessageType = "timesync"
 )
 
 type messageTypePayload struct {
@@ -27,9 +27,26 @@ type RadioMetaDataUpInfo struct {
 	GPSTime int64   `json:"gpstime"`
 	RSSI    float32 `json:"rssi"`
 	SNR     float32 `json:"snr"`
+
+	// RSig contains the received signal information per antenna (optional,
+	// only reported by gateways with multiple antennas).
+	RSig []RadioMetaDataRSig `json:"rsig,omitempty"`
 }
 
-func SetRadioMetaDataToProto(loraBand band.Band, gatewayID lorawan.EUI64, rmd RadioMetaData, pb *gw.UplinkFrame) error {
+// RadioMetaDataRSig contains the received signal information of a single
+// antenna.
+type RadioMetaDataRSig struct {
+	Antenna int     `json:"ant"`
+	Channel int     `json:"chan"`
+	RSSI    float32 `json:"rssi"`
+	SNR     float32 `json:"snr"`
+
+	// FTS contains the fine timestamp (nanoseconds within the GPS second),
+	// -1 or omitted when not available.
+	FTS *int64 `json:"fts,omitempty"`
+}
+
+func SetRadioMetaDataToProto(loraBand DataRates, gatewayID lorawan.EUI64, rmd RadioMetaData, pb *gw.UplinkFrame) error {
 	//
 	// TxInfo
 	//
@@ -70,6 +87,7 @@ func SetRadioMetaDataToProto(loraBand band.Band, gatewayID lorawan.EUI64, rmd Ra
 		GatewayId: gatewayID[:],
 		Rssi:      int32(rmd.UpInfo.RSSI),
 		LoraSnr:   float64(rmd.UpInfo.SNR),
+		Board:     uint32(XTimeRadioUnit(rmd.UpInfo.XTime)),
 	}
 
 	if gpsTime := rmd.UpInfo.GPSTime; gpsTime != 0 {
@@ -91,3 +109,48 @@ func SetRadioMetaDataToProto(loraBand band.Band, gatewayID lorawan.EUI64, rmd Ra
 
 	return nil
 }
+
+// GetUplinkFramesPerAntenna returns the given uplink frame once per antenna
+// in the rsig array of the radio meta-data, each with the antenna index, RSSI,
+// SNR and (when available) fine timestamp of that antenna. When the rsig
+// array is empty, the uplink frame is returned as-is.
+func GetUplinkFramesPerAntenna(pb gw.UplinkFrame, rmd RadioMetaData) ([]gw.UplinkFrame, error) {
+	if len(rmd.UpInfo.RSig) == 0 {
+		return []gw.UplinkFrame{pb}, nil
+	}
+
+	var out []gw.UplinkFrame
+
+	for _, rsig := range rmd.UpInfo.RSig {
+		frame := pb
+
+		rxInfo := *pb.RxInfo
+		rxInfo.Antenna = uint32(rsig.Antenna)
+		rxInfo.Channel = uint32(rsig.Channel)
+		rxInfo.Rssi = int32(rsig.RSSI)
+		rxInfo.LoraSnr = float64(rsig.SNR)
+		frame.RxInfo = &rxInfo
+
+		// The fine timestamp is relative to the GPS second, it can only be
+		// used when the GPS time is known.
+		if rsig.FTS != nil && *rsig.FTS >= 0 && rmd.UpInfo.GPSTime != 0 {
+			gpsTimeDur := time.Duration(rmd.UpInfo.GPSTime) * time.Microsecond
+			gpsTimeDur = gpsTimeDur.Truncate(time.Second) + time.Duration(*rsig.FTS)
+			ts, err := ptypes.TimestampProto(time.Time(gps.NewTimeFromTimeSinceGPSEpoch(gpsTimeDur)))
+			if err != nil {
+				return nil, errors.Wrap(err, "timestamp proto error")
+			}
+
+			rxInfo.FineTimestampType = gw.FineTimestampType_PLAIN
+			rxInfo.FineTimestamp = &gw.UplinkRXInfo_PlainFineTimestamp{
+				PlainFineTimestamp: &gw.PlainFineTimestamp{
+					Time: ts,
+				},
+			}
+		}
+
+		out = append(out, frame)
+	}
+
+	return out, nil
+}
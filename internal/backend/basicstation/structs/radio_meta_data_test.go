@@ -140,3 +140,85 @@ func TestSetRadioMetaDataToProto(t *testing.T) {
 		})
 	}
 }
+
+func TestGetUplinkFramesPerAntenna(t *testing.T) {
+	assert := require.New(t)
+
+	fts := int64(500)
+	ftsUnavailable := int64(-1)
+	gpsTime := 5*time.Second + 123*time.Millisecond
+
+	ftsTime, err := ptypes.TimestampProto(time.Time(gps.NewTimeFromTimeSinceGPSEpoch(5*time.Second + 500)))
+	assert.NoError(err)
+
+	in := gw.UplinkFrame{
+		PhyPayload: []byte{1, 2, 3, 4},
+		RxInfo: &gw.UplinkRXInfo{
+			GatewayId: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+			Rssi:      -50,
+			LoraSnr:   5.5,
+		},
+	}
+
+	tests := []struct {
+		Name string
+		In   RadioMetaData
+		Out  []gw.UplinkFrame
+	}{
+		{
+			Name: "no rsig",
+			In:   RadioMetaData{},
+			Out:  []gw.UplinkFrame{in},
+		},
+		{
+			Name: "two antennas",
+			In: RadioMetaData{
+				UpInfo: RadioMetaDataUpInfo{
+					GPSTime: int64(gpsTime / time.Microsecond),
+					RSig: []RadioMetaDataRSig{
+						{Antenna: 0, Channel: 3, RSSI: -50, SNR: 5.5, FTS: &fts},
+						{Antenna: 1, Channel: 3, RSSI: -60, SNR: 2.5, FTS: &ftsUnavailable},
+					},
+				},
+			},
+			Out: []gw.UplinkFrame{
+				{
+					PhyPayload: []byte{1, 2, 3, 4},
+					RxInfo: &gw.UplinkRXInfo{
+						GatewayId:         []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+						Rssi:              -50,
+						LoraSnr:           5.5,
+						Antenna:           0,
+						Channel:           3,
+						FineTimestampType: gw.FineTimestampType_PLAIN,
+						FineTimestamp: &gw.UplinkRXInfo_PlainFineTimestamp{
+							PlainFineTimestamp: &gw.PlainFineTimestamp{
+								Time: ftsTime,
+							},
+						},
+					},
+				},
+				{
+					PhyPayload: []byte{1, 2, 3, 4},
+					RxInfo: &gw.UplinkRXInfo{
+						GatewayId: []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08},
+						Rssi:      -60,
+						LoraSnr:   2.5,
+						Antenna:   1,
+						Channel:   3,
+					},
+				},
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			out, err := GetUplinkFramesPerAntenna(in, tst.In)
+			assert.NoError(err)
+			assert.Equal(tst.Out, out)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/beacon"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
 	"github.com/brocaar/lora-gateway-bridge/internal/config/sx1301v1"
 	"github.com/brocaar/loraserver/api/common"
@@ -36,6 +37,31 @@ type RouterConfig struct {
 	FreqRange   []uint32     `json:"freq_range"`
 	DRs         [][]int      `json:"DRs"`
 	SX1301Conf  []SX1301Conf `json:"sx1301_conf"`
+
+	// Bcning contains the Class B beaconing configuration (optional).
+	Bcning *RouterConfigBeaconing `json:"bcning,omitempty"`
+}
+
+// RouterConfigBeaconing implements the Class B beaconing configuration.
+type RouterConfigBeaconing struct {
+	DR     int      `json:"DR"`
+	Layout []int    `json:"layout"`
+	Freqs  []uint32 `json:"freqs"`
+}
+
+// SetBeaconing sets the beaconing configuration of the router-config. When
+// the given beacon configuration is nil, beaconing is disabled.
+func (rc *RouterConfig) SetBeaconing(c *beacon.Configuration) {
+	if c == nil {
+		rc.Bcning = nil
+		return
+	}
+
+	rc.Bcning = &RouterConfigBeaconing{
+		DR:     c.DataRate,
+		Layout: c.Layout[:],
+		Freqs:  c.Frequencies,
+	}
 }
 
 // SX1301Conf implements a single SX1301 configuration.
@@ -83,7 +109,7 @@ type SX1301ConfChanMultiSF struct {
 
 // GetRouterConfigOld returns the router-config message.
 // Currently only 8 multi SF + 1 single + 1 FSK channels are supported.
-func GetRouterConfigOld(region band.Name, netIDs []lorawan.NetID, joinEUIs [][2]lorawan.EUI64, freqMin, freqMax uint32, config gw.GatewayConfiguration) (RouterConfig, error) {
+func GetRouterConfigOld(region band.Name, loraBand DataRates, netIDs []lorawan.NetID, joinEUIs [][2]lorawan.EUI64, freqMin, freqMax uint32, config gw.GatewayConfiguration) (RouterConfig, error) {
 	c := RouterConfig{
 		MessageType: RouterConfigMessage,
 		Region:      regionNameMapping[region],
@@ -106,28 +132,7 @@ func GetRouterConfigOld(region band.Name, netIDs []lorawan.NetID, joinEUIs [][2]
 	}
 
 	// Set data-rates
-	b, err := band.GetConfig(region, false, lorawan.DwellTimeNoLimit)
-	if err != nil {
-		return c, errors.Wrap(err, "get band config error")
-	}
-	for i := 0; i < 16; i++ {
-		dr, err := b.GetDataRate(i)
-		if err != nil {
-			c.DRs = append(c.DRs, []int{-1, 0, 0})
-			continue
-		}
-
-		var dnOnly int
-		if _, err := b.GetDataRateIndex(true, dr); err != nil {
-			dnOnly = 1
-		}
-
-		c.DRs = append(c.DRs, []int{
-			dr.SpreadFactor,
-			dr.Bandwidth,
-			dnOnly,
-		})
-	}
+	c.DRs = getDataRates(loraBand)
 
 	// Get radio frequencies
 	radioFrequencies, err := sx1301v1.GetRadioFrequencies(config.Channels)
@@ -210,7 +215,7 @@ func GetRouterConfigOld(region band.Name, netIDs []lorawan.NetID, joinEUIs [][2]
 }
 
 // GetRouterConfig returns the router-config message.
-func GetRouterConfig(region band.Name, netIDs []lorawan.NetID, joinEUIs [][2]lorawan.EUI64, freqMin, freqMax uint32, concentrators []config.BasicStationConcentrator) (RouterConfig, error) {
+func GetRouterConfig(region band.Name, loraBand DataRates, netIDs []lorawan.NetID, joinEUIs [][2]lorawan.EUI64, freqMin, freqMax uint32, concentrators []config.BasicStationConcentrator) (RouterConfig, error) {
 	concentratorCount := len(concentrators)
 
 	c := RouterConfig{
@@ -235,28 +240,7 @@ func GetRouterConfig(region band.Name, netIDs []lorawan.NetID, joinEUIs [][2]lor
 	}
 
 	// Set data-rates
-	b, err := band.GetConfig(region, false, lorawan.DwellTimeNoLimit)
-	if err != nil {
-		return c, errors.Wrap(err, "get band config error")
-	}
-	for i := 0; i < 16; i++ {
-		dr, err := b.GetDataRate(i)
-		if err != nil {
-			c.DRs = append(c.DRs, []int{-1, 0, 0})
-			continue
-		}
-
-		var dnOnly int
-		if _, err := b.GetDataRateIndex(true, dr); err != nil {
-			dnOnly = 1
-		}
-
-		c.DRs = append(c.DRs, []int{
-			dr.SpreadFactor,
-			dr.Bandwidth,
-			dnOnly,
-		})
-	}
+	c.DRs = getDataRates(loraBand)
 
 	// Iterate over concentrators
 	for concentratorNum, concentratorConf := range concentrators {
@@ -381,3 +365,29 @@ func GetRouterConfig(region band.Name, netIDs []lorawan.NetID, joinEUIs [][2]lor
 
 	return c, nil
 }
+
+// getDataRates returns the data-rates table of the router-config message.
+func getDataRates(loraBand DataRates) [][]int {
+	var out [][]int
+
+	for i := 0; i < 16; i++ {
+		dr, err := loraBand.GetDataRate(i)
+		if err != nil {
+			out = append(out, []int{-1, 0, 0})
+			continue
+		}
+
+		var dnOnly int
+		if _, err := loraBand.GetDataRateIndex(true, dr); err != nil {
+			dnOnly = 1
+		}
+
+		out = append(out, []int{
+			dr.SpreadFactor,
+			dr.Bandwidth,
+			dnOnly,
+		})
+	}
+
+	return out
+}
@@ -1,10 +1,12 @@
 package structs
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/beacon"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
 	"github.com/brocaar/loraserver/api/common"
 	"github.com/brocaar/loraserver/api/gw"
@@ -506,7 +508,10 @@ func TestRouterConfigOld(t *testing.T) {
 		t.Run(tst.Name, func(t *testing.T) {
 			assert := require.New(t)
 
-			rc, err := GetRouterConfigOld(tst.Region, tst.NetIDs, tst.JoinEUIs, tst.FrequencyMin, tst.FrequencyMax, tst.GatewayConfiguration)
+			loraBand, err := band.GetConfig(tst.Region, false, lorawan.DwellTimeNoLimit)
+			assert.NoError(err)
+
+			rc, err := GetRouterConfigOld(tst.Region, loraBand, tst.NetIDs, tst.JoinEUIs, tst.FrequencyMin, tst.FrequencyMax, tst.GatewayConfiguration)
 			assert.Equal(tst.ExpectedError, err)
 			if err != nil {
 				return
@@ -822,7 +827,10 @@ func TestRouterConfig(t *testing.T) {
 			var conf config.Config
 			conf.Backend.BasicStation.Concentrators = tst.Concentrators
 
-			rc, err := GetRouterConfig(tst.Region, tst.NetIDs, tst.JoinEUIs, tst.FrequencyMin, tst.FrequencyMax, conf.Backend.BasicStation.Concentrators)
+			loraBand, err := band.GetConfig(tst.Region, false, lorawan.DwellTimeNoLimit)
+			assert.NoError(err)
+
+			rc, err := GetRouterConfig(tst.Region, loraBand, tst.NetIDs, tst.JoinEUIs, tst.FrequencyMin, tst.FrequencyMax, conf.Backend.BasicStation.Concentrators)
 			assert.Equal(tst.ExpectedError, err)
 			if err != nil {
 				return
@@ -831,3 +839,26 @@ func TestRouterConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestRouterConfigSetBeaconing(t *testing.T) {
+	assert := require.New(t)
+
+	var rc RouterConfig
+	rc.SetBeaconing(&beacon.Configuration{
+		DataRate:    3,
+		Frequencies: []uint32{869525000},
+		Layout:      [3]int{2, 8, 17},
+	})
+	assert.Equal(&RouterConfigBeaconing{
+		DR:     3,
+		Layout: []int{2, 8, 17},
+		Freqs:  []uint32{869525000},
+	}, rc.Bcning)
+
+	b, err := json.Marshal(rc.Bcning)
+	assert.NoError(err)
+	assert.Equal(`{"DR":3,"layout":[2,8,17],"freqs":[869525000]}`, string(b))
+
+	rc.SetBeaconing(nil)
+	assert.Nil(rc.Bcning)
+}
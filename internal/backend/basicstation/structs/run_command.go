@@ -0,0 +1,9 @@
+package structs
+
+// RunCommand implements the runcmd message, which instructs the gateway to
+// run the given command in the background.
+type RunCommand struct {
+	MessageType MessageType `json:"msgtype"`
+	Command     string      `json:"command"`
+	Arguments   []string    `json:"arguments"`
+}
@@ -0,0 +1,17 @@
+package structs
+
+// TimeSyncRequest implements the timesync request message sent by the
+// gateway.
+type TimeSyncRequest struct {
+	MessageType MessageType `json:"msgtype"`
+	TxTime      float64     `json:"txtime"`
+}
+
+// TimeSyncResponse implements the timesync response message. The TxTime
+// must be copied from the request, the GPSTime is the number of microseconds
+// since GPS epoch.
+type TimeSyncResponse struct {
+	MessageType MessageType `json:"msgtype"`
+	TxTime      float64     `json:"txtime"`
+	GPSTime     int64       `json:"gpstime"`
+}
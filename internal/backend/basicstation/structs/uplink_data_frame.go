@@ -8,7 +8,6 @@ import (
 
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
-	"github.com/brocaar/lorawan/band"
 )
 
 // UplinkDataFrame implements the uplink data-frame message.
@@ -27,7 +26,7 @@ type UplinkDataFrame struct {
 }
 
 // UplinkDataFrameToProto converts the UplinkDataFrame to the protobuf struct.
-func UplinkDataFrameToProto(loraBand band.Band, gatewayID lorawan.EUI64, updf UplinkDataFrame) (gw.UplinkFrame, error) {
+func UplinkDataFrameToProto(loraBand DataRates, gatewayID lorawan.EUI64, updf UplinkDataFrame) (gw.UplinkFrame, error) {
 	var pb gw.UplinkFrame
 	if err := SetRadioMetaDataToProto(loraBand, gatewayID, updf.RadioMetaData, &pb); err != nil {
 		return pb, errors.Wrap(err, "set radio meta-data error")
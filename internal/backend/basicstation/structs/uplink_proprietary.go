@@ -5,7 +5,6 @@ import (
 
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
-	"github.com/brocaar/lorawan/band"
 	"github.com/pkg/errors"
 )
 
@@ -18,7 +17,7 @@ type UplinkProprietaryFrame struct {
 }
 
 // UplinkProprietaryFrameToProto converts the UplinkProprietaryFrame to the protobuf struct.
-func UplinkProprietaryFrameToProto(loraBand band.Band, gatewayID lorawan.EUI64, uppf UplinkProprietaryFrame) (gw.UplinkFrame, error) {
+func UplinkProprietaryFrameToProto(loraBand DataRates, gatewayID lorawan.EUI64, uppf UplinkProprietaryFrame) (gw.UplinkFrame, error) {
 	var pb gw.UplinkFrame
 	if err := SetRadioMetaDataToProto(loraBand, gatewayID, uppf.RadioMetaData, &pb); err != nil {
 		return pb, errors.Wrap(err, "set radio meta-data error")
@@ -0,0 +1,167 @@
+package structs
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// radioMetaDataFields contains the required radio meta-data fields.
+var radioMetaDataFields = []string{"DR", "Freq", "upinfo"}
+
+// upInfoFields contains the required upinfo fields.
+var upInfoFields = []string{"rctx", "xtime", "rssi", "snr"}
+
+// requiredFields contains the required fields per message type.
+var requiredFields = map[MessageType][]string{
+	VersionMessage:              {"station", "protocol"},
+	UplinkDataFrameMessage:      {"MHdr", "DevAddr", "FCtrl", "FCnt", "FOpts", "FPort", "FRMPayload", "MIC"},
+	JoinRequestMessage:          {"MHdr", "JoinEui", "DevEui", "DevNonce", "MIC"},
+	ProprietaryDataFrameMessage: {"FRMPayload"},
+	DownlinkTransmittedMessage:  {"diid"},
+	TimeSyncMessage:             {"txtime"},
+}
+
+// Validate validates the given message against the protocol specification.
+// It returns an error when a required field is missing or when a value is
+// out of its valid range.
+func Validate(msgType MessageType, b []byte) error {
+	fields, ok := requiredFields[msgType]
+	if !ok {
+		return nil
+	}
+
+	kv, err := lowerKeys(b)
+	if err != nil {
+		return err
+	}
+
+	if err := requireFields(kv, fields); err != nil {
+		return err
+	}
+
+	switch msgType {
+	case UplinkDataFrameMessage:
+		var pl UplinkDataFrame
+		if err := json.Unmarshal(b, &pl); err != nil {
+			return errors.Wrap(err, "unmarshal json error")
+		}
+
+		if mType := pl.MHDR >> 5; mType < 2 || mType > 5 {
+			return fmt.Errorf("MHdr: invalid message type for data frame: %d", mType)
+		}
+		if pl.FPort < -1 || pl.FPort > 255 {
+			return fmt.Errorf("FPort: out of range: %d", pl.FPort)
+		}
+		if err := validateHex("FOpts", pl.FOpts); err != nil {
+			return err
+		}
+		if err := validateHex("FRMPayload", pl.FRMPayload); err != nil {
+			return err
+		}
+
+		return validateRadioMetaData(kv, pl.RadioMetaData)
+	case JoinRequestMessage:
+		var pl JoinRequest
+		if err := json.Unmarshal(b, &pl); err != nil {
+			return errors.Wrap(err, "unmarshal json error")
+		}
+
+		if mType := pl.MHDR >> 5; mType != 0 {
+			return fmt.Errorf("MHdr: invalid message type for join-request: %d", mType)
+		}
+
+		return validateRadioMetaData(kv, pl.RadioMetaData)
+	case ProprietaryDataFrameMessage:
+		var pl UplinkProprietaryFrame
+		if err := json.Unmarshal(b, &pl); err != nil {
+			return errors.Wrap(err, "unmarshal json error")
+		}
+
+		if err := validateHex("FRMPayload", pl.FRMPayload); err != nil {
+			return err
+		}
+
+		return validateRadioMetaData(kv, pl.RadioMetaData)
+	case TimeSyncMessage:
+		var pl TimeSyncRequest
+		if err := json.Unmarshal(b, &pl); err != nil {
+			return errors.Wrap(err, "unmarshal json error")
+		}
+
+		if pl.TxTime <= 0 {
+			return fmt.Errorf("txtime: out of range: %f", pl.TxTime)
+		}
+	}
+
+	return nil
+}
+
+func validateRadioMetaData(kv map[string]json.RawMessage, rmd RadioMetaData) error {
+	if err := requireFields(kv, radioMetaDataFields); err != nil {
+		return err
+	}
+
+	upInfo, err := lowerKeys(kv["upinfo"])
+	if err != nil {
+		return errors.Wrap(err, "upinfo")
+	}
+	if err := requireFields(upInfo, upInfoFields); err != nil {
+		return errors.Wrap(err, "upinfo")
+	}
+
+	if rmd.DR < 0 || rmd.DR > 15 {
+		return fmt.Errorf("DR: out of range: %d", rmd.DR)
+	}
+	if rmd.Frequency == 0 {
+		return errors.New("Freq: must be greater than 0")
+	}
+	if rmd.UpInfo.XTime == 0 {
+		return errors.New("upinfo.xtime: must be greater than 0")
+	}
+	if rmd.UpInfo.RSSI > 0 || rmd.UpInfo.RSSI < -200 {
+		return fmt.Errorf("upinfo.rssi: out of range: %f", rmd.UpInfo.RSSI)
+	}
+	if rmd.UpInfo.SNR > 50 || rmd.UpInfo.SNR < -50 {
+		return fmt.Errorf("upinfo.snr: out of range: %f", rmd.UpInfo.SNR)
+	}
+
+	return nil
+}
+
+// lowerKeys returns the fields of the given JSON object, with lower-cased
+// keys (the field names are matched case-insensitive, like json.Unmarshal).
+func lowerKeys(b []byte) (map[string]json.RawMessage, error) {
+	var kv map[string]json.RawMessage
+	if err := json.Unmarshal(b, &kv); err != nil {
+		return nil, errors.Wrap(err, "unmarshal json error")
+	}
+
+	out := make(map[string]json.RawMessage, len(kv))
+	for k, v := range kv {
+		out[strings.ToLower(k)] = v
+	}
+
+	return out, nil
+}
+
+func requireFields(kv map[string]json.RawMessage, fields []string) error {
+	for _, f := range fields {
+		v, ok := kv[strings.ToLower(f)]
+		if !ok || string(v) == "null" {
+			return fmt.Errorf("%s: required field is missing", f)
+		}
+	}
+
+	return nil
+}
+
+func validateHex(field, s string) error {
+	if _, err := hex.DecodeString(s); err != nil {
+		return errors.Wrap(err, field)
+	}
+	return nil
+}
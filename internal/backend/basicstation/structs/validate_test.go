@@ -0,0 +1,95 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	const upInfo = `"DR":5,"Freq":868100000,"upinfo":{"rctx":0,"xtime":12345,"gpstime":0,"rssi":-60,"snr":7.5}`
+
+	tests := []struct {
+		Name        string
+		MessageType MessageType
+		Message     string
+		Error       string
+	}{
+		{
+			Name:        "valid uplink data frame",
+			MessageType: UplinkDataFrameMessage,
+			Message:     `{"msgtype":"updf","MHdr":64,"DevAddr":-10,"FCtrl":128,"FCnt":400,"FOpts":"","FPort":-1,"FRMPayload":"","MIC":12345,` + upInfo + `}`,
+		},
+		{
+			Name:        "uplink data frame missing field",
+			MessageType: UplinkDataFrameMessage,
+			Message:     `{"msgtype":"updf","MHdr":64,"DevAddr":-10,"FCtrl":128,"FOpts":"","FPort":-1,"FRMPayload":"","MIC":12345,` + upInfo + `}`,
+			Error:       "FCnt: required field is missing",
+		},
+		{
+			Name:        "uplink data frame invalid mhdr",
+			MessageType: UplinkDataFrameMessage,
+			Message:     `{"msgtype":"updf","MHdr":0,"DevAddr":-10,"FCtrl":128,"FCnt":400,"FOpts":"","FPort":-1,"FRMPayload":"","MIC":12345,` + upInfo + `}`,
+			Error:       "MHdr: invalid message type for data frame: 0",
+		},
+		{
+			Name:        "uplink data frame invalid fport",
+			MessageType: UplinkDataFrameMessage,
+			Message:     `{"msgtype":"updf","MHdr":64,"DevAddr":-10,"FCtrl":128,"FCnt":400,"FOpts":"","FPort":256,"FRMPayload":"","MIC":12345,` + upInfo + `}`,
+			Error:       "FPort: out of range: 256",
+		},
+		{
+			Name:        "uplink data frame missing upinfo field",
+			MessageType: UplinkDataFrameMessage,
+			Message:     `{"msgtype":"updf","MHdr":64,"DevAddr":-10,"FCtrl":128,"FCnt":400,"FOpts":"","FPort":-1,"FRMPayload":"","MIC":12345,"DR":5,"Freq":868100000,"upinfo":{"rctx":0,"rssi":-60,"snr":7.5}}`,
+			Error:       "upinfo: xtime: required field is missing",
+		},
+		{
+			Name:        "valid join-request",
+			MessageType: JoinRequestMessage,
+			Message:     `{"msgtype":"jreq","MHdr":0,"JoinEui":"01-02-03-04-05-06-07-08","DevEui":"02-02-03-04-05-06-07-08","DevNonce":1,"MIC":12345,` + upInfo + `}`,
+		},
+		{
+			Name:        "join-request invalid data-rate",
+			MessageType: JoinRequestMessage,
+			Message:     `{"msgtype":"jreq","MHdr":0,"JoinEui":"01-02-03-04-05-06-07-08","DevEui":"02-02-03-04-05-06-07-08","DevNonce":1,"MIC":12345,"DR":16,"Freq":868100000,"upinfo":{"rctx":0,"xtime":12345,"rssi":-60,"snr":7.5}}`,
+			Error:       "DR: out of range: 16",
+		},
+		{
+			Name:        "proprietary frame invalid payload",
+			MessageType: ProprietaryDataFrameMessage,
+			Message:     `{"msgtype":"propdf","FRMPayload":"zz",` + upInfo + `}`,
+			Error:       "FRMPayload: encoding/hex: invalid byte: U+007A 'z'",
+		},
+		{
+			Name:        "downlink transmitted missing diid",
+			MessageType: DownlinkTransmittedMessage,
+			Message:     `{"msgtype":"dntxed","seqno":1}`,
+			Error:       "diid: required field is missing",
+		},
+		{
+			Name:        "timesync invalid txtime",
+			MessageType: TimeSyncMessage,
+			Message:     `{"msgtype":"timesync","txtime":0}`,
+			Error:       "txtime: out of range: 0.000000",
+		},
+		{
+			Name:        "not validated message-type",
+			MessageType: RemoteShellMessage,
+			Message:     `{"msgtype":"rmtsh"}`,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			err := Validate(tst.MessageType, []byte(tst.Message))
+			if tst.Error != "" {
+				assert.EqualError(err, tst.Error)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
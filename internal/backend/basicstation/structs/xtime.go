@@ -0,0 +1,33 @@
+package structs
+
+// The xtime value, as used by the Basic Station, is composed of:
+//
+//	bit 63:     unused
+//	bits 56-62: session ID (changes when the radio unit is re-initialized)
+//	bits 48-55: radio unit (concentrator board)
+//	bits 0-47:  concentrator counter (microseconds)
+const (
+	xtimeSessionShift   = 56
+	xtimeSessionMask    = 0x7f
+	xtimeRadioUnitShift = 48
+	xtimeRadioUnitMask  = 0xff
+	xtimeCounterMask    = 0x0000ffffffffffff
+)
+
+// XTimeSession returns the session ID of the given xtime.
+func XTimeSession(xtime uint64) uint8 {
+	return uint8((xtime >> xtimeSessionShift) & xtimeSessionMask)
+}
+
+// XTimeRadioUnit returns the radio unit of the given xtime.
+func XTimeRadioUnit(xtime uint64) uint8 {
+	return uint8((xtime >> xtimeRadioUnitShift) & xtimeRadioUnitMask)
+}
+
+// XTimeSet returns the given xtime with the radio unit and session ID bits
+// replaced by the given values.
+func XTimeSet(xtime uint64, radioUnit, session uint8) uint64 {
+	return (xtime & xtimeCounterMask) |
+		(uint64(radioUnit) << xtimeRadioUnitShift) |
+		(uint64(session&xtimeSessionMask) << xtimeSessionShift)
+}
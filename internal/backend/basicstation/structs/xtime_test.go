@@ -0,0 +1,53 @@
+package structs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestXTime(t *testing.T) {
+	tests := []struct {
+		Name      string
+		XTime     uint64
+		Session   uint8
+		RadioUnit uint8
+	}{
+		{
+			Name:  "zero",
+			XTime: 0,
+		},
+		{
+			Name:      "session 1, radio unit 0",
+			XTime:     0x0100000000000001,
+			Session:   1,
+			RadioUnit: 0,
+		},
+		{
+			Name:      "session 3, radio unit 2",
+			XTime:     0x0302000000000001,
+			Session:   3,
+			RadioUnit: 2,
+		},
+		{
+			Name:      "session 127, radio unit 255",
+			XTime:     0x7fff000000000001,
+			Session:   127,
+			RadioUnit: 255,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			assert.Equal(tst.Session, XTimeSession(tst.XTime))
+			assert.Equal(tst.RadioUnit, XTimeRadioUnit(tst.XTime))
+			assert.Equal(tst.XTime, XTimeSet(tst.XTime&xtimeCounterMask, tst.RadioUnit, tst.Session))
+		})
+	}
+
+	t.Run("XTimeSet replaces session and radio unit", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Equal(uint64(0x0501000000000010), XTimeSet(0x0302000000000010, 1, 5))
+	})
+}
@@ -0,0 +1,46 @@
+package basicstation
+
+import (
+	"context"
+	"time"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lorawan"
+)
+
+// Duplicate connection policies.
+const (
+	duplicateConnectionReject   = "reject"
+	duplicateConnectionTakeover = "takeover"
+)
+
+// sessionTakeoverCode is the code of the notify event published on a
+// session takeover.
+const sessionTakeoverCode = "SESSION_TAKEOVER"
+
+// takeoverSession closes the connection which has been taken over by a new
+// connection of the same gateway, e.g. when the gateway reconnects after a
+// silent TCP drop while the old connection is still tracked. The handler of
+// the old connection returns without removing the gateway.
+func (b *Backend) takeoverSession(ctx context.Context, gatewayID lorawan.EUI64, old *websocket.Conn) {
+	log.WithContext(ctx).Warning("backend/basicstation: connection with same gateway id already exists, taking over session")
+	sessionTakeoverCounter().Inc()
+
+	// the close message can be written concurrently with the other writes
+	msg := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "session taken over")
+	if err := old.WriteControl(websocket.CloseMessage, msg, time.Now().Add(b.writeTimeout)); err != nil {
+		log.WithContext(ctx).WithError(err).Debug("backend/basicstation: send close message error")
+	}
+	old.Close()
+
+	b.notifyChan <- events.Notify{
+		GatewayID: gatewayID,
+		Level:     "warning",
+		Code:      sessionTakeoverCode,
+		Message:   "connection with same gateway id already exists, the existing connection has been closed",
+		Time:      time.Now(),
+	}
+}
@@ -0,0 +1,79 @@
+package basicstation
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// uploadHandler stores the binary messages (e.g. log or diagnostic uploads)
+// received from the gateways, either in a directory or by posting these to
+// an HTTP endpoint.
+type uploadHandler struct {
+	directory string
+	url       string
+	client    http.Client
+}
+
+// enabled returns true when uploads are stored.
+func (h *uploadHandler) enabled() bool {
+	return h.directory != "" || h.url != ""
+}
+
+// store stores the given upload and returns its location.
+func (h *uploadHandler) store(gatewayID lorawan.EUI64, uploadID uuid.UUID, b []byte, now time.Time) (string, error) {
+	if h.url != "" {
+		return h.post(gatewayID, uploadID, b)
+	}
+
+	name := fmt.Sprintf("%s_%s_%s.bin", gatewayID, now.UTC().Format("20060102T150405Z"), uploadID)
+	path := filepath.Join(h.directory, name)
+
+	if err := os.MkdirAll(h.directory, 0755); err != nil {
+		return "", errors.Wrap(err, "create directory error")
+	}
+
+	if err := ioutil.WriteFile(path, b, 0644); err != nil {
+		return "", errors.Wrap(err, "write file error")
+	}
+
+	return path, nil
+}
+
+// post posts the upload to the configured URL. When the response contains a
+// Location header, this is returned as location of the upload.
+func (h *uploadHandler) post(gatewayID lorawan.EUI64, uploadID uuid.UUID, b []byte) (string, error) {
+	req, err := http.NewRequest("POST", h.url, bytes.NewReader(b))
+	if err != nil {
+		return "", errors.Wrap(err, "new request error")
+	}
+
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("X-Gateway-ID", gatewayID.String())
+	req.Header.Set("X-Upload-ID", uploadID.String())
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "http post error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return "", fmt.Errorf("expected 2xx response, got: %d", resp.StatusCode)
+	}
+
+	if location := resp.Header.Get("Location"); location != "" {
+		return location, nil
+	}
+
+	return h.url, nil
+}
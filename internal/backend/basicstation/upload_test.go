@@ -0,0 +1,110 @@
+package basicstation
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestUploadHandler(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	uploadID, err := uuid.NewV4()
+	require.NoError(t, err)
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+
+		var h uploadHandler
+		assert.False(h.enabled())
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		assert := require.New(t)
+
+		tempDir, err := ioutil.TempDir("", "test")
+		assert.NoError(err)
+		defer os.RemoveAll(tempDir)
+
+		h := uploadHandler{directory: filepath.Join(tempDir, "uploads")}
+		assert.True(h.enabled())
+
+		location, err := h.store(gatewayID, uploadID, []byte{1, 2, 3}, now)
+		assert.NoError(err)
+		assert.Equal(filepath.Join(tempDir, "uploads", "0102030405060708_20190910T120000Z_"+uploadID.String()+".bin"), location)
+
+		b, err := ioutil.ReadFile(location)
+		assert.NoError(err)
+		assert.Equal([]byte{1, 2, 3}, b)
+	})
+
+	t.Run("URL", func(t *testing.T) {
+		tests := []struct {
+			Name             string
+			Location         string
+			StatusCode       int
+			ExpectedLocation string
+			ExpectedError    bool
+		}{
+			{
+				Name:       "without location header",
+				StatusCode: http.StatusOK,
+			},
+			{
+				Name:             "with location header",
+				Location:         "http://files.example.com/upload.bin",
+				StatusCode:       http.StatusCreated,
+				ExpectedLocation: "http://files.example.com/upload.bin",
+			},
+			{
+				Name:          "error response",
+				StatusCode:    http.StatusInternalServerError,
+				ExpectedError: true,
+			},
+		}
+
+		for _, tst := range tests {
+			t.Run(tst.Name, func(t *testing.T) {
+				assert := require.New(t)
+
+				var body []byte
+				var header http.Header
+				server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					body, _ = ioutil.ReadAll(r.Body)
+					header = r.Header
+					if tst.Location != "" {
+						w.Header().Set("Location", tst.Location)
+					}
+					w.WriteHeader(tst.StatusCode)
+				}))
+				defer server.Close()
+
+				h := uploadHandler{url: server.URL}
+				location, err := h.store(gatewayID, uploadID, []byte{1, 2, 3}, now)
+				if tst.ExpectedError {
+					assert.Error(err)
+					return
+				}
+				assert.NoError(err)
+
+				expectedLocation := tst.ExpectedLocation
+				if expectedLocation == "" {
+					expectedLocation = server.URL
+				}
+				assert.Equal(expectedLocation, location)
+				assert.Equal([]byte{1, 2, 3}, body)
+				assert.Equal("0102030405060708", header.Get("X-Gateway-ID"))
+				assert.Equal(uploadID.String(), header.Get("X-Upload-ID"))
+			})
+		}
+	})
+}
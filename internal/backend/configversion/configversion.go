@@ -0,0 +1,256 @@
+// Package configversion stores the last gateway configuration (and its
+// version) applied to each gateway. Optionally these are persisted to a file
+// or to Redis, so that after a restart the gateway stats still contain the
+// correct config_version and the last configuration can be re-applied to
+// gateways which do not persist it themselves (e.g. Basic Station).
+package configversion
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/go-redis/redis"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/configdrift"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// record is the persisted representation of the configuration.
+type record struct {
+	Version       string `json:"version"`
+	Configuration []byte `json:"configuration"`
+}
+
+// storage defines the interface of the persistent storage.
+type storage interface {
+	load() (map[lorawan.EUI64]record, error)
+	save(records map[lorawan.EUI64]record, gatewayID lorawan.EUI64) error
+	close() error
+}
+
+// Store implements the configuration version store.
+type Store struct {
+	sync.RWMutex
+
+	storage storage
+	records map[lorawan.EUI64]record
+}
+
+// NewStore creates a new Store, loading the persisted configurations when a
+// storage is configured.
+func NewStore(conf config.Config) (*Store, error) {
+	s := Store{
+		records: make(map[lorawan.EUI64]record),
+	}
+
+	c := conf.Backend.ConfigVersions
+	switch c.Storage {
+	case "":
+		return &s, nil
+	case "file":
+		if c.File == "" {
+			return nil, errors.New("file must be set")
+		}
+		s.storage = &fileStorage{file: c.File}
+	case "redis":
+		opts, err := redis.ParseURL(c.Redis.URL)
+		if err != nil {
+			return nil, errors.Wrap(err, "parse redis url error")
+		}
+		s.storage = &redisStorage{
+			client: redis.NewClient(opts),
+			key:    c.Redis.Key,
+		}
+	default:
+		return nil, errors.Errorf("unknown storage: %s", c.Storage)
+	}
+
+	records, err := s.storage.load()
+	if err != nil {
+		return nil, errors.Wrap(err, "load configuration versions error")
+	}
+	s.records = records
+
+	for gatewayID, r := range records {
+		var gwConfig gw.GatewayConfiguration
+		if err := proto.Unmarshal(r.Configuration, &gwConfig); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal configuration of gateway %s error", gatewayID)
+		}
+		configdrift.SetConfiguration(gwConfig)
+	}
+
+	log.WithFields(log.Fields{
+		"storage": c.Storage,
+		"count":   len(records),
+	}).Info("backend/configversion: configuration versions loaded")
+
+	return &s, nil
+}
+
+// Set stores the given (applied) gateway configuration. The channel-plan
+// drift detection is updated with the configuration.
+func (s *Store) Set(gwConfig gw.GatewayConfiguration) error {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], gwConfig.GetGatewayId())
+
+	b, err := proto.Marshal(&gwConfig)
+	if err != nil {
+		return errors.Wrap(err, "marshal protobuf error")
+	}
+
+	configdrift.SetConfiguration(gwConfig)
+
+	s.Lock()
+	defer s.Unlock()
+
+	s.records[gatewayID] = record{
+		Version:       gwConfig.Version,
+		Configuration: b,
+	}
+
+	if s.storage == nil {
+		return nil
+	}
+
+	if err := s.storage.save(s.records, gatewayID); err != nil {
+		return errors.Wrap(err, "save configuration version error")
+	}
+
+	return nil
+}
+
+// Version returns the version of the last configuration applied to the
+// given gateway. It returns an empty string when unknown.
+func (s *Store) Version(gatewayID lorawan.EUI64) string {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.records[gatewayID].Version
+}
+
+// Configuration returns the last configuration applied to the given
+// gateway. It returns nil when unknown.
+func (s *Store) Configuration(gatewayID lorawan.EUI64) (*gw.GatewayConfiguration, error) {
+	s.RLock()
+	r, ok := s.records[gatewayID]
+	s.RUnlock()
+
+	if !ok {
+		return nil, nil
+	}
+
+	var gwConfig gw.GatewayConfiguration
+	if err := proto.Unmarshal(r.Configuration, &gwConfig); err != nil {
+		return nil, errors.Wrap(err, "unmarshal protobuf error")
+	}
+
+	return &gwConfig, nil
+}
+
+// Close closes the storage.
+func (s *Store) Close() error {
+	if s.storage == nil {
+		return nil
+	}
+	return s.storage.close()
+}
+
+// fileStorage persists the configurations to a JSON file.
+type fileStorage struct {
+	file string
+}
+
+func (f *fileStorage) load() (map[lorawan.EUI64]record, error) {
+	records := make(map[lorawan.EUI64]record)
+
+	b, err := ioutil.ReadFile(f.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return records, nil
+		}
+		return nil, errors.Wrap(err, "read file error")
+	}
+
+	if err := json.Unmarshal(b, &records); err != nil {
+		return nil, errors.Wrap(err, "unmarshal json error")
+	}
+
+	return records, nil
+}
+
+func (f *fileStorage) save(records map[lorawan.EUI64]record, gatewayID lorawan.EUI64) error {
+	b, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+
+	// write to a temporary file first, so that the file is never truncated
+	tmp := f.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0640); err != nil {
+		return errors.Wrap(err, "write file error")
+	}
+
+	if err := os.Rename(tmp, f.file); err != nil {
+		return errors.Wrap(err, "rename file error")
+	}
+
+	return nil
+}
+
+func (f *fileStorage) close() error {
+	return nil
+}
+
+// redisStorage persists the configurations to a Redis hash, using the
+// gateway ID as field.
+type redisStorage struct {
+	client *redis.Client
+	key    string
+}
+
+func (r *redisStorage) load() (map[lorawan.EUI64]record, error) {
+	fields, err := r.client.HGetAll(r.key).Result()
+	if err != nil {
+		return nil, errors.Wrap(err, "redis hgetall error")
+	}
+
+	records := make(map[lorawan.EUI64]record)
+	for k, v := range fields {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(k)); err != nil {
+			return nil, errors.Wrap(err, "unmarshal gateway id error")
+		}
+
+		var rec record
+		if err := json.Unmarshal([]byte(v), &rec); err != nil {
+			return nil, errors.Wrap(err, "unmarshal json error")
+		}
+		records[gatewayID] = rec
+	}
+
+	return records, nil
+}
+
+func (r *redisStorage) save(records map[lorawan.EUI64]record, gatewayID lorawan.EUI64) error {
+	b, err := json.Marshal(records[gatewayID])
+	if err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+
+	if err := r.client.HSet(r.key, gatewayID.String(), b).Err(); err != nil {
+		return errors.Wrap(err, "redis hset error")
+	}
+
+	return nil
+}
+
+func (r *redisStorage) close() error {
+	return r.client.Close()
+}
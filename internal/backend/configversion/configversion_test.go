@@ -0,0 +1,82 @@
+package configversion
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestStore(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	gwConfig := gw.GatewayConfiguration{
+		GatewayId: gatewayID[:],
+		Version:   "1.2.3",
+		Channels: []*gw.ChannelConfiguration{
+			{Frequency: 868100000},
+		},
+	}
+
+	t.Run("not persisted", func(t *testing.T) {
+		assert := require.New(t)
+
+		s, err := NewStore(config.Config{})
+		assert.NoError(err)
+		defer s.Close()
+
+		assert.Equal("", s.Version(gatewayID))
+		c, err := s.Configuration(gatewayID)
+		assert.NoError(err)
+		assert.Nil(c)
+
+		assert.NoError(s.Set(gwConfig))
+		assert.Equal("1.2.3", s.Version(gatewayID))
+		c, err = s.Configuration(gatewayID)
+		assert.NoError(err)
+		assert.True(proto.Equal(&gwConfig, c))
+	})
+
+	t.Run("file", func(t *testing.T) {
+		assert := require.New(t)
+
+		dir, err := ioutil.TempDir("", "configversion")
+		assert.NoError(err)
+		defer os.RemoveAll(dir)
+
+		var conf config.Config
+		conf.Backend.ConfigVersions.Storage = "file"
+		conf.Backend.ConfigVersions.File = filepath.Join(dir, "config-versions.json")
+
+		s, err := NewStore(conf)
+		assert.NoError(err)
+		assert.NoError(s.Set(gwConfig))
+		assert.NoError(s.Close())
+
+		// a new store (e.g. after a restart) loads the persisted configuration
+		s, err = NewStore(conf)
+		assert.NoError(err)
+		defer s.Close()
+
+		assert.Equal("1.2.3", s.Version(gatewayID))
+		c, err := s.Configuration(gatewayID)
+		assert.NoError(err)
+		assert.True(proto.Equal(&gwConfig, c))
+	})
+
+	t.Run("invalid storage", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.Backend.ConfigVersions.Storage = "foo"
+
+		_, err := NewStore(conf)
+		assert.EqualError(err, "unknown storage: foo")
+	})
+}
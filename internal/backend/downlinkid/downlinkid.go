@@ -0,0 +1,214 @@
+// Package downlinkid stores the mapping of the downlink token (as sent to the
+// gateway) to the downlink ID of the downlink frame, so that the downlink ID
+// can be added to the tx ack. Optionally the mapping is persisted to a file,
+// so that the downlink ID of the tx acks received after a restart can still
+// be looked up.
+package downlinkid
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lorawan"
+)
+
+// flushInterval defines the interval in which expired items are removed and
+// the mapping is written to the file (when changed).
+const flushInterval = time.Second
+
+// defaultTTL is used when no TTL is configured.
+const defaultTTL = time.Hour
+
+type key struct {
+	gatewayID lorawan.EUI64
+	token     uint16
+}
+
+type item struct {
+	downlinkID []byte
+	time       time.Time
+}
+
+// record is the item representation within the file.
+type record struct {
+	GatewayID  lorawan.EUI64 `json:"gatewayID"`
+	Token      uint16        `json:"token"`
+	DownlinkID []byte        `json:"downlinkID"`
+	Time       time.Time     `json:"time"`
+}
+
+// Store implements the token to downlink ID store.
+type Store struct {
+	sync.Mutex
+
+	file  string
+	ttl   time.Duration
+	items map[key]item
+	dirty bool
+
+	closed chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewStore creates a new Store. When the file is set, the mapping is loaded
+// from and persisted to this file. Items are removed after the given TTL.
+func NewStore(file string, ttl time.Duration) (*Store, error) {
+	if ttl == 0 {
+		ttl = defaultTTL
+	}
+
+	s := Store{
+		file:   file,
+		ttl:    ttl,
+		items:  make(map[key]item),
+		closed: make(chan struct{}),
+	}
+
+	if s.file != "" {
+		if err := s.load(time.Now()); err != nil {
+			return nil, errors.Wrap(err, "load downlink ids error")
+		}
+	}
+
+	s.wg.Add(1)
+	go s.flushLoop()
+
+	return &s, nil
+}
+
+// Set stores the downlink ID for the given gateway ID and token.
+func (s *Store) Set(gatewayID lorawan.EUI64, token uint16, downlinkID []byte) {
+	s.set(gatewayID, token, downlinkID, time.Now())
+}
+
+// Get returns the downlink ID for the given gateway ID and token. It returns
+// nil when the token is unknown.
+func (s *Store) Get(gatewayID lorawan.EUI64, token uint16) []byte {
+	s.Lock()
+	defer s.Unlock()
+
+	return s.items[key{gatewayID: gatewayID, token: token}].downlinkID
+}
+
+// Close stops the flush loop and writes the mapping to the file.
+func (s *Store) Close() error {
+	close(s.closed)
+	s.wg.Wait()
+
+	return s.flush(time.Now())
+}
+
+func (s *Store) set(gatewayID lorawan.EUI64, token uint16, downlinkID []byte, now time.Time) {
+	s.Lock()
+	defer s.Unlock()
+
+	s.items[key{gatewayID: gatewayID, token: token}] = item{
+		downlinkID: downlinkID,
+		time:       now,
+	}
+	s.dirty = true
+}
+
+func (s *Store) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closed:
+			return
+		case <-ticker.C:
+			if err := s.flush(time.Now()); err != nil {
+				log.WithError(err).WithField("file", s.file).Error("backend/downlinkid: write downlink ids error")
+			}
+		}
+	}
+}
+
+// flush removes the expired items and writes the mapping to the file, when
+// it has changed.
+func (s *Store) flush(now time.Time) error {
+	s.Lock()
+	defer s.Unlock()
+
+	for k, v := range s.items {
+		if now.Sub(v.time) > s.ttl {
+			delete(s.items, k)
+			s.dirty = true
+		}
+	}
+
+	if s.file == "" || !s.dirty {
+		return nil
+	}
+
+	records := make([]record, 0, len(s.items))
+	for k, v := range s.items {
+		records = append(records, record{
+			GatewayID:  k.gatewayID,
+			Token:      k.token,
+			DownlinkID: v.downlinkID,
+			Time:       v.time,
+		})
+	}
+
+	b, err := json.Marshal(records)
+	if err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+
+	// write to a temporary file first, so that the file is never truncated
+	tmp := s.file + ".tmp"
+	if err := ioutil.WriteFile(tmp, b, 0640); err != nil {
+		return errors.Wrap(err, "write file error")
+	}
+
+	if err := os.Rename(tmp, s.file); err != nil {
+		return errors.Wrap(err, "rename file error")
+	}
+
+	s.dirty = false
+	return nil
+}
+
+// load loads the (non-expired) items from the file.
+func (s *Store) load(now time.Time) error {
+	b, err := ioutil.ReadFile(s.file)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return errors.Wrap(err, "read file error")
+	}
+
+	var records []record
+	if err := json.Unmarshal(b, &records); err != nil {
+		return errors.Wrap(err, "unmarshal json error")
+	}
+
+	for _, r := range records {
+		if now.Sub(r.Time) > s.ttl {
+			continue
+		}
+
+		s.items[key{gatewayID: r.GatewayID, token: r.Token}] = item{
+			downlinkID: r.DownlinkID,
+			time:       r.Time,
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"file":  s.file,
+		"count": len(s.items),
+	}).Info("backend/downlinkid: downlink ids loaded")
+
+	return nil
+}
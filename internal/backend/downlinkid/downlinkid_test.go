@@ -0,0 +1,73 @@
+package downlinkid
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestStore(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "downlinkid")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	file := filepath.Join(dir, "downlink-ids.json")
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Now()
+
+	t.Run("set and get", func(t *testing.T) {
+		assert := require.New(t)
+
+		s, err := NewStore(file, time.Minute)
+		assert.NoError(err)
+
+		s.set(gatewayID, 123, []byte{1, 2, 3}, now.Add(-2*time.Minute))
+		s.set(gatewayID, 456, []byte{4, 5, 6}, now)
+
+		assert.Equal([]byte{1, 2, 3}, s.Get(gatewayID, 123))
+		assert.Equal([]byte{4, 5, 6}, s.Get(gatewayID, 456))
+		assert.Nil(s.Get(lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}, 456))
+		assert.Nil(s.Get(gatewayID, 789))
+
+		assert.NoError(s.Close())
+	})
+
+	t.Run("expired item removed", func(t *testing.T) {
+		assert := require.New(t)
+
+		s, err := NewStore(file, time.Minute)
+		assert.NoError(err)
+		defer s.Close()
+
+		assert.Nil(s.Get(gatewayID, 123))
+	})
+
+	t.Run("loaded after restart", func(t *testing.T) {
+		assert := require.New(t)
+
+		s, err := NewStore(file, time.Minute)
+		assert.NoError(err)
+		defer s.Close()
+
+		assert.Equal([]byte{4, 5, 6}, s.Get(gatewayID, 456))
+	})
+
+	t.Run("no file", func(t *testing.T) {
+		assert := require.New(t)
+
+		s, err := NewStore("", time.Minute)
+		assert.NoError(err)
+
+		s.Set(gatewayID, 123, []byte{1, 2, 3})
+		assert.Equal([]byte{1, 2, 3}, s.Get(gatewayID, 123))
+		assert.NoError(s.Close())
+	})
+}
@@ -0,0 +1,130 @@
+// Package events defines the gateway connection, upload, configuration diff,
+// protocol error, certificate expiry and notify events emitted by the
+// backends.
+package events
+
+import (
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/brocaar/lorawan"
+)
+
+// Connection reasons.
+const (
+	// ReasonFirstSeen is used when a gateway is seen for the first time.
+	ReasonFirstSeen = "first_seen"
+
+	// ReasonTimeout is used when no data was received from the gateway
+	// within the configured timeout.
+	ReasonTimeout = "timeout"
+
+	// ReasonClose is used when the gateway closed its connection.
+	ReasonClose = "close"
+)
+
+// Connection describes a gateway connect or disconnect.
+type Connection struct {
+	// GatewayID contains the gateway ID.
+	GatewayID lorawan.EUI64
+
+	// Reason contains the reason of the (dis)connect.
+	Reason string
+
+	// CloseCode contains the websocket close code, in case the gateway
+	// closed its websocket connection.
+	CloseCode int
+}
+
+// Upload describes a binary upload (e.g. a log or diagnostic file) received
+// from a gateway.
+type Upload struct {
+	// GatewayID contains the gateway ID.
+	GatewayID lorawan.EUI64
+
+	// UploadID contains the unique ID of the upload.
+	UploadID uuid.UUID
+
+	// Location contains the location of the stored upload (file path or URL).
+	Location string
+
+	// Size contains the size of the upload in bytes.
+	Size int
+}
+
+// ConfigurationDiff describes the changes a gateway configuration would make
+// to the current gateway configuration, when applied in dry-run mode.
+type ConfigurationDiff struct {
+	// GatewayID contains the gateway ID.
+	GatewayID lorawan.EUI64
+
+	// Version contains the version of the gateway configuration.
+	Version string
+
+	// Changes contains the changed configuration values.
+	Changes []ConfigurationChange
+}
+
+// ConfigurationChange describes a single changed configuration value.
+type ConfigurationChange struct {
+	// Path contains the path of the value (e.g. SX1301_conf.radio_0.freq).
+	Path string
+
+	// OldValue contains the JSON encoded current value (empty when added).
+	OldValue string
+
+	// NewValue contains the JSON encoded new value (empty when removed).
+	NewValue string
+}
+
+// ProtocolError describes a message received from a gateway which was
+// rejected because it does not conform to the protocol specification.
+type ProtocolError struct {
+	// GatewayID contains the gateway ID.
+	GatewayID lorawan.EUI64
+
+	// MessageType contains the type of the rejected message.
+	MessageType string
+
+	// Error contains the validation error.
+	Error string
+
+	// Payload contains the rejected message.
+	Payload []byte
+}
+
+// CertExpiry describes a client certificate, used by a gateway to connect,
+// which expires within the configured warning period.
+type CertExpiry struct {
+	// GatewayID contains the gateway ID.
+	GatewayID lorawan.EUI64
+
+	// CommonName contains the Common Name of the client certificate.
+	CommonName string
+
+	// Fingerprint contains the SHA-256 fingerprint (HEX encoded) of the
+	// client certificate.
+	Fingerprint string
+
+	// NotAfter contains the expiry of the client certificate.
+	NotAfter time.Time
+}
+
+// Notify describes a notification about a gateway (e.g. a session takeover).
+type Notify struct {
+	// GatewayID contains the gateway ID.
+	GatewayID lorawan.EUI64
+
+	// Level contains the level (warning or error).
+	Level string
+
+	// Code contains the code of the notification (e.g. SESSION_TAKEOVER).
+	Code string
+
+	// Message contains the notification message.
+	Message string
+
+	// Time contains the time of the notification.
+	Time time.Time
+}
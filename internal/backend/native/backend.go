@@ -0,0 +1,437 @@
+// Package native implements a backend for a concentrator (e.g. SX1301 or
+// SX1302) which is attached to the gateway on which the LoRa Gateway Bridge
+// is running, removing the need for running a packet-forwarder. The
+// concentrator is accessed through a HAL helper process (see
+// cmd/lora-hal-helper for the libloragw based helper), with which this
+// backend exchanges JSON messages (one per line) over the stdin and stdout of
+// the process. These messages use the JSON objects of the Semtech UDP
+// protocol.
+package native
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/downlinkid"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/filters"
+	"github.com/brocaar/lora-gateway-bridge/internal/keepalive"
+	"github.com/brocaar/lora-gateway-bridge/internal/quarantine"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// upMessage contains a message received from the HAL helper. It contains
+// the received packets and / or the concentrator stats, or the ack of a
+// downlink.
+type upMessage struct {
+	packets.PushDataPayload
+
+	Token   *uint16          `json:"token,omitempty"`
+	TXPKACK *packets.TXPKACK `json:"txpk_ack,omitempty"`
+}
+
+// downMessage contains a downlink sent to the HAL helper.
+type downMessage struct {
+	Token uint16 `json:"token"`
+	packets.PullRespPayload
+}
+
+// Backend implements a backend for a locally attached concentrator.
+type Backend struct {
+	sync.RWMutex
+
+	gatewayID       lorawan.EUI64
+	command         []string
+	restartInterval time.Duration
+	skipCRCCheck    bool
+	fakeRxTime      bool
+
+	// stdin of the running HAL helper, nil when not running
+	stdin  io.WriteCloser
+	cmd    *exec.Cmd
+	closed bool
+
+	// downlinkIDs stores the token to downlink ID (UUID) mapping.
+	downlinkIDs *downlinkid.Store
+
+	downlinkTXAckChan chan gw.DownlinkTXAck
+	uplinkFrameChan   chan gw.UplinkFrame
+	gatewayStatsChan  chan gw.GatewayStats
+	connectChan       chan events.Connection
+	disconnectChan    chan events.Connection
+	uploadChan        chan events.Upload
+
+	configurationDiffChan chan events.ConfigurationDiff
+	protocolErrorChan     chan events.ProtocolError
+	certExpiryChan        chan events.CertExpiry
+	notifyChan            chan events.Notify
+}
+
+// NewBackend creates a new Backend and starts the HAL helper.
+func NewBackend(conf config.Config) (*Backend, error) {
+	b := Backend{
+		command:           strings.Fields(conf.Backend.Native.HALCommand),
+		restartInterval:   conf.Backend.Native.RestartInterval,
+		skipCRCCheck:      conf.Backend.Native.SkipCRCCheck,
+		fakeRxTime:        conf.Backend.Native.FakeRxTime,
+		downlinkTXAckChan: make(chan gw.DownlinkTXAck),
+		uplinkFrameChan:   make(chan gw.UplinkFrame),
+		gatewayStatsChan:  make(chan gw.GatewayStats),
+		connectChan:       make(chan events.Connection),
+		disconnectChan:    make(chan events.Connection),
+		uploadChan:        make(chan events.Upload),
+
+		configurationDiffChan: make(chan events.ConfigurationDiff),
+		protocolErrorChan:     make(chan events.ProtocolError),
+		certExpiryChan:        make(chan events.CertExpiry),
+		notifyChan:            make(chan events.Notify),
+	}
+
+	if err := b.gatewayID.UnmarshalText([]byte(conf.Backend.Native.GatewayID)); err != nil {
+		return nil, errors.Wrap(err, "parse gateway_id error")
+	}
+
+	if len(b.command) == 0 {
+		return nil, errors.New("hal_command must be set")
+	}
+
+	var err error
+	b.downlinkIDs, err = downlinkid.NewStore(conf.Backend.DownlinkIDs.File, conf.Backend.DownlinkIDs.TTL)
+	if err != nil {
+		return nil, errors.Wrap(err, "new downlink id store error")
+	}
+
+	go b.runLoop()
+
+	return &b, nil
+}
+
+// Close closes the backend and stops the HAL helper.
+func (b *Backend) Close() error {
+	b.Lock()
+	defer b.Unlock()
+
+	log.Info("backend/native: closing gateway backend")
+
+	b.closed = true
+	if b.cmd != nil && b.cmd.Process != nil {
+		b.cmd.Process.Kill()
+	}
+
+	if err := b.downlinkIDs.Close(); err != nil {
+		return errors.Wrap(err, "close downlink id store error")
+	}
+
+	return nil
+}
+
+// HealthCheck returns an error when the backend is closed or when the HAL
+// helper is not running.
+func (b *Backend) HealthCheck() error {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.closed {
+		return errors.New("backend is closed")
+	}
+
+	if b.stdin == nil {
+		return errors.New("hal helper is not running")
+	}
+
+	return nil
+}
+
+// GetDownlinkTXAckChan returns the downlink tx ack channel.
+func (b *Backend) GetDownlinkTXAckChan() chan gw.DownlinkTXAck {
+	return b.downlinkTXAckChan
+}
+
+// GetGatewayStatsChan returns the gateway stats channel.
+func (b *Backend) GetGatewayStatsChan() chan gw.GatewayStats {
+	return b.gatewayStatsChan
+}
+
+// GetUplinkFrameChan returns the uplink frame channel.
+func (b *Backend) GetUplinkFrameChan() chan gw.UplinkFrame {
+	return b.uplinkFrameChan
+}
+
+// GetConnectChan returns the channel for received gateway connections. The
+// gateway is connected when the HAL helper has been started.
+func (b *Backend) GetConnectChan() chan events.Connection {
+	return b.connectChan
+}
+
+// GetDisconnectChan returns the channel for disconnected gateway
+// connections. The gateway is disconnected when the HAL helper exits.
+func (b *Backend) GetDisconnectChan() chan events.Connection {
+	return b.disconnectChan
+}
+
+// GetUploadChan returns the channel for received uploads. Uploads are not
+// supported by this backend, thus nothing is sent to this channel.
+func (b *Backend) GetUploadChan() chan events.Upload {
+	return b.uploadChan
+}
+
+// GetConfigurationDiffChan returns the channel for gateway configuration
+// diffs. Gateway configuration is not supported by this backend, thus
+// nothing is sent to this channel.
+func (b *Backend) GetConfigurationDiffChan() chan events.ConfigurationDiff {
+	return b.configurationDiffChan
+}
+
+// GetProtocolErrorChan returns the channel for rejected gateway messages.
+// Strict mode is not supported by this backend, thus nothing is sent to this
+// channel.
+func (b *Backend) GetProtocolErrorChan() chan events.ProtocolError {
+	return b.protocolErrorChan
+}
+
+// GetCertExpiryChan returns the channel for client certificates which are
+// about to expire.
+func (b *Backend) GetCertExpiryChan() chan events.CertExpiry {
+	return b.certExpiryChan
+}
+
+// GetNotifyChan returns the channel for gateway notifications.
+func (b *Backend) GetNotifyChan() chan events.Notify {
+	return b.notifyChan
+}
+
+// SendDownlinkFrame sends the given downlink frame to the HAL helper.
+func (b *Backend) SendDownlinkFrame(frame gw.DownlinkFrame) error {
+	token := uint16(frame.Token)
+
+	packet, err := packets.GetPullRespPacket(packets.ProtocolVersion2, token, frame)
+	if err != nil {
+		return errors.Wrap(err, "get PullRespPacket error")
+	}
+
+	bb, err := json.Marshal(downMessage{
+		Token:           token,
+		PullRespPayload: packet.Payload,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if b.stdin == nil {
+		return errors.New("hal helper is not running")
+	}
+
+	if _, err := b.stdin.Write(append(bb, '\n')); err != nil {
+		return errors.Wrap(err, "write to hal helper error")
+	}
+	b.downlinkIDs.Set(b.gatewayID, token, frame.DownlinkId)
+
+	messageSentCounter("down").Inc()
+
+	return nil
+}
+
+// ApplyConfiguration is not supported by this backend. The channel-plan must
+// be configured in the configuration of the HAL helper.
+func (b *Backend) ApplyConfiguration(config gw.GatewayConfiguration) error {
+	log.WithField("gateway_id", b.gatewayID).Warning("backend/native: gateway configuration is not supported, ignoring")
+	return nil
+}
+
+// runLoop runs the HAL helper and restarts it after restartInterval when it
+// exits, until the backend is closed.
+func (b *Backend) runLoop() {
+	for {
+		if err := b.run(); err != nil {
+			log.WithError(err).Error("backend/native: hal helper error")
+		}
+
+		b.RLock()
+		closed := b.closed
+		b.RUnlock()
+		if closed {
+			return
+		}
+
+		helperRestartCounter().Inc()
+		time.Sleep(b.restartInterval)
+	}
+}
+
+// run starts the HAL helper and handles its messages until it exits.
+func (b *Backend) run() error {
+	cmd := exec.Command(b.command[0], b.command[1:]...)
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return errors.Wrap(err, "get stdin pipe error")
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.Wrap(err, "get stdout pipe error")
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return errors.Wrap(err, "get stderr pipe error")
+	}
+
+	b.Lock()
+	if b.closed {
+		b.Unlock()
+		return nil
+	}
+	if err := cmd.Start(); err != nil {
+		b.Unlock()
+		return errors.Wrap(err, "start hal helper error")
+	}
+	b.cmd = cmd
+	b.stdin = stdin
+	b.Unlock()
+
+	log.WithFields(log.Fields{
+		"gateway_id": b.gatewayID,
+		"command":    strings.Join(b.command, " "),
+	}).Info("backend/native: hal helper started")
+
+	b.connectChan <- events.Connection{
+		GatewayID: b.gatewayID,
+		Reason:    events.ReasonFirstSeen,
+	}
+
+	stderrDone := make(chan struct{})
+	go func() {
+		b.logStderr(stderr)
+		close(stderrDone)
+	}()
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if err := b.handleMessage(scanner.Bytes()); err != nil {
+			log.WithError(err).WithField("gateway_id", b.gatewayID).Error("backend/native: handle message error")
+			quarantine.Error(b.gatewayID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		// the helper would block on writing its output, restart it
+		log.WithError(err).WithField("gateway_id", b.gatewayID).Error("backend/native: read hal helper output error")
+		cmd.Process.Kill()
+	}
+
+	<-stderrDone
+	err = cmd.Wait()
+
+	b.Lock()
+	b.cmd = nil
+	b.stdin = nil
+	closed := b.closed
+	b.Unlock()
+
+	if closed {
+		return nil
+	}
+
+	log.WithField("gateway_id", b.gatewayID).Warning("backend/native: hal helper exited")
+
+	b.disconnectChan <- events.Connection{
+		GatewayID: b.gatewayID,
+		Reason:    events.ReasonClose,
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "hal helper exit error")
+	}
+	return nil
+}
+
+func (b *Backend) logStderr(r io.Reader) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		log.WithField("gateway_id", b.gatewayID).Info("backend/native: hal helper: " + scanner.Text())
+	}
+}
+
+func (b *Backend) handleMessage(bb []byte) error {
+	if len(strings.TrimSpace(string(bb))) == 0 {
+		return nil
+	}
+
+	var msg upMessage
+	if err := json.Unmarshal(bb, &msg); err != nil {
+		return errors.Wrap(err, "unmarshal json error")
+	}
+
+	keepalive.Seen(b.gatewayID)
+
+	if msg.TXPKACK != nil {
+		messageReceivedCounter("ack").Inc()
+		return b.handleTXACK(msg)
+	}
+
+	p := packets.PushDataPacket{
+		GatewayMAC: b.gatewayID,
+		Payload:    msg.PushDataPayload,
+	}
+
+	stats, err := p.GetGatewayStats()
+	if err != nil {
+		return errors.Wrap(err, "get stats error")
+	}
+	if stats != nil {
+		messageReceivedCounter("stat").Inc()
+		b.gatewayStatsChan <- *stats
+	}
+
+	uplinkFrames, err := p.GetUplinkFrames(b.skipCRCCheck, b.fakeRxTime)
+	if err != nil {
+		return errors.Wrap(err, "get uplink frames error")
+	}
+
+	for i := range uplinkFrames {
+		messageReceivedCounter("rxpk").Inc()
+
+		if filters.MatchFilters(uplinkFrames[i].PhyPayload) {
+			b.uplinkFrameChan <- uplinkFrames[i]
+		} else {
+			log.WithFields(log.Fields{
+				"data_base64": base64.StdEncoding.EncodeToString(uplinkFrames[i].PhyPayload),
+			}).Debug("backend/native: frame dropped because of configured filters")
+		}
+	}
+
+	return nil
+}
+
+func (b *Backend) handleTXACK(msg upMessage) error {
+	if msg.Token == nil {
+		return errors.New("txpk_ack without token")
+	}
+
+	txAck := gw.DownlinkTXAck{
+		GatewayId:  b.gatewayID[:],
+		Token:      uint32(*msg.Token),
+		DownlinkId: b.downlinkIDs.Get(b.gatewayID, *msg.Token),
+	}
+	if msg.TXPKACK.Error != "" && msg.TXPKACK.Error != "NONE" {
+		txAck.Error = msg.TXPKACK.Error
+	}
+
+	b.downlinkTXAckChan <- txAck
+
+	return nil
+}
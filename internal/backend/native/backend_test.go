@@ -0,0 +1,106 @@
+package native
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// helperScript implements a HAL helper which sends a single uplink and acks
+// each downlink.
+const helperScript = `echo '{"rxpk":[{"tmst":1234,"freq":868.1,"chan":0,"rfch":0,"stat":1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","rssi":-35,"lsnr":5.5,"size":3,"data":"AQID"}]}'
+while read line; do
+	echo "$line" > "$(dirname "$0")/down.json"
+	echo "$line" | sed 's/^{"token":\([0-9]*\).*/{"token":\1,"txpk_ack":{"error":"NONE"}}/'
+done
+`
+
+func TestBackend(t *testing.T) {
+	assert := require.New(t)
+
+	tempDir, err := ioutil.TempDir("", "native")
+	assert.NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	helper := filepath.Join(tempDir, "helper.sh")
+	assert.NoError(ioutil.WriteFile(helper, []byte(helperScript), 0755))
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	var conf config.Config
+	conf.Backend.Native.GatewayID = "0102030405060708"
+	conf.Backend.Native.HALCommand = "sh " + helper
+	conf.Backend.Native.RestartInterval = time.Second
+
+	backend, err := NewBackend(conf)
+	assert.NoError(err)
+	defer backend.Close()
+
+	t.Run("connect", func(t *testing.T) {
+		assert := require.New(t)
+
+		conn := <-backend.GetConnectChan()
+		assert.Equal(events.Connection{
+			GatewayID: gatewayID,
+			Reason:    events.ReasonFirstSeen,
+		}, conn)
+		assert.NoError(backend.HealthCheck())
+	})
+
+	t.Run("uplink", func(t *testing.T) {
+		assert := require.New(t)
+
+		uplinkFrame := <-backend.GetUplinkFrameChan()
+		assert.Equal([]byte{1, 2, 3}, uplinkFrame.PhyPayload)
+		assert.Equal(gatewayID[:], uplinkFrame.RxInfo.GatewayId)
+		assert.EqualValues(868100000, uplinkFrame.TxInfo.Frequency)
+	})
+
+	t.Run("downlink", func(t *testing.T) {
+		assert := require.New(t)
+
+		downID := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+		assert.NoError(backend.SendDownlinkFrame(gw.DownlinkFrame{
+			PhyPayload: []byte{1, 2, 3},
+			Token:      1234,
+			DownlinkId: downID,
+			TxInfo: &gw.DownlinkTXInfo{
+				GatewayId:  gatewayID[:],
+				Frequency:  868100000,
+				Power:      14,
+				Modulation: common.Modulation_LORA,
+				ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+					LoraModulationInfo: &gw.LoRaModulationInfo{
+						Bandwidth:             125,
+						SpreadingFactor:       7,
+						CodeRate:              "4/5",
+						PolarizationInversion: true,
+					},
+				},
+				Timing: gw.DownlinkTiming_IMMEDIATELY,
+			},
+		}))
+
+		txAck := <-backend.GetDownlinkTXAckChan()
+		assert.Equal(gw.DownlinkTXAck{
+			GatewayId:  gatewayID[:],
+			Token:      1234,
+			DownlinkId: downID,
+		}, txAck)
+
+		b, err := ioutil.ReadFile(filepath.Join(tempDir, "down.json"))
+		assert.NoError(err)
+		assert.Equal(`{"token":1234,"txpk":{"imme":true,"rfch":0,"powe":14,"ant":0,"brd":0,"freq":868.1,"modu":"LORA","datr":"SF7BW125","codr":"4/5","ipol":true,"size":3,"data":"AQID"}}`+"\n", string(b))
+	})
+}
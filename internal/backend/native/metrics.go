@@ -0,0 +1,35 @@
+package native
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	mr = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_native_message_received_count",
+		Help: "The number of messages received from the HAL helper (per message type).",
+	}, []string{"type"})
+
+	ms = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_native_message_sent_count",
+		Help: "The number of messages sent to the HAL helper (per message type).",
+	}, []string{"type"})
+
+	hr = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "backend_native_hal_helper_restart_count",
+		Help: "The number of times the HAL helper was restarted.",
+	})
+)
+
+func messageReceivedCounter(typ string) prometheus.Counter {
+	return mr.With(prometheus.Labels{"type": typ})
+}
+
+func messageSentCounter(typ string) prometheus.Counter {
+	return ms.With(prometheus.Labels{"type": typ})
+}
+
+func helperRestartCounter() prometheus.Counter {
+	return hr
+}
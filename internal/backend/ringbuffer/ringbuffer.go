@@ -0,0 +1,179 @@
+// Package ringbuffer keeps the last N raw messages (both directions) per
+// gateway in memory. The messages can be retrieved using the admin API and
+// are optionally dumped to a file on conversion errors, so that it is
+// possible to reconstruct what a misbehaving gateway sent without enabling
+// the (global) debug logging or packet capture.
+package ringbuffer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lorawan"
+)
+
+// Message directions.
+const (
+	DirectionUp   = "up"
+	DirectionDown = "down"
+)
+
+// dumpInterval defines the min. interval between two dumps of the same
+// gateway, to avoid a dump per message of a misbehaving gateway.
+const dumpInterval = time.Minute
+
+// Record contains a single raw message.
+type Record struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Data      []byte    `json:"data"`
+}
+
+// ring contains the records of a single gateway.
+type ring struct {
+	records []Record
+	next    int
+	full    bool
+}
+
+// Buffer implements the per gateway ring buffer.
+type Buffer struct {
+	sync.Mutex
+
+	size          int
+	dumpDirectory string
+	gateways      map[lorawan.EUI64]*ring
+	dumped        map[lorawan.EUI64]time.Time
+}
+
+// New creates a new Buffer, keeping the last size messages per gateway.
+// When the size is 0, the buffer is disabled. When the dump directory is set,
+// the messages of a gateway are written to this directory on Dump.
+func New(size int, dumpDirectory string) *Buffer {
+	return &Buffer{
+		size:          size,
+		dumpDirectory: dumpDirectory,
+		gateways:      make(map[lorawan.EUI64]*ring),
+		dumped:        make(map[lorawan.EUI64]time.Time),
+	}
+}
+
+// Enabled returns true when the buffer is enabled.
+func (b *Buffer) Enabled() bool {
+	return b.size > 0
+}
+
+// Add adds the given message to the buffer of the gateway, overwriting the
+// oldest message when the buffer is full.
+func (b *Buffer) Add(gatewayID lorawan.EUI64, direction string, data []byte, now time.Time) {
+	if !b.Enabled() {
+		return
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	r, ok := b.gateways[gatewayID]
+	if !ok {
+		r = &ring{records: make([]Record, b.size)}
+		b.gateways[gatewayID] = r
+	}
+
+	// the data might be re-used by the caller
+	d := make([]byte, len(data))
+	copy(d, data)
+
+	r.records[r.next] = Record{
+		Time:      now.UTC(),
+		Direction: direction,
+		Data:      d,
+	}
+	r.next = (r.next + 1) % b.size
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// Get returns the messages of the given gateway, oldest first.
+func (b *Buffer) Get(gatewayID lorawan.EUI64) []Record {
+	b.Lock()
+	defer b.Unlock()
+
+	out := []Record{}
+
+	r, ok := b.gateways[gatewayID]
+	if !ok {
+		return out
+	}
+
+	if r.full {
+		out = append(out, r.records[r.next:]...)
+	}
+	return append(out, r.records[:r.next]...)
+}
+
+// Dump writes the messages of the given gateway to a file in the dump
+// directory. It returns the path of the file, or an empty string when no
+// dump directory is configured or when the gateway was dumped within the
+// dump interval.
+func (b *Buffer) Dump(gatewayID lorawan.EUI64, now time.Time) (string, error) {
+	if !b.Enabled() || b.dumpDirectory == "" {
+		return "", nil
+	}
+
+	b.Lock()
+	if last, ok := b.dumped[gatewayID]; ok && now.Sub(last) < dumpInterval {
+		b.Unlock()
+		return "", nil
+	}
+	b.dumped[gatewayID] = now
+	b.Unlock()
+
+	j, err := json.MarshalIndent(b.Get(gatewayID), "", "  ")
+	if err != nil {
+		return "", errors.Wrap(err, "marshal json error")
+	}
+
+	if err := os.MkdirAll(b.dumpDirectory, 0755); err != nil {
+		return "", errors.Wrap(err, "create directory error")
+	}
+
+	path := filepath.Join(b.dumpDirectory, fmt.Sprintf("%s_%s.json", gatewayID, now.UTC().Format("20060102T150405.000Z")))
+	if err := ioutil.WriteFile(path, j, 0640); err != nil {
+		return "", errors.Wrap(err, "write file error")
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"file":       path,
+	}).Info("backend/ringbuffer: messages dumped")
+
+	return path, nil
+}
+
+// HandleHTTP implements the admin API handler, returning the messages of
+// the gateway given by the gateway_id query parameter.
+func (b *Buffer) HandleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	if err := gatewayID.UnmarshalText([]byte(r.URL.Query().Get("gateway_id"))); err != nil {
+		admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "unmarshal gateway id error"))
+		return
+	}
+
+	admin.WriteJSON(w, b.Get(gatewayID))
+}
@@ -0,0 +1,113 @@
+package ringbuffer
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestBuffer(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Now()
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+
+		b := New(0, "")
+		assert.False(b.Enabled())
+		b.Add(gatewayID, DirectionUp, []byte{1}, now)
+		assert.Len(b.Get(gatewayID), 0)
+	})
+
+	t.Run("Add and get", func(t *testing.T) {
+		assert := require.New(t)
+
+		b := New(3, "")
+		assert.True(b.Enabled())
+		assert.Len(b.Get(gatewayID), 0)
+
+		data := []byte{1}
+		b.Add(gatewayID, DirectionUp, data, now)
+		data[0] = 9
+		b.Add(gatewayID, DirectionDown, []byte{2}, now)
+
+		records := b.Get(gatewayID)
+		assert.Len(records, 2)
+		assert.Equal([]byte{1}, records[0].Data)
+		assert.Equal(DirectionUp, records[0].Direction)
+		assert.Equal([]byte{2}, records[1].Data)
+		assert.Equal(DirectionDown, records[1].Direction)
+
+		// overwrites the oldest records
+		b.Add(gatewayID, DirectionUp, []byte{3}, now)
+		b.Add(gatewayID, DirectionUp, []byte{4}, now)
+		b.Add(gatewayID, DirectionUp, []byte{5}, now)
+
+		records = b.Get(gatewayID)
+		assert.Len(records, 3)
+		assert.Equal([]byte{3}, records[0].Data)
+		assert.Equal([]byte{4}, records[1].Data)
+		assert.Equal([]byte{5}, records[2].Data)
+
+		assert.Len(b.Get(lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}), 0)
+	})
+
+	t.Run("Dump", func(t *testing.T) {
+		assert := require.New(t)
+
+		dir, err := ioutil.TempDir("", "ringbuffer")
+		assert.NoError(err)
+		defer os.RemoveAll(dir)
+
+		b := New(3, dir)
+		b.Add(gatewayID, DirectionUp, []byte{1, 2, 3}, now)
+
+		path, err := b.Dump(gatewayID, now)
+		assert.NoError(err)
+		assert.NotEqual("", path)
+
+		content, err := ioutil.ReadFile(path)
+		assert.NoError(err)
+		var records []Record
+		assert.NoError(json.Unmarshal(content, &records))
+		assert.Len(records, 1)
+		assert.Equal([]byte{1, 2, 3}, records[0].Data)
+
+		// rate-limited
+		path, err = b.Dump(gatewayID, now.Add(30*time.Second))
+		assert.NoError(err)
+		assert.Equal("", path)
+
+		path, err = b.Dump(gatewayID, now.Add(dumpInterval))
+		assert.NoError(err)
+		assert.NotEqual("", path)
+	})
+
+	t.Run("HTTP", func(t *testing.T) {
+		assert := require.New(t)
+
+		b := New(3, "")
+		b.Add(gatewayID, DirectionUp, []byte{1, 2, 3}, now)
+
+		w := httptest.NewRecorder()
+		b.HandleHTTP(w, httptest.NewRequest(http.MethodGet, "/?gateway_id=0102030405060708", nil))
+		assert.Equal(http.StatusOK, w.Code)
+
+		var records []Record
+		assert.NoError(json.Unmarshal(w.Body.Bytes(), &records))
+		assert.Len(records, 1)
+		assert.Equal([]byte{1, 2, 3}, records[0].Data)
+
+		w = httptest.NewRecorder()
+		b.HandleHTTP(w, httptest.NewRequest(http.MethodGet, "/?gateway_id=foo", nil))
+		assert.Equal(http.StatusBadRequest, w.Code)
+	})
+}
@@ -0,0 +1,392 @@
+package semtechudp
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// affinityStore defines the interface of the shared gateway to instance
+// mapping.
+type affinityStore interface {
+	set(gatewayID lorawan.EUI64, instance string, ttl time.Duration) error
+	get(gatewayID lorawan.EUI64) (string, error)
+	close() error
+}
+
+// sessionAffinity implements the routing of downlinks between multiple
+// instances running behind a UDP load balancer. Each instance registers the
+// gateways from which it receives PULL_DATA packets (and thus holds the NAT
+// session of) in a shared mapping. Downlinks for gateways which are not
+// connected to this instance are forwarded to the instance holding the
+// session, using the SessionAffinityService gRPC service. The instances
+// authenticate each other using mutual TLS and / or a shared bearer token.
+type sessionAffinity struct {
+	store            affinityStore
+	advertiseAddress string
+	ttl              time.Duration
+	timeout          time.Duration
+	bearerToken      string
+	clientTLSConfig  *tls.Config
+	serverTLSConfig  *tls.Config
+	listener         net.Listener
+	server           *grpc.Server
+
+	// clients contains the client connection per instance.
+	clientsMux sync.Mutex
+	clients    map[string]*grpc.ClientConn
+
+	// registered contains the time of the last registration per gateway, to
+	// avoid a write to the shared mapping on every PULL_DATA.
+	mux        sync.Mutex
+	registered map[lorawan.EUI64]time.Time
+}
+
+// newSessionAffinity creates the session affinity. The downlinks forwarded
+// by the other instances are sent using the given function.
+func newSessionAffinity(conf config.Config, send func(gw.DownlinkFrame) error) (*sessionAffinity, error) {
+	c := conf.Backend.SemtechUDP.SessionAffinity
+
+	if c.AdvertiseAddress == "" {
+		return nil, errors.New("advertise_address must be set")
+	}
+
+	if c.CACert == "" && c.BearerToken == "" {
+		return nil, errors.New("ca_cert and / or bearer_token must be set")
+	}
+
+	if c.CACert != "" && (c.TLSCert == "" || c.TLSKey == "") {
+		return nil, errors.New("ca_cert requires tls_cert and tls_key")
+	}
+
+	clientTLSConfig, err := newAffinityTLSConfig(c.CACert, c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "new tls config error")
+	}
+
+	serverTLSConfig, err := newAffinityServerTLSConfig(c.CACert, c.TLSCert, c.TLSKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "new server tls config error")
+	}
+
+	opts, err := redis.ParseURL(c.RedisURL)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse redis url error")
+	}
+
+	a := sessionAffinity{
+		store: &redisAffinityStore{
+			client:    redis.NewClient(opts),
+			keyPrefix: c.KeyPrefix,
+		},
+		advertiseAddress: c.AdvertiseAddress,
+		ttl:              c.TTL,
+		timeout:          c.Timeout,
+		bearerToken:      c.BearerToken,
+		clientTLSConfig:  clientTLSConfig,
+		serverTLSConfig:  serverTLSConfig,
+		clients:          make(map[string]*grpc.ClientConn),
+		registered:       make(map[lorawan.EUI64]time.Time),
+	}
+
+	if err := a.listen(c.Bind, send); err != nil {
+		return nil, err
+	}
+
+	return &a, nil
+}
+
+// listen creates the listener and the gRPC server receiving the downlinks
+// forwarded by the other instances, which are sent using the given function.
+func (a *sessionAffinity) listen(bind string, send func(gw.DownlinkFrame) error) error {
+	var err error
+	a.listener, err = net.Listen("tcp", bind)
+	if err != nil {
+		return errors.Wrap(err, "listen tcp error")
+	}
+
+	opts := []grpc.ServerOption{
+		grpc.UnaryInterceptor(a.authenticate),
+	}
+	if a.serverTLSConfig != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(a.serverTLSConfig)))
+	}
+
+	a.server = grpc.NewServer(opts...)
+	RegisterSessionAffinityServiceServer(a.server, &sessionAffinityServer{send: send})
+
+	return nil
+}
+
+// register registers this instance as the instance holding the session of
+// the given gateway. The registration is refreshed after half of the TTL.
+func (a *sessionAffinity) register(gatewayID lorawan.EUI64, now time.Time) error {
+	a.mux.Lock()
+	last, ok := a.registered[gatewayID]
+	if ok && now.Sub(last) < a.ttl/2 {
+		a.mux.Unlock()
+		return nil
+	}
+	a.registered[gatewayID] = now
+	a.mux.Unlock()
+
+	if err := a.store.set(gatewayID, a.advertiseAddress, a.ttl); err != nil {
+		a.mux.Lock()
+		delete(a.registered, gatewayID)
+		a.mux.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// forward forwards the given downlink to the instance holding the session of
+// the gateway.
+func (a *sessionAffinity) forward(gatewayID lorawan.EUI64, frame gw.DownlinkFrame) error {
+	instance, err := a.store.get(gatewayID)
+	if err != nil {
+		return errors.Wrap(err, "get instance error")
+	}
+
+	if instance == "" || instance == a.advertiseAddress {
+		return errGatewayDoesNotExist
+	}
+
+	conn, err := a.getClient(instance)
+	if err != nil {
+		return errors.Wrap(err, "get client error")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), a.timeout)
+	defer cancel()
+
+	if _, err := NewSessionAffinityServiceClient(conn).Forward(ctx, &frame); err != nil {
+		return errors.Wrap(err, "forward rpc error")
+	}
+
+	sessionAffinityForwardCounter().Inc()
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"instance":   instance,
+	}).Debug("backend/semtechudp: downlink forwarded to instance holding the gateway session")
+
+	return nil
+}
+
+// getClient returns the client connection of the given instance. The
+// connection is created on the first use and re-used afterwards.
+func (a *sessionAffinity) getClient(instance string) (*grpc.ClientConn, error) {
+	a.clientsMux.Lock()
+	defer a.clientsMux.Unlock()
+
+	if conn, ok := a.clients[instance]; ok {
+		return conn, nil
+	}
+
+	var opts []grpc.DialOption
+	if a.clientTLSConfig != nil {
+		opts = append(opts, grpc.WithTransportCredentials(credentials.NewTLS(a.clientTLSConfig)))
+	} else {
+		opts = append(opts, grpc.WithInsecure())
+	}
+	if a.bearerToken != "" {
+		opts = append(opts, grpc.WithPerRPCCredentials(bearerTokenCredentials(a.bearerToken)))
+	}
+
+	conn, err := grpc.Dial(instance, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "grpc dial error")
+	}
+	a.clients[instance] = conn
+
+	return conn, nil
+}
+
+// serve serves the SessionAffinityService on the listener.
+func (a *sessionAffinity) serve() error {
+	return a.server.Serve(a.listener)
+}
+
+// authenticate implements the unary server interceptor validating the
+// bearer token (when configured). The client certificates are validated by
+// the TLS configuration of the server.
+func (a *sessionAffinity) authenticate(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	if a.bearerToken != "" {
+		var token string
+		md, _ := metadata.FromIncomingContext(ctx)
+		if v := md.Get("authorization"); len(v) != 0 {
+			token = strings.TrimPrefix(v[0], "Bearer ")
+		}
+
+		if subtle.ConstantTimeCompare([]byte(token), []byte(a.bearerToken)) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid bearer token")
+		}
+	}
+
+	return handler(ctx, req)
+}
+
+func (a *sessionAffinity) close() error {
+	a.server.Stop()
+
+	a.clientsMux.Lock()
+	for instance, conn := range a.clients {
+		conn.Close()
+		delete(a.clients, instance)
+	}
+	a.clientsMux.Unlock()
+
+	return a.store.close()
+}
+
+// sessionAffinityServer implements the SessionAffinityService.
+type sessionAffinityServer struct {
+	send func(gw.DownlinkFrame) error
+}
+
+// Forward sends the forwarded downlink to the gateway.
+func (s *sessionAffinityServer) Forward(ctx context.Context, frame *gw.DownlinkFrame) (*empty.Empty, error) {
+	if err := s.send(*frame); err != nil {
+		if errors.Cause(err) == errGatewayDoesNotExist {
+			return nil, status.Error(codes.NotFound, err.Error())
+		}
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &empty.Empty{}, nil
+}
+
+// bearerTokenCredentials implements the per-RPC credentials presenting the
+// bearer token to the other instances.
+type bearerTokenCredentials string
+
+func (t bearerTokenCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{
+		"authorization": "Bearer " + string(t),
+	}, nil
+}
+
+func (t bearerTokenCredentials) RequireTransportSecurity() bool {
+	return false
+}
+
+// newAffinityTLSConfig returns the TLS configuration of the client
+// forwarding the downlinks. The CA certificate is used to verify the other
+// instances, the certificate and key are presented as client certificate.
+func newAffinityTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if certFile != "" && keyFile != "" {
+		kp, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load tls key-pair error")
+		}
+		tlsConfig.Certificates = []tls.Certificate{kp}
+	}
+
+	return tlsConfig, nil
+}
+
+// newAffinityServerTLSConfig returns the TLS configuration of the server
+// receiving the forwarded downlinks. When the CA certificate is set, the
+// server only accepts client certificates signed by this CA.
+func newAffinityServerTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+
+	kp, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load tls key-pair error")
+	}
+
+	tlsConfig := &tls.Config{
+		Certificates: []tls.Certificate{kp},
+	}
+
+	if caFile != "" {
+		pool, err := loadCertPool(caFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	return tlsConfig, nil
+}
+
+func loadCertPool(caFile string) (*x509.CertPool, error) {
+	caCert, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return nil, errors.Wrap(err, "load ca cert error")
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, errors.New("append ca cert to pool error")
+	}
+
+	return pool, nil
+}
+
+// redisAffinityStore stores the gateway to instance mapping in Redis, using
+// a key (with TTL) per gateway.
+type redisAffinityStore struct {
+	client    *redis.Client
+	keyPrefix string
+}
+
+func (r *redisAffinityStore) set(gatewayID lorawan.EUI64, instance string, ttl time.Duration) error {
+	if err := r.client.Set(r.keyPrefix+gatewayID.String(), instance, ttl).Err(); err != nil {
+		return errors.Wrap(err, "redis set error")
+	}
+	return nil
+}
+
+func (r *redisAffinityStore) get(gatewayID lorawan.EUI64) (string, error) {
+	instance, err := r.client.Get(r.keyPrefix + gatewayID.String()).Result()
+	if err != nil {
+		if err == redis.Nil {
+			return "", nil
+		}
+		return "", errors.Wrap(err, "redis get error")
+	}
+	return instance, nil
+}
+
+func (r *redisAffinityStore) close() error {
+	return r.client.Close()
+}
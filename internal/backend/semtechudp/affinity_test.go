@@ -0,0 +1,220 @@
+package semtechudp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+type testAffinityStore struct {
+	sync.Mutex
+	instances map[lorawan.EUI64]string
+	sets      int
+}
+
+func (s *testAffinityStore) set(gatewayID lorawan.EUI64, instance string, ttl time.Duration) error {
+	s.Lock()
+	defer s.Unlock()
+	s.instances[gatewayID] = instance
+	s.sets++
+	return nil
+}
+
+func (s *testAffinityStore) get(gatewayID lorawan.EUI64) (string, error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.instances[gatewayID], nil
+}
+
+func (s *testAffinityStore) close() error {
+	return nil
+}
+
+// writeTestCertificates writes a CA certificate and a certificate (with key)
+// signed by this CA, valid for 127.0.0.1 and usable as server and client
+// certificate, to the given directory.
+func writeTestCertificates(t *testing.T, dir string) (string, string, string) {
+	assert := require.New(t)
+
+	writePEM := func(name, typ string, b []byte) string {
+		f := filepath.Join(dir, name)
+		assert.NoError(ioutil.WriteFile(f, pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: b}), 0600))
+		return f
+	}
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+	ca := x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, &ca, &ca, &caKey.PublicKey, caKey)
+	assert.NoError(err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+	der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "bridge"},
+		NotBefore:    time.Now(),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}, &ca, &key.PublicKey, caKey)
+	assert.NoError(err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(err)
+
+	return writePEM("ca.pem", "CERTIFICATE", caDER),
+		writePEM("cert.pem", "CERTIFICATE", der),
+		writePEM("key.pem", "EC PRIVATE KEY", keyDER)
+}
+
+func TestSessionAffinity(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	store := testAffinityStore{instances: make(map[lorawan.EUI64]string)}
+
+	dir, err := ioutil.TempDir("", "affinity")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	caFile, certFile, keyFile := writeTestCertificates(t, dir)
+
+	clientTLSConfig, err := newAffinityTLSConfig(caFile, certFile, keyFile)
+	require.NoError(t, err)
+	serverTLSConfig, err := newAffinityServerTLSConfig(caFile, certFile, keyFile)
+	require.NoError(t, err)
+
+	framesChan := make(chan gw.DownlinkFrame, 1)
+	send := func(frame gw.DownlinkFrame) error {
+		if frame.Token == 0 {
+			return errGatewayDoesNotExist
+		}
+		framesChan <- frame
+		return nil
+	}
+
+	newAffinity := func() *sessionAffinity {
+		a := sessionAffinity{
+			store:           &store,
+			ttl:             time.Minute,
+			timeout:         time.Second,
+			bearerToken:     "secret",
+			clientTLSConfig: clientTLSConfig,
+			serverTLSConfig: serverTLSConfig,
+			clients:         make(map[string]*grpc.ClientConn),
+			registered:      make(map[lorawan.EUI64]time.Time),
+		}
+		require.NoError(t, a.listen("127.0.0.1:0", send))
+		a.advertiseAddress = a.listener.Addr().String()
+		go a.serve()
+
+		return &a
+	}
+
+	a1 := newAffinity()
+	defer a1.close()
+	a2 := newAffinity()
+	defer a2.close()
+
+	t.Run("Gateway not registered", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Equal(errGatewayDoesNotExist, a2.forward(gatewayID, gw.DownlinkFrame{}))
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		assert := require.New(t)
+		now := time.Now()
+
+		assert.NoError(a1.register(gatewayID, now))
+		assert.Equal(1, store.sets)
+
+		// the registration is not refreshed within half of the ttl
+		assert.NoError(a1.register(gatewayID, now.Add(29*time.Second)))
+		assert.Equal(1, store.sets)
+
+		assert.NoError(a1.register(gatewayID, now.Add(31*time.Second)))
+		assert.Equal(2, store.sets)
+		assert.Equal(a1.advertiseAddress, store.instances[gatewayID])
+	})
+
+	t.Run("Own instance", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Equal(errGatewayDoesNotExist, a1.forward(gatewayID, gw.DownlinkFrame{}))
+	})
+
+	t.Run("Forward", func(t *testing.T) {
+		assert := require.New(t)
+
+		frame := gw.DownlinkFrame{
+			PhyPayload: []byte{1, 2, 3},
+			Token:      123,
+			TxInfo: &gw.DownlinkTXInfo{
+				GatewayId: gatewayID[:],
+				Frequency: 868100000,
+			},
+		}
+		assert.NoError(a2.forward(gatewayID, frame))
+
+		received := <-framesChan
+		assert.Equal(frame.PhyPayload, received.PhyPayload)
+		assert.Equal(frame.Token, received.Token)
+		assert.Equal(frame.TxInfo.Frequency, received.TxInfo.Frequency)
+	})
+
+	t.Run("Forward without client certificate", func(t *testing.T) {
+		assert := require.New(t)
+
+		a := newAffinity()
+		defer a.close()
+		a.clientTLSConfig = &tls.Config{RootCAs: clientTLSConfig.RootCAs}
+
+		assert.Error(a.forward(gatewayID, gw.DownlinkFrame{Token: 123}))
+	})
+
+	t.Run("Forward with invalid bearer token", func(t *testing.T) {
+		assert := require.New(t)
+
+		a := newAffinity()
+		defer a.close()
+		a.bearerToken = "invalid"
+
+		err := a.forward(gatewayID, gw.DownlinkFrame{Token: 123})
+		assert.Error(err)
+		assert.Equal(codes.Unauthenticated, status.Code(errors.Cause(err)))
+	})
+
+	t.Run("Forward error", func(t *testing.T) {
+		assert := require.New(t)
+
+		err := a2.forward(gatewayID, gw.DownlinkFrame{})
+		assert.Error(err)
+		assert.Equal(codes.NotFound, status.Code(errors.Cause(err)))
+	})
+}
@@ -1,6 +1,8 @@
 package semtechudp
 
 import (
+	"bytes"
+	"context"
 	"crypto/rand"
 	"encoding/base64"
 	"encoding/binary"
@@ -8,23 +10,56 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net"
+	"os"
 	"sync"
+	"text/template"
 	"time"
 
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/configversion"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/downlinkid"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/ringbuffer"
 	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+	"github.com/brocaar/lora-gateway-bridge/internal/beacon"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkpriority"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinktrace"
 	"github.com/brocaar/lora-gateway-bridge/internal/filters"
+	"github.com/brocaar/lora-gateway-bridge/internal/keepalive"
+	"github.com/brocaar/lora-gateway-bridge/internal/latency"
+	"github.com/brocaar/lora-gateway-bridge/internal/logcontext"
+	"github.com/brocaar/lora-gateway-bridge/internal/overload"
+	"github.com/brocaar/lora-gateway-bridge/internal/quarantine"
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
 )
 
+// frameWriter defines the interface of the stream transports, writing a
+// single GWMP message per call.
+type frameWriter interface {
+	writeFrame([]byte) error
+}
+
 // udpPacket represents a raw UDP packet.
 type udpPacket struct {
 	addr *net.UDPAddr
 	data []byte
+
+	// gatewayID is set for packets sent to the gateway, as these packets
+	// don't contain the gateway ID.
+	gatewayID lorawan.EUI64
+
+	// stream is set when the packet was received over (or must be sent over)
+	// a stream transport (TCP or websocket) instead of UDP.
+	stream frameWriter
+
+	// downlinkToken is set for PullResp packets, to trace the write of the
+	// downlink.
+	downlinkToken uint32
 }
 
 type pfConfiguration struct {
@@ -32,34 +67,93 @@ type pfConfiguration struct {
 	baseFile       string
 	outputFile     string
 	restartCommand string
-	currentVersion string
+}
+
+// pfConfigurationTemplate holds the templates used to resolve the
+// packet-forwarder configuration of gateways that are not explicitly
+// configured.
+type pfConfigurationTemplate struct {
+	baseFile       *template.Template
+	outputFile     *template.Template
+	restartCommand *template.Template
 }
 
 // Backend implements a Semtech packet-forwarder (UDP) gateway backend.
 type Backend struct {
 	sync.RWMutex
 
-	// tokenMap stores the token to UUID mapping. This should take ~ 1MB of
-	// memory. Optionaly this could be optimized by letting keys expire after
-	// a given time.
-	tokenMap map[uint16][]byte
+	// downlinkIDs stores the token to downlink ID (UUID) mapping.
+	downlinkIDs *downlinkid.Store
+
+	// configVersions stores the last configuration applied per gateway.
+	configVersions *configversion.Store
 
 	downlinkTXAckChan chan gw.DownlinkTXAck
 	uplinkFrameChan   chan gw.UplinkFrame
 	gatewayStatsChan  chan gw.GatewayStats
+	uploadChan        chan events.Upload
 	udpSendChan       chan udpPacket
 
+	configurationDiffChan chan events.ConfigurationDiff
+	protocolErrorChan     chan events.ProtocolError
+	certExpiryChan        chan events.CertExpiry
+	notifyChan            chan events.Notify
+
 	wg             sync.WaitGroup
 	conn           *net.UDPConn
+	tcpListener    net.Listener
+	wsListener     net.Listener
 	closed         bool
 	gateways       gateways
 	fakeRxTime     bool
 	configurations []pfConfiguration
+	configTemplate *pfConfigurationTemplate
 	skipCRCCheck   bool
+	capture        *packetCapture
+	ringBuffer     *ringbuffer.Buffer
+
+	// configurationDryRun enables the dry-run mode, in which case the
+	// configuration diff is published instead of applying the configuration.
+	configurationDryRun bool
+
+	// configurationRollback enables the rollback of the configuration when
+	// the restart command fails or the gateway does not send a PULL_DATA
+	// within the configurationRollbackTimeout after the restart.
+	configurationRollback        bool
+	configurationRollbackTimeout time.Duration
+
+	downlinkInFlight  *inFlightLimiter
+	downlinkSizeGuard downlinkSizeGuard
+
+	// sourcePolicy validates the source address of the received packets.
+	sourcePolicy *sourcePolicy
+
+	// sessionAffinity is set when the downlinks are routed between multiple
+	// instances running behind a UDP load balancer.
+	sessionAffinity *sessionAffinity
 }
 
 // NewBackend creates a new backend.
 func NewBackend(conf config.Config) (*Backend, error) {
+	downlinkIDs, err := downlinkid.NewStore(conf.Backend.DownlinkIDs.File, conf.Backend.DownlinkIDs.TTL)
+	if err != nil {
+		return nil, errors.Wrap(err, "new downlink id store error")
+	}
+
+	configVersions, err := configversion.NewStore(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "new configuration version store error")
+	}
+
+	sourcePolicy, err := newSourcePolicy(
+		conf.Backend.SemtechUDP.SourceAddress.Policy,
+		conf.Backend.SemtechUDP.SourceAddress.AllowedNetworks,
+		conf.Backend.SemtechUDP.SourceAddress.DeniedNetworks,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "new source address policy error")
+	}
+
 	addr, err := net.ResolveUDPAddr("udp", conf.Backend.SemtechUDP.UDPBind)
 	if err != nil {
 		return nil, errors.Wrap(err, "resolve udp addr error")
@@ -76,16 +170,48 @@ func NewBackend(conf config.Config) (*Backend, error) {
 		downlinkTXAckChan: make(chan gw.DownlinkTXAck),
 		uplinkFrameChan:   make(chan gw.UplinkFrame),
 		gatewayStatsChan:  make(chan gw.GatewayStats),
+		uploadChan:        make(chan events.Upload),
 		udpSendChan:       make(chan udpPacket),
 		gateways: gateways{
 			gateways:       make(map[lorawan.EUI64]gateway),
-			connectChan:    make(chan lorawan.EUI64),
-			disconnectChan: make(chan lorawan.EUI64),
+			connectChan:    make(chan events.Connection),
+			disconnectChan: make(chan events.Connection),
 		},
-		fakeRxTime:   conf.Backend.SemtechUDP.FakeRxTime,
-		skipCRCCheck: conf.Backend.SemtechUDP.SkipCRCCheck,
-		tokenMap:     make(map[uint16][]byte),
+		fakeRxTime:     conf.Backend.SemtechUDP.FakeRxTime,
+		skipCRCCheck:   conf.Backend.SemtechUDP.SkipCRCCheck,
+		downlinkIDs:    downlinkIDs,
+		configVersions: configVersions,
+
+		configurationDiffChan: make(chan events.ConfigurationDiff),
+		protocolErrorChan:     make(chan events.ProtocolError),
+		certExpiryChan:        make(chan events.CertExpiry),
+		notifyChan:            make(chan events.Notify),
+		configurationDryRun:   conf.Backend.SemtechUDP.ConfigurationDryRun,
+
+		configurationRollback:        conf.Backend.SemtechUDP.ConfigurationRollback.Enabled,
+		configurationRollbackTimeout: conf.Backend.SemtechUDP.ConfigurationRollback.Timeout,
+
+		downlinkInFlight: newInFlightLimiter(
+			conf.Backend.SemtechUDP.DownlinkInFlight.Max,
+			conf.Backend.SemtechUDP.DownlinkInFlight.QueueSize,
+			conf.Backend.SemtechUDP.DownlinkInFlight.Timeout,
+		),
+		downlinkSizeGuard: downlinkSizeGuard{
+			maxSize: conf.Backend.SemtechUDP.DownlinkSizeGuard.MaxSize,
+			reject:  conf.Backend.SemtechUDP.DownlinkSizeGuard.Reject,
+		},
+		capture: newPacketCapture(
+			conf.Backend.SemtechUDP.Capture.Directory,
+			conf.Backend.SemtechUDP.Capture.MaxFileSize,
+			conf.Backend.SemtechUDP.Capture.Enabled,
+		),
+		sourcePolicy: sourcePolicy,
+		ringBuffer:   ringbuffer.New(conf.Backend.RingBuffer.Size, conf.Backend.RingBuffer.DumpDirectory),
 	}
+	b.downlinkSizeGuard.notifyChan = b.notifyChan
+
+	admin.HandleFunc("/api/backend/semtech_udp/capture", b.capture.handleHTTP)
+	admin.HandleFunc("/api/backend/ring_buffer", b.ringBuffer.HandleHTTP)
 
 	for _, pfConf := range conf.Backend.SemtechUDP.Configuration {
 		c := pfConfiguration{
@@ -99,6 +225,24 @@ func NewBackend(conf config.Config) (*Backend, error) {
 		b.configurations = append(b.configurations, c)
 	}
 
+	if tmpl := conf.Backend.SemtechUDP.ConfigurationTemplate; tmpl.BaseFile != "" {
+		b.configTemplate = &pfConfigurationTemplate{}
+		for _, t := range []struct {
+			name   string
+			text   string
+			target **template.Template
+		}{
+			{"base_file", tmpl.BaseFile, &b.configTemplate.baseFile},
+			{"output_file", tmpl.OutputFile, &b.configTemplate.outputFile},
+			{"restart_command", tmpl.RestartCommand, &b.configTemplate.restartCommand},
+		} {
+			*t.target, err = template.New(t.name).Parse(t.text)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parse %s template error", t.name)
+			}
+		}
+	}
+
 	go func() {
 		for {
 			log.Debug("backend/semtechudp: cleanup gateway registry")
@@ -109,6 +253,17 @@ func NewBackend(conf config.Config) (*Backend, error) {
 		}
 	}()
 
+	if b.downlinkInFlight.enabled() {
+		go func() {
+			for !b.isClosed() {
+				for _, p := range b.downlinkInFlight.expire(time.Now()) {
+					b.udpSendChan <- p
+				}
+				time.Sleep(100 * time.Millisecond)
+			}
+		}()
+	}
+
 	go func() {
 		b.wg.Add(1)
 		err := b.readPackets()
@@ -118,6 +273,54 @@ func NewBackend(conf config.Config) (*Backend, error) {
 		b.wg.Done()
 	}()
 
+	if bind := conf.Backend.SemtechUDP.TCPBind; bind != "" {
+		b.tcpListener, err = listenTCP(bind, conf.Backend.SemtechUDP.TCPTLSCert, conf.Backend.SemtechUDP.TCPTLSKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "listen tcp error")
+		}
+		log.WithField("addr", b.tcpListener.Addr()).Info("backend/semtechudp: starting gateway tcp listener")
+
+		go func() {
+			err := b.acceptTCP()
+			if !b.isClosed() {
+				log.WithError(err).Error("backend/semtechudp: accept tcp connections error")
+			}
+		}()
+	}
+
+	if bind := conf.Backend.SemtechUDP.WebsocketBind; bind != "" {
+		b.wsListener, err = listenTCP(bind, conf.Backend.SemtechUDP.WebsocketTLSCert, conf.Backend.SemtechUDP.WebsocketTLSKey)
+		if err != nil {
+			return nil, errors.Wrap(err, "listen websocket error")
+		}
+		log.WithField("addr", b.wsListener.Addr()).Info("backend/semtechudp: starting gateway websocket listener")
+
+		go func() {
+			err := b.serveWebsocket()
+			if !b.isClosed() {
+				log.WithError(err).Error("backend/semtechudp: serve websocket error")
+			}
+		}()
+	}
+
+	if conf.Backend.SemtechUDP.SessionAffinity.Enabled {
+		b.sessionAffinity, err = newSessionAffinity(conf, b.sendDownlinkFrame)
+		if err != nil {
+			return nil, errors.Wrap(err, "new session affinity error")
+		}
+		log.WithFields(log.Fields{
+			"addr":              b.sessionAffinity.listener.Addr(),
+			"advertise_address": b.sessionAffinity.advertiseAddress,
+		}).Info("backend/semtechudp: starting session affinity grpc server")
+
+		go func() {
+			err := b.sessionAffinity.serve()
+			if !b.isClosed() {
+				log.WithError(err).Error("backend/semtechudp: serve session affinity error")
+			}
+		}()
+	}
+
 	go func() {
 		b.wg.Add(1)
 		err := b.sendPackets()
@@ -141,10 +344,55 @@ func (b *Backend) Close() error {
 		return errors.Wrap(err, "close udp listener error")
 	}
 
+	if b.tcpListener != nil {
+		if err := b.tcpListener.Close(); err != nil {
+			return errors.Wrap(err, "close tcp listener error")
+		}
+	}
+
+	if b.wsListener != nil {
+		if err := b.wsListener.Close(); err != nil {
+			return errors.Wrap(err, "close websocket listener error")
+		}
+	}
+
+	if b.sessionAffinity != nil {
+		if err := b.sessionAffinity.close(); err != nil {
+			return errors.Wrap(err, "close session affinity error")
+		}
+	}
+
 	log.Info("backend/semtechudp: handling last packets")
 	close(b.udpSendChan)
 	b.Unlock()
 	b.wg.Wait()
+
+	if err := b.capture.close(); err != nil {
+		return errors.Wrap(err, "close packet capture error")
+	}
+
+	if err := b.downlinkIDs.Close(); err != nil {
+		return errors.Wrap(err, "close downlink id store error")
+	}
+
+	if err := b.configVersions.Close(); err != nil {
+		return errors.Wrap(err, "close configuration version store error")
+	}
+
+	return nil
+}
+
+// HealthCheck returns an error when the backend is closed. It acquires the
+// backend and gateway registry locks, thus it blocks when one of the packet
+// handlers is deadlocked.
+func (b *Backend) HealthCheck() error {
+	if b.isClosed() {
+		return errors.New("backend is closed")
+	}
+
+	b.gateways.RLock()
+	b.gateways.RUnlock()
+
 	return nil
 }
 
@@ -164,18 +412,66 @@ func (b *Backend) GetUplinkFrameChan() chan gw.UplinkFrame {
 }
 
 // GetConnectChan returns the channel for received gateway connections.
-func (b *Backend) GetConnectChan() chan lorawan.EUI64 {
+func (b *Backend) GetConnectChan() chan events.Connection {
 	return b.gateways.connectChan
 }
 
 // GetDisconnectChan returns the channel for disconnected gateway connections.
-func (b *Backend) GetDisconnectChan() chan lorawan.EUI64 {
+func (b *Backend) GetDisconnectChan() chan events.Connection {
 	return b.gateways.disconnectChan
 }
 
+// GetUploadChan returns the channel for received uploads. The Semtech UDP
+// protocol does not support uploads, thus nothing is sent to this channel.
+func (b *Backend) GetUploadChan() chan events.Upload {
+	return b.uploadChan
+}
+
+// GetConfigurationDiffChan returns the channel for gateway configuration
+// diffs. These are only sent when the configuration dry-run is enabled.
+func (b *Backend) GetConfigurationDiffChan() chan events.ConfigurationDiff {
+	return b.configurationDiffChan
+}
+
+// GetProtocolErrorChan returns the channel for rejected gateway messages.
+// Strict mode is not supported by this backend, thus nothing is sent to this
+// channel.
+func (b *Backend) GetProtocolErrorChan() chan events.ProtocolError {
+	return b.protocolErrorChan
+}
+
+// GetCertExpiryChan returns the channel for client certificates which are
+// about to expire.
+func (b *Backend) GetCertExpiryChan() chan events.CertExpiry {
+	return b.certExpiryChan
+}
+
+// GetNotifyChan returns the channel for gateway notifications.
+func (b *Backend) GetNotifyChan() chan events.Notify {
+	return b.notifyChan
+}
+
 // SendDownlinkFrame sends the given downlink frame to the gateway.
 func (b *Backend) SendDownlinkFrame(frame gw.DownlinkFrame) error {
-	// mutex is needed in order to write to tokenMap
+	err := b.sendDownlinkFrame(frame)
+
+	// forward the downlink to the instance holding the gateway session
+	if errors.Cause(err) == errGatewayDoesNotExist && b.sessionAffinity != nil {
+		var gatewayID lorawan.EUI64
+		copy(gatewayID[:], frame.GetTxInfo().GetGatewayId())
+
+		if err := b.sessionAffinity.forward(gatewayID, frame); err != nil {
+			return errors.Wrap(err, "forward downlink error")
+		}
+		return nil
+	}
+
+	return err
+}
+
+// sendDownlinkFrame sends the given downlink frame to the gateway connected
+// to this instance.
+func (b *Backend) sendDownlinkFrame(frame gw.DownlinkFrame) error {
 	b.Lock()
 	defer b.Unlock()
 
@@ -188,12 +484,12 @@ func (b *Backend) SendDownlinkFrame(frame gw.DownlinkFrame) error {
 		frame.Token = uint32(binary.BigEndian.Uint16(tokenB))
 	}
 
-	// store token to UUID mapping
-	b.tokenMap[uint16(frame.Token)] = frame.DownlinkId
-
 	var gatewayID lorawan.EUI64
 	copy(gatewayID[:], frame.GetTxInfo().GetGatewayId())
 
+	// store token to UUID mapping
+	b.downlinkIDs.Set(gatewayID, uint16(frame.Token), frame.DownlinkId)
+
 	gw, err := b.gateways.get(gatewayID)
 	if err != nil {
 		return errors.Wrap(err, "get gateway error")
@@ -209,13 +505,70 @@ func (b *Backend) SendDownlinkFrame(frame gw.DownlinkFrame) error {
 		return errors.Wrap(err, "backend/semtechudp: marshal PullRespPacket error")
 	}
 
-	b.udpSendChan <- udpPacket{
-		data: bytes,
-		addr: gw.addr,
+	downlinktrace.Stage(gatewayID[:], frame.Token, downlinktrace.StageConvert)
+
+	// stream transports (TCP / websocket) are not affected by fragmentation
+	if gw.stream == nil && b.downlinkSizeGuard.check(gatewayID, frame, len(bytes)) {
+		b.rejectDownlinkFrame(gatewayID, frame, packetTooLargeCode, fmt.Errorf("PULL_RESP of %d bytes exceeds the max. size", len(bytes)))
+		return nil
 	}
+
+	p := udpPacket{
+		data:          bytes,
+		addr:          gw.addr,
+		gatewayID:     gatewayID,
+		stream:        gw.stream,
+		downlinkToken: frame.Token,
+	}
+
+	if b.downlinkInFlight.enabled() {
+		send, preempted, err := b.downlinkInFlight.send(gatewayID, uint16(frame.Token), downlinkpriority.Get(frame), p, time.Now())
+		if err != nil {
+			downlinkInFlightLimitCounter("rejected").Inc()
+			b.rejectDownlinkFrame(gatewayID, frame, "IN_FLIGHT_LIMIT", err)
+			return nil
+		}
+
+		// the queue was full, a queued downlink with a lower priority has
+		// been replaced by this downlink
+		if preempted != nil {
+			downlinkInFlightLimitCounter("preempted").Inc()
+			b.rejectPreemptedDownlink(gatewayID, *preempted)
+		}
+
+		if !send {
+			downlinkInFlightLimitCounter("queued").Inc()
+			log.WithField("gateway_id", gatewayID).Debug("backend/semtechudp: downlink queued, in-flight limit reached")
+			return nil
+		}
+	}
+
+	b.udpSendChan <- p
 	return nil
 }
 
+// rejectDownlinkFrame publishes an ack with the given error (e.g.
+// IN_FLIGHT_LIMIT) for the given downlink frame.
+func (b *Backend) rejectDownlinkFrame(gatewayID lorawan.EUI64, frame gw.DownlinkFrame, code string, err error) {
+	log.WithError(err).WithField("gateway_id", gatewayID).Warning("backend/semtechudp: downlink rejected")
+
+	b.downlinkTXAckChan <- gw.DownlinkTXAck{
+		GatewayId:  gatewayID[:],
+		Token:      frame.Token,
+		DownlinkId: frame.DownlinkId,
+		Error:      code,
+	}
+}
+
+// rejectPreemptedDownlink publishes an IN_FLIGHT_LIMIT ack for the given
+// queued downlink, preempted by a downlink with a higher priority.
+func (b *Backend) rejectPreemptedDownlink(gatewayID lorawan.EUI64, p udpPacket) {
+	b.rejectDownlinkFrame(gatewayID, gw.DownlinkFrame{
+		Token:      p.downlinkToken,
+		DownlinkId: b.downlinkIDs.Get(gatewayID, uint16(p.downlinkToken)),
+	}, "IN_FLIGHT_LIMIT", errors.New("downlink preempted by a downlink with a higher priority"))
+}
+
 // ApplyConfiguration applies the given configuration to the gateway
 // (packet-forwarder).
 func (b *Backend) ApplyConfiguration(config gw.GatewayConfiguration) error {
@@ -229,6 +582,19 @@ func (b *Backend) ApplyConfiguration(config gw.GatewayConfiguration) error {
 			pfConfig = &b.configurations[i]
 		}
 	}
+
+	if pfConfig == nil && b.configTemplate != nil {
+		c, err := b.configTemplate.resolve(gatewayID)
+		if err != nil {
+			b.Unlock()
+			return errors.Wrap(err, "resolve packet-forwarder configuration error")
+		}
+
+		if c != nil {
+			b.configurations = append(b.configurations, *c)
+			pfConfig = c
+		}
+	}
 	b.Unlock()
 
 	if pfConfig == nil {
@@ -238,6 +604,51 @@ func (b *Backend) ApplyConfiguration(config gw.GatewayConfiguration) error {
 	return b.applyConfiguration(*pfConfig, config)
 }
 
+// resolve resolves the packet-forwarder configuration for the given gateway
+// ID. It returns nil when the resolved base file does not exist, in which
+// case the gateway is not managed by this bridge.
+func (t pfConfigurationTemplate) resolve(gatewayID lorawan.EUI64) (*pfConfiguration, error) {
+	c := pfConfiguration{
+		gatewayID: gatewayID,
+	}
+
+	for _, r := range []struct {
+		tmpl   *template.Template
+		target *string
+	}{
+		{t.baseFile, &c.baseFile},
+		{t.outputFile, &c.outputFile},
+		{t.restartCommand, &c.restartCommand},
+	} {
+		buf := bytes.NewBuffer(nil)
+		if err := r.tmpl.Execute(buf, struct {
+			GatewayID lorawan.EUI64
+		}{gatewayID}); err != nil {
+			return nil, errors.Wrapf(err, "execute %s template error", r.tmpl.Name())
+		}
+		*r.target = buf.String()
+	}
+
+	if _, err := os.Stat(c.baseFile); err != nil {
+		if os.IsNotExist(err) {
+			log.WithFields(log.Fields{
+				"gateway_id": gatewayID,
+				"file":       c.baseFile,
+			}).Debug("backend/semtechudp: packet-forwarder base file does not exist")
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "stat base file error")
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id":  gatewayID,
+		"base_file":   c.baseFile,
+		"output_file": c.outputFile,
+	}).Info("backend/semtechudp: packet-forwarder configuration resolved")
+
+	return &c, nil
+}
+
 func (b *Backend) applyConfiguration(pfConfig pfConfiguration, config gw.GatewayConfiguration) error {
 	gwConfig, err := getGatewayConfig(config)
 	if err != nil {
@@ -253,12 +664,29 @@ func (b *Backend) applyConfiguration(pfConfig pfConfiguration, config gw.Gateway
 		return errors.Wrap(err, "merge config error")
 	}
 
+	if beacon.Enabled() {
+		mergeBeaconConfig(baseConfig, beacon.GetConfiguration())
+	}
+
 	// generate config json
 	bb, err := json.Marshal(baseConfig)
 	if err != nil {
 		return errors.Wrap(err, "marshal json error")
 	}
 
+	if b.configurationDryRun {
+		return b.publishConfigurationDiff(pfConfig, config.Version, bb)
+	}
+
+	// keep the current config file for the rollback
+	var previous []byte
+	if b.configurationRollback {
+		previous, err = readPreviousConfiguration(pfConfig.outputFile)
+		if err != nil {
+			return errors.Wrap(err, "read previous configuration error")
+		}
+	}
+
 	// write new config file to disk
 	if err = ioutil.WriteFile(pfConfig.outputFile, bb, 0644); err != nil {
 		return errors.Wrap(err, "write config file error")
@@ -269,7 +697,11 @@ func (b *Backend) applyConfiguration(pfConfig pfConfiguration, config gw.Gateway
 	}).Info("backend/semtechudp: new configuration file written")
 
 	// invoke restart command
+	restartedAt := time.Now()
 	if err = invokePFRestart(pfConfig.restartCommand); err != nil {
+		if previous != nil {
+			b.rollbackConfiguration(pfConfig, config.Version, previous, "restart_error", err.Error())
+		}
 		return errors.Wrap(err, "invoke packet-forwarder restart error")
 	}
 	log.WithFields(log.Fields{
@@ -277,13 +709,54 @@ func (b *Backend) applyConfiguration(pfConfig pfConfiguration, config gw.Gateway
 		"cmd":        pfConfig.restartCommand,
 	}).Info("backend/semtechudp: packet-forwarder restart command invoked")
 
-	b.Lock()
-	defer b.Unlock()
+	// the configuration version is stored once the gateway is back
+	if previous != nil {
+		go b.confirmConfiguration(pfConfig, config, previous, restartedAt)
+		return nil
+	}
 
-	for i := range b.configurations {
-		if b.configurations[i].gatewayID == pfConfig.gatewayID {
-			b.configurations[i].currentVersion = config.Version
+	if err := b.configVersions.Set(config); err != nil {
+		return errors.Wrap(err, "store configuration version error")
+	}
+
+	return nil
+}
+
+// publishConfigurationDiff publishes the diff between the current output
+// file and the given configuration, without writing the configuration or
+// restarting the packet-forwarder.
+func (b *Backend) publishConfigurationDiff(pfConfig pfConfiguration, version string, newConfig []byte) error {
+	var currentConfig []byte
+	if _, err := os.Stat(pfConfig.outputFile); err == nil {
+		current, err := loadConfigFile(pfConfig.outputFile)
+		if err != nil {
+			return errors.Wrap(err, "load output file error")
+		}
+
+		currentConfig, err = json.Marshal(current)
+		if err != nil {
+			return errors.Wrap(err, "marshal json error")
 		}
+	} else if !os.IsNotExist(err) {
+		return errors.Wrap(err, "stat output file error")
+	}
+
+	changes, err := diffConfig(currentConfig, newConfig)
+	if err != nil {
+		return errors.Wrap(err, "diff config error")
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": pfConfig.gatewayID,
+		"file":       pfConfig.outputFile,
+		"version":    version,
+		"changes":    len(changes),
+	}).Info("backend/semtechudp: configuration dry-run, configuration diff published")
+
+	b.configurationDiffChan <- events.ConfigurationDiff{
+		GatewayID: pfConfig.gatewayID,
+		Version:   version,
+		Changes:   changes,
 	}
 
 	return nil
@@ -314,41 +787,67 @@ func (b *Backend) readPackets() error {
 		// handle packet async
 		go func(up udpPacket) {
 			if err := b.handlePacket(up); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"data_base64": base64.StdEncoding.EncodeToString(up.data),
-					"addr":        up.addr,
-				}).Error("backend/semtechudp: could not handle packet")
+				b.handlePacketError(up, err)
 			}
 		}(up)
 	}
 }
 
+// handlePacketError logs the error and registers it for the gateway
+// quarantine. Errors of quarantined gateways are not logged to avoid log
+// floods.
+func (b *Backend) handlePacketError(up udpPacket, err error) {
+	if gatewayID, ok := getGatewayID(up.data); ok {
+		if _, err := b.ringBuffer.Dump(gatewayID, time.Now()); err != nil {
+			log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/semtechudp: dump ring buffer error")
+		}
+
+		quarantine.Error(gatewayID)
+		if quarantine.Quarantined(gatewayID) {
+			return
+		}
+	}
+
+	log.WithContext(logContext(up)).WithError(err).WithFields(log.Fields{
+		"data_base64": base64.StdEncoding.EncodeToString(up.data),
+	}).Error("backend/semtechudp: could not handle packet")
+}
+
 func (b *Backend) sendPackets() error {
 	for p := range b.udpSendChan {
+		ctx := logContext(p)
+
 		pt, err := packets.GetPacketType(p.data)
 		if err != nil {
-			log.WithError(err).WithFields(log.Fields{
-				"addr":        p.addr,
+			log.WithContext(ctx).WithError(err).WithFields(log.Fields{
 				"data_base64": base64.StdEncoding.EncodeToString(p.data),
 			}).Error("backend/semtechudp: get packet-type error")
 			continue
 		}
 
-		log.WithFields(log.Fields{
-			"addr":             p.addr,
-			"type":             pt,
+		log.WithContext(ctx).WithFields(log.Fields{
 			"protocol_version": p.data[0],
 		}).Debug("backend/semtechudp: sending udp packet to gateway")
 
-		_, err = b.conn.WriteToUDP(p.data, p.addr)
+		if p.stream != nil {
+			err = p.stream.writeFrame(p.data)
+		} else {
+			_, err = b.conn.WriteToUDP(p.data, p.addr)
+		}
 		if err != nil {
-			log.WithFields(log.Fields{
-				"addr":             p.addr,
-				"type":             pt,
+			log.WithContext(ctx).WithFields(log.Fields{
 				"protocol_version": p.data[0],
 			}).WithError(err).Error("backend/semtechudp: write to udp error")
+		} else if pt == packets.PullResp {
+			downlinktrace.Stage(p.gatewayID[:], p.downlinkToken, downlinktrace.StageWrite)
+			latency.DownlinkSent(p.gatewayID, uint16(p.downlinkToken))
 		}
 
+		if err := b.capture.write(p.gatewayID, captureDirectionDown, p.addr, p.data); err != nil {
+			log.WithContext(ctx).WithError(err).Error("backend/semtechudp: capture udp packet error")
+		}
+		b.ringBuffer.Add(p.gatewayID, ringbuffer.DirectionDown, p.data, time.Now())
+
 		udpWriteCounter(pt.String()).Inc()
 	}
 	return nil
@@ -366,17 +865,36 @@ func (b *Backend) handlePacket(up udpPacket) error {
 	if err != nil {
 		return err
 	}
-	log.WithFields(log.Fields{
-		"addr":             up.addr,
-		"type":             pt,
+
+	ctx := logContext(up)
+	log.WithContext(ctx).WithFields(log.Fields{
 		"protocol_version": up.data[0],
 	}).Debug("backend/semtechudp: received udp packet from gateway")
 
 	udpReadCounter(pt.String()).Inc()
 
+	// PUSH_DATA, PULL_DATA and TX_ACK contain the gateway ID at bytes 4 - 12
+	if len(up.data) >= 12 {
+		var gatewayID lorawan.EUI64
+		copy(gatewayID[:], up.data[4:12])
+
+		if err := b.capture.write(gatewayID, captureDirectionUp, up.addr, up.data); err != nil {
+			log.WithContext(ctx).WithError(err).Error("backend/semtechudp: capture udp packet error")
+		}
+		b.ringBuffer.Add(gatewayID, ringbuffer.DirectionUp, up.data, time.Now())
+
+		// a rejected packet is not returned as error, as this would count
+		// as error of the (spoofed) gateway for the quarantine
+		if err := b.checkSourceAddress(pt, gatewayID, up); err != nil {
+			sourceAddressRejectedCounter(pt.String()).Inc()
+			log.WithContext(ctx).WithError(err).Warning("backend/semtechudp: packet rejected by source address policy")
+			return nil
+		}
+	}
+
 	switch pt {
 	case packets.PushData:
-		return b.handlePushData(up)
+		return b.handlePushData(ctx, up)
 	case packets.PullData:
 		return b.handlePullData(up)
 	case packets.TXACK:
@@ -386,6 +904,17 @@ func (b *Backend) handlePacket(up udpPacket) error {
 	}
 }
 
+// checkSourceAddress validates the source address of the packet using the
+// source address policy.
+func (b *Backend) checkSourceAddress(pt packets.PacketType, gatewayID lorawan.EUI64, up udpPacket) error {
+	var registered *gateway
+	if gw, err := b.gateways.get(gatewayID); err == nil {
+		registered = &gw
+	}
+
+	return b.sourcePolicy.check(pt, up, registered)
+}
+
 func (b *Backend) handlePullData(up udpPacket) error {
 	var p packets.PullDataPacket
 	if err := p.UnmarshalBinary(up.data); err != nil {
@@ -400,8 +929,17 @@ func (b *Backend) handlePullData(up udpPacket) error {
 		return errors.Wrap(err, "marshal pull ack packet error")
 	}
 
+	keepalive.Seen(p.GatewayMAC)
+
+	if b.sessionAffinity != nil {
+		if err := b.sessionAffinity.register(p.GatewayMAC, time.Now()); err != nil {
+			log.WithError(err).WithField("gateway_id", p.GatewayMAC).Error("backend/semtechudp: register gateway session error")
+		}
+	}
+
 	err = b.gateways.set(p.GatewayMAC, gateway{
 		addr:            up.addr,
+		stream:          up.stream,
 		lastSeen:        time.Now().UTC(),
 		protocolVersion: p.ProtocolVersion,
 	})
@@ -410,8 +948,10 @@ func (b *Backend) handlePullData(up udpPacket) error {
 	}
 
 	b.udpSendChan <- udpPacket{
-		addr: up.addr,
-		data: bytes,
+		addr:      up.addr,
+		data:      bytes,
+		gatewayID: p.GatewayMAC,
+		stream:    up.stream,
 	}
 	return nil
 }
@@ -425,7 +965,8 @@ func (b *Backend) handleTXACK(up udpPacket) error {
 	b.RLock()
 	defer b.RUnlock()
 
-	downID := b.tokenMap[p.RandomToken]
+	downID := b.downlinkIDs.Get(p.GatewayMAC, p.RandomToken)
+	latency.DownlinkAcked(p.GatewayMAC, p.RandomToken)
 
 	if p.Payload != nil && p.Payload.TXPKACK.Error != "" && p.Payload.TXPKACK.Error != "NONE" {
 		b.downlinkTXAckChan <- gw.DownlinkTXAck{
@@ -442,10 +983,16 @@ func (b *Backend) handleTXACK(up udpPacket) error {
 		}
 	}
 
+	if b.downlinkInFlight.enabled() {
+		for _, pkt := range b.downlinkInFlight.ack(p.GatewayMAC, p.RandomToken, time.Now()) {
+			b.udpSendChan <- pkt
+		}
+	}
+
 	return nil
 }
 
-func (b *Backend) handlePushData(up udpPacket) error {
+func (b *Backend) handlePushData(ctx context.Context, up udpPacket) error {
 	var p packets.PushDataPacket
 	if err := p.UnmarshalBinary(up.data); err != nil {
 		return err
@@ -461,8 +1008,10 @@ func (b *Backend) handlePushData(up udpPacket) error {
 		return err
 	}
 	b.udpSendChan <- udpPacket{
-		addr: up.addr,
-		data: bytes,
+		addr:      up.addr,
+		data:      bytes,
+		gatewayID: p.GatewayMAC,
+		stream:    up.stream,
 	}
 
 	// gateway stats
@@ -475,7 +1024,7 @@ func (b *Backend) handlePushData(up udpPacket) error {
 		if up.addr.IP.IsLoopback() {
 			ip, err := getOutboundIP()
 			if err != nil {
-				log.WithError(err).Error("backend/semtechudp: get outbound ip error")
+				log.WithContext(ctx).WithError(err).Error("backend/semtechudp: get outbound ip error")
 			} else {
 				stats.Ip = ip.String()
 			}
@@ -491,28 +1040,30 @@ func (b *Backend) handlePushData(up udpPacket) error {
 	if err != nil {
 		return errors.Wrap(err, "get uplink frames error")
 	}
-	b.handleUplinkFrames(uplinkFrames)
+	b.handleUplinkFrames(ctx, uplinkFrames)
 
 	return nil
 }
 
 func (b *Backend) handleStats(gatewayID lorawan.EUI64, stats gw.GatewayStats) {
 	// set configuration version, if available
-	for _, c := range b.configurations {
-		if gatewayID == c.gatewayID {
-			stats.ConfigVersion = c.currentVersion
-		}
-	}
+	stats.ConfigVersion = b.configVersions.Version(gatewayID)
 
 	b.gatewayStatsChan <- stats
 }
 
-func (b *Backend) handleUplinkFrames(uplinkFrames []gw.UplinkFrame) error {
+func (b *Backend) handleUplinkFrames(ctx context.Context, uplinkFrames []gw.UplinkFrame) error {
 	for i := range uplinkFrames {
+		if _, drop := overload.Drop(uplinkFrames[i]); drop {
+			continue
+		}
+
 		if filters.MatchFilters(uplinkFrames[i].PhyPayload) {
+			overload.Enqueue()
 			b.uplinkFrameChan <- uplinkFrames[i]
+			overload.Dequeue()
 		} else {
-			log.WithFields(log.Fields{
+			log.WithContext(ctx).WithFields(log.Fields{
 				"data_base64": base64.StdEncoding.EncodeToString(uplinkFrames[i].PhyPayload),
 			}).Debug("backend/semtechudp: frame dropped because of configured filters")
 		}
@@ -533,3 +1084,44 @@ func getOutboundIP() (net.IP, error) {
 	localAddr := conn.LocalAddr().(*net.UDPAddr)
 	return localAddr.IP, nil
 }
+
+// logContext returns the log context of the given packet. For packets sent
+// by the gateway, the gateway ID is read from the packet.
+func logContext(up udpPacket) context.Context {
+	ctx := logcontext.WithBackend(context.Background(), "semtech_udp")
+
+	if up.addr != nil {
+		ctx = logcontext.WithRemoteAddr(ctx, up.addr.String())
+	}
+
+	if pt, err := packets.GetPacketType(up.data); err == nil {
+		ctx = logcontext.WithMessageType(ctx, pt.String())
+	}
+
+	if gatewayID, ok := getGatewayID(up.data); ok {
+		ctx = logcontext.WithGatewayID(ctx, gatewayID)
+	} else if up.gatewayID != (lorawan.EUI64{}) {
+		ctx = logcontext.WithGatewayID(ctx, up.gatewayID)
+	}
+
+	return ctx
+}
+
+// getGatewayID returns the gateway ID of packets sent by the gateway.
+// PUSH_DATA, PULL_DATA and TX_ACK contain the gateway ID at bytes 4 - 12.
+func getGatewayID(data []byte) (lorawan.EUI64, bool) {
+	var gatewayID lorawan.EUI64
+
+	pt, err := packets.GetPacketType(data)
+	if err != nil || len(data) < 12 {
+		return gatewayID, false
+	}
+
+	switch pt {
+	case packets.PushData, packets.PullData, packets.TXACK:
+		copy(gatewayID[:], data[4:12])
+		return gatewayID, true
+	default:
+		return gatewayID, false
+	}
+}
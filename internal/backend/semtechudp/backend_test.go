@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"text/template"
 	"time"
 
 	"github.com/gofrs/uuid"
@@ -17,6 +18,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
 	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
 	"github.com/brocaar/loraserver/api/common"
@@ -182,7 +184,7 @@ func (ts *BackendTestSuite) TestTXAck() {
 			id, err := uuid.NewV4()
 			assert.NoError(err)
 
-			ts.backend.tokenMap[12345] = id[:]
+			ts.backend.downlinkIDs.Set(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, 12345, id[:])
 
 			b, err := test.GatewayPacket.MarshalBinary()
 			assert.NoError(err)
@@ -538,7 +540,9 @@ func (ts *BackendTestSuite) TestSendDownlinkFrame() {
 			}
 			assert.NoError(err)
 
-			assert.Equal(id[:], ts.backend.tokenMap[uint16(test.DownlinkFrame.Token)])
+			var gatewayID lorawan.EUI64
+			copy(gatewayID[:], test.DownlinkFrame.GetTxInfo().GetGatewayId())
+			assert.Equal(id[:], ts.backend.downlinkIDs.Get(gatewayID, uint16(test.DownlinkFrame.Token)))
 
 			i, _, err := ts.gwUDPConn.ReadFromUDP(buf)
 			assert.NoError(err)
@@ -1023,6 +1027,282 @@ func (ts *BackendTestSuite) TestApplyConfiguration() {
 	}
 }
 
+func (ts *BackendTestSuite) TestApplyConfigurationDryRun() {
+	assert := require.New(ts.T())
+
+	channel := func(freq uint32) *gw.ChannelConfiguration {
+		return &gw.ChannelConfiguration{
+			Frequency:  freq,
+			Modulation: common.Modulation_LORA,
+			ModulationConfig: &gw.ChannelConfiguration_LoraModulationConfig{
+				LoraModulationConfig: &gw.LoRaModulationConfig{
+					Bandwidth:        125,
+					SpreadingFactors: []uint32{7, 8, 9, 10, 11, 12},
+				},
+			},
+		}
+	}
+
+	// apply the initial configuration
+	assert.NoError(ts.backend.ApplyConfiguration(gw.GatewayConfiguration{
+		GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Version:   "1",
+		Channels:  []*gw.ChannelConfiguration{channel(868100000), channel(868300000), channel(868500000)},
+	}))
+	assert.NoError(os.Remove(filepath.Join(ts.tempDir, "restart")))
+	outBefore, err := ioutil.ReadFile(filepath.Join(ts.tempDir, "out.json"))
+	assert.NoError(err)
+
+	ts.backend.configurationDryRun = true
+
+	diffChan := make(chan events.ConfigurationDiff, 1)
+	go func() {
+		diffChan <- <-ts.backend.GetConfigurationDiffChan()
+	}()
+
+	assert.NoError(ts.backend.ApplyConfiguration(gw.GatewayConfiguration{
+		GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Version:   "2",
+		Channels:  []*gw.ChannelConfiguration{channel(867100000), channel(867300000), channel(867500000)},
+	}))
+
+	diff := <-diffChan
+	assert.Equal(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, diff.GatewayID)
+	assert.Equal("2", diff.Version)
+	assert.Contains(diff.Changes, events.ConfigurationChange{
+		Path:     "SX1301_conf.radio_0.freq",
+		OldValue: "868500000",
+		NewValue: "867500000",
+	})
+
+	// pf has not been restarted
+	_, err = os.Stat(filepath.Join(ts.tempDir, "restart"))
+	assert.True(os.IsNotExist(err))
+
+	// config has not been written
+	outAfter, err := ioutil.ReadFile(filepath.Join(ts.tempDir, "out.json"))
+	assert.NoError(err)
+	assert.Equal(outBefore, outAfter)
+}
+
+func (ts *BackendTestSuite) TestApplyConfigurationRollback() {
+	outputFile := filepath.Join(ts.tempDir, "out.json")
+	previous := []byte(`{"SX1301_conf": {}}`)
+	rollbackPollInterval = 10 * time.Millisecond
+
+	ts.backend.configurationRollback = true
+	ts.backend.configurationRollbackTimeout = 200 * time.Millisecond
+
+	gwConfig := gw.GatewayConfiguration{
+		GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Version:   "2",
+		Channels: []*gw.ChannelConfiguration{
+			{
+				Frequency:  868100000,
+				Modulation: common.Modulation_LORA,
+				ModulationConfig: &gw.ChannelConfiguration_LoraModulationConfig{
+					LoraModulationConfig: &gw.LoRaModulationConfig{
+						Bandwidth:        125,
+						SpreadingFactors: []uint32{7, 8, 9, 10, 11, 12},
+					},
+				},
+			},
+		},
+	}
+
+	ts.T().Run("Restart error", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(ioutil.WriteFile(outputFile, previous, 0644))
+
+		restartCommand := ts.backend.configurations[0].restartCommand
+		ts.backend.configurations[0].restartCommand = "false"
+		defer func() { ts.backend.configurations[0].restartCommand = restartCommand }()
+
+		notifyChan := make(chan events.Notify, 1)
+		go func() {
+			notifyChan <- <-ts.backend.GetNotifyChan()
+		}()
+
+		assert.Error(ts.backend.ApplyConfiguration(gwConfig))
+
+		n := <-notifyChan
+		assert.Equal(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, n.GatewayID)
+		assert.Equal("error", n.Level)
+		assert.Equal(configurationRollbackCode, n.Code)
+
+		b, err := ioutil.ReadFile(outputFile)
+		assert.NoError(err)
+		assert.Equal(previous, b)
+		assert.Equal("", ts.backend.configVersions.Version(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}))
+	})
+
+	ts.T().Run("Timeout", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(ioutil.WriteFile(outputFile, previous, 0644))
+
+		assert.NoError(ts.backend.ApplyConfiguration(gwConfig))
+
+		b, err := ioutil.ReadFile(outputFile)
+		assert.NoError(err)
+		assert.NotEqual(previous, b)
+
+		n := <-ts.backend.GetNotifyChan()
+		assert.Equal(configurationRollbackCode, n.Code)
+		assert.Contains(n.Message, "configuration version 2 rolled back")
+
+		b, err = ioutil.ReadFile(outputFile)
+		assert.NoError(err)
+		assert.Equal(previous, b)
+		assert.Equal("", ts.backend.configVersions.Version(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}))
+	})
+
+	ts.T().Run("Confirmed", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(ioutil.WriteFile(outputFile, previous, 0644))
+
+		assert.NoError(ts.backend.ApplyConfiguration(gwConfig))
+
+		// the gateway is back after the restart
+		p := packets.PullDataPacket{
+			ProtocolVersion: packets.ProtocolVersion2,
+			RandomToken:     12345,
+			GatewayMAC:      [8]byte{1, 2, 3, 4, 5, 6, 7, 8},
+		}
+		b, err := p.MarshalBinary()
+		assert.NoError(err)
+		_, err = ts.gwUDPConn.WriteToUDP(b, ts.backendUDPAddr)
+		assert.NoError(err)
+
+		assert.Eventually(func() bool {
+			return ts.backend.configVersions.Version(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}) == "2"
+		}, time.Second, 10*time.Millisecond)
+
+		b, err = ioutil.ReadFile(outputFile)
+		assert.NoError(err)
+		assert.NotEqual(previous, b)
+	})
+}
+
+func TestDiffConfig(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Current         string
+		New             string
+		ExpectedChanges []events.ConfigurationChange
+	}{
+		{
+			Name:    "no changes",
+			Current: `{"gateway_conf": {"gateway_ID": "0102030405060708"}}`,
+			New:     `{"gateway_conf": {"gateway_ID": "0102030405060708"}}`,
+		},
+		{
+			Name:    "changed, added and removed values",
+			Current: `{"SX1301_conf": {"radio_0": {"enable": true, "freq": 868500000}, "lorawan_public": true}}`,
+			New:     `{"SX1301_conf": {"radio_0": {"enable": true, "freq": 867500000}, "radio_1": {"enable": false}}}`,
+			ExpectedChanges: []events.ConfigurationChange{
+				{Path: "SX1301_conf.lorawan_public", OldValue: "true"},
+				{Path: "SX1301_conf.radio_0.freq", OldValue: "868500000", NewValue: "867500000"},
+				{Path: "SX1301_conf.radio_1.enable", NewValue: "false"},
+			},
+		},
+		{
+			Name: "no current configuration",
+			New:  `{"gateway_conf": {"servers": [{"server_address": "localhost"}]}}`,
+			ExpectedChanges: []events.ConfigurationChange{
+				{Path: "gateway_conf.servers", NewValue: `[{"server_address":"localhost"}]`},
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			changes, err := diffConfig([]byte(tst.Current), []byte(tst.New))
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedChanges, changes)
+		})
+	}
+}
+
+func TestPFConfigurationTemplateResolve(t *testing.T) {
+	assert := require.New(t)
+
+	tempDir, err := ioutil.TempDir("", "test")
+	assert.NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	assert.NoError(os.Mkdir(filepath.Join(tempDir, "0102030405060708"), 0755))
+	assert.NoError(ioutil.WriteFile(filepath.Join(tempDir, "0102030405060708", "global_conf.json"), []byte("{}"), 0644))
+
+	tmpl := pfConfigurationTemplate{
+		baseFile:       template.Must(template.New("base_file").Parse(filepath.Join(tempDir, "{{ .GatewayID }}", "global_conf.json"))),
+		outputFile:     template.Must(template.New("output_file").Parse(filepath.Join(tempDir, "{{ .GatewayID }}", "local_conf.json"))),
+		restartCommand: template.Must(template.New("restart_command").Parse("restart-pf {{ .GatewayID }}")),
+	}
+
+	t.Run("Base file exists", func(t *testing.T) {
+		assert := require.New(t)
+
+		c, err := tmpl.resolve(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8})
+		assert.NoError(err)
+		assert.Equal(&pfConfiguration{
+			gatewayID:      lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			baseFile:       filepath.Join(tempDir, "0102030405060708", "global_conf.json"),
+			outputFile:     filepath.Join(tempDir, "0102030405060708", "local_conf.json"),
+			restartCommand: "restart-pf 0102030405060708",
+		}, c)
+	})
+
+	t.Run("Base file does not exist", func(t *testing.T) {
+		assert := require.New(t)
+
+		c, err := tmpl.resolve(lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1})
+		assert.NoError(err)
+		assert.Nil(c)
+	})
+}
+
+func TestGetGatewayID(t *testing.T) {
+	tests := []struct {
+		Name              string
+		Data              []byte
+		ExpectedGatewayID lorawan.EUI64
+		ExpectedOK        bool
+	}{
+		{
+			Name:              "PUSH_DATA",
+			Data:              []byte{2, 123, 0, 0, 1, 2, 3, 4, 5, 6, 7, 8, '{', '}'},
+			ExpectedGatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			ExpectedOK:        true,
+		},
+		{
+			Name:              "PULL_DATA",
+			Data:              []byte{2, 123, 0, 2, 1, 2, 3, 4, 5, 6, 7, 8},
+			ExpectedGatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			ExpectedOK:        true,
+		},
+		{
+			Name: "PULL_ACK",
+			Data: []byte{2, 123, 0, 4, 1, 2, 3, 4, 5, 6, 7, 8},
+		},
+		{
+			Name: "too short",
+			Data: []byte{2, 123, 0, 0, 1, 2, 3},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			gatewayID, ok := getGatewayID(tst.Data)
+			assert.Equal(tst.ExpectedOK, ok)
+			assert.Equal(tst.ExpectedGatewayID, gatewayID)
+		})
+	}
+}
+
 func TestBackend(t *testing.T) {
 	suite.Run(t, new(BackendTestSuite))
 }
@@ -0,0 +1,271 @@
+package semtechudp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lorawan"
+)
+
+// Capture directions.
+const (
+	captureDirectionUp   = "up"
+	captureDirectionDown = "down"
+)
+
+// captureRecord contains a single captured UDP datagram. Each record is
+// written as a single JSON line.
+type captureRecord struct {
+	Time      time.Time `json:"time"`
+	Direction string    `json:"direction"`
+	Addr      string    `json:"addr"`
+	Data      []byte    `json:"data"`
+}
+
+// captureFile contains an opened capture file and its current size.
+type captureFile struct {
+	f    *os.File
+	size int64
+}
+
+// captureStatus contains the capture status, as exposed by the admin API.
+type captureStatus struct {
+	All        bool     `json:"all"`
+	GatewayIDs []string `json:"gatewayIDs"`
+}
+
+// captureRequest contains the request for enabling or disabling the capture
+// through the admin API. When GatewayID is empty, the capture is enabled or
+// disabled for all gateways.
+type captureRequest struct {
+	GatewayID string `json:"gatewayID"`
+	Enabled   bool   `json:"enabled"`
+}
+
+// packetCapture writes the raw UDP datagrams to a jsonl file per gateway.
+// Files are rotated once they exceed the configured maximum size, keeping one
+// previous file.
+type packetCapture struct {
+	sync.Mutex
+
+	directory   string
+	maxFileSize int64
+
+	all      bool
+	gateways map[lorawan.EUI64]struct{}
+	files    map[lorawan.EUI64]*captureFile
+}
+
+func newPacketCapture(directory string, maxFileSize int64, all bool) *packetCapture {
+	return &packetCapture{
+		directory:   directory,
+		maxFileSize: maxFileSize,
+		all:         all,
+		gateways:    make(map[lorawan.EUI64]struct{}),
+		files:       make(map[lorawan.EUI64]*captureFile),
+	}
+}
+
+// setEnabled enables or disables the capture for the given gateway. When
+// gatewayID is nil, it applies to all gateways.
+func (c *packetCapture) setEnabled(gatewayID *lorawan.EUI64, enabled bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	if gatewayID == nil {
+		c.all = enabled
+		if !enabled {
+			c.gateways = make(map[lorawan.EUI64]struct{})
+		}
+	} else if enabled {
+		c.gateways[*gatewayID] = struct{}{}
+	} else {
+		delete(c.gateways, *gatewayID)
+	}
+
+	// close the files of the gateways for which capturing has been disabled
+	for id, cf := range c.files {
+		if !c.isEnabled(id) {
+			cf.f.Close()
+			delete(c.files, id)
+		}
+	}
+}
+
+// status returns the current capture status.
+func (c *packetCapture) status() captureStatus {
+	c.Lock()
+	defer c.Unlock()
+
+	out := captureStatus{
+		All:        c.all,
+		GatewayIDs: []string{},
+	}
+	for id := range c.gateways {
+		out.GatewayIDs = append(out.GatewayIDs, id.String())
+	}
+	sort.Strings(out.GatewayIDs)
+
+	return out
+}
+
+// isEnabled returns if capturing is enabled for the given gateway. Note that
+// the caller must hold the lock.
+func (c *packetCapture) isEnabled(gatewayID lorawan.EUI64) bool {
+	if c.all {
+		return true
+	}
+	_, ok := c.gateways[gatewayID]
+	return ok
+}
+
+// write writes the given datagram to the capture file of the gateway, when
+// capturing is enabled for this gateway.
+func (c *packetCapture) write(gatewayID lorawan.EUI64, direction string, addr *net.UDPAddr, data []byte) error {
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.isEnabled(gatewayID) {
+		return nil
+	}
+
+	b, err := json.Marshal(captureRecord{
+		Time:      time.Now().UTC(),
+		Direction: direction,
+		Addr:      addr.String(),
+		Data:      data,
+	})
+	if err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+	b = append(b, '\n')
+
+	cf, ok := c.files[gatewayID]
+	if ok && c.maxFileSize > 0 && cf.size+int64(len(b)) > c.maxFileSize {
+		if err := c.rotate(gatewayID); err != nil {
+			return errors.Wrap(err, "rotate capture file error")
+		}
+		ok = false
+	}
+
+	if !ok {
+		cf, err = c.open(gatewayID)
+		if err != nil {
+			return errors.Wrap(err, "open capture file error")
+		}
+		c.files[gatewayID] = cf
+	}
+
+	n, err := cf.f.Write(b)
+	cf.size += int64(n)
+	if err != nil {
+		return errors.Wrap(err, "write capture file error")
+	}
+
+	return nil
+}
+
+// close closes all opened capture files.
+func (c *packetCapture) close() error {
+	c.Lock()
+	defer c.Unlock()
+
+	for id, cf := range c.files {
+		if err := cf.f.Close(); err != nil {
+			return errors.Wrap(err, "close capture file error")
+		}
+		delete(c.files, id)
+	}
+
+	return nil
+}
+
+func (c *packetCapture) path(gatewayID lorawan.EUI64) string {
+	return filepath.Join(c.directory, fmt.Sprintf("%s.jsonl", gatewayID))
+}
+
+func (c *packetCapture) open(gatewayID lorawan.EUI64) (*captureFile, error) {
+	if err := os.MkdirAll(c.directory, 0755); err != nil {
+		return nil, errors.Wrap(err, "create directory error")
+	}
+
+	f, err := os.OpenFile(c.path(gatewayID), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0640)
+	if err != nil {
+		return nil, err
+	}
+
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, errors.Wrap(err, "stat file error")
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"file":       f.Name(),
+	}).Info("backend/semtechudp: capture file opened")
+
+	return &captureFile{f: f, size: stat.Size()}, nil
+}
+
+func (c *packetCapture) rotate(gatewayID lorawan.EUI64) error {
+	cf := c.files[gatewayID]
+	delete(c.files, gatewayID)
+
+	if err := cf.f.Close(); err != nil {
+		return errors.Wrap(err, "close file error")
+	}
+
+	path := c.path(gatewayID)
+	if err := os.Rename(path, path+".1"); err != nil {
+		return errors.Wrap(err, "rename file error")
+	}
+
+	return nil
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// current capture status, a POST request enables or disables the capture.
+func (c *packetCapture) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req captureRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+			return
+		}
+
+		var gatewayID *lorawan.EUI64
+		if req.GatewayID != "" {
+			gatewayID = &lorawan.EUI64{}
+			if err := gatewayID.UnmarshalText([]byte(req.GatewayID)); err != nil {
+				admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "unmarshal gateway id error"))
+				return
+			}
+		}
+
+		c.setEnabled(gatewayID, req.Enabled)
+
+		log.WithFields(log.Fields{
+			"gateway_id": req.GatewayID,
+			"enabled":    req.Enabled,
+		}).Info("backend/semtechudp: packet capture updated")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin.WriteJSON(w, c.status())
+}
@@ -0,0 +1,107 @@
+package semtechudp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestPacketCapture(t *testing.T) {
+	assert := require.New(t)
+
+	tempDir, err := ioutil.TempDir("", "capture")
+	assert.NoError(err)
+	defer os.RemoveAll(tempDir)
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 1700}
+	c := newPacketCapture(tempDir, 200, false)
+	defer c.close()
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+		assert.NoError(c.write(gatewayID, captureDirectionUp, addr, []byte{1, 2, 3}))
+
+		_, err := os.Stat(c.path(gatewayID))
+		assert.True(os.IsNotExist(err))
+	})
+
+	t.Run("Enabled for gateway", func(t *testing.T) {
+		assert := require.New(t)
+		c.setEnabled(&gatewayID, true)
+
+		assert.NoError(c.write(gatewayID, captureDirectionUp, addr, []byte{1, 2, 3}))
+		assert.NoError(c.write(gatewayID, captureDirectionDown, addr, []byte{4, 5, 6}))
+		assert.NoError(c.write(lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}, captureDirectionUp, addr, []byte{1}))
+
+		f, err := os.Open(c.path(gatewayID))
+		assert.NoError(err)
+		defer f.Close()
+
+		var records []captureRecord
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var rec captureRecord
+			assert.NoError(json.Unmarshal(scanner.Bytes(), &rec))
+			records = append(records, rec)
+		}
+
+		assert.Len(records, 2)
+		assert.Equal(captureDirectionUp, records[0].Direction)
+		assert.Equal("127.0.0.1:1700", records[0].Addr)
+		assert.Equal([]byte{1, 2, 3}, records[0].Data)
+		assert.Equal(captureDirectionDown, records[1].Direction)
+		assert.Equal([]byte{4, 5, 6}, records[1].Data)
+
+		_, err = os.Stat(filepath.Join(tempDir, "0807060504030201.jsonl"))
+		assert.True(os.IsNotExist(err))
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		assert := require.New(t)
+
+		for i := 0; i < 5; i++ {
+			assert.NoError(c.write(gatewayID, captureDirectionUp, addr, []byte{1, 2, 3}))
+		}
+
+		_, err := os.Stat(c.path(gatewayID) + ".1")
+		assert.NoError(err)
+
+		stat, err := os.Stat(c.path(gatewayID))
+		assert.NoError(err)
+		assert.True(stat.Size() <= 200)
+	})
+
+	t.Run("Admin API", func(t *testing.T) {
+		assert := require.New(t)
+
+		server := httptest.NewServer(http.HandlerFunc(c.handleHTTP))
+		defer server.Close()
+
+		resp, err := http.Post(server.URL, "application/json", bytes.NewBufferString(`{"enabled": false}`))
+		assert.NoError(err)
+		defer resp.Body.Close()
+
+		var status captureStatus
+		assert.NoError(json.NewDecoder(resp.Body).Decode(&status))
+		assert.Equal(captureStatus{GatewayIDs: []string{}}, status)
+
+		resp, err = http.Post(server.URL, "application/json", bytes.NewBufferString(`{"gatewayID": "0102030405060708", "enabled": true}`))
+		assert.NoError(err)
+		defer resp.Body.Close()
+
+		assert.NoError(json.NewDecoder(resp.Body).Decode(&status))
+		assert.Equal(captureStatus{GatewayIDs: []string{"0102030405060708"}}, status)
+	})
+}
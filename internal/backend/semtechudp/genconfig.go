@@ -0,0 +1,263 @@
+package semtechudp
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+// GenConfigOptions contains the options for generating a packet-forwarder
+// configuration file.
+type GenConfigOptions struct {
+	// Region of the channel-plan.
+	Region band.Name
+
+	// SubBand (1 based) of the channel-plan, for regions with more than 8
+	// uplink channels.
+	SubBand int
+
+	// Concentrator model.
+	Concentrator string
+
+	// GatewayID of the gateway.
+	GatewayID lorawan.EUI64
+
+	// ServerAddress and ServerPort of the LoRa Gateway Bridge.
+	ServerAddress string
+	ServerPort    int
+}
+
+// concentratorModel contains the radio properties of a concentrator model.
+type concentratorModel struct {
+	radioType    string
+	frequencyMin uint32
+	frequencyMax uint32
+	rssiOffset   float64
+}
+
+// concentratorModels contains the supported concentrator models.
+var concentratorModels = map[string]concentratorModel{
+	"sx1301-sx1255": {radioType: "SX1255", frequencyMin: 400000000, frequencyMax: 510000000, rssiOffset: -166.0},
+	"sx1301-sx1257": {radioType: "SX1257", frequencyMin: 862000000, frequencyMax: 1020000000, rssiOffset: -166.0},
+}
+
+// loRaSTDChannel contains the LoRa STD (single SF) channel of a
+// channel-plan.
+type loRaSTDChannel struct {
+	frequency    uint32
+	bandwidth    uint32
+	spreadFactor uint32
+}
+
+// channelPlan contains the uplink channels and the TX frequency range of a
+// region. For regions with sub-bands, the frequencies are those of the first
+// sub-band and are shifted by subBandStep for each following sub-band.
+type channelPlan struct {
+	multiSF     []uint32
+	loRaSTD     *loRaSTDChannel
+	subBands    int
+	subBandStep uint32
+	txFreqMin   uint32
+	txFreqMax   uint32
+}
+
+// channelPlans contains the channel-plan per region.
+var channelPlans = map[band.Name]channelPlan{
+	band.AS923: {
+		multiSF:   []uint32{923200000, 923400000, 922000000, 922200000, 922400000, 922600000, 922800000, 923000000},
+		loRaSTD:   &loRaSTDChannel{frequency: 922100000, bandwidth: 250, spreadFactor: 7},
+		txFreqMin: 915000000,
+		txFreqMax: 928000000,
+	},
+	band.AU915: {
+		multiSF:     getFrequencies(915200000, 200000, 8),
+		loRaSTD:     &loRaSTDChannel{frequency: 915900000, bandwidth: 500, spreadFactor: 8},
+		subBands:    8,
+		subBandStep: 1600000,
+		txFreqMin:   915000000,
+		txFreqMax:   928000000,
+	},
+	band.CN470: {
+		multiSF:     getFrequencies(470300000, 200000, 8),
+		subBands:    12,
+		subBandStep: 1600000,
+		txFreqMin:   500000000,
+		txFreqMax:   510000000,
+	},
+	band.EU868: {
+		multiSF:   []uint32{868100000, 868300000, 868500000, 867100000, 867300000, 867500000, 867700000, 867900000},
+		loRaSTD:   &loRaSTDChannel{frequency: 868300000, bandwidth: 250, spreadFactor: 7},
+		txFreqMin: 863000000,
+		txFreqMax: 870000000,
+	},
+	band.US915: {
+		multiSF:     getFrequencies(902300000, 200000, 8),
+		loRaSTD:     &loRaSTDChannel{frequency: 903000000, bandwidth: 500, spreadFactor: 8},
+		subBands:    8,
+		subBandStep: 1600000,
+		txFreqMin:   923000000,
+		txFreqMax:   928000000,
+	},
+}
+
+// txLUT contains the reference TX gain table (pa_gain, mix_gain, rf_power,
+// dig_gain). As the actual output power depends on the board, it should be
+// calibrated for the gateway.
+var txLUT = [][4]int{
+	{0, 8, -6, 0},
+	{0, 10, -3, 0},
+	{0, 12, 0, 0},
+	{1, 8, 3, 0},
+	{1, 10, 6, 0},
+	{1, 12, 10, 0},
+	{1, 13, 11, 0},
+	{2, 9, 12, 0},
+	{1, 15, 13, 0},
+	{2, 10, 14, 0},
+	{2, 11, 16, 0},
+	{3, 9, 20, 0},
+	{3, 10, 23, 0},
+	{3, 11, 25, 0},
+	{3, 12, 26, 0},
+	{3, 14, 27, 0},
+}
+
+// GenConfig generates a packet-forwarder configuration file (global_conf.json)
+// for the given options. The radios and channels are configured using the
+// same merge as used for the gateway configuration command, so that the
+// generated file can be used as base file.
+func GenConfig(opts GenConfigOptions) ([]byte, error) {
+	model, ok := concentratorModels[opts.Concentrator]
+	if !ok {
+		return nil, fmt.Errorf("unknown concentrator model: %s", opts.Concentrator)
+	}
+
+	plan, ok := channelPlans[opts.Region]
+	if !ok {
+		return nil, fmt.Errorf("region is not supported: %s", opts.Region)
+	}
+
+	var offset uint32
+	if plan.subBands != 0 {
+		if opts.SubBand < 1 || opts.SubBand > plan.subBands {
+			return nil, fmt.Errorf("sub-band must be between 1 and %d", plan.subBands)
+		}
+		offset = uint32(opts.SubBand-1) * plan.subBandStep
+	}
+
+	var gwConf gw.GatewayConfiguration
+	for _, f := range plan.multiSF {
+		gwConf.Channels = append(gwConf.Channels, &gw.ChannelConfiguration{
+			Frequency:  f + offset,
+			Modulation: common.Modulation_LORA,
+			ModulationConfig: &gw.ChannelConfiguration_LoraModulationConfig{
+				LoraModulationConfig: &gw.LoRaModulationConfig{
+					Bandwidth:        125,
+					SpreadingFactors: []uint32{7, 8, 9, 10, 11, 12},
+				},
+			},
+		})
+	}
+	if c := plan.loRaSTD; c != nil {
+		gwConf.Channels = append(gwConf.Channels, &gw.ChannelConfiguration{
+			Frequency:  c.frequency + offset,
+			Modulation: common.Modulation_LORA,
+			ModulationConfig: &gw.ChannelConfiguration_LoraModulationConfig{
+				LoraModulationConfig: &gw.LoRaModulationConfig{
+					Bandwidth:        c.bandwidth,
+					SpreadingFactors: []uint32{c.spreadFactor},
+				},
+			},
+		})
+	}
+
+	gc, err := getGatewayConfig(gwConf)
+	if err != nil {
+		return nil, errors.Wrap(err, "get gateway configuration error")
+	}
+
+	for i, r := range gc.Radios {
+		if r.Enable && (uint32(r.Freq) < model.frequencyMin || uint32(r.Freq) > model.frequencyMax) {
+			return nil, fmt.Errorf("radio_%d frequency %d is not supported by concentrator model %s", i, r.Freq, opts.Concentrator)
+		}
+	}
+
+	config := baseConfig(model, plan, opts)
+	if err := mergeConfig(opts.GatewayID, config, gc); err != nil {
+		return nil, errors.Wrap(err, "merge config error")
+	}
+
+	b, err := json.MarshalIndent(config, "", "\t")
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal json error")
+	}
+
+	return append(b, '\n'), nil
+}
+
+// baseConfig returns the configuration for the given concentrator model,
+// without the radio and channel configuration.
+func baseConfig(model concentratorModel, plan channelPlan, opts GenConfigOptions) configFile {
+	sx1301Conf := map[string]interface{}{
+		"lorawan_public": true,
+		"clksrc":         1,
+		"antenna_gain":   0,
+	}
+
+	for i := 0; i < radioCount; i++ {
+		radio := map[string]interface{}{
+			"type":        model.radioType,
+			"rssi_offset": model.rssiOffset,
+			"tx_enable":   i == 0,
+		}
+		if i == 0 {
+			radio["tx_freq_min"] = plan.txFreqMin
+			radio["tx_freq_max"] = plan.txFreqMax
+		}
+		sx1301Conf[fmt.Sprintf("radio_%d", i)] = radio
+	}
+
+	for i := 0; i < channelCount; i++ {
+		sx1301Conf[fmt.Sprintf("chan_multiSF_%d", i)] = map[string]interface{}{}
+	}
+	sx1301Conf["chan_Lora_std"] = map[string]interface{}{}
+	sx1301Conf["chan_FSK"] = map[string]interface{}{}
+
+	for i, lut := range txLUT {
+		sx1301Conf[fmt.Sprintf("tx_lut_%d", i)] = map[string]interface{}{
+			"pa_gain":  lut[0],
+			"mix_gain": lut[1],
+			"rf_power": lut[2],
+			"dig_gain": lut[3],
+		}
+	}
+
+	return configFile{
+		SX1301Conf: sx1301Conf,
+		GatewayConf: map[string]interface{}{
+			"server_address":       opts.ServerAddress,
+			"serv_port_up":         opts.ServerPort,
+			"serv_port_down":       opts.ServerPort,
+			"keepalive_interval":   10,
+			"stat_interval":        30,
+			"push_timeout_ms":      100,
+			"forward_crc_valid":    true,
+			"forward_crc_error":    false,
+			"forward_crc_disabled": false,
+		},
+	}
+}
+
+func getFrequencies(start, step uint32, count int) []uint32 {
+	var out []uint32
+	for i := 0; i < count; i++ {
+		out = append(out, start+uint32(i)*step)
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package semtechudp
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+func TestGenConfig(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	tests := []struct {
+		Name          string
+		Options       GenConfigOptions
+		ExpectedError string
+
+		ExpectedRadios  [radioCount]radioConfig
+		ExpectedLoRaSTD loRaSTDChannelConfig
+	}{
+		{
+			Name: "EU868",
+			Options: GenConfigOptions{
+				Region:       band.EU868,
+				Concentrator: "sx1301-sx1257",
+			},
+			ExpectedRadios: [radioCount]radioConfig{
+				{Enable: true, Freq: 867500000},
+				{Enable: true, Freq: 868500000},
+			},
+			ExpectedLoRaSTD: loRaSTDChannelConfig{Enable: true, Radio: 1, IF: -200000, Bandwidth: 250000, SpreadFactor: 7},
+		},
+		{
+			Name: "US915 sub-band 2",
+			Options: GenConfigOptions{
+				Region:       band.US915,
+				SubBand:      2,
+				Concentrator: "sx1301-sx1257",
+			},
+			ExpectedRadios: [radioCount]radioConfig{
+				{Enable: true, Freq: 904300000},
+				{Enable: true, Freq: 905300000},
+			},
+			ExpectedLoRaSTD: loRaSTDChannelConfig{Enable: true, Radio: 0, IF: 300000, Bandwidth: 500000, SpreadFactor: 8},
+		},
+		{
+			Name: "invalid sub-band",
+			Options: GenConfigOptions{
+				Region:       band.US915,
+				SubBand:      9,
+				Concentrator: "sx1301-sx1257",
+			},
+			ExpectedError: "sub-band must be between 1 and 8",
+		},
+		{
+			Name: "unknown concentrator model",
+			Options: GenConfigOptions{
+				Region:       band.EU868,
+				Concentrator: "foo",
+			},
+			ExpectedError: "unknown concentrator model: foo",
+		},
+		{
+			Name: "frequency not supported by concentrator model",
+			Options: GenConfigOptions{
+				Region:       band.EU868,
+				Concentrator: "sx1301-sx1255",
+			},
+			ExpectedError: "radio_0 frequency 867500000 is not supported by concentrator model sx1301-sx1255",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			tst.Options.GatewayID = gatewayID
+			tst.Options.ServerAddress = "localhost"
+			tst.Options.ServerPort = 1700
+
+			b, err := GenConfig(tst.Options)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+
+			tempDir, err := ioutil.TempDir("", "genconfig")
+			assert.NoError(err)
+			defer os.RemoveAll(tempDir)
+
+			filePath := filepath.Join(tempDir, "global_conf.json")
+			assert.NoError(ioutil.WriteFile(filePath, b, 0644))
+
+			config, err := loadConfigFile(filePath)
+			assert.NoError(err)
+
+			assert.Equal("0102030405060708", config.GatewayConf["gateway_ID"])
+			assert.Equal("localhost", config.GatewayConf["server_address"])
+			assert.EqualValues(1700, config.GatewayConf["serv_port_up"])
+
+			for i, r := range tst.ExpectedRadios {
+				radio := config.SX1301Conf[fmt.Sprintf("radio_%d", i)].(map[string]interface{})
+				assert.Equal(r.Enable, radio["enable"])
+				assert.EqualValues(r.Freq, radio["freq"])
+			}
+
+			std := config.SX1301Conf["chan_Lora_std"].(map[string]interface{})
+			assert.Equal(tst.ExpectedLoRaSTD.Enable, std["enable"])
+			assert.EqualValues(tst.ExpectedLoRaSTD.Radio, std["radio"])
+			assert.EqualValues(tst.ExpectedLoRaSTD.IF, std["if"])
+			assert.EqualValues(tst.ExpectedLoRaSTD.Bandwidth, std["bandwidth"])
+			assert.EqualValues(tst.ExpectedLoRaSTD.SpreadFactor, std["spread_factor"])
+
+			// the generated file must be usable as base file
+			assert.NoError(mergeConfig(gatewayID, config, gatewayConfiguration{}))
+		})
+	}
+}
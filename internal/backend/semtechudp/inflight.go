@@ -0,0 +1,181 @@
+package semtechudp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkpriority"
+	"github.com/brocaar/lorawan"
+)
+
+// errInFlightLimit is returned when the downlink exceeds the in-flight limit
+// and the queue of the gateway is full.
+var errInFlightLimit = errors.New("downlink in-flight limit exceeded")
+
+// inFlightLimiter limits the number of in-flight (not yet acknowledged)
+// downlinks per gateway, as many packet-forwarders silently drop a PULL_RESP
+// received while the previous one is still being processed. The queue is
+// ordered by priority, so that class-A responses are sent before class-C and
+// multicast downlinks.
+type inFlightLimiter struct {
+	sync.Mutex
+
+	max       int
+	queueSize int
+	timeout   time.Duration
+
+	gateways map[lorawan.EUI64]*inFlightGateway
+}
+
+type inFlightGateway struct {
+	// inFlight contains the token to sent time mapping of the in-flight
+	// downlinks.
+	inFlight map[uint16]time.Time
+
+	// queue contains the queued downlinks, ordered by priority (highest
+	// first) and by arrival within the same priority.
+	queue []inFlightDownlink
+}
+
+type inFlightDownlink struct {
+	token    uint16
+	priority downlinkpriority.Priority
+	packet   udpPacket
+}
+
+func newInFlightLimiter(max, queueSize int, timeout time.Duration) *inFlightLimiter {
+	return &inFlightLimiter{
+		max:       max,
+		queueSize: queueSize,
+		timeout:   timeout,
+		gateways:  make(map[lorawan.EUI64]*inFlightGateway),
+	}
+}
+
+// enabled returns true when the in-flight limit is enabled.
+func (l *inFlightLimiter) enabled() bool {
+	return l.max > 0
+}
+
+// send registers the downlink. It returns true when the downlink can be sent
+// immediately and false when it has been queued. When the queue is full, the
+// last queued downlink with a lower priority is preempted and returned (it
+// must be rejected by the caller). An error is returned when the downlink
+// must be rejected.
+func (l *inFlightLimiter) send(gatewayID lorawan.EUI64, token uint16, priority downlinkpriority.Priority, p udpPacket, now time.Time) (bool, *udpPacket, error) {
+	l.Lock()
+	defer l.Unlock()
+
+	gw, ok := l.gateways[gatewayID]
+	if !ok {
+		gw = &inFlightGateway{
+			inFlight: make(map[uint16]time.Time),
+		}
+		l.gateways[gatewayID] = gw
+	}
+
+	// a packet-forwarder might have been restarted, in which case the
+	// in-flight downlinks are never acknowledged
+	l.expireGateway(gw, now)
+
+	if len(gw.inFlight) < l.max && len(gw.queue) == 0 {
+		gw.inFlight[token] = now
+		return true, nil, nil
+	}
+
+	d := inFlightDownlink{token: token, priority: priority, packet: p}
+
+	if len(gw.queue) < l.queueSize {
+		gw.queue = insertDownlink(gw.queue, d)
+		return false, nil, nil
+	}
+
+	// the last queued downlink has the lowest priority
+	if last := len(gw.queue) - 1; last >= 0 && gw.queue[last].priority < priority {
+		preempted := gw.queue[last].packet
+		gw.queue = insertDownlink(gw.queue[:last], d)
+		return false, &preempted, nil
+	}
+
+	return false, nil, errInFlightLimit
+}
+
+// insertDownlink inserts the downlink after the queued downlinks with the
+// same or a higher priority.
+func insertDownlink(queue []inFlightDownlink, d inFlightDownlink) []inFlightDownlink {
+	i := len(queue)
+	for i > 0 && queue[i-1].priority < d.priority {
+		i--
+	}
+
+	queue = append(queue, inFlightDownlink{})
+	copy(queue[i+1:], queue[i:])
+	queue[i] = d
+
+	return queue
+}
+
+// ack removes the acknowledged downlink. It returns the queued downlinks
+// that can be sent.
+func (l *inFlightLimiter) ack(gatewayID lorawan.EUI64, token uint16, now time.Time) []udpPacket {
+	l.Lock()
+	defer l.Unlock()
+
+	gw, ok := l.gateways[gatewayID]
+	if !ok {
+		return nil
+	}
+
+	delete(gw.inFlight, token)
+	l.expireGateway(gw, now)
+
+	return l.dequeue(gw, now)
+}
+
+// expire removes the in-flight downlinks exceeding the timeout. It returns
+// the queued downlinks that can be sent.
+func (l *inFlightLimiter) expire(now time.Time) []udpPacket {
+	l.Lock()
+	defer l.Unlock()
+
+	var out []udpPacket
+
+	for gatewayID, gw := range l.gateways {
+		l.expireGateway(gw, now)
+		out = append(out, l.dequeue(gw, now)...)
+
+		if len(gw.inFlight) == 0 && len(gw.queue) == 0 {
+			delete(l.gateways, gatewayID)
+		}
+	}
+
+	return out
+}
+
+// expireGateway removes the in-flight downlinks exceeding the timeout. This
+// must be called with the mutex locked.
+func (l *inFlightLimiter) expireGateway(gw *inFlightGateway, now time.Time) {
+	for token, sentAt := range gw.inFlight {
+		if now.Sub(sentAt) >= l.timeout {
+			delete(gw.inFlight, token)
+		}
+	}
+}
+
+// dequeue returns the queued downlinks that can be sent and registers these
+// as in-flight. This must be called with the mutex locked.
+func (l *inFlightLimiter) dequeue(gw *inFlightGateway, now time.Time) []udpPacket {
+	var out []udpPacket
+
+	for len(gw.inFlight) < l.max && len(gw.queue) != 0 {
+		d := gw.queue[0]
+		gw.queue = gw.queue[1:]
+
+		gw.inFlight[d.token] = now
+		out = append(out, d.packet)
+	}
+
+	return out
+}
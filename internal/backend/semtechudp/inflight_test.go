@@ -0,0 +1,124 @@
+package semtechudp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkpriority"
+	"github.com/brocaar/lorawan"
+)
+
+func TestInFlightLimiter(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	p1 := udpPacket{data: []byte{1}}
+	p2 := udpPacket{data: []byte{2}}
+	p3 := udpPacket{data: []byte{3}}
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+
+		l := newInFlightLimiter(0, 0, time.Second)
+		assert.False(l.enabled())
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		assert := require.New(t)
+
+		l := newInFlightLimiter(1, 0, time.Second)
+		assert.True(l.enabled())
+
+		send, _, err := l.send(gatewayID, 1, downlinkpriority.Low, p1, now)
+		assert.NoError(err)
+		assert.True(send)
+
+		_, _, err = l.send(gatewayID, 2, downlinkpriority.Low, p2, now)
+		assert.Equal(errInFlightLimit, err)
+
+		// after the ack, the next downlink can be sent
+		assert.Len(l.ack(gatewayID, 1, now), 0)
+		send, _, err = l.send(gatewayID, 2, downlinkpriority.Low, p2, now)
+		assert.NoError(err)
+		assert.True(send)
+	})
+
+	t.Run("Queue", func(t *testing.T) {
+		assert := require.New(t)
+
+		l := newInFlightLimiter(1, 1, time.Second)
+
+		send, _, err := l.send(gatewayID, 1, downlinkpriority.Low, p1, now)
+		assert.NoError(err)
+		assert.True(send)
+
+		send, _, err = l.send(gatewayID, 2, downlinkpriority.Low, p2, now)
+		assert.NoError(err)
+		assert.False(send)
+
+		_, _, err = l.send(gatewayID, 3, downlinkpriority.Low, p3, now)
+		assert.Equal(errInFlightLimit, err)
+
+		// the ack releases the queued downlink
+		assert.Equal([]udpPacket{p2}, l.ack(gatewayID, 1, now))
+		assert.Len(l.ack(gatewayID, 2, now), 0)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		assert := require.New(t)
+
+		l := newInFlightLimiter(1, 1, time.Second)
+
+		send, _, err := l.send(gatewayID, 1, downlinkpriority.Low, p1, now)
+		assert.NoError(err)
+		assert.True(send)
+
+		send, _, err = l.send(gatewayID, 2, downlinkpriority.Low, p2, now)
+		assert.NoError(err)
+		assert.False(send)
+
+		assert.Len(l.expire(now.Add(500*time.Millisecond)), 0)
+		assert.Equal([]udpPacket{p2}, l.expire(now.Add(time.Second)))
+
+		// the gateway is removed once nothing is in-flight
+		assert.Len(l.expire(now.Add(2*time.Second)), 0)
+		assert.Len(l.gateways, 0)
+	})
+
+	t.Run("Priority", func(t *testing.T) {
+		assert := require.New(t)
+
+		l := newInFlightLimiter(1, 2, time.Second)
+		p4 := udpPacket{data: []byte{4}}
+
+		send, _, err := l.send(gatewayID, 1, downlinkpriority.Low, p1, now)
+		assert.NoError(err)
+		assert.True(send)
+
+		send, _, err = l.send(gatewayID, 2, downlinkpriority.Low, p2, now)
+		assert.NoError(err)
+		assert.False(send)
+
+		// the class-A response is queued before the class-C downlink
+		send, preempted, err := l.send(gatewayID, 3, downlinkpriority.High, p3, now)
+		assert.NoError(err)
+		assert.False(send)
+		assert.Nil(preempted)
+
+		// the queue is full, the class-C downlink is preempted
+		send, preempted, err = l.send(gatewayID, 4, downlinkpriority.Normal, p4, now)
+		assert.NoError(err)
+		assert.False(send)
+		assert.Equal(&p2, preempted)
+
+		// a downlink with the lowest priority is rejected
+		_, _, err = l.send(gatewayID, 5, downlinkpriority.Normal, p1, now)
+		assert.Equal(errInFlightLimit, err)
+
+		assert.Equal([]udpPacket{p3}, l.ack(gatewayID, 1, now))
+		assert.Equal([]udpPacket{p4}, l.ack(gatewayID, 3, now))
+		assert.Len(l.ack(gatewayID, 4, now), 0)
+	})
+}
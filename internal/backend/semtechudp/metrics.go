@@ -25,6 +25,31 @@ var (
 		Name: "backend_semtechudp_gateway_diconnect_count",
 		Help: "The number of gateways that disconnected from the backend.",
 	})
+
+	dif = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_semtechudp_downlink_in_flight_limit_count",
+		Help: "The number of downlinks exceeding the in-flight limit (per action: queued, rejected or preempted).",
+	}, []string{"action"})
+
+	sar = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_semtechudp_source_address_rejected_count",
+		Help: "The number of packets rejected by the source address policy (per packet_type).",
+	}, []string{"packet_type"})
+
+	cfr = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_semtechudp_configuration_rollback_count",
+		Help: "The number of packet-forwarder configurations rolled back (per reason).",
+	}, []string{"reason"})
+
+	saf = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "backend_semtechudp_session_affinity_forward_count",
+		Help: "The number of downlinks forwarded to the instance holding the gateway session.",
+	})
+
+	dsg = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_semtechudp_downlink_size_guard_count",
+		Help: "The number of PULL_RESP packets exceeding the max. size (per action: warned or rejected).",
+	}, []string{"action"})
 )
 
 func udpWriteCounter(pt string) prometheus.Counter {
@@ -42,3 +67,23 @@ func connectCounter() prometheus.Counter {
 func disconnectCounter() prometheus.Counter {
 	return gwd
 }
+
+func downlinkInFlightLimitCounter(action string) prometheus.Counter {
+	return dif.With(prometheus.Labels{"action": action})
+}
+
+func sourceAddressRejectedCounter(pt string) prometheus.Counter {
+	return sar.With(prometheus.Labels{"packet_type": pt})
+}
+
+func configurationRollbackCounter(reason string) prometheus.Counter {
+	return cfr.With(prometheus.Labels{"reason": reason})
+}
+
+func sessionAffinityForwardCounter() prometheus.Counter {
+	return saf
+}
+
+func downlinkSizeGuardCounter(action string) prometheus.Counter {
+	return dsg.With(prometheus.Labels{"action": action})
+}
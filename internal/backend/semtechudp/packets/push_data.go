@@ -1,6 +1,7 @@
 package packets
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"regexp"
@@ -76,6 +77,14 @@ func (p PushDataPacket) GetGatewayStats() (*gw.GatewayStats, error) {
 		}
 	}
 
+	// vendor specific stat fields
+	if len(p.Payload.Stat.Extensions) != 0 {
+		stats.MetaData = make(map[string]string)
+		for k, v := range p.Payload.Stat.Extensions {
+			stats.MetaData[k] = v
+		}
+	}
+
 	// set stats id
 	statsID, err := uuid.NewV4()
 	if err != nil {
@@ -274,6 +283,101 @@ type Stat struct {
 	ACKR float64      `json:"ackr"` // Percentage of upstream datagrams that were acknowledged
 	DWNb uint32       `json:"dwnb"` // Number of downlink datagrams received (unsigned integer)
 	TXNb uint32       `json:"txnb"` // Number of packets emitted (unsigned integer)
+
+	// Extensions contains the vendor specific stat fields (e.g. temperature,
+	// fan-speed or voltage), which are not part of the protocol.
+	Extensions map[string]string `json:"-"`
+}
+
+// statKeys contains the stat keys defined by the protocol.
+var statKeys = map[string]struct{}{
+	"time": {},
+	"lati": {},
+	"long": {},
+	"alti": {},
+	"rxnb": {},
+	"rxok": {},
+	"rxfw": {},
+	"ackr": {},
+	"dwnb": {},
+	"txnb": {},
+}
+
+// statExtensionAliases maps the abbreviated vendor stat keys to the key
+// under which these are exposed.
+var statExtensionAliases = map[string]string{
+	"temp": "temperature",
+	"fan":  "fan_speed",
+	"volt": "voltage",
+}
+
+// UnmarshalJSON implements the json.Unmarshaler interface. Keys which are not
+// defined by the protocol are stored in Extensions.
+func (s *Stat) UnmarshalJSON(data []byte) error {
+	type stat Stat
+	var out stat
+	if err := json.Unmarshal(data, &out); err != nil {
+		return err
+	}
+
+	var kv map[string]json.RawMessage
+	if err := json.Unmarshal(data, &kv); err != nil {
+		return err
+	}
+
+	for k, v := range kv {
+		if _, ok := statKeys[k]; ok {
+			continue
+		}
+
+		if alias, ok := statExtensionAliases[k]; ok {
+			k = alias
+		}
+
+		if out.Extensions == nil {
+			out.Extensions = make(map[string]string)
+		}
+
+		if err := flattenStatExtension(out.Extensions, k, v); err != nil {
+			return errors.Wrapf(err, "unmarshal stat extension %s error", k)
+		}
+	}
+
+	*s = Stat(out)
+	return nil
+}
+
+// flattenStatExtension adds the given value to out. String values are added
+// as-is, objects are flattened (using '_' as key separator) and other values
+// are added as JSON.
+func flattenStatExtension(out map[string]string, key string, data json.RawMessage) error {
+	switch {
+	case len(data) == 0 || string(data) == "null":
+	case data[0] == '"':
+		var str string
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+		out[key] = str
+	case data[0] == '{':
+		var kv map[string]json.RawMessage
+		if err := json.Unmarshal(data, &kv); err != nil {
+			return err
+		}
+		for k, v := range kv {
+			if err := flattenStatExtension(out, key+"_"+k, v); err != nil {
+				return err
+			}
+		}
+	default:
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, data); err != nil {
+			return err
+		}
+		out[key] = buf.String()
+	}
+
+	return nil
 }
 
 // RXPK contain a RF packet and associated metadata.
@@ -1,6 +1,7 @@
 package packets
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -127,6 +128,27 @@ func TestGetGatewayStats(t *testing.T) {
 				TxPacketsEmitted:    6,
 			},
 		},
+		{
+			PushDataPacket: PushDataPacket{
+				ProtocolVersion: ProtocolVersion2,
+				GatewayMAC:      lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+				Payload: PushDataPayload{
+					Stat: &Stat{
+						Time: ecNow,
+						Extensions: map[string]string{
+							"temperature": "41.5",
+						},
+					},
+				},
+			},
+			GatewayStats: &gw.GatewayStats{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				Time:      pbTime,
+				MetaData: map[string]string{
+					"temperature": "41.5",
+				},
+			},
+		},
 	}
 
 	for _, test := range testTable {
@@ -142,6 +164,49 @@ func TestGetGatewayStats(t *testing.T) {
 	}
 }
 
+func TestStatUnmarshalJSON(t *testing.T) {
+	testTable := []struct {
+		Name       string
+		JSON       string
+		Extensions map[string]string
+	}{
+		{
+			Name: "no extensions",
+			JSON: `{"time":"2019-01-01 00:00:00 GMT","rxnb":1}`,
+		},
+		{
+			Name: "extensions",
+			JSON: `{"time":"2019-01-01 00:00:00 GMT","rxnb":1,"temp":41.5,"fan":2300,"volt":12.1,"hw_rev":"2.1","boot":null,"gps":true}`,
+			Extensions: map[string]string{
+				"temperature": "41.5",
+				"fan_speed":   "2300",
+				"voltage":     "12.1",
+				"hw_rev":      "2.1",
+				"gps":         "true",
+			},
+		},
+		{
+			Name: "nested extensions",
+			JSON: `{"time":"2019-01-01 00:00:00 GMT","temp":{"board":40,"radio":[38, 39]}}`,
+			Extensions: map[string]string{
+				"temperature_board": "40",
+				"temperature_radio": "[38,39]",
+			},
+		},
+	}
+
+	for _, test := range testTable {
+		t.Run(test.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var s Stat
+			assert.NoError(json.Unmarshal([]byte(test.JSON), &s))
+			assert.Equal(test.Extensions, s.Extensions)
+			assert.Equal(time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC), time.Time(s.Time).UTC())
+		})
+	}
+}
+
 func TestGetUplinkFrame(t *testing.T) {
 	assert := assert.New(t)
 
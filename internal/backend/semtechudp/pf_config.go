@@ -8,9 +8,12 @@ import (
 	"regexp"
 	"sort"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lora-gateway-bridge/internal/beacon"
 	"github.com/brocaar/loraserver/api/common"
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
@@ -341,6 +344,106 @@ func mergeConfig(mac lorawan.EUI64, config configFile, newConfig gatewayConfigur
 	return nil
 }
 
+// mergeBeaconConfig merges the beacon configuration into the gateway_conf
+// of the given configuration. When the beacon configuration is nil,
+// beaconing is disabled by setting the beacon period to 0.
+func mergeBeaconConfig(config configFile, c *beacon.Configuration) {
+	if c == nil {
+		config.GatewayConf["beacon_period"] = 0
+		return
+	}
+
+	config.GatewayConf["beacon_period"] = int(beacon.Period / time.Second)
+	config.GatewayConf["beacon_freq_hz"] = c.Frequencies[0]
+	config.GatewayConf["beacon_freq_nb"] = len(c.Frequencies)
+	config.GatewayConf["beacon_freq_step"] = c.FrequencyStep()
+	config.GatewayConf["beacon_datarate"] = c.SpreadingFactor
+	config.GatewayConf["beacon_bw_hz"] = c.Bandwidth
+	config.GatewayConf["beacon_power"] = c.Power
+	config.GatewayConf["beacon_infodesc"] = c.InfoDesc
+}
+
+// diffConfig returns the changed values between the current and the new
+// configuration (both JSON encoded). Objects are compared per key, all other
+// values (including arrays) are compared as a whole. An empty current
+// configuration results in all values being reported as added.
+func diffConfig(currentConfig, newConfig []byte) ([]events.ConfigurationChange, error) {
+	currentValues := make(map[string]string)
+	newValues := make(map[string]string)
+
+	for _, c := range []struct {
+		b      []byte
+		values map[string]string
+	}{
+		{currentConfig, currentValues},
+		{newConfig, newValues},
+	} {
+		if len(c.b) == 0 {
+			continue
+		}
+
+		var v interface{}
+		if err := json.Unmarshal(c.b, &v); err != nil {
+			return nil, errors.Wrap(err, "unmarshal config json error")
+		}
+		if err := flattenConfig("", v, c.values); err != nil {
+			return nil, err
+		}
+	}
+
+	var out []events.ConfigurationChange
+
+	for path, newValue := range newValues {
+		if oldValue := currentValues[path]; oldValue != newValue {
+			out = append(out, events.ConfigurationChange{
+				Path:     path,
+				OldValue: oldValue,
+				NewValue: newValue,
+			})
+		}
+	}
+
+	for path, oldValue := range currentValues {
+		if _, ok := newValues[path]; !ok {
+			out = append(out, events.ConfigurationChange{
+				Path:     path,
+				OldValue: oldValue,
+			})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Path < out[j].Path
+	})
+
+	return out, nil
+}
+
+// flattenConfig stores the JSON encoded leaf values of the given value by
+// their (dot separated) path.
+func flattenConfig(path string, v interface{}, values map[string]string) error {
+	if obj, ok := v.(map[string]interface{}); ok {
+		for k, vv := range obj {
+			p := k
+			if path != "" {
+				p = path + "." + k
+			}
+			if err := flattenConfig(p, vv, values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+	values[path] = string(b)
+
+	return nil
+}
+
 func invokePFRestart(cmd string) error {
 	parts := strings.Fields(cmd)
 	if len(parts) == 0 {
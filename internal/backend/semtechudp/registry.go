@@ -6,6 +6,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
 	"github.com/brocaar/lorawan"
 )
 
@@ -21,6 +22,7 @@ var gatewayCleanupDuration = -1 * time.Minute
 // gateway contains a connection and meta-data for a gateway connection.
 type gateway struct {
 	addr            *net.UDPAddr
+	stream          frameWriter
 	lastSeen        time.Time
 	protocolVersion uint8
 }
@@ -30,8 +32,8 @@ type gateways struct {
 	sync.RWMutex
 	gateways map[lorawan.EUI64]gateway
 
-	connectChan    chan lorawan.EUI64
-	disconnectChan chan lorawan.EUI64
+	connectChan    chan events.Connection
+	disconnectChan chan events.Connection
 }
 
 // get returns the gateway object for the given MAC.
@@ -55,7 +57,10 @@ func (c *gateways) set(gatewayID lorawan.EUI64, gw gateway) error {
 	_, ok := c.gateways[gatewayID]
 	if !ok {
 		connectCounter().Inc()
-		c.connectChan <- gatewayID
+		c.connectChan <- events.Connection{
+			GatewayID: gatewayID,
+			Reason:    events.ReasonFirstSeen,
+		}
 	}
 	c.gateways[gatewayID] = gw
 	return nil
@@ -69,7 +74,10 @@ func (c *gateways) cleanup() error {
 	for gatewayID := range c.gateways {
 		if c.gateways[gatewayID].lastSeen.Before(time.Now().Add(gatewayCleanupDuration)) {
 			disconnectCounter().Inc()
-			c.disconnectChan <- gatewayID
+			c.disconnectChan <- events.Connection{
+				GatewayID: gatewayID,
+				Reason:    events.ReasonTimeout,
+			}
 			delete(c.gateways, gatewayID)
 		}
 	}
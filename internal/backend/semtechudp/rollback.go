@@ -0,0 +1,93 @@
+package semtechudp
+
+import (
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+// configurationRollbackCode is the code of the notify event published on a
+// configuration rollback.
+const configurationRollbackCode = "CONFIG_ROLLBACK"
+
+// rollbackPollInterval defines the interval in which the gateway registry is
+// polled for a PULL_DATA after the packet-forwarder restart.
+var rollbackPollInterval = time.Second
+
+// readPreviousConfiguration returns the content of the current output file.
+// It returns nil when the output file does not exist yet, in which case the
+// configuration can not be rolled back.
+func readPreviousConfiguration(outputFile string) ([]byte, error) {
+	b, err := ioutil.ReadFile(outputFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "read output file error")
+	}
+	return b, nil
+}
+
+// confirmConfiguration waits until the gateway sends a PULL_DATA after the
+// packet-forwarder restart. When this happens within the rollback timeout,
+// the configuration version is stored. Else the previous configuration is
+// restored.
+func (b *Backend) confirmConfiguration(pfConfig pfConfiguration, config gw.GatewayConfiguration, previous []byte, restartedAt time.Time) {
+	deadline := restartedAt.Add(b.configurationRollbackTimeout)
+
+	for time.Now().Before(deadline) && !b.isClosed() {
+		if gw, err := b.gateways.get(pfConfig.gatewayID); err == nil && gw.lastSeen.After(restartedAt) {
+			log.WithFields(log.Fields{
+				"gateway_id": pfConfig.gatewayID,
+				"version":    config.Version,
+			}).Info("backend/semtechudp: gateway is back after packet-forwarder restart, configuration confirmed")
+
+			if err := b.configVersions.Set(config); err != nil {
+				log.WithError(err).WithField("gateway_id", pfConfig.gatewayID).Error("backend/semtechudp: store configuration version error")
+			}
+			return
+		}
+
+		time.Sleep(rollbackPollInterval)
+	}
+
+	if b.isClosed() {
+		return
+	}
+
+	b.rollbackConfiguration(pfConfig, config.Version, previous, "timeout", "gateway did not send a PULL_DATA after the packet-forwarder restart")
+}
+
+// rollbackConfiguration restores the previous output file, invokes the
+// restart command again and publishes the rollback notify event.
+func (b *Backend) rollbackConfiguration(pfConfig pfConfiguration, version string, previous []byte, reason, message string) {
+	configurationRollbackCounter(reason).Inc()
+	log.WithFields(log.Fields{
+		"gateway_id": pfConfig.gatewayID,
+		"version":    version,
+		"reason":     message,
+	}).Error("backend/semtechudp: rolling back packet-forwarder configuration")
+
+	if err := ioutil.WriteFile(pfConfig.outputFile, previous, 0644); err != nil {
+		log.WithError(err).WithField("file", pfConfig.outputFile).Error("backend/semtechudp: restore config file error")
+		return
+	}
+
+	if err := invokePFRestart(pfConfig.restartCommand); err != nil {
+		log.WithError(err).WithField("cmd", pfConfig.restartCommand).Error("backend/semtechudp: invoke packet-forwarder restart error")
+	}
+
+	b.notifyChan <- events.Notify{
+		GatewayID: pfConfig.gatewayID,
+		Level:     "error",
+		Code:      configurationRollbackCode,
+		Message:   "configuration version " + version + " rolled back: " + message,
+		Time:      time.Now(),
+	}
+}
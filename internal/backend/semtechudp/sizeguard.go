@@ -0,0 +1,67 @@
+package semtechudp
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// packetTooLargeCode is the code of the notify event and the error of the
+// ack, used when a PULL_RESP exceeds the max. size.
+const packetTooLargeCode = "PACKET_TOO_LARGE"
+
+// downlinkSizeGuard detects PULL_RESP packets which are likely to be
+// fragmented on the backhaul of the gateway. Fragmented datagrams are
+// frequently dropped (e.g. by NAT gateways and firewalls), in which case the
+// downlink is silently lost.
+type downlinkSizeGuard struct {
+	maxSize    int
+	reject     bool
+	notifyChan chan events.Notify
+}
+
+// check returns true when the PULL_RESP of the given size must be rejected.
+// Oversized packets are counted and emitted as notification.
+func (g downlinkSizeGuard) check(gatewayID lorawan.EUI64, frame gw.DownlinkFrame, size int) bool {
+	if g.maxSize == 0 || size <= g.maxSize {
+		return false
+	}
+
+	action := "warned"
+	if g.reject {
+		action = "rejected"
+	}
+
+	downlinkSizeGuardCounter(action).Inc()
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"size":       size,
+		"max_size":   g.maxSize,
+		"action":     action,
+	}).Warning("backend/semtechudp: PULL_RESP exceeds the max. size")
+
+	level := "warning"
+	message := fmt.Sprintf("PULL_RESP of %d bytes exceeds the max. size of %d bytes and is likely to be fragmented", size, g.maxSize)
+	if g.reject {
+		level = "error"
+		message += ", downlink rejected"
+	}
+
+	// the notification must not block the downlink
+	go func(n events.Notify) {
+		g.notifyChan <- n
+	}(events.Notify{
+		GatewayID: gatewayID,
+		Level:     level,
+		Code:      packetTooLargeCode,
+		Message:   message,
+		Time:      time.Now(),
+	})
+
+	return g.reject
+}
@@ -0,0 +1,63 @@
+package semtechudp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestDownlinkSizeGuard(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	tests := []struct {
+		Name   string
+		Guard  downlinkSizeGuard
+		Size   int
+		Reject bool
+		Level  string
+	}{
+		{
+			Name: "disabled",
+			Size: 2000,
+		},
+		{
+			Name:  "within max size",
+			Guard: downlinkSizeGuard{maxSize: 1472, reject: true},
+			Size:  1472,
+		},
+		{
+			Name:  "exceeds max size, warn only",
+			Guard: downlinkSizeGuard{maxSize: 1472},
+			Size:  1473,
+			Level: "warning",
+		},
+		{
+			Name:   "exceeds max size, reject",
+			Guard:  downlinkSizeGuard{maxSize: 1472, reject: true},
+			Size:   1473,
+			Reject: true,
+			Level:  "error",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			tst.Guard.notifyChan = make(chan events.Notify, 1)
+			assert.Equal(tst.Reject, tst.Guard.check(gatewayID, gw.DownlinkFrame{}, tst.Size))
+
+			if tst.Level == "" {
+				return
+			}
+
+			n := <-tst.Guard.notifyChan
+			assert.Equal(gatewayID, n.GatewayID)
+			assert.Equal(tst.Level, n.Level)
+			assert.Equal(packetTooLargeCode, n.Code)
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package semtechudp
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+)
+
+// Source address policies.
+const (
+	// sourcePolicyEUI matches the gateways by EUI only, downlinks are sent
+	// to the source address of the last PULL_DATA packet.
+	sourcePolicyEUI = "eui"
+
+	// sourcePolicyIP pins the gateway to the source IP of its PULL_DATA
+	// packets, the source ports may differ (e.g. because of NAT).
+	sourcePolicyIP = "ip"
+
+	// sourcePolicyStrict pins the gateway to the source IP and port of its
+	// PULL_DATA packets. PUSH_DATA and TX_ACK packets must originate from
+	// the same IP.
+	sourcePolicyStrict = "strict"
+)
+
+// sourcePolicy implements the validation of the source address of the
+// received packets.
+type sourcePolicy struct {
+	policy  string
+	allowed []*net.IPNet
+	denied  []*net.IPNet
+}
+
+func newSourcePolicy(policy string, allowed, denied []string) (*sourcePolicy, error) {
+	switch policy {
+	case "", sourcePolicyEUI, sourcePolicyIP, sourcePolicyStrict:
+	default:
+		return nil, errors.Errorf("unknown source address policy: %s", policy)
+	}
+
+	p := sourcePolicy{
+		policy: policy,
+	}
+
+	for _, n := range []struct {
+		cidrs  []string
+		target *[]*net.IPNet
+	}{
+		{allowed, &p.allowed},
+		{denied, &p.denied},
+	} {
+		for _, cidr := range n.cidrs {
+			_, ipNet, err := net.ParseCIDR(cidr)
+			if err != nil {
+				return nil, errors.Wrap(err, "parse cidr error")
+			}
+			*n.target = append(*n.target, ipNet)
+		}
+	}
+
+	return &p, nil
+}
+
+// check validates the source address of the given packet against the
+// allowed and denied networks, and against the address of the gateway
+// registered by its PULL_DATA packets. Packets received over TCP and packets
+// of gateways which are not (yet) registered are not pinned.
+func (p *sourcePolicy) check(pt packets.PacketType, up udpPacket, gw *gateway) error {
+	if up.addr == nil {
+		return nil
+	}
+
+	for _, n := range p.denied {
+		if n.Contains(up.addr.IP) {
+			return errors.Errorf("source ip %s is in denied network %s", up.addr.IP, n)
+		}
+	}
+
+	if len(p.allowed) != 0 {
+		var allowed bool
+		for _, n := range p.allowed {
+			if n.Contains(up.addr.IP) {
+				allowed = true
+				break
+			}
+		}
+
+		if !allowed {
+			return errors.Errorf("source ip %s is not in allowed networks", up.addr.IP)
+		}
+	}
+
+	if up.stream != nil || gw == nil || gw.stream != nil || gw.addr == nil {
+		return nil
+	}
+
+	switch p.policy {
+	case sourcePolicyIP, sourcePolicyStrict:
+		if !gw.addr.IP.Equal(up.addr.IP) {
+			return errors.Errorf("source ip %s does not match gateway ip %s", up.addr.IP, gw.addr.IP)
+		}
+
+		if p.policy == sourcePolicyStrict && pt == packets.PullData && gw.addr.Port != up.addr.Port {
+			return errors.Errorf("source port %d does not match gateway port %d", up.addr.Port, gw.addr.Port)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package semtechudp
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+)
+
+func TestSourcePolicy(t *testing.T) {
+	registered := gateway{
+		addr: &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 50000},
+	}
+
+	tests := []struct {
+		Name          string
+		Policy        string
+		Allowed       []string
+		Denied        []string
+		PacketType    packets.PacketType
+		Addr          *net.UDPAddr
+		TCP           bool
+		Gateway       *gateway
+		ExpectedError string
+	}{
+		{
+			Name:       "eui, different address",
+			Policy:     sourcePolicyEUI,
+			PacketType: packets.PullData,
+			Addr:       &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234},
+			Gateway:    &registered,
+		},
+		{
+			Name:       "ip, same ip different port",
+			Policy:     sourcePolicyIP,
+			PacketType: packets.PullData,
+			Addr:       &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 50001},
+			Gateway:    &registered,
+		},
+		{
+			Name:          "ip, different ip",
+			Policy:        sourcePolicyIP,
+			PacketType:    packets.PushData,
+			Addr:          &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000},
+			Gateway:       &registered,
+			ExpectedError: "source ip 10.0.0.1 does not match gateway ip 192.168.1.10",
+		},
+		{
+			Name:       "ip, unregistered gateway",
+			Policy:     sourcePolicyIP,
+			PacketType: packets.PullData,
+			Addr:       &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000},
+		},
+		{
+			Name:       "strict, push data different port",
+			Policy:     sourcePolicyStrict,
+			PacketType: packets.PushData,
+			Addr:       &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 50001},
+			Gateway:    &registered,
+		},
+		{
+			Name:          "strict, pull data different port",
+			Policy:        sourcePolicyStrict,
+			PacketType:    packets.PullData,
+			Addr:          &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 50001},
+			Gateway:       &registered,
+			ExpectedError: "source port 50001 does not match gateway port 50000",
+		},
+		{
+			Name:       "strict, tcp",
+			Policy:     sourcePolicyStrict,
+			PacketType: packets.PullData,
+			Addr:       &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50001},
+			TCP:        true,
+			Gateway:    &registered,
+		},
+		{
+			Name:          "denied network",
+			Policy:        sourcePolicyEUI,
+			Allowed:       []string{"10.0.0.0/8"},
+			Denied:        []string{"10.0.0.0/24"},
+			PacketType:    packets.PushData,
+			Addr:          &net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50000},
+			ExpectedError: "source ip 10.0.0.1 is in denied network 10.0.0.0/24",
+		},
+		{
+			Name:       "allowed network",
+			Policy:     sourcePolicyEUI,
+			Allowed:    []string{"10.0.0.0/8"},
+			Denied:     []string{"10.0.0.0/24"},
+			PacketType: packets.PushData,
+			Addr:       &net.UDPAddr{IP: net.IPv4(10, 0, 1, 1), Port: 50000},
+		},
+		{
+			Name:          "not in allowed networks",
+			Policy:        sourcePolicyEUI,
+			Allowed:       []string{"10.0.0.0/8"},
+			PacketType:    packets.PushData,
+			Addr:          &net.UDPAddr{IP: net.IPv4(192, 168, 1, 10), Port: 50000},
+			ExpectedError: "source ip 192.168.1.10 is not in allowed networks",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			p, err := newSourcePolicy(tst.Policy, tst.Allowed, tst.Denied)
+			assert.NoError(err)
+
+			up := udpPacket{addr: tst.Addr}
+			if tst.TCP {
+				up.stream = &tcpConn{}
+			}
+
+			err = p.check(tst.PacketType, up, tst.Gateway)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+
+	t.Run("invalid policy", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := newSourcePolicy("port", nil, nil)
+		assert.EqualError(err, "unknown source address policy: port")
+	})
+
+	t.Run("invalid network", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := newSourcePolicy(sourcePolicyEUI, []string{"10.0.0.1"}, nil)
+		assert.Error(err)
+	})
+}
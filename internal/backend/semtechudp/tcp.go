@@ -0,0 +1,121 @@
+package semtechudp
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// maxTCPFrameSize defines the max. size of a GWMP frame over TCP (the
+// length prefix is a 16 bit unsigned integer).
+const maxTCPFrameSize = 65535
+
+// tcpConn implements the TCP (or TLS) transport of the packet-forwarder
+// protocol. Each GWMP message is prefixed by its length, encoded as 2 byte
+// big-endian unsigned integer.
+type tcpConn struct {
+	sync.Mutex
+	conn net.Conn
+}
+
+// writeFrame writes the given GWMP message as length-prefixed frame.
+func (c *tcpConn) writeFrame(b []byte) error {
+	if len(b) > maxTCPFrameSize {
+		return fmt.Errorf("frame exceeds max size of %d bytes", maxTCPFrameSize)
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	frame := make([]byte, 2+len(b))
+	binary.BigEndian.PutUint16(frame, uint16(len(b)))
+	copy(frame[2:], b)
+
+	_, err := c.conn.Write(frame)
+	return err
+}
+
+// readFrame reads a single length-prefixed GWMP message.
+func readFrame(r io.Reader) ([]byte, error) {
+	var lenB [2]byte
+	if _, err := io.ReadFull(r, lenB[:]); err != nil {
+		return nil, err
+	}
+
+	b := make([]byte, binary.BigEndian.Uint16(lenB[:]))
+	if _, err := io.ReadFull(r, b); err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// listenTCP returns the TCP listener, using TLS when a certificate and key
+// are given.
+func listenTCP(bind, tlsCert, tlsKey string) (net.Listener, error) {
+	if tlsCert == "" && tlsKey == "" {
+		return net.Listen("tcp", bind)
+	}
+
+	cert, err := tls.LoadX509KeyPair(tlsCert, tlsKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "load tls key-pair error")
+	}
+
+	return tls.Listen("tcp", bind, &tls.Config{
+		Certificates: []tls.Certificate{cert},
+	})
+}
+
+func (b *Backend) acceptTCP() error {
+	for {
+		conn, err := b.tcpListener.Accept()
+		if err != nil {
+			if b.isClosed() {
+				return nil
+			}
+			return err
+		}
+
+		go b.handleTCPConn(conn)
+	}
+}
+
+func (b *Backend) handleTCPConn(conn net.Conn) {
+	defer conn.Close()
+
+	log.WithField("remote_addr", conn.RemoteAddr()).Info("backend/semtechudp: tcp connection established")
+
+	// the UDP address is used for logging and packet capture
+	var addr net.UDPAddr
+	if tcpAddr, ok := conn.RemoteAddr().(*net.TCPAddr); ok {
+		addr = net.UDPAddr{IP: tcpAddr.IP, Port: tcpAddr.Port, Zone: tcpAddr.Zone}
+	}
+
+	tc := &tcpConn{conn: conn}
+
+	for {
+		data, err := readFrame(conn)
+		if err != nil {
+			if err != io.EOF && !b.isClosed() {
+				log.WithError(err).WithField("remote_addr", conn.RemoteAddr()).Error("backend/semtechudp: read tcp frame error")
+			}
+			break
+		}
+
+		up := udpPacket{data: data, addr: &addr, stream: tc}
+		go func(up udpPacket) {
+			if err := b.handlePacket(up); err != nil {
+				b.handlePacketError(up, err)
+			}
+		}(up)
+	}
+
+	log.WithField("remote_addr", conn.RemoteAddr()).Info("backend/semtechudp: tcp connection closed")
+}
@@ -0,0 +1,88 @@
+package semtechudp
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func TestFrame(t *testing.T) {
+	assert := require.New(t)
+
+	var buf bytes.Buffer
+	c := tcpConn{conn: &bufConn{Buffer: &buf}}
+
+	assert.NoError(c.writeFrame([]byte{1, 2, 3}))
+	assert.Equal([]byte{0, 3, 1, 2, 3}, buf.Bytes())
+
+	b, err := readFrame(&buf)
+	assert.NoError(err)
+	assert.Equal([]byte{1, 2, 3}, b)
+
+	assert.Error(c.writeFrame(make([]byte, maxTCPFrameSize+1)))
+}
+
+func TestTCPTransport(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Backend.SemtechUDP.UDPBind = "127.0.0.1:0"
+	conf.Backend.SemtechUDP.TCPBind = "127.0.0.1:0"
+
+	backend, err := NewBackend(conf)
+	assert.NoError(err)
+	defer backend.Close()
+
+	go func() {
+		for range backend.GetConnectChan() {
+		}
+	}()
+
+	conn, err := net.Dial("tcp", backend.tcpListener.Addr().String())
+	assert.NoError(err)
+	defer conn.Close()
+	assert.NoError(conn.SetDeadline(time.Now().Add(time.Second)))
+
+	p := packets.PullDataPacket{
+		ProtocolVersion: packets.ProtocolVersion2,
+		RandomToken:     12345,
+		GatewayMAC:      [8]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	b, err := p.MarshalBinary()
+	assert.NoError(err)
+
+	tc := tcpConn{conn: conn}
+	assert.NoError(tc.writeFrame(b))
+
+	b, err = readFrame(conn)
+	assert.NoError(err)
+
+	var ack packets.PullACKPacket
+	assert.NoError(ack.UnmarshalBinary(b))
+	assert.Equal(p.RandomToken, ack.RandomToken)
+	assert.Equal(p.ProtocolVersion, ack.ProtocolVersion)
+
+	gw, err := backend.gateways.get(p.GatewayMAC)
+	assert.NoError(err)
+	assert.NotNil(gw.stream)
+}
+
+// bufConn implements net.Conn, writing to the given buffer.
+type bufConn struct {
+	net.Conn
+	*bytes.Buffer
+}
+
+func (c *bufConn) Write(b []byte) (int, error) {
+	return c.Buffer.Write(b)
+}
+
+func (c *bufConn) Read(b []byte) (int, error) {
+	return c.Buffer.Read(b)
+}
@@ -0,0 +1,91 @@
+package semtechudp
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/gorilla/websocket"
+	log "github.com/sirupsen/logrus"
+)
+
+// wsConn implements the websocket transport of the packet-forwarder
+// protocol, used by the udp-tunnel agent for gateways which can't be reached
+// over UDP (e.g. behind carrier-grade NAT). Each GWMP message is sent as a
+// single binary websocket message.
+type wsConn struct {
+	sync.Mutex
+	conn *websocket.Conn
+}
+
+// writeFrame writes the given GWMP message as binary websocket message.
+func (c *wsConn) writeFrame(b []byte) error {
+	c.Lock()
+	defer c.Unlock()
+
+	return c.conn.WriteMessage(websocket.BinaryMessage, b)
+}
+
+func (b *Backend) serveWebsocket() error {
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+
+	server := http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			conn, err := upgrader.Upgrade(w, r, nil)
+			if err != nil {
+				log.WithError(err).WithField("remote_addr", r.RemoteAddr).Error("backend/semtechudp: websocket upgrade error")
+				return
+			}
+
+			b.handleWebsocketConn(conn)
+		}),
+	}
+
+	return server.Serve(b.wsListener)
+}
+
+func (b *Backend) handleWebsocketConn(conn *websocket.Conn) {
+	defer conn.Close()
+
+	log.WithField("remote_addr", conn.RemoteAddr()).Info("backend/semtechudp: websocket connection established")
+
+	// the UDP address is used for logging and packet capture
+	var addr net.UDPAddr
+	if host, port, err := net.SplitHostPort(conn.RemoteAddr().String()); err == nil {
+		addr.IP = net.ParseIP(host)
+		addr.Port, _ = strconv.Atoi(port)
+	}
+
+	wc := &wsConn{conn: conn}
+
+	for {
+		msgType, data, err := conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway) && !b.isClosed() {
+				log.WithError(err).WithField("remote_addr", conn.RemoteAddr()).Error("backend/semtechudp: read websocket message error")
+			}
+			break
+		}
+
+		if msgType != websocket.BinaryMessage {
+			log.WithFields(log.Fields{
+				"remote_addr":  conn.RemoteAddr(),
+				"message_type": msgType,
+			}).Warning("backend/semtechudp: ignoring non-binary websocket message")
+			continue
+		}
+
+		up := udpPacket{data: data, addr: &addr, stream: wc}
+		go func(up udpPacket) {
+			if err := b.handlePacket(up); err != nil {
+				b.handlePacketError(up, err)
+			}
+		}(up)
+	}
+
+	log.WithField("remote_addr", conn.RemoteAddr()).Info("backend/semtechudp: websocket connection closed")
+}
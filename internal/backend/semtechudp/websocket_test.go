@@ -0,0 +1,56 @@
+package semtechudp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func TestWebsocketTransport(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Backend.SemtechUDP.UDPBind = "127.0.0.1:0"
+	conf.Backend.SemtechUDP.WebsocketBind = "127.0.0.1:0"
+
+	backend, err := NewBackend(conf)
+	assert.NoError(err)
+	defer backend.Close()
+
+	go func() {
+		for range backend.GetConnectChan() {
+		}
+	}()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws://"+backend.wsListener.Addr().String(), nil)
+	assert.NoError(err)
+	defer conn.Close()
+	assert.NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	p := packets.PullDataPacket{
+		ProtocolVersion: packets.ProtocolVersion2,
+		RandomToken:     12345,
+		GatewayMAC:      [8]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	b, err := p.MarshalBinary()
+	assert.NoError(err)
+	assert.NoError(conn.WriteMessage(websocket.BinaryMessage, b))
+
+	msgType, b, err := conn.ReadMessage()
+	assert.NoError(err)
+	assert.Equal(websocket.BinaryMessage, msgType)
+
+	var ack packets.PullACKPacket
+	assert.NoError(ack.UnmarshalBinary(b))
+	assert.Equal(p.RandomToken, ack.RandomToken)
+	assert.Equal(p.ProtocolVersion, ack.ProtocolVersion)
+
+	gw, err := backend.gateways.get(p.GatewayMAC)
+	assert.NoError(err)
+	assert.NotNil(gw.stream)
+}
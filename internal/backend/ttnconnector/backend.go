@@ -0,0 +1,452 @@
+// Package ttnconnector implements a backend for gateways using the
+// TheThingsNetwork (v2) gateway-connector protocol. These gateways (e.g.
+// running the TTN packet-forwarder) publish their messages as Protobuf
+// encoded messages to a MQTT broker. This backend connects to the same MQTT
+// broker and translates these messages to and from the LoRa Gateway Bridge
+// messages. Only the MQTT transport of the protocol is implemented, the gRPC
+// transport is not supported.
+package ttnconnector
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/ttnconnector/messages"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/filters"
+	"github.com/brocaar/lora-gateway-bridge/internal/keepalive"
+	"github.com/brocaar/lora-gateway-bridge/internal/quarantine"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// Topics used by the gateway-connector protocol.
+const (
+	connectTopic    = "connect"
+	disconnectTopic = "disconnect"
+	uplinkTopic     = "+/up"
+	statusTopic     = "+/status"
+)
+
+// Backend implements a TheThingsNetwork gateway-connector backend.
+type Backend struct {
+	sync.RWMutex
+
+	conn   paho.Client
+	qos    uint8
+	closed bool
+
+	downlinkTXAckChan chan gw.DownlinkTXAck
+	uplinkFrameChan   chan gw.UplinkFrame
+	gatewayStatsChan  chan gw.GatewayStats
+	connectChan       chan events.Connection
+	disconnectChan    chan events.Connection
+	uploadChan        chan events.Upload
+
+	configurationDiffChan chan events.ConfigurationDiff
+	protocolErrorChan     chan events.ProtocolError
+	certExpiryChan        chan events.CertExpiry
+	notifyChan            chan events.Notify
+
+	// gateways contains the connected gateways and the TTN gateway ID used
+	// by each gateway, which is needed for publishing downlinks.
+	gateways map[lorawan.EUI64]string
+}
+
+// NewBackend creates a new Backend.
+func NewBackend(conf config.Config) (*Backend, error) {
+	b := Backend{
+		qos:               conf.Backend.TTNConnector.QOS,
+		downlinkTXAckChan: make(chan gw.DownlinkTXAck),
+		uplinkFrameChan:   make(chan gw.UplinkFrame),
+		gatewayStatsChan:  make(chan gw.GatewayStats),
+		connectChan:       make(chan events.Connection),
+		disconnectChan:    make(chan events.Connection),
+		uploadChan:        make(chan events.Upload),
+		gateways:          make(map[lorawan.EUI64]string),
+
+		configurationDiffChan: make(chan events.ConfigurationDiff),
+		protocolErrorChan:     make(chan events.ProtocolError),
+		certExpiryChan:        make(chan events.CertExpiry),
+		notifyChan:            make(chan events.Notify),
+	}
+
+	opts := paho.NewClientOptions()
+	opts.AddBroker(conf.Backend.TTNConnector.Server)
+	opts.SetUsername(conf.Backend.TTNConnector.Username)
+	opts.SetPassword(conf.Backend.TTNConnector.Password)
+	opts.SetClientID(conf.Backend.TTNConnector.ClientID)
+	opts.SetCleanSession(true)
+	opts.SetProtocolVersion(4)
+	opts.SetAutoReconnect(true)
+	opts.SetMaxReconnectInterval(conf.Backend.TTNConnector.MaxReconnectInterval)
+	opts.SetOnConnectHandler(b.onConnected)
+	opts.SetConnectionLostHandler(b.onConnectionLost)
+
+	tlsConfig, err := newTLSConfig(
+		conf.Backend.TTNConnector.CACert,
+		conf.Backend.TTNConnector.TLSCert,
+		conf.Backend.TTNConnector.TLSKey,
+	)
+	if err != nil {
+		return nil, errors.Wrap(err, "new tls config error")
+	}
+	if tlsConfig != nil {
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	log.WithField("server", conf.Backend.TTNConnector.Server).Info("backend/ttnconnector: connecting to mqtt broker")
+
+	b.conn = paho.NewClient(opts)
+	if token := b.conn.Connect(); token.Wait() && token.Error() != nil {
+		return nil, errors.Wrap(token.Error(), "connect to mqtt broker error")
+	}
+
+	return &b, nil
+}
+
+// Close closes the backend.
+func (b *Backend) Close() error {
+	b.Lock()
+	b.closed = true
+	b.Unlock()
+
+	log.Info("backend/ttnconnector: closing gateway backend")
+
+	b.conn.Disconnect(250)
+	return nil
+}
+
+// HealthCheck returns an error when the backend is closed or when the
+// connection with the MQTT broker is lost.
+func (b *Backend) HealthCheck() error {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.closed {
+		return errors.New("backend is closed")
+	}
+
+	if !b.conn.IsConnectionOpen() {
+		return errors.New("not connected to mqtt broker")
+	}
+
+	return nil
+}
+
+// GetDownlinkTXAckChan returns the downlink tx ack channel. The
+// gateway-connector protocol does not support tx acknowledgements, thus
+// nothing is sent to this channel.
+func (b *Backend) GetDownlinkTXAckChan() chan gw.DownlinkTXAck {
+	return b.downlinkTXAckChan
+}
+
+// GetGatewayStatsChan returns the gateway stats channel.
+func (b *Backend) GetGatewayStatsChan() chan gw.GatewayStats {
+	return b.gatewayStatsChan
+}
+
+// GetUplinkFrameChan returns the uplink frame channel.
+func (b *Backend) GetUplinkFrameChan() chan gw.UplinkFrame {
+	return b.uplinkFrameChan
+}
+
+// GetConnectChan returns the channel for received gateway connections.
+func (b *Backend) GetConnectChan() chan events.Connection {
+	return b.connectChan
+}
+
+// GetDisconnectChan returns the channel for disconnected gateway connections.
+func (b *Backend) GetDisconnectChan() chan events.Connection {
+	return b.disconnectChan
+}
+
+// GetUploadChan returns the channel for received uploads. The
+// gateway-connector protocol does not support uploads, thus nothing is sent
+// to this channel.
+func (b *Backend) GetUploadChan() chan events.Upload {
+	return b.uploadChan
+}
+
+// GetConfigurationDiffChan returns the channel for gateway configuration
+// diffs. Gateway configuration is not supported by this backend, thus
+// nothing is sent to this channel.
+func (b *Backend) GetConfigurationDiffChan() chan events.ConfigurationDiff {
+	return b.configurationDiffChan
+}
+
+// GetProtocolErrorChan returns the channel for rejected gateway messages.
+// Strict mode is not supported by this backend, thus nothing is sent to this
+// channel.
+func (b *Backend) GetProtocolErrorChan() chan events.ProtocolError {
+	return b.protocolErrorChan
+}
+
+// GetCertExpiryChan returns the channel for client certificates which are
+// about to expire.
+func (b *Backend) GetCertExpiryChan() chan events.CertExpiry {
+	return b.certExpiryChan
+}
+
+// GetNotifyChan returns the channel for gateway notifications.
+func (b *Backend) GetNotifyChan() chan events.Notify {
+	return b.notifyChan
+}
+
+// SendDownlinkFrame sends the given downlink frame to the gateway.
+func (b *Backend) SendDownlinkFrame(frame gw.DownlinkFrame) error {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.GetTxInfo().GetGatewayId())
+
+	msg, err := messages.GetDownlinkMessage(frame)
+	if err != nil {
+		return errors.Wrap(err, "get downlink message error")
+	}
+
+	b.RLock()
+	id, ok := b.gateways[gatewayID]
+	b.RUnlock()
+	if !ok {
+		id = messages.FormatGatewayID(gatewayID)
+	}
+
+	bb, err := proto.Marshal(&msg)
+	if err != nil {
+		return errors.Wrap(err, "marshal protobuf error")
+	}
+
+	topic := fmt.Sprintf("%s/down", id)
+	if token := b.conn.Publish(topic, b.qos, false, bb); token.Wait() && token.Error() != nil {
+		return errors.Wrap(token.Error(), "publish downlink message error")
+	}
+
+	mqttPublishCounter("down").Inc()
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"topic":      topic,
+	}).Info("backend/ttnconnector: downlink message published")
+
+	return nil
+}
+
+// ApplyConfiguration is not supported by the gateway-connector protocol.
+// The channel-plan must be configured on the gateway itself.
+func (b *Backend) ApplyConfiguration(config gw.GatewayConfiguration) error {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], config.GatewayId)
+
+	log.WithField("gateway_id", gatewayID).Warning("backend/ttnconnector: gateway configuration is not supported, ignoring")
+	return nil
+}
+
+func (b *Backend) onConnected(c paho.Client) {
+	log.Info("backend/ttnconnector: connected to mqtt broker")
+
+	for topic, handler := range map[string]paho.MessageHandler{
+		connectTopic:    b.handleConnect,
+		disconnectTopic: b.handleDisconnect,
+		uplinkTopic:     b.handleUplink,
+		statusTopic:     b.handleStatus,
+	} {
+		log.WithFields(log.Fields{
+			"topic": topic,
+			"qos":   b.qos,
+		}).Info("backend/ttnconnector: subscribing to topic")
+
+		if token := c.Subscribe(topic, b.qos, handler); token.Wait() && token.Error() != nil {
+			log.WithError(token.Error()).WithField("topic", topic).Error("backend/ttnconnector: subscribe topic error")
+		}
+	}
+}
+
+func (b *Backend) onConnectionLost(c paho.Client, err error) {
+	b.RLock()
+	closed := b.closed
+	b.RUnlock()
+
+	if !closed {
+		log.WithError(err).Error("backend/ttnconnector: mqtt connection error")
+	}
+}
+
+func (b *Backend) handleConnect(c paho.Client, msg paho.Message) {
+	mqttReceiveCounter("connect").Inc()
+
+	var pl messages.ConnectMessage
+	if err := proto.Unmarshal(msg.Payload(), &pl); err != nil {
+		log.WithError(err).Error("backend/ttnconnector: unmarshal connect message error")
+		return
+	}
+
+	gatewayID, err := messages.ParseGatewayID(pl.Id)
+	if err != nil {
+		log.WithError(err).Error("backend/ttnconnector: parse gateway id error")
+		return
+	}
+
+	b.setConnected(gatewayID, pl.Id)
+}
+
+func (b *Backend) handleDisconnect(c paho.Client, msg paho.Message) {
+	mqttReceiveCounter("disconnect").Inc()
+
+	var pl messages.ConnectMessage
+	if err := proto.Unmarshal(msg.Payload(), &pl); err != nil {
+		log.WithError(err).Error("backend/ttnconnector: unmarshal disconnect message error")
+		return
+	}
+
+	gatewayID, err := messages.ParseGatewayID(pl.Id)
+	if err != nil {
+		log.WithError(err).Error("backend/ttnconnector: parse gateway id error")
+		return
+	}
+
+	b.Lock()
+	_, ok := b.gateways[gatewayID]
+	delete(b.gateways, gatewayID)
+	b.Unlock()
+
+	if !ok {
+		return
+	}
+
+	disconnectCounter().Inc()
+	log.WithField("gateway_id", gatewayID).Info("backend/ttnconnector: gateway disconnected")
+
+	b.disconnectChan <- events.Connection{
+		GatewayID: gatewayID,
+		Reason:    events.ReasonClose,
+	}
+}
+
+func (b *Backend) handleUplink(c paho.Client, msg paho.Message) {
+	mqttReceiveCounter("up").Inc()
+
+	id := strings.TrimSuffix(msg.Topic(), "/up")
+	gatewayID, err := messages.ParseGatewayID(id)
+	if err != nil {
+		log.WithError(err).Error("backend/ttnconnector: parse gateway id error")
+		return
+	}
+
+	var pl messages.UplinkMessage
+	if err := proto.Unmarshal(msg.Payload(), &pl); err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/ttnconnector: unmarshal uplink message error")
+		quarantine.Error(gatewayID)
+		return
+	}
+
+	b.setConnected(gatewayID, id)
+	keepalive.Seen(gatewayID)
+
+	uplinkFrames, err := messages.GetUplinkFrames(gatewayID, pl)
+	if err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/ttnconnector: get uplink frames error")
+		quarantine.Error(gatewayID)
+		return
+	}
+
+	for i := range uplinkFrames {
+		if filters.MatchFilters(uplinkFrames[i].PhyPayload) {
+			b.uplinkFrameChan <- uplinkFrames[i]
+		} else {
+			log.WithFields(log.Fields{
+				"data_base64": base64.StdEncoding.EncodeToString(uplinkFrames[i].PhyPayload),
+			}).Debug("backend/ttnconnector: frame dropped because of configured filters")
+		}
+	}
+}
+
+func (b *Backend) handleStatus(c paho.Client, msg paho.Message) {
+	mqttReceiveCounter("status").Inc()
+
+	id := strings.TrimSuffix(msg.Topic(), "/status")
+	gatewayID, err := messages.ParseGatewayID(id)
+	if err != nil {
+		log.WithError(err).Error("backend/ttnconnector: parse gateway id error")
+		return
+	}
+
+	var pl messages.Status
+	if err := proto.Unmarshal(msg.Payload(), &pl); err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/ttnconnector: unmarshal status message error")
+		quarantine.Error(gatewayID)
+		return
+	}
+
+	b.setConnected(gatewayID, id)
+	keepalive.Seen(gatewayID)
+
+	stats, err := messages.GetGatewayStats(gatewayID, pl)
+	if err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("backend/ttnconnector: get gateway stats error")
+		return
+	}
+
+	b.gatewayStatsChan <- stats
+}
+
+// setConnected registers the gateway as connected. When the gateway was not
+// connected yet, a connection event is sent.
+func (b *Backend) setConnected(gatewayID lorawan.EUI64, id string) {
+	b.Lock()
+	_, ok := b.gateways[gatewayID]
+	b.gateways[gatewayID] = id
+	b.Unlock()
+
+	if ok {
+		return
+	}
+
+	connectCounter().Inc()
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"id":         id,
+	}).Info("backend/ttnconnector: gateway connected")
+
+	b.connectChan <- events.Connection{
+		GatewayID: gatewayID,
+		Reason:    events.ReasonFirstSeen,
+	}
+}
+
+func newTLSConfig(cafile, certFile, certKeyFile string) (*tls.Config, error) {
+	if cafile == "" && certFile == "" && certKeyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+
+	if cafile != "" {
+		cacert, err := ioutil.ReadFile(cafile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load ca-cert error")
+		}
+		certpool := x509.NewCertPool()
+		certpool.AppendCertsFromPEM(cacert)
+		tlsConfig.RootCAs = certpool
+	}
+
+	if certFile != "" && certKeyFile != "" {
+		kp, err := tls.LoadX509KeyPair(certFile, certKeyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "load tls key-pair error")
+		}
+		tlsConfig.Certificates = []tls.Certificate{kp}
+	}
+
+	return tlsConfig, nil
+}
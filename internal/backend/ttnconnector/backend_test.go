@@ -0,0 +1,369 @@
+package ttnconnector_test
+
+import (
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/ttnconnector"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/ttnconnector/messages"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/testsuite"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// BackendTestSuite tests the backend using the embedded MQTT broker of the
+// testsuite package. As the testsuite package imports the backend package
+// (and thus this package), this is an external test package.
+type BackendTestSuite struct {
+	suite.Suite
+
+	broker    *testsuite.Broker
+	backend   *ttnconnector.Backend
+	gateway   paho.Client
+	downlinks chan paho.Message
+	gatewayID lorawan.EUI64
+}
+
+func (ts *BackendTestSuite) SetupSuite() {
+	log.SetLevel(log.ErrorLevel)
+}
+
+func (ts *BackendTestSuite) SetupTest() {
+	assert := require.New(ts.T())
+	ts.gatewayID = lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	ts.downlinks = make(chan paho.Message, 10)
+
+	var err error
+	ts.broker, err = testsuite.NewBroker("127.0.0.1:0")
+	assert.NoError(err)
+
+	var conf config.Config
+	conf.Backend.TTNConnector.Server = ts.broker.Server()
+	conf.Backend.TTNConnector.ClientID = "lora-gateway-bridge"
+	conf.Backend.TTNConnector.MaxReconnectInterval = time.Second
+
+	ts.backend, err = ttnconnector.NewBackend(conf)
+	assert.NoError(err)
+
+	// the gateway (packet-forwarder) connected to the same broker
+	ts.gateway = paho.NewClient(paho.NewClientOptions().AddBroker(ts.broker.Server()).SetClientID("gateway"))
+	token := ts.gateway.Connect()
+	token.Wait()
+	assert.NoError(token.Error())
+
+	token = ts.gateway.Subscribe("+/down", 0, func(_ paho.Client, msg paho.Message) {
+		ts.downlinks <- msg
+	})
+	token.Wait()
+	assert.NoError(token.Error())
+
+	// give the backend some time to subscribe to the gateway topics
+	time.Sleep(100 * time.Millisecond)
+}
+
+func (ts *BackendTestSuite) TearDownTest() {
+	ts.gateway.Disconnect(0)
+	assert := require.New(ts.T())
+	assert.NoError(ts.backend.Close())
+	assert.NoError(ts.broker.Close())
+}
+
+func (ts *BackendTestSuite) publish(topic string, msg proto.Message) {
+	assert := require.New(ts.T())
+
+	b, err := proto.Marshal(msg)
+	assert.NoError(err)
+
+	token := ts.gateway.Publish(topic, 0, false, b)
+	token.Wait()
+	assert.NoError(token.Error())
+}
+
+func (ts *BackendTestSuite) connect(id string) {
+	assert := require.New(ts.T())
+
+	ts.publish("connect", &messages.ConnectMessage{Id: id})
+
+	select {
+	case conn := <-ts.backend.GetConnectChan():
+		assert.Equal(events.Connection{
+			GatewayID: ts.gatewayID,
+			Reason:    events.ReasonFirstSeen,
+		}, conn)
+	case <-time.After(time.Second):
+		ts.T().Fatal("timeout waiting for connection")
+	}
+}
+
+func (ts *BackendTestSuite) TestHealthCheck() {
+	assert := require.New(ts.T())
+	assert.NoError(ts.backend.HealthCheck())
+}
+
+func (ts *BackendTestSuite) TestConnectDisconnect() {
+	assert := require.New(ts.T())
+
+	ts.connect("eui-0102030405060708")
+
+	// a second connect does not result in a connection event
+	ts.publish("connect", &messages.ConnectMessage{Id: "eui-0102030405060708"})
+
+	ts.publish("disconnect", &messages.ConnectMessage{Id: "eui-0102030405060708"})
+	select {
+	case conn := <-ts.backend.GetDisconnectChan():
+		assert.Equal(events.Connection{
+			GatewayID: ts.gatewayID,
+			Reason:    events.ReasonClose,
+		}, conn)
+	case <-ts.backend.GetConnectChan():
+		ts.T().Fatal("unexpected connection")
+	case <-time.After(time.Second):
+		ts.T().Fatal("timeout waiting for disconnection")
+	}
+
+	// a disconnect of a gateway which is not connected is ignored
+	ts.publish("disconnect", &messages.ConnectMessage{Id: "eui-0102030405060708"})
+	select {
+	case <-ts.backend.GetDisconnectChan():
+		ts.T().Fatal("unexpected disconnection")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (ts *BackendTestSuite) TestConnectInvalidGatewayID() {
+	ts.publish("connect", &messages.ConnectMessage{Id: "my-gateway"})
+
+	select {
+	case <-ts.backend.GetConnectChan():
+		ts.T().Fatal("unexpected connection")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func (ts *BackendTestSuite) TestUplink() {
+	assert := require.New(ts.T())
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	nowPB, _ := ptypes.TimestampProto(now)
+
+	ts.connect("eui-0102030405060708")
+
+	ts.publish("eui-0102030405060708/up", &messages.UplinkMessage{
+		Payload: []byte{1, 2, 3, 4},
+		ProtocolMetadata: &messages.RxMetadata{
+			Protocol: &messages.RxMetadata_Lorawan{
+				Lorawan: &messages.LoRaWANMetadata{
+					Modulation: messages.Modulation_LORA,
+					DataRate:   "SF7BW125",
+					CodingRate: "4/5",
+				},
+			},
+		},
+		GatewayMetadata: &messages.GatewayRxMetadata{
+			GatewayId: "eui-0102030405060708",
+			Timestamp: 1000,
+			Time:      now.UnixNano(),
+			Channel:   2,
+			Frequency: 868100000,
+			Rssi:      -50,
+			Snr:       5.5,
+		},
+	})
+
+	select {
+	case uf := <-ts.backend.GetUplinkFrameChan():
+		assert.Len(uf.RxInfo.UplinkId, 16)
+		uf.RxInfo.UplinkId = nil
+
+		assert.Equal(gw.UplinkFrame{
+			PhyPayload: []byte{1, 2, 3, 4},
+			TxInfo: &gw.UplinkTXInfo{
+				Frequency:  868100000,
+				Modulation: common.Modulation_LORA,
+				ModulationInfo: &gw.UplinkTXInfo_LoraModulationInfo{
+					LoraModulationInfo: &gw.LoRaModulationInfo{
+						Bandwidth:       125,
+						SpreadingFactor: 7,
+						CodeRate:        "4/5",
+					},
+				},
+			},
+			RxInfo: &gw.UplinkRXInfo{
+				GatewayId: ts.gatewayID[:],
+				Time:      nowPB,
+				Rssi:      -50,
+				LoraSnr:   5.5,
+				Channel:   2,
+				Context:   []byte{0x00, 0x00, 0x03, 0xe8},
+			},
+		}, uf)
+	case <-time.After(time.Second):
+		ts.T().Fatal("timeout waiting for uplink frame")
+	}
+}
+
+func (ts *BackendTestSuite) TestUplinkConnects() {
+	assert := require.New(ts.T())
+
+	// the first uplink of a gateway which did not send a connect message
+	// results in a connection event
+	ts.publish("eui-0102030405060708/up", &messages.UplinkMessage{
+		Payload: []byte{1, 2, 3, 4},
+		ProtocolMetadata: &messages.RxMetadata{
+			Protocol: &messages.RxMetadata_Lorawan{
+				Lorawan: &messages.LoRaWANMetadata{
+					Modulation: messages.Modulation_LORA,
+					DataRate:   "SF7BW125",
+					CodingRate: "4/5",
+				},
+			},
+		},
+		GatewayMetadata: &messages.GatewayRxMetadata{
+			GatewayId: "eui-0102030405060708",
+			Frequency: 868100000,
+		},
+	})
+
+	select {
+	case conn := <-ts.backend.GetConnectChan():
+		assert.Equal(ts.gatewayID, conn.GatewayID)
+	case <-time.After(time.Second):
+		ts.T().Fatal("timeout waiting for connection")
+	}
+
+	select {
+	case uf := <-ts.backend.GetUplinkFrameChan():
+		assert.Equal([]byte{1, 2, 3, 4}, uf.PhyPayload)
+	case <-time.After(time.Second):
+		ts.T().Fatal("timeout waiting for uplink frame")
+	}
+}
+
+func (ts *BackendTestSuite) TestStatus() {
+	assert := require.New(ts.T())
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	ts.connect("eui-0102030405060708")
+
+	ts.publish("eui-0102030405060708/status", &messages.Status{
+		Time:     now.UnixNano(),
+		RxIn:     10,
+		RxOk:     9,
+		TxIn:     3,
+		TxOk:     2,
+		Ip:       []string{"10.0.0.1"},
+		Platform: "test",
+	})
+
+	select {
+	case stats := <-ts.backend.GetGatewayStatsChan():
+		assert.Equal(ts.gatewayID[:], stats.GatewayId)
+		assert.EqualValues(10, stats.RxPacketsReceived)
+		assert.EqualValues(9, stats.RxPacketsReceivedOk)
+		assert.EqualValues(3, stats.TxPacketsReceived)
+		assert.EqualValues(2, stats.TxPacketsEmitted)
+		assert.Equal("10.0.0.1", stats.Ip)
+	case <-time.After(time.Second):
+		ts.T().Fatal("timeout waiting for gateway stats")
+	}
+}
+
+func (ts *BackendTestSuite) TestSendDownlinkFrame() {
+	assert := require.New(ts.T())
+
+	frame := gw.DownlinkFrame{
+		PhyPayload: []byte{1, 2, 3},
+		Token:      123,
+		TxInfo: &gw.DownlinkTXInfo{
+			GatewayId:  ts.gatewayID[:],
+			Frequency:  868100000,
+			Power:      14,
+			Modulation: common.Modulation_LORA,
+			ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+				LoraModulationInfo: &gw.LoRaModulationInfo{
+					Bandwidth:             125,
+					SpreadingFactor:       7,
+					CodeRate:              "4/5",
+					PolarizationInversion: true,
+				},
+			},
+			Timing: gw.DownlinkTiming_DELAY,
+			TimingInfo: &gw.DownlinkTXInfo_DelayTimingInfo{
+				DelayTimingInfo: &gw.DelayTimingInfo{
+					Delay: ptypes.DurationProto(time.Second),
+				},
+			},
+			Context: []byte{0x00, 0x00, 0x03, 0xe8},
+		},
+	}
+
+	// the downlink is published using the ID with which the gateway
+	// connected
+	tests := []struct {
+		Name          string
+		ConnectID     string
+		ExpectedTopic string
+	}{
+		{
+			Name:          "gateway not connected",
+			ExpectedTopic: "eui-0102030405060708/down",
+		},
+		{
+			Name:          "gateway connected using upper-case id",
+			ConnectID:     "EUI-0102030405060708",
+			ExpectedTopic: "EUI-0102030405060708/down",
+		},
+	}
+
+	for _, tst := range tests {
+		ts.T().Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			if tst.ConnectID != "" {
+				ts.connect(tst.ConnectID)
+			}
+
+			assert.NoError(ts.backend.SendDownlinkFrame(frame))
+
+			select {
+			case msg := <-ts.downlinks:
+				assert.Equal(tst.ExpectedTopic, msg.Topic())
+
+				var dl messages.DownlinkMessage
+				assert.NoError(proto.Unmarshal(msg.Payload(), &dl))
+				assert.Equal([]byte{1, 2, 3}, dl.Payload)
+				assert.Equal("SF7BW125", dl.GetProtocolConfiguration().GetLorawan().DataRate)
+				assert.EqualValues(868100000, dl.GetGatewayConfiguration().Frequency)
+				assert.EqualValues(14, dl.GetGatewayConfiguration().Power)
+				assert.True(dl.GetGatewayConfiguration().PolarizationInversion)
+				assert.EqualValues(1000+1000000, dl.GetGatewayConfiguration().Timestamp)
+			case <-time.After(time.Second):
+				t.Fatal("timeout waiting for downlink message")
+			}
+		})
+	}
+
+	// the protocol does not support tx acknowledgements
+	select {
+	case <-ts.backend.GetDownlinkTXAckChan():
+		ts.T().Fatal("unexpected downlink tx ack")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// unsupported timing
+	frame.TxInfo.Timing = gw.DownlinkTiming_IMMEDIATELY
+	assert.Error(ts.backend.SendDownlinkFrame(frame))
+}
+
+func TestBackend(t *testing.T) {
+	suite.Run(t, new(BackendTestSuite))
+}
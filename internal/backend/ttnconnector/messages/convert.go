@@ -0,0 +1,290 @@
+// Package messages implements the (subset of the) TheThingsNetwork v2
+// gateway-connector protocol messages and their conversion. The messages are
+// generated from messages.proto, which matches the TheThingsNetwork API
+// Protobuf definitions (router.UplinkMessage, router.DownlinkMessage,
+// gateway.Status and types.ConnectMessage).
+package messages
+
+import (
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// gatewayIDPrefix is the prefix used by TheThingsNetwork for gateway IDs
+// derived from the gateway EUI.
+const gatewayIDPrefix = "eui-"
+
+var loRaDataRateRegex = regexp.MustCompile(`^SF(\d+)BW(\d+)$`)
+
+// ParseGatewayID parses the given TTN gateway ID into a gateway EUI. Both
+// the eui-<gateway eui> and the plain <gateway eui> format are supported.
+func ParseGatewayID(id string) (lorawan.EUI64, error) {
+	var gatewayID lorawan.EUI64
+	if err := gatewayID.UnmarshalText([]byte(strings.TrimPrefix(strings.ToLower(id), gatewayIDPrefix))); err != nil {
+		return gatewayID, errors.Wrapf(err, "gateway id %s does not contain a gateway eui", id)
+	}
+	return gatewayID, nil
+}
+
+// FormatGatewayID returns the TTN gateway ID for the given gateway EUI.
+func FormatGatewayID(gatewayID lorawan.EUI64) string {
+	return gatewayIDPrefix + gatewayID.String()
+}
+
+// GetUplinkFrames returns the uplink frames for the given uplink message.
+// When the message contains the meta-data per antenna, an uplink frame is
+// returned for each antenna.
+func GetUplinkFrames(gatewayID lorawan.EUI64, msg UplinkMessage) ([]gw.UplinkFrame, error) {
+	if msg.GetProtocolMetadata().GetLorawan() == nil {
+		return nil, errors.New("lorawan protocol meta-data must not be nil")
+	}
+	if msg.GatewayMetadata == nil {
+		return nil, errors.New("gateway meta-data must not be nil")
+	}
+
+	md := msg.GatewayMetadata
+	frame := gw.UplinkFrame{
+		PhyPayload: msg.Payload,
+		TxInfo: &gw.UplinkTXInfo{
+			Frequency: uint32(md.Frequency),
+		},
+		RxInfo: &gw.UplinkRXInfo{
+			GatewayId: gatewayID[:],
+			Rssi:      int32(md.Rssi),
+			LoraSnr:   float64(md.Snr),
+			Channel:   md.Channel,
+			RfChain:   md.RfChain,
+			Context:   make([]byte, 4),
+		},
+	}
+
+	// Context
+	binary.BigEndian.PutUint32(frame.RxInfo.Context, md.Timestamp)
+
+	// Time
+	if md.Time != 0 {
+		ts, err := ptypes.TimestampProto(time.Unix(0, md.Time))
+		if err != nil {
+			return nil, errors.Wrap(err, "timestamp proto error")
+		}
+		frame.RxInfo.Time = ts
+	}
+
+	// Location
+	if loc := getLocation(md.Location); loc != nil {
+		frame.RxInfo.Location = loc
+	}
+
+	// Data-rate
+	lorawanMD := msg.GetProtocolMetadata().GetLorawan()
+	switch lorawanMD.Modulation {
+	case Modulation_LORA:
+		sf, bw, err := parseLoRaDataRate(lorawanMD.DataRate)
+		if err != nil {
+			return nil, err
+		}
+
+		frame.TxInfo.Modulation = common.Modulation_LORA
+		frame.TxInfo.ModulationInfo = &gw.UplinkTXInfo_LoraModulationInfo{
+			LoraModulationInfo: &gw.LoRaModulationInfo{
+				Bandwidth:       bw,
+				SpreadingFactor: sf,
+				CodeRate:        lorawanMD.CodingRate,
+			},
+		}
+	case Modulation_FSK:
+		frame.TxInfo.Modulation = common.Modulation_FSK
+		frame.TxInfo.ModulationInfo = &gw.UplinkTXInfo_FskModulationInfo{
+			FskModulationInfo: &gw.FSKModulationInfo{
+				Bitrate: lorawanMD.BitRate,
+			},
+		}
+	default:
+		return nil, fmt.Errorf("invalid modulation: %d", lorawanMD.Modulation)
+	}
+
+	var frames []gw.UplinkFrame
+	if len(md.Antennas) == 0 {
+		frames = append(frames, frame)
+	}
+
+	for _, ant := range md.Antennas {
+		f := frame
+		rxInfo := *frame.RxInfo
+		rxInfo.Antenna = ant.Antenna
+		rxInfo.Channel = ant.Channel
+		rxInfo.Rssi = int32(ant.Rssi)
+		rxInfo.LoraSnr = float64(ant.Snr)
+		f.RxInfo = &rxInfo
+
+		frames = append(frames, f)
+	}
+
+	for i := range frames {
+		uplinkID, err := uuid.NewV4()
+		if err != nil {
+			return nil, errors.Wrap(err, "new uuid error")
+		}
+		frames[i].RxInfo.UplinkId = uplinkID[:]
+	}
+
+	return frames, nil
+}
+
+// GetGatewayStats returns the gateway stats for the given status message.
+func GetGatewayStats(gatewayID lorawan.EUI64, status Status) (gw.GatewayStats, error) {
+	stats := gw.GatewayStats{
+		GatewayId:           gatewayID[:],
+		RxPacketsReceived:   status.RxIn,
+		RxPacketsReceivedOk: status.RxOk,
+		TxPacketsReceived:   status.TxIn,
+		TxPacketsEmitted:    status.TxOk,
+		Location:            getLocation(status.Location),
+	}
+
+	if len(status.Ip) != 0 {
+		stats.Ip = status.Ip[0]
+	}
+
+	t := time.Now()
+	if status.Time != 0 {
+		t = time.Unix(0, status.Time)
+	}
+
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return stats, errors.Wrap(err, "timestamp proto error")
+	}
+	stats.Time = ts
+
+	statsID, err := uuid.NewV4()
+	if err != nil {
+		return stats, errors.Wrap(err, "new uuid error")
+	}
+	stats.StatsId = statsID[:]
+
+	return stats, nil
+}
+
+// GetDownlinkMessage returns the downlink message for the given downlink
+// frame. As the TTN gateway-connector protocol only supports timestamp based
+// scheduling, only the DELAY timing is supported.
+func GetDownlinkMessage(frame gw.DownlinkFrame) (DownlinkMessage, error) {
+	txInfo := frame.GetTxInfo()
+	if txInfo == nil {
+		return DownlinkMessage{}, errors.New("tx_info must not be nil")
+	}
+
+	lorawanConf := LoRaWANTxConfiguration{}
+	msg := DownlinkMessage{
+		Payload: frame.PhyPayload,
+		ProtocolConfiguration: &TxConfiguration{
+			Protocol: &TxConfiguration_Lorawan{
+				Lorawan: &lorawanConf,
+			},
+		},
+		GatewayConfiguration: &GatewayTxConfiguration{
+			Frequency: uint64(txInfo.Frequency),
+			Power:     txInfo.Power,
+		},
+	}
+
+	switch txInfo.Modulation {
+	case common.Modulation_LORA:
+		modInfo := txInfo.GetLoraModulationInfo()
+		if modInfo == nil {
+			return msg, errors.New("lora_modulation_info must not be nil")
+		}
+
+		lorawanConf.Modulation = Modulation_LORA
+		lorawanConf.DataRate = fmt.Sprintf("SF%dBW%d", modInfo.SpreadingFactor, modInfo.Bandwidth)
+		lorawanConf.CodingRate = modInfo.CodeRate
+		msg.GatewayConfiguration.PolarizationInversion = modInfo.PolarizationInversion
+	case common.Modulation_FSK:
+		modInfo := txInfo.GetFskModulationInfo()
+		if modInfo == nil {
+			return msg, errors.New("fsk_modulation_info must not be nil")
+		}
+
+		lorawanConf.Modulation = Modulation_FSK
+		lorawanConf.BitRate = modInfo.Bitrate
+		msg.GatewayConfiguration.FrequencyDeviation = modInfo.Bitrate / 2
+	default:
+		return msg, fmt.Errorf("invalid modulation: %s", txInfo.Modulation)
+	}
+
+	if txInfo.Timing != gw.DownlinkTiming_DELAY {
+		return msg, fmt.Errorf("unsupported downlink timing: %s", txInfo.Timing)
+	}
+
+	timingInfo := txInfo.GetDelayTimingInfo()
+	if timingInfo == nil {
+		return msg, errors.New("delay_timing_info must not be nil")
+	}
+
+	delay, err := ptypes.Duration(timingInfo.Delay)
+	if err != nil {
+		return msg, errors.Wrap(err, "get delay duration error")
+	}
+
+	if len(txInfo.Context) < 4 {
+		return msg, fmt.Errorf("context must contain at least 4 bytes, got: %d", len(txInfo.Context))
+	}
+	msg.GatewayConfiguration.Timestamp = binary.BigEndian.Uint32(txInfo.Context[0:4]) + uint32(delay/time.Microsecond)
+
+	return msg, nil
+}
+
+func parseLoRaDataRate(dr string) (uint32, uint32, error) {
+	match := loRaDataRateRegex.FindStringSubmatch(dr)
+	if len(match) != 3 {
+		return 0, 0, fmt.Errorf("could not parse lora data-rate: %s", dr)
+	}
+
+	sf, err := strconv.ParseUint(match[1], 10, 32)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse spreading-factor error")
+	}
+
+	bw, err := strconv.ParseUint(match[2], 10, 32)
+	if err != nil {
+		return 0, 0, errors.Wrap(err, "parse bandwidth error")
+	}
+
+	return uint32(sf), uint32(bw), nil
+}
+
+func getLocation(loc *LocationMetadata) *common.Location {
+	if loc == nil || (loc.Latitude == 0 && loc.Longitude == 0) {
+		return nil
+	}
+
+	out := common.Location{
+		Latitude:  float64(loc.Latitude),
+		Longitude: float64(loc.Longitude),
+		Altitude:  float64(loc.Altitude),
+	}
+
+	switch loc.Source {
+	case LocationMetadata_GPS:
+		out.Source = common.LocationSource_GPS
+	case LocationMetadata_CONFIG, LocationMetadata_REGISTRY:
+		out.Source = common.LocationSource_CONFIG
+	default:
+		out.Source = common.LocationSource_UNKNOWN
+	}
+
+	return &out
+}
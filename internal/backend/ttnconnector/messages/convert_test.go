@@ -0,0 +1,272 @@
+package messages
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestParseGatewayID(t *testing.T) {
+	tests := []struct {
+		ID                string
+		ExpectedGatewayID lorawan.EUI64
+		ExpectedError     bool
+	}{
+		{"eui-0102030405060708", lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, false},
+		{"EUI-0102030405060708", lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, false},
+		{"0102030405060708", lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, false},
+		{"my-gateway", lorawan.EUI64{}, true},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.ID, func(t *testing.T) {
+			assert := require.New(t)
+
+			gatewayID, err := ParseGatewayID(tst.ID)
+			if tst.ExpectedError {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedGatewayID, gatewayID)
+		})
+	}
+
+	require.Equal(t, "eui-0102030405060708", FormatGatewayID(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}))
+}
+
+func TestGetUplinkFrames(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	nowPB, _ := ptypes.TimestampProto(now)
+
+	msg := UplinkMessage{
+		Payload: []byte{1, 2, 3, 4},
+		ProtocolMetadata: &RxMetadata{
+			Protocol: &RxMetadata_Lorawan{
+				Lorawan: &LoRaWANMetadata{
+					Modulation: Modulation_LORA,
+					DataRate:   "SF7BW125",
+					CodingRate: "4/5",
+				},
+			},
+		},
+		GatewayMetadata: &GatewayRxMetadata{
+			GatewayId: "eui-0102030405060708",
+			Timestamp: 1000,
+			Time:      now.UnixNano(),
+			RfChain:   1,
+			Channel:   2,
+			Frequency: 868100000,
+			Rssi:      -50,
+			Snr:       5.5,
+		},
+	}
+
+	// the message must survive the protobuf round-trip
+	b, err := proto.Marshal(&msg)
+	require.NoError(t, err)
+	var decoded UplinkMessage
+	require.NoError(t, proto.Unmarshal(b, &decoded))
+
+	t.Run("Single antenna", func(t *testing.T) {
+		assert := require.New(t)
+
+		frames, err := GetUplinkFrames(gatewayID, decoded)
+		assert.NoError(err)
+		assert.Len(frames, 1)
+		assert.Len(frames[0].RxInfo.UplinkId, 16)
+		frames[0].RxInfo.UplinkId = nil
+
+		assert.Equal(gw.UplinkFrame{
+			PhyPayload: []byte{1, 2, 3, 4},
+			TxInfo: &gw.UplinkTXInfo{
+				Frequency:  868100000,
+				Modulation: common.Modulation_LORA,
+				ModulationInfo: &gw.UplinkTXInfo_LoraModulationInfo{
+					LoraModulationInfo: &gw.LoRaModulationInfo{
+						Bandwidth:       125,
+						SpreadingFactor: 7,
+						CodeRate:        "4/5",
+					},
+				},
+			},
+			RxInfo: &gw.UplinkRXInfo{
+				GatewayId: gatewayID[:],
+				Time:      nowPB,
+				Rssi:      -50,
+				LoraSnr:   5.5,
+				Channel:   2,
+				RfChain:   1,
+				Context:   []byte{0x00, 0x00, 0x03, 0xe8},
+			},
+		}, frames[0])
+	})
+
+	t.Run("Multiple antennas", func(t *testing.T) {
+		assert := require.New(t)
+
+		msg := decoded
+		md := *msg.GatewayMetadata
+		md.Antennas = []*AntennaMetadata{
+			{Antenna: 0, Channel: 2, Rssi: -50, Snr: 5.5},
+			{Antenna: 1, Channel: 2, Rssi: -60, Snr: 3},
+		}
+		msg.GatewayMetadata = &md
+
+		frames, err := GetUplinkFrames(gatewayID, msg)
+		assert.NoError(err)
+		assert.Len(frames, 2)
+		assert.EqualValues(1, frames[1].RxInfo.Antenna)
+		assert.EqualValues(-60, frames[1].RxInfo.Rssi)
+		assert.EqualValues(3, frames[1].RxInfo.LoraSnr)
+		assert.NotEqual(frames[0].RxInfo.UplinkId, frames[1].RxInfo.UplinkId)
+	})
+
+	t.Run("Invalid data-rate", func(t *testing.T) {
+		assert := require.New(t)
+
+		msg := decoded
+		msg.ProtocolMetadata = &RxMetadata{
+			Protocol: &RxMetadata_Lorawan{
+				Lorawan: &LoRaWANMetadata{
+					DataRate: "foo",
+				},
+			},
+		}
+
+		_, err := GetUplinkFrames(gatewayID, msg)
+		assert.EqualError(err, "could not parse lora data-rate: foo")
+	})
+}
+
+func TestGetGatewayStats(t *testing.T) {
+	assert := require.New(t)
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	nowPB, _ := ptypes.TimestampProto(now)
+
+	stats, err := GetGatewayStats(gatewayID, Status{
+		Time: now.UnixNano(),
+		Ip:   []string{"192.168.1.10"},
+		Location: &LocationMetadata{
+			Latitude:  1.5,
+			Longitude: 2.5,
+			Altitude:  10,
+			Source:    LocationMetadata_GPS,
+		},
+		RxIn: 10,
+		RxOk: 9,
+		TxIn: 3,
+		TxOk: 2,
+	})
+	assert.NoError(err)
+	assert.Len(stats.StatsId, 16)
+	stats.StatsId = nil
+
+	assert.Equal(gw.GatewayStats{
+		GatewayId: gatewayID[:],
+		Ip:        "192.168.1.10",
+		Time:      nowPB,
+		Location: &common.Location{
+			Latitude:  1.5,
+			Longitude: 2.5,
+			Altitude:  10,
+			Source:    common.LocationSource_GPS,
+		},
+		RxPacketsReceived:   10,
+		RxPacketsReceivedOk: 9,
+		TxPacketsReceived:   3,
+		TxPacketsEmitted:    2,
+	}, stats)
+}
+
+func TestGetDownlinkMessage(t *testing.T) {
+	tests := []struct {
+		Name            string
+		DownlinkFrame   gw.DownlinkFrame
+		ExpectedMessage DownlinkMessage
+		ExpectedError   string
+	}{
+		{
+			Name: "LoRa delay timing",
+			DownlinkFrame: gw.DownlinkFrame{
+				PhyPayload: []byte{1, 2, 3},
+				TxInfo: &gw.DownlinkTXInfo{
+					Frequency:  868100000,
+					Power:      14,
+					Modulation: common.Modulation_LORA,
+					ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+						LoraModulationInfo: &gw.LoRaModulationInfo{
+							Bandwidth:             125,
+							SpreadingFactor:       7,
+							CodeRate:              "4/5",
+							PolarizationInversion: true,
+						},
+					},
+					Timing: gw.DownlinkTiming_DELAY,
+					TimingInfo: &gw.DownlinkTXInfo_DelayTimingInfo{
+						DelayTimingInfo: &gw.DelayTimingInfo{
+							Delay: ptypes.DurationProto(time.Second),
+						},
+					},
+					Context: []byte{0x00, 0x00, 0x03, 0xe8},
+				},
+			},
+			ExpectedMessage: DownlinkMessage{
+				Payload: []byte{1, 2, 3},
+				ProtocolConfiguration: &TxConfiguration{
+					Protocol: &TxConfiguration_Lorawan{
+						Lorawan: &LoRaWANTxConfiguration{
+							Modulation: Modulation_LORA,
+							DataRate:   "SF7BW125",
+							CodingRate: "4/5",
+						},
+					},
+				},
+				GatewayConfiguration: &GatewayTxConfiguration{
+					Timestamp:             1001000,
+					Frequency:             868100000,
+					Power:                 14,
+					PolarizationInversion: true,
+				},
+			},
+		},
+		{
+			Name: "Immediately timing",
+			DownlinkFrame: gw.DownlinkFrame{
+				TxInfo: &gw.DownlinkTXInfo{
+					Modulation: common.Modulation_LORA,
+					ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+						LoraModulationInfo: &gw.LoRaModulationInfo{},
+					},
+					Timing: gw.DownlinkTiming_IMMEDIATELY,
+				},
+			},
+			ExpectedError: "unsupported downlink timing: IMMEDIATELY",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			msg, err := GetDownlinkMessage(tst.DownlinkFrame)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedMessage, msg)
+		})
+	}
+}
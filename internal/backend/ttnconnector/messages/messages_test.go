@@ -0,0 +1,187 @@
+package messages
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGoldenBytes validates the wire-compatibility with the TheThingsNetwork
+// API Protobuf definitions. The golden bytes are encoded by hand using the
+// field numbers and types of the TheThingsNetwork definitions, as noted in
+// the comments.
+func TestGoldenBytes(t *testing.T) {
+	tests := []struct {
+		Name    string
+		Message proto.Message
+		Empty   proto.Message
+		Golden  []byte
+	}{
+		{
+			Name: "types.ConnectMessage",
+			Message: &ConnectMessage{
+				Id:  "eui-0102030405060708",
+				Key: "secret",
+			},
+			Empty: &ConnectMessage{},
+			Golden: []byte{
+				// id (1)
+				0x0a, 0x14, 0x65, 0x75, 0x69, 0x2d, 0x30, 0x31, 0x30, 0x32, 0x30, 0x33, 0x30, 0x34, 0x30, 0x35, 0x30, 0x36, 0x30, 0x37, 0x30, 0x38,
+				// key (2)
+				0x12, 0x06, 0x73, 0x65, 0x63, 0x72, 0x65, 0x74,
+			},
+		},
+		{
+			Name: "router.UplinkMessage",
+			Message: &UplinkMessage{
+				Payload: []byte{1, 2, 3, 4},
+				ProtocolMetadata: &RxMetadata{
+					Protocol: &RxMetadata_Lorawan{
+						Lorawan: &LoRaWANMetadata{
+							Modulation: Modulation_LORA,
+							DataRate:   "SF7BW125",
+							CodingRate: "4/5",
+						},
+					},
+				},
+				GatewayMetadata: &GatewayRxMetadata{
+					GatewayId: "eui-0102030405060708",
+					Timestamp: 1000,
+					RfChain:   1,
+					Channel:   2,
+					Frequency: 868100000,
+					Rssi:      -50,
+					Snr:       5.5,
+				},
+			},
+			Empty: &UplinkMessage{},
+			Golden: []byte{
+				// payload (1)
+				0x0a, 0x04, 0x01, 0x02, 0x03, 0x04,
+				// protocol_metadata (11)
+				0x5a, 0x11,
+				// protocol_metadata.lorawan (1)
+				0x0a, 0x0f,
+				// data_rate (12)
+				0x62, 0x08, 0x53, 0x46, 0x37, 0x42, 0x57, 0x31, 0x32, 0x35,
+				// coding_rate (14)
+				0x72, 0x03, 0x34, 0x2f, 0x35,
+				// gateway_metadata (12)
+				0x62, 0x32,
+				// gateway_id (1)
+				0x0a, 0x14, 0x65, 0x75, 0x69, 0x2d, 0x30, 0x31, 0x30, 0x32, 0x30, 0x33, 0x30, 0x34, 0x30, 0x35, 0x30, 0x36, 0x30, 0x37, 0x30, 0x38,
+				// timestamp (11)
+				0x58, 0xe8, 0x07,
+				// rf_chain (21)
+				0xa8, 0x01, 0x01,
+				// channel (22)
+				0xb0, 0x01, 0x02,
+				// frequency (31)
+				0xf8, 0x01, 0xa0, 0xcf, 0xf8, 0x9d, 0x03,
+				// rssi (32)
+				0x85, 0x02, 0x00, 0x00, 0x48, 0xc2,
+				// snr (33)
+				0x8d, 0x02, 0x00, 0x00, 0xb0, 0x40,
+			},
+		},
+		{
+			Name: "router.DownlinkMessage",
+			Message: &DownlinkMessage{
+				Payload: []byte{1, 2, 3},
+				ProtocolConfiguration: &TxConfiguration{
+					Protocol: &TxConfiguration_Lorawan{
+						Lorawan: &LoRaWANTxConfiguration{
+							Modulation: Modulation_FSK,
+							BitRate:    50000,
+						},
+					},
+				},
+				GatewayConfiguration: &GatewayTxConfiguration{
+					Timestamp:          1001000,
+					Frequency:          868100000,
+					Power:              14,
+					FrequencyDeviation: 25000,
+				},
+			},
+			Empty: &DownlinkMessage{},
+			Golden: []byte{
+				// payload (1)
+				0x0a, 0x03, 0x01, 0x02, 0x03,
+				// protocol_configuration (11)
+				0x5a, 0x08,
+				// protocol_configuration.lorawan (1)
+				0x0a, 0x06,
+				// modulation (11)
+				0x58, 0x01,
+				// bit_rate (13)
+				0x68, 0xd0, 0x86, 0x03,
+				// gateway_configuration (12)
+				0x62, 0x13,
+				// timestamp (11)
+				0x58, 0xa8, 0x8c, 0x3d,
+				// frequency (22)
+				0xb0, 0x01, 0xa0, 0xcf, 0xf8, 0x9d, 0x03,
+				// power (23)
+				0xb8, 0x01, 0x0e,
+				// frequency_deviation (32)
+				0x80, 0x02, 0xa8, 0xc3, 0x01,
+			},
+		},
+		{
+			Name: "gateway.Status",
+			Message: &Status{
+				Ip:       []string{"192.168.1.10"},
+				Platform: "IMST + Rpi",
+				Location: &LocationMetadata{
+					Latitude:  1.5,
+					Longitude: 2.5,
+					Altitude:  10,
+					Source:    LocationMetadata_GPS,
+				},
+				RxIn: 10,
+				RxOk: 9,
+				TxIn: 3,
+				TxOk: 2,
+			},
+			Empty: &Status{},
+			Golden: []byte{
+				// ip (11)
+				0x5a, 0x0c, 0x31, 0x39, 0x32, 0x2e, 0x31, 0x36, 0x38, 0x2e, 0x31, 0x2e, 0x31, 0x30,
+				// platform (12)
+				0x62, 0x0a, 0x49, 0x4d, 0x53, 0x54, 0x20, 0x2b, 0x20, 0x52, 0x70, 0x69,
+				// location (21)
+				0xaa, 0x01, 0x0e,
+				// latitude (2)
+				0x15, 0x00, 0x00, 0xc0, 0x3f,
+				// longitude (3)
+				0x1d, 0x00, 0x00, 0x20, 0x40,
+				// altitude (4)
+				0x20, 0x0a,
+				// source (6)
+				0x30, 0x01,
+				// rx_in (41)
+				0xc8, 0x02, 0x0a,
+				// rx_ok (42)
+				0xd0, 0x02, 0x09,
+				// tx_in (43)
+				0xd8, 0x02, 0x03,
+				// tx_ok (44)
+				0xe0, 0x02, 0x02,
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := proto.Marshal(tst.Message)
+			assert.NoError(err)
+			assert.Equal(tst.Golden, b)
+
+			assert.NoError(proto.Unmarshal(tst.Golden, tst.Empty))
+			assert.True(proto.Equal(tst.Message, tst.Empty))
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package ttnconnector
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	mr = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_ttnconnector_mqtt_received_count",
+		Help: "The number of MQTT messages received by the backend (per message type).",
+	}, []string{"type"})
+
+	ms = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "backend_ttnconnector_mqtt_sent_count",
+		Help: "The number of MQTT messages sent by the backend (per message type).",
+	}, []string{"type"})
+
+	gwc = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "backend_ttnconnector_gateway_connect_count",
+		Help: "The number of gateway connections received by the backend.",
+	})
+
+	gwd = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "backend_ttnconnector_gateway_disconnect_count",
+		Help: "The number of gateways that disconnected from the backend.",
+	})
+)
+
+func mqttReceiveCounter(typ string) prometheus.Counter {
+	return mr.With(prometheus.Labels{"type": typ})
+}
+
+func mqttPublishCounter(typ string) prometheus.Counter {
+	return ms.With(prometheus.Labels{"type": typ})
+}
+
+func connectCounter() prometheus.Counter {
+	return gwc
+}
+
+func disconnectCounter() prometheus.Counter {
+	return gwd
+}
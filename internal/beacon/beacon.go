@@ -0,0 +1,219 @@
+// Package beacon implements the Class B beacon configuration. It computes the
+// beacon parameters (frequencies, data-rate, layout and timing) from the
+// configured region and a GPS or NTP (system) time source. These parameters
+// are used by the backends to instruct the gateways to transmit beacons.
+package beacon
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+	"github.com/brocaar/lorawan/gps"
+)
+
+// Period defines the beacon period.
+const Period = 128 * time.Second
+
+// Configuration contains the beacon configuration.
+type Configuration struct {
+	Region          band.Name `json:"region"`
+	DataRate        int       `json:"dataRate"`
+	SpreadingFactor int       `json:"spreadingFactor"`
+	Bandwidth       int       `json:"bandwidth"`
+	Frequencies     []uint32  `json:"frequencies"`
+	Power           int       `json:"power"`
+	InfoDesc        int       `json:"infoDesc"`
+
+	// Layout contains the offset of the time field, the offset of the
+	// InfoDesc field and the total length of the beacon (in bytes).
+	Layout [3]int `json:"layout"`
+
+	// NextBeacon contains the time since GPS epoch of the next beacon.
+	NextBeacon time.Duration `json:"nextBeacon"`
+}
+
+// FrequencyStep returns the step between the beacon frequencies, or 0 in
+// case of a single beacon frequency.
+func (c Configuration) FrequencyStep() uint32 {
+	if len(c.Frequencies) < 2 {
+		return 0
+	}
+	return c.Frequencies[1] - c.Frequencies[0]
+}
+
+// GetFrequency returns the frequency of the beacon transmitted at the given
+// time since GPS epoch.
+func (c Configuration) GetFrequency(beaconTime time.Duration) uint32 {
+	i := int(beaconTime/Period) % len(c.Frequencies)
+	return c.Frequencies[i]
+}
+
+// regionalConfiguration contains the beacon parameters of a region.
+type regionalConfiguration struct {
+	dataRate    int
+	frequencies []uint32
+	layout      [3]int
+}
+
+// regionalConfigurations contains the beacon parameters per region as
+// defined by the LoRaWAN Regional Parameters.
+var regionalConfigurations = map[band.Name]regionalConfiguration{
+	band.AS923: {dataRate: 3, frequencies: []uint32{923400000}, layout: [3]int{2, 8, 17}},
+	band.AU915: {dataRate: 8, frequencies: getFrequencies(923300000, 600000, 8), layout: [3]int{5, 11, 23}},
+	band.CN470: {dataRate: 2, frequencies: getFrequencies(508300000, 200000, 8), layout: [3]int{3, 9, 19}},
+	band.CN779: {dataRate: 3, frequencies: []uint32{785000000}, layout: [3]int{2, 8, 17}},
+	band.EU433: {dataRate: 3, frequencies: []uint32{434665000}, layout: [3]int{2, 8, 17}},
+	band.EU868: {dataRate: 3, frequencies: []uint32{869525000}, layout: [3]int{2, 8, 17}},
+	band.IN865: {dataRate: 4, frequencies: []uint32{866550000}, layout: [3]int{1, 7, 19}},
+	band.KR920: {dataRate: 3, frequencies: []uint32{923100000}, layout: [3]int{2, 8, 17}},
+	band.RU864: {dataRate: 3, frequencies: []uint32{869100000}, layout: [3]int{2, 8, 17}},
+	band.US915: {dataRate: 8, frequencies: getFrequencies(923300000, 600000, 8), layout: [3]int{5, 11, 23}},
+}
+
+var (
+	mux sync.RWMutex
+
+	enabled    bool
+	beaconConf Configuration
+	timeSource TimeSource
+)
+
+// Setup configures the beacon package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !conf.Beacon.Enabled {
+		return nil
+	}
+
+	c, err := getConfiguration(band.Name(conf.Beacon.Region), conf.Beacon.Power, conf.Beacon.InfoDesc)
+	if err != nil {
+		return errors.Wrap(err, "get beacon configuration error")
+	}
+
+	ts, err := newTimeSource(conf)
+	if err != nil {
+		return errors.Wrap(err, "new time source error")
+	}
+
+	enabled = true
+	beaconConf = c
+	timeSource = ts
+
+	log.WithFields(log.Fields{
+		"region":      c.Region,
+		"data_rate":   c.DataRate,
+		"frequencies": c.Frequencies,
+		"time_source": conf.Beacon.TimeSource.Type,
+	}).Info("beacon: class-b beaconing enabled")
+
+	admin.HandleFunc("/api/beacon", handleHTTP)
+
+	return nil
+}
+
+// Enabled returns true when beaconing is enabled.
+func Enabled() bool {
+	mux.RLock()
+	defer mux.RUnlock()
+	return enabled
+}
+
+// GetConfiguration returns the beacon configuration. It returns nil when
+// beaconing is disabled or when the time source is not synchronized, in
+// which case the gateways must not be instructed to transmit beacons.
+func GetConfiguration() *Configuration {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if !enabled {
+		return nil
+	}
+
+	gpsTime, err := timeSource.GPSTime()
+	if err != nil {
+		log.WithError(err).Warning("beacon: get gps time error, beaconing is not configured")
+		return nil
+	}
+
+	c := beaconConf
+	c.NextBeacon = GetNextBeacon(gpsTime)
+
+	return &c
+}
+
+// GetNextBeacon returns the time since GPS epoch of the first beacon after
+// the given time since GPS epoch.
+func GetNextBeacon(gpsTime time.Duration) time.Duration {
+	return gpsTime.Truncate(Period) + Period
+}
+
+func getConfiguration(region band.Name, power, infoDesc int) (Configuration, error) {
+	rc, ok := regionalConfigurations[region]
+	if !ok {
+		return Configuration{}, errors.Errorf("beaconing is not supported for region: %s", region)
+	}
+
+	b, err := band.GetConfig(region, false, lorawan.DwellTimeNoLimit)
+	if err != nil {
+		return Configuration{}, errors.Wrap(err, "get band config error")
+	}
+
+	dr, err := b.GetDataRate(rc.dataRate)
+	if err != nil {
+		return Configuration{}, errors.Wrap(err, "get data-rate error")
+	}
+
+	return Configuration{
+		Region:          region,
+		DataRate:        rc.dataRate,
+		SpreadingFactor: dr.SpreadFactor,
+		Bandwidth:       dr.Bandwidth * 1000,
+		Frequencies:     rc.frequencies,
+		Power:           power,
+		InfoDesc:        infoDesc,
+		Layout:          rc.layout,
+	}, nil
+}
+
+func getFrequencies(start, step uint32, count int) []uint32 {
+	var out []uint32
+	for i := 0; i < count; i++ {
+		out = append(out, start+uint32(i)*step)
+	}
+	return out
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// beacon configuration, including the time and frequency of the next beacon.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	c := GetConfiguration()
+	if c == nil {
+		admin.WriteError(w, http.StatusServiceUnavailable, errors.New("time source is not synchronized"))
+		return
+	}
+
+	admin.WriteJSON(w, struct {
+		Configuration
+		NextBeaconTime      time.Time `json:"nextBeaconTime"`
+		NextBeaconFrequency uint32    `json:"nextBeaconFrequency"`
+	}{
+		Configuration:       *c,
+		NextBeaconTime:      time.Time(gps.NewTimeFromTimeSinceGPSEpoch(c.NextBeacon)),
+		NextBeaconFrequency: c.GetFrequency(c.NextBeacon),
+	})
+}
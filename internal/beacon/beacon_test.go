@@ -0,0 +1,144 @@
+package beacon
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan/band"
+)
+
+type testTimeSource struct {
+	gpsTime time.Duration
+	err     error
+}
+
+func (ts *testTimeSource) GPSTime() (time.Duration, error) {
+	return ts.gpsTime, ts.err
+}
+
+func TestGetConfiguration(t *testing.T) {
+	tests := []struct {
+		Name   string
+		Region band.Name
+
+		ExpectedConfiguration Configuration
+		ExpectedError         error
+	}{
+		{
+			Name:   "EU868",
+			Region: band.EU868,
+			ExpectedConfiguration: Configuration{
+				Region:          band.EU868,
+				DataRate:        3,
+				SpreadingFactor: 9,
+				Bandwidth:       125000,
+				Frequencies:     []uint32{869525000},
+				Power:           14,
+				Layout:          [3]int{2, 8, 17},
+			},
+		},
+		{
+			Name:   "US915",
+			Region: band.US915,
+			ExpectedConfiguration: Configuration{
+				Region:          band.US915,
+				DataRate:        8,
+				SpreadingFactor: 12,
+				Bandwidth:       500000,
+				Frequencies:     []uint32{923300000, 923900000, 924500000, 925100000, 925700000, 926300000, 926900000, 927500000},
+				Power:           14,
+				Layout:          [3]int{5, 11, 23},
+			},
+		},
+		{
+			Name:          "invalid region",
+			Region:        band.Name("FOO"),
+			ExpectedError: errors.New("beaconing is not supported for region: FOO"),
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			c, err := getConfiguration(tst.Region, 14, 0)
+			if tst.ExpectedError != nil {
+				assert.EqualError(err, tst.ExpectedError.Error())
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedConfiguration, c)
+		})
+	}
+}
+
+func TestBeaconTiming(t *testing.T) {
+	c, err := getConfiguration(band.US915, 14, 0)
+	require.NoError(t, err)
+
+	t.Run("GetNextBeacon", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.Equal(128*time.Second, GetNextBeacon(0))
+		assert.Equal(256*time.Second, GetNextBeacon(128*time.Second))
+		assert.Equal(256*time.Second, GetNextBeacon(200*time.Second))
+	})
+
+	t.Run("GetFrequency", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.EqualValues(600000, c.FrequencyStep())
+		assert.EqualValues(923300000, c.GetFrequency(0))
+		assert.EqualValues(923900000, c.GetFrequency(128*time.Second))
+		assert.EqualValues(923300000, c.GetFrequency(8*128*time.Second))
+	})
+
+	t.Run("GetConfiguration", func(t *testing.T) {
+		assert := require.New(t)
+
+		enabled = true
+		beaconConf = c
+		timeSource = &testTimeSource{gpsTime: 1000 * time.Second}
+		defer func() { enabled = false }()
+
+		bc := GetConfiguration()
+		assert.NotNil(bc)
+		assert.Equal(1024*time.Second, bc.NextBeacon)
+
+		timeSource = &testTimeSource{err: errors.New("not synchronized")}
+		assert.Nil(GetConfiguration())
+	})
+}
+
+func TestGPSDTimeSource(t *testing.T) {
+	assert := require.New(t)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(err)
+	defer ln.Close()
+
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		conn.Write([]byte(`{"class":"VERSION","release":"3.17"}` + "\n"))
+		conn.Write([]byte(`{"class":"TPV","mode":3,"time":"1980-01-06T00:10:00.000Z"}` + "\n"))
+	}()
+
+	ts := gpsdTimeSource{
+		server:  ln.Addr().String(),
+		timeout: time.Second,
+	}
+
+	gpsTime, err := ts.GPSTime()
+	assert.NoError(err)
+	assert.Equal(10*time.Minute, gpsTime)
+}
@@ -0,0 +1,97 @@
+package beacon
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan/gps"
+)
+
+// TimeSource defines the interface of a time source.
+type TimeSource interface {
+	// GPSTime returns the current time since GPS epoch. It returns an error
+	// when the time source is not available or not synchronized.
+	GPSTime() (time.Duration, error)
+}
+
+func newTimeSource(conf config.Config) (TimeSource, error) {
+	switch conf.Beacon.TimeSource.Type {
+	case "system":
+		return &systemTimeSource{}, nil
+	case "gpsd":
+		return &gpsdTimeSource{
+			server:  conf.Beacon.TimeSource.GPSD.Server,
+			timeout: conf.Beacon.TimeSource.GPSD.Timeout,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown time source type: %s", conf.Beacon.TimeSource.Type)
+	}
+}
+
+// systemTimeSource uses the system clock as time source. The system clock
+// is expected to be synchronized using NTP.
+type systemTimeSource struct{}
+
+func (s *systemTimeSource) GPSTime() (time.Duration, error) {
+	return gps.Time(time.Now()).TimeSinceGPSEpoch(), nil
+}
+
+// gpsdTimeSource uses gpsd as time source.
+type gpsdTimeSource struct {
+	server  string
+	timeout time.Duration
+}
+
+// gpsdTPV implements the gpsd TPV (time-position-velocity) report.
+type gpsdTPV struct {
+	Class string    `json:"class"`
+	Mode  int       `json:"mode"`
+	Time  time.Time `json:"time"`
+}
+
+func (s *gpsdTimeSource) GPSTime() (time.Duration, error) {
+	conn, err := net.DialTimeout("tcp", s.server, s.timeout)
+	if err != nil {
+		return 0, errors.Wrap(err, "dial gpsd error")
+	}
+	defer conn.Close()
+
+	if err := conn.SetDeadline(time.Now().Add(s.timeout)); err != nil {
+		return 0, errors.Wrap(err, "set deadline error")
+	}
+
+	if _, err := conn.Write([]byte(`?WATCH={"enable":true,"json":true};`)); err != nil {
+		return 0, errors.Wrap(err, "write watch command error")
+	}
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var tpv gpsdTPV
+		if err := json.Unmarshal(scanner.Bytes(), &tpv); err != nil {
+			continue
+		}
+
+		if tpv.Class != "TPV" {
+			continue
+		}
+
+		// mode 2 (2D) and 3 (3D) indicate a GPS fix
+		if tpv.Mode < 2 || tpv.Time.IsZero() {
+			return 0, errors.New("gpsd has no fix")
+		}
+
+		return gps.Time(tpv.Time).TimeSinceGPSEpoch(), nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, errors.Wrap(err, "read gpsd error")
+	}
+
+	return 0, errors.New("gpsd closed the connection")
+}
@@ -0,0 +1,31 @@
+package cmdline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	assert := require.New(t)
+
+	tests := []struct {
+		In    string
+		Out   []string
+		Error error
+	}{
+		{
+			In:  "/path/to/bin arg1 arg2 arg3",
+			Out: []string{"/path/to/bin", "arg1", "arg2", "arg3"},
+		},
+	}
+
+	for _, tst := range tests {
+		out, err := Parse(tst.In)
+		assert.Equal(tst.Error, err)
+		if err != nil {
+			continue
+		}
+		assert.Equal(tst.Out, out)
+	}
+}
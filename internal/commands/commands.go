@@ -12,6 +12,7 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/commands/cmdline"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
 	"github.com/brocaar/lora-gateway-bridge/internal/integration"
 	"github.com/brocaar/loraserver/api/gw"
@@ -49,7 +50,12 @@ func Setup(conf config.Config) error {
 		}).Info("commands: configuring command")
 	}
 
+	if err := setupSpectralScan(conf); err != nil {
+		return err
+	}
+
 	go executeLoop()
+	go spectralScanLoop()
 
 	return nil
 }
@@ -93,7 +99,7 @@ func execute(command string, stdin []byte, environment map[string]string) ([]byt
 		return nil, nil, errors.New("command does not exist")
 	}
 
-	cmdArgs, err := ParseCommandLine(cmd.Command)
+	cmdArgs, err := cmdline.Parse(cmd.Command)
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "parse command error")
 	}
@@ -151,69 +157,3 @@ func execute(command string, stdin []byte, environment map[string]string) ([]byt
 
 	return stdoutB, stderrB, nil
 }
-
-// source: https://stackoverflow.com/questions/34118732/parse-a-command-line-string-into-flags-and-arguments-in-golang
-func ParseCommandLine(command string) ([]string, error) {
-	var args []string
-	state := "start"
-	current := ""
-	quote := "\""
-	escapeNext := true
-	for i := 0; i < len(command); i++ {
-		c := command[i]
-
-		if state == "quotes" {
-			if string(c) != quote {
-				current += string(c)
-			} else {
-				args = append(args, current)
-				current = ""
-				state = "start"
-			}
-			continue
-		}
-
-		if escapeNext {
-			current += string(c)
-			escapeNext = false
-			continue
-		}
-
-		if c == '\\' {
-			escapeNext = true
-			continue
-		}
-
-		if c == '"' || c == '\'' {
-			state = "quotes"
-			quote = string(c)
-			continue
-		}
-
-		if state == "arg" {
-			if c == ' ' || c == '\t' {
-				args = append(args, current)
-				current = ""
-				state = "start"
-			} else {
-				current += string(c)
-			}
-			continue
-		}
-
-		if c != ' ' && c != '\t' {
-			state = "arg"
-			current += string(c)
-		}
-	}
-
-	if state == "quotes" {
-		return []string{}, errors.New(fmt.Sprintf("Unclosed quote in command line: %s", command))
-	}
-
-	if current != "" {
-		args = append(args, current)
-	}
-
-	return args, nil
-}
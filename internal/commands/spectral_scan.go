@@ -0,0 +1,174 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"os/exec"
+	"text/template"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/commands/cmdline"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
+	"github.com/brocaar/lorawan"
+)
+
+// RemoteCommander is implemented by backends that are able to run a command
+// on the gateway (e.g. the Basic Station runcmd message).
+type RemoteCommander interface {
+	RunCommand(gatewayID lorawan.EUI64, command string, args []string) error
+}
+
+var (
+	spectralScanCommand              *template.Template
+	spectralScanMaxExecutionDuration time.Duration
+
+	remoteCommander RemoteCommander
+)
+
+// SetRemoteCommander sets the remote commander used to run the spectral scan
+// command on the gateway. When not set, the command is executed locally.
+func SetRemoteCommander(rc RemoteCommander) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	remoteCommander = rc
+}
+
+// spectralScanTemplateData contains the data available to the spectral scan
+// command template.
+type spectralScanTemplateData struct {
+	GatewayID      lorawan.EUI64
+	FrequencyStart uint32
+	FrequencyEnd   uint32
+	FrequencyStep  uint32
+	Samples        uint32
+}
+
+func setupSpectralScan(conf config.Config) error {
+	spectralScanCommand = nil
+	spectralScanMaxExecutionDuration = conf.Commands.SpectralScan.MaxExecutionDuration
+
+	if conf.Commands.SpectralScan.Command == "" {
+		return nil
+	}
+
+	var err error
+	spectralScanCommand, err = template.New("spectral_scan").Parse(conf.Commands.SpectralScan.Command)
+	if err != nil {
+		return errors.Wrap(err, "parse spectral scan command template error")
+	}
+
+	log.WithFields(log.Fields{
+		"command":                conf.Commands.SpectralScan.Command,
+		"max_execution_duration": spectralScanMaxExecutionDuration,
+	}).Info("commands: configuring spectral scan command")
+
+	return nil
+}
+
+func spectralScanLoop() {
+	for req := range integration.GetIntegration().GetSpectralScanRequestChan() {
+		go func(req spectralscan.Request) {
+			executeSpectralScan(req)
+		}(req)
+	}
+}
+
+func executeSpectralScan(req spectralscan.Request) {
+	var gatewayID lorawan.EUI64
+	var scanID uuid.UUID
+	copy(gatewayID[:], req.GatewayId)
+	copy(scanID[:], req.ScanId)
+
+	resp := spectralscan.Result{
+		GatewayId: req.GatewayId,
+		ScanId:    req.ScanId,
+	}
+
+	status, measurements, err := runSpectralScan(gatewayID, req)
+	if err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"scan_id":    scanID,
+		}).Error("commands: spectral scan error")
+
+		resp.Status = spectralscan.StatusError
+		resp.Error = err.Error()
+	} else {
+		resp.Status = status
+		resp.Measurements = measurements
+	}
+
+	if err := integration.GetIntegration().PublishEvent(gatewayID, integration.EventSpectralScan, scanID, &resp); err != nil {
+		log.WithError(err).Error("commands: publish spectral scan event error")
+	}
+}
+
+// runSpectralScan runs the spectral scan. When the backend is able to run
+// the command on the gateway, the scan is started by the gateway and the
+// measurements are not returned. Else the command is executed locally and
+// its output is parsed into measurements.
+func runSpectralScan(gatewayID lorawan.EUI64, req spectralscan.Request) (string, []*spectralscan.Measurement, error) {
+	if spectralScanCommand == nil {
+		return "", nil, errors.New("spectral scan command is not configured")
+	}
+
+	cmd := bytes.NewBuffer(nil)
+	if err := spectralScanCommand.Execute(cmd, spectralScanTemplateData{
+		GatewayID:      gatewayID,
+		FrequencyStart: req.FrequencyStart,
+		FrequencyEnd:   req.FrequencyEnd,
+		FrequencyStep:  req.FrequencyStep,
+		Samples:        req.Samples,
+	}); err != nil {
+		return "", nil, errors.Wrap(err, "execute command template error")
+	}
+
+	cmdArgs, err := cmdline.Parse(cmd.String())
+	if err != nil {
+		return "", nil, errors.Wrap(err, "parse command error")
+	}
+	if len(cmdArgs) == 0 {
+		return "", nil, errors.New("no command is given")
+	}
+
+	mux.RLock()
+	rc := remoteCommander
+	mux.RUnlock()
+
+	if rc != nil {
+		if err := rc.RunCommand(gatewayID, cmdArgs[0], cmdArgs[1:]); err != nil {
+			return "", nil, errors.Wrap(err, "run command on gateway error")
+		}
+
+		return spectralscan.StatusStarted, nil, nil
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id":             gatewayID,
+		"exec":                   cmdArgs[0],
+		"args":                   cmdArgs[1:],
+		"max_execution_duration": spectralScanMaxExecutionDuration,
+	}).Info("commands: executing spectral scan command")
+
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(spectralScanMaxExecutionDuration))
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...).Output()
+	if err != nil {
+		return "", nil, errors.Wrap(err, "execute command error")
+	}
+
+	measurements, err := spectralscan.ParseMeasurements(out)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "parse measurements error")
+	}
+
+	return spectralscan.StatusDone, measurements, nil
+}
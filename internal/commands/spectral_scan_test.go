@@ -0,0 +1,114 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
+	"github.com/brocaar/lorawan"
+)
+
+func TestRunSpectralScan(t *testing.T) {
+	tests := []struct {
+		Name                 string
+		Command              string
+		MaxExecutionDuration time.Duration
+
+		ExpectedStatus       string
+		ExpectedMeasurements []*spectralscan.Measurement
+		ExpectedError        error
+	}{
+		{
+			Name:          "not configured",
+			ExpectedError: errors.New("spectral scan command is not configured"),
+		},
+		{
+			Name:                 "local helper",
+			Command:              `sh -c 'echo {{ .FrequencyStart }},-120.5; echo {{ .FrequencyEnd }},-118'`,
+			MaxExecutionDuration: time.Second,
+			ExpectedStatus:       spectralscan.StatusDone,
+			ExpectedMeasurements: []*spectralscan.Measurement{
+				{Frequency: 868100000, Rssi: -120.5},
+				{Frequency: 868300000, Rssi: -118},
+			},
+		},
+		{
+			Name:                 "invalid output",
+			Command:              "echo foo",
+			MaxExecutionDuration: time.Second,
+			ExpectedError:        errors.New("parse measurements error: line 1: expected <frequency>,<rssi>"),
+		},
+		{
+			Name:                 "execution time expired",
+			Command:              "sleep 1",
+			MaxExecutionDuration: time.Millisecond,
+			ExpectedError:        errors.New("execute command error: signal: killed"),
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Commands.SpectralScan.Command = tst.Command
+			conf.Commands.SpectralScan.MaxExecutionDuration = tst.MaxExecutionDuration
+			assert.NoError(setupSpectralScan(conf))
+
+			status, measurements, err := runSpectralScan(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, spectralscan.Request{
+				FrequencyStart: 868100000,
+				FrequencyEnd:   868300000,
+				FrequencyStep:  200000,
+			})
+			if tst.ExpectedError != nil {
+				assert.EqualError(err, tst.ExpectedError.Error())
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedStatus, status)
+			assert.Equal(tst.ExpectedMeasurements, measurements)
+		})
+	}
+}
+
+type testRemoteCommander struct {
+	gatewayID lorawan.EUI64
+	command   string
+	args      []string
+}
+
+func (c *testRemoteCommander) RunCommand(gatewayID lorawan.EUI64, command string, args []string) error {
+	c.gatewayID = gatewayID
+	c.command = command
+	c.args = args
+	return nil
+}
+
+func TestRunSpectralScanRemote(t *testing.T) {
+	assert := require.New(t)
+
+	rc := testRemoteCommander{}
+	SetRemoteCommander(&rc)
+	defer SetRemoteCommander(nil)
+
+	var conf config.Config
+	conf.Commands.SpectralScan.Command = "spectral-scan -b {{ .FrequencyStart }}"
+	assert.NoError(setupSpectralScan(conf))
+
+	status, measurements, err := runSpectralScan(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, spectralscan.Request{
+		FrequencyStart: 868100000,
+	})
+	assert.NoError(err)
+	assert.Equal(spectralscan.StatusStarted, status)
+	assert.Len(measurements, 0)
+	assert.Equal(testRemoteCommander{
+		gatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		command:   "spectral-scan",
+		args:      []string{"-b", "868100000"},
+	}, rc)
+}
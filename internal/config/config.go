@@ -8,8 +8,23 @@ import (
 type Config struct {
 	General struct {
 		LogLevel int `mapstructure:"log_level"`
+
+		ErrorReporting struct {
+			Type        string        `mapstructure:"type"`
+			SentryDSN   string        `mapstructure:"sentry_dsn"`
+			WebhookURL  string        `mapstructure:"webhook_url"`
+			Environment string        `mapstructure:"environment"`
+			Timeout     time.Duration `mapstructure:"timeout"`
+		} `mapstructure:"error_reporting"`
 	}
 
+	Proxy struct {
+		URL      string   `mapstructure:"url"`
+		Username string   `mapstructure:"username"`
+		Password string   `mapstructure:"password"`
+		NoProxy  []string `mapstructure:"no_proxy"`
+	} `mapstructure:"proxy"`
+
 	Filters struct {
 		NetIDs   []string    `mapstructure:"net_ids"`
 		JoinEUIs [][2]string `mapstructure:"join_euis"`
@@ -18,26 +33,139 @@ type Config struct {
 	Backend struct {
 		Type string `mapstructure:"type"`
 
+		DownlinkIDs struct {
+			File string        `mapstructure:"file"`
+			TTL  time.Duration `mapstructure:"ttl"`
+		} `mapstructure:"downlink_ids"`
+
+		ConfigVersions struct {
+			Storage string `mapstructure:"storage"`
+			File    string `mapstructure:"file"`
+			Redis   struct {
+				URL string `mapstructure:"url"`
+				Key string `mapstructure:"key"`
+			} `mapstructure:"redis"`
+		} `mapstructure:"config_versions"`
+
+		RingBuffer struct {
+			Size          int    `mapstructure:"size"`
+			DumpDirectory string `mapstructure:"dump_directory"`
+		} `mapstructure:"ring_buffer"`
+
 		SemtechUDP struct {
-			UDPBind       string `mapstructure:"udp_bind"`
-			SkipCRCCheck  bool   `mapstructure:"skip_crc_check"`
-			FakeRxTime    bool   `mapstructure:"fake_rx_time"`
+			UDPBind             string        `mapstructure:"udp_bind"`
+			TCPBind             string        `mapstructure:"tcp_bind"`
+			TCPTLSCert          string        `mapstructure:"tcp_tls_cert"`
+			TCPTLSKey           string        `mapstructure:"tcp_tls_key"`
+			WebsocketBind       string        `mapstructure:"websocket_bind"`
+			WebsocketTLSCert    string        `mapstructure:"websocket_tls_cert"`
+			WebsocketTLSKey     string        `mapstructure:"websocket_tls_key"`
+			SkipCRCCheck        bool          `mapstructure:"skip_crc_check"`
+			FakeRxTime          bool          `mapstructure:"fake_rx_time"`
+			DownlinkMinLeadTime time.Duration `mapstructure:"downlink_min_lead_time"`
+			ConfigurationDryRun bool          `mapstructure:"configuration_dry_run"`
+			DownlinkInFlight    struct {
+				Max       int           `mapstructure:"max"`
+				QueueSize int           `mapstructure:"queue_size"`
+				Timeout   time.Duration `mapstructure:"timeout"`
+			} `mapstructure:"downlink_in_flight"`
+			SourceAddress struct {
+				Policy          string   `mapstructure:"policy"`
+				AllowedNetworks []string `mapstructure:"allowed_networks"`
+				DeniedNetworks  []string `mapstructure:"denied_networks"`
+			} `mapstructure:"source_address"`
+			Capture struct {
+				Enabled     bool   `mapstructure:"enabled"`
+				Directory   string `mapstructure:"directory"`
+				MaxFileSize int64  `mapstructure:"max_file_size"`
+			} `mapstructure:"capture"`
+			ConfigurationRollback struct {
+				Enabled bool          `mapstructure:"enabled"`
+				Timeout time.Duration `mapstructure:"timeout"`
+			} `mapstructure:"configuration_rollback"`
+			SessionAffinity struct {
+				Enabled          bool          `mapstructure:"enabled"`
+				Bind             string        `mapstructure:"bind"`
+				AdvertiseAddress string        `mapstructure:"advertise_address"`
+				RedisURL         string        `mapstructure:"redis_url"`
+				KeyPrefix        string        `mapstructure:"key_prefix"`
+				TTL              time.Duration `mapstructure:"ttl"`
+				Timeout          time.Duration `mapstructure:"timeout"`
+				CACert           string        `mapstructure:"ca_cert"`
+				TLSCert          string        `mapstructure:"tls_cert"`
+				TLSKey           string        `mapstructure:"tls_key"`
+				BearerToken      string        `mapstructure:"bearer_token"`
+			} `mapstructure:"session_affinity"`
+			DownlinkSizeGuard struct {
+				MaxSize int  `mapstructure:"max_size"`
+				Reject  bool `mapstructure:"reject"`
+			} `mapstructure:"downlink_size_guard"`
 			Configuration []struct {
 				GatewayID      string `mapstructure:"gateway_id"`
 				BaseFile       string `mapstructure:"base_file"`
 				OutputFile     string `mapstructure:"output_file"`
 				RestartCommand string `mapstructure:"restart_command"`
 			} `mapstructure:"configuration"`
+			ConfigurationTemplate struct {
+				BaseFile       string `mapstructure:"base_file"`
+				OutputFile     string `mapstructure:"output_file"`
+				RestartCommand string `mapstructure:"restart_command"`
+			} `mapstructure:"configuration_template"`
 		} `mapstructure:"semtech_udp"`
 
 		BasicStation struct {
-			Bind         string        `mapstructure:"bind"`
-			TLSCert      string        `mapstructure:"tls_cert"`
-			TLSKey       string        `mapstructure:"tls_key"`
-			CACert       string        `mapstructure:"ca_cert"`
-			PingInterval time.Duration `mapstructure:"ping_interval"`
-			ReadTimeout  time.Duration `mapstructure:"read_timeout"`
-			WriteTimeout time.Duration `mapstructure:"write_timeout"`
+			Bind                string        `mapstructure:"bind"`
+			TLSCert             string        `mapstructure:"tls_cert"`
+			TLSKey              string        `mapstructure:"tls_key"`
+			CACert              string        `mapstructure:"ca_cert"`
+			CertExpiryWarning   time.Duration `mapstructure:"cert_expiry_warning"`
+			PingInterval        time.Duration `mapstructure:"ping_interval"`
+			ReadTimeout         time.Duration `mapstructure:"read_timeout"`
+			WriteTimeout        time.Duration `mapstructure:"write_timeout"`
+			DownlinkMinLeadTime time.Duration `mapstructure:"downlink_min_lead_time"`
+			Shards              int           `mapstructure:"shards"`
+			Strict              bool          `mapstructure:"strict"`
+			DuplicateConnection string        `mapstructure:"duplicate_connection"`
+			ACME                struct {
+				Enabled        bool          `mapstructure:"enabled"`
+				Domains        []string      `mapstructure:"domains"`
+				Email          string        `mapstructure:"email"`
+				DirectoryURL   string        `mapstructure:"directory_url"`
+				Challenge      string        `mapstructure:"challenge"`
+				CacheDir       string        `mapstructure:"cache_dir"`
+				HTTPBind       string        `mapstructure:"http_bind"`
+				DNSHookCommand string        `mapstructure:"dns_hook_command"`
+				RenewBefore    time.Duration `mapstructure:"renew_before"`
+			} `mapstructure:"acme"`
+			Websocket struct {
+				PathPrefix     string   `mapstructure:"path_prefix"`
+				AllowedOrigins []string `mapstructure:"allowed_origins"`
+				AllowedHosts   []string `mapstructure:"allowed_hosts"`
+			} `mapstructure:"websocket"`
+			Uploads struct {
+				Directory string        `mapstructure:"directory"`
+				URL       string        `mapstructure:"url"`
+				Timeout   time.Duration `mapstructure:"timeout"`
+			} `mapstructure:"uploads"`
+			RegionalParameters struct {
+				File           string        `mapstructure:"file"`
+				ReloadInterval time.Duration `mapstructure:"reload_interval"`
+			} `mapstructure:"regional_parameters"`
+			RouterInfo struct {
+				Bind      string   `mapstructure:"bind"`
+				TLSCert   string   `mapstructure:"tls_cert"`
+				TLSKey    string   `mapstructure:"tls_key"`
+				CACert    string   `mapstructure:"ca_cert"`
+				URI       string   `mapstructure:"uri"`
+				Muxs      []string `mapstructure:"muxs"`
+				DNSSRV    string   `mapstructure:"dns_srv"`
+				Selection string   `mapstructure:"selection"`
+
+				Authorization struct {
+					URL     string        `mapstructure:"url"`
+					Timeout time.Duration `mapstructure:"timeout"`
+				} `mapstructure:"authorization"`
+			} `mapstructure:"router_info"`
 			// TODO: remove Filters in the next major release, use global filters instead
 			Filters struct {
 				NetIDs   []string    `mapstructure:"net_ids"`
@@ -48,29 +176,89 @@ type Config struct {
 			FrequencyMax  uint32                     `mapstructure:"frequency_max"`
 			Concentrators []BasicStationConcentrator `mapstructure:"concentrators"`
 		} `mapstructure:"basic_station"`
+
+		TTNConnector struct {
+			Server               string        `mapstructure:"server"`
+			Username             string        `mapstructure:"username"`
+			Password             string        `mapstructure:"password"`
+			CACert               string        `mapstructure:"ca_cert"`
+			TLSCert              string        `mapstructure:"tls_cert"`
+			TLSKey               string        `mapstructure:"tls_key"`
+			QOS                  uint8         `mapstructure:"qos"`
+			ClientID             string        `mapstructure:"client_id"`
+			MaxReconnectInterval time.Duration `mapstructure:"max_reconnect_interval"`
+		} `mapstructure:"ttn_connector"`
+
+		Native struct {
+			GatewayID       string        `mapstructure:"gateway_id"`
+			HALCommand      string        `mapstructure:"hal_command"`
+			RestartInterval time.Duration `mapstructure:"restart_interval"`
+			SkipCRCCheck    bool          `mapstructure:"skip_crc_check"`
+			FakeRxTime      bool          `mapstructure:"fake_rx_time"`
+		} `mapstructure:"native"`
 	} `mapstructure:"backend"`
 
 	Integration struct {
-		Marshaler string `mapstructure:"marshaler"`
+		Type                  string            `mapstructure:"type"`
+		Marshaler             string            `mapstructure:"marshaler"`
+		EventMarshalers       map[string]string `mapstructure:"event_marshalers"`
+		DownlinkMaxAge        time.Duration     `mapstructure:"downlink_max_age"`
+		PublishDownlinkTiming bool              `mapstructure:"publish_downlink_timing"`
+
+		TopicVariables struct {
+			BridgeID string `mapstructure:"bridge_id"`
+			Region   string `mapstructure:"region"`
+			Tenant   string `mapstructure:"tenant"`
+			Band     string `mapstructure:"band"`
+		} `mapstructure:"topic_variables"`
 
 		MQTT struct {
-			EventTopicTemplate   string        `mapstructure:"event_topic_template"`
-			CommandTopicTemplate string        `mapstructure:"command_topic_template"`
-			MaxReconnectInterval time.Duration `mapstructure:"max_reconnect_interval"`
+			EventTopicTemplate      string        `mapstructure:"event_topic_template"`
+			CommandTopicTemplate    string        `mapstructure:"command_topic_template"`
+			Compatibility           string        `mapstructure:"compatibility"`
+			SharedCommandTopic      string        `mapstructure:"shared_command_topic"`
+			SharedSubscriptionGroup string        `mapstructure:"shared_subscription_group"`
+			MaxReconnectInterval    time.Duration `mapstructure:"max_reconnect_interval"`
+			ReconcileInterval       time.Duration `mapstructure:"reconcile_interval"`
+
+			BridgeCommandTopic          string `mapstructure:"bridge_command_topic"`
+			BridgeResponseTopicTemplate string `mapstructure:"bridge_response_topic_template"`
+			RawUplinkTopicTemplate      string `mapstructure:"raw_uplink_topic_template"`
+
+			Tenants []MQTTTenant `mapstructure:"tenants"`
+
+			EventQOS struct {
+				Up    uint8 `mapstructure:"up"`
+				Stats uint8 `mapstructure:"stats"`
+				Ack   uint8 `mapstructure:"ack"`
+				Exec  uint8 `mapstructure:"exec"`
+			} `mapstructure:"event_qos"`
+
+			PublishRetry struct {
+				MaxRetries      int           `mapstructure:"max_retries"`
+				InitialInterval time.Duration `mapstructure:"initial_interval"`
+				MaxInterval     time.Duration `mapstructure:"max_interval"`
+				DeadLetterFile  string        `mapstructure:"dead_letter_file"`
+				DeadLetterTopic string        `mapstructure:"dead_letter_topic"`
+			} `mapstructure:"publish_retry"`
 
 			Auth struct {
 				Type string `mapstructure:"type"`
 
 				Generic struct {
-					Server       string `mapstructure:"server"`
-					Username     string `mapstructure:"username"`
-					Password     string `mapstrucure:"password"`
-					CACert       string `mapstructure:"ca_cert"`
-					TLSCert      string `mapstructure:"tls_cert"`
-					TLSKey       string `mapstructure:"tls_key"`
-					QOS          uint8  `mapstructure:"qos"`
-					CleanSession bool   `mapstructure:"clean_session"`
-					ClientID     string `mapstructure:"client_id"`
+					Server             string        `mapstructure:"server"`
+					Servers            []string      `mapstructure:"servers"`
+					ServerSelection    string        `mapstructure:"server_selection"`
+					HealthCheckTimeout time.Duration `mapstructure:"health_check_timeout"`
+					FailoverTimeout    time.Duration `mapstructure:"failover_timeout"`
+					Username           string        `mapstructure:"username"`
+					Password           string        `mapstrucure:"password"`
+					CACert             string        `mapstructure:"ca_cert"`
+					TLSCert            string        `mapstructure:"tls_cert"`
+					TLSKey             string        `mapstructure:"tls_key"`
+					QOS                uint8         `mapstructure:"qos"`
+					CleanSession       bool          `mapstructure:"clean_session"`
+					ClientID           string        `mapstructure:"client_id"`
 				} `mapstructure:"generic"`
 
 				GCPCloudIoTCore struct {
@@ -94,12 +282,47 @@ type Config struct {
 				} `mapstructure:"azure_iot_hub"`
 			} `mapstructure:"auth"`
 		} `mapstructure:"mqtt"`
+
+		AMQP struct {
+			URL                  string `mapstructure:"url"`
+			EventAddressTemplate string `mapstructure:"event_address_template"`
+			CommandAddress       string `mapstructure:"command_address"`
+		} `mapstructure:"amqp"`
 	} `mapstructure:"integration"`
 
+	Admin struct {
+		Bind        string `mapstructure:"bind"`
+		TLSCert     string `mapstructure:"tls_cert"`
+		TLSKey      string `mapstructure:"tls_key"`
+		BearerToken string `mapstructure:"bearer_token"`
+		JWTSecret   string `mapstructure:"jwt_secret"`
+	} `mapstructure:"admin"`
+
+	SelfUpdate struct {
+		Enabled         bool          `mapstructure:"enabled"`
+		PublicKeyFile   string        `mapstructure:"public_key_file"`
+		DownloadTimeout time.Duration `mapstructure:"download_timeout"`
+	} `mapstructure:"self_update"`
+
 	Metrics struct {
 		Prometheus struct {
 			EndpointEnabled bool   `mapstructure:"endpoint_enabled"`
 			Bind            string `mapstructure:"bind"`
+			TLSCert         string `mapstructure:"tls_cert"`
+			TLSKey          string `mapstructure:"tls_key"`
+			BearerToken     string `mapstructure:"bearer_token"`
+			JWTSecret       string `mapstructure:"jwt_secret"`
+
+			Push struct {
+				Type        string        `mapstructure:"type"`
+				URL         string        `mapstructure:"url"`
+				Interval    time.Duration `mapstructure:"interval"`
+				Job         string        `mapstructure:"job"`
+				Instance    string        `mapstructure:"instance"`
+				Username    string        `mapstructure:"username"`
+				Password    string        `mapstructure:"password"`
+				BearerToken string        `mapstructure:"bearer_token"`
+			} `mapstructure:"push"`
 		}
 	}
 
@@ -110,6 +333,12 @@ type Config struct {
 			MaxExecutionDuration time.Duration     `mapstructure:"max_execution_duration"`
 			Commands             map[string]string `mapstructure:"commands"`
 		} `mapstructure:"dynamic"`
+		HTTP struct {
+			Interval      time.Duration `mapstructure:"interval"`
+			Timeout       time.Duration `mapstructure:"timeout"`
+			CacheDuration time.Duration `mapstructure:"cache_duration"`
+			URLs          []string      `mapstructure:"urls"`
+		} `mapstructure:"http"`
 	} `mapstructure:"meta_data"`
 
 	Commands struct {
@@ -117,7 +346,290 @@ type Config struct {
 			MaxExecutionDuration time.Duration `mapstructure:"max_execution_duration"`
 			Command              string        `mapstructure:"command"`
 		} `mapstructure:"commands"`
+		SpectralScan struct {
+			MaxExecutionDuration time.Duration `mapstructure:"max_execution_duration"`
+			Command              string        `mapstructure:"command"`
+		} `mapstructure:"spectral_scan"`
 	} `mapstructure:"commands"`
+
+	Keepalive struct {
+		Enabled              bool          `mapstructure:"enabled"`
+		Interval             time.Duration `mapstructure:"interval"`
+		Missed               int           `mapstructure:"missed"`
+		AlertCommand         string        `mapstructure:"alert_command"`
+		AlertWebhook         string        `mapstructure:"alert_webhook"`
+		MaxExecutionDuration time.Duration `mapstructure:"max_execution_duration"`
+	} `mapstructure:"keepalive"`
+
+	Accounting struct {
+		Enabled             bool          `mapstructure:"enabled"`
+		Window              time.Duration `mapstructure:"window"`
+		MaxUplinksPerMinute int           `mapstructure:"max_uplinks_per_minute"`
+	} `mapstructure:"accounting"`
+
+	StatsHistory struct {
+		Enabled    bool          `mapstructure:"enabled"`
+		Window     time.Duration `mapstructure:"window"`
+		Resolution time.Duration `mapstructure:"resolution"`
+	} `mapstructure:"stats_history"`
+
+	Beacon struct {
+		Enabled    bool   `mapstructure:"enabled"`
+		Region     string `mapstructure:"region"`
+		Power      int    `mapstructure:"power"`
+		InfoDesc   int    `mapstructure:"info_desc"`
+		TimeSource struct {
+			Type string `mapstructure:"type"`
+			GPSD struct {
+				Server  string        `mapstructure:"server"`
+				Timeout time.Duration `mapstructure:"timeout"`
+			} `mapstructure:"gpsd"`
+		} `mapstructure:"time_source"`
+	} `mapstructure:"beacon"`
+
+	FrequencyCheck struct {
+		Enabled       bool          `mapstructure:"enabled"`
+		Region        string        `mapstructure:"region"`
+		FrequencyMin  uint32        `mapstructure:"frequency_min"`
+		FrequencyMax  uint32        `mapstructure:"frequency_max"`
+		EventInterval time.Duration `mapstructure:"event_interval"`
+	} `mapstructure:"frequency_check"`
+
+	ConfigDrift struct {
+		Enabled       bool          `mapstructure:"enabled"`
+		EventInterval time.Duration `mapstructure:"event_interval"`
+	} `mapstructure:"config_drift"`
+
+	Allowlist struct {
+		Mode string `mapstructure:"mode"`
+		File string `mapstructure:"file"`
+	} `mapstructure:"allowlist"`
+
+	Quarantine struct {
+		Enabled   bool          `mapstructure:"enabled"`
+		MaxErrors int           `mapstructure:"max_errors"`
+		Window    time.Duration `mapstructure:"window"`
+		Cooldown  time.Duration `mapstructure:"cooldown"`
+	} `mapstructure:"quarantine"`
+
+	Overload struct {
+		Enabled         bool          `mapstructure:"enabled"`
+		HighWatermark   int           `mapstructure:"high_watermark"`
+		LowWatermark    int           `mapstructure:"low_watermark"`
+		DropProprietary bool          `mapstructure:"drop_proprietary"`
+		MinSNR          float64       `mapstructure:"min_snr"`
+		RetryAfter      time.Duration `mapstructure:"retry_after"`
+	} `mapstructure:"overload"`
+
+	RXTime struct {
+		Source   string        `mapstructure:"source"`
+		MaxDrift time.Duration `mapstructure:"max_drift"`
+		Gateways []struct {
+			GatewayID string `mapstructure:"gateway_id"`
+			Source    string `mapstructure:"source"`
+		} `mapstructure:"gateways"`
+	} `mapstructure:"rx_time"`
+
+	Pipeline struct {
+		Middlewares []string      `mapstructure:"middlewares"`
+		DedupWindow time.Duration `mapstructure:"dedup_window"`
+	} `mapstructure:"pipeline"`
+
+	GatewayStats struct {
+		CounterMode string `mapstructure:"counter_mode"`
+		Gateways    []struct {
+			GatewayID   string `mapstructure:"gateway_id"`
+			CounterMode string `mapstructure:"counter_mode"`
+		} `mapstructure:"gateways"`
+	} `mapstructure:"gateway_stats"`
+
+	GPSTime struct {
+		Enabled      bool          `mapstructure:"enabled"`
+		MaxAge       time.Duration `mapstructure:"max_age"`
+		ChronySocket string        `mapstructure:"chrony_socket"`
+	} `mapstructure:"gps_time"`
+
+	PreRegistration struct {
+		GatewayIDs []string `mapstructure:"gateway_ids"`
+	} `mapstructure:"pre_registration"`
+
+	UplinkSet struct {
+		Enabled     bool          `mapstructure:"enabled"`
+		Window      time.Duration `mapstructure:"window"`
+		MinGateways int           `mapstructure:"min_gateways"`
+	} `mapstructure:"uplink_set"`
+
+	DownlinkSwitch struct {
+		DisableAll         bool     `mapstructure:"disable_all"`
+		DisabledGatewayIDs []string `mapstructure:"disabled_gateway_ids"`
+	} `mapstructure:"downlink_switch"`
+
+	DownlinkPolicy struct {
+		Enabled         bool   `mapstructure:"enabled"`
+		Region          string `mapstructure:"region"`
+		MaxTXPower      int    `mapstructure:"max_tx_power"`
+		TXPowerPolicy   string `mapstructure:"tx_power_policy"`
+		FrequencyPolicy string `mapstructure:"frequency_policy"`
+	} `mapstructure:"downlink_policy"`
+
+	Multicast struct {
+		AckTimeout time.Duration `mapstructure:"ack_timeout"`
+		Groups     []struct {
+			Name       string   `mapstructure:"name"`
+			GatewayIDs []string `mapstructure:"gateway_ids"`
+		} `mapstructure:"groups"`
+
+		Scheduler struct {
+			Enabled         bool          `mapstructure:"enabled"`
+			StaggerInterval time.Duration `mapstructure:"stagger_interval"`
+			MaxDutyCycle    float64       `mapstructure:"max_duty_cycle"`
+			DutyCycleWindow time.Duration `mapstructure:"duty_cycle_window"`
+			Sites           []struct {
+				Name       string   `mapstructure:"name"`
+				GatewayIDs []string `mapstructure:"gateway_ids"`
+			} `mapstructure:"sites"`
+		} `mapstructure:"scheduler"`
+	} `mapstructure:"multicast"`
+
+	BackhaulLatency struct {
+		Enabled bool          `mapstructure:"enabled"`
+		Margin  time.Duration `mapstructure:"margin"`
+		MaxAge  time.Duration `mapstructure:"max_age"`
+	} `mapstructure:"backhaul_latency"`
+
+	Reorder struct {
+		Enabled bool          `mapstructure:"enabled"`
+		Window  time.Duration `mapstructure:"window"`
+	} `mapstructure:"reorder"`
+
+	ConfigurationFanOut struct {
+		MaxConcurrency int `mapstructure:"max_concurrency"`
+		Groups         []struct {
+			Name       string   `mapstructure:"name"`
+			GatewayIDs []string `mapstructure:"gateway_ids"`
+		} `mapstructure:"groups"`
+	} `mapstructure:"configuration_fan_out"`
+
+	TestDownlink struct {
+		Enabled    bool          `mapstructure:"enabled"`
+		Region     string        `mapstructure:"region"`
+		Power      int           `mapstructure:"power"`
+		AckTimeout time.Duration `mapstructure:"ack_timeout"`
+	} `mapstructure:"test_downlink"`
+
+	PacketForwarderLog struct {
+		Path             string        `mapstructure:"path"`
+		GatewayID        string        `mapstructure:"gateway_id"`
+		PollInterval     time.Duration `mapstructure:"poll_interval"`
+		SuppressInterval time.Duration `mapstructure:"suppress_interval"`
+	} `mapstructure:"packet_forwarder_log"`
+
+	Privacy struct {
+		Enabled            bool          `mapstructure:"enabled"`
+		GatewayIDs         []string      `mapstructure:"gateway_ids"`
+		StripIP            bool          `mapstructure:"strip_ip"`
+		StripLocation      bool          `mapstructure:"strip_location"`
+		LocationDecimals   int           `mapstructure:"location_decimals"`
+		StripFineTimestamp bool          `mapstructure:"strip_fine_timestamp"`
+		TimeTruncate       time.Duration `mapstructure:"time_truncate"`
+	} `mapstructure:"privacy"`
+
+	Locations []struct {
+		GatewayID string  `mapstructure:"gateway_id"`
+		Latitude  float64 `mapstructure:"latitude"`
+		Longitude float64 `mapstructure:"longitude"`
+		Altitude  float64 `mapstructure:"altitude"`
+	} `mapstructure:"locations"`
+
+	AntennaMap struct {
+		Gateways []AntennaMapGateway `mapstructure:"gateways"`
+	} `mapstructure:"antenna_map"`
+
+	AntennaGain struct {
+		Gateways []AntennaGainGateway `mapstructure:"gateways"`
+	} `mapstructure:"antenna_gain"`
+
+	Overlay struct {
+		Rules []OverlayRule `mapstructure:"rules"`
+	} `mapstructure:"overlay"`
+
+	Sampling struct {
+		Rules []SamplingRule `mapstructure:"rules"`
+	} `mapstructure:"sampling"`
+
+	Archive struct {
+		Enabled   bool          `mapstructure:"enabled"`
+		Path      string        `mapstructure:"path"`
+		Retention time.Duration `mapstructure:"retention"`
+	} `mapstructure:"archive"`
+
+	Status struct {
+		Enabled  bool          `mapstructure:"enabled"`
+		Interval time.Duration `mapstructure:"interval"`
+		Sinks    []StatusSink  `mapstructure:"sinks"`
+	} `mapstructure:"status"`
+}
+
+// AntennaMapGateway holds the RF chain / channel mapping of a gateway.
+type AntennaMapGateway struct {
+	GatewayID string              `mapstructure:"gateway_id"`
+	Channels  []AntennaMapChannel `mapstructure:"channels"`
+}
+
+// AntennaMapChannel maps a RF chain and channel to a board and antenna.
+type AntennaMapChannel struct {
+	RFChain uint32 `mapstructure:"rf_chain"`
+	Channel uint32 `mapstructure:"channel"`
+	Board   uint32 `mapstructure:"board"`
+	Antenna uint32 `mapstructure:"antenna"`
+}
+
+// AntennaGainGateway holds the antenna gain and cable loss of a gateway.
+type AntennaGainGateway struct {
+	GatewayID   string  `mapstructure:"gateway_id"`
+	AntennaGain float64 `mapstructure:"antenna_gain"`
+	CableLoss   float64 `mapstructure:"cable_loss"`
+}
+
+// OverlayRule holds a field override of the published events. The value is
+// JSON encoded.
+type OverlayRule struct {
+	GatewayID string `mapstructure:"gateway_id"`
+	Event     string `mapstructure:"event"`
+	Field     string `mapstructure:"field"`
+	Value     string `mapstructure:"value"`
+}
+
+// SamplingRule holds the sampling rate (1 in Rate) of an event type. For
+// uplinks, the rule can be limited to the given NetIDs.
+type SamplingRule struct {
+	Event  string   `mapstructure:"event"`
+	NetIDs []string `mapstructure:"net_ids"`
+	Rate   int      `mapstructure:"rate"`
+}
+
+// MQTTTenant holds the MQTT credentials and topic prefix of a tenant (gateway
+// group).
+type MQTTTenant struct {
+	Name        string   `mapstructure:"name"`
+	GatewayIDs  []string `mapstructure:"gateway_ids"`
+	Username    string   `mapstructure:"username"`
+	Password    string   `mapstructure:"password"`
+	ClientID    string   `mapstructure:"client_id"`
+	TopicPrefix string   `mapstructure:"topic_prefix"`
+}
+
+// StatusSink holds the configuration of a status sink, to which the
+// connection-state changes are emitted.
+type StatusSink struct {
+	Type       string `mapstructure:"type"`
+	State      string `mapstructure:"state"`
+	Path       string `mapstructure:"path"`
+	ActiveLow  bool   `mapstructure:"active_low"`
+	Command    string `mapstructure:"command"`
+	Bus        string `mapstructure:"bus"`
+	ObjectPath string `mapstructure:"object_path"`
+	Interface  string `mapstructure:"interface"`
 }
 
 // BasicStationConcentrator holds the configuration for a BasicStation concentrator.
@@ -0,0 +1,250 @@
+// Package configcheck implements the validation of the configuration, as used
+// by the check command. Unlike the setup of the individual components, all
+// checks are executed (so that all errors are reported at once) and no
+// listeners or connections are opened.
+package configcheck
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/topic"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+// Check statuses.
+const (
+	StatusOK    = "ok"
+	StatusError = "error"
+)
+
+// Result contains the result of a single check.
+type Result struct {
+	Section string `json:"section"`
+	Check   string `json:"check"`
+	Status  string `json:"status"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Report contains the results of all checks.
+type Report struct {
+	Results []Result `json:"results"`
+	Errors  int      `json:"errors"`
+}
+
+func (r *Report) add(section, check string, err error) {
+	res := Result{
+		Section: section,
+		Check:   check,
+		Status:  StatusOK,
+	}
+
+	if err != nil {
+		res.Status = StatusError
+		res.Error = err.Error()
+		r.Errors++
+	}
+
+	r.Results = append(r.Results, res)
+}
+
+// Check validates the given configuration and returns the report.
+func Check(conf config.Config) Report {
+	var r Report
+
+	checkBackend(&r, conf)
+	checkIntegration(&r, conf)
+	checkFilters(&r, conf)
+	checkRegions(&r, conf)
+
+	if conf.Admin.Bind != "" {
+		r.add("admin", "bind", resolveTCP(conf.Admin.Bind))
+		checkKeyPair(&r, "admin", conf.Admin.TLSCert, conf.Admin.TLSKey)
+	}
+
+	if c := conf.Metrics.Prometheus; c.EndpointEnabled {
+		r.add("metrics.prometheus", "bind", resolveTCP(c.Bind))
+		checkKeyPair(&r, "metrics.prometheus", c.TLSCert, c.TLSKey)
+	}
+
+	return r
+}
+
+func checkBackend(r *Report, conf config.Config) {
+	switch conf.Backend.Type {
+	case "semtech_udp":
+		c := conf.Backend.SemtechUDP
+		r.add("backend.semtech_udp", "udp_bind", resolveUDP(c.UDPBind))
+		if c.TCPBind != "" {
+			r.add("backend.semtech_udp", "tcp_bind", resolveTCP(c.TCPBind))
+			checkKeyPair(r, "backend.semtech_udp", c.TCPTLSCert, c.TCPTLSKey)
+		}
+		if c.WebsocketBind != "" {
+			r.add("backend.semtech_udp", "websocket_bind", resolveTCP(c.WebsocketBind))
+			checkKeyPair(r, "backend.semtech_udp", c.WebsocketTLSCert, c.WebsocketTLSKey)
+		}
+	case "basic_station":
+		c := conf.Backend.BasicStation
+		r.add("backend.basic_station", "bind", resolveTCP(c.Bind))
+		if c.ACME.Enabled {
+			r.add("backend.basic_station.acme", "http_bind", resolveTCP(c.ACME.HTTPBind))
+		} else {
+			checkKeyPair(r, "backend.basic_station", c.TLSCert, c.TLSKey)
+		}
+		checkCACert(r, "backend.basic_station", c.CACert)
+
+		if c.RouterInfo.Bind != "" {
+			r.add("backend.basic_station.router_info", "bind", resolveTCP(c.RouterInfo.Bind))
+			checkKeyPair(r, "backend.basic_station.router_info", c.RouterInfo.TLSCert, c.RouterInfo.TLSKey)
+			checkCACert(r, "backend.basic_station.router_info", c.RouterInfo.CACert)
+		}
+	case "ttn_connector":
+		c := conf.Backend.TTNConnector
+		checkKeyPair(r, "backend.ttn_connector", c.TLSCert, c.TLSKey)
+		checkCACert(r, "backend.ttn_connector", c.CACert)
+	case "native":
+		var gatewayID lorawan.EUI64
+		r.add("backend.native", "gateway_id", gatewayID.UnmarshalText([]byte(conf.Backend.Native.GatewayID)))
+	default:
+		r.add("backend", "type", fmt.Errorf("unknown backend type: %s", conf.Backend.Type))
+	}
+}
+
+func checkIntegration(r *Report, conf config.Config) {
+	switch conf.Integration.Type {
+	case "mqtt":
+		c := conf.Integration.MQTT
+		for _, t := range []struct {
+			name string
+			text string
+		}{
+			{"event_topic_template", c.EventTopicTemplate},
+			{"command_topic_template", c.CommandTopicTemplate},
+			{"bridge_response_topic_template", c.BridgeResponseTopicTemplate},
+			{"raw_uplink_topic_template", c.RawUplinkTopicTemplate},
+		} {
+			_, err := topic.Parse(t.name, t.text)
+			r.add("integration.mqtt", t.name, errors.Wrap(err, "parse template error"))
+		}
+
+		switch c.Auth.Type {
+		case "generic":
+			checkKeyPair(r, "integration.mqtt.auth.generic", c.Auth.Generic.TLSCert, c.Auth.Generic.TLSKey)
+			checkCACert(r, "integration.mqtt.auth.generic", c.Auth.Generic.CACert)
+		case "gcp_cloud_iot_core":
+			_, err := ioutil.ReadFile(c.Auth.GCPCloudIoTCore.JWTKeyFile)
+			r.add("integration.mqtt.auth.gcp_cloud_iot_core", "jwt_key_file", errors.Wrap(err, "read file error"))
+		case "azure_iot_hub":
+			checkKeyPair(r, "integration.mqtt.auth.azure_iot_hub", c.Auth.AzureIoTHub.TLSCert, c.Auth.AzureIoTHub.TLSKey)
+		default:
+			r.add("integration.mqtt.auth", "type", fmt.Errorf("unknown auth type: %s", c.Auth.Type))
+		}
+	case "amqp":
+		_, err := topic.Parse("event_address_template", conf.Integration.AMQP.EventAddressTemplate)
+		r.add("integration.amqp", "event_address_template", errors.Wrap(err, "parse template error"))
+	default:
+		r.add("integration", "type", fmt.Errorf("unknown integration type: %s", conf.Integration.Type))
+	}
+}
+
+func checkFilters(r *Report, conf config.Config) {
+	for _, s := range conf.Filters.NetIDs {
+		r.add("filters", "net_ids", checkNetID(s))
+	}
+	for _, set := range conf.Filters.JoinEUIs {
+		r.add("filters", "join_euis", checkJoinEUIRange(set))
+	}
+
+	for _, s := range conf.Backend.BasicStation.Filters.NetIDs {
+		r.add("backend.basic_station.filters", "net_ids", checkNetID(s))
+	}
+	for _, set := range conf.Backend.BasicStation.Filters.JoinEUIs {
+		r.add("backend.basic_station.filters", "join_euis", checkJoinEUIRange(set))
+	}
+
+	for _, rule := range conf.Sampling.Rules {
+		for _, s := range rule.NetIDs {
+			r.add("sampling.rules", "net_ids", checkNetID(s))
+		}
+	}
+}
+
+func checkRegions(r *Report, conf config.Config) {
+	if conf.Backend.Type == "basic_station" {
+		r.add("backend.basic_station", "region", checkRegion(conf.Backend.BasicStation.Region))
+	}
+	if conf.Beacon.Enabled {
+		r.add("beacon", "region", checkRegion(conf.Beacon.Region))
+	}
+	if conf.FrequencyCheck.Enabled {
+		r.add("frequency_check", "region", checkRegion(conf.FrequencyCheck.Region))
+	}
+	if conf.DownlinkPolicy.Enabled {
+		r.add("downlink_policy", "region", checkRegion(conf.DownlinkPolicy.Region))
+	}
+}
+
+// checkKeyPair adds the tls_cert / tls_key check when one of these is set.
+func checkKeyPair(r *Report, section, certFile, keyFile string) {
+	if certFile == "" && keyFile == "" {
+		return
+	}
+
+	_, err := tls.LoadX509KeyPair(certFile, keyFile)
+	r.add(section, "tls_cert", errors.Wrap(err, "load tls key-pair error"))
+}
+
+// checkCACert adds the ca_cert check when it is set.
+func checkCACert(r *Report, section, caCert string) {
+	if caCert == "" {
+		return
+	}
+
+	r.add(section, "ca_cert", func() error {
+		b, err := ioutil.ReadFile(caCert)
+		if err != nil {
+			return errors.Wrap(err, "read ca certificate error")
+		}
+		if !x509.NewCertPool().AppendCertsFromPEM(b) {
+			return errors.New("ca certificate does not contain any PEM encoded certificate")
+		}
+		return nil
+	}())
+}
+
+func resolveUDP(bind string) error {
+	_, err := net.ResolveUDPAddr("udp", bind)
+	return errors.Wrap(err, "resolve udp address error")
+}
+
+func resolveTCP(bind string) error {
+	_, err := net.ResolveTCPAddr("tcp", bind)
+	return errors.Wrap(err, "resolve tcp address error")
+}
+
+func checkRegion(region string) error {
+	_, err := band.GetConfig(band.Name(region), false, lorawan.DwellTimeNoLimit)
+	return errors.Wrap(err, "get band config error")
+}
+
+func checkNetID(s string) error {
+	var netID lorawan.NetID
+	return errors.Wrap(netID.UnmarshalText([]byte(s)), "unmarshal NetID error")
+}
+
+func checkJoinEUIRange(set [2]string) error {
+	for _, s := range set {
+		var joinEUI lorawan.EUI64
+		if err := joinEUI.UnmarshalText([]byte(s)); err != nil {
+			return errors.Wrap(err, "unmarshal JoinEUI error")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package configcheck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func TestCheck(t *testing.T) {
+	validConfig := func() config.Config {
+		var conf config.Config
+		conf.Backend.Type = "semtech_udp"
+		conf.Backend.SemtechUDP.UDPBind = "0.0.0.0:1700"
+		conf.Integration.Type = "mqtt"
+		conf.Integration.MQTT.EventTopicTemplate = "gateway/{{ .GatewayID }}/event/{{ .EventType }}"
+		conf.Integration.MQTT.CommandTopicTemplate = "gateway/{{ .GatewayID }}/command/#"
+		conf.Integration.MQTT.Auth.Type = "generic"
+		return conf
+	}
+
+	tests := []struct {
+		Name   string
+		Config func() config.Config
+		Errors []Result
+	}{
+		{
+			Name:   "valid",
+			Config: validConfig,
+		},
+		{
+			Name: "invalid topic template",
+			Config: func() config.Config {
+				conf := validConfig()
+				conf.Integration.MQTT.EventTopicTemplate = "gateway/{{ .GatewayID"
+				return conf
+			},
+			Errors: []Result{
+				{Section: "integration.mqtt", Check: "event_topic_template"},
+			},
+		},
+		{
+			Name: "invalid bind",
+			Config: func() config.Config {
+				conf := validConfig()
+				conf.Backend.SemtechUDP.UDPBind = "0.0.0.0:foo"
+				return conf
+			},
+			Errors: []Result{
+				{Section: "backend.semtech_udp", Check: "udp_bind"},
+			},
+		},
+		{
+			Name: "missing tls material",
+			Config: func() config.Config {
+				conf := validConfig()
+				conf.Integration.MQTT.Auth.Generic.CACert = "/does/not/exist/ca.pem"
+				conf.Integration.MQTT.Auth.Generic.TLSCert = "/does/not/exist/cert.pem"
+				conf.Integration.MQTT.Auth.Generic.TLSKey = "/does/not/exist/key.pem"
+				return conf
+			},
+			Errors: []Result{
+				{Section: "integration.mqtt.auth.generic", Check: "tls_cert"},
+				{Section: "integration.mqtt.auth.generic", Check: "ca_cert"},
+			},
+		},
+		{
+			Name: "invalid region",
+			Config: func() config.Config {
+				conf := validConfig()
+				conf.Backend.Type = "basic_station"
+				conf.Backend.BasicStation.Bind = ":3001"
+				conf.Backend.BasicStation.Region = "EU868"
+				conf.Beacon.Enabled = true
+				conf.Beacon.Region = "FOO"
+				return conf
+			},
+			Errors: []Result{
+				{Section: "beacon", Check: "region"},
+			},
+		},
+		{
+			Name: "invalid filters",
+			Config: func() config.Config {
+				conf := validConfig()
+				conf.Filters.NetIDs = []string{"000000", "foo"}
+				conf.Filters.JoinEUIs = [][2]string{{"0000000000000000", "bar"}}
+				return conf
+			},
+			Errors: []Result{
+				{Section: "filters", Check: "net_ids"},
+				{Section: "filters", Check: "join_euis"},
+			},
+		},
+		{
+			Name: "unknown types",
+			Config: func() config.Config {
+				conf := validConfig()
+				conf.Backend.Type = "foo"
+				conf.Integration.Type = "bar"
+				return conf
+			},
+			Errors: []Result{
+				{Section: "backend", Check: "type"},
+				{Section: "integration", Check: "type"},
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			report := Check(tst.Config())
+			assert.Equal(len(tst.Errors), report.Errors)
+
+			var errs []Result
+			for _, res := range report.Results {
+				if res.Status == StatusError {
+					assert.NotEqual("", res.Error)
+					errs = append(errs, Result{Section: res.Section, Check: res.Check})
+				}
+			}
+			assert.Equal(tst.Errors, errs)
+		})
+	}
+}
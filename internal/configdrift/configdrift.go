@@ -0,0 +1,185 @@
+// Package configdrift detects uplinks received on a frequency which is not a
+// channel of the gateway configuration last applied to the gateway. This
+// indicates a failed configuration apply or a manual override of the
+// channel-plan on the gateway. The drift is counted per gateway and reported
+// using a metric and the notify event (code CONFIG_DRIFT).
+package configdrift
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// notifyCode is the code of the published notify events.
+const notifyCode = "CONFIG_DRIFT"
+
+// plan contains the channel-plan of the applied gateway configuration.
+type plan struct {
+	version     string
+	frequencies map[uint32]struct{}
+}
+
+type gateway struct {
+	// lastEvent contains the time of the last reported drift, pending the
+	// number of drifted uplinks since.
+	lastEvent time.Time
+	pending   int
+}
+
+// drift contains a channel-plan drift to report.
+type drift struct {
+	gatewayID lorawan.EUI64
+	frequency uint32
+	version   string
+	count     int
+}
+
+var (
+	mux sync.RWMutex
+
+	enabled       bool
+	eventInterval time.Duration
+
+	plans    = make(map[lorawan.EUI64]plan)
+	gateways = make(map[lorawan.EUI64]*gateway)
+)
+
+// Setup configures the channel-plan drift detection.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	enabled = conf.ConfigDrift.Enabled
+	eventInterval = conf.ConfigDrift.EventInterval
+
+	if enabled {
+		log.WithField("event_interval", eventInterval).Info("configdrift: channel-plan drift detection enabled")
+	}
+
+	return nil
+}
+
+// SetConfiguration sets the gateway configuration applied to the gateway.
+// This is called by the backends, also for the configurations loaded from
+// the configuration version storage.
+func SetConfiguration(gwConfig gw.GatewayConfiguration) {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], gwConfig.GetGatewayId())
+
+	p := plan{
+		version:     gwConfig.Version,
+		frequencies: make(map[uint32]struct{}),
+	}
+	for _, c := range gwConfig.GetChannels() {
+		p.frequencies[c.GetFrequency()] = struct{}{}
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	plans[gatewayID] = p
+	delete(gateways, gatewayID)
+}
+
+// Uplink checks the frequency of an uplink received by the given gateway
+// against the channel-plan of the applied configuration. Gateways without
+// applied configuration are not checked.
+func Uplink(gatewayID lorawan.EUI64, frequency uint32) {
+	if d := uplink(gatewayID, frequency, time.Now()); d != nil {
+		go report(*d)
+	}
+}
+
+// uplink registers the uplink frequency. It returns the drift to report or
+// nil when there is nothing to report (yet).
+func uplink(gatewayID lorawan.EUI64, frequency uint32, now time.Time) *drift {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return nil
+	}
+
+	p, ok := plans[gatewayID]
+	if !ok || len(p.frequencies) == 0 {
+		return nil
+	}
+
+	if _, ok := p.frequencies[frequency]; ok {
+		return nil
+	}
+
+	gw, ok := gateways[gatewayID]
+	if !ok {
+		gw = &gateway{}
+		gateways[gatewayID] = gw
+	}
+
+	gw.pending++
+	driftCounter(gatewayID).Inc()
+
+	if !gw.lastEvent.IsZero() && now.Sub(gw.lastEvent) < eventInterval {
+		return nil
+	}
+
+	d := drift{
+		gatewayID: gatewayID,
+		frequency: frequency,
+		version:   p.version,
+		count:     gw.pending,
+	}
+	gw.lastEvent = now
+	gw.pending = 0
+
+	return &d
+}
+
+func report(d drift) {
+	log.WithFields(log.Fields{
+		"gateway_id":     d.gatewayID,
+		"frequency":      d.frequency,
+		"config_version": d.version,
+		"drift_count":    d.count,
+	}).Warning("configdrift: uplink received on a frequency which is not a channel of the applied configuration")
+
+	if err := publishDrift(d, time.Now()); err != nil {
+		log.WithError(err).WithField("gateway_id", d.gatewayID).Error("configdrift: publish notify event error")
+	}
+}
+
+func publishDrift(d drift, now time.Time) error {
+	i := integration.GetIntegration()
+	if i == nil {
+		return nil
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return errors.Wrap(err, "new uuid error")
+	}
+
+	ts, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return errors.Wrap(err, "timestamp proto error")
+	}
+
+	return i.PublishEvent(d.gatewayID, integration.EventNotify, id, &integration.Notify{
+		GatewayId:       d.gatewayID[:],
+		Level:           "warning",
+		Code:            notifyCode,
+		Message:         fmt.Sprintf("uplink received on %d Hz, which is not a channel of the applied configuration (version: %s)", d.frequency, d.version),
+		Time:            ts,
+		SuppressedCount: uint32(d.count - 1),
+	})
+}
@@ -0,0 +1,85 @@
+package configdrift
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestUplink(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.ConfigDrift.Enabled = true
+	conf.ConfigDrift.EventInterval = time.Hour
+	assert.NoError(Setup(conf))
+	defer Setup(config.Config{})
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Now()
+
+	t.Run("no applied configuration", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Nil(uplink(gatewayID, 868100000, now))
+	})
+
+	SetConfiguration(gw.GatewayConfiguration{
+		GatewayId: gatewayID[:],
+		Version:   "1.2.3",
+		Channels: []*gw.ChannelConfiguration{
+			{Frequency: 868100000},
+			{Frequency: 868300000},
+		},
+	})
+
+	t.Run("channel of applied configuration", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Nil(uplink(gatewayID, 868300000, now))
+	})
+
+	t.Run("drift is reported", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Equal(&drift{
+			gatewayID: gatewayID,
+			frequency: 867100000,
+			version:   "1.2.3",
+			count:     1,
+		}, uplink(gatewayID, 867100000, now))
+	})
+
+	t.Run("drift within event interval is aggregated", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Nil(uplink(gatewayID, 867300000, now.Add(time.Minute)))
+		assert.Equal(&drift{
+			gatewayID: gatewayID,
+			frequency: 867500000,
+			version:   "1.2.3",
+			count:     2,
+		}, uplink(gatewayID, 867500000, now.Add(time.Hour)))
+	})
+
+	t.Run("new configuration resets the drift", func(t *testing.T) {
+		assert := require.New(t)
+
+		SetConfiguration(gw.GatewayConfiguration{
+			GatewayId: gatewayID[:],
+			Version:   "1.2.4",
+			Channels: []*gw.ChannelConfiguration{
+				{Frequency: 867100000},
+			},
+		})
+
+		assert.Nil(uplink(gatewayID, 867100000, now.Add(time.Hour)))
+		assert.Equal(&drift{
+			gatewayID: gatewayID,
+			frequency: 868100000,
+			version:   "1.2.4",
+			count:     1,
+		}, uplink(gatewayID, 868100000, now.Add(time.Hour)))
+	})
+}
@@ -0,0 +1,19 @@
+package configdrift
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	dc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "config_drift_uplink_count",
+		Help: "The number of uplinks received on a frequency which is not a channel of the applied gateway configuration (per gateway).",
+	}, []string{"gateway_id"})
+)
+
+func driftCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return dc.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
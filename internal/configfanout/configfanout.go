@@ -0,0 +1,219 @@
+// Package configfanout implements the fan-out of a single gateway
+// configuration to all connected gateways or to the gateways of a configured
+// group, e.g. for rolling out a channel-plan to the whole fleet. The results
+// are aggregated into a single result. It does not depend on the backend
+// package, so that the request and result messages can be used by the
+// integration implementations.
+package configfanout
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/registry"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// TargetAll is the target for applying the configuration to all connected
+// gateways.
+const TargetAll = "all"
+
+// ErrorNotConnected is the result error used when a gateway of the group is
+// not connected.
+const ErrorNotConnected = "NOT_CONNECTED"
+
+// ApplyFunc applies the gateway configuration to a single gateway.
+type ApplyFunc func(gw.GatewayConfiguration) error
+
+var (
+	mux sync.RWMutex
+
+	maxConcurrency     int
+	groups             map[string][]lorawan.EUI64
+	applyConfiguration ApplyFunc
+)
+
+// Setup configures the configuration fan-out package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	maxConcurrency = conf.ConfigurationFanOut.MaxConcurrency
+	if maxConcurrency < 1 {
+		maxConcurrency = 1
+	}
+
+	groups = make(map[string][]lorawan.EUI64)
+
+	for _, g := range conf.ConfigurationFanOut.Groups {
+		if g.Name == "" {
+			return errors.New("configuration fan-out group name must be set")
+		}
+
+		if g.Name == TargetAll {
+			return errors.Errorf("configuration fan-out group name is reserved: %s", g.Name)
+		}
+
+		for _, id := range g.GatewayIDs {
+			var gatewayID lorawan.EUI64
+			if err := gatewayID.UnmarshalText([]byte(id)); err != nil {
+				return errors.Wrapf(err, "unmarshal gateway_id error (group: %s)", g.Name)
+			}
+			groups[g.Name] = append(groups[g.Name], gatewayID)
+		}
+
+		log.WithFields(log.Fields{
+			"group":    g.Name,
+			"gateways": len(groups[g.Name]),
+		}).Info("configfanout: group configured")
+	}
+
+	return nil
+}
+
+// SetApplyFunc sets the function applying the configuration to a single
+// gateway. This is set by the forwarder, as this package can not depend on
+// the backend package.
+func SetApplyFunc(f ApplyFunc) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	applyConfiguration = f
+}
+
+// FanOut applies the configuration of the request to the targeted gateways,
+// with at most the configured max. concurrency. It returns once the
+// configuration has been applied to all gateways.
+func FanOut(req Request) Result {
+	res := Result{
+		RequestId: req.GetRequestId(),
+		Target:    req.GetTarget(),
+		Version:   req.GetConfiguration().GetVersion(),
+	}
+
+	gatewayIDs, notConnected, err := resolveGatewayIDs(req)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+
+	mux.RLock()
+	apply := applyConfiguration
+	limit := maxConcurrency
+	mux.RUnlock()
+
+	if apply == nil {
+		res.Error = "configuration fan-out is not available"
+		return res
+	}
+
+	items := make([]*ResultItem, len(gatewayIDs))
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+
+	for i := range gatewayIDs {
+		gatewayID := gatewayIDs[i]
+		item := ResultItem{
+			GatewayId: gatewayID[:],
+		}
+		items[i] = &item
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			gwConfig := *req.Configuration
+			gwConfig.GatewayId = gatewayID[:]
+
+			if err := apply(gwConfig); err != nil {
+				log.WithError(err).WithField("gateway_id", gatewayID).Error("configfanout: apply gateway configuration error")
+				item.Error = err.Error()
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	for _, item := range items {
+		if item.Error == "" {
+			res.AppliedCount++
+		} else {
+			res.FailedCount++
+		}
+	}
+
+	for i := range notConnected {
+		gatewayID := notConnected[i]
+		items = append(items, &ResultItem{
+			GatewayId: gatewayID[:],
+			Error:     ErrorNotConnected,
+		})
+		res.FailedCount++
+	}
+
+	res.Items = items
+
+	log.WithFields(log.Fields{
+		"target":  res.Target,
+		"version": res.Version,
+		"applied": res.AppliedCount,
+		"failed":  res.FailedCount,
+	}).Info("configfanout: gateway configuration applied")
+
+	return res
+}
+
+// resolveGatewayIDs returns the connected gateways targeted by the given
+// request and the gateways of the targeted group which are not connected.
+func resolveGatewayIDs(req Request) ([]lorawan.EUI64, []lorawan.EUI64, error) {
+	if req.GetConfiguration() == nil {
+		return nil, nil, errors.New("configuration must be set")
+	}
+
+	connected := registry.Gateways()
+
+	switch req.GetTarget() {
+	case "":
+		return nil, nil, errors.New("target must be set")
+	case TargetAll:
+		return connected, nil, nil
+	}
+
+	mux.RLock()
+	ids, ok := groups[req.GetTarget()]
+	mux.RUnlock()
+
+	if !ok {
+		return nil, nil, errors.Errorf("unknown configuration fan-out group: %s", req.GetTarget())
+	}
+
+	isConnected := make(map[lorawan.EUI64]struct{})
+	for _, id := range connected {
+		isConnected[id] = struct{}{}
+	}
+
+	var gatewayIDs, notConnected []lorawan.EUI64
+	seen := make(map[lorawan.EUI64]struct{})
+	for _, id := range ids {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+
+		if _, ok := isConnected[id]; ok {
+			gatewayIDs = append(gatewayIDs, id)
+		} else {
+			notConnected = append(notConnected, id)
+		}
+	}
+
+	return gatewayIDs, notConnected, nil
+}
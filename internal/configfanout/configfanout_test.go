@@ -0,0 +1,150 @@
+package configfanout
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/registry"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestFanOut(t *testing.T) {
+	assert := require.New(t)
+
+	gatewayID1 := lorawan.EUI64{1, 1, 1, 1, 1, 1, 1, 1}
+	gatewayID2 := lorawan.EUI64{2, 2, 2, 2, 2, 2, 2, 2}
+	gatewayID3 := lorawan.EUI64{3, 3, 3, 3, 3, 3, 3, 3}
+
+	var conf config.Config
+	conf.ConfigurationFanOut.MaxConcurrency = 2
+	conf.ConfigurationFanOut.Groups = append(conf.ConfigurationFanOut.Groups, struct {
+		Name       string   `mapstructure:"name"`
+		GatewayIDs []string `mapstructure:"gateway_ids"`
+	}{
+		Name:       "city-center",
+		GatewayIDs: []string{"0202020202020202", "0303030303030303", "0202020202020202"},
+	})
+	assert.NoError(Setup(conf))
+	assert.NoError(registry.Setup(conf))
+
+	registry.Connected(gatewayID1)
+	registry.Connected(gatewayID2)
+
+	var mux sync.Mutex
+	var applied []gw.GatewayConfiguration
+	SetApplyFunc(func(c gw.GatewayConfiguration) error {
+		mux.Lock()
+		defer mux.Unlock()
+
+		if c.GatewayId[0] == 2 {
+			return errors.New("gateway does not exist")
+		}
+		applied = append(applied, c)
+		return nil
+	})
+
+	tests := []struct {
+		Name            string
+		Request         Request
+		ExpectedResult  Result
+		ExpectedApplied []gw.GatewayConfiguration
+	}{
+		{
+			Name: "all",
+			Request: Request{
+				RequestId:     []byte{1, 2, 3},
+				Target:        "all",
+				Configuration: &gw.GatewayConfiguration{Version: "1.2.3"},
+			},
+			ExpectedResult: Result{
+				RequestId:    []byte{1, 2, 3},
+				Target:       "all",
+				Version:      "1.2.3",
+				AppliedCount: 1,
+				FailedCount:  1,
+				Items: []*ResultItem{
+					{GatewayId: gatewayID1[:]},
+					{GatewayId: gatewayID2[:], Error: "gateway does not exist"},
+				},
+			},
+			ExpectedApplied: []gw.GatewayConfiguration{
+				{GatewayId: gatewayID1[:], Version: "1.2.3"},
+			},
+		},
+		{
+			Name: "group",
+			Request: Request{
+				Target:        "city-center",
+				Configuration: &gw.GatewayConfiguration{Version: "1.2.3"},
+			},
+			ExpectedResult: Result{
+				Target:      "city-center",
+				Version:     "1.2.3",
+				FailedCount: 2,
+				Items: []*ResultItem{
+					{GatewayId: gatewayID2[:], Error: "gateway does not exist"},
+					{GatewayId: gatewayID3[:], Error: ErrorNotConnected},
+				},
+			},
+		},
+		{
+			Name: "unknown group",
+			Request: Request{
+				Target:        "suburbs",
+				Configuration: &gw.GatewayConfiguration{},
+			},
+			ExpectedResult: Result{
+				Target: "suburbs",
+				Error:  "unknown configuration fan-out group: suburbs",
+			},
+		},
+		{
+			Name: "no target",
+			Request: Request{
+				Configuration: &gw.GatewayConfiguration{},
+			},
+			ExpectedResult: Result{
+				Error: "target must be set",
+			},
+		},
+		{
+			Name: "no configuration",
+			Request: Request{
+				Target: "all",
+			},
+			ExpectedResult: Result{
+				Target: "all",
+				Error:  "configuration must be set",
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			applied = nil
+
+			res := FanOut(tst.Request)
+			assert.Equal(tst.ExpectedResult, res)
+			assert.Equal(tst.ExpectedApplied, applied)
+		})
+	}
+}
+
+func TestSetup(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.ConfigurationFanOut.Groups = append(conf.ConfigurationFanOut.Groups, struct {
+		Name       string   `mapstructure:"name"`
+		GatewayIDs []string `mapstructure:"gateway_ids"`
+	}{
+		Name: "all",
+	})
+	assert.EqualError(Setup(conf), "configuration fan-out group name is reserved: all")
+}
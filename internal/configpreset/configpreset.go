@@ -0,0 +1,76 @@
+// Package configpreset implements the region and backend presets of the
+// configfile command, so that a ready-to-run configuration file can be
+// generated for new installations.
+package configpreset
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/frequencycheck"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+// ApplyRegion sets the region and the regional frequency range of all the
+// region dependent sections (these are not enabled).
+func ApplyRegion(conf *config.Config, region string) error {
+	name := band.Name(strings.ToUpper(region))
+
+	if _, err := band.GetConfig(name, false, lorawan.DwellTimeNoLimit); err != nil {
+		return errors.Wrap(err, "get band config error")
+	}
+
+	frequencyMin, frequencyMax, ok := frequencycheck.RegionFrequencyRange(name)
+	if !ok {
+		return fmt.Errorf("no frequency range defined for region: %s", name)
+	}
+
+	conf.Backend.BasicStation.Region = string(name)
+	conf.Backend.BasicStation.FrequencyMin = frequencyMin
+	conf.Backend.BasicStation.FrequencyMax = frequencyMax
+
+	// the frequency range is derived from the region when not set
+	conf.FrequencyCheck.Region = string(name)
+	conf.FrequencyCheck.FrequencyMin = 0
+	conf.FrequencyCheck.FrequencyMax = 0
+
+	conf.Beacon.Region = string(name)
+	conf.DownlinkPolicy.Region = string(name)
+	conf.TestDownlink.Region = string(name)
+
+	return nil
+}
+
+// ApplyBackend sets the backend type and the recommended timeouts of the
+// given backend.
+func ApplyBackend(conf *config.Config, backend string) error {
+	switch backend {
+	case "semtech_udp":
+		if conf.Backend.SemtechUDP.UDPBind == "" {
+			conf.Backend.SemtechUDP.UDPBind = "0.0.0.0:1700"
+		}
+		conf.Backend.SemtechUDP.DownlinkInFlight.Timeout = 5 * time.Second
+		conf.Backend.SemtechUDP.ConfigurationRollback.Timeout = time.Minute
+	case "basic_station":
+		if conf.Backend.BasicStation.Bind == "" {
+			conf.Backend.BasicStation.Bind = ":3001"
+		}
+		// the read timeout must exceed the ping interval, as the Basic
+		// Station does not send any data when there is no traffic
+		conf.Backend.BasicStation.PingInterval = time.Minute
+		conf.Backend.BasicStation.ReadTimeout = time.Minute + 5*time.Second
+		conf.Backend.BasicStation.WriteTimeout = time.Second
+	case "ttn_connector", "native":
+	default:
+		return fmt.Errorf("unknown backend type: %s", backend)
+	}
+
+	conf.Backend.Type = backend
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package configpreset
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func TestApplyRegion(t *testing.T) {
+	t.Run("Valid region", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.FrequencyCheck.FrequencyMin = 863000000
+		conf.FrequencyCheck.FrequencyMax = 870000000
+
+		assert.NoError(ApplyRegion(&conf, "us915"))
+		assert.Equal("US915", conf.Backend.BasicStation.Region)
+		assert.EqualValues(902000000, conf.Backend.BasicStation.FrequencyMin)
+		assert.EqualValues(928000000, conf.Backend.BasicStation.FrequencyMax)
+		assert.Equal("US915", conf.FrequencyCheck.Region)
+		assert.EqualValues(0, conf.FrequencyCheck.FrequencyMin)
+		assert.EqualValues(0, conf.FrequencyCheck.FrequencyMax)
+		assert.Equal("US915", conf.Beacon.Region)
+		assert.Equal("US915", conf.DownlinkPolicy.Region)
+		assert.Equal("US915", conf.TestDownlink.Region)
+	})
+
+	t.Run("Invalid region", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		assert.Error(ApplyRegion(&conf, "FOO"))
+		assert.Equal("", conf.Backend.BasicStation.Region)
+	})
+}
+
+func TestApplyBackend(t *testing.T) {
+	t.Run("Basic Station", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.Backend.BasicStation.ReadTimeout = time.Second
+
+		assert.NoError(ApplyBackend(&conf, "basic_station"))
+		assert.Equal("basic_station", conf.Backend.Type)
+		assert.Equal(":3001", conf.Backend.BasicStation.Bind)
+		assert.Equal(time.Minute, conf.Backend.BasicStation.PingInterval)
+		assert.Equal(65*time.Second, conf.Backend.BasicStation.ReadTimeout)
+		assert.Equal(time.Second, conf.Backend.BasicStation.WriteTimeout)
+	})
+
+	t.Run("Semtech UDP", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.Backend.SemtechUDP.UDPBind = "0.0.0.0:1800"
+
+		assert.NoError(ApplyBackend(&conf, "semtech_udp"))
+		assert.Equal("semtech_udp", conf.Backend.Type)
+		assert.Equal("0.0.0.0:1800", conf.Backend.SemtechUDP.UDPBind)
+		assert.Equal(5*time.Second, conf.Backend.SemtechUDP.DownlinkInFlight.Timeout)
+	})
+
+	t.Run("Unknown backend", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		assert.Error(ApplyBackend(&conf, "foo"))
+		assert.Equal("", conf.Backend.Type)
+	})
+}
@@ -0,0 +1,310 @@
+// Package debug implements the runtime debug controls, exposed through the
+// admin API. These make it possible to (temporarily) change the log level
+// and to enable the dumping of the frames of a single gateway, without
+// restarting the LoRa Gateway Bridge.
+package debug
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+// logLevelStatus contains the log level status, as exposed by the admin API.
+type logLevelStatus struct {
+	Level   string     `json:"level"`
+	ResetAt *time.Time `json:"resetAt"`
+}
+
+// logLevelRequest contains the request for changing the log level through
+// the admin API. When Duration is set, the log level is reset to the
+// configured log level after the given duration.
+type logLevelRequest struct {
+	Level    string `json:"level"`
+	Duration string `json:"duration"`
+}
+
+// gatewayStatus contains the frame dumping status of a single gateway.
+type gatewayStatus struct {
+	GatewayID lorawan.EUI64 `json:"gatewayID"`
+	ExpiresAt *time.Time    `json:"expiresAt"`
+}
+
+// gatewayRequest contains the request for enabling or disabling the frame
+// dumping of a gateway through the admin API. When Duration is set, the
+// frame dumping is disabled after the given duration.
+type gatewayRequest struct {
+	GatewayID string `json:"gatewayID"`
+	Enabled   bool   `json:"enabled"`
+	Duration  string `json:"duration"`
+}
+
+var (
+	mux sync.RWMutex
+
+	configuredLevel log.Level
+	levelResetAt    *time.Time
+	levelTimer      *time.Timer
+
+	// gateways contains the gateways for which frame dumping is enabled,
+	// with the optional expiry time.
+	gateways = make(map[lorawan.EUI64]*time.Time)
+)
+
+// Setup configures the debug package and registers the admin API handlers.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	configuredLevel = log.Level(uint8(conf.General.LogLevel))
+
+	admin.HandleFunc("/api/debug/log-level", handleLogLevelHTTP)
+	admin.HandleFunc("/api/debug/gateways", handleGatewaysHTTP)
+
+	return nil
+}
+
+// SetLogLevel sets the log level. When the given duration is not 0, the
+// log level is reset to the configured log level after this duration.
+func SetLogLevel(level log.Level, duration time.Duration) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if levelTimer != nil {
+		levelTimer.Stop()
+		levelTimer = nil
+	}
+	levelResetAt = nil
+
+	log.SetLevel(level)
+
+	if duration != 0 {
+		resetAt := time.Now().Add(duration)
+		levelResetAt = &resetAt
+		levelTimer = time.AfterFunc(duration, resetLogLevel)
+	}
+
+	log.WithFields(log.Fields{
+		"level":    level,
+		"duration": duration,
+	}).Warning("debug: log level changed")
+}
+
+func resetLogLevel() {
+	mux.Lock()
+	defer mux.Unlock()
+
+	levelTimer = nil
+	levelResetAt = nil
+	log.SetLevel(configuredLevel)
+
+	log.WithField("level", configuredLevel).Warning("debug: log level reset")
+}
+
+// SetGatewayDebug enables or disables the frame dumping for the given
+// gateway. When enabling and the given duration is not 0, the frame dumping
+// is disabled after this duration.
+func SetGatewayDebug(gatewayID lorawan.EUI64, enabled bool, duration time.Duration) {
+	setGatewayDebug(gatewayID, enabled, duration, time.Now())
+}
+
+// Enabled returns if the frame dumping is enabled for the given gateway.
+func Enabled(gatewayID lorawan.EUI64) bool {
+	return enabled(gatewayID, time.Now())
+}
+
+// DumpFrame logs the given frame (as JSON) when the frame dumping is enabled
+// for the given gateway.
+func DumpFrame(gatewayID lorawan.EUI64, frameType string, frame proto.Message) {
+	if !Enabled(gatewayID) {
+		return
+	}
+
+	marshaler := &jsonpb.Marshaler{
+		EnumsAsInts:  false,
+		EmitDefaults: true,
+	}
+	str, err := marshaler.MarshalToString(frame)
+	if err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("debug: marshal frame error")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"frame_type": frameType,
+		"frame":      str,
+	}).Info("debug: frame dump")
+}
+
+func setGatewayDebug(gatewayID lorawan.EUI64, enabled bool, duration time.Duration, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		delete(gateways, gatewayID)
+		return
+	}
+
+	var expiresAt *time.Time
+	if duration != 0 {
+		t := now.Add(duration)
+		expiresAt = &t
+	}
+	gateways[gatewayID] = expiresAt
+}
+
+func enabled(gatewayID lorawan.EUI64, now time.Time) bool {
+	mux.RLock()
+	expiresAt, ok := gateways[gatewayID]
+	mux.RUnlock()
+
+	if !ok {
+		return false
+	}
+
+	if expiresAt != nil && !now.Before(*expiresAt) {
+		mux.Lock()
+		delete(gateways, gatewayID)
+		mux.Unlock()
+		return false
+	}
+
+	return true
+}
+
+func getLogLevelStatus() logLevelStatus {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	return logLevelStatus{
+		Level:   log.GetLevel().String(),
+		ResetAt: levelResetAt,
+	}
+}
+
+func getGatewayStatus(now time.Time) []gatewayStatus {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	out := []gatewayStatus{}
+	for gatewayID, expiresAt := range gateways {
+		if expiresAt != nil && !now.Before(*expiresAt) {
+			continue
+		}
+
+		out = append(out, gatewayStatus{
+			GatewayID: gatewayID,
+			ExpiresAt: expiresAt,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].GatewayID.String() < out[j].GatewayID.String()
+	})
+
+	return out
+}
+
+func parseDuration(s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, err
+	}
+	if d < 0 {
+		return 0, errors.New("duration must not be negative")
+	}
+
+	return d, nil
+}
+
+// handleLogLevelHTTP implements the admin API handler for the log level.
+// A GET request returns the current log level, a POST request changes the
+// log level.
+func handleLogLevelHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req logLevelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+			return
+		}
+
+		level, err := log.ParseLevel(req.Level)
+		if err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "parse level error"))
+			return
+		}
+
+		duration, err := parseDuration(req.Duration)
+		if err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "parse duration error"))
+			return
+		}
+
+		SetLogLevel(level, duration)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin.WriteJSON(w, getLogLevelStatus())
+}
+
+// handleGatewaysHTTP implements the admin API handler for the per-gateway
+// frame dumping. A GET request returns the gateways for which frame dumping
+// is enabled, a POST request enables or disables the frame dumping.
+func handleGatewaysHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req gatewayRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+			return
+		}
+
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(req.GatewayID)); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "unmarshal gateway id error"))
+			return
+		}
+
+		duration, err := parseDuration(req.Duration)
+		if err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "parse duration error"))
+			return
+		}
+
+		SetGatewayDebug(gatewayID, req.Enabled, duration)
+
+		log.WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"enabled":    req.Enabled,
+			"duration":   duration,
+		}).Info("debug: gateway frame dumping updated")
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin.WriteJSON(w, struct {
+		Gateways []gatewayStatus `json:"gateways"`
+	}{getGatewayStatus(time.Now())})
+}
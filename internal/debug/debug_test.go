@@ -0,0 +1,151 @@
+package debug
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestGatewayDebug(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Disabled by default", func(t *testing.T) {
+		assert := require.New(t)
+		gateways = make(map[lorawan.EUI64]*time.Time)
+
+		assert.False(enabled(gatewayID, now))
+	})
+
+	t.Run("Enabled without expiry", func(t *testing.T) {
+		assert := require.New(t)
+		gateways = make(map[lorawan.EUI64]*time.Time)
+
+		setGatewayDebug(gatewayID, true, 0, now)
+		assert.True(enabled(gatewayID, now.Add(24*time.Hour)))
+
+		setGatewayDebug(gatewayID, false, 0, now)
+		assert.False(enabled(gatewayID, now))
+	})
+
+	t.Run("Enabled with expiry", func(t *testing.T) {
+		assert := require.New(t)
+		gateways = make(map[lorawan.EUI64]*time.Time)
+
+		setGatewayDebug(gatewayID, true, 10*time.Minute, now)
+		assert.True(enabled(gatewayID, now.Add(9*time.Minute)))
+		assert.Len(getGatewayStatus(now.Add(9*time.Minute)), 1)
+
+		assert.Len(getGatewayStatus(now.Add(10*time.Minute)), 0)
+		assert.False(enabled(gatewayID, now.Add(10*time.Minute)))
+		assert.Len(gateways, 0)
+	})
+}
+
+func TestLogLevel(t *testing.T) {
+	assert := require.New(t)
+
+	configuredLevel = log.InfoLevel
+	log.SetLevel(log.InfoLevel)
+	defer log.SetLevel(log.InfoLevel)
+
+	SetLogLevel(log.DebugLevel, 0)
+	assert.Equal(log.DebugLevel, log.GetLevel())
+	assert.Nil(getLogLevelStatus().ResetAt)
+
+	SetLogLevel(log.DebugLevel, 10*time.Millisecond)
+	assert.NotNil(getLogLevelStatus().ResetAt)
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(log.InfoLevel, log.GetLevel())
+	assert.Nil(getLogLevelStatus().ResetAt)
+}
+
+func TestHandlers(t *testing.T) {
+	configuredLevel = log.InfoLevel
+	gateways = make(map[lorawan.EUI64]*time.Time)
+	defer log.SetLevel(log.InfoLevel)
+
+	tests := []struct {
+		Name    string
+		Handler http.HandlerFunc
+		Method  string
+		Body    string
+
+		ExpectedStatusCode int
+		ExpectedBody       string
+	}{
+		{
+			Name:               "set log level",
+			Handler:            handleLogLevelHTTP,
+			Method:             http.MethodPost,
+			Body:               `{"level": "debug"}`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedBody:       `{"level":"debug","resetAt":null}`,
+		},
+		{
+			Name:               "invalid log level",
+			Handler:            handleLogLevelHTTP,
+			Method:             http.MethodPost,
+			Body:               `{"level": "foo"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedBody:       `{"error":"parse level error: not a valid logrus Level: \"foo\""}`,
+		},
+		{
+			Name:               "enable gateway",
+			Handler:            handleGatewaysHTTP,
+			Method:             http.MethodPost,
+			Body:               `{"gatewayID": "0102030405060708", "enabled": true}`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedBody:       `{"gateways":[{"gatewayID":"0102030405060708","expiresAt":null}]}`,
+		},
+		{
+			Name:               "invalid duration",
+			Handler:            handleGatewaysHTTP,
+			Method:             http.MethodPost,
+			Body:               `{"gatewayID": "0102030405060708", "enabled": true, "duration": "-1m"}`,
+			ExpectedStatusCode: http.StatusBadRequest,
+			ExpectedBody:       `{"error":"parse duration error: duration must not be negative"}`,
+		},
+		{
+			Name:               "disable gateway",
+			Handler:            handleGatewaysHTTP,
+			Method:             http.MethodPost,
+			Body:               `{"gatewayID": "0102030405060708", "enabled": false}`,
+			ExpectedStatusCode: http.StatusOK,
+			ExpectedBody:       `{"gateways":[]}`,
+		},
+		{
+			Name:               "invalid method",
+			Handler:            handleGatewaysHTTP,
+			Method:             http.MethodDelete,
+			ExpectedStatusCode: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(tst.Method, "/", bytes.NewBufferString(tst.Body))
+			tst.Handler(w, r)
+
+			assert.Equal(tst.ExpectedStatusCode, w.Code)
+			if tst.ExpectedBody != "" {
+				var expected, actual interface{}
+				assert.NoError(json.Unmarshal([]byte(tst.ExpectedBody), &expected))
+				assert.NoError(json.Unmarshal(w.Body.Bytes(), &actual))
+				assert.Equal(expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,98 @@
+// Package downlinkexpiry implements the enqueue time of the downlinks, used
+// to discard the downlinks exceeding the max age. Downlink commands might be
+// queued by the broker while the LoRa Gateway Bridge is reconnecting. As
+// only Class-A and Class-B downlinks have an implicit deadline, the enqueue
+// time can be set using the enqueued_at key in the meta-data of the downlink
+// command.
+package downlinkexpiry
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// MetaDataKey defines the meta-data key of the downlink command holding the
+// enqueue time (RFC3339 formatted).
+const MetaDataKey = "enqueued_at"
+
+// retention defines how long an enqueue time is kept when the downlink is
+// never sent.
+const retention = time.Minute
+
+type entry struct {
+	created    time.Time
+	enqueuedAt time.Time
+}
+
+var (
+	mux     sync.Mutex
+	entries = make(map[string]entry)
+	cleaned time.Time
+)
+
+// SetFromMetaData sets the enqueue time of the given downlink when the given
+// meta-data contains the enqueue time key.
+func SetFromMetaData(gatewayID []byte, token uint32, metaData map[string]string) error {
+	s, ok := metaData[MetaDataKey]
+	if !ok {
+		return nil
+	}
+
+	enqueuedAt, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return errors.Wrap(err, "parse enqueue time error")
+	}
+
+	set(gatewayID, token, enqueuedAt, time.Now())
+	return nil
+}
+
+// Get returns the enqueue time of the given downlink (which is removed) and
+// true when set.
+func Get(gatewayID []byte, token uint32) (time.Time, bool) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	k := key(gatewayID, token)
+	e, ok := entries[k]
+	if !ok {
+		return time.Time{}, false
+	}
+
+	delete(entries, k)
+	return e.enqueuedAt, true
+}
+
+func set(gatewayID []byte, token uint32, enqueuedAt, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	cleanup(now)
+	entries[key(gatewayID, token)] = entry{
+		created:    now,
+		enqueuedAt: enqueuedAt,
+	}
+}
+
+// cleanup removes the enqueue times exceeding the retention. A lock must be
+// held by the caller.
+func cleanup(now time.Time) {
+	if now.Sub(cleaned) < retention {
+		return
+	}
+
+	for k, e := range entries {
+		if now.Sub(e.created) > retention {
+			delete(entries, k)
+		}
+	}
+	cleaned = now
+}
+
+func key(gatewayID []byte, token uint32) string {
+	return fmt.Sprintf("%s/%d", hex.EncodeToString(gatewayID), token)
+}
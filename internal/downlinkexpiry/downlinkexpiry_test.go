@@ -0,0 +1,49 @@
+package downlinkexpiry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	gatewayID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	enqueuedAt := time.Date(2019, 10, 1, 12, 30, 0, 500000000, time.UTC)
+
+	t.Run("from meta-data", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(SetFromMetaData(gatewayID, 1, map[string]string{"enqueued_at": "2019-10-01T12:30:00.5Z"}))
+		ts, ok := Get(gatewayID, 1)
+		assert.True(ok)
+		assert.True(enqueuedAt.Equal(ts))
+
+		// the enqueue time is removed once used
+		_, ok = Get(gatewayID, 1)
+		assert.False(ok)
+
+		assert.NoError(SetFromMetaData(gatewayID, 2, nil))
+		_, ok = Get(gatewayID, 2)
+		assert.False(ok)
+
+		assert.Error(SetFromMetaData(gatewayID, 3, map[string]string{"enqueued_at": "yesterday"}))
+		_, ok = Get(gatewayID, 3)
+		assert.False(ok)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert := require.New(t)
+		now := time.Now()
+
+		set(gatewayID, 5, enqueuedAt, now)
+		set(gatewayID, 6, enqueuedAt, now.Add(2*retention))
+
+		mux.Lock()
+		_, ok := entries[key(gatewayID, 5)]
+		assert.False(ok)
+		_, ok = entries[key(gatewayID, 6)]
+		assert.True(ok)
+		mux.Unlock()
+	})
+}
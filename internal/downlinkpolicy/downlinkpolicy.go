@@ -0,0 +1,323 @@
+// Package downlinkpolicy validates the downlinks against the regional
+// parameters before they are sent to the gateway. Depending on the configured
+// policy, a downlink failing validation is rejected or its TX parameters are
+// substituted (e.g. the TX power is clamped to the max. supported TX power).
+// This is useful when the network-server and gateway configurations drift.
+// The substitutions are reported in the meta-data of the ack.
+package downlinkpolicy
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+// Policies.
+const (
+	// PolicyReject rejects the downlink.
+	PolicyReject = "reject"
+
+	// PolicyClamp clamps the TX power to the max. TX power.
+	PolicyClamp = "clamp"
+
+	// PolicyRX2 substitutes the frequency and data-rate by the RX2 defaults
+	// of the region.
+	PolicyRX2 = "rx2"
+)
+
+// Checks.
+const (
+	CheckTXPower   = "tx_power"
+	CheckFrequency = "frequency"
+	CheckDataRate  = "data_rate"
+)
+
+// Ack errors of rejected downlinks. TX_POWER and TX_FREQ are also used by
+// the Semtech packet-forwarder.
+const (
+	ErrorTXPower  = "TX_POWER"
+	ErrorTXFreq   = "TX_FREQ"
+	ErrorDataRate = "TX_DATARATE"
+)
+
+// metaDataPrefix is the prefix of the ack meta-data keys of the
+// substitutions.
+const metaDataPrefix = "substituted_"
+
+// retention defines how long the substitutions of a downlink are kept when
+// the downlink is not acked.
+const retention = time.Minute
+
+// regionalDefaults contains the downlink frequency range (Hz), the default
+// RX2 frequency (Hz) and data-rate and the max. EIRP (dBm) per region.
+var regionalDefaults = map[band.Name]struct {
+	frequencyMin uint32
+	frequencyMax uint32
+	rx2Frequency uint32
+	rx2DataRate  int
+	maxEIRP      int
+}{
+	band.AS923: {915000000, 928000000, 923200000, 2, 16},
+	band.AU915: {923300000, 927500000, 923300000, 8, 30},
+	band.CN470: {500300000, 509700000, 505300000, 0, 19},
+	band.CN779: {779500000, 786500000, 786000000, 0, 12},
+	band.EU433: {433175000, 434665000, 434665000, 0, 12},
+	band.EU868: {863000000, 870000000, 869525000, 0, 27},
+	band.IN865: {865000000, 867000000, 866550000, 2, 30},
+	band.KR920: {920900000, 923300000, 921900000, 0, 14},
+	band.RU864: {864000000, 870000000, 869100000, 0, 16},
+	band.US915: {923300000, 927500000, 923300000, 8, 30},
+}
+
+type substitutions struct {
+	created  time.Time
+	metaData map[string]string
+}
+
+var (
+	mux sync.Mutex
+
+	enabled         bool
+	loraBand        band.Band
+	frequencyMin    uint32
+	frequencyMax    uint32
+	rx2Frequency    uint32
+	rx2DataRate     band.DataRate
+	maxTXPower      int
+	txPowerPolicy   string
+	frequencyPolicy string
+
+	pending = make(map[string]substitutions)
+	cleaned time.Time
+)
+
+// Setup configures the downlink policies.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	enabled = false
+	if !conf.DownlinkPolicy.Enabled {
+		return nil
+	}
+
+	region := band.Name(conf.DownlinkPolicy.Region)
+	rd, ok := regionalDefaults[region]
+	if !ok {
+		return fmt.Errorf("downlink policy is not supported for region: %s", conf.DownlinkPolicy.Region)
+	}
+
+	b, err := band.GetConfig(region, false, lorawan.DwellTimeNoLimit)
+	if err != nil {
+		return errors.Wrap(err, "get band config error")
+	}
+
+	dr, err := b.GetDataRate(rd.rx2DataRate)
+	if err != nil {
+		return errors.Wrap(err, "get data-rate error")
+	}
+
+	switch conf.DownlinkPolicy.TXPowerPolicy {
+	case PolicyReject, PolicyClamp:
+	default:
+		return fmt.Errorf("invalid tx_power_policy: %s", conf.DownlinkPolicy.TXPowerPolicy)
+	}
+
+	switch conf.DownlinkPolicy.FrequencyPolicy {
+	case PolicyReject, PolicyRX2:
+	default:
+		return fmt.Errorf("invalid frequency_policy: %s", conf.DownlinkPolicy.FrequencyPolicy)
+	}
+
+	enabled = true
+	loraBand = b
+	frequencyMin = rd.frequencyMin
+	frequencyMax = rd.frequencyMax
+	rx2Frequency = rd.rx2Frequency
+	rx2DataRate = dr
+	maxTXPower = rd.maxEIRP
+	if conf.DownlinkPolicy.MaxTXPower != 0 {
+		maxTXPower = conf.DownlinkPolicy.MaxTXPower
+	}
+	txPowerPolicy = conf.DownlinkPolicy.TXPowerPolicy
+	frequencyPolicy = conf.DownlinkPolicy.FrequencyPolicy
+
+	log.WithFields(log.Fields{
+		"region":           region,
+		"max_tx_power":     maxTXPower,
+		"tx_power_policy":  txPowerPolicy,
+		"frequency_policy": frequencyPolicy,
+	}).Info("downlinkpolicy: downlink policies enabled")
+
+	return nil
+}
+
+// Apply validates the given downlink frame and applies the configured
+// policies. It returns the ack error when the downlink must be rejected, or
+// an empty string when the (possibly substituted) downlink can be sent. The
+// substitutions are kept until they are retrieved using Substitutions.
+func Apply(frame *gw.DownlinkFrame) string {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled || frame.TxInfo == nil {
+		return ""
+	}
+
+	metaData, ackError := apply(frame.TxInfo)
+	if ackError != "" {
+		return ackError
+	}
+
+	if len(metaData) != 0 {
+		now := time.Now()
+		cleanup(now)
+		pending[key(frame.TxInfo.GatewayId, frame.Token)] = substitutions{
+			created:  now,
+			metaData: metaData,
+		}
+
+		log.WithFields(log.Fields{
+			"gateway_id": hex.EncodeToString(frame.TxInfo.GatewayId),
+			"token":      frame.Token,
+		}).WithFields(toFields(metaData)).Warning("downlinkpolicy: downlink tx parameters substituted")
+	}
+
+	return ""
+}
+
+// Substitutions returns and removes the substitutions of the given downlink,
+// as ack meta-data. It returns nil when no substitutions were made.
+func Substitutions(gatewayID []byte, token uint32) map[string]string {
+	mux.Lock()
+	defer mux.Unlock()
+
+	k := key(gatewayID, token)
+	s, ok := pending[k]
+	if !ok {
+		return nil
+	}
+	delete(pending, k)
+
+	return s.metaData
+}
+
+// Remove removes the substitutions of the given downlink, e.g. when it could
+// not be sent to the gateway.
+func Remove(gatewayID []byte, token uint32) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	delete(pending, key(gatewayID, token))
+}
+
+// apply validates and substitutes the TX parameters. A lock must be held by
+// the caller.
+func apply(txInfo *gw.DownlinkTXInfo) (map[string]string, string) {
+	metaData := make(map[string]string)
+
+	if int(txInfo.Power) > maxTXPower {
+		if txPowerPolicy != PolicyClamp {
+			policyCounter(CheckTXPower, PolicyReject).Inc()
+			return nil, ErrorTXPower
+		}
+
+		metaData[metaDataPrefix+CheckTXPower] = fmt.Sprintf("%d -> %d", txInfo.Power, maxTXPower)
+		txInfo.Power = int32(maxTXPower)
+		policyCounter(CheckTXPower, PolicyClamp).Inc()
+	}
+
+	validFrequency := txInfo.Frequency >= frequencyMin && txInfo.Frequency <= frequencyMax
+	validDataRate := true
+	if dr, ok := dataRate(txInfo); ok {
+		_, err := loraBand.GetDataRateIndex(false, dr)
+		validDataRate = err == nil
+	}
+
+	if validFrequency && validDataRate {
+		return metaData, ""
+	}
+
+	check, ackError := CheckFrequency, ErrorTXFreq
+	if validFrequency {
+		check, ackError = CheckDataRate, ErrorDataRate
+	}
+
+	// the RX2 substitution is only supported for LoRa modulation
+	if frequencyPolicy != PolicyRX2 || txInfo.Modulation != common.Modulation_LORA {
+		policyCounter(check, PolicyReject).Inc()
+		return nil, ackError
+	}
+
+	// the modulation info is copied, as it might be shared with other
+	// downlink frames (e.g. multicast)
+	modInfo := gw.LoRaModulationInfo{CodeRate: "4/5", PolarizationInversion: true}
+	if mi := txInfo.GetLoraModulationInfo(); mi != nil {
+		modInfo = *mi
+	}
+	txInfo.ModulationInfo = &gw.DownlinkTXInfo_LoraModulationInfo{LoraModulationInfo: &modInfo}
+
+	if txInfo.Frequency != rx2Frequency {
+		metaData[metaDataPrefix+CheckFrequency] = fmt.Sprintf("%d -> %d", txInfo.Frequency, rx2Frequency)
+		txInfo.Frequency = rx2Frequency
+	}
+	if modInfo.SpreadingFactor != uint32(rx2DataRate.SpreadFactor) || modInfo.Bandwidth != uint32(rx2DataRate.Bandwidth) {
+		metaData[metaDataPrefix+CheckDataRate] = fmt.Sprintf("SF%dBW%d -> SF%dBW%d", modInfo.SpreadingFactor, modInfo.Bandwidth, rx2DataRate.SpreadFactor, rx2DataRate.Bandwidth)
+		modInfo.SpreadingFactor = uint32(rx2DataRate.SpreadFactor)
+		modInfo.Bandwidth = uint32(rx2DataRate.Bandwidth)
+	}
+	policyCounter(check, PolicyRX2).Inc()
+
+	return metaData, ""
+}
+
+// dataRate returns the LoRa data-rate of the given TX info.
+func dataRate(txInfo *gw.DownlinkTXInfo) (band.DataRate, bool) {
+	modInfo := txInfo.GetLoraModulationInfo()
+	if txInfo.Modulation != common.Modulation_LORA || modInfo == nil {
+		return band.DataRate{}, false
+	}
+
+	return band.DataRate{
+		Modulation:   band.LoRaModulation,
+		SpreadFactor: int(modInfo.SpreadingFactor),
+		Bandwidth:    int(modInfo.Bandwidth),
+	}, true
+}
+
+// cleanup removes the substitutions of downlinks which were not acked within
+// the retention. A lock must be held by the caller.
+func cleanup(now time.Time) {
+	if now.Sub(cleaned) < retention {
+		return
+	}
+
+	for k, s := range pending {
+		if now.Sub(s.created) > retention {
+			delete(pending, k)
+		}
+	}
+	cleaned = now
+}
+
+func key(gatewayID []byte, token uint32) string {
+	return fmt.Sprintf("%s/%d", hex.EncodeToString(gatewayID), token)
+}
+
+func toFields(metaData map[string]string) log.Fields {
+	out := make(log.Fields, len(metaData))
+	for k, v := range metaData {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,167 @@
+package downlinkpolicy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestApply(t *testing.T) {
+	gatewayID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	downlinkFrame := func(frequency uint32, power int32, sf uint32) gw.DownlinkFrame {
+		return gw.DownlinkFrame{
+			Token: 123,
+			TxInfo: &gw.DownlinkTXInfo{
+				GatewayId:  gatewayID,
+				Frequency:  frequency,
+				Power:      power,
+				Modulation: common.Modulation_LORA,
+				ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+					LoraModulationInfo: &gw.LoRaModulationInfo{
+						Bandwidth:       125,
+						SpreadingFactor: sf,
+						CodeRate:        "4/5",
+					},
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		Name            string
+		TXPowerPolicy   string
+		FrequencyPolicy string
+		MaxTXPower      int
+		DownlinkFrame   gw.DownlinkFrame
+
+		ExpectedAckError      string
+		ExpectedFrequency     uint32
+		ExpectedPower         int32
+		ExpectedSF            uint32
+		ExpectedSubstitutions map[string]string
+	}{
+		{
+			Name:              "valid downlink",
+			TXPowerPolicy:     PolicyReject,
+			FrequencyPolicy:   PolicyReject,
+			DownlinkFrame:     downlinkFrame(868100000, 14, 7),
+			ExpectedFrequency: 868100000,
+			ExpectedPower:     14,
+			ExpectedSF:        7,
+		},
+		{
+			Name:             "tx power exceeded - reject",
+			TXPowerPolicy:    PolicyReject,
+			FrequencyPolicy:  PolicyReject,
+			MaxTXPower:       14,
+			DownlinkFrame:    downlinkFrame(868100000, 20, 7),
+			ExpectedAckError: ErrorTXPower,
+		},
+		{
+			Name:              "tx power exceeded - clamp",
+			TXPowerPolicy:     PolicyClamp,
+			FrequencyPolicy:   PolicyReject,
+			MaxTXPower:        14,
+			DownlinkFrame:     downlinkFrame(868100000, 20, 7),
+			ExpectedFrequency: 868100000,
+			ExpectedPower:     14,
+			ExpectedSF:        7,
+			ExpectedSubstitutions: map[string]string{
+				"substituted_tx_power": "20 -> 14",
+			},
+		},
+		{
+			Name:             "invalid frequency - reject",
+			TXPowerPolicy:    PolicyReject,
+			FrequencyPolicy:  PolicyReject,
+			DownlinkFrame:    downlinkFrame(915000000, 14, 7),
+			ExpectedAckError: ErrorTXFreq,
+		},
+		{
+			Name:              "invalid frequency - rx2",
+			TXPowerPolicy:     PolicyReject,
+			FrequencyPolicy:   PolicyRX2,
+			DownlinkFrame:     downlinkFrame(915000000, 14, 7),
+			ExpectedFrequency: 869525000,
+			ExpectedPower:     14,
+			ExpectedSF:        12,
+			ExpectedSubstitutions: map[string]string{
+				"substituted_frequency": "915000000 -> 869525000",
+				"substituted_data_rate": "SF7BW125 -> SF12BW125",
+			},
+		},
+		{
+			Name:             "invalid data-rate - reject",
+			TXPowerPolicy:    PolicyReject,
+			FrequencyPolicy:  PolicyReject,
+			DownlinkFrame:    downlinkFrame(868100000, 14, 13),
+			ExpectedAckError: ErrorDataRate,
+		},
+		{
+			Name:              "invalid data-rate - rx2",
+			TXPowerPolicy:     PolicyReject,
+			FrequencyPolicy:   PolicyRX2,
+			DownlinkFrame:     downlinkFrame(868100000, 14, 13),
+			ExpectedFrequency: 869525000,
+			ExpectedPower:     14,
+			ExpectedSF:        12,
+			ExpectedSubstitutions: map[string]string{
+				"substituted_frequency": "868100000 -> 869525000",
+				"substituted_data_rate": "SF13BW125 -> SF12BW125",
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.DownlinkPolicy.Enabled = true
+			conf.DownlinkPolicy.Region = "EU868"
+			conf.DownlinkPolicy.MaxTXPower = tst.MaxTXPower
+			conf.DownlinkPolicy.TXPowerPolicy = tst.TXPowerPolicy
+			conf.DownlinkPolicy.FrequencyPolicy = tst.FrequencyPolicy
+			assert.NoError(Setup(conf))
+
+			assert.Equal(tst.ExpectedAckError, Apply(&tst.DownlinkFrame))
+			if tst.ExpectedAckError != "" {
+				return
+			}
+
+			txInfo := tst.DownlinkFrame.TxInfo
+			assert.Equal(tst.ExpectedFrequency, txInfo.Frequency)
+			assert.Equal(tst.ExpectedPower, txInfo.Power)
+			assert.Equal(tst.ExpectedSF, txInfo.GetLoraModulationInfo().SpreadingFactor)
+			assert.Equal(tst.ExpectedSubstitutions, Substitutions(gatewayID, 123))
+			assert.Nil(Substitutions(gatewayID, 123))
+		})
+	}
+
+	t.Run("invalid region", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.DownlinkPolicy.Enabled = true
+		conf.DownlinkPolicy.Region = "FOO"
+		conf.DownlinkPolicy.TXPowerPolicy = PolicyReject
+		conf.DownlinkPolicy.FrequencyPolicy = PolicyReject
+		assert.Error(Setup(conf))
+	})
+
+	t.Run("invalid policy", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.DownlinkPolicy.Enabled = true
+		conf.DownlinkPolicy.Region = "EU868"
+		conf.DownlinkPolicy.TXPowerPolicy = PolicyRX2
+		conf.DownlinkPolicy.FrequencyPolicy = PolicyReject
+		assert.Error(Setup(conf))
+	})
+}
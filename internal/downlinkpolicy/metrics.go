@@ -0,0 +1,17 @@
+package downlinkpolicy
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	pc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "downlink_policy_count",
+		Help: "The number of downlinks failing validation (per check and applied policy).",
+	}, []string{"check", "policy"})
+)
+
+func policyCounter(check, policy string) prometheus.Counter {
+	return pc.With(prometheus.Labels{"check": check, "policy": policy})
+}
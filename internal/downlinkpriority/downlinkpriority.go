@@ -0,0 +1,162 @@
+// Package downlinkpriority implements the priority of the downlinks, used by
+// the backends to order the per gateway send queue. Class-A responses have
+// tight deadlines (the RX1 / RX2 windows) and must preempt class-C and
+// multicast transmissions. By default the priority is derived from the
+// timing of the downlink, it can be overridden using the priority key in the
+// meta-data of the downlink command.
+package downlinkpriority
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+// MetaDataKey defines the meta-data key of the downlink command holding the
+// priority.
+const MetaDataKey = "priority"
+
+// Priority defines the downlink priority.
+type Priority int
+
+// Downlink priorities.
+const (
+	// Low is the priority of IMMEDIATELY timing (e.g. class-C) and multicast
+	// downlinks.
+	Low Priority = iota
+
+	// Normal is the priority of GPS_EPOCH timing (e.g. class-B) downlinks.
+	Normal
+
+	// High is the priority of DELAY timing (e.g. class-A) downlinks.
+	High
+)
+
+// String implements fmt.Stringer.
+func (p Priority) String() string {
+	switch p {
+	case Low:
+		return "low"
+	case Normal:
+		return "normal"
+	case High:
+		return "high"
+	default:
+		return fmt.Sprintf("Priority(%d)", int(p))
+	}
+}
+
+// retention defines how long an explicit priority is kept when the downlink
+// is never sent by the backend.
+const retention = time.Minute
+
+type entry struct {
+	created  time.Time
+	priority Priority
+}
+
+var (
+	mux     sync.Mutex
+	entries = make(map[string]entry)
+	cleaned time.Time
+)
+
+// Parse parses the given priority (low, normal or high).
+func Parse(s string) (Priority, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "low":
+		return Low, nil
+	case "normal":
+		return Normal, nil
+	case "high":
+		return High, nil
+	default:
+		return Low, fmt.Errorf("invalid priority: %s", s)
+	}
+}
+
+// FromTiming returns the priority derived from the timing of the given
+// downlink.
+func FromTiming(frame gw.DownlinkFrame) Priority {
+	switch frame.GetTxInfo().GetTiming() {
+	case gw.DownlinkTiming_DELAY:
+		return High
+	case gw.DownlinkTiming_GPS_EPOCH:
+		return Normal
+	default:
+		return Low
+	}
+}
+
+// Set sets the explicit priority of the given downlink, overriding the
+// priority derived from its timing.
+func Set(gatewayID []byte, token uint32, p Priority) {
+	set(gatewayID, token, p, time.Now())
+}
+
+// SetFromMetaData sets the explicit priority of the given downlink when the
+// given meta-data contains the priority key.
+func SetFromMetaData(gatewayID []byte, token uint32, metaData map[string]string) error {
+	s, ok := metaData[MetaDataKey]
+	if !ok {
+		return nil
+	}
+
+	p, err := Parse(s)
+	if err != nil {
+		return err
+	}
+
+	Set(gatewayID, token, p)
+	return nil
+}
+
+// Get returns the priority of the given downlink. This returns the explicit
+// priority (which is removed) when set, else the priority derived from the
+// timing of the downlink.
+func Get(frame gw.DownlinkFrame) Priority {
+	mux.Lock()
+	defer mux.Unlock()
+
+	k := key(frame.GetTxInfo().GetGatewayId(), frame.Token)
+	if e, ok := entries[k]; ok {
+		delete(entries, k)
+		return e.priority
+	}
+
+	return FromTiming(frame)
+}
+
+func set(gatewayID []byte, token uint32, p Priority, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	cleanup(now)
+	entries[key(gatewayID, token)] = entry{
+		created:  now,
+		priority: p,
+	}
+}
+
+// cleanup removes the explicit priorities exceeding the retention. A lock
+// must be held by the caller.
+func cleanup(now time.Time) {
+	if now.Sub(cleaned) < retention {
+		return
+	}
+
+	for k, e := range entries {
+		if now.Sub(e.created) > retention {
+			delete(entries, k)
+		}
+	}
+	cleaned = now
+}
+
+func key(gatewayID []byte, token uint32) string {
+	return fmt.Sprintf("%s/%d", hex.EncodeToString(gatewayID), token)
+}
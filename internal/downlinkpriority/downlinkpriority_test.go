@@ -0,0 +1,90 @@
+package downlinkpriority
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Value    string
+		Priority Priority
+		Error    bool
+	}{
+		{"low", "low", Low, false},
+		{"normal", "Normal", Normal, false},
+		{"high", " HIGH ", High, false},
+		{"invalid", "urgent", Low, true},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			p, err := Parse(tst.Value)
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Priority, p)
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	gatewayID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+
+	frame := func(token uint32, timing gw.DownlinkTiming) gw.DownlinkFrame {
+		return gw.DownlinkFrame{
+			Token: token,
+			TxInfo: &gw.DownlinkTXInfo{
+				GatewayId: gatewayID,
+				Timing:    timing,
+			},
+		}
+	}
+
+	t.Run("from timing", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.Equal(High, Get(frame(1, gw.DownlinkTiming_DELAY)))
+		assert.Equal(Normal, Get(frame(1, gw.DownlinkTiming_GPS_EPOCH)))
+		assert.Equal(Low, Get(frame(1, gw.DownlinkTiming_IMMEDIATELY)))
+	})
+
+	t.Run("from meta-data", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(SetFromMetaData(gatewayID, 2, map[string]string{"priority": "high"}))
+		assert.Equal(High, Get(frame(2, gw.DownlinkTiming_IMMEDIATELY)))
+
+		// the explicit priority is removed once used
+		assert.Equal(Low, Get(frame(2, gw.DownlinkTiming_IMMEDIATELY)))
+
+		assert.NoError(SetFromMetaData(gatewayID, 3, nil))
+		assert.Equal(High, Get(frame(3, gw.DownlinkTiming_DELAY)))
+
+		assert.Error(SetFromMetaData(gatewayID, 4, map[string]string{"priority": "urgent"}))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert := require.New(t)
+		now := time.Now()
+
+		set(gatewayID, 5, High, now)
+		set(gatewayID, 6, High, now.Add(2*retention))
+
+		mux.Lock()
+		_, ok := entries[key(gatewayID, 5)]
+		assert.False(ok)
+		_, ok = entries[key(gatewayID, 6)]
+		assert.True(ok)
+		mux.Unlock()
+	})
+}
@@ -0,0 +1,162 @@
+// Package downlinkswitch implements the switch to disable the downlink
+// transmission globally or per gateway (receive-only mode). Downlinks for
+// disabled gateways are not sent, a DISABLED ack is published instead.
+package downlinkswitch
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+type switchRequest struct {
+	GatewayID   lorawan.EUI64 `json:"gatewayID"`
+	AllGateways bool          `json:"allGateways"`
+	Disabled    bool          `json:"disabled"`
+}
+
+var (
+	mux sync.RWMutex
+
+	disabledAll bool
+	gateways    = make(map[lorawan.EUI64]struct{})
+)
+
+// Setup configures the downlink switch.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	disabledAll = conf.DownlinkSwitch.DisableAll
+	gateways = make(map[lorawan.EUI64]struct{})
+
+	for _, id := range conf.DownlinkSwitch.DisabledGatewayIDs {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(id)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+		gateways[gatewayID] = struct{}{}
+	}
+
+	if disabledAll || len(gateways) != 0 {
+		log.WithFields(log.Fields{
+			"disable_all": disabledAll,
+			"gateways":    len(gateways),
+		}).Warning("downlinkswitch: downlinks are disabled")
+	}
+
+	admin.HandleFunc("/api/downlink-switch", handleHTTP)
+
+	return nil
+}
+
+// Disabled returns true when the downlinks of the given gateway are
+// disabled.
+func Disabled(gatewayID lorawan.EUI64) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if disabledAll {
+		return true
+	}
+
+	_, ok := gateways[gatewayID]
+	return ok
+}
+
+// SetAll disables or enables the downlinks of all gateways. Enabling the
+// downlinks of all gateways also enables the downlinks of the individually
+// disabled gateways.
+func SetAll(disabled bool) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	disabledAll = disabled
+	if !disabled {
+		gateways = make(map[lorawan.EUI64]struct{})
+	}
+
+	log.WithField("disabled", disabled).Info("downlinkswitch: downlinks of all gateways switched")
+}
+
+// Set disables or enables the downlinks of the given gateway.
+func Set(gatewayID lorawan.EUI64, disabled bool) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if disabled {
+		gateways[gatewayID] = struct{}{}
+	} else {
+		delete(gateways, gatewayID)
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"disabled":   disabled,
+	}).Info("downlinkswitch: downlinks of gateway switched")
+}
+
+// HandleRequest handles the downlink_switch command.
+func HandleRequest(req Request) {
+	if req.GetAllGateways() {
+		SetAll(req.GetDisabled())
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], req.GetGatewayId())
+	Set(gatewayID, req.GetDisabled())
+}
+
+func get() (bool, []lorawan.EUI64) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	var out []lorawan.EUI64
+	for id := range gateways {
+		out = append(out, id)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].String() < out[j].String()
+	})
+
+	return disabledAll, out
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// disabled gateways, a POST request disables or enables the downlinks.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req switchRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+			return
+		}
+
+		if req.AllGateways {
+			SetAll(req.Disabled)
+		} else {
+			Set(req.GatewayID, req.Disabled)
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	all, ids := get()
+	admin.WriteJSON(w, struct {
+		DisabledAll bool            `json:"disabledAll"`
+		Gateways    []lorawan.EUI64 `json:"gateways"`
+	}{all, ids})
+}
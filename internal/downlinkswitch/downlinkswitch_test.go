@@ -0,0 +1,70 @@
+package downlinkswitch
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+func TestDownlinkSwitch(t *testing.T) {
+	assert := require.New(t)
+
+	gatewayID1 := lorawan.EUI64{1, 1, 1, 1, 1, 1, 1, 1}
+	gatewayID2 := lorawan.EUI64{2, 2, 2, 2, 2, 2, 2, 2}
+
+	var conf config.Config
+	conf.DownlinkSwitch.DisabledGatewayIDs = []string{"0101010101010101"}
+	assert.NoError(Setup(conf))
+
+	t.Run("configured gateway", func(t *testing.T) {
+		assert := require.New(t)
+		assert.True(Disabled(gatewayID1))
+		assert.False(Disabled(gatewayID2))
+	})
+
+	t.Run("enable gateway", func(t *testing.T) {
+		assert := require.New(t)
+		HandleRequest(Request{GatewayId: gatewayID1[:]})
+		assert.False(Disabled(gatewayID1))
+	})
+
+	t.Run("disable gateway", func(t *testing.T) {
+		assert := require.New(t)
+		HandleRequest(Request{GatewayId: gatewayID2[:], Disabled: true})
+		assert.False(Disabled(gatewayID1))
+		assert.True(Disabled(gatewayID2))
+
+		all, ids := get()
+		assert.False(all)
+		assert.Equal([]lorawan.EUI64{gatewayID2}, ids)
+	})
+
+	t.Run("disable all gateways", func(t *testing.T) {
+		assert := require.New(t)
+		HandleRequest(Request{AllGateways: true, Disabled: true})
+		assert.True(Disabled(gatewayID1))
+		assert.True(Disabled(gatewayID2))
+	})
+
+	t.Run("enable all gateways", func(t *testing.T) {
+		assert := require.New(t)
+		HandleRequest(Request{AllGateways: true})
+		assert.False(Disabled(gatewayID1))
+		assert.False(Disabled(gatewayID2))
+
+		all, ids := get()
+		assert.False(all)
+		assert.Len(ids, 0)
+	})
+
+	t.Run("invalid gateway id", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.DownlinkSwitch.DisabledGatewayIDs = []string{"foo"}
+		assert.Error(Setup(conf))
+	})
+}
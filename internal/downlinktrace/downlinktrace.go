@@ -0,0 +1,159 @@
+// Package downlinktrace traces the latency of the downlink path. For each
+// downlink, the duration of each stage (from receiving the command from the
+// integration up to receiving the gateway ack) is recorded as a Prometheus
+// histogram, so that it can be determined where RX window misses are
+// introduced. On ack, the spans of the downlink are logged (debug level).
+package downlinktrace
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	log "github.com/sirupsen/logrus"
+)
+
+// Downlink stages. Each stage ends at the moment the stage is marked and
+// starts at the end of the previous stage.
+const (
+	// StageUnmarshal ends after the command has been unmarshaled by the
+	// integration.
+	StageUnmarshal = "unmarshal"
+
+	// StageQueue ends when the forwarder starts handling the downlink.
+	StageQueue = "queue"
+
+	// StageConvert ends after the downlink has been converted into the
+	// backend format.
+	StageConvert = "convert"
+
+	// StageWrite ends after the downlink has been written to the gateway
+	// connection (UDP or websocket).
+	StageWrite = "write"
+
+	// StageAck ends when the gateway ack has been received.
+	StageAck = "ack"
+)
+
+// retention defines how long a downlink is traced. Downlinks which are not
+// acked within this duration are removed.
+const retention = time.Minute
+
+type span struct {
+	stage    string
+	duration time.Duration
+}
+
+type trace struct {
+	downlinkID []byte
+	started    time.Time
+	last       time.Time
+	spans      []span
+}
+
+var (
+	mux     sync.Mutex
+	traces  = make(map[string]*trace)
+	cleaned time.Time
+)
+
+// Start starts the trace of the given downlink, received from the
+// integration at the given time, and records the unmarshal stage.
+func Start(gatewayID []byte, token uint32, downlinkID []byte, received time.Time) {
+	start(gatewayID, token, downlinkID, received, time.Now())
+}
+
+// Stage records the given stage of the given downlink. It is a no-op when
+// the downlink is not traced.
+func Stage(gatewayID []byte, token uint32, stage string) {
+	mark(gatewayID, token, stage, time.Now())
+}
+
+// Ack records the ack stage of the given downlink and ends its trace.
+func Ack(gatewayID []byte, token uint32) {
+	t := mark(gatewayID, token, StageAck, time.Now())
+	if t == nil {
+		return
+	}
+	Remove(gatewayID, token)
+
+	var downID uuid.UUID
+	copy(downID[:], t.downlinkID)
+
+	fields := log.Fields{
+		"gateway_id":  hex.EncodeToString(gatewayID),
+		"downlink_id": downID,
+		"total":       t.last.Sub(t.started),
+	}
+	for _, s := range t.spans {
+		fields[s.stage] = s.duration
+	}
+
+	log.WithFields(fields).Debug("downlinktrace: downlink spans")
+}
+
+// Remove ends the trace of the given downlink, e.g. when it could not be
+// sent to the gateway.
+func Remove(gatewayID []byte, token uint32) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	delete(traces, key(gatewayID, token))
+}
+
+func key(gatewayID []byte, token uint32) string {
+	return fmt.Sprintf("%s/%d", hex.EncodeToString(gatewayID), token)
+}
+
+func start(gatewayID []byte, token uint32, downlinkID []byte, received, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if now.Sub(cleaned) > retention {
+		for k, t := range traces {
+			if now.Sub(t.started) > retention {
+				delete(traces, k)
+			}
+		}
+		cleaned = now
+	}
+
+	t := trace{
+		downlinkID: downlinkID,
+		started:    received,
+		last:       received,
+	}
+	observe(&t, StageUnmarshal, now)
+
+	traces[key(gatewayID, token)] = &t
+}
+
+// mark records the stage and returns a copy of the trace. It returns nil
+// when the downlink is not traced.
+func mark(gatewayID []byte, token uint32, stage string, now time.Time) *trace {
+	mux.Lock()
+	defer mux.Unlock()
+
+	t, ok := traces[key(gatewayID, token)]
+	if !ok {
+		return nil
+	}
+
+	observe(t, stage, now)
+
+	out := *t
+	out.spans = append([]span(nil), t.spans...)
+	return &out
+}
+
+// observe records the duration of the stage. A lock must be held by the
+// caller.
+func observe(t *trace, stage string, now time.Time) {
+	d := now.Sub(t.last)
+	t.last = now
+	t.spans = append(t.spans, span{stage: stage, duration: d})
+
+	stageDuration(stage).Observe(d.Seconds())
+}
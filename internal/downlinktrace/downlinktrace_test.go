@@ -0,0 +1,54 @@
+package downlinktrace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	gatewayID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Now()
+
+	start(gatewayID, 123, []byte{1, 2, 3}, now, now.Add(time.Millisecond))
+
+	t.Run("mark stages", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NotNil(mark(gatewayID, 123, StageQueue, now.Add(3*time.Millisecond)))
+		tr := mark(gatewayID, 123, StageConvert, now.Add(6*time.Millisecond))
+		assert.NotNil(tr)
+
+		assert.Equal([]span{
+			{stage: StageUnmarshal, duration: time.Millisecond},
+			{stage: StageQueue, duration: 2 * time.Millisecond},
+			{stage: StageConvert, duration: 3 * time.Millisecond},
+		}, tr.spans)
+		assert.Equal(6*time.Millisecond, tr.last.Sub(tr.started))
+	})
+
+	t.Run("unknown downlink", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Nil(mark(gatewayID, 124, StageQueue, now))
+	})
+
+	t.Run("remove", func(t *testing.T) {
+		assert := require.New(t)
+		Remove(gatewayID, 123)
+		assert.Nil(mark(gatewayID, 123, StageWrite, now))
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert := require.New(t)
+
+		start(gatewayID, 1, nil, now, now)
+		start(gatewayID, 2, nil, now.Add(2*retention), now.Add(2*retention))
+
+		mux.Lock()
+		assert.Len(traces, 1)
+		_, ok := traces[key(gatewayID, 2)]
+		assert.True(ok)
+		mux.Unlock()
+	})
+}
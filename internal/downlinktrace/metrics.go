@@ -0,0 +1,18 @@
+package downlinktrace
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	sd = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "downlink_stage_duration_seconds",
+		Help:    "The duration of each stage of the downlink path (per stage).",
+		Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+	}, []string{"stage"})
+)
+
+func stageDuration(stage string) prometheus.Observer {
+	return sd.With(prometheus.Labels{"stage": stage})
+}
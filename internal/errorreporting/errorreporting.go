@@ -0,0 +1,278 @@
+// Package errorreporting forwards panics and error-level log events to Sentry
+// or to a generic webhook. It is implemented as a logrus hook, so that the
+// log fields (e.g. gateway_id) are included as context.
+package errorreporting
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
+)
+
+// event contains a single error event.
+type event struct {
+	Level   string                 `json:"level"`
+	Time    time.Time              `json:"time"`
+	Message string                 `json:"message"`
+	Backend string                 `json:"backend"`
+	Fields  map[string]interface{} `json:"fields"`
+}
+
+// sender sends the given event.
+type sender func(client *http.Client, e event) error
+
+var (
+	send        sender
+	backend     string
+	environment string
+	client      *http.Client
+	eventChan   chan event
+)
+
+// Setup configures the error reporting.
+func Setup(conf config.Config) error {
+	c := conf.General.ErrorReporting
+
+	switch c.Type {
+	case "":
+		return nil
+	case "sentry":
+		s, err := newSentrySender(c.SentryDSN)
+		if err != nil {
+			return errors.Wrap(err, "new sentry sender error")
+		}
+		send = s
+	case "webhook":
+		if c.WebhookURL == "" {
+			return errors.New("webhook_url must be set")
+		}
+		send = newWebhookSender(c.WebhookURL)
+	default:
+		return fmt.Errorf("unknown error reporting type: %s", c.Type)
+	}
+
+	backend = conf.Backend.Type
+	environment = c.Environment
+	client = &http.Client{
+		Timeout:   c.Timeout,
+		Transport: proxy.Transport(),
+	}
+	eventChan = make(chan event, 100)
+
+	go sendLoop()
+
+	log.AddHook(hook{})
+
+	log.WithField("type", c.Type).Info("errorreporting: error reporting enabled")
+
+	return nil
+}
+
+// Recover reports the recovered panic (if any) and re-panics. It must be
+// called using defer.
+func Recover() {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	if send != nil {
+		e := event{
+			Level:   "fatal",
+			Time:    time.Now(),
+			Message: fmt.Sprintf("panic: %v", r),
+			Backend: backend,
+			Fields: map[string]interface{}{
+				"stack": string(debug.Stack()),
+			},
+		}
+
+		// send synchronously, as the process is about to exit
+		if err := send(client, e); err != nil {
+			log.WithError(err).Warning("errorreporting: report panic error")
+		}
+	}
+
+	panic(r)
+}
+
+func sendLoop() {
+	for e := range eventChan {
+		if err := send(client, e); err != nil {
+			// don't use log.Error here, as this would trigger the hook again
+			log.WithError(err).Warning("errorreporting: send error event error")
+		}
+	}
+}
+
+// hook implements a logrus hook.
+type hook struct{}
+
+// Levels returns the levels for which the hook is fired.
+func (hook) Levels() []log.Level {
+	return []log.Level{log.PanicLevel, log.FatalLevel, log.ErrorLevel}
+}
+
+// Fire queues the given log entry for reporting. When the queue is full,
+// the entry is dropped so that logging never blocks.
+func (hook) Fire(entry *log.Entry) error {
+	e := event{
+		Level:   entry.Level.String(),
+		Time:    entry.Time,
+		Message: entry.Message,
+		Backend: backend,
+		Fields:  make(map[string]interface{}),
+	}
+
+	for k, v := range entry.Data {
+		switch v := v.(type) {
+		case error:
+			e.Fields[k] = v.Error()
+		case fmt.Stringer:
+			e.Fields[k] = v.String()
+		default:
+			e.Fields[k] = v
+		}
+	}
+
+	select {
+	case eventChan <- e:
+	default:
+	}
+
+	return nil
+}
+
+func newWebhookSender(webhookURL string) sender {
+	return func(client *http.Client, e event) error {
+		b, err := json.Marshal(e)
+		if err != nil {
+			return errors.Wrap(err, "marshal json error")
+		}
+
+		return post(client, webhookURL, nil, b)
+	}
+}
+
+// sentryEvent implements the Sentry event payload.
+type sentryEvent struct {
+	EventID     string                 `json:"event_id"`
+	Timestamp   string                 `json:"timestamp"`
+	Level       string                 `json:"level"`
+	Logger      string                 `json:"logger"`
+	Platform    string                 `json:"platform"`
+	Message     string                 `json:"message"`
+	Environment string                 `json:"environment,omitempty"`
+	Tags        map[string]string      `json:"tags"`
+	Extra       map[string]interface{} `json:"extra"`
+}
+
+// sentryTags contains the fields that are sent as Sentry tags (which can be
+// used for grouping and searching), other fields are sent as extra data.
+var sentryTags = []string{"gateway_id", "type", "event_type", "error"}
+
+func newSentrySender(dsn string) (sender, error) {
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse dsn error")
+	}
+
+	if u.User == nil || u.User.Username() == "" {
+		return nil, errors.New("dsn must contain the public key")
+	}
+
+	i := strings.LastIndex(u.Path, "/")
+	if i == -1 || u.Path[i+1:] == "" {
+		return nil, errors.New("dsn must contain the project id")
+	}
+
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=lora-gateway-bridge, sentry_key=%s", u.User.Username())
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+
+	storeURL := fmt.Sprintf("%s://%s%s/api/%s/store/", u.Scheme, u.Host, u.Path[:i], u.Path[i+1:])
+	headers := map[string]string{
+		"X-Sentry-Auth": auth,
+	}
+
+	return func(client *http.Client, e event) error {
+		id, err := uuid.NewV4()
+		if err != nil {
+			return errors.Wrap(err, "new uuid error")
+		}
+
+		level := e.Level
+		if level == "panic" {
+			level = "fatal"
+		}
+
+		se := sentryEvent{
+			EventID:     hex.EncodeToString(id[:]),
+			Timestamp:   e.Time.UTC().Format("2006-01-02T15:04:05"),
+			Level:       level,
+			Logger:      "lora-gateway-bridge",
+			Platform:    "go",
+			Message:     e.Message,
+			Environment: environment,
+			Tags: map[string]string{
+				"backend": e.Backend,
+			},
+			Extra: make(map[string]interface{}),
+		}
+
+		for k, v := range e.Fields {
+			se.Extra[k] = v
+		}
+		for _, k := range sentryTags {
+			if v, ok := se.Extra[k]; ok {
+				se.Tags[k] = fmt.Sprintf("%v", v)
+				delete(se.Extra, k)
+			}
+		}
+
+		b, err := json.Marshal(se)
+		if err != nil {
+			return errors.Wrap(err, "marshal json error")
+		}
+
+		return post(client, storeURL, headers, b)
+	}, nil
+}
+
+func post(client *http.Client, url string, headers map[string]string, b []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "new request error")
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "http post error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("expected 2xx response, got: %d", resp.StatusCode)
+	}
+
+	return nil
+}
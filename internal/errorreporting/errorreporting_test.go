@@ -0,0 +1,155 @@
+package errorreporting
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+type testServer struct {
+	server   *httptest.Server
+	requests chan *http.Request
+	bodies   chan []byte
+}
+
+func newTestServer() *testServer {
+	ts := testServer{
+		requests: make(chan *http.Request, 1),
+		bodies:   make(chan []byte, 1),
+	}
+	ts.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		ts.requests <- r
+		ts.bodies <- b
+	}))
+	return &ts
+}
+
+func TestHook(t *testing.T) {
+	assert := require.New(t)
+
+	eventChan = make(chan event, 1)
+	backend = "semtech_udp"
+
+	entry := log.WithFields(log.Fields{
+		"gateway_id": lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		"error":      errors.New("boom"),
+		"count":      3,
+	})
+	entry.Level = log.ErrorLevel
+	entry.Message = "something failed"
+
+	assert.NoError(hook{}.Fire(entry))
+
+	e := <-eventChan
+	assert.Equal("error", e.Level)
+	assert.Equal("something failed", e.Message)
+	assert.Equal("semtech_udp", e.Backend)
+	assert.Equal(map[string]interface{}{
+		"gateway_id": "0102030405060708",
+		"error":      "boom",
+		"count":      3,
+	}, e.Fields)
+
+	t.Run("Queue full", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(hook{}.Fire(entry))
+		assert.NoError(hook{}.Fire(entry))
+		assert.Len(eventChan, 1)
+	})
+}
+
+func TestWebhookSender(t *testing.T) {
+	assert := require.New(t)
+
+	ts := newTestServer()
+	defer ts.server.Close()
+
+	e := event{
+		Level:   "error",
+		Time:    time.Now().UTC().Truncate(time.Second),
+		Message: "something failed",
+		Backend: "basic_station",
+		Fields: map[string]interface{}{
+			"gateway_id": "0102030405060708",
+		},
+	}
+
+	assert.NoError(newWebhookSender(ts.server.URL)(http.DefaultClient, e))
+
+	r := <-ts.requests
+	assert.Equal("application/json", r.Header.Get("Content-Type"))
+
+	var received event
+	assert.NoError(json.Unmarshal(<-ts.bodies, &received))
+	assert.Equal(e, received)
+}
+
+func TestSentrySender(t *testing.T) {
+	t.Run("Invalid DSN", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := newSentrySender("https://sentry.example.com/1")
+		assert.Error(err)
+
+		_, err = newSentrySender("https://public@sentry.example.com/")
+		assert.Error(err)
+	})
+
+	t.Run("Send", func(t *testing.T) {
+		assert := require.New(t)
+
+		ts := newTestServer()
+		defer ts.server.Close()
+
+		environment = "test"
+		s, err := newSentrySender("http://public:secret@" + ts.server.Listener.Addr().String() + "/prefix/42")
+		assert.NoError(err)
+
+		assert.NoError(s(http.DefaultClient, event{
+			Level:   "error",
+			Time:    time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC),
+			Message: "something failed",
+			Backend: "semtech_udp",
+			Fields: map[string]interface{}{
+				"gateway_id": "0102030405060708",
+				"count":      3,
+			},
+		}))
+
+		r := <-ts.requests
+		assert.Equal("/prefix/api/42/store/", r.URL.Path)
+		assert.Equal("Sentry sentry_version=7, sentry_client=lora-gateway-bridge, sentry_key=public, sentry_secret=secret", r.Header.Get("X-Sentry-Auth"))
+
+		var se sentryEvent
+		assert.NoError(json.Unmarshal(<-ts.bodies, &se))
+		assert.Len(se.EventID, 32)
+		se.EventID = ""
+
+		assert.Equal(sentryEvent{
+			Timestamp:   "2019-09-10T12:00:00",
+			Level:       "error",
+			Logger:      "lora-gateway-bridge",
+			Platform:    "go",
+			Message:     "something failed",
+			Environment: "test",
+			Tags: map[string]string{
+				"backend":    "semtech_udp",
+				"gateway_id": "0102030405060708",
+			},
+			Extra: map[string]interface{}{
+				"count": float64(3),
+			},
+		}, se)
+	})
+}
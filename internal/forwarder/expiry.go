@@ -0,0 +1,123 @@
+package forwarder
+
+import (
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan/gps"
+)
+
+// downlinkTooLate is the TXAck error used for expired downlinks.
+const downlinkTooLate = "TOO_LATE"
+
+// downlinkSendError is the multicast result error used when the downlink
+// could not be sent to the gateway.
+const downlinkSendError = "SEND_ERROR"
+
+// downlinkDisabled is the TXAck error used for downlinks of gateways of
+// which the downlinks are disabled.
+const downlinkDisabled = "DISABLED"
+
+// errDownlinkTooLate is returned when the downlink has expired.
+var errDownlinkTooLate = errors.New("downlink too late")
+
+// errDownlinkDisabled is returned when the downlinks of the gateway are
+// disabled.
+var errDownlinkDisabled = errors.New("downlink disabled")
+
+// downlinkRejectedError is returned when the downlink was rejected by the
+// downlink policies. It holds the TXAck error.
+type downlinkRejectedError string
+
+func (e downlinkRejectedError) Error() string {
+	return "downlink rejected: " + string(e)
+}
+
+// uplinkContexts stores the receive time per uplink context. As Class-A
+// downlinks embed the context of the uplink they respond to, this is used to
+// determine the age of a downlink.
+type uplinkContexts struct {
+	sync.Mutex
+	times map[string]time.Time
+}
+
+func (c *uplinkContexts) key(gatewayID, context []byte) string {
+	return hex.EncodeToString(gatewayID) + "/" + hex.EncodeToString(context)
+}
+
+// store stores the receive time of the given uplink context.
+func (c *uplinkContexts) store(gatewayID, context []byte, t time.Time) {
+	c.Lock()
+	defer c.Unlock()
+
+	c.times[c.key(gatewayID, context)] = t
+}
+
+// get returns the receive time of the given uplink context.
+func (c *uplinkContexts) get(gatewayID, context []byte) (time.Time, bool) {
+	c.Lock()
+	defer c.Unlock()
+
+	t, ok := c.times[c.key(gatewayID, context)]
+	return t, ok
+}
+
+// cleanup removes the contexts received before the given time.
+func (c *uplinkContexts) cleanup(before time.Time) {
+	c.Lock()
+	defer c.Unlock()
+
+	for k, t := range c.times {
+		if t.Before(before) {
+			delete(c.times, k)
+		}
+	}
+}
+
+// downlinkExpired returns true when the given downlink frame can't be
+// transmitted in time, or exceeds the given max age (when > 0). A downlink
+// can't be transmitted in time when less than minLeadTime remains before its
+// transmission time. The age is based on the given enqueue time (when not
+// zero), else for delay timing on the receive time of the uplink context.
+// Without enqueue time, downlinks with immediate timing or with an unknown
+// context never expire.
+func downlinkExpired(contexts *uplinkContexts, maxAge, minLeadTime time.Duration, df gw.DownlinkFrame, enqueuedAt, now time.Time) (bool, error) {
+	txInfo := df.GetTxInfo()
+
+	if maxAge > 0 && !enqueuedAt.IsZero() && now.Sub(enqueuedAt) > maxAge {
+		return true, nil
+	}
+
+	switch txInfo.GetTiming() {
+	case gw.DownlinkTiming_DELAY:
+		rxTime, ok := contexts.get(txInfo.GetGatewayId(), txInfo.GetContext())
+		if !ok {
+			return false, nil
+		}
+
+		delay, err := ptypes.Duration(txInfo.GetDelayTimingInfo().GetDelay())
+		if err != nil {
+			return false, errors.Wrap(err, "get delay duration error")
+		}
+
+		if (maxAge > 0 && now.Sub(rxTime) > maxAge) || now.Add(minLeadTime).After(rxTime.Add(delay)) {
+			return true, nil
+		}
+	case gw.DownlinkTiming_GPS_EPOCH:
+		timeSinceGPSEpoch, err := ptypes.Duration(txInfo.GetGpsEpochTimingInfo().GetTimeSinceGpsEpoch())
+		if err != nil {
+			return false, errors.Wrap(err, "get time since gps epoch error")
+		}
+
+		if now.Add(minLeadTime).After(time.Time(gps.NewTimeFromTimeSinceGPSEpoch(timeSinceGPSEpoch))) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
@@ -0,0 +1,156 @@
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan/gps"
+)
+
+func TestDownlinkExpired(t *testing.T) {
+	now := time.Now()
+	contexts := uplinkContexts{times: make(map[string]time.Time)}
+
+	gatewayID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	contexts.store(gatewayID, []byte{1, 2, 3, 4}, now.Add(-500*time.Millisecond))
+	contexts.store(gatewayID, []byte{4, 3, 2, 1}, now.Add(-1500*time.Millisecond))
+
+	delayTxInfo := func(context []byte, delay time.Duration) *gw.DownlinkTXInfo {
+		return &gw.DownlinkTXInfo{
+			GatewayId: gatewayID,
+			Context:   context,
+			Timing:    gw.DownlinkTiming_DELAY,
+			TimingInfo: &gw.DownlinkTXInfo_DelayTimingInfo{
+				DelayTimingInfo: &gw.DelayTimingInfo{
+					Delay: ptypes.DurationProto(delay),
+				},
+			},
+		}
+	}
+
+	gpsTxInfo := func(t time.Time) *gw.DownlinkTXInfo {
+		return &gw.DownlinkTXInfo{
+			GatewayId: gatewayID,
+			Timing:    gw.DownlinkTiming_GPS_EPOCH,
+			TimingInfo: &gw.DownlinkTXInfo_GpsEpochTimingInfo{
+				GpsEpochTimingInfo: &gw.GPSEpochTimingInfo{
+					TimeSinceGpsEpoch: ptypes.DurationProto(gps.Time(t).TimeSinceGPSEpoch()),
+				},
+			},
+		}
+	}
+
+	tests := []struct {
+		Name        string
+		TxInfo      *gw.DownlinkTXInfo
+		MaxAge      time.Duration
+		MinLeadTime time.Duration
+		EnqueuedAt  time.Time
+		Expected    bool
+	}{
+		{
+			Name:   "immediately",
+			TxInfo: &gw.DownlinkTXInfo{Timing: gw.DownlinkTiming_IMMEDIATELY},
+			MaxAge: time.Millisecond,
+		},
+		{
+			Name:       "immediately enqueued within max age",
+			TxInfo:     &gw.DownlinkTXInfo{Timing: gw.DownlinkTiming_IMMEDIATELY},
+			MaxAge:     time.Second,
+			EnqueuedAt: now.Add(-500 * time.Millisecond),
+		},
+		{
+			Name:       "immediately enqueued exceeds max age",
+			TxInfo:     &gw.DownlinkTXInfo{Timing: gw.DownlinkTiming_IMMEDIATELY},
+			MaxAge:     100 * time.Millisecond,
+			EnqueuedAt: now.Add(-500 * time.Millisecond),
+			Expected:   true,
+		},
+		{
+			Name:       "immediately enqueued without max age",
+			TxInfo:     &gw.DownlinkTXInfo{Timing: gw.DownlinkTiming_IMMEDIATELY},
+			EnqueuedAt: now.Add(-time.Hour),
+		},
+		{
+			Name:   "delay within max age",
+			TxInfo: delayTxInfo([]byte{1, 2, 3, 4}, time.Second),
+			MaxAge: time.Second,
+		},
+		{
+			Name:     "delay exceeds max age",
+			TxInfo:   delayTxInfo([]byte{1, 2, 3, 4}, time.Second),
+			MaxAge:   100 * time.Millisecond,
+			Expected: true,
+		},
+		{
+			Name:     "delay rx window passed",
+			TxInfo:   delayTxInfo([]byte{4, 3, 2, 1}, time.Second),
+			MaxAge:   time.Minute,
+			Expected: true,
+		},
+		{
+			Name:   "delay unknown context",
+			TxInfo: delayTxInfo([]byte{5, 5, 5, 5}, time.Second),
+			MaxAge: time.Millisecond,
+		},
+		{
+			Name:       "delay unknown context enqueued exceeds max age",
+			TxInfo:     delayTxInfo([]byte{5, 5, 5, 5}, time.Second),
+			MaxAge:     100 * time.Millisecond,
+			EnqueuedAt: now.Add(-500 * time.Millisecond),
+			Expected:   true,
+		},
+		{
+			Name:        "delay within min lead time",
+			TxInfo:      delayTxInfo([]byte{1, 2, 3, 4}, time.Second),
+			MinLeadTime: 100 * time.Millisecond,
+		},
+		{
+			Name:        "delay exceeds min lead time",
+			TxInfo:      delayTxInfo([]byte{1, 2, 3, 4}, time.Second),
+			MinLeadTime: 600 * time.Millisecond,
+			Expected:    true,
+		},
+		{
+			Name:   "gps epoch in future",
+			TxInfo: gpsTxInfo(now.Add(time.Second)),
+			MaxAge: time.Second,
+		},
+		{
+			Name:     "gps epoch passed",
+			TxInfo:   gpsTxInfo(now.Add(-time.Second)),
+			MaxAge:   time.Second,
+			Expected: true,
+		},
+		{
+			Name:        "gps epoch exceeds min lead time",
+			TxInfo:      gpsTxInfo(now.Add(time.Second)),
+			MinLeadTime: 2 * time.Second,
+			Expected:    true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			expired, err := downlinkExpired(&contexts, tst.MaxAge, tst.MinLeadTime, gw.DownlinkFrame{TxInfo: tst.TxInfo}, tst.EnqueuedAt, now)
+			assert.NoError(err)
+			assert.Equal(tst.Expected, expired)
+		})
+	}
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert := require.New(t)
+
+		contexts.cleanup(now.Add(-time.Second))
+		_, ok := contexts.get(gatewayID, []byte{1, 2, 3, 4})
+		assert.True(ok)
+		_, ok = contexts.get(gatewayID, []byte{4, 3, 2, 1})
+		assert.False(ok)
+	})
+}
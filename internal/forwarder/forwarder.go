@@ -1,20 +1,52 @@
 package forwarder
 
 import (
+	"time"
+
 	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/accounting"
+	"github.com/brocaar/lora-gateway-bridge/internal/antennagain"
+	"github.com/brocaar/lora-gateway-bridge/internal/archive"
 	"github.com/brocaar/lora-gateway-bridge/internal/backend"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/events"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/configfanout"
+	"github.com/brocaar/lora-gateway-bridge/internal/debug"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkexpiry"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkpolicy"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkpriority"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinktrace"
+	"github.com/brocaar/lora-gateway-bridge/internal/errorreporting"
 	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/latency"
 	"github.com/brocaar/lora-gateway-bridge/internal/metadata"
+	"github.com/brocaar/lora-gateway-bridge/internal/metrics"
+	"github.com/brocaar/lora-gateway-bridge/internal/multicast"
+	"github.com/brocaar/lora-gateway-bridge/internal/pipeline"
+	"github.com/brocaar/lora-gateway-bridge/internal/registry"
+	"github.com/brocaar/lora-gateway-bridge/internal/reorder"
+	"github.com/brocaar/lora-gateway-bridge/internal/testdownlink"
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
 )
 
 var alwaysSubscribe []lorawan.EUI64
 
+var (
+	downlinkMaxAge        time.Duration
+	downlinkMinLeadTime   time.Duration
+	publishDownlinkTiming bool
+	contexts              = uplinkContexts{times: make(map[string]time.Time)}
+	timings               = downlinkTimings{timings: make(map[string]downlinkTiming)}
+	handleEvent           pipeline.Handler
+)
+
 func Setup(conf config.Config) error {
 	b := backend.GetBackend()
 	i := integration.GetIntegration()
@@ -27,17 +59,42 @@ func Setup(conf config.Config) error {
 		return errors.New("integration is not set")
 	}
 
-	for _, c := range conf.Backend.SemtechUDP.Configuration {
-		var gatewayID lorawan.EUI64
-		if err := gatewayID.UnmarshalText([]byte(c.GatewayID)); err != nil {
-			return errors.Wrap(err, "unmarshal gateway_id error")
-		}
+	// with shared subscriptions, a command could be delivered to an instance
+	// to which the gateway is not connected, the gateways are then only
+	// subscribed when connected
+	if err := preRegister(i, conf, !integration.SharedSubscriptions(conf)); err != nil {
+		return err
+	}
+
+	handleEvent = pipeline.Chain(publishEvent)
+
+	configfanout.SetApplyFunc(b.ApplyConfiguration)
+	reorder.SetHandler(handleUplinkFrame)
+
+	downlinkMaxAge = conf.Integration.DownlinkMaxAge
+	publishDownlinkTiming = conf.Integration.PublishDownlinkTiming
 
-		if err := i.SubscribeGateway(gatewayID); err != nil {
-			return errors.Wrap(err, "subscribe gateway error")
+	switch conf.Backend.Type {
+	case "semtech_udp":
+		downlinkMinLeadTime = conf.Backend.SemtechUDP.DownlinkMinLeadTime
+	case "basic_station":
+		downlinkMinLeadTime = conf.Backend.BasicStation.DownlinkMinLeadTime
+	}
+
+	if trackUplinkContexts() {
+		// keep the contexts at least a minute, which covers all RX windows
+		retention := time.Minute
+		if downlinkMaxAge > retention {
+			retention = downlinkMaxAge
 		}
 
-		alwaysSubscribe = append(alwaysSubscribe, gatewayID)
+		go func() {
+			for {
+				time.Sleep(time.Minute)
+				contexts.cleanup(time.Now().Add(-retention))
+				timings.cleanup(time.Now().Add(-retention))
+			}
+		}()
 	}
 
 	go onConnectedLoop()
@@ -48,123 +105,680 @@ func Setup(conf config.Config) error {
 	go forwardDownlinkTxAckLoop()
 	go forwardDownlinkFrameLoop()
 	go forwardGatewayConfigurationLoop()
+	go forwardUploadLoop()
+	go forwardConfigurationDiffLoop()
+	go forwardProtocolErrorLoop()
+	go forwardCertExpiryLoop()
+	go forwardNotifyLoop()
+	go forwardMulticastLoop()
+	go forwardDownlinkSwitchLoop()
+	go expireMulticastLoop()
+
+	return nil
+}
+
+// preRegister subscribes the gateways configured in the Semtech UDP backend
+// configuration and the pre-registered gateways, independent of their
+// connection state unless pin is false. For each pre-registered gateway, the
+// REGISTERED conn event is published.
+func preRegister(i integration.Integration, conf config.Config, pin bool) error {
+	for _, c := range conf.Backend.SemtechUDP.Configuration {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(c.GatewayID)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+
+		if err := alwaysSubscribeGateway(i, gatewayID, pin); err != nil {
+			return err
+		}
+	}
+
+	for _, id := range conf.PreRegistration.GatewayIDs {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(id)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+
+		if err := alwaysSubscribeGateway(i, gatewayID, pin); err != nil {
+			return err
+		}
+
+		log.WithField("gateway_id", gatewayID).Info("gateway pre-registered")
+		publishConnState(i, events.Connection{GatewayID: gatewayID}, integration.ConnStateRegistered)
+	}
+
+	return nil
+}
+
+// alwaysSubscribeGateway subscribes the given gateway independent of its
+// connection state, unless pin is false.
+func alwaysSubscribeGateway(i integration.Integration, gatewayID lorawan.EUI64, pin bool) error {
+	if !pin {
+		return nil
+	}
+
+	if err := i.SubscribeGateway(gatewayID); err != nil {
+		return errors.Wrap(err, "subscribe gateway error")
+	}
+
+	alwaysSubscribe = append(alwaysSubscribe, gatewayID)
+	registry.Pin(gatewayID)
 
 	return nil
 }
 
 func onConnectedLoop() {
-	for gatewayID := range backend.GetBackend().GetConnectChan() {
+	for conn := range backend.GetBackend().GetConnectChan() {
 		var found bool
 		for _, gwID := range alwaysSubscribe {
-			if gatewayID == gwID {
+			if conn.GatewayID == gwID {
 				found = true
 			}
 		}
-		if found {
-			break
-		}
 
-		if err := integration.GetIntegration().SubscribeGateway(gatewayID); err != nil {
-			log.WithError(err).Error("subscribe gateway error")
+		// the gateway is registered before subscribing, else the
+		// subscription could be seen as orphan by the integration
+		registry.Connected(conn.GatewayID)
+
+		if !found {
+			if err := integration.GetIntegration().SubscribeGateway(conn.GatewayID); err != nil {
+				log.WithError(err).Error("subscribe gateway error")
+			}
 		}
+
+		publishConnState(integration.GetIntegration(), conn, integration.ConnStateOnline)
 	}
 }
 
 func onDisconnectedLoop() {
-	for gatewayID := range backend.GetBackend().GetDisconnectChan() {
+	for conn := range backend.GetBackend().GetDisconnectChan() {
+		registry.Disconnected(conn.GatewayID)
+		publishConnState(integration.GetIntegration(), conn, integration.ConnStateOffline)
+
 		var found bool
 		for _, gwID := range alwaysSubscribe {
-			if gatewayID == gwID {
+			if conn.GatewayID == gwID {
 				found = true
 			}
 		}
 		if found {
-			break
+			continue
 		}
 
-		if err := integration.GetIntegration().UnsubscribeGateway(gatewayID); err != nil {
+		if err := integration.GetIntegration().UnsubscribeGateway(conn.GatewayID); err != nil {
 			log.WithError(err).Error("unsubscribe gateway error")
 		}
 	}
 }
 
+func publishConnState(i integration.Integration, conn events.Connection, state string) {
+	connID, err := uuid.NewV4()
+	if err != nil {
+		log.WithError(err).Error("new uuid error")
+		return
+	}
+
+	connState := integration.ConnState{
+		GatewayId: conn.GatewayID[:],
+		State:     state,
+		Reason:    conn.Reason,
+		CloseCode: uint32(conn.CloseCode),
+	}
+
+	if err := i.PublishEvent(conn.GatewayID, integration.EventConn, connID, &connState); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"gateway_id": conn.GatewayID,
+			"event_type": integration.EventConn,
+			"conn_id":    connID,
+		}).Error("publish event error")
+	}
+}
+
 func forwardUplinkFrameLoop() {
 	for uplinkFrame := range backend.GetBackend().GetUplinkFrameChan() {
 		go func(uplinkFrame gw.UplinkFrame) {
-			var gatewayID lorawan.EUI64
-			var uplinkID uuid.UUID
-			copy(gatewayID[:], uplinkFrame.RxInfo.GatewayId)
-			copy(uplinkID[:], uplinkFrame.RxInfo.UplinkId)
+			defer errorreporting.Recover()
 
-			if err := integration.GetIntegration().PublishEvent(gatewayID, integration.EventUp, uplinkID, &uplinkFrame); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"gateway_id": gatewayID,
-					"event_type": integration.EventUp,
-					"uplink_id":  uplinkID,
-				}).Error("publish event error")
+			// the reorder buffer calls handleUplinkFrame once released
+			if reorder.Enabled() {
+				reorder.Add(uplinkFrame)
+				return
 			}
+
+			handleUplinkFrame(uplinkFrame)
 		}(uplinkFrame)
 	}
 }
 
+func handleUplinkFrame(uplinkFrame gw.UplinkFrame) {
+	defer errorreporting.Recover()
+
+	e := pipeline.Event{
+		Type:    integration.EventUp,
+		Message: &uplinkFrame,
+	}
+	copy(e.GatewayID[:], uplinkFrame.RxInfo.GatewayId)
+	copy(e.ID[:], uplinkFrame.RxInfo.UplinkId)
+
+	handle(&e)
+}
+
 func forwardGatewayStatsLoop() {
 	for stats := range backend.GetBackend().GetGatewayStatsChan() {
 		go func(stats gw.GatewayStats) {
-			var gatewayID lorawan.EUI64
-			var statsID uuid.UUID
-			copy(gatewayID[:], stats.GatewayId)
-			copy(statsID[:], stats.StatsId)
+			defer errorreporting.Recover()
 
-			// add meta-data to stats
-			stats.MetaData = metadata.Get()
+			applyMetaData(&stats)
 
-			if err := integration.GetIntegration().PublishEvent(gatewayID, integration.EventStats, statsID, &stats); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"gateway_id": gatewayID,
-					"event_type": integration.EventStats,
-					"stats_id":   statsID,
-				}).Error("publish event error")
+			e := pipeline.Event{
+				Type:    integration.EventStats,
+				Message: &stats,
 			}
+			copy(e.GatewayID[:], stats.GatewayId)
+			copy(e.ID[:], stats.StatsId)
+
+			handle(&e)
 		}(stats)
 	}
 }
 
+// applyMetaData adds the configured meta-data to the given stats, before
+// these pass the pipeline. The configured meta-data takes precedence over
+// the meta-data reported by the gateway (e.g. vendor specific stat fields).
+func applyMetaData(stats *gw.GatewayStats) {
+	if len(stats.MetaData) == 0 {
+		stats.MetaData = metadata.Get()
+		return
+	}
+
+	for k, v := range metadata.Get() {
+		stats.MetaData[k] = v
+	}
+}
+
 func forwardDownlinkTxAckLoop() {
 	for txAck := range backend.GetBackend().GetDownlinkTXAckChan() {
 		go func(txAck gw.DownlinkTXAck) {
-			var gatewayID lorawan.EUI64
-			copy(gatewayID[:], txAck.GatewayId)
+			defer errorreporting.Recover()
 
-			var downID uuid.UUID
-			copy(downID[:], txAck.DownlinkId)
+			// the ack of a test downlink is returned to the caller
+			if testdownlink.Ack(txAck) {
+				return
+			}
 
-			if err := integration.GetIntegration().PublishEvent(gatewayID, integration.EventAck, downID, &txAck); err != nil {
-				log.WithError(err).WithFields(log.Fields{
-					"gateway_id":  gatewayID,
-					"event_type":  integration.EventAck,
-					"downlink_id": downID,
-				}).Error("publish event error")
+			downlinktrace.Ack(txAck.GatewayId, txAck.Token)
+
+			if res, ok := multicast.Ack(txAck); ok {
+				publishMulticastResult(res)
+			}
+
+			e := pipeline.Event{
+				Type:    integration.EventAck,
+				Message: &txAck,
 			}
+			copy(e.GatewayID[:], txAck.GatewayId)
+			copy(e.ID[:], txAck.DownlinkId)
+
+			handle(&e)
 		}(txAck)
 	}
 }
 
+// handle passes the event through the pipeline.
+func handle(e *pipeline.Event) {
+	registry.Seen(e.GatewayID)
+
+	if err := handleEvent(e); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"gateway_id": e.GatewayID,
+			"event_type": e.Type,
+			"event_id":   e.ID,
+		}).Error("handle event error")
+	}
+}
+
+// publishEvent is the final handler of the pipeline, it publishes the event
+// using the integration.
+func publishEvent(e *pipeline.Event) error {
+	if uplinkFrame, ok := e.Message.(*gw.UplinkFrame); ok && trackUplinkContexts() {
+		contexts.store(uplinkFrame.RxInfo.GatewayId, uplinkFrame.RxInfo.Context, time.Now())
+	}
+
+	msg := e.Message
+	if txAck, ok := e.Message.(*gw.DownlinkTXAck); ok {
+		if metaData := downlinkTXAckMetaData(*txAck); metaData != nil {
+			msg = &integration.DownlinkTXAck{
+				GatewayId:  txAck.GatewayId,
+				Token:      txAck.Token,
+				Error:      txAck.Error,
+				DownlinkId: txAck.DownlinkId,
+				MetaData:   metaData,
+			}
+		}
+	}
+
+	if err := integration.GetIntegration().PublishEvent(e.GatewayID, e.Type, e.ID, msg); err != nil {
+		return errors.Wrap(err, "publish event error")
+	}
+
+	return nil
+}
+
+// downlinkTXAckMetaData returns the meta-data of the given ack, containing
+// the substitutions of the downlink policies and the timing breakdown (when
+// enabled). It returns nil when there is no meta-data.
+func downlinkTXAckMetaData(txAck gw.DownlinkTXAck) map[string]string {
+	metaData := downlinkpolicy.Substitutions(txAck.GatewayId, txAck.Token)
+
+	if publishDownlinkTiming {
+		if timing, ok := timings.acked(txAck, time.Now()); ok {
+			if metaData == nil {
+				metaData = make(map[string]string)
+			}
+			for k, v := range timing {
+				metaData[k] = v
+			}
+		}
+	}
+
+	return metaData
+}
+
 func forwardDownlinkFrameLoop() {
 	for downlinkFrame := range integration.GetIntegration().GetDownlinkFrameChan() {
 		go func(downlinkFrame gw.DownlinkFrame) {
-			if err := backend.GetBackend().SendDownlinkFrame(downlinkFrame); err != nil {
+			defer errorreporting.Recover()
+
+			err := forwardDownlinkFrame(downlinkFrame)
+			if _, rejected := err.(downlinkRejectedError); err != nil && err != errDownlinkTooLate && err != errDownlinkDisabled && !rejected {
 				log.WithError(err).Error("send downlink frame error")
 			}
 		}(downlinkFrame)
 	}
 }
 
+// forwardDownlinkFrame sends the downlink frame to the gateway. It returns
+// errDownlinkTooLate when the downlink has expired, in which case the
+// TOO_LATE ack has been published, errDownlinkDisabled when the downlinks
+// of the gateway are disabled, in which case the DISABLED ack has been
+// published, or a downlinkRejectedError when the downlink was rejected by the
+// downlink policies, in which case the ack with its error has been published.
+func forwardDownlinkFrame(downlinkFrame gw.DownlinkFrame) error {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], downlinkFrame.GetTxInfo().GetGatewayId())
+
+	downlinktrace.Stage(gatewayID[:], downlinkFrame.Token, downlinktrace.StageQueue)
+	debug.DumpFrame(gatewayID, "down", &downlinkFrame)
+
+	if downlinkswitch.Disabled(gatewayID) {
+		downlinktrace.Remove(gatewayID[:], downlinkFrame.Token)
+		publishDownlinkError(downlinkFrame, downlinkDisabled)
+		return errDownlinkDisabled
+	}
+
+	if ackError := downlinkpolicy.Apply(&downlinkFrame); ackError != "" {
+		downlinktrace.Remove(gatewayID[:], downlinkFrame.Token)
+		publishDownlinkError(downlinkFrame, ackError)
+		return downlinkRejectedError(ackError)
+	}
+
+	// the downlink policies apply to the EIRP, the gateway expects the
+	// conducted TX power
+	antennagain.ApplyToDownlinkFrame(&downlinkFrame)
+
+	if publishDownlinkTiming {
+		timings.received(&contexts, downlinkFrame, time.Now())
+	}
+
+	enqueuedAt, _ := downlinkexpiry.Get(gatewayID[:], downlinkFrame.Token)
+
+	if downlinkMaxAge > 0 || downlinkMinLeadTime > 0 || latency.Enabled() {
+		// the predicted backhaul latency must be covered as well, else the
+		// downlink would miss its rx window
+		predicted := latency.LeadTime(gatewayID)
+
+		expired, err := downlinkExpired(&contexts, downlinkMaxAge, downlinkMinLeadTime+predicted, downlinkFrame, enqueuedAt, time.Now())
+		if err != nil {
+			log.WithError(err).Error("downlink expiry check error")
+		}
+		if expired {
+			log.WithFields(log.Fields{
+				"gateway_id":          gatewayID,
+				"predicted_lead_time": predicted,
+			}).Debug("downlink expired, publishing TOO_LATE ack")
+
+			timings.remove(downlinkFrame)
+			downlinktrace.Remove(gatewayID[:], downlinkFrame.Token)
+			publishDownlinkError(downlinkFrame, downlinkTooLate)
+			return errDownlinkTooLate
+		}
+	}
+
+	if err := backend.GetBackend().SendDownlinkFrame(downlinkFrame); err != nil {
+		timings.remove(downlinkFrame)
+		downlinktrace.Remove(gatewayID[:], downlinkFrame.Token)
+		downlinkpolicy.Remove(gatewayID[:], downlinkFrame.Token)
+		return err
+	}
+
+	accounting.Downlink(gatewayID, len(downlinkFrame.PhyPayload))
+	metrics.Downlink(downlinkFrame)
+
+	if publishDownlinkTiming {
+		timings.forwarded(downlinkFrame, time.Now())
+	}
+
+	return nil
+}
+
+func forwardMulticastLoop() {
+	for req := range integration.GetIntegration().GetMulticastRequestChan() {
+		go func(req multicast.Request) {
+			defer errorreporting.Recover()
+
+			frames, err := multicast.FanOut(req)
+			if err != nil {
+				log.WithError(err).Error("multicast fan-out error")
+				return
+			}
+
+			start := time.Now()
+			for _, scheduled := range multicast.Schedule(frames) {
+				downlinkFrame := scheduled.DownlinkFrame
+
+				// register the error as the ack of this gateway, as the
+				// gateway will not ack the downlink
+				txAck := gw.DownlinkTXAck{
+					GatewayId:  downlinkFrame.GetTxInfo().GetGatewayId(),
+					Token:      downlinkFrame.Token,
+					DownlinkId: downlinkFrame.DownlinkId,
+					Error:      scheduled.Error,
+				}
+
+				if txAck.Error == "" {
+					time.Sleep(time.Until(start.Add(scheduled.Delay)))
+
+					// class-A responses must preempt the multicast downlinks,
+					// also for GPS_EPOCH timing (class-B multicast)
+					downlinkpriority.Set(downlinkFrame.GetTxInfo().GetGatewayId(), downlinkFrame.Token, downlinkpriority.Low)
+
+					err := forwardDownlinkFrame(downlinkFrame)
+					if err == nil {
+						continue
+					}
+
+					switch err {
+					case errDownlinkTooLate:
+						txAck.Error = downlinkTooLate
+					case errDownlinkDisabled:
+						txAck.Error = downlinkDisabled
+					default:
+						if rejected, ok := err.(downlinkRejectedError); ok {
+							txAck.Error = string(rejected)
+							break
+						}
+						log.WithError(err).Error("send multicast downlink frame error")
+						txAck.Error = downlinkSendError
+					}
+				}
+
+				if res, ok := multicast.Ack(txAck); ok {
+					publishMulticastResult(res)
+				}
+			}
+		}(req)
+	}
+}
+
+func forwardDownlinkSwitchLoop() {
+	for req := range integration.GetIntegration().GetDownlinkSwitchRequestChan() {
+		downlinkswitch.HandleRequest(req)
+	}
+}
+
+func expireMulticastLoop() {
+	for {
+		time.Sleep(time.Second)
+
+		for _, res := range multicast.Expire() {
+			publishMulticastResult(res)
+		}
+	}
+}
+
+// publishMulticastResult publishes the multicast result. As events are
+// published per gateway, the event of the first gateway is used.
+func publishMulticastResult(res multicast.Result) {
+	if len(res.Items) == 0 {
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	var multicastID uuid.UUID
+	copy(gatewayID[:], res.Items[0].GatewayId)
+	copy(multicastID[:], res.MulticastId)
+
+	if err := integration.GetIntegration().PublishEvent(gatewayID, integration.EventMulticast, multicastID, &res); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"gateway_id":   gatewayID,
+			"event_type":   integration.EventMulticast,
+			"multicast_id": multicastID,
+		}).Error("publish event error")
+	}
+}
+
 func forwardGatewayConfigurationLoop() {
 	for gatewayConfig := range integration.GetIntegration().GetGatewayConfigurationChan() {
 		go func(gatewayConfig gw.GatewayConfiguration) {
+			defer errorreporting.Recover()
+
 			if err := backend.GetBackend().ApplyConfiguration(gatewayConfig); err != nil {
 				log.WithError(err).Error("apply gateway-configuration error")
 			}
 		}(gatewayConfig)
 	}
 }
+
+func forwardUploadLoop() {
+	for upload := range backend.GetBackend().GetUploadChan() {
+		go func(upload events.Upload) {
+			defer errorreporting.Recover()
+
+			pl := integration.Upload{
+				GatewayId: upload.GatewayID[:],
+				UploadId:  upload.UploadID[:],
+				Location:  upload.Location,
+				Size:      uint32(upload.Size),
+			}
+
+			if err := integration.GetIntegration().PublishEvent(upload.GatewayID, integration.EventUpload, upload.UploadID, &pl); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"gateway_id": upload.GatewayID,
+					"event_type": integration.EventUpload,
+					"upload_id":  upload.UploadID,
+				}).Error("publish event error")
+			}
+		}(upload)
+	}
+}
+
+func forwardConfigurationDiffLoop() {
+	for diff := range backend.GetBackend().GetConfigurationDiffChan() {
+		go func(diff events.ConfigurationDiff) {
+			defer errorreporting.Recover()
+
+			diffID, err := uuid.NewV4()
+			if err != nil {
+				log.WithError(err).Error("new uuid error")
+				return
+			}
+
+			pl := integration.ConfigDiff{
+				GatewayId: diff.GatewayID[:],
+				Version:   diff.Version,
+			}
+			for _, c := range diff.Changes {
+				pl.Changes = append(pl.Changes, &integration.ConfigChange{
+					Path:     c.Path,
+					OldValue: c.OldValue,
+					NewValue: c.NewValue,
+				})
+			}
+
+			if err := integration.GetIntegration().PublishEvent(diff.GatewayID, integration.EventConfigDiff, diffID, &pl); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"gateway_id": diff.GatewayID,
+					"event_type": integration.EventConfigDiff,
+					"diff_id":    diffID,
+				}).Error("publish event error")
+			}
+		}(diff)
+	}
+}
+
+func forwardProtocolErrorLoop() {
+	for pe := range backend.GetBackend().GetProtocolErrorChan() {
+		go func(pe events.ProtocolError) {
+			defer errorreporting.Recover()
+
+			errorID, err := uuid.NewV4()
+			if err != nil {
+				log.WithError(err).Error("new uuid error")
+				return
+			}
+
+			pl := integration.ProtocolError{
+				GatewayId:   pe.GatewayID[:],
+				MessageType: pe.MessageType,
+				Error:       pe.Error,
+				Payload:     pe.Payload,
+			}
+
+			if err := integration.GetIntegration().PublishEvent(pe.GatewayID, integration.EventProtocolError, errorID, &pl); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"gateway_id": pe.GatewayID,
+					"event_type": integration.EventProtocolError,
+					"error_id":   errorID,
+				}).Error("publish event error")
+			}
+		}(pe)
+	}
+}
+
+func forwardCertExpiryLoop() {
+	for ce := range backend.GetBackend().GetCertExpiryChan() {
+		go func(ce events.CertExpiry) {
+			defer errorreporting.Recover()
+
+			eventID, err := uuid.NewV4()
+			if err != nil {
+				log.WithError(err).Error("new uuid error")
+				return
+			}
+
+			notAfter, err := ptypes.TimestampProto(ce.NotAfter)
+			if err != nil {
+				log.WithError(err).Error("timestamp proto error")
+				return
+			}
+
+			pl := integration.CertExpiry{
+				GatewayId:   ce.GatewayID[:],
+				CommonName:  ce.CommonName,
+				Fingerprint: ce.Fingerprint,
+				NotAfter:    notAfter,
+			}
+
+			if err := integration.GetIntegration().PublishEvent(ce.GatewayID, integration.EventCertExpiry, eventID, &pl); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"gateway_id": ce.GatewayID,
+					"event_type": integration.EventCertExpiry,
+					"event_id":   eventID,
+				}).Error("publish event error")
+			}
+		}(ce)
+	}
+}
+
+func forwardNotifyLoop() {
+	for n := range backend.GetBackend().GetNotifyChan() {
+		go func(n events.Notify) {
+			defer errorreporting.Recover()
+
+			eventID, err := uuid.NewV4()
+			if err != nil {
+				log.WithError(err).Error("new uuid error")
+				return
+			}
+
+			ts, err := ptypes.TimestampProto(n.Time)
+			if err != nil {
+				log.WithError(err).Error("timestamp proto error")
+				return
+			}
+
+			pl := integration.Notify{
+				GatewayId: n.GatewayID[:],
+				Level:     n.Level,
+				Code:      n.Code,
+				Message:   n.Message,
+				Time:      ts,
+			}
+
+			if err := integration.GetIntegration().PublishEvent(n.GatewayID, integration.EventNotify, eventID, &pl); err != nil {
+				log.WithError(err).WithFields(log.Fields{
+					"gateway_id": n.GatewayID,
+					"event_type": integration.EventNotify,
+					"event_id":   eventID,
+				}).Error("publish event error")
+			}
+		}(n)
+	}
+}
+
+// publishDownlinkError publishes the ack with the given error for a
+// downlink frame which was not sent to the gateway.
+func publishDownlinkError(downlinkFrame gw.DownlinkFrame, ackError string) {
+	var gatewayID lorawan.EUI64
+	var downID uuid.UUID
+	copy(gatewayID[:], downlinkFrame.GetTxInfo().GetGatewayId())
+	copy(downID[:], downlinkFrame.GetDownlinkId())
+
+	log.WithFields(log.Fields{
+		"gateway_id":  gatewayID,
+		"downlink_id": downID,
+		"error":       ackError,
+	}).Warning("downlink frame not sent")
+
+	txAck := gw.DownlinkTXAck{
+		GatewayId:  gatewayID[:],
+		Token:      downlinkFrame.Token,
+		DownlinkId: downlinkFrame.DownlinkId,
+		Error:      ackError,
+	}
+
+	archiveEvent(gatewayID, integration.EventAck, downID, &txAck)
+
+	if err := integration.GetIntegration().PublishEvent(gatewayID, integration.EventAck, downID, &txAck); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"gateway_id":  gatewayID,
+			"event_type":  integration.EventAck,
+			"downlink_id": downID,
+		}).Error("publish event error")
+	}
+}
+
+// trackUplinkContexts returns true when the receive time of uplink contexts
+// must be stored.
+func trackUplinkContexts() bool {
+	return downlinkMaxAge > 0 || downlinkMinLeadTime > 0 || publishDownlinkTiming || latency.Enabled()
+}
+
+func archiveEvent(gatewayID lorawan.EUI64, event string, id uuid.UUID, msg proto.Message) {
+	if err := archive.Store(gatewayID, event, id, msg); err != nil {
+		log.WithError(err).WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"event_type": event,
+		}).Error("archive event error")
+	}
+}
@@ -0,0 +1,143 @@
+package forwarder
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/registry"
+	"github.com/brocaar/lorawan"
+)
+
+type testEvent struct {
+	gatewayID lorawan.EUI64
+	event     string
+	message   proto.Message
+}
+
+// testIntegration records the subscriptions and the published events. The
+// methods which are not used by the tests are not implemented.
+type testIntegration struct {
+	integration.Integration
+
+	subscribed []lorawan.EUI64
+	events     []testEvent
+}
+
+func (i *testIntegration) SubscribeGateway(gatewayID lorawan.EUI64) error {
+	i.subscribed = append(i.subscribed, gatewayID)
+	return nil
+}
+
+func (i *testIntegration) PublishEvent(gatewayID lorawan.EUI64, event string, id uuid.UUID, v proto.Message) error {
+	i.events = append(i.events, testEvent{gatewayID: gatewayID, event: event, message: v})
+	return nil
+}
+
+func TestPreRegister(t *testing.T) {
+	gatewayID1 := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	gatewayID2 := lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}
+
+	tests := []struct {
+		Name                  string
+		SemtechUDPGatewayIDs  []string
+		PreRegGatewayIDs      []string
+		Pin                   bool
+		ExpectedSubscribed    []lorawan.EUI64
+		ExpectedSubscriptions []lorawan.EUI64
+		ExpectedRegistered    []lorawan.EUI64
+		ExpectedError         string
+	}{
+		{
+			Name: "no gateways",
+			Pin:  true,
+		},
+		{
+			Name:                  "pre-registered gateways",
+			PreRegGatewayIDs:      []string{"0102030405060708", "0807060504030201"},
+			Pin:                   true,
+			ExpectedSubscribed:    []lorawan.EUI64{gatewayID1, gatewayID2},
+			ExpectedSubscriptions: []lorawan.EUI64{gatewayID1, gatewayID2},
+			ExpectedRegistered:    []lorawan.EUI64{gatewayID1, gatewayID2},
+		},
+		{
+			Name:                  "semtech udp configuration",
+			SemtechUDPGatewayIDs:  []string{"0102030405060708"},
+			Pin:                   true,
+			ExpectedSubscribed:    []lorawan.EUI64{gatewayID1},
+			ExpectedSubscriptions: []lorawan.EUI64{gatewayID1},
+		},
+		{
+			Name:                  "semtech udp configuration and pre-registered gateway",
+			SemtechUDPGatewayIDs:  []string{"0102030405060708"},
+			PreRegGatewayIDs:      []string{"0807060504030201"},
+			Pin:                   true,
+			ExpectedSubscribed:    []lorawan.EUI64{gatewayID1, gatewayID2},
+			ExpectedSubscriptions: []lorawan.EUI64{gatewayID1, gatewayID2},
+			ExpectedRegistered:    []lorawan.EUI64{gatewayID2},
+		},
+		{
+			Name:               "not pinned",
+			PreRegGatewayIDs:   []string{"0102030405060708"},
+			Pin:                false,
+			ExpectedRegistered: []lorawan.EUI64{gatewayID1},
+		},
+		{
+			Name:             "invalid pre-registered gateway id",
+			PreRegGatewayIDs: []string{"010203"},
+			Pin:              true,
+			ExpectedError:    "unmarshal gateway_id error: lorawan: exactly 8 bytes are expected",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.PreRegistration.GatewayIDs = tst.PreRegGatewayIDs
+			conf.Backend.SemtechUDP.Configuration = make([]struct {
+				GatewayID      string `mapstructure:"gateway_id"`
+				BaseFile       string `mapstructure:"base_file"`
+				OutputFile     string `mapstructure:"output_file"`
+				RestartCommand string `mapstructure:"restart_command"`
+			}, len(tst.SemtechUDPGatewayIDs))
+			for j, id := range tst.SemtechUDPGatewayIDs {
+				conf.Backend.SemtechUDP.Configuration[j].GatewayID = id
+			}
+
+			assert.NoError(registry.Setup(conf))
+			alwaysSubscribe = nil
+
+			var i testIntegration
+			err := preRegister(&i, conf, tst.Pin)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+
+			assert.Equal(tst.ExpectedSubscribed, i.subscribed)
+			assert.Equal(tst.ExpectedSubscribed, alwaysSubscribe)
+			assert.Equal(tst.ExpectedSubscriptions, registry.Subscriptions())
+
+			var registered []lorawan.EUI64
+			for _, e := range i.events {
+				assert.Equal(integration.EventConn, e.event)
+				assert.True(proto.Equal(&integration.ConnState{
+					GatewayId: e.gatewayID[:],
+					State:     integration.ConnStateRegistered,
+				}, e.message))
+				registered = append(registered, e.gatewayID)
+			}
+			assert.Equal(tst.ExpectedRegistered, registered)
+
+			// pre-registered gateways are not registered as connected
+			assert.Empty(registry.Gateways())
+		})
+	}
+}
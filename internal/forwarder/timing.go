@@ -0,0 +1,117 @@
+package forwarder
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+// downlinkTiming holds the timestamps of a single downlink.
+type downlinkTiming struct {
+	uplinkReceived    time.Time
+	downlinkReceived  time.Time
+	downlinkForwarded time.Time
+}
+
+// downlinkTimings keeps track of the timing of the downlinks that are
+// pending a gateway ack. Downlinks are identified by gateway ID and token,
+// as both are included in the ack.
+type downlinkTimings struct {
+	sync.Mutex
+	timings map[string]downlinkTiming
+}
+
+func (d *downlinkTimings) key(gatewayID []byte, token uint32) string {
+	return fmt.Sprintf("%s/%d", hex.EncodeToString(gatewayID), token)
+}
+
+// received stores the receive time of the given downlink frame. The receive
+// time of the uplink is looked up using the uplink context.
+func (d *downlinkTimings) received(contexts *uplinkContexts, df gw.DownlinkFrame, now time.Time) {
+	txInfo := df.GetTxInfo()
+	t := downlinkTiming{
+		downlinkReceived: now,
+	}
+
+	if txInfo.GetTiming() == gw.DownlinkTiming_DELAY {
+		t.uplinkReceived, _ = contexts.get(txInfo.GetGatewayId(), txInfo.GetContext())
+	}
+
+	d.Lock()
+	defer d.Unlock()
+
+	d.timings[d.key(txInfo.GetGatewayId(), df.Token)] = t
+}
+
+// forwarded stores the time the given downlink frame was sent to the gateway.
+func (d *downlinkTimings) forwarded(df gw.DownlinkFrame, now time.Time) {
+	d.Lock()
+	defer d.Unlock()
+
+	k := d.key(df.GetTxInfo().GetGatewayId(), df.Token)
+	if t, ok := d.timings[k]; ok {
+		t.downlinkForwarded = now
+		d.timings[k] = t
+	}
+}
+
+// remove removes the given downlink frame, e.g. when it could not be sent.
+func (d *downlinkTimings) remove(df gw.DownlinkFrame) {
+	d.Lock()
+	defer d.Unlock()
+
+	delete(d.timings, d.key(df.GetTxInfo().GetGatewayId(), df.Token))
+}
+
+// Meta-data keys of the timing breakdown, added to the meta-data of the ack.
+const (
+	timingUplinkReceived    = "timing_uplink_received"
+	timingDownlinkReceived  = "timing_downlink_received"
+	timingDownlinkForwarded = "timing_downlink_forwarded"
+	timingAckReceived       = "timing_ack_received"
+)
+
+// acked returns and removes the timing breakdown for the given ack, as
+// meta-data of the ack. It returns false when the downlink is unknown.
+func (d *downlinkTimings) acked(txAck gw.DownlinkTXAck, now time.Time) (map[string]string, bool) {
+	d.Lock()
+	k := d.key(txAck.GatewayId, txAck.Token)
+	t, ok := d.timings[k]
+	delete(d.timings, k)
+	d.Unlock()
+
+	if !ok {
+		return nil, false
+	}
+
+	out := make(map[string]string)
+	for key, ts := range map[string]time.Time{
+		timingUplinkReceived:    t.uplinkReceived,
+		timingDownlinkReceived:  t.downlinkReceived,
+		timingDownlinkForwarded: t.downlinkForwarded,
+		timingAckReceived:       now,
+	} {
+		if ts.IsZero() {
+			continue
+		}
+		out[key] = ts.UTC().Format(time.RFC3339Nano)
+	}
+
+	return out, true
+}
+
+// cleanup removes the downlinks received before the given time, e.g. because
+// the gateway never sent an ack.
+func (d *downlinkTimings) cleanup(before time.Time) {
+	d.Lock()
+	defer d.Unlock()
+
+	for k, t := range d.timings {
+		if t.downlinkReceived.Before(before) {
+			delete(d.timings, k)
+		}
+	}
+}
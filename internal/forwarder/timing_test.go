@@ -0,0 +1,89 @@
+package forwarder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestDownlinkTimings(t *testing.T) {
+	now := time.Now()
+	contexts := uplinkContexts{times: make(map[string]time.Time)}
+	timings := downlinkTimings{timings: make(map[string]downlinkTiming)}
+
+	format := func(t time.Time) string {
+		return t.UTC().Format(time.RFC3339Nano)
+	}
+
+	gatewayID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	contexts.store(gatewayID, []byte{1, 2, 3, 4}, now)
+
+	df := gw.DownlinkFrame{
+		Token:      1234,
+		DownlinkId: []byte{1, 2, 3},
+		TxInfo: &gw.DownlinkTXInfo{
+			GatewayId: gatewayID,
+			Context:   []byte{1, 2, 3, 4},
+			Timing:    gw.DownlinkTiming_DELAY,
+		},
+	}
+
+	timings.received(&contexts, df, now.Add(100*time.Millisecond))
+	timings.forwarded(df, now.Add(110*time.Millisecond))
+
+	t.Run("unknown ack", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, ok := timings.acked(gw.DownlinkTXAck{GatewayId: gatewayID, Token: 4321}, now)
+		assert.False(ok)
+	})
+
+	t.Run("ack", func(t *testing.T) {
+		assert := require.New(t)
+
+		metaData, ok := timings.acked(gw.DownlinkTXAck{GatewayId: gatewayID, Token: 1234}, now.Add(150*time.Millisecond))
+		assert.True(ok)
+
+		assert.Equal(map[string]string{
+			"timing_uplink_received":    format(now),
+			"timing_downlink_received":  format(now.Add(100 * time.Millisecond)),
+			"timing_downlink_forwarded": format(now.Add(110 * time.Millisecond)),
+			"timing_ack_received":       format(now.Add(150 * time.Millisecond)),
+		}, metaData)
+
+		// the timing has been removed
+		_, ok = timings.acked(gw.DownlinkTXAck{GatewayId: gatewayID, Token: 1234}, now)
+		assert.False(ok)
+	})
+
+	t.Run("class-c", func(t *testing.T) {
+		assert := require.New(t)
+
+		df := gw.DownlinkFrame{
+			Token: 5678,
+			TxInfo: &gw.DownlinkTXInfo{
+				GatewayId: gatewayID,
+				Timing:    gw.DownlinkTiming_IMMEDIATELY,
+			},
+		}
+
+		timings.received(&contexts, df, now)
+		metaData, ok := timings.acked(gw.DownlinkTXAck{GatewayId: gatewayID, Token: 5678}, now.Add(50*time.Millisecond))
+		assert.True(ok)
+		assert.Equal(map[string]string{
+			"timing_downlink_received": format(now),
+			"timing_ack_received":      format(now.Add(50 * time.Millisecond)),
+		}, metaData)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		assert := require.New(t)
+
+		timings.received(&contexts, df, now)
+		timings.cleanup(now.Add(time.Second))
+		assert.Len(timings.timings, 0)
+	})
+}
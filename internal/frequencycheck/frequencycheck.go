@@ -0,0 +1,308 @@
+// Package frequencycheck detects uplinks received on a frequency outside the
+// configured frequency range. This typically indicates a gateway configured
+// with the wrong channel-plan. The mismatches are aggregated per gateway and
+// reported using a metric and the notify event (FREQUENCY_MISMATCH).
+package frequencycheck
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+// notifyCode is the code of the notify event published on a mismatch.
+const notifyCode = "FREQUENCY_MISMATCH"
+
+// maxFrequencies defines the max. number of distinct mismatching frequencies
+// that are kept per gateway.
+const maxFrequencies = 16
+
+// regionalFrequencyRanges contains the frequency range (Hz) per region.
+var regionalFrequencyRanges = map[band.Name][2]uint32{
+	band.AS923: {915000000, 928000000},
+	band.AU915: {915000000, 928000000},
+	band.CN470: {470000000, 510000000},
+	band.CN779: {779000000, 787000000},
+	band.EU433: {433175000, 434665000},
+	band.EU868: {863000000, 870000000},
+	band.IN865: {865000000, 867000000},
+	band.KR920: {920900000, 923300000},
+	band.RU864: {864000000, 870000000},
+	band.US915: {902000000, 928000000},
+}
+
+// RegionFrequencyRange returns the frequency range (Hz) of the given region.
+func RegionFrequencyRange(region band.Name) (uint32, uint32, bool) {
+	r, ok := regionalFrequencyRanges[region]
+	return r[0], r[1], ok
+}
+
+// GatewayMismatches contains the frequency mismatches of a single gateway.
+type GatewayMismatches struct {
+	GatewayID     lorawan.EUI64 `json:"gatewayID"`
+	MismatchCount int           `json:"mismatchCount"`
+	Frequencies   []uint32      `json:"frequencies"`
+	FirstSeen     time.Time     `json:"firstSeen"`
+	LastSeen      time.Time     `json:"lastSeen"`
+}
+
+type gateway struct {
+	mismatchCount int
+	frequencies   []uint32
+	firstSeen     time.Time
+	lastSeen      time.Time
+
+	// lastEvent contains the time of the last reported mismatch, pending
+	// the number of mismatches since.
+	lastEvent time.Time
+	pending   int
+}
+
+// mismatch contains a frequency mismatch to report.
+type mismatch struct {
+	gatewayID lorawan.EUI64
+	frequency uint32
+	count     int
+}
+
+var (
+	mux sync.RWMutex
+
+	enabled       bool
+	frequencyMin  uint32
+	frequencyMax  uint32
+	eventInterval time.Duration
+
+	gateways = make(map[lorawan.EUI64]*gateway)
+)
+
+// Setup configures the uplink frequency check.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !conf.FrequencyCheck.Enabled {
+		return nil
+	}
+
+	if conf.FrequencyCheck.Region != "" {
+		r, ok := regionalFrequencyRanges[band.Name(conf.FrequencyCheck.Region)]
+		if !ok {
+			return fmt.Errorf("frequency check is not supported for region: %s", conf.FrequencyCheck.Region)
+		}
+		frequencyMin = r[0]
+		frequencyMax = r[1]
+	}
+
+	if conf.FrequencyCheck.FrequencyMin != 0 {
+		frequencyMin = conf.FrequencyCheck.FrequencyMin
+	}
+	if conf.FrequencyCheck.FrequencyMax != 0 {
+		frequencyMax = conf.FrequencyCheck.FrequencyMax
+	}
+
+	if frequencyMax == 0 {
+		return errors.New("frequency check requires a region or a frequency_min and frequency_max")
+	}
+	if frequencyMin >= frequencyMax {
+		return errors.New("frequency check frequency_min must be less than frequency_max")
+	}
+
+	enabled = true
+	eventInterval = conf.FrequencyCheck.EventInterval
+
+	log.WithFields(log.Fields{
+		"frequency_min":  frequencyMin,
+		"frequency_max":  frequencyMax,
+		"event_interval": eventInterval,
+	}).Info("frequencycheck: uplink frequency check enabled")
+
+	admin.HandleFunc("/api/frequency-check", handleHTTP)
+
+	return nil
+}
+
+// Uplink checks the frequency of an uplink received by the given gateway.
+// Uplinks outside the configured frequency range are not dropped, they are
+// only reported.
+func Uplink(gatewayID lorawan.EUI64, frequency uint32) {
+	if m := uplink(gatewayID, frequency, time.Now()); m != nil {
+		go report(*m)
+	}
+}
+
+// Get returns the frequency mismatches of all gateways.
+func Get() []GatewayMismatches {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	var out []GatewayMismatches
+
+	for gatewayID, gw := range gateways {
+		out = append(out, GatewayMismatches{
+			GatewayID:     gatewayID,
+			MismatchCount: gw.mismatchCount,
+			Frequencies:   append([]uint32(nil), gw.frequencies...),
+			FirstSeen:     gw.firstSeen,
+			LastSeen:      gw.lastSeen,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].GatewayID.String() < out[j].GatewayID.String()
+	})
+
+	return out
+}
+
+// uplink registers the uplink frequency. It returns the mismatch to report
+// or nil when there is nothing to report (yet).
+func uplink(gatewayID lorawan.EUI64, frequency uint32, now time.Time) *mismatch {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled || (frequency >= frequencyMin && frequency <= frequencyMax) {
+		return nil
+	}
+
+	gw, ok := gateways[gatewayID]
+	if !ok {
+		gw = &gateway{
+			firstSeen: now,
+		}
+		gateways[gatewayID] = gw
+	}
+
+	gw.mismatchCount++
+	gw.pending++
+	gw.lastSeen = now
+	addFrequency(gw, frequency)
+	mismatchCounter(gatewayID).Inc()
+
+	if !gw.lastEvent.IsZero() && now.Sub(gw.lastEvent) < eventInterval {
+		return nil
+	}
+
+	m := mismatch{
+		gatewayID: gatewayID,
+		frequency: frequency,
+		count:     gw.pending,
+	}
+	gw.lastEvent = now
+	gw.pending = 0
+
+	return &m
+}
+
+// addFrequency adds the frequency to the (sorted) list of mismatching
+// frequencies of the gateway. This must be called with the mutex locked.
+func addFrequency(gw *gateway, frequency uint32) {
+	i := sort.Search(len(gw.frequencies), func(i int) bool {
+		return gw.frequencies[i] >= frequency
+	})
+
+	if i < len(gw.frequencies) && gw.frequencies[i] == frequency {
+		return
+	}
+
+	if len(gw.frequencies) >= maxFrequencies {
+		return
+	}
+
+	gw.frequencies = append(gw.frequencies, 0)
+	copy(gw.frequencies[i+1:], gw.frequencies[i:])
+	gw.frequencies[i] = frequency
+}
+
+func report(m mismatch) {
+	log.WithFields(log.Fields{
+		"gateway_id":     m.gatewayID,
+		"frequency":      m.frequency,
+		"mismatch_count": m.count,
+		"frequency_min":  frequencyMin,
+		"frequency_max":  frequencyMax,
+	}).Warning("frequencycheck: uplink received outside configured frequency range, check the gateway channel-plan")
+
+	if err := publishMismatch(m); err != nil {
+		log.WithError(err).WithField("gateway_id", m.gatewayID).Error("frequencycheck: publish notify event error")
+	}
+}
+
+func publishMismatch(m mismatch) error {
+	i := integration.GetIntegration()
+	if i == nil {
+		return nil
+	}
+
+	pl, err := mismatchNotify(m, time.Now())
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return errors.Wrap(err, "new uuid error")
+	}
+
+	return i.PublishEvent(m.gatewayID, integration.EventNotify, id, pl)
+}
+
+// mismatchNotify returns the notify event payload for the given mismatch.
+// The mismatches aggregated since the previous event are reported as
+// suppressed.
+func mismatchNotify(m mismatch, now time.Time) (*integration.Notify, error) {
+	ts, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return nil, errors.Wrap(err, "timestamp proto error")
+	}
+
+	return &integration.Notify{
+		GatewayId:       m.gatewayID[:],
+		Level:           "warning",
+		Code:            notifyCode,
+		Message:         fmt.Sprintf("uplink received on frequency %d outside configured frequency range %d - %d", m.frequency, frequencyMin, frequencyMax),
+		Time:            ts,
+		SuppressedCount: uint32(m.count - 1),
+		Details: &integration.Notify_FrequencyMismatch{
+			FrequencyMismatch: &integration.FrequencyMismatch{
+				GatewayId:     m.gatewayID[:],
+				Frequency:     m.frequency,
+				MismatchCount: uint32(m.count),
+				FrequencyMin:  frequencyMin,
+				FrequencyMax:  frequencyMax,
+			},
+		},
+	}, nil
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// configured frequency range and the frequency mismatches per gateway.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin.WriteJSON(w, struct {
+		FrequencyMin uint32              `json:"frequencyMin"`
+		FrequencyMax uint32              `json:"frequencyMax"`
+		Gateways     []GatewayMismatches `json:"gateways"`
+	}{
+		FrequencyMin: frequencyMin,
+		FrequencyMax: frequencyMax,
+		Gateways:     Get(),
+	})
+}
@@ -0,0 +1,200 @@
+package frequencycheck
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lorawan"
+)
+
+func setup() {
+	enabled = true
+	frequencyMin = 863000000
+	frequencyMax = 870000000
+	eventInterval = time.Hour
+	gateways = make(map[lorawan.EUI64]*gateway)
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		Name                 string
+		Region               string
+		FrequencyMin         uint32
+		FrequencyMax         uint32
+		ExpectedFrequencyMin uint32
+		ExpectedFrequencyMax uint32
+		ExpectedError        string
+	}{
+		{
+			Name:                 "region",
+			Region:               "US915",
+			ExpectedFrequencyMin: 902000000,
+			ExpectedFrequencyMax: 928000000,
+		},
+		{
+			Name:                 "region with override",
+			Region:               "US915",
+			FrequencyMin:         902300000,
+			FrequencyMax:         914900000,
+			ExpectedFrequencyMin: 902300000,
+			ExpectedFrequencyMax: 914900000,
+		},
+		{
+			Name:                 "frequency range",
+			FrequencyMin:         863000000,
+			FrequencyMax:         870000000,
+			ExpectedFrequencyMin: 863000000,
+			ExpectedFrequencyMax: 870000000,
+		},
+		{
+			Name:          "invalid region",
+			Region:        "FOO",
+			ExpectedError: "frequency check is not supported for region: FOO",
+		},
+		{
+			Name:          "no frequency range",
+			ExpectedError: "frequency check requires a region or a frequency_min and frequency_max",
+		},
+		{
+			Name:          "invalid frequency range",
+			FrequencyMin:  870000000,
+			FrequencyMax:  863000000,
+			ExpectedError: "frequency check frequency_min must be less than frequency_max",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			enabled = false
+			frequencyMin = 0
+			frequencyMax = 0
+
+			var conf config.Config
+			conf.FrequencyCheck.Enabled = true
+			conf.FrequencyCheck.Region = tst.Region
+			conf.FrequencyCheck.FrequencyMin = tst.FrequencyMin
+			conf.FrequencyCheck.FrequencyMax = tst.FrequencyMax
+
+			err := Setup(conf)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				assert.False(enabled)
+				return
+			}
+
+			assert.NoError(err)
+			assert.True(enabled)
+			assert.Equal(tst.ExpectedFrequencyMin, frequencyMin)
+			assert.Equal(tst.ExpectedFrequencyMax, frequencyMax)
+		})
+	}
+}
+
+func TestUplink(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+		enabled = false
+
+		assert.Nil(uplink(gatewayID, 923200000, now))
+		assert.Len(Get(), 0)
+	})
+
+	t.Run("Within range", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		assert.Nil(uplink(gatewayID, 863000000, now))
+		assert.Nil(uplink(gatewayID, 868100000, now))
+		assert.Nil(uplink(gatewayID, 870000000, now))
+		assert.Len(Get(), 0)
+	})
+
+	t.Run("Mismatch aggregation", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		// the first mismatch is reported immediately
+		assert.Equal(&mismatch{
+			gatewayID: gatewayID,
+			frequency: 923200000,
+			count:     1,
+		}, uplink(gatewayID, 923200000, now))
+
+		// within the event interval, mismatches are aggregated
+		assert.Nil(uplink(gatewayID, 923400000, now.Add(time.Minute)))
+		assert.Nil(uplink(gatewayID, 923200000, now.Add(2*time.Minute)))
+
+		// after the event interval, the aggregated mismatches are reported
+		assert.Equal(&mismatch{
+			gatewayID: gatewayID,
+			frequency: 922000000,
+			count:     3,
+		}, uplink(gatewayID, 922000000, now.Add(time.Hour)))
+
+		assert.Equal([]GatewayMismatches{
+			{
+				GatewayID:     gatewayID,
+				MismatchCount: 4,
+				Frequencies:   []uint32{922000000, 923200000, 923400000},
+				FirstSeen:     now,
+				LastSeen:      now.Add(time.Hour),
+			},
+		}, Get())
+	})
+
+	t.Run("Max frequencies", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		for i := 0; i < maxFrequencies+5; i++ {
+			uplink(gatewayID, 902300000+uint32(i)*200000, now)
+		}
+
+		out := Get()
+		assert.Len(out, 1)
+		assert.Equal(maxFrequencies+5, out[0].MismatchCount)
+		assert.Len(out[0].Frequencies, maxFrequencies)
+	})
+}
+
+func TestMismatchNotify(t *testing.T) {
+	assert := require.New(t)
+	setup()
+
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	pl, err := mismatchNotify(mismatch{
+		gatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		frequency: 923200000,
+		count:     12,
+	}, now)
+	assert.NoError(err)
+
+	nowPB, _ := ptypes.TimestampProto(now)
+	assert.Equal(&integration.Notify{
+		GatewayId:       []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Level:           "warning",
+		Code:            "FREQUENCY_MISMATCH",
+		Message:         "uplink received on frequency 923200000 outside configured frequency range 863000000 - 870000000",
+		Time:            nowPB,
+		SuppressedCount: 11,
+		Details: &integration.Notify_FrequencyMismatch{
+			FrequencyMismatch: &integration.FrequencyMismatch{
+				GatewayId:     []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				Frequency:     923200000,
+				MismatchCount: 12,
+				FrequencyMin:  863000000,
+				FrequencyMax:  870000000,
+			},
+		},
+	}, pl)
+}
@@ -0,0 +1,19 @@
+package frequencycheck
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	mc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "frequency_check_mismatch_count",
+		Help: "The number of uplinks received outside the configured frequency range (per gateway).",
+	}, []string{"gateway_id"})
+)
+
+func mismatchCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return mc.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
@@ -0,0 +1,91 @@
+package gpstime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"net"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// chronySockMagic is the magic value of the chrony SOCK refclock sample.
+const chronySockMagic = 0x534f434b
+
+// chronySample implements the sample struct of the chrony SOCK refclock
+// (see refclock_sock.c). The reference time equals tv + offset.
+type chronySample struct {
+	TV     syscall.Timeval
+	Offset float64
+	Pulse  int32
+	Leap   int32
+	Pad    int32
+	Magic  int32
+}
+
+// nativeEndian is the byte-order of the host, which is used by chrony for
+// the sample struct.
+var nativeEndian binary.ByteOrder = func() binary.ByteOrder {
+	i := uint16(1)
+	if *(*byte)(unsafe.Pointer(&i)) == 1 {
+		return binary.LittleEndian
+	}
+	return binary.BigEndian
+}()
+
+func chronyLoop(socket string) {
+	for {
+		time.Sleep(time.Second)
+
+		o, ok := Offset()
+		if !ok {
+			continue
+		}
+
+		if err := sendChronySample(socket, time.Now(), o); err != nil {
+			log.WithError(err).WithField("socket", socket).Error("gpstime: send chrony sample error")
+		}
+	}
+}
+
+// sendChronySample sends the offset between the GPS time and the local time
+// (now) to the chrony SOCK refclock.
+func sendChronySample(socket string, now time.Time, offset time.Duration) error {
+	b, err := marshalChronySample(now, offset)
+	if err != nil {
+		return errors.Wrap(err, "marshal chrony sample error")
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{
+		Name: socket,
+		Net:  "unixgram",
+	})
+	if err != nil {
+		return errors.Wrap(err, "dial chrony socket error")
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write(b); err != nil {
+		return errors.Wrap(err, "write to chrony socket error")
+	}
+
+	return nil
+}
+
+func marshalChronySample(now time.Time, offset time.Duration) ([]byte, error) {
+	s := chronySample{
+		TV:     syscall.NsecToTimeval(now.UnixNano()),
+		Offset: offset.Seconds(),
+		Magic:  chronySockMagic,
+	}
+
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, nativeEndian, s); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
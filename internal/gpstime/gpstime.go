@@ -0,0 +1,195 @@
+// Package gpstime aggregates the GPS time reported by GPS-equipped gateways,
+// so that it can be used as time source by hosts lacking a reliable time
+// source (e.g. edge deployments). Per gateway, the offset between the GPS
+// time of the latest uplink and the time it was received by the bridge is
+// kept. The aggregated offset (the median over all gateways) is exposed by
+// the admin API and is optionally sent to a chrony SOCK refclock.
+package gpstime
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/gps"
+)
+
+// GatewayOffset contains the GPS time offset of a single gateway.
+type GatewayOffset struct {
+	GatewayID lorawan.EUI64 `json:"gatewayID"`
+	Offset    string        `json:"offset"`
+	LastSeen  time.Time     `json:"lastSeen"`
+}
+
+type gateway struct {
+	offset   time.Duration
+	lastSeen time.Time
+}
+
+var (
+	mux sync.RWMutex
+
+	enabled bool
+	maxAge  time.Duration
+
+	gateways = make(map[lorawan.EUI64]*gateway)
+)
+
+// Setup configures the GPS time distribution.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !conf.GPSTime.Enabled {
+		return nil
+	}
+
+	if conf.GPSTime.MaxAge <= 0 {
+		return errors.New("gps_time max_age must be greater than 0")
+	}
+
+	enabled = true
+	maxAge = conf.GPSTime.MaxAge
+
+	log.WithFields(log.Fields{
+		"max_age":       maxAge,
+		"chrony_socket": conf.GPSTime.ChronySocket,
+	}).Info("gpstime: gps time distribution enabled")
+
+	admin.HandleFunc("/api/gps-time", handleHTTP)
+
+	if conf.GPSTime.ChronySocket != "" {
+		go chronyLoop(conf.GPSTime.ChronySocket)
+	}
+
+	return nil
+}
+
+// Uplink registers the GPS time of the given uplink, if available.
+func Uplink(frame gw.UplinkFrame) {
+	if frame.RxInfo == nil || frame.RxInfo.TimeSinceGpsEpoch == nil {
+		return
+	}
+
+	d, err := ptypes.Duration(frame.RxInfo.TimeSinceGpsEpoch)
+	if err != nil {
+		log.WithError(err).Error("gpstime: time since gps epoch error")
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.RxInfo.GetGatewayId())
+
+	sample(gatewayID, time.Time(gps.NewTimeFromTimeSinceGPSEpoch(d)), time.Now())
+}
+
+// Offset returns the aggregated offset between the GPS time and the local
+// time. It returns false when no (recent) GPS time is available.
+func Offset() (time.Duration, bool) {
+	return offset(time.Now())
+}
+
+// Get returns the GPS time offset per gateway.
+func Get() []GatewayOffset {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	var out []GatewayOffset
+
+	for gatewayID, g := range gateways {
+		out = append(out, GatewayOffset{
+			GatewayID: gatewayID,
+			Offset:    g.offset.String(),
+			LastSeen:  g.lastSeen,
+		})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].GatewayID.String() < out[j].GatewayID.String()
+	})
+
+	return out
+}
+
+// sample registers the GPS time reported by the gateway and the local time
+// at which it was received.
+func sample(gatewayID lorawan.EUI64, gpsTime, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	gateways[gatewayID] = &gateway{
+		offset:   gpsTime.Sub(now),
+		lastSeen: now,
+	}
+}
+
+// offset returns the median offset of the gateways seen within the max. age.
+// Gateways exceeding the max. age are removed.
+func offset(now time.Time) (time.Duration, bool) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	var offsets []time.Duration
+
+	for gatewayID, g := range gateways {
+		if now.Sub(g.lastSeen) > maxAge {
+			delete(gateways, gatewayID)
+			continue
+		}
+		offsets = append(offsets, g.offset)
+	}
+
+	if len(offsets) == 0 {
+		return 0, false
+	}
+
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+
+	if len(offsets)%2 == 0 {
+		return (offsets[len(offsets)/2-1] + offsets[len(offsets)/2]) / 2, true
+	}
+
+	return offsets[len(offsets)/2], true
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// aggregated GPS time and the offset per gateway.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	now := time.Now()
+	resp := struct {
+		Available bool            `json:"available"`
+		Time      *time.Time      `json:"time,omitempty"`
+		Offset    string          `json:"offset,omitempty"`
+		Gateways  []GatewayOffset `json:"gateways"`
+	}{}
+
+	if o, ok := offset(now); ok {
+		t := now.Add(o).UTC()
+		resp.Available = true
+		resp.Time = &t
+		resp.Offset = o.String()
+	}
+	resp.Gateways = Get()
+
+	admin.WriteJSON(w, resp)
+}
@@ -0,0 +1,103 @@
+package gpstime
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func setup() {
+	enabled = true
+	maxAge = 5 * time.Minute
+	gateways = make(map[lorawan.EUI64]*gateway)
+}
+
+func TestOffset(t *testing.T) {
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+		enabled = false
+
+		sample(lorawan.EUI64{1}, now.Add(time.Second), now)
+		_, ok := offset(now)
+		assert.False(ok)
+	})
+
+	t.Run("No samples", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		_, ok := offset(now)
+		assert.False(ok)
+	})
+
+	t.Run("Median", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		sample(lorawan.EUI64{1}, now.Add(100*time.Millisecond), now)
+		sample(lorawan.EUI64{2}, now.Add(200*time.Millisecond), now)
+		sample(lorawan.EUI64{3}, now.Add(10*time.Second), now)
+
+		o, ok := offset(now)
+		assert.True(ok)
+		assert.Equal(200*time.Millisecond, o)
+
+		sample(lorawan.EUI64{4}, now.Add(300*time.Millisecond), now)
+
+		o, ok = offset(now)
+		assert.True(ok)
+		assert.Equal(250*time.Millisecond, o)
+	})
+
+	t.Run("Max age", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		sample(lorawan.EUI64{1}, now.Add(100*time.Millisecond), now)
+		sample(lorawan.EUI64{2}, now.Add(6*time.Minute+200*time.Millisecond), now.Add(6*time.Minute))
+
+		o, ok := offset(now.Add(6 * time.Minute))
+		assert.True(ok)
+		assert.Equal(200*time.Millisecond, o)
+		assert.Len(Get(), 1)
+	})
+}
+
+func TestSendChronySample(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "gpstime")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "chrony.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.NoError(err)
+	defer conn.Close()
+
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	assert.NoError(sendChronySample(socket, now, 250*time.Millisecond))
+
+	buf := make([]byte, 64)
+	assert.NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	assert.NoError(err)
+
+	var s chronySample
+	assert.NoError(binary.Read(bytes.NewReader(buf[:n]), nativeEndian, &s))
+	assert.EqualValues(now.Unix(), s.TV.Sec)
+	assert.Equal(0.25, s.Offset)
+	assert.EqualValues(chronySockMagic, s.Magic)
+}
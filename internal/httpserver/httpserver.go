@@ -0,0 +1,106 @@
+// Package httpserver implements the TLS and authentication logic shared by
+// the HTTP servers of the LoRa Gateway Bridge (e.g. the Prometheus metrics
+// endpoint and the admin API).
+package httpserver
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/pkg/errors"
+)
+
+// Options holds the HTTP server options.
+type Options struct {
+	// Bind holds the ip:port to bind the server to.
+	Bind string
+
+	// TLSCert and TLSKey hold the TLS certificate and key files. When set,
+	// the server will use TLS.
+	TLSCert string
+	TLSKey  string
+
+	// BearerToken holds the (static) bearer token that must be presented
+	// by the client.
+	BearerToken string
+
+	// JWTSecret holds the secret used to validate HS256 signed JWT bearer
+	// tokens presented by the client. The tokens must contain an exp claim.
+	JWTSecret string
+}
+
+// ListenAndServe starts the HTTP server using the given handler. When
+// authentication is configured, the handler is wrapped by the
+// authentication middleware.
+func ListenAndServe(opts Options, handler http.Handler) error {
+	server := http.Server{
+		Handler: AuthHandler(opts, handler),
+		Addr:    opts.Bind,
+	}
+
+	if opts.TLSCert != "" || opts.TLSKey != "" {
+		return server.ListenAndServeTLS(opts.TLSCert, opts.TLSKey)
+	}
+
+	return server.ListenAndServe()
+}
+
+// AuthHandler wraps the given handler with the bearer-token / JWT
+// authentication middleware. When no authentication is configured, the given
+// handler is returned.
+func AuthHandler(opts Options, next http.Handler) http.Handler {
+	if opts.BearerToken == "" && opts.JWTSecret == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := authenticate(opts, r); err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="lora-gateway-bridge"`)
+			http.Error(w, fmt.Sprintf("unauthorized: %s", err), http.StatusUnauthorized)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func authenticate(opts Options, r *http.Request) error {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return errors.New("bearer token is missing")
+	}
+	token := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+
+	if opts.BearerToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(opts.BearerToken)) == 1 {
+		return nil
+	}
+
+	if opts.JWTSecret != "" {
+		return validateJWT(token, opts.JWTSecret)
+	}
+
+	return errors.New("invalid bearer token")
+}
+
+func validateJWT(token, secret string) error {
+	var claims jwt.StandardClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(t *jwt.Token) (interface{}, error) {
+		if t.Method.Alg() != jwt.SigningMethodHS256.Alg() {
+			return nil, fmt.Errorf("unexpected signing method: %v", t.Header["alg"])
+		}
+		return []byte(secret), nil
+	})
+	if err != nil {
+		return errors.Wrap(err, "invalid jwt")
+	}
+
+	// tokens without expiration would be valid forever
+	if claims.ExpiresAt == 0 {
+		return errors.New("invalid jwt: exp claim is missing")
+	}
+
+	return nil
+}
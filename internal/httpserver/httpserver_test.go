@@ -0,0 +1,110 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	jwt "github.com/dgrijalva/jwt-go"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuthHandler(t *testing.T) {
+	// sign returns a signed token, without exp claim when exp is zero.
+	sign := func(method jwt.SigningMethod, secret string, exp time.Time) string {
+		var claims jwt.StandardClaims
+		if !exp.IsZero() {
+			claims.ExpiresAt = exp.Unix()
+		}
+		token, err := jwt.NewWithClaims(method, claims).SignedString([]byte(secret))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return token
+	}
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	tests := []struct {
+		Name          string
+		Options       Options
+		Authorization string
+		StatusCode    int
+	}{
+		{
+			Name:       "no authentication configured",
+			StatusCode: http.StatusOK,
+		},
+		{
+			Name:       "bearer token missing",
+			Options:    Options{BearerToken: "secret"},
+			StatusCode: http.StatusUnauthorized,
+		},
+		{
+			Name:          "valid bearer token",
+			Options:       Options{BearerToken: "secret"},
+			Authorization: "Bearer secret",
+			StatusCode:    http.StatusOK,
+		},
+		{
+			Name:          "invalid bearer token",
+			Options:       Options{BearerToken: "secret"},
+			Authorization: "Bearer invalid",
+			StatusCode:    http.StatusUnauthorized,
+		},
+		{
+			Name:          "valid jwt",
+			Options:       Options{JWTSecret: "secret"},
+			Authorization: "Bearer " + sign(jwt.SigningMethodHS256, "secret", time.Now().Add(time.Minute)),
+			StatusCode:    http.StatusOK,
+		},
+		{
+			Name:          "expired jwt",
+			Options:       Options{JWTSecret: "secret"},
+			Authorization: "Bearer " + sign(jwt.SigningMethodHS256, "secret", time.Now().Add(-time.Minute)),
+			StatusCode:    http.StatusUnauthorized,
+		},
+		{
+			Name:          "jwt with invalid secret",
+			Options:       Options{JWTSecret: "secret"},
+			Authorization: "Bearer " + sign(jwt.SigningMethodHS256, "invalid", time.Now().Add(time.Minute)),
+			StatusCode:    http.StatusUnauthorized,
+		},
+		{
+			Name:          "jwt signed using hs512",
+			Options:       Options{JWTSecret: "secret"},
+			Authorization: "Bearer " + sign(jwt.SigningMethodHS512, "secret", time.Now().Add(time.Minute)),
+			StatusCode:    http.StatusUnauthorized,
+		},
+		{
+			Name:          "jwt without exp claim",
+			Options:       Options{JWTSecret: "secret"},
+			Authorization: "Bearer " + sign(jwt.SigningMethodHS256, "secret", time.Time{}),
+			StatusCode:    http.StatusUnauthorized,
+		},
+		{
+			Name:          "bearer token and jwt configured, jwt presented",
+			Options:       Options{BearerToken: "token", JWTSecret: "secret"},
+			Authorization: "Bearer " + sign(jwt.SigningMethodHS256, "secret", time.Now().Add(time.Minute)),
+			StatusCode:    http.StatusOK,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tst.Authorization != "" {
+				r.Header.Set("Authorization", tst.Authorization)
+			}
+			w := httptest.NewRecorder()
+
+			AuthHandler(tst.Options, handler).ServeHTTP(w, r)
+			assert.Equal(tst.StatusCode, w.Code)
+		})
+	}
+}
@@ -0,0 +1,546 @@
+// Package amqp implements an AMQP 1.0 integration (e.g. Azure Event Hubs or
+// RabbitMQ with the AMQP 1.0 plugin).
+package amqp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"pack.ag/amqp"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkexpiry"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkpriority"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinktrace"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/marshaler"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/topic"
+	"github.com/brocaar/lora-gateway-bridge/internal/multicast"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// sendTimeout defines the max. duration of sending a single event.
+const sendTimeout = 5 * time.Second
+
+// Backend implements an AMQP 1.0 backend.
+type Backend struct {
+	sync.RWMutex
+
+	url            string
+	commandAddress string
+	closed         bool
+
+	client   *amqp.Client
+	session  *amqp.Session
+	receiver *amqp.Receiver
+	senders  map[string]*amqp.Sender
+
+	downlinkFrameChan             chan gw.DownlinkFrame
+	gatewayConfigurationChan      chan gw.GatewayConfiguration
+	gatewayCommandExecRequestChan chan gw.GatewayCommandExecRequest
+	spectralScanRequestChan       chan spectralscan.Request
+	multicastRequestChan          chan multicast.Request
+	downlinkSwitchRequestChan     chan downlinkswitch.Request
+
+	eventAddressTemplate *template.Template
+	topicVars            topic.Vars
+
+	marshal      marshaler.MarshalFunc
+	unmarshal    marshaler.UnmarshalFunc
+	eventMarshal map[string]marshaler.MarshalFunc
+}
+
+// NewBackend creates a new Backend.
+func NewBackend(conf config.Config) (*Backend, error) {
+	var err error
+
+	b := Backend{
+		url:                           conf.Integration.AMQP.URL,
+		commandAddress:                conf.Integration.AMQP.CommandAddress,
+		senders:                       make(map[string]*amqp.Sender),
+		downlinkFrameChan:             make(chan gw.DownlinkFrame),
+		gatewayConfigurationChan:      make(chan gw.GatewayConfiguration),
+		gatewayCommandExecRequestChan: make(chan gw.GatewayCommandExecRequest),
+		spectralScanRequestChan:       make(chan spectralscan.Request),
+		multicastRequestChan:          make(chan multicast.Request),
+		downlinkSwitchRequestChan:     make(chan downlinkswitch.Request),
+	}
+
+	b.marshal, b.unmarshal, err = marshaler.Get(conf.Integration.Marshaler)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/amqp: get marshaler error")
+	}
+
+	b.eventMarshal, err = marshaler.GetEventMarshalers(conf.Integration.EventMarshalers)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/amqp: get event marshalers error")
+	}
+
+	b.topicVars, err = topic.NewVars(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/amqp: get topic variables error")
+	}
+
+	b.eventAddressTemplate, err = topic.Parse("event", conf.Integration.AMQP.EventAddressTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/amqp: parse event-address template error")
+	}
+
+	b.connectLoop()
+	go b.commandLoop()
+
+	return &b, nil
+}
+
+// Close closes the backend.
+func (b *Backend) Close() error {
+	b.Lock()
+	b.closed = true
+	b.Unlock()
+
+	return b.disconnect()
+}
+
+// HealthCheck returns an error when the integration is closed or when the
+// connection with the AMQP broker is lost.
+func (b *Backend) HealthCheck() error {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.closed {
+		return errors.New("integration is closed")
+	}
+
+	if b.client == nil {
+		return errors.New("not connected to amqp broker")
+	}
+
+	return nil
+}
+
+// GetDownlinkFrameChan returns the downlink frame channel.
+func (b *Backend) GetDownlinkFrameChan() chan gw.DownlinkFrame {
+	return b.downlinkFrameChan
+}
+
+// GetGatewayConfigurationChan returns the gateway configuration channel.
+func (b *Backend) GetGatewayConfigurationChan() chan gw.GatewayConfiguration {
+	return b.gatewayConfigurationChan
+}
+
+// GetGatewayCommandExecRequestChan() returns the channel for gateway command execution.
+func (b *Backend) GetGatewayCommandExecRequestChan() chan gw.GatewayCommandExecRequest {
+	return b.gatewayCommandExecRequestChan
+}
+
+// GetSpectralScanRequestChan returns the channel for spectral scan requests.
+func (b *Backend) GetSpectralScanRequestChan() chan spectralscan.Request {
+	return b.spectralScanRequestChan
+}
+
+// GetMulticastRequestChan returns the channel for multicast downlink requests.
+func (b *Backend) GetMulticastRequestChan() chan multicast.Request {
+	return b.multicastRequestChan
+}
+
+// GetDownlinkSwitchRequestChan returns the channel for downlink switch requests.
+func (b *Backend) GetDownlinkSwitchRequestChan() chan downlinkswitch.Request {
+	return b.downlinkSwitchRequestChan
+}
+
+// SubscribeGateway subscribes a gateway to its commands.
+// As all commands are consumed from a single address, this is a no-op.
+func (b *Backend) SubscribeGateway(gatewayID lorawan.EUI64) error {
+	return nil
+}
+
+// UnsubscribeGateway unsubscribes the gateway from its commands.
+// As all commands are consumed from a single address, this is a no-op.
+func (b *Backend) UnsubscribeGateway(gatewayID lorawan.EUI64) error {
+	return nil
+}
+
+// PublishEvent publishes the given event.
+func (b *Backend) PublishEvent(gatewayID lorawan.EUI64, event string, id uuid.UUID, v proto.Message) error {
+	amqpEventCounter(event).Inc()
+
+	address, err := b.getEventAddress(gatewayID, event)
+	if err != nil {
+		return errors.Wrap(err, "get event address error")
+	}
+
+	marshal, ok := b.eventMarshal[event]
+	if !ok {
+		marshal = b.marshal
+	}
+
+	bb, err := marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "marshal message error")
+	}
+
+	msg := amqp.NewMessage(bb)
+	msg.Properties = &amqp.MessageProperties{
+		Subject: event,
+	}
+	msg.ApplicationProperties = map[string]interface{}{
+		"gateway_id": gatewayID.String(),
+		"event":      event,
+		"id":         id.String(),
+	}
+
+	sender, err := b.getSender(address)
+	if err != nil {
+		return errors.Wrap(err, "get sender error")
+	}
+
+	log.WithFields(log.Fields{
+		"address": address,
+		"event":   event,
+		"id":      id,
+	}).Info("integration/amqp: publishing event")
+
+	ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+	defer cancel()
+
+	if err := sender.Send(ctx, msg); err != nil {
+		// remove the sender, so that it will be re-created on the next event
+		b.Lock()
+		delete(b.senders, address)
+		b.Unlock()
+
+		return errors.Wrap(err, "send message error")
+	}
+
+	return nil
+}
+
+func (b *Backend) getEventAddress(gatewayID lorawan.EUI64, event string) (string, error) {
+	vars := b.topicVars
+	vars.GatewayID = gatewayID
+	vars.EventType = event
+
+	address := bytes.NewBuffer(nil)
+	if err := b.eventAddressTemplate.Execute(address, vars); err != nil {
+		return "", errors.Wrap(err, "execute event template error")
+	}
+
+	return address.String(), nil
+}
+
+func (b *Backend) getSender(address string) (*amqp.Sender, error) {
+	b.Lock()
+	defer b.Unlock()
+
+	if sender, ok := b.senders[address]; ok {
+		return sender, nil
+	}
+
+	if b.session == nil {
+		return nil, errors.New("not connected")
+	}
+
+	sender, err := b.session.NewSender(amqp.LinkTargetAddress(address))
+	if err != nil {
+		return nil, errors.Wrap(err, "new sender error")
+	}
+	b.senders[address] = sender
+
+	return sender, nil
+}
+
+func (b *Backend) connect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	client, err := amqp.Dial(b.url)
+	if err != nil {
+		return errors.Wrap(err, "dial error")
+	}
+
+	session, err := client.NewSession()
+	if err != nil {
+		client.Close()
+		return errors.Wrap(err, "new session error")
+	}
+
+	receiver, err := session.NewReceiver(
+		amqp.LinkSourceAddress(b.commandAddress),
+		amqp.LinkCredit(10),
+	)
+	if err != nil {
+		client.Close()
+		return errors.Wrap(err, "new receiver error")
+	}
+
+	b.client = client
+	b.session = session
+	b.receiver = receiver
+	b.senders = make(map[string]*amqp.Sender)
+
+	amqpConnectCounter().Inc()
+	log.WithField("command_address", b.commandAddress).Info("integration/amqp: connected to amqp broker")
+
+	return nil
+}
+
+// connectLoop blocks until the client is connected
+func (b *Backend) connectLoop() {
+	for {
+		if err := b.connect(); err != nil {
+			log.WithError(err).Error("integration/amqp: connection error")
+			time.Sleep(time.Second * 2)
+		} else {
+			break
+		}
+	}
+}
+
+func (b *Backend) disconnect() error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.client == nil {
+		return nil
+	}
+
+	amqpDisconnectCounter().Inc()
+
+	err := b.client.Close()
+	b.client = nil
+	b.session = nil
+	b.receiver = nil
+	b.senders = make(map[string]*amqp.Sender)
+
+	return err
+}
+
+func (b *Backend) isClosed() bool {
+	b.RLock()
+	defer b.RUnlock()
+	return b.closed
+}
+
+func (b *Backend) commandLoop() {
+	for {
+		b.RLock()
+		receiver := b.receiver
+		b.RUnlock()
+
+		if b.isClosed() {
+			return
+		}
+
+		if receiver == nil {
+			b.connectLoop()
+			continue
+		}
+
+		msg, err := receiver.Receive(context.Background())
+		if err != nil {
+			if b.isClosed() {
+				return
+			}
+
+			log.WithError(err).Error("integration/amqp: receive command error")
+			b.disconnect()
+			time.Sleep(time.Second * 2)
+			b.connectLoop()
+			continue
+		}
+
+		msg.Accept()
+		b.handleCommand(msg)
+	}
+}
+
+func (b *Backend) handleCommand(msg *amqp.Message) {
+	command := getCommand(msg)
+
+	switch command {
+	case "down":
+		amqpCommandCounter("down").Inc()
+		b.handleDownlinkFrame(msg)
+	case "config":
+		amqpCommandCounter("config").Inc()
+		b.handleGatewayConfiguration(msg)
+	case "exec":
+		amqpCommandCounter("exec").Inc()
+		b.handleGatewayCommandExecRequest(msg)
+	case "spectral_scan":
+		amqpCommandCounter("spectral_scan").Inc()
+		b.handleSpectralScanRequest(msg)
+	case "multicast":
+		amqpCommandCounter("multicast").Inc()
+		b.handleMulticastRequest(msg)
+	case "downlink_switch":
+		amqpCommandCounter("downlink_switch").Inc()
+		b.handleDownlinkSwitchRequest(msg)
+	default:
+		log.WithField("command", command).Warning("integration/amqp: unexpected command received")
+	}
+}
+
+func (b *Backend) handleDownlinkFrame(msg *amqp.Message) {
+	received := time.Now()
+
+	var downlinkFrame gw.DownlinkFrame
+	if err := b.unmarshal(msg.GetData(), &downlinkFrame); err != nil {
+		log.WithError(err).Error("integration/amqp: unmarshal downlink frame error")
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	var downID uuid.UUID
+	copy(gatewayID[:], downlinkFrame.GetTxInfo().GetGatewayId())
+	copy(downID[:], downlinkFrame.GetDownlinkId())
+
+	log.WithFields(log.Fields{
+		"gateway_id":  gatewayID,
+		"downlink_id": downID,
+	}).Info("integration/amqp: downlink frame received")
+
+	b.setDownlinkMetaData(gatewayID, downlinkFrame.Token, msg.GetData())
+
+	downlinktrace.Start(gatewayID[:], downlinkFrame.Token, downlinkFrame.DownlinkId, received)
+	b.downlinkFrameChan <- downlinkFrame
+}
+
+// setDownlinkMetaData sets the explicit priority and the enqueue time of the
+// downlink when the meta-data of the command contains these.
+func (b *Backend) setDownlinkMetaData(gatewayID lorawan.EUI64, token uint32, payload []byte) {
+	var md downlinkpriority.DownlinkFrameMetaData
+	if err := b.unmarshal(payload, &md); err != nil {
+		log.WithError(err).Error("integration/amqp: unmarshal downlink frame meta-data error")
+		return
+	}
+
+	if err := downlinkpriority.SetFromMetaData(gatewayID[:], token, md.GetMetaData()); err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Warning("integration/amqp: invalid downlink priority")
+	}
+
+	if err := downlinkexpiry.SetFromMetaData(gatewayID[:], token, md.GetMetaData()); err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Warning("integration/amqp: invalid downlink enqueue time")
+	}
+}
+
+func (b *Backend) handleGatewayConfiguration(msg *amqp.Message) {
+	var gatewayConfig gw.GatewayConfiguration
+	if err := b.unmarshal(msg.GetData(), &gatewayConfig); err != nil {
+		log.WithError(err).Error("integration/amqp: unmarshal gateway configuration error")
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], gatewayConfig.GetGatewayId())
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"version":    gatewayConfig.GetVersion(),
+	}).Info("integration/amqp: gateway configuration received")
+
+	b.gatewayConfigurationChan <- gatewayConfig
+}
+
+func (b *Backend) handleGatewayCommandExecRequest(msg *amqp.Message) {
+	var gatewayCommandExecRequest gw.GatewayCommandExecRequest
+	if err := b.unmarshal(msg.GetData(), &gatewayCommandExecRequest); err != nil {
+		log.WithError(err).Error("integration/amqp: unmarshal gateway command execution request error")
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	var execID uuid.UUID
+	copy(gatewayID[:], gatewayCommandExecRequest.GetGatewayId())
+	copy(execID[:], gatewayCommandExecRequest.GetExecId())
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"exec_id":    execID,
+	}).Info("integration/amqp: gateway command execution request received")
+
+	b.gatewayCommandExecRequestChan <- gatewayCommandExecRequest
+}
+
+func (b *Backend) handleSpectralScanRequest(msg *amqp.Message) {
+	var req spectralscan.Request
+	if err := b.unmarshal(msg.GetData(), &req); err != nil {
+		log.WithError(err).Error("integration/amqp: unmarshal spectral scan request error")
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	var scanID uuid.UUID
+	copy(gatewayID[:], req.GetGatewayId())
+	copy(scanID[:], req.GetScanId())
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"scan_id":    scanID,
+	}).Info("integration/amqp: spectral scan request received")
+
+	b.spectralScanRequestChan <- req
+}
+
+func (b *Backend) handleMulticastRequest(msg *amqp.Message) {
+	var req multicast.Request
+	if err := b.unmarshal(msg.GetData(), &req); err != nil {
+		log.WithError(err).Error("integration/amqp: unmarshal multicast request error")
+		return
+	}
+
+	var multicastID uuid.UUID
+	copy(multicastID[:], req.GetMulticastId())
+
+	log.WithFields(log.Fields{
+		"multicast_id": multicastID,
+		"gateways":     len(req.GetGatewayIds()),
+		"group":        req.GetGroup(),
+	}).Info("integration/amqp: multicast request received")
+
+	b.multicastRequestChan <- req
+}
+
+func (b *Backend) handleDownlinkSwitchRequest(msg *amqp.Message) {
+	var req downlinkswitch.Request
+	if err := b.unmarshal(msg.GetData(), &req); err != nil {
+		log.WithError(err).Error("integration/amqp: unmarshal downlink switch request error")
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], req.GetGatewayId())
+
+	log.WithFields(log.Fields{
+		"gateway_id":   gatewayID,
+		"all_gateways": req.GetAllGateways(),
+		"disabled":     req.GetDisabled(),
+	}).Info("integration/amqp: downlink switch request received")
+
+	b.downlinkSwitchRequestChan <- req
+}
+
+// getCommand returns the command type of the given message. The command
+// type is read from the message subject, or when not set, from the "command"
+// application property.
+func getCommand(msg *amqp.Message) string {
+	if msg.Properties != nil && msg.Properties.Subject != "" {
+		return msg.Properties.Subject
+	}
+
+	if v, ok := msg.ApplicationProperties["command"]; ok {
+		return fmt.Sprintf("%v", v)
+	}
+
+	return ""
+}
@@ -0,0 +1,98 @@
+package amqp
+
+import (
+	"testing"
+	"text/template"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+	"pack.ag/amqp"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/marshaler"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestBackend(t *testing.T) {
+	assert := require.New(t)
+
+	b := Backend{
+		downlinkFrameChan:             make(chan gw.DownlinkFrame, 1),
+		gatewayConfigurationChan:      make(chan gw.GatewayConfiguration, 1),
+		gatewayCommandExecRequestChan: make(chan gw.GatewayCommandExecRequest, 1),
+	}
+
+	var err error
+	b.marshal, b.unmarshal, err = marshaler.Get("protobuf")
+	assert.NoError(err)
+
+	b.eventAddressTemplate, err = template.New("event").Parse("/exchange/amq.topic/gateway.{{ .GatewayID }}.event.{{ .EventType }}")
+	assert.NoError(err)
+
+	t.Run("Event address", func(t *testing.T) {
+		assert := require.New(t)
+
+		address, err := b.getEventAddress(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, "up")
+		assert.NoError(err)
+		assert.Equal("/exchange/amq.topic/gateway.0102030405060708.event.up", address)
+	})
+
+	t.Run("Downlink frame by subject", func(t *testing.T) {
+		assert := require.New(t)
+
+		pl := gw.DownlinkFrame{
+			PhyPayload: []byte{1, 2, 3, 4},
+			TxInfo: &gw.DownlinkTXInfo{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			},
+		}
+		bb, err := proto.Marshal(&pl)
+		assert.NoError(err)
+
+		b.handleCommand(&amqp.Message{
+			Data:       [][]byte{bb},
+			Properties: &amqp.MessageProperties{Subject: "down"},
+		})
+
+		received := <-b.downlinkFrameChan
+		assert.True(proto.Equal(&pl, &received))
+	})
+
+	t.Run("Gateway configuration by application property", func(t *testing.T) {
+		assert := require.New(t)
+
+		pl := gw.GatewayConfiguration{
+			GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Version:   "1.2.3",
+		}
+		bb, err := proto.Marshal(&pl)
+		assert.NoError(err)
+
+		b.handleCommand(&amqp.Message{
+			Data:                  [][]byte{bb},
+			ApplicationProperties: map[string]interface{}{"command": "config"},
+		})
+
+		received := <-b.gatewayConfigurationChan
+		assert.True(proto.Equal(&pl, &received))
+	})
+
+	t.Run("Gateway command exec request", func(t *testing.T) {
+		assert := require.New(t)
+
+		pl := gw.GatewayCommandExecRequest{
+			GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Command:   "reboot",
+		}
+		bb, err := proto.Marshal(&pl)
+		assert.NoError(err)
+
+		b.handleCommand(&amqp.Message{
+			Data:       [][]byte{bb},
+			Properties: &amqp.MessageProperties{Subject: "exec"},
+		})
+
+		received := <-b.gatewayCommandExecRequestChan
+		assert.True(proto.Equal(&pl, &received))
+	})
+}
@@ -0,0 +1,44 @@
+package amqp
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	pc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "integration_amqp_event_count",
+		Help: "The number of gateway events published by the AMQP integration (per event).",
+	}, []string{"event"})
+
+	cc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "integration_amqp_command_count",
+		Help: "The number of commands received by the AMQP integration (per command).",
+	}, []string{"command"})
+
+	ac = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "integration_amqp_connect_count",
+		Help: "The number of times the integration connected to the AMQP broker.",
+	})
+
+	ad = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "integration_amqp_disconnect_count",
+		Help: "The number of times the integration disconnected from the AMQP broker.",
+	})
+)
+
+func amqpEventCounter(e string) prometheus.Counter {
+	return pc.With(prometheus.Labels{"event": e})
+}
+
+func amqpCommandCounter(c string) prometheus.Counter {
+	return cc.With(prometheus.Labels{"command": c})
+}
+
+func amqpConnectCounter() prometheus.Counter {
+	return ac
+}
+
+func amqpDisconnectCounter() prometheus.Counter {
+	return ad
+}
@@ -0,0 +1,320 @@
+// Package chirpstackv4 implements the conversion of the events and commands
+// from and to the ChirpStack v4 gateway API, for the chirpstack_v4
+// compatibility mode of the MQTT integration. In this mode, the bridge can
+// be connected to a ChirpStack v4 network-server without an intermediate
+// converter.
+package chirpstackv4
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// Topic templates of the ChirpStack v4 topic layout. The region ID (e.g.
+// eu868) is set using the band topic variable.
+const (
+	EventTopicTemplate   = "{{ .Band | lower }}/gateway/{{ .GatewayID }}/event/{{ .EventType }}"
+	StateTopicTemplate   = "{{ .Band | lower }}/gateway/{{ .GatewayID }}/state/{{ .EventType }}"
+	CommandTopicTemplate = "{{ .Band | lower }}/gateway/{{ .GatewayID }}/command/#"
+)
+
+// State events are published to the state topic (as retained message)
+// instead of the event topic.
+var stateEvents = map[string]struct{}{
+	"conn": {},
+}
+
+// codeRates maps the legacy code-rate strings to the v4 code-rates.
+var codeRates = map[string]CodeRate{
+	"4/5": CodeRate_CR_4_5,
+	"4/6": CodeRate_CR_4_6,
+	"4/7": CodeRate_CR_4_7,
+	"4/8": CodeRate_CR_4_8,
+}
+
+// connState is implemented by the conn event message of the integration
+// package (which can't be imported, as it imports the MQTT integration).
+type connState interface {
+	GetGatewayId() []byte
+	GetState() string
+}
+
+// IsState returns true when the given event is a state event.
+func IsState(event string) bool {
+	_, ok := stateEvents[event]
+	return ok
+}
+
+// Event converts the given event message into the v4 message. It returns
+// false when the event is not supported by ChirpStack v4.
+func Event(event string, msg proto.Message) (proto.Message, bool) {
+	switch event {
+	case "up":
+		if v, ok := msg.(*gw.UplinkFrame); ok {
+			return uplinkFrame(v), true
+		}
+	case "stats":
+		if v, ok := msg.(*gw.GatewayStats); ok {
+			return gatewayStats(v), true
+		}
+	case "ack":
+		if v, ok := msg.(*gw.DownlinkTXAck); ok {
+			return downlinkTXAck(v), true
+		}
+	case "conn":
+		if v, ok := msg.(connState); ok {
+			return connStateMessage(v), true
+		}
+	}
+
+	return nil, false
+}
+
+// LegacyDownlinkFrame converts the given v4 downlink frame into the legacy
+// downlink frame. Only the first item is used, the (RX2) fallback items are
+// not supported.
+func LegacyDownlinkFrame(df DownlinkFrame) (gw.DownlinkFrame, error) {
+	if len(df.Items) == 0 {
+		return gw.DownlinkFrame{}, errors.New("downlink frame has no items")
+	}
+	item := df.Items[0]
+
+	gatewayID, err := gatewayID(df.GatewayId, df.GatewayIdLegacy)
+	if err != nil {
+		return gw.DownlinkFrame{}, err
+	}
+
+	txInfo, err := downlinkTXInfo(item.GetTxInfo())
+	if err != nil {
+		return gw.DownlinkFrame{}, err
+	}
+	txInfo.GatewayId = gatewayID[:]
+
+	return gw.DownlinkFrame{
+		PhyPayload: item.PhyPayload,
+		TxInfo:     txInfo,
+		Token:      df.DownlinkId,
+		DownlinkId: df.DownlinkIdLegacy,
+	}, nil
+}
+
+func uplinkFrame(uf *gw.UplinkFrame) *UplinkFrame {
+	txInfo := uf.GetTxInfo()
+	rxInfo := uf.GetRxInfo()
+
+	out := UplinkFrame{
+		PhyPayload: uf.PhyPayload,
+		TxInfo: &UplinkTxInfo{
+			Frequency: txInfo.GetFrequency(),
+		},
+		RxInfo: &UplinkRxInfo{
+			GatewayId:         hex.EncodeToString(rxInfo.GetGatewayId()),
+			GwTime:            rxInfo.GetTime(),
+			TimeSinceGpsEpoch: rxInfo.GetTimeSinceGpsEpoch(),
+			Rssi:              rxInfo.GetRssi(),
+			Snr:               float32(rxInfo.GetLoraSnr()),
+			Channel:           rxInfo.GetChannel(),
+			RfChain:           rxInfo.GetRfChain(),
+			Board:             rxInfo.GetBoard(),
+			Antenna:           rxInfo.GetAntenna(),
+			Location:          location(rxInfo.GetLocation()),
+			Context:           rxInfo.GetContext(),
+			// the bridge only forwards uplinks with valid CRC, unless the CRC
+			// check is disabled
+			CrcStatus: CRCStatus_CRC_OK,
+		},
+	}
+
+	// v4 uses a 32 bit uplink ID
+	if id := rxInfo.GetUplinkId(); len(id) >= 4 {
+		out.RxInfo.UplinkId = binary.BigEndian.Uint32(id)
+	}
+
+	if lora := txInfo.GetLoraModulationInfo(); lora != nil {
+		out.TxInfo.Modulation = &Modulation{
+			Parameters: &Modulation_Lora{
+				Lora: &LoraModulationInfo{
+					Bandwidth:             lora.Bandwidth * 1000,
+					SpreadingFactor:       lora.SpreadingFactor,
+					CodeRateLegacy:        lora.CodeRate,
+					CodeRate:              codeRates[lora.CodeRate],
+					PolarizationInversion: lora.PolarizationInversion,
+				},
+			},
+		}
+	}
+
+	if fsk := txInfo.GetFskModulationInfo(); fsk != nil {
+		out.TxInfo.Modulation = &Modulation{
+			Parameters: &Modulation_Fsk{
+				Fsk: &FskModulationInfo{
+					FrequencyDeviation: fsk.Bandwidth * 1000 / 2,
+					Datarate:           fsk.Bitrate,
+				},
+			},
+		}
+	}
+
+	return &out
+}
+
+func gatewayStats(stats *gw.GatewayStats) *GatewayStats {
+	return &GatewayStats{
+		GatewayId:           hex.EncodeToString(stats.GetGatewayId()),
+		Time:                stats.GetTime(),
+		Location:            location(stats.GetLocation()),
+		ConfigVersion:       stats.GetConfigVersion(),
+		RxPacketsReceived:   stats.GetRxPacketsReceived(),
+		RxPacketsReceivedOk: stats.GetRxPacketsReceivedOk(),
+		TxPacketsReceived:   stats.GetTxPacketsReceived(),
+		TxPacketsEmitted:    stats.GetTxPacketsEmitted(),
+		Metadata:            stats.GetMetaData(),
+	}
+}
+
+func downlinkTXAck(ack *gw.DownlinkTXAck) *DownlinkTxAck {
+	status := TxAckStatus_OK
+	if ack.GetError() != "" {
+		if v, ok := TxAckStatus_value[ack.GetError()]; ok {
+			status = TxAckStatus(v)
+		} else {
+			status = TxAckStatus_INTERNAL_ERROR
+		}
+	}
+
+	return &DownlinkTxAck{
+		GatewayIdLegacy:  ack.GetGatewayId(),
+		GatewayId:        hex.EncodeToString(ack.GetGatewayId()),
+		DownlinkId:       ack.GetToken(),
+		DownlinkIdLegacy: ack.GetDownlinkId(),
+		Items:            []*DownlinkTxAckItem{{Status: status}},
+	}
+}
+
+func connStateMessage(cs connState) *ConnState {
+	out := ConnState{
+		GatewayIdLegacy: cs.GetGatewayId(),
+		GatewayId:       hex.EncodeToString(cs.GetGatewayId()),
+		State:           ConnState_ONLINE,
+	}
+
+	if cs.GetState() == "OFFLINE" {
+		out.State = ConnState_OFFLINE
+	}
+
+	return &out
+}
+
+func location(loc *common.Location) *Location {
+	if loc == nil {
+		return nil
+	}
+
+	return &Location{
+		Latitude:  loc.Latitude,
+		Longitude: loc.Longitude,
+		Altitude:  loc.Altitude,
+		Source:    LocationSource(loc.Source),
+		Accuracy:  float32(loc.Accuracy),
+	}
+}
+
+func downlinkTXInfo(txInfo *DownlinkTxInfo) (*gw.DownlinkTXInfo, error) {
+	if txInfo == nil {
+		return nil, errors.New("tx_info must be set")
+	}
+
+	out := gw.DownlinkTXInfo{
+		Frequency: txInfo.Frequency,
+		Power:     txInfo.Power,
+		Board:     txInfo.Board,
+		Antenna:   txInfo.Antenna,
+		Context:   txInfo.Context,
+	}
+
+	switch {
+	case txInfo.GetModulation().GetLora() != nil:
+		lora := txInfo.GetModulation().GetLora()
+
+		codeRate := lora.CodeRateLegacy
+		for k, v := range codeRates {
+			if v == lora.CodeRate {
+				codeRate = k
+			}
+		}
+
+		out.Modulation = common.Modulation_LORA
+		out.ModulationInfo = &gw.DownlinkTXInfo_LoraModulationInfo{
+			LoraModulationInfo: &gw.LoRaModulationInfo{
+				Bandwidth:             lora.Bandwidth / 1000,
+				SpreadingFactor:       lora.SpreadingFactor,
+				CodeRate:              codeRate,
+				PolarizationInversion: lora.PolarizationInversion,
+			},
+		}
+	case txInfo.GetModulation().GetFsk() != nil:
+		fsk := txInfo.GetModulation().GetFsk()
+
+		out.Modulation = common.Modulation_FSK
+		out.ModulationInfo = &gw.DownlinkTXInfo_FskModulationInfo{
+			FskModulationInfo: &gw.FSKModulationInfo{
+				Bandwidth: fsk.FrequencyDeviation * 2 / 1000,
+				Bitrate:   fsk.Datarate,
+			},
+		}
+	default:
+		return nil, errors.New("unsupported modulation")
+	}
+
+	switch {
+	case txInfo.GetTiming().GetDelay() != nil:
+		out.Timing = gw.DownlinkTiming_DELAY
+		out.TimingInfo = &gw.DownlinkTXInfo_DelayTimingInfo{
+			DelayTimingInfo: &gw.DelayTimingInfo{
+				Delay: txInfo.GetTiming().GetDelay().Delay,
+			},
+		}
+	case txInfo.GetTiming().GetGpsEpoch() != nil:
+		out.Timing = gw.DownlinkTiming_GPS_EPOCH
+		out.TimingInfo = &gw.DownlinkTXInfo_GpsEpochTimingInfo{
+			GpsEpochTimingInfo: &gw.GPSEpochTimingInfo{
+				TimeSinceGpsEpoch: txInfo.GetTiming().GetGpsEpoch().TimeSinceGpsEpoch,
+			},
+		}
+	default:
+		out.Timing = gw.DownlinkTiming_IMMEDIATELY
+		out.TimingInfo = &gw.DownlinkTXInfo_ImmediatelyTimingInfo{
+			ImmediatelyTimingInfo: &gw.ImmediatelyTimingInfo{},
+		}
+	}
+
+	return &out, nil
+}
+
+// gatewayID returns the gateway ID, using the legacy (bytes) gateway ID when
+// the (hex encoded) gateway ID is not set.
+func gatewayID(s string, legacy []byte) (lorawan.EUI64, error) {
+	var id lorawan.EUI64
+
+	if s == "" {
+		if len(legacy) != len(id) {
+			return id, fmt.Errorf("invalid gateway id: %x", legacy)
+		}
+		copy(id[:], legacy)
+		return id, nil
+	}
+
+	if err := id.UnmarshalText([]byte(s)); err != nil {
+		return id, errors.Wrap(err, "unmarshal gateway id error")
+	}
+
+	return id, nil
+}
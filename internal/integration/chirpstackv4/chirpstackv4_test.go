@@ -0,0 +1,280 @@
+package chirpstackv4
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+type testConnState struct {
+	gatewayID []byte
+	state     string
+}
+
+func (t *testConnState) Reset()               {}
+func (t *testConnState) String() string       { return "" }
+func (t *testConnState) ProtoMessage()        {}
+func (t *testConnState) GetGatewayId() []byte { return t.gatewayID }
+func (t *testConnState) GetState() string     { return t.state }
+
+func TestEvent(t *testing.T) {
+	gatewayID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	delay := ptypes.DurationProto(time.Second)
+
+	tests := []struct {
+		Name     string
+		Event    string
+		Message  proto.Message
+		Expected proto.Message
+	}{
+		{
+			Name:  "uplink",
+			Event: "up",
+			Message: &gw.UplinkFrame{
+				PhyPayload: []byte{1, 2, 3},
+				TxInfo: &gw.UplinkTXInfo{
+					Frequency:  868100000,
+					Modulation: common.Modulation_LORA,
+					ModulationInfo: &gw.UplinkTXInfo_LoraModulationInfo{
+						LoraModulationInfo: &gw.LoRaModulationInfo{
+							Bandwidth:       125,
+							SpreadingFactor: 7,
+							CodeRate:        "4/5",
+						},
+					},
+				},
+				RxInfo: &gw.UplinkRXInfo{
+					GatewayId:         gatewayID,
+					TimeSinceGpsEpoch: delay,
+					Rssi:              -50,
+					LoraSnr:           5.5,
+					Channel:           2,
+					Board:             1,
+					Context:           []byte{4, 5},
+					UplinkId:          []byte{0, 0, 1, 2, 9, 9, 9, 9},
+					Location: &common.Location{
+						Latitude: 1.5,
+						Source:   common.LocationSource_GPS,
+						Accuracy: 10,
+					},
+				},
+			},
+			Expected: &UplinkFrame{
+				PhyPayload: []byte{1, 2, 3},
+				TxInfo: &UplinkTxInfo{
+					Frequency: 868100000,
+					Modulation: &Modulation{
+						Parameters: &Modulation_Lora{
+							Lora: &LoraModulationInfo{
+								Bandwidth:       125000,
+								SpreadingFactor: 7,
+								CodeRateLegacy:  "4/5",
+								CodeRate:        CodeRate_CR_4_5,
+							},
+						},
+					},
+				},
+				RxInfo: &UplinkRxInfo{
+					GatewayId:         "0102030405060708",
+					UplinkId:          258,
+					TimeSinceGpsEpoch: delay,
+					Rssi:              -50,
+					Snr:               5.5,
+					Channel:           2,
+					Board:             1,
+					Context:           []byte{4, 5},
+					Location: &Location{
+						Latitude: 1.5,
+						Source:   1,
+						Accuracy: 10,
+					},
+					CrcStatus: CRCStatus_CRC_OK,
+				},
+			},
+		},
+		{
+			Name:  "stats",
+			Event: "stats",
+			Message: &gw.GatewayStats{
+				GatewayId:         gatewayID,
+				ConfigVersion:     "1.2.3",
+				RxPacketsReceived: 10,
+				TxPacketsEmitted:  2,
+				MetaData:          map[string]string{"foo": "bar"},
+			},
+			Expected: &GatewayStats{
+				GatewayId:         "0102030405060708",
+				ConfigVersion:     "1.2.3",
+				RxPacketsReceived: 10,
+				TxPacketsEmitted:  2,
+				Metadata:          map[string]string{"foo": "bar"},
+			},
+		},
+		{
+			Name:  "ack ok",
+			Event: "ack",
+			Message: &gw.DownlinkTXAck{
+				GatewayId: gatewayID,
+				Token:     123,
+			},
+			Expected: &DownlinkTxAck{
+				GatewayIdLegacy: gatewayID,
+				GatewayId:       "0102030405060708",
+				DownlinkId:      123,
+				Items:           []*DownlinkTxAckItem{{Status: TxAckStatus_OK}},
+			},
+		},
+		{
+			Name:  "ack error",
+			Event: "ack",
+			Message: &gw.DownlinkTXAck{
+				GatewayId: gatewayID,
+				Token:     123,
+				Error:     "TOO_LATE",
+			},
+			Expected: &DownlinkTxAck{
+				GatewayIdLegacy: gatewayID,
+				GatewayId:       "0102030405060708",
+				DownlinkId:      123,
+				Items:           []*DownlinkTxAckItem{{Status: 2}},
+			},
+		},
+		{
+			Name:  "ack unknown error",
+			Event: "ack",
+			Message: &gw.DownlinkTXAck{
+				GatewayId: gatewayID,
+				Token:     123,
+				Error:     "SOMETHING_ELSE",
+			},
+			Expected: &DownlinkTxAck{
+				GatewayIdLegacy: gatewayID,
+				GatewayId:       "0102030405060708",
+				DownlinkId:      123,
+				Items:           []*DownlinkTxAckItem{{Status: TxAckStatus_INTERNAL_ERROR}},
+			},
+		},
+		{
+			Name:    "conn offline",
+			Event:   "conn",
+			Message: &testConnState{gatewayID: gatewayID, state: "OFFLINE"},
+			Expected: &ConnState{
+				GatewayIdLegacy: gatewayID,
+				GatewayId:       "0102030405060708",
+				State:           ConnState_OFFLINE,
+			},
+		},
+		{
+			Name:    "conn registered",
+			Event:   "conn",
+			Message: &testConnState{gatewayID: gatewayID, state: "REGISTERED"},
+			Expected: &ConnState{
+				GatewayIdLegacy: gatewayID,
+				GatewayId:       "0102030405060708",
+				State:           ConnState_ONLINE,
+			},
+		},
+		{
+			Name:    "unsupported event",
+			Event:   "notify",
+			Message: &gw.DownlinkTXAck{},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			out, ok := Event(tst.Event, tst.Message)
+			if tst.Expected == nil {
+				assert.False(ok)
+				return
+			}
+
+			assert.True(ok)
+			assert.Equal(tst.Expected, out)
+
+			// the messages must be encodable using both marshalers
+			_, err := proto.Marshal(out)
+			assert.NoError(err)
+			_, err = (&jsonpb.Marshaler{}).MarshalToString(out)
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestDownlinkFrame(t *testing.T) {
+	assert := require.New(t)
+
+	// v4 JSON payload, as published by ChirpStack
+	payload := `{
+		"downlinkId": 123,
+		"gatewayId": "0102030405060708",
+		"items": [{
+			"phyPayload": "AQID",
+			"txInfo": {
+				"frequency": 868100000,
+				"power": 14,
+				"modulation": {"lora": {"bandwidth": 125000, "spreadingFactor": 7, "codeRate": "CR_4_5", "polarizationInversion": true}},
+				"board": 1,
+				"timing": {"delay": {"delay": "1s"}},
+				"context": "BAU="
+			}
+		}, {
+			"phyPayload": "BAUG",
+			"txInfo": {"frequency": 869525000}
+		}]
+	}`
+
+	var df DownlinkFrame
+	assert.NoError((&jsonpb.Unmarshaler{AllowUnknownFields: true}).Unmarshal(bytes.NewReader([]byte(payload)), &df))
+
+	out, err := LegacyDownlinkFrame(df)
+	assert.NoError(err)
+	assert.Equal(gw.DownlinkFrame{
+		PhyPayload: []byte{1, 2, 3},
+		Token:      123,
+		TxInfo: &gw.DownlinkTXInfo{
+			GatewayId:  []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Frequency:  868100000,
+			Power:      14,
+			Board:      1,
+			Modulation: common.Modulation_LORA,
+			ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+				LoraModulationInfo: &gw.LoRaModulationInfo{
+					Bandwidth:             125,
+					SpreadingFactor:       7,
+					CodeRate:              "4/5",
+					PolarizationInversion: true,
+				},
+			},
+			Timing: gw.DownlinkTiming_DELAY,
+			TimingInfo: &gw.DownlinkTXInfo_DelayTimingInfo{
+				DelayTimingInfo: &gw.DelayTimingInfo{
+					Delay: ptypes.DurationProto(time.Second),
+				},
+			},
+			Context: []byte{4, 5},
+		},
+	}, out)
+
+	// the protobuf encoding must round-trip
+	b, err := proto.Marshal(&df)
+	assert.NoError(err)
+	var df2 DownlinkFrame
+	assert.NoError(proto.Unmarshal(b, &df2))
+	assert.Equal(df.Items[0].TxInfo.Frequency, df2.Items[0].TxInfo.Frequency)
+	assert.Equal(df.GatewayId, df2.GatewayId)
+	assert.True(proto.Equal(df.Items[0].TxInfo, df2.Items[0].TxInfo))
+
+	_, err = LegacyDownlinkFrame(DownlinkFrame{GatewayId: "0102030405060708"})
+	assert.EqualError(err, "downlink frame has no items")
+}
@@ -0,0 +1,8 @@
+package integration
+
+// Connection states.
+const (
+	ConnStateOnline     = "ONLINE"
+	ConnStateOffline    = "OFFLINE"
+	ConnStateRegistered = "REGISTERED"
+)
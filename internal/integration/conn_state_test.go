@@ -0,0 +1,35 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/marshaler"
+)
+
+func TestConnState(t *testing.T) {
+	connState := ConnState{
+		GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		State:     ConnStateOffline,
+		Reason:    "close",
+		CloseCode: 1006,
+	}
+
+	for _, name := range []string{"json", "protobuf"} {
+		t.Run(name, func(t *testing.T) {
+			assert := require.New(t)
+
+			marshal, unmarshal, err := marshaler.Get(name)
+			assert.NoError(err)
+
+			b, err := marshal(&connState)
+			assert.NoError(err)
+
+			var out ConnState
+			assert.NoError(unmarshal(b, &out))
+			assert.True(proto.Equal(&connState, &out))
+		})
+	}
+}
@@ -1,35 +1,98 @@
 package integration
 
 import (
+	"fmt"
+
 	"github.com/gofrs/uuid"
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/amqp"
 	"github.com/brocaar/lora-gateway-bridge/internal/integration/mqtt"
+	"github.com/brocaar/lora-gateway-bridge/internal/multicast"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
 )
 
 // Event types.
 const (
-	EventUp    = "up"
-	EventStats = "stats"
-	EventAck   = "ack"
+	EventUp            = "up"
+	EventStats         = "stats"
+	EventAck           = "ack"
+	EventConn          = "conn"
+	EventUpload        = "upload"
+	EventSpectralScan  = "spectral_scan"
+	EventConfigDiff    = "config_diff"
+	EventMulticast     = "multicast"
+	EventUplinkSet     = "uplink_set"
+	EventProtocolError = "protocol_error"
+	EventCertExpiry    = "cert_expiry"
+	EventNotify        = "notify"
 )
 
 var integration Integration
 
+// Setup sets up the integration. The integration can be swapped at runtime
+// using the /api/integration endpoint of the admin API.
 func Setup(conf config.Config) error {
-	var err error
-	integration, err = mqtt.NewBackend(conf)
+	i, err := newIntegration(conf)
 	if err != nil {
-		return errors.Wrap(err, "setup mqtt integration error")
+		return err
 	}
 
+	s := newSwappable(conf, i)
+	integration = s
+
+	admin.HandleFunc("/api/integration", s.handleHTTP)
+
 	return nil
 }
 
+func newIntegration(conf config.Config) (Integration, error) {
+	switch conf.Integration.Type {
+	case "mqtt":
+		if len(conf.Integration.MQTT.Tenants) != 0 {
+			return newTenants(conf)
+		}
+
+		i, err := mqtt.NewBackend(conf)
+		if err != nil {
+			return nil, errors.Wrap(err, "setup mqtt integration error")
+		}
+		return i, nil
+	case "amqp":
+		i, err := amqp.NewBackend(conf)
+		if err != nil {
+			return nil, errors.Wrap(err, "setup amqp integration error")
+		}
+		return i, nil
+	default:
+		return nil, fmt.Errorf("unknown integration type: %s", conf.Integration.Type)
+	}
+}
+
+// SharedSubscriptions returns true when the integration subscribes to the
+// command topics using MQTT shared subscriptions. As the broker delivers each
+// command to only one of the subscribed instances, the integration must then
+// only be subscribed for the gateways connected to this instance.
+func SharedSubscriptions(conf config.Config) bool {
+	if conf.Integration.Type != "mqtt" || conf.Integration.MQTT.SharedSubscriptionGroup == "" {
+		return false
+	}
+
+	// the shared subscription group is ignored by these authentication types
+	switch conf.Integration.MQTT.Auth.Type {
+	case "gcp_cloud_iot_core", "azure_iot_hub":
+		return false
+	}
+
+	return true
+}
+
 // GetIntegration returns the integration.
 func GetIntegration() Integration {
 	return integration
@@ -54,6 +117,19 @@ type Integration interface {
 	// GetGatewayCommandExecRequestChan() returns the channel for gateway command execution.
 	GetGatewayCommandExecRequestChan() chan gw.GatewayCommandExecRequest
 
+	// GetSpectralScanRequestChan returns the channel for spectral scan requests.
+	GetSpectralScanRequestChan() chan spectralscan.Request
+
+	// GetMulticastRequestChan returns the channel for multicast downlink requests.
+	GetMulticastRequestChan() chan multicast.Request
+
+	// GetDownlinkSwitchRequestChan returns the channel for downlink switch requests.
+	GetDownlinkSwitchRequestChan() chan downlinkswitch.Request
+
+	// HealthCheck returns an error when the integration is not healthy. Note
+	// that a deadlocked integration might block this call.
+	HealthCheck() error
+
 	// Close closes the integration.
 	Close() error
 }
@@ -0,0 +1,62 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func TestSharedSubscriptions(t *testing.T) {
+	tests := []struct {
+		Name            string
+		Type            string
+		AuthType        string
+		Group           string
+		ExpectedEnabled bool
+	}{
+		{
+			Name:     "no group",
+			Type:     "mqtt",
+			AuthType: "generic",
+		},
+		{
+			Name:            "generic",
+			Type:            "mqtt",
+			AuthType:        "generic",
+			Group:           "lora-gateway-bridge",
+			ExpectedEnabled: true,
+		},
+		{
+			Name:     "gcp cloud iot core",
+			Type:     "mqtt",
+			AuthType: "gcp_cloud_iot_core",
+			Group:    "lora-gateway-bridge",
+		},
+		{
+			Name:     "azure iot hub",
+			Type:     "mqtt",
+			AuthType: "azure_iot_hub",
+			Group:    "lora-gateway-bridge",
+		},
+		{
+			Name:  "amqp",
+			Type:  "amqp",
+			Group: "lora-gateway-bridge",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Integration.Type = tst.Type
+			conf.Integration.MQTT.Auth.Type = tst.AuthType
+			conf.Integration.MQTT.SharedSubscriptionGroup = tst.Group
+
+			assert.Equal(tst.ExpectedEnabled, SharedSubscriptions(conf))
+		})
+	}
+}
@@ -0,0 +1,120 @@
+// Package marshaler implements the marshalers used by the integrations for
+// encoding events and decoding commands.
+package marshaler
+
+import (
+	"bytes"
+	"fmt"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+)
+
+// MarshalFunc defines the signature of a marshal function.
+type MarshalFunc func(msg proto.Message) ([]byte, error)
+
+// UnmarshalFunc defines the signature of an unmarshal function.
+type UnmarshalFunc func(b []byte, msg proto.Message) error
+
+// Marshaler defines the interface of a marshaler. Custom marshalers (e.g.
+// using a schema registry) can be added using Register.
+type Marshaler interface {
+	// Marshal encodes the given message.
+	Marshal(msg proto.Message) ([]byte, error)
+
+	// Unmarshal decodes the given bytes into the given message.
+	Unmarshal(b []byte, msg proto.Message) error
+}
+
+// funcs implements the Marshaler interface using marshal and unmarshal
+// functions.
+type funcs struct {
+	marshal   MarshalFunc
+	unmarshal UnmarshalFunc
+}
+
+func (f funcs) Marshal(msg proto.Message) ([]byte, error) {
+	return f.marshal(msg)
+}
+
+func (f funcs) Unmarshal(b []byte, msg proto.Message) error {
+	return f.unmarshal(b, msg)
+}
+
+var (
+	mux sync.RWMutex
+
+	marshalers = map[string]Marshaler{
+		"json":     funcs{marshalJSON, unmarshalJSON},
+		"protobuf": funcs{marshalProtobuf, unmarshalProtobuf},
+	}
+)
+
+// Register registers the marshaler under the given name. This must be called
+// before the integration is set up.
+func Register(name string, m Marshaler) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if _, ok := marshalers[name]; ok {
+		return fmt.Errorf("marshaler %s already registered", name)
+	}
+
+	marshalers[name] = m
+	return nil
+}
+
+// Get returns the marshal and unmarshal functions for the given marshaler
+// name. Valid names are json, protobuf and the names of the registered
+// marshalers.
+func Get(name string) (MarshalFunc, UnmarshalFunc, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	m, ok := marshalers[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown marshaler: %s", name)
+	}
+
+	return m.Marshal, m.Unmarshal, nil
+}
+
+// GetEventMarshalers returns the marshal function per event type, for the
+// given event type to marshaler name mapping.
+func GetEventMarshalers(names map[string]string) (map[string]MarshalFunc, error) {
+	out := make(map[string]MarshalFunc)
+	for event, name := range names {
+		marshal, _, err := Get(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "get %s event marshaler error", event)
+		}
+		out[event] = marshal
+	}
+	return out, nil
+}
+
+func marshalJSON(msg proto.Message) ([]byte, error) {
+	marshaler := &jsonpb.Marshaler{
+		EnumsAsInts:  false,
+		EmitDefaults: true,
+	}
+	str, err := marshaler.MarshalToString(msg)
+	return []byte(str), err
+}
+
+func unmarshalJSON(b []byte, msg proto.Message) error {
+	unmarshaler := &jsonpb.Unmarshaler{
+		AllowUnknownFields: true, // we don't want to fail on unknown fields
+	}
+	return unmarshaler.Unmarshal(bytes.NewReader(b), msg)
+}
+
+func marshalProtobuf(msg proto.Message) ([]byte, error) {
+	return proto.Marshal(msg)
+}
+
+func unmarshalProtobuf(b []byte, msg proto.Message) error {
+	return proto.Unmarshal(b, msg)
+}
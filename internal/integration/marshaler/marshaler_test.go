@@ -0,0 +1,93 @@
+package marshaler
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestMarshaler(t *testing.T) {
+	frame := gw.UplinkFrame{
+		PhyPayload: []byte{1, 2, 3, 4},
+		RxInfo: &gw.UplinkRXInfo{
+			GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		},
+	}
+
+	for _, name := range []string{"json", "protobuf"} {
+		t.Run(name, func(t *testing.T) {
+			assert := require.New(t)
+
+			marshal, unmarshal, err := Get(name)
+			assert.NoError(err)
+
+			b, err := marshal(&frame)
+			assert.NoError(err)
+
+			var out gw.UplinkFrame
+			assert.NoError(unmarshal(b, &out))
+			assert.True(proto.Equal(&frame, &out))
+		})
+	}
+
+	t.Run("unknown", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, _, err := Get("xml")
+		assert.EqualError(err, "unknown marshaler: xml")
+	})
+}
+
+type testMarshaler struct{}
+
+func (testMarshaler) Marshal(msg proto.Message) ([]byte, error) {
+	return []byte("test"), nil
+}
+
+func (testMarshaler) Unmarshal(b []byte, msg proto.Message) error {
+	return nil
+}
+
+func TestRegister(t *testing.T) {
+	assert := require.New(t)
+
+	assert.NoError(Register("test", testMarshaler{}))
+	assert.EqualError(Register("test", testMarshaler{}), "marshaler test already registered")
+	assert.EqualError(Register("json", testMarshaler{}), "marshaler json already registered")
+
+	marshal, _, err := Get("test")
+	assert.NoError(err)
+
+	b, err := marshal(&gw.UplinkFrame{})
+	assert.NoError(err)
+	assert.Equal([]byte("test"), b)
+}
+
+func TestGetEventMarshalers(t *testing.T) {
+	assert := require.New(t)
+
+	frame := gw.UplinkFrame{
+		PhyPayload: []byte{1, 2, 3, 4},
+	}
+
+	out, err := GetEventMarshalers(map[string]string{
+		"up":    "protobuf",
+		"stats": "json",
+	})
+	assert.NoError(err)
+	assert.Len(out, 2)
+
+	b, err := out["up"](&frame)
+	assert.NoError(err)
+	exp, err := proto.Marshal(&frame)
+	assert.NoError(err)
+	assert.Equal(exp, b)
+
+	_, err = GetEventMarshalers(map[string]string{
+		"up": "xml",
+	})
+	assert.EqualError(err, "get up event marshaler error: unknown marshaler: xml")
+}
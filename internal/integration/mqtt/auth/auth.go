@@ -23,6 +23,22 @@ type Authentication interface {
 	ReconnectAfter() time.Duration
 }
 
+// FailoverAuthentication is implemented by the authentication types
+// supporting multiple brokers.
+type FailoverAuthentication interface {
+	Authentication
+
+	// Connected must be called after the connection with the broker
+	// selected by Update has been established.
+	Connected()
+
+	// FailoverTimeout returns the duration after which the client must fail
+	// over to the next broker, when it did not reconnect after the
+	// connection was lost.
+	// Note: return 0 to disable the failover.
+	FailoverTimeout() time.Duration
+}
+
 func newTLSConfig(cafile, certFile, certKeyFile string) (*tls.Config, error) {
 	if cafile == "" && certFile == "" && certKeyFile == "" {
 		return nil, nil
@@ -2,6 +2,9 @@ package auth
 
 import (
 	"crypto/tls"
+	"net"
+	"net/url"
+	"sync"
 	"time"
 
 	mqtt "github.com/eclipse/paho.mqtt.golang"
@@ -10,15 +13,52 @@ import (
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
 )
 
+// Broker selection strategies.
+const (
+	// SelectionFailover selects the brokers in the configured order.
+	SelectionFailover = "failover"
+
+	// SelectionRoundRobin rotates the broker order on each (re)connect.
+	SelectionRoundRobin = "round_robin"
+)
+
+// defaultPorts contains the default MQTT port per scheme, used for the
+// broker health-check.
+var defaultPorts = map[string]string{
+	"tcp":  "1883",
+	"ssl":  "8883",
+	"tls":  "8883",
+	"tcps": "8883",
+	"ws":   "80",
+	"wss":  "443",
+}
+
 // GenericAuthentication implements a generic MQTT authentication.
 type GenericAuthentication struct {
-	server       string
+	servers      []string
 	username     string
 	password     string
 	cleanSession bool
 	clientID     string
 
+	selection          string
+	healthCheckTimeout time.Duration
+	failoverTimeout    time.Duration
+
 	tlsConfig *tls.Config
+
+	// dial is used by the broker health-check.
+	dial func(network, address string, timeout time.Duration) (net.Conn, error)
+
+	mux sync.Mutex
+
+	// round contains the broker order of the current connect round and
+	// attempt the number of connect attempts within this round.
+	round   []string
+	attempt int
+
+	// rotation is the offset of the broker order (round-robin).
+	rotation int
 }
 
 // NewGenericAuthentication creates a GenericAuthentication.
@@ -32,20 +72,39 @@ func NewGenericAuthentication(conf config.Config) (Authentication, error) {
 		return nil, errors.Wrap(err, "mqtt/auth: new tls config error")
 	}
 
+	servers := conf.Integration.MQTT.Auth.Generic.Servers
+	if len(servers) == 0 {
+		servers = []string{conf.Integration.MQTT.Auth.Generic.Server}
+	}
+
+	selection := conf.Integration.MQTT.Auth.Generic.ServerSelection
+	switch selection {
+	case "":
+		selection = SelectionFailover
+	case SelectionFailover, SelectionRoundRobin:
+	default:
+		return nil, errors.Errorf("mqtt/auth: unknown server selection: %s", selection)
+	}
+
 	return &GenericAuthentication{
 		tlsConfig: tlsConfig,
 
-		server:       conf.Integration.MQTT.Auth.Generic.Server,
+		servers:      servers,
 		username:     conf.Integration.MQTT.Auth.Generic.Username,
 		password:     conf.Integration.MQTT.Auth.Generic.Password,
 		cleanSession: conf.Integration.MQTT.Auth.Generic.CleanSession,
 		clientID:     conf.Integration.MQTT.Auth.Generic.ClientID,
+
+		selection:          selection,
+		healthCheckTimeout: conf.Integration.MQTT.Auth.Generic.HealthCheckTimeout,
+		failoverTimeout:    conf.Integration.MQTT.Auth.Generic.FailoverTimeout,
+
+		dial: net.DialTimeout,
 	}, nil
 }
 
 // Init applies the initial configuration.
 func (a *GenericAuthentication) Init(opts *mqtt.ClientOptions) error {
-	opts.AddBroker(a.server)
 	opts.SetUsername(a.username)
 	opts.SetPassword(a.password)
 	opts.SetCleanSession(a.cleanSession)
@@ -58,8 +117,12 @@ func (a *GenericAuthentication) Init(opts *mqtt.ClientOptions) error {
 	return nil
 }
 
-// Update updates the authentication options.
+// Update updates the authentication options. It selects the broker for the
+// next connect attempt.
 func (a *GenericAuthentication) Update(opts *mqtt.ClientOptions) error {
+	opts.Servers = nil
+	opts.AddBroker(a.nextServer())
+
 	return nil
 }
 
@@ -68,3 +131,89 @@ func (a *GenericAuthentication) Update(opts *mqtt.ClientOptions) error {
 func (a *GenericAuthentication) ReconnectAfter() time.Duration {
 	return 0
 }
+
+// Connected starts a new broker selection round for the next connect.
+func (a *GenericAuthentication) Connected() {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	a.attempt = 0
+	if a.selection == SelectionRoundRobin {
+		a.rotation++
+	}
+}
+
+// FailoverTimeout returns the duration after which the client must fail
+// over to the next broker when the connection was lost. It returns 0 when
+// only a single broker is configured.
+func (a *GenericAuthentication) FailoverTimeout() time.Duration {
+	if len(a.servers) < 2 {
+		return 0
+	}
+	return a.failoverTimeout
+}
+
+// nextServer returns the broker for the next connect attempt. The brokers
+// of the current round are tried in order, wrapping around when all brokers
+// have been tried.
+func (a *GenericAuthentication) nextServer() string {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+
+	if a.attempt == 0 {
+		a.round = a.order()
+	}
+
+	server := a.round[a.attempt%len(a.round)]
+	a.attempt++
+
+	return server
+}
+
+// order returns the broker order. When the health-check is enabled, the
+// healthy brokers are ordered before the unhealthy brokers.
+func (a *GenericAuthentication) order() []string {
+	offset := 0
+	if a.selection == SelectionRoundRobin {
+		offset = a.rotation % len(a.servers)
+	}
+
+	servers := append(append([]string{}, a.servers[offset:]...), a.servers[:offset]...)
+
+	if a.healthCheckTimeout == 0 || len(servers) < 2 {
+		return servers
+	}
+
+	var healthy, unhealthy []string
+	for _, s := range servers {
+		if a.healthy(s) {
+			healthy = append(healthy, s)
+		} else {
+			unhealthy = append(unhealthy, s)
+		}
+	}
+
+	return append(healthy, unhealthy...)
+}
+
+// healthy returns true when a TCP connection can be established with the
+// given broker.
+func (a *GenericAuthentication) healthy(server string) bool {
+	u, err := url.Parse(server)
+	if err != nil {
+		return false
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), defaultPorts[u.Scheme])
+	}
+
+	conn, err := a.dial("tcp", host, a.healthCheckTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+
+	return true
+}
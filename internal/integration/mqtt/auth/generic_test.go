@@ -0,0 +1,130 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func TestGenericAuthenticationServers(t *testing.T) {
+	servers := []string{"tcp://broker-1:1883", "ssl://broker-2", "tcp://broker-3:1883"}
+
+	tests := []struct {
+		Name               string
+		Server             string
+		Servers            []string
+		Selection          string
+		HealthCheckTimeout time.Duration
+		Unhealthy          []string
+
+		// Connected contains per connect round the number of connect
+		// attempts, after which the connection was established.
+		Connected        []int
+		ExpectedServers  []string
+		ExpectedFailover time.Duration
+	}{
+		{
+			Name:            "single server",
+			Server:          "tcp://localhost:1883",
+			Connected:       []int{1, 1},
+			ExpectedServers: []string{"tcp://localhost:1883", "tcp://localhost:1883"},
+		},
+		{
+			Name:             "failover",
+			Servers:          servers,
+			Selection:        SelectionFailover,
+			Connected:        []int{4, 1},
+			ExpectedServers:  []string{"tcp://broker-1:1883", "ssl://broker-2", "tcp://broker-3:1883", "tcp://broker-1:1883", "tcp://broker-1:1883"},
+			ExpectedFailover: 30 * time.Second,
+		},
+		{
+			Name:             "round-robin",
+			Servers:          servers,
+			Selection:        SelectionRoundRobin,
+			Connected:        []int{1, 2, 1},
+			ExpectedServers:  []string{"tcp://broker-1:1883", "ssl://broker-2", "tcp://broker-3:1883", "tcp://broker-3:1883"},
+			ExpectedFailover: 30 * time.Second,
+		},
+		{
+			Name:               "health-check",
+			Servers:            servers,
+			Selection:          SelectionFailover,
+			HealthCheckTimeout: time.Second,
+			Unhealthy:          []string{"broker-1:1883"},
+			Connected:          []int{2},
+			ExpectedServers:    []string{"ssl://broker-2", "tcp://broker-3:1883"},
+			ExpectedFailover:   30 * time.Second,
+		},
+		{
+			Name:               "health-check default port",
+			Servers:            servers,
+			Selection:          SelectionRoundRobin,
+			HealthCheckTimeout: time.Second,
+			Unhealthy:          []string{"broker-2:8883"},
+			Connected:          []int{1, 1},
+			ExpectedServers:    []string{"tcp://broker-1:1883", "tcp://broker-3:1883"},
+			ExpectedFailover:   30 * time.Second,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Integration.MQTT.Auth.Generic.Server = tst.Server
+			conf.Integration.MQTT.Auth.Generic.Servers = tst.Servers
+			conf.Integration.MQTT.Auth.Generic.ServerSelection = tst.Selection
+			conf.Integration.MQTT.Auth.Generic.HealthCheckTimeout = tst.HealthCheckTimeout
+			conf.Integration.MQTT.Auth.Generic.FailoverTimeout = 30 * time.Second
+
+			a, err := NewGenericAuthentication(conf)
+			assert.NoError(err)
+
+			ga := a.(*GenericAuthentication)
+			ga.dial = func(network, address string, timeout time.Duration) (net.Conn, error) {
+				assert.Equal(tst.HealthCheckTimeout, timeout)
+				for _, u := range tst.Unhealthy {
+					if u == address {
+						return nil, errors.New("connection refused")
+					}
+				}
+
+				c, _ := net.Pipe()
+				return c, nil
+			}
+
+			opts := mqtt.NewClientOptions()
+			assert.NoError(a.Init(opts))
+
+			var servers []string
+			for _, attempts := range tst.Connected {
+				for i := 0; i < attempts; i++ {
+					assert.NoError(a.Update(opts))
+					assert.Len(opts.Servers, 1)
+					servers = append(servers, opts.Servers[0].String())
+				}
+				ga.Connected()
+			}
+
+			assert.Equal(tst.ExpectedServers, servers)
+			assert.Equal(tst.ExpectedFailover, ga.FailoverTimeout())
+		})
+	}
+
+	t.Run("invalid selection", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.Integration.MQTT.Auth.Generic.ServerSelection = "random"
+
+		_, err := NewGenericAuthentication(conf)
+		assert.EqualError(err, "mqtt/auth: unknown server selection: random")
+	})
+}
@@ -5,18 +5,29 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 
 	paho "github.com/eclipse/paho.mqtt.golang"
 	"github.com/gofrs/uuid"
-	"github.com/golang/protobuf/jsonpb"
 	"github.com/golang/protobuf/proto"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/configfanout"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkexpiry"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkpriority"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinktrace"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/chirpstackv4"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/marshaler"
 	"github.com/brocaar/lora-gateway-bridge/internal/integration/mqtt/auth"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/topic"
+	"github.com/brocaar/lora-gateway-bridge/internal/multicast"
+	"github.com/brocaar/lora-gateway-bridge/internal/registry"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/brocaar/lorawan"
 )
@@ -27,32 +38,65 @@ type Backend struct {
 
 	auth                          auth.Authentication
 	conn                          paho.Client
+	broker                        string
+	failingOver                   int32
 	closed                        bool
 	clientOpts                    *paho.ClientOptions
 	downlinkFrameChan             chan gw.DownlinkFrame
 	gatewayConfigurationChan      chan gw.GatewayConfiguration
 	gatewayCommandExecRequestChan chan gw.GatewayCommandExecRequest
+	spectralScanRequestChan       chan spectralscan.Request
+	multicastRequestChan          chan multicast.Request
+	downlinkSwitchRequestChan     chan downlinkswitch.Request
 	gateways                      map[lorawan.EUI64]struct{}
 
-	qos                  uint8
-	eventTopicTemplate   *template.Template
-	commandTopicTemplate *template.Template
-
-	marshal   func(msg proto.Message) ([]byte, error)
-	unmarshal func(b []byte, msg proto.Message) error
+	qos                     uint8
+	eventQOS                map[string]uint8
+	eventTopicTemplate      *template.Template
+	stateTopicTemplate      *template.Template
+	commandTopicTemplate    *template.Template
+	chirpstackV4            bool
+	sharedCommandTopic      string
+	sharedSubscriptionGroup string
+	reconcileInterval       time.Duration
+
+	bridgeCommandTopic          string
+	bridgeResponseTopicTemplate *template.Template
+	rawUplinkTopicTemplate      *template.Template
+	topicVars                   topic.Vars
+
+	publishRetry publishRetry
+
+	marshal      marshaler.MarshalFunc
+	unmarshal    marshaler.UnmarshalFunc
+	eventMarshal map[string]marshaler.MarshalFunc
 }
 
 // NewBackend creates a new Backend.
 func NewBackend(conf config.Config) (*Backend, error) {
-	var err error
+	eventQOS, err := newEventQOS(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/mqtt: event qos error")
+	}
 
 	b := Backend{
 		qos:                           conf.Integration.MQTT.Auth.Generic.QOS,
+		eventQOS:                      eventQOS,
 		clientOpts:                    paho.NewClientOptions(),
 		downlinkFrameChan:             make(chan gw.DownlinkFrame),
 		gatewayConfigurationChan:      make(chan gw.GatewayConfiguration),
 		gatewayCommandExecRequestChan: make(chan gw.GatewayCommandExecRequest),
+		spectralScanRequestChan:       make(chan spectralscan.Request),
+		multicastRequestChan:          make(chan multicast.Request),
+		downlinkSwitchRequestChan:     make(chan downlinkswitch.Request),
 		gateways:                      make(map[lorawan.EUI64]struct{}),
+		publishRetry: publishRetry{
+			maxRetries:      conf.Integration.MQTT.PublishRetry.MaxRetries,
+			initialInterval: conf.Integration.MQTT.PublishRetry.InitialInterval,
+			maxInterval:     conf.Integration.MQTT.PublishRetry.MaxInterval,
+			deadLetterFile:  conf.Integration.MQTT.PublishRetry.DeadLetterFile,
+			deadLetterTopic: conf.Integration.MQTT.PublishRetry.DeadLetterTopic,
+		},
 	}
 
 	switch conf.Integration.MQTT.Auth.Type {
@@ -69,6 +113,9 @@ func NewBackend(conf config.Config) (*Backend, error) {
 
 		conf.Integration.MQTT.EventTopicTemplate = "/devices/gw-{{ .GatewayID }}/events/{{ .EventType }}"
 		conf.Integration.MQTT.CommandTopicTemplate = "/devices/gw-{{ .GatewayID }}/commands/#"
+		conf.Integration.MQTT.SharedCommandTopic = ""
+		conf.Integration.MQTT.SharedSubscriptionGroup = ""
+		conf.Integration.MQTT.BridgeCommandTopic = ""
 	case "azure_iot_hub":
 		b.auth, err = auth.NewAzureIoTHubAuthentication(conf)
 		if err != nil {
@@ -77,49 +124,83 @@ func NewBackend(conf config.Config) (*Backend, error) {
 
 		conf.Integration.MQTT.EventTopicTemplate = "devices/{{ .GatewayID }}/messages/events/{{ .EventType }}"
 		conf.Integration.MQTT.CommandTopicTemplate = "devices/{{ .GatewayID }}/messages/devicebound/#"
+		conf.Integration.MQTT.SharedCommandTopic = ""
+		conf.Integration.MQTT.SharedSubscriptionGroup = ""
+		conf.Integration.MQTT.BridgeCommandTopic = ""
 	default:
 		return nil, fmt.Errorf("integration/mqtt: unknown auth type: %s", conf.Integration.MQTT.Auth.Type)
 	}
 
-	switch conf.Integration.Marshaler {
-	case "json":
-		b.marshal = func(msg proto.Message) ([]byte, error) {
-			marshaler := &jsonpb.Marshaler{
-				EnumsAsInts:  false,
-				EmitDefaults: true,
-			}
-			str, err := marshaler.MarshalToString(msg)
-			return []byte(str), err
-		}
-
-		b.unmarshal = func(b []byte, msg proto.Message) error {
-			unmarshaler := &jsonpb.Unmarshaler{
-				AllowUnknownFields: true, // we don't want to fail on unknown fields
-			}
-			return unmarshaler.Unmarshal(bytes.NewReader(b), msg)
+	switch conf.Integration.MQTT.Compatibility {
+	case "":
+	case "chirpstack_v4":
+		if conf.Integration.MQTT.Auth.Type != "generic" {
+			return nil, errors.New("integration/mqtt: chirpstack_v4 compatibility requires the generic auth type")
 		}
-	case "protobuf":
-		b.marshal = func(msg proto.Message) ([]byte, error) {
-			return proto.Marshal(msg)
+		if conf.Integration.TopicVariables.Band == "" {
+			return nil, errors.New("integration/mqtt: chirpstack_v4 compatibility requires the band topic variable (region id)")
 		}
 
-		b.unmarshal = func(b []byte, msg proto.Message) error {
-			return proto.Unmarshal(b, msg)
-		}
+		conf.Integration.MQTT.EventTopicTemplate = chirpstackv4.EventTopicTemplate
+		conf.Integration.MQTT.CommandTopicTemplate = chirpstackv4.CommandTopicTemplate
+		b.chirpstackV4 = true
 	default:
-		return nil, fmt.Errorf("integration/mqtt: unknown marshaler: %s", conf.Integration.Marshaler)
+		return nil, fmt.Errorf("integration/mqtt: unknown compatibility: %s", conf.Integration.MQTT.Compatibility)
+	}
+
+	b.marshal, b.unmarshal, err = marshaler.Get(conf.Integration.Marshaler)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/mqtt: get marshaler error")
+	}
+
+	b.eventMarshal, err = marshaler.GetEventMarshalers(conf.Integration.EventMarshalers)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/mqtt: get event marshalers error")
+	}
+
+	b.topicVars, err = topic.NewVars(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/mqtt: get topic variables error")
 	}
 
-	b.eventTopicTemplate, err = template.New("event").Parse(conf.Integration.MQTT.EventTopicTemplate)
+	b.eventTopicTemplate, err = topic.Parse("event", conf.Integration.MQTT.EventTopicTemplate)
 	if err != nil {
 		return nil, errors.Wrap(err, "integration/mqtt: parse event-topic template error")
 	}
 
-	b.commandTopicTemplate, err = template.New("event").Parse(conf.Integration.MQTT.CommandTopicTemplate)
+	if b.chirpstackV4 {
+		b.stateTopicTemplate, err = topic.Parse("state", chirpstackv4.StateTopicTemplate)
+		if err != nil {
+			return nil, errors.Wrap(err, "integration/mqtt: parse state-topic template error")
+		}
+	}
+
+	b.commandTopicTemplate, err = topic.Parse("event", conf.Integration.MQTT.CommandTopicTemplate)
 	if err != nil {
 		return nil, errors.Wrap(err, "integration/mqtt: parse event-topic template error")
 	}
 
+	b.bridgeResponseTopicTemplate, err = topic.Parse("bridge_response", conf.Integration.MQTT.BridgeResponseTopicTemplate)
+	if err != nil {
+		return nil, errors.Wrap(err, "integration/mqtt: parse bridge-response-topic template error")
+	}
+
+	if conf.Integration.MQTT.RawUplinkTopicTemplate != "" {
+		b.rawUplinkTopicTemplate, err = topic.Parse("raw_uplink", conf.Integration.MQTT.RawUplinkTopicTemplate)
+		if err != nil {
+			return nil, errors.Wrap(err, "integration/mqtt: parse raw-uplink-topic template error")
+		}
+	}
+
+	b.sharedCommandTopic = conf.Integration.MQTT.SharedCommandTopic
+	b.sharedSubscriptionGroup = conf.Integration.MQTT.SharedSubscriptionGroup
+	b.bridgeCommandTopic = conf.Integration.MQTT.BridgeCommandTopic
+	b.reconcileInterval = conf.Integration.MQTT.ReconcileInterval
+
+	if b.sharedCommandTopic != "" && b.sharedSubscriptionGroup != "" {
+		return nil, errors.New("integration/mqtt: shared_command_topic and shared_subscription_group can not be combined")
+	}
+
 	b.clientOpts.SetProtocolVersion(4)
 	b.clientOpts.SetAutoReconnect(true) // this is required for buffering messages in case offline!
 	b.clientOpts.SetOnConnectHandler(b.onConnected)
@@ -133,6 +214,10 @@ func NewBackend(conf config.Config) (*Backend, error) {
 	b.connectLoop()
 	go b.reconnectLoop()
 
+	if b.reconcileInterval > 0 {
+		go b.reconcileLoop()
+	}
+
 	return &b, nil
 }
 
@@ -146,6 +231,23 @@ func (b *Backend) Close() error {
 	return nil
 }
 
+// HealthCheck returns an error when the integration is closed or when the
+// connection with the MQTT broker is lost.
+func (b *Backend) HealthCheck() error {
+	b.RLock()
+	defer b.RUnlock()
+
+	if b.closed {
+		return errors.New("integration is closed")
+	}
+
+	if b.conn == nil || !b.conn.IsConnectionOpen() {
+		return errors.New("not connected to mqtt broker")
+	}
+
+	return nil
+}
+
 // GetDownlinkFrameChan returns the downlink frame channel.
 func (b *Backend) GetDownlinkFrameChan() chan gw.DownlinkFrame {
 	return b.downlinkFrameChan
@@ -161,13 +263,32 @@ func (b *Backend) GetGatewayCommandExecRequestChan() chan gw.GatewayCommandExecR
 	return b.gatewayCommandExecRequestChan
 }
 
-// SubscribeGateway subscribes a gateway to its topics.
+// GetSpectralScanRequestChan returns the channel for spectral scan requests.
+func (b *Backend) GetSpectralScanRequestChan() chan spectralscan.Request {
+	return b.spectralScanRequestChan
+}
+
+// GetMulticastRequestChan returns the channel for multicast downlink requests.
+func (b *Backend) GetMulticastRequestChan() chan multicast.Request {
+	return b.multicastRequestChan
+}
+
+// GetDownlinkSwitchRequestChan returns the channel for downlink switch requests.
+func (b *Backend) GetDownlinkSwitchRequestChan() chan downlinkswitch.Request {
+	return b.downlinkSwitchRequestChan
+}
+
+// SubscribeGateway subscribes a gateway to its topics. When the shared
+// command topic is configured, the gateway is only registered as connected
+// to this instance.
 func (b *Backend) SubscribeGateway(gatewayID lorawan.EUI64) error {
 	b.Lock()
 	defer b.Unlock()
 
-	if err := b.subscribeGateway(gatewayID); err != nil {
-		return err
+	if b.sharedCommandTopic == "" {
+		if err := b.subscribeGateway(gatewayID); err != nil {
+			return err
+		}
 	}
 
 	b.gateways[gatewayID] = struct{}{}
@@ -175,16 +296,59 @@ func (b *Backend) SubscribeGateway(gatewayID lorawan.EUI64) error {
 }
 
 func (b *Backend) subscribeGateway(gatewayID lorawan.EUI64) error {
-	topic := bytes.NewBuffer(nil)
-	if err := b.commandTopicTemplate.Execute(topic, struct{ GatewayID lorawan.EUI64 }{gatewayID}); err != nil {
-		return errors.Wrap(err, "execute command topic template error")
+	topic, err := b.getCommandTopic(gatewayID)
+	if err != nil {
+		return err
 	}
 	log.WithFields(log.Fields{
-		"topic": topic.String(),
+		"topic": topic,
 		"qos":   b.qos,
 	}).Info("integration/mqtt: subscribing to topic")
 
-	if token := b.conn.Subscribe(topic.String(), b.qos, b.handleCommand); token.Wait() && token.Error() != nil {
+	if token := b.conn.Subscribe(topic, b.qos, b.handleCommand); token.Wait() && token.Error() != nil {
+		return errors.Wrap(token.Error(), "subscribe topic error")
+	}
+	return nil
+}
+
+// getCommandTopic returns the command topic of the given gateway. When the
+// shared subscription group is configured, the $share/GROUP/ prefix is
+// added.
+func (b *Backend) getCommandTopic(gatewayID lorawan.EUI64) (string, error) {
+	vars := b.topicVars
+	vars.GatewayID = gatewayID
+
+	topic := bytes.NewBuffer(nil)
+	if err := b.commandTopicTemplate.Execute(topic, vars); err != nil {
+		return "", errors.Wrap(err, "execute command topic template error")
+	}
+
+	if b.sharedSubscriptionGroup != "" {
+		return fmt.Sprintf("$share/%s/%s", b.sharedSubscriptionGroup, topic.String()), nil
+	}
+
+	return topic.String(), nil
+}
+
+func (b *Backend) subscribeSharedCommandTopic() error {
+	log.WithFields(log.Fields{
+		"topic": b.sharedCommandTopic,
+		"qos":   b.qos,
+	}).Info("integration/mqtt: subscribing to shared command topic")
+
+	if token := b.conn.Subscribe(b.sharedCommandTopic, b.qos, b.handleCommand); token.Wait() && token.Error() != nil {
+		return errors.Wrap(token.Error(), "subscribe topic error")
+	}
+	return nil
+}
+
+func (b *Backend) subscribeBridgeCommandTopic() error {
+	log.WithFields(log.Fields{
+		"topic": b.bridgeCommandTopic,
+		"qos":   b.qos,
+	}).Info("integration/mqtt: subscribing to bridge command topic")
+
+	if token := b.conn.Subscribe(b.bridgeCommandTopic, b.qos, b.handleBridgeCommand); token.Wait() && token.Error() != nil {
 		return errors.Wrap(token.Error(), "subscribe topic error")
 	}
 	return nil
@@ -195,30 +359,70 @@ func (b *Backend) UnsubscribeGateway(gatewayID lorawan.EUI64) error {
 	b.Lock()
 	defer b.Unlock()
 
-	topic := bytes.NewBuffer(nil)
-	if err := b.commandTopicTemplate.Execute(topic, struct{ GatewayID lorawan.EUI64 }{gatewayID}); err != nil {
-		return errors.Wrap(err, "execute command topic template error")
+	if b.sharedCommandTopic != "" {
+		delete(b.gateways, gatewayID)
+		return nil
+	}
+
+	if err := b.unsubscribeGateway(gatewayID); err != nil {
+		return err
+	}
+
+	delete(b.gateways, gatewayID)
+	return nil
+}
+
+func (b *Backend) unsubscribeGateway(gatewayID lorawan.EUI64) error {
+	topic, err := b.getCommandTopic(gatewayID)
+	if err != nil {
+		return err
 	}
 	log.WithFields(log.Fields{
-		"topic": topic.String(),
+		"topic": topic,
 	}).Info("integration/mqtt: unsubscribe topic")
 
-	if token := b.conn.Unsubscribe(topic.String()); token.Wait() && token.Error() != nil {
+	if token := b.conn.Unsubscribe(topic); token.Wait() && token.Error() != nil {
 		return errors.Wrap(token.Error(), "unsubscribe topic error")
 	}
-
-	delete(b.gateways, gatewayID)
 	return nil
 }
 
 // PublishEvent publishes the given event.
 func (b *Backend) PublishEvent(gatewayID lorawan.EUI64, event string, id uuid.UUID, v proto.Message) error {
 	mqttEventCounter(event).Inc()
+
+	if frame, ok := v.(*gw.UplinkFrame); ok && event == "up" && b.rawUplinkTopicTemplate != nil {
+		if err := b.publishRawUplink(gatewayID, frame); err != nil {
+			log.WithError(err).WithField("gateway_id", gatewayID).Error("integration/mqtt: publish raw uplink error")
+		}
+	}
+
+	if b.chirpstackV4 {
+		msg, ok := chirpstackv4.Event(event, v)
+		if !ok {
+			log.WithFields(log.Fields{
+				"gateway_id": gatewayID,
+				"event":      event,
+			}).Debug("integration/mqtt: event not supported by chirpstack v4, skipping event")
+			return nil
+		}
+		v = msg
+	}
+
 	idPrefix := map[string]string{
-		"up":    "uplink_",
-		"ack":   "downlink_",
-		"stats": "stats_",
-		"exec":  "exec_",
+		"up":             "uplink_",
+		"ack":            "downlink_",
+		"stats":          "stats_",
+		"exec":           "exec_",
+		"conn":           "conn_",
+		"upload":         "upload_",
+		"spectral_scan":  "scan_",
+		"config_diff":    "diff_",
+		"multicast":      "multicast_",
+		"uplink_set":     "set_",
+		"protocol_error": "error_",
+		"cert_expiry":    "cert_expiry_",
+		"notify":         "notify_",
 	}
 	return b.publish(gatewayID, event, log.Fields{
 		idPrefix[event] + "id": id,
@@ -238,9 +442,60 @@ func (b *Backend) connect() error {
 		return token.Error()
 	}
 
+	if fa, ok := b.auth.(auth.FailoverAuthentication); ok {
+		fa.Connected()
+	}
+
+	b.setBroker()
+
 	return nil
 }
 
+// setBroker updates the connected broker metric, using the broker of the
+// client options.
+func (b *Backend) setBroker() {
+	if len(b.clientOpts.Servers) == 0 {
+		return
+	}
+
+	// make sure the credentials (if any) are not exposed
+	u := *b.clientOpts.Servers[0]
+	u.User = nil
+	broker := u.String()
+
+	if b.broker != "" && b.broker != broker {
+		mqttBrokerConnectedGauge(b.broker).Set(0)
+	}
+	mqttBrokerConnectedGauge(broker).Set(1)
+	b.broker = broker
+}
+
+// failover fails over to the next broker in case the client did not
+// reconnect within the given timeout.
+func (b *Backend) failover(timeout time.Duration) {
+	time.Sleep(timeout)
+
+	b.RLock()
+	reconnected := b.closed || b.conn.IsConnectionOpen()
+	broker := b.broker
+	b.RUnlock()
+
+	if reconnected || !atomic.CompareAndSwapInt32(&b.failingOver, 0, 1) {
+		return
+	}
+	defer atomic.StoreInt32(&b.failingOver, 0)
+
+	log.WithFields(log.Fields{
+		"broker":  broker,
+		"timeout": timeout,
+	}).Warning("integration/mqtt: not reconnected to mqtt broker, failing over to next broker")
+
+	mqttFailoverCounter().Inc()
+
+	b.disconnect()
+	b.connectLoop()
+}
+
 // connectLoop blocks until the client is connected
 func (b *Backend) connectLoop() {
 	for {
@@ -289,6 +544,30 @@ func (b *Backend) onConnected(c paho.Client) {
 
 	log.Info("integration/mqtt: connected to mqtt broker")
 
+	if b.bridgeCommandTopic != "" {
+		for {
+			if err := b.subscribeBridgeCommandTopic(); err != nil {
+				log.WithError(err).Error("integration/mqtt: subscribe bridge command topic error")
+				time.Sleep(time.Second)
+				continue
+			}
+
+			break
+		}
+	}
+
+	if b.sharedCommandTopic != "" {
+		for {
+			if err := b.subscribeSharedCommandTopic(); err != nil {
+				log.WithError(err).Error("integration/mqtt: subscribe shared command topic error")
+				time.Sleep(time.Second)
+				continue
+			}
+
+			return
+		}
+	}
+
 	for gatewayID := range b.gateways {
 		for {
 			if err := b.subscribeGateway(gatewayID); err != nil {
@@ -305,11 +584,17 @@ func (b *Backend) onConnected(c paho.Client) {
 func (b *Backend) onConnectionLost(c paho.Client, err error) {
 	mqttDisconnectCounter().Inc()
 	log.WithError(err).Error("mqtt: connection error")
+
+	if fa, ok := b.auth.(auth.FailoverAuthentication); ok && fa.FailoverTimeout() > 0 {
+		go b.failover(fa.FailoverTimeout())
+	}
 }
 
 func (b *Backend) handleDownlinkFrame(c paho.Client, msg paho.Message) {
+	received := time.Now()
+
 	var downlinkFrame gw.DownlinkFrame
-	if err := b.unmarshal(msg.Payload(), &downlinkFrame); err != nil {
+	if err := b.unmarshalDownlinkFrame(msg.Payload(), &downlinkFrame); err != nil {
 		log.WithFields(log.Fields{
 			"topic": msg.Topic(),
 		}).WithError(err).Error("integration/mqtt: unmarshal downlink frame error")
@@ -321,14 +606,60 @@ func (b *Backend) handleDownlinkFrame(c paho.Client, msg paho.Message) {
 	copy(gatewayID[:], downlinkFrame.GetTxInfo().GetGatewayId())
 	copy(downID[:], downlinkFrame.GetDownlinkId())
 
+	if !b.ownsGateway(gatewayID) {
+		return
+	}
+
 	log.WithFields(log.Fields{
 		"gateway_id":  gatewayID,
 		"downlink_id": downID,
 	}).Info("integration/mqtt: downlink frame received")
 
+	b.setDownlinkMetaData(gatewayID, downlinkFrame.Token, msg.Payload())
+
+	downlinktrace.Start(gatewayID[:], downlinkFrame.Token, downlinkFrame.DownlinkId, received)
 	b.downlinkFrameChan <- downlinkFrame
 }
 
+// setDownlinkMetaData sets the explicit priority and the enqueue time of the
+// downlink when the meta-data of the command contains these.
+func (b *Backend) setDownlinkMetaData(gatewayID lorawan.EUI64, token uint32, payload []byte) {
+	var md downlinkpriority.DownlinkFrameMetaData
+	if err := b.unmarshal(payload, &md); err != nil {
+		log.WithError(err).Error("integration/mqtt: unmarshal downlink frame meta-data error")
+		return
+	}
+
+	if err := downlinkpriority.SetFromMetaData(gatewayID[:], token, md.GetMetaData()); err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Warning("integration/mqtt: invalid downlink priority")
+	}
+
+	if err := downlinkexpiry.SetFromMetaData(gatewayID[:], token, md.GetMetaData()); err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Warning("integration/mqtt: invalid downlink enqueue time")
+	}
+}
+
+// unmarshalDownlinkFrame decodes the downlink frame. In chirpstack_v4
+// compatibility mode, the v4 downlink frame is converted.
+func (b *Backend) unmarshalDownlinkFrame(payload []byte, downlinkFrame *gw.DownlinkFrame) error {
+	if !b.chirpstackV4 {
+		return b.unmarshal(payload, downlinkFrame)
+	}
+
+	var df chirpstackv4.DownlinkFrame
+	if err := b.unmarshal(payload, &df); err != nil {
+		return err
+	}
+
+	out, err := chirpstackv4.LegacyDownlinkFrame(df)
+	if err != nil {
+		return errors.Wrap(err, "convert chirpstack v4 downlink frame error")
+	}
+	*downlinkFrame = out
+
+	return nil
+}
+
 // TODO: this feature is deprecated. Remove this in the next major release.
 func (b *Backend) handleGatewayConfiguration(c paho.Client, msg paho.Message) {
 	log.WithFields(log.Fields{
@@ -341,6 +672,12 @@ func (b *Backend) handleGatewayConfiguration(c paho.Client, msg paho.Message) {
 		return
 	}
 
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], gatewayConfig.GetGatewayId())
+	if !b.ownsGateway(gatewayID) {
+		return
+	}
+
 	b.gatewayConfigurationChan <- gatewayConfig
 }
 
@@ -358,6 +695,10 @@ func (b *Backend) handleGatewayCommandExecRequest(c paho.Client, msg paho.Messag
 	copy(gatewayID[:], gatewayCommandExecRequest.GetGatewayId())
 	copy(execID[:], gatewayCommandExecRequest.GetExecId())
 
+	if !b.ownsGateway(gatewayID) {
+		return
+	}
+
 	log.WithFields(log.Fields{
 		"gateway_id": gatewayID,
 		"exec_id":    execID,
@@ -366,7 +707,106 @@ func (b *Backend) handleGatewayCommandExecRequest(c paho.Client, msg paho.Messag
 	b.gatewayCommandExecRequestChan <- gatewayCommandExecRequest
 }
 
+func (b *Backend) handleSpectralScanRequest(c paho.Client, msg paho.Message) {
+	var req spectralscan.Request
+	if err := b.unmarshal(msg.Payload(), &req); err != nil {
+		log.WithFields(log.Fields{
+			"topic": msg.Topic(),
+		}).WithError(err).Error("integration/mqtt: unmarshal spectral scan request error")
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	var scanID uuid.UUID
+	copy(gatewayID[:], req.GetGatewayId())
+	copy(scanID[:], req.GetScanId())
+
+	if !b.ownsGateway(gatewayID) {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"scan_id":    scanID,
+	}).Info("integration/mqtt: spectral scan request received")
+
+	b.spectralScanRequestChan <- req
+}
+
+func (b *Backend) handleMulticastRequest(c paho.Client, msg paho.Message) {
+	var req multicast.Request
+	if err := b.unmarshal(msg.Payload(), &req); err != nil {
+		log.WithFields(log.Fields{
+			"topic": msg.Topic(),
+		}).WithError(err).Error("integration/mqtt: unmarshal multicast request error")
+		return
+	}
+
+	var multicastID uuid.UUID
+	copy(multicastID[:], req.GetMulticastId())
+
+	log.WithFields(log.Fields{
+		"multicast_id": multicastID,
+		"gateways":     len(req.GetGatewayIds()),
+		"group":        req.GetGroup(),
+	}).Info("integration/mqtt: multicast request received")
+
+	b.multicastRequestChan <- req
+}
+
+func (b *Backend) handleDownlinkSwitchRequest(c paho.Client, msg paho.Message) {
+	var req downlinkswitch.Request
+	if err := b.unmarshal(msg.Payload(), &req); err != nil {
+		log.WithFields(log.Fields{
+			"topic": msg.Topic(),
+		}).WithError(err).Error("integration/mqtt: unmarshal downlink switch request error")
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], req.GetGatewayId())
+
+	if !req.GetAllGateways() && !b.ownsGateway(gatewayID) {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id":   gatewayID,
+		"all_gateways": req.GetAllGateways(),
+		"disabled":     req.GetDisabled(),
+	}).Info("integration/mqtt: downlink switch request received")
+
+	b.downlinkSwitchRequestChan <- req
+}
+
+// ownsGateway returns true when the command for the given gateway must be
+// handled by this instance. This is always the case, unless the shared
+// command topic is used and the gateway is not connected to this instance.
+func (b *Backend) ownsGateway(gatewayID lorawan.EUI64) bool {
+	if b.sharedCommandTopic == "" {
+		return true
+	}
+
+	b.RLock()
+	_, ok := b.gateways[gatewayID]
+	b.RUnlock()
+
+	if !ok {
+		log.WithField("gateway_id", gatewayID).Debug("integration/mqtt: ignoring command for gateway not connected to this instance")
+	}
+
+	return ok
+}
+
 func (b *Backend) handleCommand(c paho.Client, msg paho.Message) {
+	// the v4 config and exec commands are not supported
+	if b.chirpstackV4 && (strings.HasSuffix(msg.Topic(), "config") || strings.HasSuffix(msg.Topic(), "exec")) {
+		log.WithFields(log.Fields{
+			"topic": msg.Topic(),
+		}).Warning("integration/mqtt: command not supported in chirpstack_v4 compatibility mode")
+		return
+	}
+
 	if strings.HasSuffix(msg.Topic(), "down") || strings.Contains(msg.Topic(), "command=down") {
 		mqttCommandCounter("down").Inc()
 		b.handleDownlinkFrame(c, msg)
@@ -375,6 +815,15 @@ func (b *Backend) handleCommand(c paho.Client, msg paho.Message) {
 		b.handleGatewayConfiguration(c, msg)
 	} else if strings.HasSuffix(msg.Topic(), "exec") || strings.Contains(msg.Topic(), "command=exec") {
 		b.handleGatewayCommandExecRequest(c, msg)
+	} else if strings.HasSuffix(msg.Topic(), "spectral_scan") || strings.Contains(msg.Topic(), "command=spectral_scan") {
+		mqttCommandCounter("spectral_scan").Inc()
+		b.handleSpectralScanRequest(c, msg)
+	} else if strings.HasSuffix(msg.Topic(), "multicast") || strings.Contains(msg.Topic(), "command=multicast") {
+		mqttCommandCounter("multicast").Inc()
+		b.handleMulticastRequest(c, msg)
+	} else if strings.HasSuffix(msg.Topic(), "downlink_switch") || strings.Contains(msg.Topic(), "command=downlink_switch") {
+		mqttCommandCounter("downlink_switch").Inc()
+		b.handleDownlinkSwitchRequest(c, msg)
 	} else {
 		log.WithFields(log.Fields{
 			"topic": msg.Topic(),
@@ -382,27 +831,228 @@ func (b *Backend) handleCommand(c paho.Client, msg paho.Message) {
 	}
 }
 
+func (b *Backend) handleBridgeCommand(c paho.Client, msg paho.Message) {
+	if strings.HasSuffix(msg.Topic(), "list_gateways") || strings.Contains(msg.Topic(), "command=list_gateways") {
+		mqttCommandCounter("list_gateways").Inc()
+		b.handleListGatewaysRequest(c, msg)
+	} else if strings.HasSuffix(msg.Topic(), "gateway_configuration") || strings.Contains(msg.Topic(), "command=gateway_configuration") {
+		mqttCommandCounter("gateway_configuration").Inc()
+		b.handleConfigurationFanOutRequest(c, msg)
+	} else {
+		log.WithFields(log.Fields{
+			"topic": msg.Topic(),
+		}).Warning("integration/mqtt: unexpected bridge command received")
+	}
+}
+
+func (b *Backend) handleListGatewaysRequest(c paho.Client, msg paho.Message) {
+	var req registry.ListGatewaysRequest
+	if err := b.unmarshal(msg.Payload(), &req); err != nil {
+		log.WithFields(log.Fields{
+			"topic": msg.Topic(),
+		}).WithError(err).Error("integration/mqtt: unmarshal list gateways request error")
+		return
+	}
+
+	resp, err := registry.ListGateways(req)
+	if err != nil {
+		log.WithError(err).Error("integration/mqtt: list gateways error")
+		return
+	}
+
+	if err := b.publishBridgeResponse("list_gateways", &resp); err != nil {
+		log.WithError(err).Error("integration/mqtt: publish list gateways response error")
+	}
+}
+
+func (b *Backend) handleConfigurationFanOutRequest(c paho.Client, msg paho.Message) {
+	var req configfanout.Request
+	if err := b.unmarshal(msg.Payload(), &req); err != nil {
+		log.WithFields(log.Fields{
+			"topic": msg.Topic(),
+		}).WithError(err).Error("integration/mqtt: unmarshal gateway configuration fan-out request error")
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"target":  req.GetTarget(),
+		"version": req.GetConfiguration().GetVersion(),
+	}).Info("integration/mqtt: gateway configuration fan-out request received")
+
+	// applying the configuration to many gateways could take a while
+	go func() {
+		res := configfanout.FanOut(req)
+		if err := b.publishBridgeResponse("gateway_configuration", &res); err != nil {
+			log.WithError(err).Error("integration/mqtt: publish gateway configuration fan-out response error")
+		}
+	}()
+}
+
+// publishBridgeResponse publishes the response to the given bridge command.
+func (b *Backend) publishBridgeResponse(command string, msg proto.Message) error {
+	vars := b.topicVars
+	vars.CommandType = command
+
+	topic := bytes.NewBuffer(nil)
+	if err := b.bridgeResponseTopicTemplate.Execute(topic, vars); err != nil {
+		return errors.Wrap(err, "execute bridge response template error")
+	}
+
+	bytes, err := b.marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "marshal message error")
+	}
+
+	log.WithFields(log.Fields{
+		"topic":   topic.String(),
+		"qos":     b.qos,
+		"command": command,
+	}).Info("integration/mqtt: publishing bridge response")
+
+	if token := b.conn.Publish(topic.String(), b.qos, false, bytes); token.Wait() && token.Error() != nil {
+		return errors.Wrap(token.Error(), "publish error")
+	}
+	return nil
+}
+
+// getRawUplinkTopic returns the raw uplink topic for the given uplink.
+func (b *Backend) getRawUplinkTopic(gatewayID lorawan.EUI64, frame *gw.UplinkFrame) (string, error) {
+	vars := b.topicVars
+	vars.GatewayID = gatewayID
+	vars.EventType = "up"
+	vars.Frequency = frame.GetTxInfo().GetFrequency()
+	vars.SpreadingFactor = frame.GetTxInfo().GetLoraModulationInfo().GetSpreadingFactor()
+	vars.RSSI = frame.GetRxInfo().GetRssi()
+
+	topic := bytes.NewBuffer(nil)
+	if err := b.rawUplinkTopicTemplate.Execute(topic, vars); err != nil {
+		return "", errors.Wrap(err, "execute raw uplink template error")
+	}
+
+	return topic.String(), nil
+}
+
+// newEventQOS returns the event type to QoS level mapping. An error is
+// returned when a QoS level is not 0, 1 or 2.
+func newEventQOS(conf config.Config) (map[string]uint8, error) {
+	if conf.Integration.MQTT.Auth.Generic.QOS > 2 {
+		return nil, fmt.Errorf("invalid qos: %d", conf.Integration.MQTT.Auth.Generic.QOS)
+	}
+
+	out := map[string]uint8{
+		"up":    conf.Integration.MQTT.EventQOS.Up,
+		"stats": conf.Integration.MQTT.EventQOS.Stats,
+		"ack":   conf.Integration.MQTT.EventQOS.Ack,
+		"exec":  conf.Integration.MQTT.EventQOS.Exec,
+	}
+
+	for event, qos := range out {
+		if qos > 2 {
+			return nil, fmt.Errorf("invalid qos for %s event: %d", event, qos)
+		}
+	}
+
+	return out, nil
+}
+
+// getEventQOS returns the QoS level of the given event type. Event types
+// without an explicit QoS level use the generic QoS level.
+func (b *Backend) getEventQOS(event string) uint8 {
+	if qos, ok := b.eventQOS[event]; ok {
+		return qos
+	}
+	return b.qos
+}
+
+// publishRawUplink publishes the PHYPayload of the given uplink (without any
+// encoding) to the raw uplink topic, for consumers that do not want to
+// unmarshal the uplink frame. In contrast to the events, this is published
+// without retries.
+func (b *Backend) publishRawUplink(gatewayID lorawan.EUI64, frame *gw.UplinkFrame) error {
+	topic, err := b.getRawUplinkTopic(gatewayID, frame)
+	if err != nil {
+		return err
+	}
+
+	qos := b.getEventQOS("up")
+
+	log.WithFields(log.Fields{
+		"topic":      topic,
+		"qos":        qos,
+		"gateway_id": gatewayID,
+	}).Debug("integration/mqtt: publishing raw uplink")
+
+	if token := b.conn.Publish(topic, qos, false, frame.PhyPayload); token.Wait() && token.Error() != nil {
+		return errors.Wrap(token.Error(), "publish error")
+	}
+
+	mqttRawUplinkCounter().Inc()
+	return nil
+}
+
 func (b *Backend) publish(gatewayID lorawan.EUI64, event string, fields log.Fields, msg proto.Message) error {
+	vars := b.topicVars
+	vars.GatewayID = gatewayID
+	vars.EventType = event
+
+	// in chirpstack_v4 compatibility mode, the state events are published
+	// as retained message to the state topic
+	tmpl := b.eventTopicTemplate
+	retained := false
+	if b.chirpstackV4 && chirpstackv4.IsState(event) {
+		tmpl = b.stateTopicTemplate
+		retained = true
+	}
+
 	topic := bytes.NewBuffer(nil)
-	if err := b.eventTopicTemplate.Execute(topic, struct {
-		GatewayID lorawan.EUI64
-		EventType string
-	}{gatewayID, event}); err != nil {
+	if err := tmpl.Execute(topic, vars); err != nil {
 		return errors.Wrap(err, "execute event template error")
 	}
 
-	bytes, err := b.marshal(msg)
+	marshal, ok := b.eventMarshal[event]
+	if !ok {
+		marshal = b.marshal
+	}
+
+	bytes, err := marshal(msg)
 	if err != nil {
 		return errors.Wrap(err, "marshal message error")
 	}
 
+	qos := b.getEventQOS(event)
+
 	fields["topic"] = topic.String()
-	fields["qos"] = b.qos
+	fields["qos"] = qos
 	fields["event"] = event
 
 	log.WithFields(fields).Info("integration/mqtt: publishing event")
-	if token := b.conn.Publish(topic.String(), b.qos, false, bytes); token.Wait() && token.Error() != nil {
-		return token.Error()
+
+	var pubErr error
+	for retry := 0; ; retry++ {
+		token := b.conn.Publish(topic.String(), qos, retained, bytes)
+		if token.Wait() && token.Error() == nil {
+			return nil
+		}
+		pubErr = token.Error()
+
+		if retry >= b.publishRetry.maxRetries {
+			break
+		}
+
+		backoff := b.publishRetry.backoff(retry)
+		mqttPublishRetryCounter(event).Inc()
+		log.WithError(pubErr).WithFields(fields).WithField("backoff", backoff).Warning("integration/mqtt: publish event error, retrying")
+		time.Sleep(backoff)
 	}
-	return nil
+
+	b.deadLetter(deadLetter{
+		Time:      time.Now(),
+		GatewayID: gatewayID,
+		Event:     event,
+		Topic:     topic.String(),
+		Error:     pubErr.Error(),
+		Payload:   bytes,
+	}, qos)
+
+	return pubErr
 }
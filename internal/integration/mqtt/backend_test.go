@@ -3,8 +3,10 @@ package mqtt
 import (
 	"os"
 	"testing"
+	"text/template"
 	"time"
 
+	"github.com/brocaar/loraserver/api/common"
 	"github.com/brocaar/loraserver/api/gw"
 	"github.com/gofrs/uuid"
 
@@ -14,6 +16,7 @@ import (
 	"github.com/stretchr/testify/suite"
 
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
 	"github.com/brocaar/lorawan"
 )
 
@@ -92,6 +95,31 @@ func (ts *MQTTBackendTestSuite) TestSubscribeGateway() {
 	})
 }
 
+func (ts *MQTTBackendTestSuite) TestReconcile() {
+	assert := require.New(ts.T())
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 9}
+
+	ts.T().Run("missing subscription", func(t *testing.T) {
+		assert := require.New(t)
+
+		ts.backend.reconcile([]lorawan.EUI64{ts.gatewayID, gatewayID})
+		_, ok := ts.backend.gateways[gatewayID]
+		assert.True(ok)
+	})
+
+	ts.T().Run("orphan subscription", func(t *testing.T) {
+		assert := require.New(t)
+
+		ts.backend.reconcile([]lorawan.EUI64{ts.gatewayID})
+		_, ok := ts.backend.gateways[gatewayID]
+		assert.False(ok)
+	})
+
+	_, ok := ts.backend.gateways[ts.gatewayID]
+	assert.True(ok)
+}
+
 func (ts *MQTTBackendTestSuite) TestPublishUplinkFrame() {
 	assert := require.New(ts.T())
 	id, err := uuid.NewV4()
@@ -230,6 +258,172 @@ func (ts *MQTTBackendTestSuite) TestGatewayCommandExecRequest() {
 	assert.Equal(execReq, receivedExecReq)
 }
 
+func (ts *MQTTBackendTestSuite) TestSpectralScanRequest() {
+	assert := require.New(ts.T())
+	id, err := uuid.NewV4()
+	assert.NoError(err)
+
+	req := spectralscan.Request{
+		GatewayId:      ts.gatewayID[:],
+		ScanId:         id[:],
+		FrequencyStart: 863000000,
+		FrequencyEnd:   870000000,
+		FrequencyStep:  100000,
+	}
+
+	b, err := ts.backend.marshal(&req)
+	assert.NoError(err)
+
+	token := ts.mqttClient.Publish("gateway/0807060504030201/command/spectral_scan", 0, false, b)
+	token.Wait()
+	assert.NoError(token.Error())
+
+	receivedReq := <-ts.backend.GetSpectralScanRequestChan()
+	assert.Equal(req, receivedReq)
+}
+
 func TestMQTTBackend(t *testing.T) {
 	suite.Run(t, new(MQTTBackendTestSuite))
 }
+
+func TestOwnsGateway(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	t.Run("Per gateway subscription", func(t *testing.T) {
+		assert := require.New(t)
+		b := Backend{
+			gateways: make(map[lorawan.EUI64]struct{}),
+		}
+
+		assert.True(b.ownsGateway(gatewayID))
+	})
+
+	t.Run("Shared command topic", func(t *testing.T) {
+		assert := require.New(t)
+		b := Backend{
+			sharedCommandTopic: "gateway/+/command/#",
+			gateways:           make(map[lorawan.EUI64]struct{}),
+		}
+
+		assert.False(b.ownsGateway(gatewayID))
+
+		b.gateways[gatewayID] = struct{}{}
+		assert.True(b.ownsGateway(gatewayID))
+	})
+}
+
+func TestGetCommandTopic(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	tests := []struct {
+		Name                    string
+		SharedSubscriptionGroup string
+		ExpectedTopic           string
+	}{
+		{
+			Name:          "command topic",
+			ExpectedTopic: "gateway/0102030405060708/command/#",
+		},
+		{
+			Name:                    "shared subscription",
+			SharedSubscriptionGroup: "lora-gateway-bridge",
+			ExpectedTopic:           "$share/lora-gateway-bridge/gateway/0102030405060708/command/#",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b := Backend{
+				commandTopicTemplate:    template.Must(template.New("command").Parse("gateway/{{ .GatewayID }}/command/#")),
+				sharedSubscriptionGroup: tst.SharedSubscriptionGroup,
+			}
+
+			topic, err := b.getCommandTopic(gatewayID)
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedTopic, topic)
+		})
+	}
+}
+
+func TestEventQOS(t *testing.T) {
+	tests := []struct {
+		Name     string
+		QOS      uint8
+		EventQOS [4]uint8
+		Expected map[string]uint8
+		Error    string
+	}{
+		{
+			Name:     "per event qos",
+			QOS:      1,
+			EventQOS: [4]uint8{0, 1, 2, 1},
+			Expected: map[string]uint8{"up": 0, "stats": 1, "ack": 2, "exec": 1, "conn": 1},
+		},
+		{
+			Name:  "invalid qos",
+			QOS:   3,
+			Error: "invalid qos: 3",
+		},
+		{
+			Name:     "invalid event qos",
+			EventQOS: [4]uint8{0, 0, 4, 0},
+			Error:    "invalid qos for ack event: 4",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Integration.MQTT.Auth.Generic.QOS = tst.QOS
+			conf.Integration.MQTT.EventQOS.Up = tst.EventQOS[0]
+			conf.Integration.MQTT.EventQOS.Stats = tst.EventQOS[1]
+			conf.Integration.MQTT.EventQOS.Ack = tst.EventQOS[2]
+			conf.Integration.MQTT.EventQOS.Exec = tst.EventQOS[3]
+
+			eventQOS, err := newEventQOS(conf)
+			if tst.Error != "" {
+				assert.EqualError(err, tst.Error)
+				return
+			}
+			assert.NoError(err)
+
+			// events without a qos level (e.g. conn) use the generic qos
+			b := Backend{qos: tst.QOS, eventQOS: eventQOS}
+			for event, qos := range tst.Expected {
+				assert.Equal(qos, b.getEventQOS(event), event)
+			}
+		})
+	}
+}
+
+func TestGetRawUplinkTopic(t *testing.T) {
+	assert := require.New(t)
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	b := Backend{
+		rawUplinkTopicTemplate: template.Must(template.New("raw_uplink").Parse("gateway/{{ .GatewayID }}/raw/{{ .Frequency }}/{{ .SpreadingFactor }}/{{ .RSSI }}")),
+	}
+
+	topic, err := b.getRawUplinkTopic(gatewayID, &gw.UplinkFrame{
+		PhyPayload: []byte{1, 2, 3},
+		TxInfo: &gw.UplinkTXInfo{
+			Frequency:  868100000,
+			Modulation: common.Modulation_LORA,
+			ModulationInfo: &gw.UplinkTXInfo_LoraModulationInfo{
+				LoraModulationInfo: &gw.LoRaModulationInfo{
+					Bandwidth:       125,
+					SpreadingFactor: 7,
+				},
+			},
+		},
+		RxInfo: &gw.UplinkRXInfo{
+			Rssi: -80,
+		},
+	})
+	assert.NoError(err)
+	assert.Equal("gateway/0102030405060708/raw/868100000/7/-80", topic)
+}
@@ -0,0 +1,111 @@
+package mqtt
+
+import (
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lorawan"
+)
+
+// publishRetry holds the publish retry and dead-letter configuration.
+type publishRetry struct {
+	maxRetries      int
+	initialInterval time.Duration
+	maxInterval     time.Duration
+	deadLetterFile  string
+	deadLetterTopic string
+
+	fileMux sync.Mutex
+}
+
+// deadLetter is written to the dead-letter file and / or published to the
+// dead-letter topic when an event could not be published.
+type deadLetter struct {
+	Time      time.Time     `json:"time"`
+	GatewayID lorawan.EUI64 `json:"gatewayID"`
+	Event     string        `json:"event"`
+	Topic     string        `json:"topic"`
+	Error     string        `json:"error"`
+	Payload   []byte        `json:"payload"`
+}
+
+// backoff returns the interval to wait before the given (zero based) retry.
+// The interval doubles on every retry, capped at the max interval.
+func (p *publishRetry) backoff(retry int) time.Duration {
+	d := p.initialInterval
+	for i := 0; i < retry; i++ {
+		d = d * 2
+		if p.maxInterval > 0 && d >= p.maxInterval {
+			return p.maxInterval
+		}
+	}
+
+	return d
+}
+
+// writeFile appends the dead-letter as JSON line to the dead-letter file.
+func (p *publishRetry) writeFile(dl deadLetter) error {
+	b, err := json.Marshal(dl)
+	if err != nil {
+		return errors.Wrap(err, "marshal dead-letter error")
+	}
+
+	p.fileMux.Lock()
+	defer p.fileMux.Unlock()
+
+	f, err := os.OpenFile(p.deadLetterFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0640)
+	if err != nil {
+		return errors.Wrap(err, "open dead-letter file error")
+	}
+	defer f.Close()
+
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return errors.Wrap(err, "write dead-letter file error")
+	}
+
+	return nil
+}
+
+// deadLetter stores the event which could not be published in the
+// dead-letter file and publishes it to the dead-letter topic (when
+// configured).
+func (b *Backend) deadLetter(dl deadLetter, qos uint8) {
+	if b.publishRetry.deadLetterFile == "" && b.publishRetry.deadLetterTopic == "" {
+		return
+	}
+
+	mqttDeadLetterCounter(dl.Event).Inc()
+
+	logFields := log.Fields{
+		"gateway_id": dl.GatewayID,
+		"event":      dl.Event,
+		"topic":      dl.Topic,
+	}
+
+	if b.publishRetry.deadLetterFile != "" {
+		if err := b.publishRetry.writeFile(dl); err != nil {
+			log.WithError(err).WithFields(logFields).Error("integration/mqtt: write dead-letter file error")
+		} else {
+			log.WithFields(logFields).Warning("integration/mqtt: event written to dead-letter file")
+		}
+	}
+
+	if b.publishRetry.deadLetterTopic != "" {
+		bytes, err := json.Marshal(dl)
+		if err != nil {
+			log.WithError(err).WithFields(logFields).Error("integration/mqtt: marshal dead-letter error")
+			return
+		}
+
+		if token := b.conn.Publish(b.publishRetry.deadLetterTopic, qos, false, bytes); token.Wait() && token.Error() != nil {
+			log.WithError(token.Error()).WithFields(logFields).Error("integration/mqtt: publish dead-letter error")
+		} else {
+			log.WithFields(logFields).Warning("integration/mqtt: event published to dead-letter topic")
+		}
+	}
+}
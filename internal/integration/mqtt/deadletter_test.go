@@ -0,0 +1,79 @@
+package mqtt
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestPublishRetryBackoff(t *testing.T) {
+	assert := require.New(t)
+
+	p := publishRetry{
+		initialInterval: 500 * time.Millisecond,
+		maxInterval:     3 * time.Second,
+	}
+
+	assert.Equal(500*time.Millisecond, p.backoff(0))
+	assert.Equal(time.Second, p.backoff(1))
+	assert.Equal(2*time.Second, p.backoff(2))
+	assert.Equal(3*time.Second, p.backoff(3))
+	assert.Equal(3*time.Second, p.backoff(10))
+}
+
+func TestPublishRetryWriteFile(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "deadletter")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	p := publishRetry{
+		deadLetterFile: filepath.Join(dir, "dead-letter.json"),
+	}
+
+	dls := []deadLetter{
+		{
+			Time:      time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			GatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			Event:     "up",
+			Topic:     "gateway/0102030405060708/event/up",
+			Error:     "not connected",
+			Payload:   []byte{1, 2, 3},
+		},
+		{
+			Time:      time.Date(2019, 1, 1, 0, 0, 1, 0, time.UTC),
+			GatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+			Event:     "stats",
+			Topic:     "gateway/0102030405060708/event/stats",
+			Error:     "not connected",
+			Payload:   []byte{4, 5, 6},
+		},
+	}
+
+	for _, dl := range dls {
+		assert.NoError(p.writeFile(dl))
+	}
+
+	f, err := os.Open(p.deadLetterFile)
+	assert.NoError(err)
+	defer f.Close()
+
+	var out []deadLetter
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var dl deadLetter
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &dl))
+		out = append(out, dl)
+	}
+	assert.NoError(scanner.Err())
+	assert.Equal(dls, out)
+}
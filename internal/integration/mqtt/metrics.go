@@ -16,6 +16,21 @@ var (
 		Help: "The number of commands received by the MQTT integration (per command).",
 	}, []string{"command"})
 
+	prc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "integration_mqtt_publish_retry_count",
+		Help: "The number of event publish retries by the MQTT integration (per event).",
+	}, []string{"event"})
+
+	dlc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "integration_mqtt_dead_letter_count",
+		Help: "The number of events written to the dead-letter file / topic after the publish retries were exhausted (per event).",
+	}, []string{"event"})
+
+	rupc = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "integration_mqtt_raw_uplink_count",
+		Help: "The number of raw uplinks (PHYPayloads) published by the MQTT integration.",
+	})
+
 	mqttc = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "integration_mqtt_connect_count",
 		Help: "The number of times the integration connected to the MQTT broker.",
@@ -30,6 +45,21 @@ var (
 		Name: "integration_mqtt_reconnect_count",
 		Help: "The number of times the integration reconnected to the MQTT broker (this also increments the disconnect and connect counters).",
 	})
+
+	mqttf = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "integration_mqtt_failover_count",
+		Help: "The number of times the integration failed over to the next MQTT broker.",
+	})
+
+	mqttb = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "integration_mqtt_broker_connected",
+		Help: "The MQTT broker to which the integration is connected (1 for the connected broker, 0 for previously connected brokers).",
+	}, []string{"broker"})
+
+	rcc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "integration_mqtt_subscription_correction_count",
+		Help: "The number of gateway subscriptions corrected by the subscription reconciliation (per action).",
+	}, []string{"action"})
 )
 
 func mqttEventCounter(e string) prometheus.Counter {
@@ -40,6 +70,18 @@ func mqttCommandCounter(c string) prometheus.Counter {
 	return cc.With(prometheus.Labels{"command": c})
 }
 
+func mqttPublishRetryCounter(e string) prometheus.Counter {
+	return prc.With(prometheus.Labels{"event": e})
+}
+
+func mqttDeadLetterCounter(e string) prometheus.Counter {
+	return dlc.With(prometheus.Labels{"event": e})
+}
+
+func mqttRawUplinkCounter() prometheus.Counter {
+	return rupc
+}
+
 func mqttConnectCounter() prometheus.Counter {
 	return mqttc
 }
@@ -51,3 +93,15 @@ func mqttDisconnectCounter() prometheus.Counter {
 func mqttReconnectCounter() prometheus.Counter {
 	return mqttr
 }
+
+func mqttFailoverCounter() prometheus.Counter {
+	return mqttf
+}
+
+func mqttBrokerConnectedGauge(broker string) prometheus.Gauge {
+	return mqttb.With(prometheus.Labels{"broker": broker})
+}
+
+func mqttSubscriptionCorrectionCounter(a string) prometheus.Counter {
+	return rcc.With(prometheus.Labels{"action": a})
+}
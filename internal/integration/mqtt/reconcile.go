@@ -0,0 +1,82 @@
+package mqtt
+
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/registry"
+	"github.com/brocaar/lorawan"
+)
+
+// reconcileLoop reconciles the gateway subscriptions with the gateway
+// registry at the configured interval, until the backend is closed.
+func (b *Backend) reconcileLoop() {
+	for {
+		time.Sleep(b.reconcileInterval)
+
+		b.RLock()
+		closed := b.closed
+		b.RUnlock()
+
+		if closed {
+			return
+		}
+
+		b.reconcile(registry.Subscriptions())
+	}
+}
+
+// reconcile compares the subscribed gateways with the given gateways which
+// must be subscribed and repairs any drift. Missing subscriptions (e.g. a
+// subscribe which failed after a reconnect) are subscribed and orphan
+// subscriptions (e.g. an unsubscribe which failed after a disconnect) are
+// unsubscribed. When the shared command topic is configured, only the
+// gateways registered as connected to this instance are corrected.
+func (b *Backend) reconcile(gatewayIDs []lorawan.EUI64) {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.conn == nil || !b.conn.IsConnectionOpen() {
+		return
+	}
+
+	expected := make(map[lorawan.EUI64]struct{})
+	for _, gatewayID := range gatewayIDs {
+		expected[gatewayID] = struct{}{}
+
+		if _, ok := b.gateways[gatewayID]; ok {
+			continue
+		}
+
+		log.WithField("gateway_id", gatewayID).Warning("integration/mqtt: gateway subscription missing, subscribing")
+
+		if b.sharedCommandTopic == "" {
+			if err := b.subscribeGateway(gatewayID); err != nil {
+				log.WithError(err).WithField("gateway_id", gatewayID).Error("integration/mqtt: subscribe gateway error")
+				continue
+			}
+		}
+
+		b.gateways[gatewayID] = struct{}{}
+		mqttSubscriptionCorrectionCounter("subscribe").Inc()
+	}
+
+	for gatewayID := range b.gateways {
+		if _, ok := expected[gatewayID]; ok {
+			continue
+		}
+
+		log.WithField("gateway_id", gatewayID).Warning("integration/mqtt: orphan gateway subscription, unsubscribing")
+
+		if b.sharedCommandTopic == "" {
+			if err := b.unsubscribeGateway(gatewayID); err != nil {
+				log.WithError(err).WithField("gateway_id", gatewayID).Error("integration/mqtt: unsubscribe gateway error")
+				continue
+			}
+		}
+
+		delete(b.gateways, gatewayID)
+		mqttSubscriptionCorrectionCounter("unsubscribe").Inc()
+	}
+}
@@ -0,0 +1,7 @@
+package integration
+
+// Quarantine states.
+const (
+	QuarantineStateQuarantined = "QUARANTINED"
+	QuarantineStateReleased    = "RELEASED"
+)
@@ -0,0 +1,354 @@
+package integration
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/lora-gateway-bridge/internal/multicast"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// swapTimeout defines the max. duration to wait for the new integration to
+// connect.
+const swapTimeout = 30 * time.Second
+
+// settings contains the integration settings which can be changed at
+// runtime. Empty values keep the current value.
+type settings struct {
+	Type string `json:"type"`
+
+	MQTT struct {
+		Server               string `json:"server"`
+		Username             string `json:"username"`
+		Password             string `json:"password,omitempty"`
+		EventTopicTemplate   string `json:"eventTopicTemplate"`
+		CommandTopicTemplate string `json:"commandTopicTemplate"`
+	} `json:"mqtt"`
+
+	AMQP struct {
+		URL                  string `json:"url"`
+		EventAddressTemplate string `json:"eventAddressTemplate"`
+		CommandAddress       string `json:"commandAddress"`
+	} `json:"amqp"`
+}
+
+// swappable wraps the integration, so that it can be replaced at runtime.
+// The channels returned by swappable are forwarded from the wrapped
+// integration, so that the consumers of these channels are not affected by
+// a swap.
+type swappable struct {
+	sync.RWMutex
+
+	// swapMux serializes the swaps.
+	swapMux sync.Mutex
+
+	conf     config.Config
+	current  Integration
+	done     chan struct{}
+	stopped  chan struct{}
+	gateways map[lorawan.EUI64]struct{}
+
+	downlinkFrameChan             chan gw.DownlinkFrame
+	gatewayConfigurationChan      chan gw.GatewayConfiguration
+	gatewayCommandExecRequestChan chan gw.GatewayCommandExecRequest
+	spectralScanRequestChan       chan spectralscan.Request
+	multicastRequestChan          chan multicast.Request
+	downlinkSwitchRequestChan     chan downlinkswitch.Request
+}
+
+func newSwappable(conf config.Config, i Integration) *swappable {
+	s := swappable{
+		conf:                          conf,
+		current:                       i,
+		done:                          make(chan struct{}),
+		stopped:                       make(chan struct{}),
+		gateways:                      make(map[lorawan.EUI64]struct{}),
+		downlinkFrameChan:             make(chan gw.DownlinkFrame),
+		gatewayConfigurationChan:      make(chan gw.GatewayConfiguration),
+		gatewayCommandExecRequestChan: make(chan gw.GatewayCommandExecRequest),
+		spectralScanRequestChan:       make(chan spectralscan.Request),
+		multicastRequestChan:          make(chan multicast.Request),
+		downlinkSwitchRequestChan:     make(chan downlinkswitch.Request),
+	}
+
+	go s.forward(i, s.done, s.stopped)
+
+	return &s
+}
+
+// SubscribeGateway creates a subscription for the given gateway ID.
+func (s *swappable) SubscribeGateway(gatewayID lorawan.EUI64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.current.SubscribeGateway(gatewayID); err != nil {
+		return err
+	}
+	s.gateways[gatewayID] = struct{}{}
+
+	return nil
+}
+
+// UnsubscribeGateway removes the subscription for the given gateway ID.
+func (s *swappable) UnsubscribeGateway(gatewayID lorawan.EUI64) error {
+	s.Lock()
+	defer s.Unlock()
+
+	delete(s.gateways, gatewayID)
+	return s.current.UnsubscribeGateway(gatewayID)
+}
+
+// PublishEvent publishes the given event.
+func (s *swappable) PublishEvent(gatewayID lorawan.EUI64, event string, id uuid.UUID, v proto.Message) error {
+	return s.get().PublishEvent(gatewayID, event, id, v)
+}
+
+// GetDownlinkFrameChan returns the channel for downlink frames.
+func (s *swappable) GetDownlinkFrameChan() chan gw.DownlinkFrame {
+	return s.downlinkFrameChan
+}
+
+// GetGatewayConfigurationChan returns the channel for gateway configuration.
+func (s *swappable) GetGatewayConfigurationChan() chan gw.GatewayConfiguration {
+	return s.gatewayConfigurationChan
+}
+
+// GetGatewayCommandExecRequestChan returns the channel for gateway command execution.
+func (s *swappable) GetGatewayCommandExecRequestChan() chan gw.GatewayCommandExecRequest {
+	return s.gatewayCommandExecRequestChan
+}
+
+// GetSpectralScanRequestChan returns the channel for spectral scan requests.
+func (s *swappable) GetSpectralScanRequestChan() chan spectralscan.Request {
+	return s.spectralScanRequestChan
+}
+
+// GetMulticastRequestChan returns the channel for multicast downlink requests.
+func (s *swappable) GetMulticastRequestChan() chan multicast.Request {
+	return s.multicastRequestChan
+}
+
+// GetDownlinkSwitchRequestChan returns the channel for downlink switch requests.
+func (s *swappable) GetDownlinkSwitchRequestChan() chan downlinkswitch.Request {
+	return s.downlinkSwitchRequestChan
+}
+
+// HealthCheck returns an error when the integration is not healthy.
+func (s *swappable) HealthCheck() error {
+	return s.get().HealthCheck()
+}
+
+// Close closes the integration.
+func (s *swappable) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	close(s.done)
+	return s.current.Close()
+}
+
+func (s *swappable) get() Integration {
+	s.RLock()
+	defer s.RUnlock()
+
+	return s.current
+}
+
+// connect creates the integration for the given configuration and swaps it
+// with the current integration. It returns an error when the integration
+// could not be created or when it did not connect within swapTimeout, in
+// which case the current integration is kept.
+func (s *swappable) connect(conf config.Config) error {
+	s.swapMux.Lock()
+	defer s.swapMux.Unlock()
+
+	type result struct {
+		i   Integration
+		err error
+	}
+	resultChan := make(chan result, 1)
+
+	go func() {
+		i, err := newIntegration(conf)
+		resultChan <- result{i, err}
+	}()
+
+	select {
+	case res := <-resultChan:
+		if res.err != nil {
+			return res.err
+		}
+		if err := s.swap(conf, res.i); err != nil {
+			res.i.Close()
+			return err
+		}
+		return nil
+	case <-time.After(swapTimeout):
+		// the integration keeps connecting in the background, close it once
+		// connected
+		go func() {
+			if res := <-resultChan; res.err == nil {
+				res.i.Close()
+			}
+		}()
+		return errors.New("connect integration timeout")
+	}
+}
+
+// swap swaps the current integration with the given (connected)
+// integration. The subscriptions of the current integration are created on
+// the new integration before publishing is switched over, after which the
+// previous integration is closed.
+func (s *swappable) swap(conf config.Config, i Integration) error {
+	s.Lock()
+
+	for gatewayID := range s.gateways {
+		if err := i.SubscribeGateway(gatewayID); err != nil {
+			s.Unlock()
+			return errors.Wrap(err, "subscribe gateway error")
+		}
+	}
+
+	prev := s.current
+	prevStopped := s.stopped
+	gateways := len(s.gateways)
+	close(s.done)
+
+	s.conf = conf
+	s.current = i
+	s.done = make(chan struct{})
+	s.stopped = make(chan struct{})
+	go s.forward(i, s.done, s.stopped)
+
+	s.Unlock()
+
+	// wait until the messages of the previous integration are no longer
+	// forwarded, this is not done while holding the lock as the forwarding
+	// could be blocked until the consumer reads the message
+	<-prevStopped
+
+	if err := prev.Close(); err != nil {
+		log.WithError(err).Error("integration: close previous integration error")
+	}
+
+	log.WithFields(log.Fields{
+		"type":     conf.Integration.Type,
+		"gateways": gateways,
+	}).Info("integration: integration swapped")
+
+	return nil
+}
+
+// forward forwards the messages received from the given integration, until
+// done is closed. The stopped channel is closed on return.
+func (s *swappable) forward(i Integration, done, stopped chan struct{}) {
+	defer close(stopped)
+
+	for {
+		select {
+		case v := <-i.GetDownlinkFrameChan():
+			s.downlinkFrameChan <- v
+		case v := <-i.GetGatewayConfigurationChan():
+			s.gatewayConfigurationChan <- v
+		case v := <-i.GetGatewayCommandExecRequestChan():
+			s.gatewayCommandExecRequestChan <- v
+		case v := <-i.GetSpectralScanRequestChan():
+			s.spectralScanRequestChan <- v
+		case v := <-i.GetMulticastRequestChan():
+			s.multicastRequestChan <- v
+		case v := <-i.GetDownlinkSwitchRequestChan():
+			s.downlinkSwitchRequestChan <- v
+		case <-done:
+			return
+		}
+	}
+}
+
+// getSettings returns the current settings. Passwords are omitted.
+func (s *swappable) getSettings() settings {
+	s.RLock()
+	defer s.RUnlock()
+
+	var out settings
+	out.Type = s.conf.Integration.Type
+	out.MQTT.Server = s.conf.Integration.MQTT.Auth.Generic.Server
+	out.MQTT.Username = s.conf.Integration.MQTT.Auth.Generic.Username
+	out.MQTT.EventTopicTemplate = s.conf.Integration.MQTT.EventTopicTemplate
+	out.MQTT.CommandTopicTemplate = s.conf.Integration.MQTT.CommandTopicTemplate
+	out.AMQP.URL = s.conf.Integration.AMQP.URL
+	out.AMQP.EventAddressTemplate = s.conf.Integration.AMQP.EventAddressTemplate
+	out.AMQP.CommandAddress = s.conf.Integration.AMQP.CommandAddress
+
+	if u, err := url.Parse(out.AMQP.URL); err == nil && u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), "xxxxx")
+			out.AMQP.URL = u.String()
+		}
+	}
+
+	return out
+}
+
+// applySettings returns a copy of the current configuration with the given
+// settings applied.
+func (s *swappable) applySettings(set settings) config.Config {
+	s.RLock()
+	conf := s.conf
+	s.RUnlock()
+
+	apply := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+
+	apply(&conf.Integration.Type, set.Type)
+	apply(&conf.Integration.MQTT.Auth.Generic.Server, set.MQTT.Server)
+	apply(&conf.Integration.MQTT.Auth.Generic.Username, set.MQTT.Username)
+	apply(&conf.Integration.MQTT.Auth.Generic.Password, set.MQTT.Password)
+	apply(&conf.Integration.MQTT.EventTopicTemplate, set.MQTT.EventTopicTemplate)
+	apply(&conf.Integration.MQTT.CommandTopicTemplate, set.MQTT.CommandTopicTemplate)
+	apply(&conf.Integration.AMQP.URL, set.AMQP.URL)
+	apply(&conf.Integration.AMQP.EventAddressTemplate, set.AMQP.EventAddressTemplate)
+	apply(&conf.Integration.AMQP.CommandAddress, set.AMQP.CommandAddress)
+
+	return conf
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// current settings, a POST request connects a new integration with the
+// given settings and swaps it with the current integration.
+func (s *swappable) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var set settings
+		if err := json.NewDecoder(r.Body).Decode(&set); err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+			return
+		}
+
+		if err := s.connect(s.applySettings(set)); err != nil {
+			admin.WriteError(w, http.StatusBadGateway, errors.Wrap(err, "swap integration error"))
+			return
+		}
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin.WriteJSON(w, s.getSettings())
+}
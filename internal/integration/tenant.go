@@ -0,0 +1,259 @@
+package integration
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/mqtt"
+	"github.com/brocaar/lora-gateway-bridge/internal/multicast"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// tenants implements an integration maintaining a MQTT connection per
+// tenant (gateway group), each with its own credentials and topic prefix.
+// The gateways of a tenant are subscribed and their events are published
+// using the connection of the tenant, the other gateways use the default
+// connection. The messages received from all connections are forwarded to
+// a single set of channels.
+type tenants struct {
+	def         Integration
+	connections map[string]Integration
+	gateways    map[lorawan.EUI64]Integration
+	done        chan struct{}
+
+	downlinkFrameChan             chan gw.DownlinkFrame
+	gatewayConfigurationChan      chan gw.GatewayConfiguration
+	gatewayCommandExecRequestChan chan gw.GatewayCommandExecRequest
+	spectralScanRequestChan       chan spectralscan.Request
+	multicastRequestChan          chan multicast.Request
+	downlinkSwitchRequestChan     chan downlinkswitch.Request
+}
+
+func newTenants(conf config.Config) (Integration, error) {
+	if conf.Integration.MQTT.Auth.Type != "generic" {
+		return nil, errors.New("tenants require the generic mqtt authentication type")
+	}
+
+	t := tenants{
+		connections:                   make(map[string]Integration),
+		gateways:                      make(map[lorawan.EUI64]Integration),
+		done:                          make(chan struct{}),
+		downlinkFrameChan:             make(chan gw.DownlinkFrame),
+		gatewayConfigurationChan:      make(chan gw.GatewayConfiguration),
+		gatewayCommandExecRequestChan: make(chan gw.GatewayCommandExecRequest),
+		spectralScanRequestChan:       make(chan spectralscan.Request),
+		multicastRequestChan:          make(chan multicast.Request),
+		downlinkSwitchRequestChan:     make(chan downlinkswitch.Request),
+	}
+
+	// validate the tenants before connecting
+	tenantGateways := make(map[string][]lorawan.EUI64)
+	seen := make(map[lorawan.EUI64]string)
+	for _, tc := range conf.Integration.MQTT.Tenants {
+		if tc.Name == "" {
+			return nil, errors.New("tenant name must be set")
+		}
+		if _, ok := tenantGateways[tc.Name]; ok {
+			return nil, errors.Errorf("duplicate tenant name: %s", tc.Name)
+		}
+		tenantGateways[tc.Name] = nil
+
+		for _, id := range tc.GatewayIDs {
+			var gatewayID lorawan.EUI64
+			if err := gatewayID.UnmarshalText([]byte(id)); err != nil {
+				return nil, errors.Wrapf(err, "unmarshal gateway_id error (tenant: %s)", tc.Name)
+			}
+			if name, ok := seen[gatewayID]; ok {
+				return nil, errors.Errorf("gateway %s is assigned to tenants %s and %s", gatewayID, name, tc.Name)
+			}
+			seen[gatewayID] = tc.Name
+			tenantGateways[tc.Name] = append(tenantGateways[tc.Name], gatewayID)
+		}
+	}
+
+	def, err := mqtt.NewBackend(conf)
+	if err != nil {
+		return nil, errors.Wrap(err, "setup mqtt integration error")
+	}
+	t.def = def
+	go t.forward(def)
+
+	for _, tc := range conf.Integration.MQTT.Tenants {
+		i, err := mqtt.NewBackend(tenantConfig(conf, tc))
+		if err != nil {
+			t.Close()
+			return nil, errors.Wrapf(err, "setup mqtt integration error (tenant: %s)", tc.Name)
+		}
+		t.connections[tc.Name] = i
+		go t.forward(i)
+
+		for _, gatewayID := range tenantGateways[tc.Name] {
+			t.gateways[gatewayID] = i
+		}
+
+		log.WithFields(log.Fields{
+			"tenant":       tc.Name,
+			"gateways":     len(tenantGateways[tc.Name]),
+			"topic_prefix": tc.TopicPrefix,
+		}).Info("integration: tenant mqtt connection configured")
+	}
+
+	return &t, nil
+}
+
+// tenantConfig returns the configuration of the MQTT connection of the given
+// tenant. The bridge commands are only handled by the default connection.
+func tenantConfig(conf config.Config, tc config.MQTTTenant) config.Config {
+	mc := &conf.Integration.MQTT
+	generic := &mc.Auth.Generic
+
+	if tc.Username != "" {
+		generic.Username = tc.Username
+	}
+	if tc.Password != "" {
+		generic.Password = tc.Password
+	}
+
+	switch {
+	case tc.ClientID != "":
+		generic.ClientID = tc.ClientID
+	case generic.ClientID != "":
+		// two connections using the same client id would disconnect each
+		// other
+		generic.ClientID = fmt.Sprintf("%s-%s", generic.ClientID, tc.Name)
+	}
+
+	if tc.TopicPrefix != "" {
+		prefix := strings.TrimSuffix(tc.TopicPrefix, "/") + "/"
+		mc.EventTopicTemplate = prefix + mc.EventTopicTemplate
+		mc.CommandTopicTemplate = prefix + mc.CommandTopicTemplate
+		if mc.SharedCommandTopic != "" {
+			mc.SharedCommandTopic = prefix + mc.SharedCommandTopic
+		}
+	}
+
+	mc.BridgeCommandTopic = ""
+	mc.Tenants = nil
+
+	return conf
+}
+
+// get returns the connection for the given gateway.
+func (t *tenants) get(gatewayID lorawan.EUI64) Integration {
+	if i, ok := t.gateways[gatewayID]; ok {
+		return i
+	}
+	return t.def
+}
+
+// SubscribeGateway creates a subscription for the given gateway ID.
+func (t *tenants) SubscribeGateway(gatewayID lorawan.EUI64) error {
+	return t.get(gatewayID).SubscribeGateway(gatewayID)
+}
+
+// UnsubscribeGateway removes the subscription for the given gateway ID.
+func (t *tenants) UnsubscribeGateway(gatewayID lorawan.EUI64) error {
+	return t.get(gatewayID).UnsubscribeGateway(gatewayID)
+}
+
+// PublishEvent publishes the given event.
+func (t *tenants) PublishEvent(gatewayID lorawan.EUI64, event string, id uuid.UUID, v proto.Message) error {
+	return t.get(gatewayID).PublishEvent(gatewayID, event, id, v)
+}
+
+// GetDownlinkFrameChan returns the channel for downlink frames.
+func (t *tenants) GetDownlinkFrameChan() chan gw.DownlinkFrame {
+	return t.downlinkFrameChan
+}
+
+// GetGatewayConfigurationChan returns the channel for gateway configuration.
+func (t *tenants) GetGatewayConfigurationChan() chan gw.GatewayConfiguration {
+	return t.gatewayConfigurationChan
+}
+
+// GetGatewayCommandExecRequestChan returns the channel for gateway command execution.
+func (t *tenants) GetGatewayCommandExecRequestChan() chan gw.GatewayCommandExecRequest {
+	return t.gatewayCommandExecRequestChan
+}
+
+// GetSpectralScanRequestChan returns the channel for spectral scan requests.
+func (t *tenants) GetSpectralScanRequestChan() chan spectralscan.Request {
+	return t.spectralScanRequestChan
+}
+
+// GetMulticastRequestChan returns the channel for multicast downlink requests.
+func (t *tenants) GetMulticastRequestChan() chan multicast.Request {
+	return t.multicastRequestChan
+}
+
+// GetDownlinkSwitchRequestChan returns the channel for downlink switch requests.
+func (t *tenants) GetDownlinkSwitchRequestChan() chan downlinkswitch.Request {
+	return t.downlinkSwitchRequestChan
+}
+
+// HealthCheck returns an error when one of the connections is not healthy.
+func (t *tenants) HealthCheck() error {
+	if err := t.def.HealthCheck(); err != nil {
+		return err
+	}
+
+	for name, i := range t.connections {
+		if err := i.HealthCheck(); err != nil {
+			return errors.Wrapf(err, "tenant %s", name)
+		}
+	}
+
+	return nil
+}
+
+// Close closes all connections.
+func (t *tenants) Close() error {
+	close(t.done)
+
+	var closeErr error
+	for name, i := range t.connections {
+		if err := i.Close(); err != nil {
+			closeErr = errors.Wrapf(err, "close tenant %s error", name)
+		}
+	}
+
+	if t.def != nil {
+		if err := t.def.Close(); err != nil {
+			closeErr = err
+		}
+	}
+
+	return closeErr
+}
+
+// forward forwards the messages received from the given connection, until
+// the integration is closed.
+func (t *tenants) forward(i Integration) {
+	for {
+		select {
+		case v := <-i.GetDownlinkFrameChan():
+			t.downlinkFrameChan <- v
+		case v := <-i.GetGatewayConfigurationChan():
+			t.gatewayConfigurationChan <- v
+		case v := <-i.GetGatewayCommandExecRequestChan():
+			t.gatewayCommandExecRequestChan <- v
+		case v := <-i.GetSpectralScanRequestChan():
+			t.spectralScanRequestChan <- v
+		case v := <-i.GetMulticastRequestChan():
+			t.multicastRequestChan <- v
+		case v := <-i.GetDownlinkSwitchRequestChan():
+			t.downlinkSwitchRequestChan <- v
+		case <-t.done:
+			return
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package integration
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/lora-gateway-bridge/internal/multicast"
+	"github.com/brocaar/lora-gateway-bridge/internal/spectralscan"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestTenantConfig(t *testing.T) {
+	var conf config.Config
+	conf.Integration.MQTT.EventTopicTemplate = "gateway/{{ .GatewayID }}/event/{{ .EventType }}"
+	conf.Integration.MQTT.CommandTopicTemplate = "gateway/{{ .GatewayID }}/command/#"
+	conf.Integration.MQTT.BridgeCommandTopic = "bridge/command/#"
+	conf.Integration.MQTT.Auth.Generic.Username = "bridge"
+	conf.Integration.MQTT.Auth.Generic.Password = "secret"
+	conf.Integration.MQTT.Tenants = []config.MQTTTenant{
+		{Name: "tenant-a"},
+	}
+
+	tests := []struct {
+		Name                 string
+		ClientID             string
+		Tenant               config.MQTTTenant
+		ExpectedUsername     string
+		ExpectedPassword     string
+		ExpectedClientID     string
+		ExpectedEventTopic   string
+		ExpectedCommandTopic string
+	}{
+		{
+			Name: "credentials and topic prefix",
+			Tenant: config.MQTTTenant{
+				Name:        "tenant-a",
+				Username:    "tenant-a",
+				Password:    "tenant-a-secret",
+				TopicPrefix: "tenant-a/",
+			},
+			ExpectedUsername:     "tenant-a",
+			ExpectedPassword:     "tenant-a-secret",
+			ExpectedEventTopic:   "tenant-a/gateway/{{ .GatewayID }}/event/{{ .EventType }}",
+			ExpectedCommandTopic: "tenant-a/gateway/{{ .GatewayID }}/command/#",
+		},
+		{
+			Name:     "default credentials and client id suffix",
+			ClientID: "bridge",
+			Tenant: config.MQTTTenant{
+				Name: "tenant-b",
+			},
+			ExpectedUsername:     "bridge",
+			ExpectedPassword:     "secret",
+			ExpectedClientID:     "bridge-tenant-b",
+			ExpectedEventTopic:   "gateway/{{ .GatewayID }}/event/{{ .EventType }}",
+			ExpectedCommandTopic: "gateway/{{ .GatewayID }}/command/#",
+		},
+		{
+			Name:     "client id",
+			ClientID: "bridge",
+			Tenant: config.MQTTTenant{
+				Name:     "tenant-c",
+				ClientID: "tenant-c",
+			},
+			ExpectedUsername:     "bridge",
+			ExpectedPassword:     "secret",
+			ExpectedClientID:     "tenant-c",
+			ExpectedEventTopic:   "gateway/{{ .GatewayID }}/event/{{ .EventType }}",
+			ExpectedCommandTopic: "gateway/{{ .GatewayID }}/command/#",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			c := conf
+			c.Integration.MQTT.Auth.Generic.ClientID = tst.ClientID
+			tc := tenantConfig(c, tst.Tenant)
+
+			assert.Equal(tst.ExpectedUsername, tc.Integration.MQTT.Auth.Generic.Username)
+			assert.Equal(tst.ExpectedPassword, tc.Integration.MQTT.Auth.Generic.Password)
+			assert.Equal(tst.ExpectedClientID, tc.Integration.MQTT.Auth.Generic.ClientID)
+			assert.Equal(tst.ExpectedEventTopic, tc.Integration.MQTT.EventTopicTemplate)
+			assert.Equal(tst.ExpectedCommandTopic, tc.Integration.MQTT.CommandTopicTemplate)
+			assert.Equal("", tc.Integration.MQTT.BridgeCommandTopic)
+			assert.Len(tc.Integration.MQTT.Tenants, 0)
+
+			// the default configuration is not modified
+			assert.Equal("bridge", c.Integration.MQTT.Auth.Generic.Username)
+			assert.Equal("bridge/command/#", c.Integration.MQTT.BridgeCommandTopic)
+		})
+	}
+}
+
+func TestTenants(t *testing.T) {
+	assert := require.New(t)
+
+	gatewayID1 := lorawan.EUI64{1, 1, 1, 1, 1, 1, 1, 1}
+	gatewayID2 := lorawan.EUI64{2, 2, 2, 2, 2, 2, 2, 2}
+
+	def := newTestIntegration()
+	tenantA := newTestIntegration()
+
+	ten := tenants{
+		def: def,
+		connections: map[string]Integration{
+			"tenant-a": tenantA,
+		},
+		gateways: map[lorawan.EUI64]Integration{
+			gatewayID1: tenantA,
+		},
+		done:                          make(chan struct{}),
+		downlinkFrameChan:             make(chan gw.DownlinkFrame),
+		gatewayConfigurationChan:      make(chan gw.GatewayConfiguration),
+		gatewayCommandExecRequestChan: make(chan gw.GatewayCommandExecRequest),
+		spectralScanRequestChan:       make(chan spectralscan.Request),
+		multicastRequestChan:          make(chan multicast.Request),
+		downlinkSwitchRequestChan:     make(chan downlinkswitch.Request),
+	}
+	go ten.forward(def)
+	go ten.forward(tenantA)
+
+	assert.NoError(ten.SubscribeGateway(gatewayID1))
+	assert.NoError(ten.SubscribeGateway(gatewayID2))
+	assert.Equal(map[lorawan.EUI64]struct{}{gatewayID1: {}}, tenantA.subscribed)
+	assert.Equal(map[lorawan.EUI64]struct{}{gatewayID2: {}}, def.subscribed)
+
+	assert.NoError(ten.PublishEvent(gatewayID1, EventUp, uuid.Nil, &gw.UplinkFrame{}))
+	assert.NoError(ten.PublishEvent(gatewayID2, EventStats, uuid.Nil, &gw.GatewayStats{}))
+	assert.Equal([]string{EventUp}, tenantA.published)
+	assert.Equal([]string{EventStats}, def.published)
+
+	tenantA.downlinkFrameChan <- gw.DownlinkFrame{Token: 1}
+	assert.Equal(uint32(1), (<-ten.GetDownlinkFrameChan()).Token)
+	def.downlinkFrameChan <- gw.DownlinkFrame{Token: 2}
+	assert.Equal(uint32(2), (<-ten.GetDownlinkFrameChan()).Token)
+
+	assert.NoError(ten.UnsubscribeGateway(gatewayID1))
+	assert.Len(tenantA.subscribed, 0)
+
+	assert.NoError(ten.Close())
+	assert.True(def.closed)
+	assert.True(tenantA.closed)
+}
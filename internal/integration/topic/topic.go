@@ -0,0 +1,87 @@
+// Package topic implements the parsing and rendering of the topic (MQTT) and
+// address (AMQP) templates of the integrations.
+package topic
+
+import (
+	"os"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+// funcs contains the (Sprig compatible) functions available in the
+// templates. The string to operate on is the last argument, so that these
+// functions can be used in pipelines, e.g. {{ .Region | replace "-" "_" }}.
+var funcs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"replace": func(old, new, s string) string {
+		return strings.Replace(s, old, new, -1)
+	},
+	"trimPrefix": func(prefix, s string) string {
+		return strings.TrimPrefix(s, prefix)
+	},
+	"trimSuffix": func(suffix, s string) string {
+		return strings.TrimSuffix(s, suffix)
+	},
+}
+
+// Vars holds the variables available in the templates.
+type Vars struct {
+	// GatewayID of the gateway (event and command topics).
+	GatewayID lorawan.EUI64
+
+	// EventType (e.g. up or stats) of the event topics.
+	EventType string
+
+	// CommandType (e.g. list_gateways) of the bridge response topics.
+	CommandType string
+
+	// BridgeID identifies the LoRa Gateway Bridge instance.
+	BridgeID string
+
+	// Region (e.g. the deployment region) of the LoRa Gateway Bridge.
+	Region string
+
+	// Tenant of the LoRa Gateway Bridge.
+	Tenant string
+
+	// Band (e.g. EU868) of the gateways.
+	Band string
+
+	// Frequency (Hz), SpreadingFactor (0 for FSK) and RSSI (dBm) of the
+	// uplink (raw uplink topics).
+	Frequency       uint32
+	SpreadingFactor uint32
+	RSSI            int32
+}
+
+// NewVars returns the variables configured in the topic_variables section.
+// When the bridge ID is not configured, the hostname is used.
+func NewVars(conf config.Config) (Vars, error) {
+	v := Vars{
+		BridgeID: conf.Integration.TopicVariables.BridgeID,
+		Region:   conf.Integration.TopicVariables.Region,
+		Tenant:   conf.Integration.TopicVariables.Tenant,
+		Band:     conf.Integration.TopicVariables.Band,
+	}
+
+	if v.BridgeID == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return v, errors.Wrap(err, "get hostname error")
+		}
+		v.BridgeID = hostname
+	}
+
+	return v, nil
+}
+
+// Parse parses the given template, with the topic functions.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcs).Parse(text)
+}
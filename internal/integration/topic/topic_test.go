@@ -0,0 +1,100 @@
+package topic
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+func TestTemplate(t *testing.T) {
+	vars := Vars{
+		GatewayID:   lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		EventType:   "up",
+		CommandType: "list_gateways",
+		BridgeID:    "bridge-1",
+		Region:      "EU-West",
+		Tenant:      "acme",
+		Band:        "EU868",
+	}
+
+	tests := []struct {
+		Name          string
+		Template      string
+		Expected      string
+		ExpectedError string
+	}{
+		{
+			Name:     "default event topic",
+			Template: "gateway/{{ .GatewayID }}/event/{{ .EventType }}",
+			Expected: "gateway/0102030405060708/event/up",
+		},
+		{
+			Name:     "bridge response topic",
+			Template: "lora-gateway-bridge/{{ .BridgeID }}/response/{{ .CommandType }}",
+			Expected: "lora-gateway-bridge/bridge-1/response/list_gateways",
+		},
+		{
+			Name:     "topic variables",
+			Template: "{{ .Tenant }}/{{ .Region }}/{{ .Band }}/gateway/{{ .GatewayID }}",
+			Expected: "acme/EU-West/EU868/gateway/0102030405060708",
+		},
+		{
+			Name:     "functions",
+			Template: `{{ .Region | lower | replace "-" "_" }}/{{ .Band | lower | trimPrefix "eu" }}/{{ .Tenant | upper }}/{{ trimSuffix "-1" .BridgeID }}`,
+			Expected: "eu_west/868/ACME/bridge",
+		},
+		{
+			Name:          "unknown function",
+			Template:      "{{ .Region | title }}",
+			ExpectedError: `template: test:1: function "title" not defined`,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			tmpl, err := Parse("test", tst.Template)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+
+			b := bytes.NewBuffer(nil)
+			assert.NoError(tmpl.Execute(b, vars))
+			assert.Equal(tst.Expected, b.String())
+		})
+	}
+}
+
+func TestNewVars(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Integration.TopicVariables.Region = "eu-west"
+	conf.Integration.TopicVariables.Tenant = "acme"
+	conf.Integration.TopicVariables.Band = "EU868"
+
+	hostname, err := os.Hostname()
+	assert.NoError(err)
+
+	vars, err := NewVars(conf)
+	assert.NoError(err)
+	assert.Equal(Vars{
+		BridgeID: hostname,
+		Region:   "eu-west",
+		Tenant:   "acme",
+		Band:     "EU868",
+	}, vars)
+
+	conf.Integration.TopicVariables.BridgeID = "bridge-1"
+	vars, err = NewVars(conf)
+	assert.NoError(err)
+	assert.Equal("bridge-1", vars.BridgeID)
+}
@@ -0,0 +1,279 @@
+// Package keepalive tracks the keepalives (Semtech UDP PULL_DATA or Basic
+// Station websocket pongs) of the gateways. When a gateway misses the
+// configured number of keepalives, the alert hooks are invoked and a notify
+// event (KEEPALIVE_TIMEOUT) is published.
+package keepalive
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/commands/cmdline"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
+	"github.com/brocaar/lorawan"
+)
+
+// notifyCode is the code of the notify event published on a timeout.
+const notifyCode = "KEEPALIVE_TIMEOUT"
+
+// timeout contains a gateway keepalive timeout.
+type timeout struct {
+	GatewayID        lorawan.EUI64 `json:"gatewayID"`
+	LastSeen         time.Time     `json:"lastSeen"`
+	MissedKeepalives int           `json:"missedKeepalives"`
+}
+
+type gateway struct {
+	lastSeen time.Time
+	alerted  bool
+}
+
+var (
+	mux sync.RWMutex
+
+	enabled              bool
+	interval             time.Duration
+	missed               int
+	alertCommand         string
+	alertWebhook         string
+	maxExecutionDuration time.Duration
+
+	gateways = make(map[lorawan.EUI64]*gateway)
+)
+
+// Setup configures the keepalive tracking.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !conf.Keepalive.Enabled {
+		return nil
+	}
+
+	if conf.Keepalive.Interval <= 0 {
+		return errors.New("keepalive interval must be greater than 0")
+	}
+
+	enabled = true
+	interval = conf.Keepalive.Interval
+	missed = conf.Keepalive.Missed
+	alertCommand = conf.Keepalive.AlertCommand
+	alertWebhook = conf.Keepalive.AlertWebhook
+	maxExecutionDuration = conf.Keepalive.MaxExecutionDuration
+
+	if missed < 1 {
+		missed = 1
+	}
+
+	log.WithFields(log.Fields{
+		"interval": interval,
+		"missed":   missed,
+	}).Info("keepalive: keepalive tracking enabled")
+
+	go func() {
+		for {
+			time.Sleep(time.Second)
+			for _, t := range check(time.Now()) {
+				go alert(t)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Seen registers a keepalive of the given gateway.
+func Seen(gatewayID lorawan.EUI64) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	now := time.Now()
+
+	if gw, ok := gateways[gatewayID]; ok {
+		keepaliveIntervalHistogram().Observe(now.Sub(gw.lastSeen).Seconds())
+
+		if gw.alerted {
+			log.WithFields(log.Fields{
+				"gateway_id": gatewayID,
+				"last_seen":  gw.lastSeen,
+			}).Info("keepalive: gateway keepalive received after timeout")
+		}
+	}
+
+	gateways[gatewayID] = &gateway{
+		lastSeen: now,
+	}
+}
+
+// check returns the gateways that missed the configured number of
+// keepalives and for which no alert has been triggered yet.
+func check(now time.Time) []timeout {
+	mux.Lock()
+	defer mux.Unlock()
+
+	var out []timeout
+
+	for gatewayID, gw := range gateways {
+		if gw.alerted {
+			continue
+		}
+
+		missedKeepalives := int(now.Sub(gw.lastSeen) / interval)
+		if missedKeepalives < missed {
+			continue
+		}
+
+		gw.alerted = true
+		out = append(out, timeout{
+			GatewayID:        gatewayID,
+			LastSeen:         gw.lastSeen,
+			MissedKeepalives: missedKeepalives,
+		})
+	}
+
+	return out
+}
+
+func alert(t timeout) {
+	keepaliveTimeoutCounter().Inc()
+
+	log.WithFields(log.Fields{
+		"gateway_id":        t.GatewayID,
+		"last_seen":         t.LastSeen,
+		"missed_keepalives": t.MissedKeepalives,
+	}).Warning("keepalive: gateway missed keepalives")
+
+	if err := publishTimeout(t); err != nil {
+		log.WithError(err).WithField("gateway_id", t.GatewayID).Error("keepalive: publish notify event error")
+	}
+
+	if err := runAlertCommand(t); err != nil {
+		log.WithError(err).WithField("gateway_id", t.GatewayID).Error("keepalive: run alert command error")
+	}
+
+	if err := callAlertWebhook(t); err != nil {
+		log.WithError(err).WithField("gateway_id", t.GatewayID).Error("keepalive: call alert webhook error")
+	}
+}
+
+func publishTimeout(t timeout) error {
+	i := integration.GetIntegration()
+	if i == nil {
+		return nil
+	}
+
+	pl, err := timeoutNotify(t, time.Now())
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return errors.Wrap(err, "new uuid error")
+	}
+
+	return i.PublishEvent(t.GatewayID, integration.EventNotify, id, pl)
+}
+
+// timeoutNotify returns the notify event payload for the given timeout.
+func timeoutNotify(t timeout, now time.Time) (*integration.Notify, error) {
+	lastSeen, err := ptypes.TimestampProto(t.LastSeen)
+	if err != nil {
+		return nil, errors.Wrap(err, "timestamp proto error")
+	}
+
+	ts, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return nil, errors.Wrap(err, "timestamp proto error")
+	}
+
+	return &integration.Notify{
+		GatewayId: t.GatewayID[:],
+		Level:     "error",
+		Code:      notifyCode,
+		Message:   fmt.Sprintf("gateway missed %d keepalives, last seen at %s", t.MissedKeepalives, t.LastSeen.Format(time.RFC3339)),
+		Time:      ts,
+		Details: &integration.Notify_Timeout{
+			Timeout: &integration.Timeout{
+				GatewayId:        t.GatewayID[:],
+				LastSeen:         lastSeen,
+				MissedKeepalives: uint32(t.MissedKeepalives),
+			},
+		},
+	}, nil
+}
+
+func runAlertCommand(t timeout) error {
+	if alertCommand == "" {
+		return nil
+	}
+
+	cmdArgs, err := cmdline.Parse(alertCommand)
+	if err != nil {
+		return errors.Wrap(err, "parse command error")
+	}
+	if len(cmdArgs) == 0 {
+		return errors.New("no command is given")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), maxExecutionDuration)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, cmdArgs[0], cmdArgs[1:]...)
+	cmd.Env = []string{
+		fmt.Sprintf("GATEWAY_ID=%s", t.GatewayID),
+		fmt.Sprintf("LAST_SEEN=%s", t.LastSeen.Format(time.RFC3339)),
+		fmt.Sprintf("MISSED_KEEPALIVES=%d", t.MissedKeepalives),
+	}
+
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return errors.Wrapf(err, "execution error (output: %s)", out)
+	}
+
+	return nil
+}
+
+func callAlertWebhook(t timeout) error {
+	if alertWebhook == "" {
+		return nil
+	}
+
+	b, err := json.Marshal(t)
+	if err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+
+	client := http.Client{
+		Timeout:   maxExecutionDuration,
+		Transport: proxy.Transport(),
+	}
+
+	resp, err := client.Post(alertWebhook, "application/json", bytes.NewReader(b))
+	if err != nil {
+		return errors.Wrap(err, "http post error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("expected 2xx response, got: %d", resp.StatusCode)
+	}
+
+	return nil
+}
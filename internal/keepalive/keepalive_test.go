@@ -0,0 +1,127 @@
+package keepalive
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lorawan"
+)
+
+func TestCheck(t *testing.T) {
+	assert := require.New(t)
+
+	enabled = true
+	interval = 10 * time.Second
+	missed = 3
+	gateways = make(map[lorawan.EUI64]*gateway)
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	Seen(gatewayID)
+	lastSeen := gateways[gatewayID].lastSeen
+
+	t.Run("Within interval", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Len(check(lastSeen.Add(29*time.Second)), 0)
+	})
+
+	t.Run("Missed keepalives", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Equal([]timeout{
+			{
+				GatewayID:        gatewayID,
+				LastSeen:         lastSeen,
+				MissedKeepalives: 3,
+			},
+		}, check(lastSeen.Add(30*time.Second)))
+	})
+
+	t.Run("Alert is triggered once", func(t *testing.T) {
+		assert := require.New(t)
+		assert.Len(check(lastSeen.Add(time.Minute)), 0)
+	})
+
+	t.Run("Seen resets alert", func(t *testing.T) {
+		assert := require.New(t)
+		Seen(gatewayID)
+		assert.False(gateways[gatewayID].alerted)
+	})
+
+	assert.Len(gateways, 1)
+}
+
+func TestTimeoutNotify(t *testing.T) {
+	assert := require.New(t)
+
+	lastSeen := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	to := timeout{
+		GatewayID:        lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		LastSeen:         lastSeen,
+		MissedKeepalives: 3,
+	}
+
+	pl, err := timeoutNotify(to, lastSeen.Add(30*time.Second))
+	assert.NoError(err)
+
+	lastSeenPB, _ := ptypes.TimestampProto(lastSeen)
+	nowPB, _ := ptypes.TimestampProto(lastSeen.Add(30 * time.Second))
+	assert.Equal(&integration.Notify{
+		GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Level:     "error",
+		Code:      "KEEPALIVE_TIMEOUT",
+		Message:   "gateway missed 3 keepalives, last seen at 2019-09-10T12:00:00Z",
+		Time:      nowPB,
+		Details: &integration.Notify_Timeout{
+			Timeout: &integration.Timeout{
+				GatewayId:        []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				LastSeen:         lastSeenPB,
+				MissedKeepalives: 3,
+			},
+		},
+	}, pl)
+}
+
+func TestCallAlertWebhook(t *testing.T) {
+	assert := require.New(t)
+
+	var received timeout
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.NoError(json.NewDecoder(r.Body).Decode(&received))
+	}))
+	defer server.Close()
+
+	alertWebhook = server.URL
+	maxExecutionDuration = time.Second
+	defer func() { alertWebhook = "" }()
+
+	to := timeout{
+		GatewayID:        lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		LastSeen:         time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+		MissedKeepalives: 3,
+	}
+	assert.NoError(callAlertWebhook(to))
+	assert.Equal(to, received)
+}
+
+func TestRunAlertCommand(t *testing.T) {
+	assert := require.New(t)
+
+	maxExecutionDuration = time.Second
+	defer func() { alertCommand = "" }()
+
+	to := timeout{
+		GatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+
+	alertCommand = `sh -c 'test "$GATEWAY_ID" = "0102030405060708"'`
+	assert.NoError(runAlertCommand(to))
+
+	alertCommand = `sh -c 'test "$GATEWAY_ID" = "0807060504030201"'`
+	assert.Error(runAlertCommand(to))
+}
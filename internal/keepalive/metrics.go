@@ -0,0 +1,27 @@
+package keepalive
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	ki = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "keepalive_interval_seconds",
+		Help:    "The interval between two gateway keepalives (in seconds).",
+		Buckets: []float64{1, 5, 10, 15, 30, 60, 120, 300},
+	})
+
+	kt = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "keepalive_timeout_count",
+		Help: "The number of gateways that missed the configured number of keepalives.",
+	})
+)
+
+func keepaliveIntervalHistogram() prometheus.Histogram {
+	return ki
+}
+
+func keepaliveTimeoutCounter() prometheus.Counter {
+	return kt
+}
@@ -0,0 +1,171 @@
+// Package latency estimates the backhaul latency per gateway, so that the
+// forwarder can predict when a downlink can no longer make its RX window. The
+// round-trip time is measured using the WebSocket ping / pong messages (Basic
+// Station) or the time between sending a PULL_RESP and receiving its TX_ACK
+// (Semtech UDP). The predicted lead time of a downlink is half the smoothed
+// round-trip time plus the configured margin.
+package latency
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+// smoothingFactor defines the weight of a new round-trip time sample.
+const smoothingFactor = 0.25
+
+// sentRetention defines how long the sent time of a downlink is kept when
+// its ack is not received.
+const sentRetention = time.Minute
+
+type estimate struct {
+	rtt     time.Duration
+	updated time.Time
+}
+
+var (
+	mux sync.Mutex
+
+	enabled bool
+	margin  time.Duration
+	maxAge  time.Duration
+
+	estimates = make(map[lorawan.EUI64]*estimate)
+
+	// sent contains the sent time per gateway ID and token of the downlinks
+	// waiting for their ack.
+	sent        = make(map[string]time.Time)
+	sentCleaned time.Time
+)
+
+// Setup configures the latency package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	enabled = conf.BackhaulLatency.Enabled
+	margin = conf.BackhaulLatency.Margin
+	maxAge = conf.BackhaulLatency.MaxAge
+	estimates = make(map[lorawan.EUI64]*estimate)
+	sent = make(map[string]time.Time)
+
+	if enabled {
+		log.WithFields(log.Fields{
+			"margin":  margin,
+			"max_age": maxAge,
+		}).Info("latency: rx window miss prediction enabled")
+	}
+
+	return nil
+}
+
+// Enabled returns true when the RX window miss prediction is enabled.
+func Enabled() bool {
+	mux.Lock()
+	defer mux.Unlock()
+
+	return enabled
+}
+
+// Observe registers the measured round-trip time of the given gateway.
+func Observe(gatewayID lorawan.EUI64, rtt time.Duration) {
+	observe(gatewayID, rtt, time.Now())
+}
+
+// DownlinkSent registers the time the downlink with the given token was sent
+// to the gateway. On ack, the round-trip time is observed.
+func DownlinkSent(gatewayID lorawan.EUI64, token uint16) {
+	downlinkSent(gatewayID, token, time.Now())
+}
+
+// DownlinkAcked observes the round-trip time of the downlink with the given
+// token, if its sent time was registered.
+func DownlinkAcked(gatewayID lorawan.EUI64, token uint16) {
+	downlinkAcked(gatewayID, token, time.Now())
+}
+
+// LeadTime returns the predicted lead time needed for a downlink to reach
+// the given gateway in time. It returns 0 when the prediction is disabled or
+// when no (recent) round-trip time of the gateway is known.
+func LeadTime(gatewayID lorawan.EUI64) time.Duration {
+	return leadTime(gatewayID, time.Now())
+}
+
+func observe(gatewayID lorawan.EUI64, rtt time.Duration, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	e, ok := estimates[gatewayID]
+	if !ok || (maxAge > 0 && now.Sub(e.updated) > maxAge) {
+		estimates[gatewayID] = &estimate{rtt: rtt, updated: now}
+		return
+	}
+
+	e.rtt += time.Duration(smoothingFactor * float64(rtt-e.rtt))
+	e.updated = now
+}
+
+func downlinkSent(gatewayID lorawan.EUI64, token uint16, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	if now.Sub(sentCleaned) > sentRetention {
+		for k, t := range sent {
+			if now.Sub(t) > sentRetention {
+				delete(sent, k)
+			}
+		}
+		sentCleaned = now
+	}
+
+	sent[sentKey(gatewayID, token)] = now
+}
+
+func downlinkAcked(gatewayID lorawan.EUI64, token uint16, now time.Time) {
+	mux.Lock()
+	key := sentKey(gatewayID, token)
+	t, ok := sent[key]
+	delete(sent, key)
+	mux.Unlock()
+
+	if !ok {
+		return
+	}
+
+	observe(gatewayID, now.Sub(t), now)
+}
+
+func leadTime(gatewayID lorawan.EUI64, now time.Time) time.Duration {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return 0
+	}
+
+	e, ok := estimates[gatewayID]
+	if !ok || (maxAge > 0 && now.Sub(e.updated) > maxAge) {
+		return 0
+	}
+
+	return e.rtt/2 + margin
+}
+
+func sentKey(gatewayID lorawan.EUI64, token uint16) string {
+	return fmt.Sprintf("%s/%d", hex.EncodeToString(gatewayID[:]), token)
+}
@@ -0,0 +1,104 @@
+package latency
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+func TestLeadTime(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Now()
+
+	tests := []struct {
+		Name             string
+		Enabled          bool
+		Observe          func()
+		Now              time.Time
+		ExpectedLeadTime time.Duration
+	}{
+		{
+			Name:    "disabled",
+			Enabled: false,
+			Observe: func() {
+				observe(gatewayID, 100*time.Millisecond, now)
+			},
+			Now: now,
+		},
+		{
+			Name:    "unknown gateway",
+			Enabled: true,
+			Observe: func() {},
+			Now:     now,
+		},
+		{
+			Name:    "single sample",
+			Enabled: true,
+			Observe: func() {
+				observe(gatewayID, 100*time.Millisecond, now)
+			},
+			Now:              now,
+			ExpectedLeadTime: 70 * time.Millisecond,
+		},
+		{
+			Name:    "smoothed",
+			Enabled: true,
+			Observe: func() {
+				observe(gatewayID, 100*time.Millisecond, now)
+				observe(gatewayID, 500*time.Millisecond, now)
+			},
+			Now:              now,
+			ExpectedLeadTime: 120 * time.Millisecond,
+		},
+		{
+			Name:    "expired estimate",
+			Enabled: true,
+			Observe: func() {
+				observe(gatewayID, 100*time.Millisecond, now)
+			},
+			Now: now.Add(time.Hour),
+		},
+		{
+			Name:    "expired estimate is reset",
+			Enabled: true,
+			Observe: func() {
+				observe(gatewayID, 100*time.Millisecond, now.Add(-time.Hour))
+				observe(gatewayID, 500*time.Millisecond, now)
+			},
+			Now:              now,
+			ExpectedLeadTime: 270 * time.Millisecond,
+		},
+		{
+			Name:    "downlink ack",
+			Enabled: true,
+			Observe: func() {
+				downlinkSent(gatewayID, 123, now)
+				downlinkAcked(gatewayID, 123, now.Add(200*time.Millisecond))
+
+				// unknown token
+				downlinkAcked(gatewayID, 124, now.Add(time.Second))
+			},
+			Now:              now,
+			ExpectedLeadTime: 120 * time.Millisecond,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.BackhaulLatency.Enabled = tst.Enabled
+			conf.BackhaulLatency.Margin = 20 * time.Millisecond
+			conf.BackhaulLatency.MaxAge = 5 * time.Minute
+			assert.NoError(Setup(conf))
+
+			tst.Observe()
+			assert.Equal(tst.ExpectedLeadTime, leadTime(gatewayID, tst.Now))
+		})
+	}
+}
@@ -0,0 +1,97 @@
+// Package locations implements the injection of statically configured
+// gateway locations, for gateways that do not report a location themselves
+// (e.g. indoor gateways without GPS).
+package locations
+
+import (
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	mux       sync.RWMutex
+	locations map[lorawan.EUI64]common.Location
+)
+
+// Setup configures the static gateway locations.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	locations = make(map[lorawan.EUI64]common.Location)
+
+	for _, loc := range conf.Locations {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(loc.GatewayID)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+
+		locations[gatewayID] = common.Location{
+			Latitude:  loc.Latitude,
+			Longitude: loc.Longitude,
+			Altitude:  loc.Altitude,
+			Source:    common.LocationSource_CONFIG,
+		}
+
+		log.WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"latitude":   loc.Latitude,
+			"longitude":  loc.Longitude,
+			"altitude":   loc.Altitude,
+		}).Info("locations: gateway location configured")
+	}
+
+	return nil
+}
+
+// SetGatewayStatsLocation sets the configured location of the gateway in
+// case the stats do not contain a location.
+func SetGatewayStatsLocation(stats *gw.GatewayStats) {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], stats.GetGatewayId())
+
+	if loc := getLocation(gatewayID, stats.GetLocation()); loc != nil {
+		stats.Location = loc
+	}
+}
+
+// SetUplinkFrameLocation sets the configured location of the gateway in
+// case the uplink rx-info does not contain a location.
+func SetUplinkFrameLocation(frame *gw.UplinkFrame) {
+	if frame.RxInfo == nil {
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.RxInfo.GetGatewayId())
+
+	if loc := getLocation(gatewayID, frame.RxInfo.GetLocation()); loc != nil {
+		frame.RxInfo.Location = loc
+	}
+}
+
+// getLocation returns the configured location for the given gateway ID when
+// the reported location is not set. It returns nil when the reported location
+// must be kept.
+func getLocation(gatewayID lorawan.EUI64, reported *common.Location) *common.Location {
+	if reported != nil && (reported.Latitude != 0 || reported.Longitude != 0) {
+		return nil
+	}
+
+	mux.RLock()
+	defer mux.RUnlock()
+
+	loc, ok := locations[gatewayID]
+	if !ok {
+		return nil
+	}
+
+	return &loc
+}
@@ -0,0 +1,93 @@
+package locations
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestLocations(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Locations = append(conf.Locations, struct {
+		GatewayID string  `mapstructure:"gateway_id"`
+		Latitude  float64 `mapstructure:"latitude"`
+		Longitude float64 `mapstructure:"longitude"`
+		Altitude  float64 `mapstructure:"altitude"`
+	}{
+		GatewayID: "0102030405060708",
+		Latitude:  1.123,
+		Longitude: 2.123,
+		Altitude:  3.123,
+	})
+	assert.NoError(Setup(conf))
+
+	configured := common.Location{
+		Latitude:  1.123,
+		Longitude: 2.123,
+		Altitude:  3.123,
+		Source:    common.LocationSource_CONFIG,
+	}
+	gps := common.Location{
+		Latitude:  4.123,
+		Longitude: 5.123,
+		Altitude:  6.123,
+		Source:    common.LocationSource_GPS,
+	}
+
+	tests := []struct {
+		Name      string
+		GatewayID []byte
+		Location  *common.Location
+		Expected  *common.Location
+	}{
+		{
+			Name:      "no location reported",
+			GatewayID: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Expected:  &configured,
+		},
+		{
+			Name:      "zero location reported",
+			GatewayID: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Location:  &common.Location{},
+			Expected:  &configured,
+		},
+		{
+			Name:      "gps location reported",
+			GatewayID: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Location:  &gps,
+			Expected:  &gps,
+		},
+		{
+			Name:      "no location configured",
+			GatewayID: []byte{8, 7, 6, 5, 4, 3, 2, 1},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			stats := gw.GatewayStats{
+				GatewayId: tst.GatewayID,
+				Location:  tst.Location,
+			}
+			SetGatewayStatsLocation(&stats)
+			assert.Equal(tst.Expected, stats.Location)
+
+			frame := gw.UplinkFrame{
+				RxInfo: &gw.UplinkRXInfo{
+					GatewayId: tst.GatewayID,
+					Location:  tst.Location,
+				},
+			}
+			SetUplinkFrameLocation(&frame)
+			assert.Equal(tst.Expected, frame.RxInfo.Location)
+		})
+	}
+}
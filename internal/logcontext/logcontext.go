@@ -0,0 +1,134 @@
+// Package logcontext implements the injection of the gateway context into
+// the log entries. The gateway ID, backend type, remote address and message
+// type are stored in the context.Context of the handling of a gateway
+// message, a logrus hook adds them as fields to all entries logged with this
+// context (log.WithContext), so that the logs can reliably be filtered per
+// gateway.
+package logcontext
+
+import (
+	"context"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+// Log fields.
+const (
+	FieldGatewayID   = "gateway_id"
+	FieldBackend     = "backend"
+	FieldRemoteAddr  = "remote_addr"
+	FieldMessageType = "message_type"
+)
+
+type contextKey struct{}
+
+// fields contains the log fields stored in the context. Empty fields are
+// not added to the log entry.
+type fields struct {
+	gatewayID    lorawan.EUI64
+	gatewayIDSet bool
+	backend      string
+	remoteAddr   string
+	messageType  string
+}
+
+// Setup registers the logrus hook. It must be called before the other
+// hooks are registered, so that these receive the injected fields.
+func Setup(conf config.Config) error {
+	log.AddHook(hook{})
+	return nil
+}
+
+// WithGatewayID returns a copy of the context containing the gateway ID.
+func WithGatewayID(ctx context.Context, gatewayID lorawan.EUI64) context.Context {
+	f := fromContext(ctx)
+	f.gatewayID = gatewayID
+	f.gatewayIDSet = true
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// WithBackend returns a copy of the context containing the backend type.
+func WithBackend(ctx context.Context, backend string) context.Context {
+	f := fromContext(ctx)
+	f.backend = backend
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// WithRemoteAddr returns a copy of the context containing the remote
+// address of the gateway.
+func WithRemoteAddr(ctx context.Context, addr string) context.Context {
+	f := fromContext(ctx)
+	f.remoteAddr = addr
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+// WithMessageType returns a copy of the context containing the type of the
+// handled message.
+func WithMessageType(ctx context.Context, messageType string) context.Context {
+	f := fromContext(ctx)
+	f.messageType = messageType
+	return context.WithValue(ctx, contextKey{}, f)
+}
+
+func fromContext(ctx context.Context) fields {
+	if ctx == nil {
+		return fields{}
+	}
+
+	f, _ := ctx.Value(contextKey{}).(fields)
+	return f
+}
+
+// hook implements a logrus hook.
+type hook struct{}
+
+// Levels returns the levels for which the hook is fired.
+func (hook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+// Fire adds the fields stored in the context of the entry. Fields which
+// are set explicitly take precedence.
+func (hook) Fire(entry *log.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+
+	f, ok := entry.Context.Value(contextKey{}).(fields)
+	if !ok {
+		return nil
+	}
+
+	// the data is shared with the entry the log call was made on, it must
+	// not be modified
+	data := make(log.Fields, len(entry.Data)+4)
+	for k, v := range entry.Data {
+		data[k] = v
+	}
+
+	add := func(key string, value interface{}) {
+		if _, ok := data[key]; !ok {
+			data[key] = value
+		}
+	}
+
+	if f.gatewayIDSet {
+		add(FieldGatewayID, f.gatewayID)
+	}
+	if f.backend != "" {
+		add(FieldBackend, f.backend)
+	}
+	if f.remoteAddr != "" {
+		add(FieldRemoteAddr, f.remoteAddr)
+	}
+	if f.messageType != "" {
+		add(FieldMessageType, f.messageType)
+	}
+
+	entry.Data = data
+
+	return nil
+}
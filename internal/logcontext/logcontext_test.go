@@ -0,0 +1,93 @@
+package logcontext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestHook(t *testing.T) {
+	var buf bytes.Buffer
+	logger := log.New()
+	logger.Out = &buf
+	logger.Formatter = &log.JSONFormatter{}
+	logger.AddHook(hook{})
+
+	ctx := WithBackend(context.Background(), "semtech_udp")
+	ctx = WithRemoteAddr(ctx, "127.0.0.1:1700")
+	ctx = WithGatewayID(ctx, lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8})
+
+	tests := []struct {
+		Name           string
+		Log            func()
+		ExpectedFields map[string]interface{}
+	}{
+		{
+			Name: "no context",
+			Log: func() {
+				logger.WithField("foo", "bar").Info("test")
+			},
+			ExpectedFields: map[string]interface{}{
+				"foo": "bar",
+			},
+		},
+		{
+			Name: "context",
+			Log: func() {
+				logger.WithContext(ctx).WithField("foo", "bar").Info("test")
+			},
+			ExpectedFields: map[string]interface{}{
+				"foo":         "bar",
+				"gateway_id":  "0102030405060708",
+				"backend":     "semtech_udp",
+				"remote_addr": "127.0.0.1:1700",
+			},
+		},
+		{
+			Name: "message type",
+			Log: func() {
+				logger.WithContext(WithMessageType(ctx, "PushData")).Info("test")
+			},
+			ExpectedFields: map[string]interface{}{
+				"gateway_id":   "0102030405060708",
+				"backend":      "semtech_udp",
+				"remote_addr":  "127.0.0.1:1700",
+				"message_type": "PushData",
+			},
+		},
+		{
+			Name: "explicit field takes precedence",
+			Log: func() {
+				logger.WithContext(ctx).WithField("backend", "basic_station").Info("test")
+			},
+			ExpectedFields: map[string]interface{}{
+				"gateway_id":  "0102030405060708",
+				"backend":     "basic_station",
+				"remote_addr": "127.0.0.1:1700",
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			buf.Reset()
+			tst.Log()
+
+			var fields map[string]interface{}
+			assert.NoError(json.Unmarshal(buf.Bytes(), &fields))
+			delete(fields, "level")
+			delete(fields, "msg")
+			delete(fields, "time")
+
+			assert.Equal(tst.ExpectedFields, fields)
+		})
+	}
+}
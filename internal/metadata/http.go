@@ -0,0 +1,94 @@
+package metadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type httpResult struct {
+	values    map[string]string
+	fetchedAt time.Time
+}
+
+var (
+	httpURLs          []string
+	httpInterval      time.Duration
+	httpClient        *http.Client
+	httpCacheDuration time.Duration
+
+	// httpCache contains the last successful result per URL.
+	httpCache map[string]httpResult
+)
+
+func fetchURLs() {
+	for _, u := range httpURLs {
+		values, err := fetchURL(u)
+		if err != nil {
+			log.WithError(err).WithField("url", u).Error("metadata: fetch url error")
+			continue
+		}
+
+		mux.Lock()
+		httpCache[u] = httpResult{
+			values:    values,
+			fetchedAt: time.Now(),
+		}
+		mux.Unlock()
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+	refresh(time.Now())
+}
+
+// fetchURL requests the given URL, which must return a JSON object. String
+// values are used as-is, other values are used as their JSON representation.
+func fetchURL(u string) (map[string]string, error) {
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request error")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http get error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("expected 200 response, got: %d", resp.StatusCode)
+	}
+
+	b, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "read response error")
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(b, &raw); err != nil {
+		// the message of the type error depends on the Go version
+		if _, ok := err.(*json.UnmarshalTypeError); ok {
+			return nil, errors.New("unmarshal json error: expected json object")
+		}
+		return nil, errors.Wrap(err, "unmarshal json error")
+	}
+
+	out := make(map[string]string)
+	for k, v := range raw {
+		var str string
+		if err := json.Unmarshal(v, &str); err == nil {
+			out[k] = str
+		} else {
+			out[k] = string(v)
+		}
+	}
+
+	return out, nil
+}
@@ -0,0 +1,105 @@
+package metadata
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFetchURL(t *testing.T) {
+	tests := []struct {
+		Name       string
+		StatusCode int
+		Body       string
+		Expected   map[string]string
+		Error      string
+	}{
+		{
+			Name:       "json object",
+			StatusCode: http.StatusOK,
+			Body:       `{"firmware": "1.2.3", "uptime": 3600, "lte": {"rssi": -71}}`,
+			Expected: map[string]string{
+				"firmware": "1.2.3",
+				"uptime":   "3600",
+				"lte":      `{"rssi": -71}`,
+			},
+		},
+		{
+			Name:       "json array",
+			StatusCode: http.StatusOK,
+			Body:       `["foo"]`,
+			Error:      "unmarshal json error: expected json object",
+		},
+		{
+			Name:       "server error",
+			StatusCode: http.StatusInternalServerError,
+			Error:      "expected 200 response, got: 500",
+		},
+	}
+
+	httpClient = &http.Client{
+		Timeout: time.Second,
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tst.StatusCode)
+				w.Write([]byte(tst.Body))
+			}))
+			defer server.Close()
+
+			out, err := fetchURL(server.URL)
+			if tst.Error != "" {
+				assert.EqualError(err, tst.Error)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Expected, out)
+		})
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	assert := require.New(t)
+	now := time.Now()
+
+	defer func() {
+		cmndsKV = nil
+		httpURLs = nil
+		httpCache = nil
+	}()
+
+	static = map[string]string{
+		"foo": "static",
+		"bar": "static",
+		"baz": "static",
+	}
+	cmndsKV = map[string]string{
+		"foo": "command",
+	}
+	httpURLs = []string{"http://localhost/a", "http://localhost/b"}
+	httpCacheDuration = time.Minute
+	httpCache = map[string]httpResult{
+		"http://localhost/a": {
+			values:    map[string]string{"foo": "http", "bar": "http"},
+			fetchedAt: now,
+		},
+		"http://localhost/b": {
+			values:    map[string]string{"baz": "http"},
+			fetchedAt: now.Add(-2 * time.Minute),
+		},
+	}
+
+	refresh(now)
+	assert.Equal(map[string]string{
+		"foo": "command",
+		"bar": "http",
+		"baz": "static",
+	}, Get())
+}
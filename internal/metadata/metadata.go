@@ -2,6 +2,7 @@ package metadata
 
 import (
 	"context"
+	"net/http"
 	"os/exec"
 	"strings"
 	"sync"
@@ -11,16 +12,18 @@ import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 
-	"github.com/brocaar/lora-gateway-bridge/internal/commands"
+	"github.com/brocaar/lora-gateway-bridge/internal/commands/cmdline"
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
 )
 
 var (
 	mux sync.RWMutex
 
-	static map[string]string
-	cmnds  map[string]string
-	cached map[string]string
+	static  map[string]string
+	cmnds   map[string]string
+	cmndsKV map[string]string
+	cached  map[string]string
 
 	interval     time.Duration
 	maxExecution time.Duration
@@ -37,6 +40,15 @@ func Setup(conf config.Config) error {
 	interval = conf.MetaData.Dynamic.ExecutionInterval
 	maxExecution = conf.MetaData.Dynamic.MaxExecutionDuration
 
+	httpURLs = conf.MetaData.HTTP.URLs
+	httpInterval = conf.MetaData.HTTP.Interval
+	httpClient = &http.Client{
+		Timeout:   conf.MetaData.HTTP.Timeout,
+		Transport: proxy.Transport(),
+	}
+	httpCacheDuration = conf.MetaData.HTTP.CacheDuration
+	httpCache = make(map[string]httpResult)
+
 	go func() {
 		for {
 			runCommands()
@@ -44,6 +56,15 @@ func Setup(conf config.Config) error {
 		}
 	}()
 
+	if len(httpURLs) != 0 {
+		go func() {
+			for {
+				fetchURLs()
+				time.Sleep(httpInterval)
+			}
+		}()
+	}
+
 	return nil
 }
 
@@ -57,10 +78,6 @@ func Get() map[string]string {
 
 func runCommands() {
 	newKV := make(map[string]string)
-	for k, v := range static {
-		newKV[k] = v
-	}
-
 	for k, cmd := range cmnds {
 		out, err := runCommand(cmd)
 		if err != nil {
@@ -76,11 +93,38 @@ func runCommands() {
 
 	mux.Lock()
 	defer mux.Unlock()
+	cmndsKV = newKV
+	refresh(time.Now())
+}
+
+// refresh updates the cached metadata. The static values are overwritten
+// by the HTTP values, which are overwritten by the command values.
+// A lock must be held by the caller.
+func refresh(now time.Time) {
+	newKV := make(map[string]string)
+	for k, v := range static {
+		newKV[k] = v
+	}
+
+	for _, u := range httpURLs {
+		res, ok := httpCache[u]
+		if !ok || now.Sub(res.fetchedAt) > httpCacheDuration {
+			continue
+		}
+		for k, v := range res.values {
+			newKV[k] = v
+		}
+	}
+
+	for k, v := range cmndsKV {
+		newKV[k] = v
+	}
+
 	cached = newKV
 }
 
 func runCommand(cmdStr string) (string, error) {
-	cmdArgs, err := commands.ParseCommandLine(cmdStr)
+	cmdArgs, err := cmdline.Parse(cmdStr)
 	if err != nil {
 		return "", errors.Wrap(err, "parse command error")
 	}
@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/airtime"
+)
+
+var (
+	ups = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "uplink_payload_size_bytes",
+		Help:    "The PHYPayload size of the received uplinks (per gateway).",
+		Buckets: []float64{8, 16, 32, 64, 128, 256},
+	}, []string{"gateway_id"})
+
+	usf = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "uplink_spreading_factor",
+		Help:    "The spreading factor of the received LoRa uplinks (per gateway).",
+		Buckets: []float64{7, 8, 9, 10, 11, 12},
+	}, []string{"gateway_id"})
+
+	uat = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "uplink_airtime_seconds",
+		Help:    "The airtime of the received LoRa uplinks (per gateway and frequency).",
+		Buckets: []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"gateway_id", "frequency"})
+
+	dps = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "downlink_payload_size_bytes",
+		Help:    "The PHYPayload size of the sent downlinks (per gateway).",
+		Buckets: []float64{8, 16, 32, 64, 128, 256},
+	}, []string{"gateway_id"})
+
+	dat = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "downlink_airtime_seconds",
+		Help:    "The airtime of the sent LoRa downlinks (per gateway and frequency).",
+		Buckets: []float64{0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	}, []string{"gateway_id", "frequency"})
+)
+
+// Uplink registers the payload size, LoRaWAN header, spreading factor and
+// airtime metrics of the given uplink.
+func Uplink(frame gw.UplinkFrame) {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.GetRxInfo().GetGatewayId())
+
+	ups.With(prometheus.Labels{"gateway_id": gatewayID.String()}).Observe(float64(len(frame.PhyPayload)))
+	uplinkHeaderMetrics(gatewayID, frame.PhyPayload)
+
+	modInfo := frame.GetTxInfo().GetLoraModulationInfo()
+	if frame.GetTxInfo().GetModulation() != common.Modulation_LORA || modInfo == nil {
+		return
+	}
+
+	usf.With(prometheus.Labels{"gateway_id": gatewayID.String()}).Observe(float64(modInfo.SpreadingFactor))
+
+	d, err := loraAirtime(len(frame.PhyPayload), modInfo.SpreadingFactor, modInfo.Bandwidth, modInfo.CodeRate)
+	if err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("metrics: calculate uplink airtime error")
+		return
+	}
+
+	uat.With(prometheus.Labels{
+		"gateway_id": gatewayID.String(),
+		"frequency":  strconv.FormatUint(uint64(frame.GetTxInfo().GetFrequency()), 10),
+	}).Observe(d)
+}
+
+// Downlink registers the payload size and airtime metrics of the given
+// downlink.
+func Downlink(frame gw.DownlinkFrame) {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.GetTxInfo().GetGatewayId())
+
+	dps.With(prometheus.Labels{"gateway_id": gatewayID.String()}).Observe(float64(len(frame.PhyPayload)))
+
+	modInfo := frame.GetTxInfo().GetLoraModulationInfo()
+	if frame.GetTxInfo().GetModulation() != common.Modulation_LORA || modInfo == nil {
+		return
+	}
+
+	d, err := loraAirtime(len(frame.PhyPayload), modInfo.SpreadingFactor, modInfo.Bandwidth, modInfo.CodeRate)
+	if err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("metrics: calculate downlink airtime error")
+		return
+	}
+
+	dat.With(prometheus.Labels{
+		"gateway_id": gatewayID.String(),
+		"frequency":  strconv.FormatUint(uint64(frame.GetTxInfo().GetFrequency()), 10),
+	}).Observe(d)
+}
+
+// loraAirtime returns the airtime in seconds. The bandwidth is in kHz.
+func loraAirtime(payloadSize int, sf, bandwidth uint32, codeRate string) (float64, error) {
+	var cr airtime.CodingRate
+	switch codeRate {
+	case "4/5":
+		cr = airtime.CodingRate45
+	case "4/6":
+		cr = airtime.CodingRate46
+	case "4/7":
+		cr = airtime.CodingRate47
+	case "4/8":
+		cr = airtime.CodingRate48
+	default:
+		return 0, errors.Errorf("invalid code-rate: %s", codeRate)
+	}
+
+	// low data-rate optimization is mandated for SF11 and SF12 at 125 kHz
+	ldro := bandwidth == 125 && sf >= 11
+
+	d, err := airtime.CalculateLoRaAirtime(payloadSize, int(sf), int(bandwidth), 8, cr, true, ldro)
+	if err != nil {
+		return 0, errors.Wrap(err, "calculate lora airtime error")
+	}
+
+	return d.Seconds(), nil
+}
@@ -0,0 +1,59 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoRaAirtime(t *testing.T) {
+	tests := []struct {
+		Name            string
+		PayloadSize     int
+		SpreadingFactor uint32
+		Bandwidth       uint32
+		CodeRate        string
+		ExpectedAirtime float64
+		ExpectedError   string
+	}{
+		{
+			Name:            "SF7",
+			PayloadSize:     13,
+			SpreadingFactor: 7,
+			Bandwidth:       125,
+			CodeRate:        "4/5",
+			ExpectedAirtime: 0.046336,
+		},
+		{
+			Name:            "SF12 (low data-rate optimization)",
+			PayloadSize:     13,
+			SpreadingFactor: 12,
+			Bandwidth:       125,
+			CodeRate:        "4/5",
+			ExpectedAirtime: 1.155072,
+		},
+		{
+			Name:            "invalid code-rate",
+			PayloadSize:     13,
+			SpreadingFactor: 7,
+			Bandwidth:       125,
+			CodeRate:        "4/9",
+			ExpectedError:   "invalid code-rate: 4/9",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			d, err := loraAirtime(tst.PayloadSize, tst.SpreadingFactor, tst.Bandwidth, tst.CodeRate)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+
+			assert.NoError(err)
+			assert.InDelta(tst.ExpectedAirtime, d, 0.000001)
+		})
+	}
+}
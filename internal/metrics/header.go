@@ -0,0 +1,126 @@
+package metrics
+
+import (
+	"encoding/hex"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	umt = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "uplink_message_type_count",
+		Help: "The number of received uplinks (per gateway and LoRaWAN message type).",
+	}, []string{"gateway_id", "mtype"})
+
+	ufp = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "uplink_fport_count",
+		Help: "The number of received data uplinks (per FPort).",
+	}, []string{"fport"})
+
+	unc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "uplink_nwk_id_count",
+		Help: "The number of received data uplinks (per NetID type and NwkID of the DevAddr).",
+	}, []string{"net_id_type", "nwk_id"})
+
+	unb = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "uplink_nwk_id_payload_bytes",
+		Help: "The PHYPayload bytes of the received data uplinks (per NetID type and NwkID of the DevAddr).",
+	}, []string{"net_id_type", "nwk_id"})
+)
+
+// mTypeLabels contains the metric label values of the message types.
+var mTypeLabels = map[lorawan.MType]string{
+	lorawan.JoinRequest:         "join_request",
+	lorawan.JoinAccept:          "join_accept",
+	lorawan.UnconfirmedDataUp:   "unconfirmed_data_up",
+	lorawan.UnconfirmedDataDown: "unconfirmed_data_down",
+	lorawan.ConfirmedDataUp:     "confirmed_data_up",
+	lorawan.ConfirmedDataDown:   "confirmed_data_down",
+	lorawan.RejoinRequest:       "rejoin_request",
+	lorawan.Proprietary:         "proprietary",
+}
+
+// uplinkHeader contains the LoRaWAN header fields of an uplink.
+type uplinkHeader struct {
+	mType lorawan.MType
+
+	// devAddr is set for data uplinks.
+	devAddr *lorawan.DevAddr
+
+	// fPort is set for data uplinks containing the FPort field.
+	fPort *uint8
+}
+
+// decodeUplinkHeader decodes the MHDR, and for data uplinks the DevAddr and
+// FPort of the given PHYPayload. As only the plain-text header is decoded,
+// the session keys are not needed.
+func decodeUplinkHeader(b []byte) (uplinkHeader, error) {
+	var h uplinkHeader
+
+	// MHDR (1) and MIC (4)
+	if len(b) < 5 {
+		return h, errors.New("phypayload is too short")
+	}
+
+	if lorawan.Major(b[0]&0x03) != lorawan.LoRaWANR1 {
+		return h, errors.New("unsupported major version")
+	}
+
+	h.mType = lorawan.MType(b[0] >> 5)
+	if h.mType != lorawan.UnconfirmedDataUp && h.mType != lorawan.ConfirmedDataUp {
+		return h, nil
+	}
+
+	// MHDR (1), FHDR without FOpts (7) and MIC (4)
+	if len(b) < 12 {
+		return h, errors.New("data phypayload is too short")
+	}
+
+	var devAddr lorawan.DevAddr
+	if err := devAddr.UnmarshalBinary(b[1:5]); err != nil {
+		return h, errors.Wrap(err, "unmarshal devaddr error")
+	}
+	h.devAddr = &devAddr
+
+	fPortIndex := 8 + int(b[5]&0x0f)
+	if fPortIndex > len(b)-4 {
+		return h, errors.New("fopts exceed phypayload")
+	}
+
+	if fPortIndex < len(b)-4 {
+		fPort := b[fPortIndex]
+		h.fPort = &fPort
+	}
+
+	return h, nil
+}
+
+// uplinkHeaderMetrics registers the message type, FPort and NwkID metrics
+// of the given uplink PHYPayload.
+func uplinkHeaderMetrics(gatewayID lorawan.EUI64, b []byte) {
+	h, err := decodeUplinkHeader(b)
+	if err != nil {
+		umt.With(prometheus.Labels{"gateway_id": gatewayID.String(), "mtype": "invalid"}).Inc()
+		return
+	}
+
+	umt.With(prometheus.Labels{"gateway_id": gatewayID.String(), "mtype": mTypeLabels[h.mType]}).Inc()
+
+	if h.fPort != nil {
+		ufp.With(prometheus.Labels{"fport": strconv.FormatUint(uint64(*h.fPort), 10)}).Inc()
+	}
+
+	if h.devAddr != nil {
+		labels := prometheus.Labels{
+			"net_id_type": strconv.Itoa(h.devAddr.NetIDType()),
+			"nwk_id":      hex.EncodeToString(h.devAddr.NwkID()),
+		}
+		unc.With(labels).Inc()
+		unb.With(labels).Add(float64(len(b)))
+	}
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestDecodeUplinkHeader(t *testing.T) {
+	devAddr := lorawan.DevAddr{0x26, 0x01, 0x02, 0x03}
+	fPort := uint8(10)
+
+	dataUp := func(mType lorawan.MType, fOpts []lorawan.Payload, fPort *uint8) []byte {
+		phy := lorawan.PHYPayload{
+			MHDR: lorawan.MHDR{
+				MType: mType,
+				Major: lorawan.LoRaWANR1,
+			},
+			MACPayload: &lorawan.MACPayload{
+				FHDR: lorawan.FHDR{
+					DevAddr: devAddr,
+					FOpts:   fOpts,
+				},
+				FPort: fPort,
+			},
+		}
+		if fPort != nil {
+			phy.MACPayload.(*lorawan.MACPayload).FRMPayload = []lorawan.Payload{&lorawan.DataPayload{Bytes: []byte{1, 2, 3}}}
+		}
+
+		b, err := phy.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return b
+	}
+
+	tests := []struct {
+		Name          string
+		PHYPayload    []byte
+		Expected      uplinkHeader
+		ExpectedError string
+	}{
+		{
+			Name: "join-request",
+			PHYPayload: func() []byte {
+				phy := lorawan.PHYPayload{
+					MHDR: lorawan.MHDR{
+						MType: lorawan.JoinRequest,
+						Major: lorawan.LoRaWANR1,
+					},
+					MACPayload: &lorawan.JoinRequestPayload{},
+				}
+				b, _ := phy.MarshalBinary()
+				return b
+			}(),
+			Expected: uplinkHeader{mType: lorawan.JoinRequest},
+		},
+		{
+			Name:       "unconfirmed data-up",
+			PHYPayload: dataUp(lorawan.UnconfirmedDataUp, nil, &fPort),
+			Expected:   uplinkHeader{mType: lorawan.UnconfirmedDataUp, devAddr: &devAddr, fPort: &fPort},
+		},
+		{
+			Name:       "confirmed data-up with fopts",
+			PHYPayload: dataUp(lorawan.ConfirmedDataUp, []lorawan.Payload{&lorawan.MACCommand{CID: lorawan.LinkCheckReq}}, &fPort),
+			Expected:   uplinkHeader{mType: lorawan.ConfirmedDataUp, devAddr: &devAddr, fPort: &fPort},
+		},
+		{
+			Name:       "data-up without fport",
+			PHYPayload: dataUp(lorawan.UnconfirmedDataUp, nil, nil),
+			Expected:   uplinkHeader{mType: lorawan.UnconfirmedDataUp, devAddr: &devAddr},
+		},
+		{
+			Name:       "proprietary",
+			PHYPayload: []byte{0xe0, 1, 2, 3, 4, 5},
+			Expected:   uplinkHeader{mType: lorawan.Proprietary},
+		},
+		{
+			Name:          "too short",
+			PHYPayload:    []byte{0x40, 1, 2},
+			ExpectedError: "phypayload is too short",
+		},
+		{
+			Name:          "unsupported major version",
+			PHYPayload:    []byte{0x41, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+			ExpectedError: "unsupported major version",
+		},
+		{
+			Name:          "fopts exceed phypayload",
+			PHYPayload:    []byte{0x40, 1, 2, 3, 4, 0x0f, 6, 7, 8, 9, 10, 11},
+			ExpectedError: "fopts exceed phypayload",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			h, err := decodeUplinkHeader(tst.PHYPayload)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.Expected, h)
+		})
+	}
+}
@@ -1,15 +1,19 @@
 package metrics
 
 import (
-	"net/http"
-
+	"github.com/pkg/errors"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	log "github.com/sirupsen/logrus"
 
 	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/httpserver"
 )
 
 func Setup(conf config.Config) error {
+	if err := setupPush(conf); err != nil {
+		return errors.Wrap(err, "setup metrics push error")
+	}
+
 	if !conf.Metrics.Prometheus.EndpointEnabled {
 		return nil
 	}
@@ -18,13 +22,16 @@ func Setup(conf config.Config) error {
 		"bind": conf.Metrics.Prometheus.Bind,
 	}).Info("metrics: starting prometheus metrics server")
 
-	server := http.Server{
-		Handler: promhttp.Handler(),
-		Addr:    conf.Metrics.Prometheus.Bind,
+	opts := httpserver.Options{
+		Bind:        conf.Metrics.Prometheus.Bind,
+		TLSCert:     conf.Metrics.Prometheus.TLSCert,
+		TLSKey:      conf.Metrics.Prometheus.TLSKey,
+		BearerToken: conf.Metrics.Prometheus.BearerToken,
+		JWTSecret:   conf.Metrics.Prometheus.JWTSecret,
 	}
 
 	go func() {
-		err := server.ListenAndServe()
+		err := httpserver.ListenAndServe(opts, promhttp.Handler())
 		log.WithError(err).Error("metrics: prometheus metrics server error")
 	}()
 
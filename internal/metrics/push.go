@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/push"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
+)
+
+// pushTimeout defines the timeout of a single push.
+const pushTimeout = 10 * time.Second
+
+// pusher pushes the gathered metrics to a Prometheus Pushgateway or to a
+// remote-write endpoint, for bridges which can't be scraped (e.g. gateways
+// without inbound connectivity).
+type pusher struct {
+	typ      string
+	url      string
+	job      string
+	instance string
+
+	client   *http.Client
+	username string
+	password string
+	token    string
+
+	gatherer prometheus.Gatherer
+}
+
+func setupPush(conf config.Config) error {
+	c := conf.Metrics.Prometheus.Push
+	if c.Type == "" {
+		return nil
+	}
+
+	p, err := newPusher(conf, prometheus.DefaultGatherer)
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"type":     p.typ,
+		"url":      p.url,
+		"interval": c.Interval,
+	}).Info("metrics: starting prometheus metrics push")
+
+	go func() {
+		for {
+			time.Sleep(c.Interval)
+			if err := p.push(time.Now()); err != nil {
+				log.WithError(err).WithField("type", p.typ).Error("metrics: push prometheus metrics error")
+			}
+		}
+	}()
+
+	return nil
+}
+
+func newPusher(conf config.Config, gatherer prometheus.Gatherer) (*pusher, error) {
+	c := conf.Metrics.Prometheus.Push
+
+	switch c.Type {
+	case "pushgateway", "remote_write":
+	default:
+		return nil, fmt.Errorf("unknown push type: %s", c.Type)
+	}
+
+	if c.URL == "" {
+		return nil, errors.New("push url must be set")
+	}
+
+	p := pusher{
+		typ:      c.Type,
+		url:      c.URL,
+		job:      c.Job,
+		instance: c.Instance,
+		client: &http.Client{
+			Timeout:   pushTimeout,
+			Transport: proxy.Transport(),
+		},
+		username: c.Username,
+		password: c.Password,
+		token:    c.BearerToken,
+		gatherer: gatherer,
+	}
+
+	if p.instance == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, errors.Wrap(err, "get hostname error")
+		}
+		p.instance = hostname
+	}
+
+	return &p, nil
+}
+
+// Do implements push.HTTPDoer, adding the bearer token to the request.
+func (p *pusher) Do(req *http.Request) (*http.Response, error) {
+	if p.token != "" {
+		req.Header.Set("Authorization", "Bearer "+p.token)
+	}
+	return p.client.Do(req)
+}
+
+func (p *pusher) push(now time.Time) error {
+	switch p.typ {
+	case "pushgateway":
+		return p.pushGateway()
+	default:
+		return p.remoteWrite(now)
+	}
+}
+
+// pushGateway replaces the metrics of the job and instance grouping on the
+// Pushgateway.
+func (p *pusher) pushGateway() error {
+	pg := push.New(p.url, p.job).
+		Gatherer(p.gatherer).
+		Grouping("instance", p.instance).
+		Client(p)
+
+	if p.username != "" || p.password != "" {
+		pg = pg.BasicAuth(p.username, p.password)
+	}
+
+	if err := pg.Push(); err != nil {
+		return errors.Wrap(err, "push to pushgateway error")
+	}
+
+	return nil
+}
+
+// remoteWrite sends the metrics to the remote-write endpoint, as snappy
+// compressed WriteRequest.
+func (p *pusher) remoteWrite(now time.Time) error {
+	mfs, err := p.gatherer.Gather()
+	if err != nil {
+		return errors.Wrap(err, "gather metrics error")
+	}
+
+	wr := writeRequest{
+		Timeseries: toTimeSeries(mfs, []*label{
+			{Name: "job", Value: p.job},
+			{Name: "instance", Value: p.instance},
+		}, now),
+	}
+
+	b, err := proto.Marshal(&wr)
+	if err != nil {
+		return errors.Wrap(err, "marshal write request error")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(snappy.Encode(nil, b)))
+	if err != nil {
+		return errors.Wrap(err, "new request error")
+	}
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+
+	if p.username != "" || p.password != "" {
+		req.SetBasicAuth(p.username, p.password)
+	}
+
+	resp, err := p.Do(req)
+	if err != nil {
+		return errors.Wrap(err, "remote-write request error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote-write endpoint returned status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package metrics
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/golang/snappy"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+func testRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+
+	c := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "test_count",
+		Help: "Test counter.",
+	}, []string{"gateway_id"})
+	c.With(prometheus.Labels{"gateway_id": "0102030405060708"}).Add(3)
+
+	h := prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "test_seconds",
+		Help:    "Test histogram.",
+		Buckets: []float64{0.5},
+	})
+	h.Observe(0.25)
+	h.Observe(1)
+
+	reg.MustRegister(c, h)
+	return reg
+}
+
+func TestPushGateway(t *testing.T) {
+	assert := require.New(t)
+
+	var method, path, auth, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := ioutil.ReadAll(r.Body)
+		method, path, auth, body = r.Method, r.URL.Path, r.Header.Get("Authorization"), string(b)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	var conf config.Config
+	conf.Metrics.Prometheus.Push.Type = "pushgateway"
+	conf.Metrics.Prometheus.Push.URL = server.URL
+	conf.Metrics.Prometheus.Push.Job = "lora-gateway-bridge"
+	conf.Metrics.Prometheus.Push.Instance = "gw1"
+	conf.Metrics.Prometheus.Push.BearerToken = "secret"
+
+	p, err := newPusher(conf, testRegistry())
+	assert.NoError(err)
+	assert.NoError(p.push(time.Now()))
+
+	assert.Equal(http.MethodPut, method)
+	assert.Equal("/metrics/job/lora-gateway-bridge/instance/gw1", path)
+	assert.Equal("Bearer secret", auth)
+	assert.NotEmpty(body)
+}
+
+func TestRemoteWrite(t *testing.T) {
+	assert := require.New(t)
+
+	var wr writeRequest
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		b, _ := ioutil.ReadAll(r.Body)
+		b, err := snappy.Decode(nil, b)
+		if err == nil {
+			err = proto.Unmarshal(b, &wr)
+		}
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	var conf config.Config
+	conf.Metrics.Prometheus.Push.Type = "remote_write"
+	conf.Metrics.Prometheus.Push.URL = server.URL
+	conf.Metrics.Prometheus.Push.Job = "lora-gateway-bridge"
+	conf.Metrics.Prometheus.Push.Instance = "gw1"
+	conf.Metrics.Prometheus.Push.Username = "user"
+	conf.Metrics.Prometheus.Push.Password = "pass"
+
+	now := time.Unix(1570000000, 0)
+	p, err := newPusher(conf, testRegistry())
+	assert.NoError(err)
+	assert.NoError(p.push(now))
+
+	assert.Equal("snappy", headers.Get("Content-Encoding"))
+	assert.Equal("application/x-protobuf", headers.Get("Content-Type"))
+	user, pass, ok := (&http.Request{Header: headers}).BasicAuth()
+	assert.True(ok)
+	assert.Equal("user", user)
+	assert.Equal("pass", pass)
+
+	series := make(map[string]float64)
+	for _, ts := range wr.Timeseries {
+		var key string
+		for _, l := range ts.Labels {
+			key += l.Name + "=" + l.Value + ","
+		}
+		assert.Len(ts.Samples, 1)
+		assert.Equal(int64(1570000000000), ts.Samples[0].Timestamp)
+		series[key] = ts.Samples[0].Value
+	}
+
+	assert.Equal(map[string]float64{
+		"__name__=test_count,gateway_id=0102030405060708,instance=gw1,job=lora-gateway-bridge,": 3,
+		"__name__=test_seconds_bucket,instance=gw1,job=lora-gateway-bridge,le=0.5,":             1,
+		"__name__=test_seconds_bucket,instance=gw1,job=lora-gateway-bridge,le=+Inf,":            2,
+		"__name__=test_seconds_sum,instance=gw1,job=lora-gateway-bridge,":                       1.25,
+		"__name__=test_seconds_count,instance=gw1,job=lora-gateway-bridge,":                     2,
+	}, series)
+}
+
+func TestNewPusherValidation(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Metrics.Prometheus.Push.Type = "foo"
+	_, err := newPusher(conf, testRegistry())
+	assert.EqualError(err, "unknown push type: foo")
+
+	conf.Metrics.Prometheus.Push.Type = "pushgateway"
+	_, err = newPusher(conf, testRegistry())
+	assert.EqualError(err, "push url must be set")
+}
@@ -0,0 +1,17 @@
+package multicast
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	dco = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "multicast_duty_cycle_overflow_count",
+		Help: "The number of multicast downlinks not sent as these would exceed the aggregate duty cycle (per site).",
+	}, []string{"site"})
+)
+
+func dutyCycleOverflowCounter(site string) prometheus.Counter {
+	return dco.With(prometheus.Labels{"site": site})
+}
@@ -0,0 +1,271 @@
+// Package multicast implements the fan-out of multicast downlink requests
+// to multiple gateways and the aggregation of the per-gateway acks into a
+// single result. It does not depend on the integration package, so that the
+// request and result messages can be used by the integration implementations.
+package multicast
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// ErrorAckTimeout is the result error used when the gateway did not ack the
+// downlink within the configured ack timeout.
+const ErrorAckTimeout = "ACK_TIMEOUT"
+
+var (
+	mux sync.Mutex
+
+	ackTimeout time.Duration
+	groups     map[string][]lorawan.EUI64
+
+	// pending contains the fan-outs waiting for acks, by multicast ID.
+	pending map[uuid.UUID]*fanOut
+
+	// downlinks contains the multicast ID by downlink ID.
+	downlinks map[uuid.UUID]uuid.UUID
+)
+
+type fanOut struct {
+	result    Result
+	remaining int
+	expires   time.Time
+}
+
+// Setup configures the multicast package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	ackTimeout = conf.Multicast.AckTimeout
+	groups = make(map[string][]lorawan.EUI64)
+	pending = make(map[uuid.UUID]*fanOut)
+	downlinks = make(map[uuid.UUID]uuid.UUID)
+
+	for _, g := range conf.Multicast.Groups {
+		if g.Name == "" {
+			return errors.New("multicast group name must be set")
+		}
+
+		for _, id := range g.GatewayIDs {
+			var gatewayID lorawan.EUI64
+			if err := gatewayID.UnmarshalText([]byte(id)); err != nil {
+				return errors.Wrapf(err, "unmarshal gateway_id error (group: %s)", g.Name)
+			}
+			groups[g.Name] = append(groups[g.Name], gatewayID)
+		}
+
+		log.WithFields(log.Fields{
+			"group":    g.Name,
+			"gateways": len(groups[g.Name]),
+		}).Info("multicast: group configured")
+	}
+
+	return setupScheduler(conf)
+}
+
+// FanOut returns the downlink frames for each gateway of the given request.
+// Each frame has its own downlink ID and token, which are used to aggregate
+// the acks into the multicast result.
+func FanOut(req Request) ([]gw.DownlinkFrame, error) {
+	return fanOutRequest(req, time.Now())
+}
+
+// Ack registers the ack of a multicast downlink. It returns the multicast
+// result and true when this was the last outstanding ack of the multicast.
+func Ack(txAck gw.DownlinkTXAck) (Result, bool) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	var downID uuid.UUID
+	copy(downID[:], txAck.GetDownlinkId())
+
+	multicastID, ok := downlinks[downID]
+	if !ok {
+		return Result{}, false
+	}
+	delete(downlinks, downID)
+
+	f, ok := pending[multicastID]
+	if !ok {
+		return Result{}, false
+	}
+
+	for _, item := range f.result.Items {
+		if uuid.FromBytesOrNil(item.DownlinkId) == downID {
+			item.Error = txAck.Error
+		}
+	}
+
+	f.remaining--
+	if f.remaining > 0 {
+		return Result{}, false
+	}
+
+	delete(pending, multicastID)
+	return f.result, true
+}
+
+// Expire returns the results of the multicasts exceeding the ack timeout.
+// The downlinks that were not acked are marked with ErrorAckTimeout.
+func Expire() []Result {
+	return expire(time.Now())
+}
+
+func fanOutRequest(req Request, now time.Time) ([]gw.DownlinkFrame, error) {
+	if req.DownlinkFrame == nil || req.DownlinkFrame.TxInfo == nil {
+		return nil, errors.New("downlink frame and tx-info must be set")
+	}
+
+	// the context of a delayed downlink refers to an uplink received by a
+	// single gateway
+	if req.DownlinkFrame.TxInfo.Timing == gw.DownlinkTiming_DELAY {
+		return nil, errors.New("delay timing is not supported for multicast downlinks")
+	}
+
+	gatewayIDs, err := resolveGatewayIDs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	multicastID, err := uuid.FromBytes(req.MulticastId)
+	if err != nil {
+		if multicastID, err = uuid.NewV4(); err != nil {
+			return nil, errors.Wrap(err, "new uuid error")
+		}
+	}
+
+	f := fanOut{
+		result: Result{
+			MulticastId: multicastID.Bytes(),
+		},
+		remaining: len(gatewayIDs),
+		expires:   now.Add(ackTimeout),
+	}
+
+	var out []gw.DownlinkFrame
+	for i := range gatewayIDs {
+		gatewayID := gatewayIDs[i]
+
+		downID, err := uuid.NewV4()
+		if err != nil {
+			return nil, errors.Wrap(err, "new uuid error")
+		}
+
+		token, err := newToken()
+		if err != nil {
+			return nil, errors.Wrap(err, "new token error")
+		}
+
+		txInfo := *req.DownlinkFrame.TxInfo
+		txInfo.GatewayId = gatewayID[:]
+
+		out = append(out, gw.DownlinkFrame{
+			PhyPayload: req.DownlinkFrame.PhyPayload,
+			TxInfo:     &txInfo,
+			Token:      token,
+			DownlinkId: downID.Bytes(),
+		})
+
+		f.result.Items = append(f.result.Items, &ResultItem{
+			GatewayId:  gatewayID[:],
+			DownlinkId: downID.Bytes(),
+		})
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	pending[multicastID] = &f
+	for _, item := range f.result.Items {
+		downlinks[uuid.FromBytesOrNil(item.DownlinkId)] = multicastID
+	}
+
+	return out, nil
+}
+
+// resolveGatewayIDs returns the unique gateway IDs of the given request,
+// either set directly or through the configured group.
+func resolveGatewayIDs(req Request) ([]lorawan.EUI64, error) {
+	var gatewayIDs []lorawan.EUI64
+
+	for _, b := range req.GatewayIds {
+		var gatewayID lorawan.EUI64
+		if len(b) != len(gatewayID) {
+			return nil, errors.New("invalid gateway id length")
+		}
+		copy(gatewayID[:], b)
+		gatewayIDs = append(gatewayIDs, gatewayID)
+	}
+
+	if req.Group != "" {
+		mux.Lock()
+		ids, ok := groups[req.Group]
+		mux.Unlock()
+
+		if !ok {
+			return nil, errors.Errorf("unknown multicast group: %s", req.Group)
+		}
+		gatewayIDs = append(gatewayIDs, ids...)
+	}
+
+	seen := make(map[lorawan.EUI64]struct{})
+	var out []lorawan.EUI64
+	for _, id := range gatewayIDs {
+		if _, ok := seen[id]; ok {
+			continue
+		}
+		seen[id] = struct{}{}
+		out = append(out, id)
+	}
+
+	if len(out) == 0 {
+		return nil, errors.New("no gateways to send the multicast downlink to")
+	}
+
+	return out, nil
+}
+
+func expire(now time.Time) []Result {
+	mux.Lock()
+	defer mux.Unlock()
+
+	var out []Result
+
+	for multicastID, f := range pending {
+		if now.Before(f.expires) {
+			continue
+		}
+
+		for _, item := range f.result.Items {
+			downID := uuid.FromBytesOrNil(item.DownlinkId)
+			if _, ok := downlinks[downID]; ok {
+				item.Error = ErrorAckTimeout
+				delete(downlinks, downID)
+			}
+		}
+
+		delete(pending, multicastID)
+		out = append(out, f.result)
+	}
+
+	return out
+}
+
+func newToken() (uint32, error) {
+	b := make([]byte, 2)
+	if _, err := rand.Read(b); err != nil {
+		return 0, err
+	}
+	return uint32(binary.BigEndian.Uint16(b)), nil
+}
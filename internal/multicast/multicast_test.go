@@ -0,0 +1,156 @@
+package multicast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func setup(assert *require.Assertions) {
+	var conf config.Config
+	conf.Multicast.AckTimeout = 10 * time.Second
+	conf.Multicast.Groups = append(conf.Multicast.Groups, struct {
+		Name       string   `mapstructure:"name"`
+		GatewayIDs []string `mapstructure:"gateway_ids"`
+	}{
+		Name:       "city",
+		GatewayIDs: []string{"0101010101010101", "0202020202020202"},
+	})
+
+	assert.NoError(Setup(conf))
+}
+
+func TestFanOut(t *testing.T) {
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	multicastID, _ := uuid.FromString("9cb249eb-3f1f-4438-8e2c-7533448a3f0f")
+
+	downlinkFrame := gw.DownlinkFrame{
+		PhyPayload: []byte{1, 2, 3},
+		TxInfo: &gw.DownlinkTXInfo{
+			Frequency: 869525000,
+			Timing:    gw.DownlinkTiming_IMMEDIATELY,
+		},
+	}
+
+	t.Run("Gateway IDs and group", func(t *testing.T) {
+		assert := require.New(t)
+		setup(assert)
+
+		frames, err := fanOutRequest(Request{
+			MulticastId:   multicastID.Bytes(),
+			GatewayIds:    [][]byte{{2, 2, 2, 2, 2, 2, 2, 2}, {3, 3, 3, 3, 3, 3, 3, 3}},
+			Group:         "city",
+			DownlinkFrame: &downlinkFrame,
+		}, now)
+		assert.NoError(err)
+		assert.Len(frames, 3)
+
+		var gatewayIDs []lorawan.EUI64
+		downIDs := make(map[uuid.UUID]struct{})
+		for _, f := range frames {
+			var gatewayID lorawan.EUI64
+			copy(gatewayID[:], f.TxInfo.GatewayId)
+			gatewayIDs = append(gatewayIDs, gatewayID)
+			downIDs[uuid.FromBytesOrNil(f.DownlinkId)] = struct{}{}
+
+			assert.Equal(downlinkFrame.PhyPayload, f.PhyPayload)
+			assert.Equal(downlinkFrame.TxInfo.Frequency, f.TxInfo.Frequency)
+		}
+
+		assert.Equal([]lorawan.EUI64{
+			{2, 2, 2, 2, 2, 2, 2, 2},
+			{3, 3, 3, 3, 3, 3, 3, 3},
+			{1, 1, 1, 1, 1, 1, 1, 1},
+		}, gatewayIDs)
+		assert.Len(downIDs, 3)
+
+		// the template is not modified
+		assert.Nil(downlinkFrame.TxInfo.GatewayId)
+	})
+
+	t.Run("Unknown group", func(t *testing.T) {
+		assert := require.New(t)
+		setup(assert)
+
+		_, err := fanOutRequest(Request{
+			Group:         "unknown",
+			DownlinkFrame: &downlinkFrame,
+		}, now)
+		assert.Error(err)
+	})
+
+	t.Run("Delay timing", func(t *testing.T) {
+		assert := require.New(t)
+		setup(assert)
+
+		_, err := fanOutRequest(Request{
+			Group: "city",
+			DownlinkFrame: &gw.DownlinkFrame{
+				TxInfo: &gw.DownlinkTXInfo{
+					Timing: gw.DownlinkTiming_DELAY,
+				},
+			},
+		}, now)
+		assert.Error(err)
+	})
+}
+
+func TestAck(t *testing.T) {
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	req := Request{
+		Group: "city",
+		DownlinkFrame: &gw.DownlinkFrame{
+			TxInfo: &gw.DownlinkTXInfo{
+				Timing: gw.DownlinkTiming_IMMEDIATELY,
+			},
+		},
+	}
+
+	t.Run("All acked", func(t *testing.T) {
+		assert := require.New(t)
+		setup(assert)
+
+		frames, err := fanOutRequest(req, now)
+		assert.NoError(err)
+
+		_, ok := Ack(gw.DownlinkTXAck{DownlinkId: frames[0].DownlinkId})
+		assert.False(ok)
+
+		res, ok := Ack(gw.DownlinkTXAck{DownlinkId: frames[1].DownlinkId, Error: "COLLISION_PACKET"})
+		assert.True(ok)
+		assert.Len(res.Items, 2)
+		assert.Equal("", res.Items[0].Error)
+		assert.Equal("COLLISION_PACKET", res.Items[1].Error)
+
+		assert.Len(pending, 0)
+		assert.Len(downlinks, 0)
+	})
+
+	t.Run("Ack timeout", func(t *testing.T) {
+		assert := require.New(t)
+		setup(assert)
+
+		frames, err := fanOutRequest(req, now)
+		assert.NoError(err)
+
+		_, ok := Ack(gw.DownlinkTXAck{DownlinkId: frames[0].DownlinkId})
+		assert.False(ok)
+
+		assert.Len(expire(now.Add(5*time.Second)), 0)
+
+		results := expire(now.Add(10 * time.Second))
+		assert.Len(results, 1)
+		assert.Equal("", results[0].Items[0].Error)
+		assert.Equal(ErrorAckTimeout, results[0].Items[1].Error)
+
+		// late acks are ignored
+		_, ok = Ack(gw.DownlinkTXAck{DownlinkId: frames[1].DownlinkId})
+		assert.False(ok)
+	})
+}
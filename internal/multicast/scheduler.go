@@ -0,0 +1,258 @@
+package multicast
+
+import (
+	"sort"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/airtime"
+)
+
+// ErrorDutyCycle is the result error used when the downlink was not sent as
+// it would exceed the aggregate duty cycle of the site of the gateway.
+const ErrorDutyCycle = "DUTY_CYCLE_OVERFLOW"
+
+// defaultSite is the site of the gateways which are not part of a configured
+// site. For staggering, these gateways share the backhaul of the LoRa Gateway
+// Bridge, for the duty cycle each gateway is accounted individually.
+const defaultSite = ""
+
+// ScheduledFrame contains a downlink frame of a multicast fan-out and the
+// delay (relative to the start of the fan-out) after which it must be sent.
+type ScheduledFrame struct {
+	DownlinkFrame gw.DownlinkFrame
+	Delay         time.Duration
+
+	// Error is set when the frame must not be sent.
+	Error string
+}
+
+type airtimeRecord struct {
+	sentAt  time.Time
+	airtime time.Duration
+}
+
+var (
+	schedulerEnabled bool
+	staggerInterval  time.Duration
+	maxDutyCycle     float64
+	dutyCycleWindow  time.Duration
+
+	// sites contains the site name by gateway ID.
+	sites map[lorawan.EUI64]string
+
+	// airtimes contains the scheduled airtime by duty cycle key (site name
+	// or gateway ID).
+	airtimes map[string][]airtimeRecord
+)
+
+// setupScheduler configures the scheduler. It must be called with the
+// lock acquired.
+func setupScheduler(conf config.Config) error {
+	schedulerEnabled = conf.Multicast.Scheduler.Enabled
+	staggerInterval = conf.Multicast.Scheduler.StaggerInterval
+	maxDutyCycle = conf.Multicast.Scheduler.MaxDutyCycle
+	dutyCycleWindow = conf.Multicast.Scheduler.DutyCycleWindow
+	sites = make(map[lorawan.EUI64]string)
+	airtimes = make(map[string][]airtimeRecord)
+
+	if !schedulerEnabled {
+		return nil
+	}
+
+	if maxDutyCycle < 0 || maxDutyCycle > 100 {
+		return errors.New("max_duty_cycle must be between 0 and 100")
+	}
+
+	if maxDutyCycle > 0 && dutyCycleWindow <= 0 {
+		return errors.New("duty_cycle_window must be set when max_duty_cycle is set")
+	}
+
+	for _, s := range conf.Multicast.Scheduler.Sites {
+		if s.Name == "" {
+			return errors.New("multicast scheduler site name must be set")
+		}
+
+		for _, id := range s.GatewayIDs {
+			var gatewayID lorawan.EUI64
+			if err := gatewayID.UnmarshalText([]byte(id)); err != nil {
+				return errors.Wrapf(err, "unmarshal gateway_id error (site: %s)", s.Name)
+			}
+
+			if site, ok := sites[gatewayID]; ok {
+				return errors.Errorf("gateway %s is part of multiple sites (%s, %s)", gatewayID, site, s.Name)
+			}
+			sites[gatewayID] = s.Name
+		}
+
+		log.WithFields(log.Fields{
+			"site":     s.Name,
+			"gateways": len(s.GatewayIDs),
+		}).Info("multicast: scheduler site configured")
+	}
+
+	return nil
+}
+
+// Schedule returns the given fan-out frames with the delay after which each
+// frame must be sent, sorted by delay. When the scheduler is enabled, the
+// frames for gateways sharing a site are staggered by the configured
+// interval and frames exceeding the aggregate duty cycle of the site are
+// marked with ErrorDutyCycle. The ack timeout of the multicast is extended
+// by the largest delay.
+func Schedule(frames []gw.DownlinkFrame) []ScheduledFrame {
+	return schedule(frames, time.Now())
+}
+
+func schedule(frames []gw.DownlinkFrame, now time.Time) []ScheduledFrame {
+	out := make([]ScheduledFrame, 0, len(frames))
+	for _, f := range frames {
+		out = append(out, ScheduledFrame{DownlinkFrame: f})
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !schedulerEnabled {
+		return out
+	}
+
+	siteIndex := make(map[string]int)
+	var maxDelay time.Duration
+
+	for i := range out {
+		var gatewayID lorawan.EUI64
+		copy(gatewayID[:], out[i].DownlinkFrame.GetTxInfo().GetGatewayId())
+
+		site := sites[gatewayID]
+		delay := time.Duration(siteIndex[site]) * staggerInterval
+
+		if maxDutyCycle > 0 && !reserveDutyCycle(site, gatewayID, out[i].DownlinkFrame, now.Add(delay)) {
+			log.WithFields(log.Fields{
+				"gateway_id": gatewayID,
+				"site":       site,
+			}).Warning("multicast: downlink exceeds aggregate duty cycle")
+			dutyCycleOverflowCounter(site).Inc()
+			out[i].Error = ErrorDutyCycle
+			continue
+		}
+
+		out[i].Delay = delay
+		siteIndex[site]++
+
+		if delay > maxDelay {
+			maxDelay = delay
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Delay < out[j].Delay
+	})
+
+	if len(out) != 0 {
+		if multicastID, ok := downlinks[uuid.FromBytesOrNil(out[0].DownlinkFrame.DownlinkId)]; ok {
+			if f, ok := pending[multicastID]; ok {
+				f.expires = f.expires.Add(maxDelay)
+			}
+		}
+	}
+
+	return out
+}
+
+// reserveDutyCycle reserves the airtime of the given frame within the duty
+// cycle of the given site. Gateways which are not part of a site are
+// accounted individually. It returns false when the airtime would exceed the
+// max duty cycle.
+func reserveDutyCycle(site string, gatewayID lorawan.EUI64, frame gw.DownlinkFrame, sentAt time.Time) bool {
+	key := site
+	if key == defaultSite {
+		key = gatewayID.String()
+	}
+
+	d, err := frameAirtime(frame)
+	if err != nil {
+		log.WithError(err).WithField("gateway_id", gatewayID).Error("multicast: calculate downlink airtime error")
+		return true
+	}
+
+	return reserveAirtime(key, d, sentAt)
+}
+
+// reserveAirtime reserves the given airtime for the given duty cycle key
+// when this does not exceed the max duty cycle within the duty cycle window.
+// It returns false when the airtime could not be reserved.
+func reserveAirtime(key string, d time.Duration, sentAt time.Time) bool {
+	var used time.Duration
+	var records []airtimeRecord
+
+	for _, r := range airtimes[key] {
+		if !r.sentAt.After(sentAt.Add(-dutyCycleWindow)) {
+			continue
+		}
+		records = append(records, r)
+		used += r.airtime
+	}
+
+	if float64(used+d) > float64(dutyCycleWindow)*maxDutyCycle/100 {
+		airtimes[key] = records
+		return false
+	}
+
+	airtimes[key] = append(records, airtimeRecord{sentAt: sentAt, airtime: d})
+	return true
+}
+
+// frameAirtime returns the airtime of the given downlink frame.
+func frameAirtime(frame gw.DownlinkFrame) (time.Duration, error) {
+	txInfo := frame.GetTxInfo()
+
+	switch txInfo.GetModulation() {
+	case common.Modulation_LORA:
+		modInfo := txInfo.GetLoraModulationInfo()
+		if modInfo == nil {
+			return 0, errors.New("lora modulation info must be set")
+		}
+
+		var cr airtime.CodingRate
+		switch modInfo.CodeRate {
+		case "4/5":
+			cr = airtime.CodingRate45
+		case "4/6":
+			cr = airtime.CodingRate46
+		case "4/7":
+			cr = airtime.CodingRate47
+		case "4/8":
+			cr = airtime.CodingRate48
+		default:
+			return 0, errors.Errorf("invalid code-rate: %s", modInfo.CodeRate)
+		}
+
+		// low data-rate optimization is mandated for SF11 and SF12 at 125 kHz
+		ldro := modInfo.Bandwidth == 125 && modInfo.SpreadingFactor >= 11
+
+		d, err := airtime.CalculateLoRaAirtime(len(frame.PhyPayload), int(modInfo.SpreadingFactor), int(modInfo.Bandwidth), 8, cr, true, ldro)
+		if err != nil {
+			return 0, errors.Wrap(err, "calculate lora airtime error")
+		}
+		return d, nil
+	case common.Modulation_FSK:
+		modInfo := txInfo.GetFskModulationInfo()
+		if modInfo == nil || modInfo.Bitrate == 0 {
+			return 0, errors.New("fsk modulation info and bitrate must be set")
+		}
+
+		// preamble (5), sync word (3), length (1) and crc (2) bytes
+		bits := (len(frame.PhyPayload) + 11) * 8
+		return time.Duration(bits) * time.Second / time.Duration(modInfo.Bitrate), nil
+	default:
+		return 0, errors.Errorf("unsupported modulation: %s", txInfo.GetModulation())
+	}
+}
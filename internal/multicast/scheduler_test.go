@@ -0,0 +1,168 @@
+package multicast
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func setupWithScheduler(assert *require.Assertions, maxDutyCycle float64) {
+	var conf config.Config
+	conf.Multicast.AckTimeout = 10 * time.Second
+	conf.Multicast.Scheduler.Enabled = true
+	conf.Multicast.Scheduler.StaggerInterval = 100 * time.Millisecond
+	conf.Multicast.Scheduler.MaxDutyCycle = maxDutyCycle
+	conf.Multicast.Scheduler.DutyCycleWindow = time.Hour
+	conf.Multicast.Scheduler.Sites = append(conf.Multicast.Scheduler.Sites, struct {
+		Name       string   `mapstructure:"name"`
+		GatewayIDs []string `mapstructure:"gateway_ids"`
+	}{
+		Name:       "city",
+		GatewayIDs: []string{"0101010101010101", "0202020202020202", "0303030303030303"},
+	})
+
+	assert.NoError(Setup(conf))
+}
+
+func TestSchedule(t *testing.T) {
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	req := Request{
+		GatewayIds: [][]byte{
+			{1, 1, 1, 1, 1, 1, 1, 1},
+			{4, 4, 4, 4, 4, 4, 4, 4},
+			{2, 2, 2, 2, 2, 2, 2, 2},
+			{5, 5, 5, 5, 5, 5, 5, 5},
+			{3, 3, 3, 3, 3, 3, 3, 3},
+		},
+		DownlinkFrame: &gw.DownlinkFrame{
+			PhyPayload: make([]byte, 51),
+			TxInfo: &gw.DownlinkTXInfo{
+				Frequency:  869525000,
+				Modulation: common.Modulation_LORA,
+				ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+					LoraModulationInfo: &gw.LoRaModulationInfo{
+						Bandwidth:       125,
+						SpreadingFactor: 12,
+						CodeRate:        "4/5",
+					},
+				},
+				Timing: gw.DownlinkTiming_IMMEDIATELY,
+			},
+		},
+	}
+
+	gatewayID := func(f ScheduledFrame) lorawan.EUI64 {
+		var id lorawan.EUI64
+		copy(id[:], f.DownlinkFrame.GetTxInfo().GetGatewayId())
+		return id
+	}
+
+	t.Run("Stagger", func(t *testing.T) {
+		assert := require.New(t)
+		setupWithScheduler(assert, 0)
+
+		frames, err := fanOutRequest(req, now)
+		assert.NoError(err)
+
+		scheduled := schedule(frames, now)
+		assert.Len(scheduled, 5)
+
+		var gatewayIDs []lorawan.EUI64
+		var delays []time.Duration
+		for _, f := range scheduled {
+			assert.Equal("", f.Error)
+			gatewayIDs = append(gatewayIDs, gatewayID(f))
+			delays = append(delays, f.Delay)
+		}
+
+		assert.Equal([]lorawan.EUI64{
+			{1, 1, 1, 1, 1, 1, 1, 1},
+			{4, 4, 4, 4, 4, 4, 4, 4},
+			{2, 2, 2, 2, 2, 2, 2, 2},
+			{5, 5, 5, 5, 5, 5, 5, 5},
+			{3, 3, 3, 3, 3, 3, 3, 3},
+		}, gatewayIDs)
+		assert.Equal([]time.Duration{0, 0, 100 * time.Millisecond, 100 * time.Millisecond, 200 * time.Millisecond}, delays)
+
+		t.Run("Ack timeout is extended", func(t *testing.T) {
+			assert := require.New(t)
+
+			assert.Len(expire(now.Add(10*time.Second)), 0)
+			assert.Len(expire(now.Add(10*time.Second+200*time.Millisecond)), 1)
+		})
+	})
+
+	t.Run("Duty cycle", func(t *testing.T) {
+		assert := require.New(t)
+
+		// SF12 with 51 bytes payload takes ~2.5s of airtime, 0.25% of one
+		// hour (9s) allows three of these downlinks per site / gateway
+		setupWithScheduler(assert, 0.25)
+
+		for i := 0; i < 2; i++ {
+			frames, err := fanOutRequest(req, now)
+			assert.NoError(err)
+
+			for _, f := range schedule(frames, now.Add(time.Duration(i)*time.Minute)) {
+				if i == 0 {
+					assert.Equal("", f.Error)
+					continue
+				}
+
+				// the city site has used its duty cycle
+				switch gatewayID(f) {
+				case lorawan.EUI64{4, 4, 4, 4, 4, 4, 4, 4}, lorawan.EUI64{5, 5, 5, 5, 5, 5, 5, 5}:
+					assert.Equal("", f.Error)
+				default:
+					assert.Equal(ErrorDutyCycle, f.Error)
+					assert.Equal(time.Duration(0), f.Delay)
+				}
+			}
+		}
+
+		t.Run("Window passed", func(t *testing.T) {
+			assert := require.New(t)
+
+			frames, err := fanOutRequest(req, now)
+			assert.NoError(err)
+
+			for _, f := range schedule(frames, now.Add(time.Hour+time.Minute)) {
+				assert.Equal("", f.Error)
+			}
+		})
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+		setup(assert)
+
+		frames, err := fanOutRequest(req, now)
+		assert.NoError(err)
+
+		for i, f := range schedule(frames, now) {
+			assert.Equal(frames[i], f.DownlinkFrame)
+			assert.Equal(time.Duration(0), f.Delay)
+			assert.Equal("", f.Error)
+		}
+	})
+
+	t.Run("Airtime", func(t *testing.T) {
+		assert := require.New(t)
+
+		d, err := frameAirtime(*req.DownlinkFrame)
+		assert.NoError(err)
+		assert.Equal(2465792*time.Microsecond, d)
+
+		_, err = frameAirtime(gw.DownlinkFrame{
+			TxInfo: &gw.DownlinkTXInfo{Modulation: common.Modulation_LORA},
+		})
+		assert.Error(err)
+	})
+}
@@ -0,0 +1,179 @@
+// Package overlay implements the configurable field overrides of the
+// published events, e.g. to compensate for buggy packet-forwarder firmware
+// (forcing the board to 0, injecting a fixed location, ...). The fields are
+// addressed by their protobuf (snake_case) names, e.g. rx_info.board, and the
+// values are JSON encoded. The overrides are applied by marshaling the event
+// to its JSON representation and back.
+package overlay
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// events contains the events supporting overrides, with a function returning
+// an empty message of the event type (used for the validation).
+var events = map[string]func() proto.Message{
+	"up":    func() proto.Message { return &gw.UplinkFrame{} },
+	"stats": func() proto.Message { return &gw.GatewayStats{} },
+	"ack":   func() proto.Message { return &gw.DownlinkTXAck{} },
+}
+
+type rule struct {
+	gatewayID *lorawan.EUI64
+	event     string
+	path      []string
+	value     interface{}
+}
+
+var (
+	mux   sync.RWMutex
+	rules []rule
+
+	marshaler = jsonpb.Marshaler{OrigName: true}
+)
+
+// Setup configures the overlay rules.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	rules = nil
+
+	for i, c := range conf.Overlay.Rules {
+		r, err := newRule(c)
+		if err != nil {
+			return errors.Wrapf(err, "overlay rule %d error", i)
+		}
+
+		// apply the rule to an empty message, to validate the field path and
+		// value
+		if err := apply(events[r.event](), []rule{r}); err != nil {
+			return errors.Wrapf(err, "overlay rule %d (field: %s) error", i, c.Field)
+		}
+
+		rules = append(rules, r)
+	}
+
+	if len(rules) != 0 {
+		log.WithField("rules", len(rules)).Info("overlay: event field overrides configured")
+	}
+
+	return nil
+}
+
+// Apply applies the matching rules to the given event message.
+func Apply(gatewayID lorawan.EUI64, event string, msg proto.Message) error {
+	mux.RLock()
+	var matching []rule
+	for _, r := range rules {
+		if r.event == event && (r.gatewayID == nil || *r.gatewayID == gatewayID) {
+			matching = append(matching, r)
+		}
+	}
+	mux.RUnlock()
+
+	if len(matching) == 0 {
+		return nil
+	}
+
+	return apply(msg, matching)
+}
+
+func newRule(c config.OverlayRule) (rule, error) {
+	var r rule
+
+	if _, ok := events[c.Event]; !ok {
+		return r, errors.Errorf("unsupported event: %s", c.Event)
+	}
+	r.event = c.Event
+
+	if c.GatewayID != "" {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(c.GatewayID)); err != nil {
+			return r, errors.Wrap(err, "unmarshal gateway_id error")
+		}
+		r.gatewayID = &gatewayID
+	}
+
+	if c.Field == "" {
+		return r, errors.New("field must be set")
+	}
+	r.path = strings.Split(c.Field, ".")
+
+	if err := json.Unmarshal([]byte(c.Value), &r.value); err != nil {
+		return r, errors.Wrap(err, "unmarshal value error")
+	}
+
+	return r, nil
+}
+
+// apply sets the fields of the given rules. The message is only modified
+// when all rules could be applied.
+func apply(msg proto.Message, rs []rule) error {
+	var buf bytes.Buffer
+	if err := marshaler.Marshal(&buf, msg); err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+
+	var m map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &m); err != nil {
+		return errors.Wrap(err, "unmarshal json error")
+	}
+
+	for _, r := range rs {
+		if err := setField(m, r.path, r.value); err != nil {
+			return err
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return errors.Wrap(err, "marshal json error")
+	}
+
+	out := proto.Clone(msg)
+	out.Reset()
+	if err := jsonpb.Unmarshal(bytes.NewReader(b), out); err != nil {
+		return errors.Wrap(err, "unmarshal event error")
+	}
+
+	msg.Reset()
+	proto.Merge(msg, out)
+
+	return nil
+}
+
+// setField sets the field of the given path, creating the intermediate
+// objects when these are not set.
+func setField(m map[string]interface{}, path []string, value interface{}) error {
+	for i, name := range path[:len(path)-1] {
+		v, ok := m[name]
+		if !ok || v == nil {
+			child := make(map[string]interface{})
+			m[name] = child
+			m = child
+			continue
+		}
+
+		child, ok := v.(map[string]interface{})
+		if !ok {
+			return errors.Errorf("field %s is not an object", strings.Join(path[:i+1], "."))
+		}
+		m = child
+	}
+
+	m[path[len(path)-1]] = value
+	return nil
+}
@@ -0,0 +1,151 @@
+package overlay
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestApply(t *testing.T) {
+	gatewayID1 := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	gatewayID2 := lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}
+
+	var conf config.Config
+	conf.Overlay.Rules = []config.OverlayRule{
+		{Event: "up", Field: "rx_info.board", Value: "0"},
+		{GatewayID: "0102030405060708", Event: "up", Field: "rx_info.location", Value: `{"latitude": 1.123, "longitude": 2.123, "altitude": 3}`},
+		{GatewayID: "0102030405060708", Event: "stats", Field: "config_version", Value: `"1.2.3"`},
+	}
+
+	assert := require.New(t)
+	assert.NoError(Setup(conf))
+	defer Setup(config.Config{})
+
+	tests := []struct {
+		Name      string
+		GatewayID lorawan.EUI64
+		Event     string
+		In        func() interface{}
+		Expected  interface{}
+	}{
+		{
+			Name:      "all gateways rule",
+			GatewayID: gatewayID2,
+			Event:     "up",
+			In: func() interface{} {
+				return &gw.UplinkFrame{
+					PhyPayload: []byte{1, 2, 3},
+					RxInfo:     &gw.UplinkRXInfo{GatewayId: gatewayID2[:], Board: 2, Rssi: -60},
+				}
+			},
+			Expected: &gw.UplinkFrame{
+				PhyPayload: []byte{1, 2, 3},
+				RxInfo:     &gw.UplinkRXInfo{GatewayId: gatewayID2[:], Board: 0, Rssi: -60},
+			},
+		},
+		{
+			Name:      "gateway rules",
+			GatewayID: gatewayID1,
+			Event:     "up",
+			In: func() interface{} {
+				return &gw.UplinkFrame{
+					RxInfo: &gw.UplinkRXInfo{GatewayId: gatewayID1[:], Board: 2},
+				}
+			},
+			Expected: &gw.UplinkFrame{
+				RxInfo: &gw.UplinkRXInfo{
+					GatewayId: gatewayID1[:],
+					Location: &common.Location{
+						Latitude:  1.123,
+						Longitude: 2.123,
+						Altitude:  3,
+					},
+				},
+			},
+		},
+		{
+			Name:      "stats",
+			GatewayID: gatewayID1,
+			Event:     "stats",
+			In: func() interface{} {
+				return &gw.GatewayStats{GatewayId: gatewayID1[:], RxPacketsReceived: 10}
+			},
+			Expected: &gw.GatewayStats{GatewayId: gatewayID1[:], RxPacketsReceived: 10, ConfigVersion: "1.2.3"},
+		},
+		{
+			Name:      "no matching rules",
+			GatewayID: gatewayID2,
+			Event:     "stats",
+			In: func() interface{} {
+				return &gw.GatewayStats{GatewayId: gatewayID2[:]}
+			},
+			Expected: &gw.GatewayStats{GatewayId: gatewayID2[:]},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			switch v := tst.In().(type) {
+			case *gw.UplinkFrame:
+				assert.NoError(Apply(tst.GatewayID, tst.Event, v))
+				assert.Equal(tst.Expected, v)
+			case *gw.GatewayStats:
+				assert.NoError(Apply(tst.GatewayID, tst.Event, v))
+				assert.Equal(tst.Expected, v)
+			}
+		})
+	}
+}
+
+func TestSetupValidation(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Rule          config.OverlayRule
+		ExpectedError string
+	}{
+		{
+			Name:          "unsupported event",
+			Rule:          config.OverlayRule{Event: "conn", Field: "state", Value: "1"},
+			ExpectedError: "overlay rule 0 error: unsupported event: conn",
+		},
+		{
+			Name:          "no field",
+			Rule:          config.OverlayRule{Event: "up", Value: "1"},
+			ExpectedError: "overlay rule 0 error: field must be set",
+		},
+		{
+			Name: "invalid value",
+			Rule: config.OverlayRule{Event: "up", Field: "rx_info.board", Value: "foo"},
+		},
+		{
+			Name: "unknown field",
+			Rule: config.OverlayRule{Event: "up", Field: "rx_info.foo", Value: "1"},
+		},
+		{
+			Name: "invalid value type",
+			Rule: config.OverlayRule{Event: "up", Field: "rx_info.board", Value: `"foo"`},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Overlay.Rules = []config.OverlayRule{tst.Rule}
+
+			err := Setup(conf)
+			assert.Error(err)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+			}
+		})
+	}
+}
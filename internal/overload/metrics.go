@@ -0,0 +1,44 @@
+package overload
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	qg = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "overload_queued_count",
+		Help: "The number of uplinks queued for the forwarder.",
+	})
+
+	ag = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "overload_active",
+		Help: "Set to 1 when the overload mode is active.",
+	})
+
+	dc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "overload_dropped_count",
+		Help: "The number of uplinks dropped in overload mode (per reason).",
+	}, []string{"reason"})
+
+	rc = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "overload_rejected_connection_count",
+		Help: "The number of gateway connections rejected in overload mode.",
+	})
+)
+
+func queuedGauge() prometheus.Gauge {
+	return qg
+}
+
+func activeGauge() prometheus.Gauge {
+	return ag
+}
+
+func droppedCounter(reason string) prometheus.Counter {
+	return dc.With(prometheus.Labels{"reason": reason})
+}
+
+func rejectedConnectionCounter() prometheus.Counter {
+	return rc
+}
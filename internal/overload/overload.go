@@ -0,0 +1,183 @@
+// Package overload implements the overload mode. The number of uplinks
+// queued for the forwarder (blocked backend handlers) is tracked. When it
+// exceeds the high watermark, the overload mode is activated until it drops
+// below the low watermark. In overload mode, low-priority uplinks
+// (proprietary frames and frames below the min. SNR) are dropped and new
+// Basic Station connections are rejected with a retry-after hint, so that
+// the bridge degrades gracefully instead of running out of memory.
+package overload
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// Drop reasons.
+const (
+	ReasonProprietary = "proprietary"
+	ReasonLowSNR      = "low_snr"
+)
+
+var (
+	mux sync.Mutex
+
+	enabled         bool
+	highWatermark   int
+	lowWatermark    int
+	dropProprietary bool
+	minSNR          float64
+	retryAfter      time.Duration
+
+	queued int
+	active bool
+)
+
+// Setup configures the overload mode.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	enabled = false
+	queued = 0
+	active = false
+
+	c := conf.Overload
+	if !c.Enabled {
+		return nil
+	}
+
+	if c.HighWatermark < 1 {
+		return errors.New("overload high_watermark must be greater than 0")
+	}
+	if c.LowWatermark < 0 || c.LowWatermark >= c.HighWatermark {
+		return errors.New("overload low_watermark must be between 0 and high_watermark")
+	}
+
+	enabled = true
+	highWatermark = c.HighWatermark
+	lowWatermark = c.LowWatermark
+	dropProprietary = c.DropProprietary
+	minSNR = c.MinSNR
+	retryAfter = c.RetryAfter
+
+	log.WithFields(log.Fields{
+		"high_watermark": highWatermark,
+		"low_watermark":  lowWatermark,
+	}).Info("overload: overload mode enabled")
+
+	return nil
+}
+
+// Enqueue must be called before an uplink is handed over to the forwarder.
+func Enqueue() {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	queued++
+	queuedGauge().Set(float64(queued))
+
+	if !active && queued >= highWatermark {
+		active = true
+		activeGauge().Set(1)
+		log.WithField("queued", queued).Warning("overload: overload mode activated")
+	}
+}
+
+// Dequeue must be called after the uplink has been handed over to the
+// forwarder.
+func Dequeue() {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return
+	}
+
+	if queued > 0 {
+		queued--
+	}
+	queuedGauge().Set(float64(queued))
+
+	if active && queued <= lowWatermark {
+		active = false
+		activeGauge().Set(0)
+		log.WithField("queued", queued).Info("overload: overload mode deactivated")
+	}
+}
+
+// Active returns true when the overload mode is active.
+func Active() bool {
+	mux.Lock()
+	defer mux.Unlock()
+
+	return active
+}
+
+// RejectConnection returns true when a new gateway connection must be
+// rejected because the overload mode is active.
+func RejectConnection() bool {
+	if !Active() {
+		return false
+	}
+
+	rejectedConnectionCounter().Inc()
+	return true
+}
+
+// RetryAfterReason returns the close reason sent to rejected gateways,
+// containing the duration after which these should reconnect.
+func RetryAfterReason() string {
+	mux.Lock()
+	defer mux.Unlock()
+
+	return fmt.Sprintf("overloaded, retry after %s", retryAfter)
+}
+
+// Drop returns true (and the reason) when the given uplink must be dropped
+// because the overload mode is active and the uplink is of low priority.
+func Drop(frame gw.UplinkFrame) (string, bool) {
+	mux.Lock()
+	isActive, drop, snr := active, dropProprietary, minSNR
+	mux.Unlock()
+
+	if !isActive {
+		return "", false
+	}
+
+	var reason string
+	switch {
+	case drop && isProprietary(frame.PhyPayload):
+		reason = ReasonProprietary
+	case frame.GetRxInfo().GetLoraSnr() < snr:
+		reason = ReasonLowSNR
+	default:
+		return "", false
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.GetRxInfo().GetGatewayId())
+
+	droppedCounter(reason).Inc()
+	log.WithFields(log.Fields{
+		"gateway_id": gatewayID,
+		"reason":     reason,
+	}).Debug("overload: uplink dropped")
+
+	return reason, true
+}
+
+func isProprietary(b []byte) bool {
+	return len(b) != 0 && lorawan.MType(b[0]>>5) == lorawan.Proprietary
+}
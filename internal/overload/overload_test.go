@@ -0,0 +1,94 @@
+package overload
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestOverload(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Overload.Enabled = true
+	conf.Overload.HighWatermark = 3
+	conf.Overload.LowWatermark = 1
+	conf.Overload.DropProprietary = true
+	conf.Overload.MinSNR = -10
+	conf.Overload.RetryAfter = time.Minute
+	assert.NoError(Setup(conf))
+	defer Setup(config.Config{})
+
+	proprietary := gw.UplinkFrame{
+		PhyPayload: []byte{0xe0, 1, 2, 3},
+		RxInfo:     &gw.UplinkRXInfo{LoraSnr: 5},
+	}
+	lowSNR := gw.UplinkFrame{
+		PhyPayload: []byte{0x40, 1, 2, 3},
+		RxInfo:     &gw.UplinkRXInfo{LoraSnr: -15},
+	}
+	data := gw.UplinkFrame{
+		PhyPayload: []byte{0x40, 1, 2, 3},
+		RxInfo:     &gw.UplinkRXInfo{LoraSnr: 5},
+	}
+
+	t.Run("Not active", func(t *testing.T) {
+		assert := require.New(t)
+
+		Enqueue()
+		Enqueue()
+		assert.False(Active())
+		assert.False(RejectConnection())
+
+		_, drop := Drop(proprietary)
+		assert.False(drop)
+	})
+
+	t.Run("Active", func(t *testing.T) {
+		assert := require.New(t)
+
+		Enqueue()
+		assert.True(Active())
+		assert.True(RejectConnection())
+		assert.Equal("overloaded, retry after 1m0s", RetryAfterReason())
+
+		reason, drop := Drop(proprietary)
+		assert.True(drop)
+		assert.Equal(ReasonProprietary, reason)
+
+		reason, drop = Drop(lowSNR)
+		assert.True(drop)
+		assert.Equal(ReasonLowSNR, reason)
+
+		_, drop = Drop(data)
+		assert.False(drop)
+
+		// still active until the low watermark is reached
+		Dequeue()
+		assert.True(Active())
+	})
+
+	t.Run("Deactivated", func(t *testing.T) {
+		assert := require.New(t)
+
+		Dequeue()
+		assert.False(Active())
+
+		_, drop := Drop(lowSNR)
+		assert.False(drop)
+	})
+
+	t.Run("Invalid watermarks", func(t *testing.T) {
+		assert := require.New(t)
+
+		var conf config.Config
+		conf.Overload.Enabled = true
+		conf.Overload.HighWatermark = 1
+		conf.Overload.LowWatermark = 1
+		assert.Error(Setup(conf))
+	})
+}
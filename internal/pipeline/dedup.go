@@ -0,0 +1,52 @@
+package pipeline
+
+import (
+	"sync"
+	"time"
+
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+// dedupWindow defines the duration within which an uplink received again
+// from the same gateway is considered a duplicate.
+var dedupWindow time.Duration
+
+var dedup = uplinkDedup{seen: make(map[string]time.Time)}
+
+// uplinkDedup keeps track of the received uplinks. An uplink is identified
+// by the gateway ID, the uplink context (which contains the concentrator
+// timestamp) and the PHYPayload.
+type uplinkDedup struct {
+	sync.Mutex
+	seen    map[string]time.Time
+	cleaned time.Time
+}
+
+// duplicate returns true when the given uplink was already received within
+// the dedup window.
+func (d *uplinkDedup) duplicate(frame gw.UplinkFrame, now time.Time) bool {
+	if dedupWindow == 0 || len(frame.GetRxInfo().GetContext()) == 0 {
+		return false
+	}
+
+	key := string(frame.GetRxInfo().GetGatewayId()) + string(frame.GetRxInfo().GetContext()) + string(frame.PhyPayload)
+
+	d.Lock()
+	defer d.Unlock()
+
+	if now.Sub(d.cleaned) > dedupWindow {
+		for k, t := range d.seen {
+			if now.Sub(t) > dedupWindow {
+				delete(d.seen, k)
+			}
+		}
+		d.cleaned = now
+	}
+
+	if t, ok := d.seen[key]; ok && now.Sub(t) <= dedupWindow {
+		return true
+	}
+
+	d.seen[key] = now
+	return false
+}
@@ -0,0 +1,224 @@
+package pipeline
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/accounting"
+	"github.com/brocaar/lora-gateway-bridge/internal/allowlist"
+	"github.com/brocaar/lora-gateway-bridge/internal/antennagain"
+	"github.com/brocaar/lora-gateway-bridge/internal/antennamap"
+	"github.com/brocaar/lora-gateway-bridge/internal/archive"
+	"github.com/brocaar/lora-gateway-bridge/internal/configdrift"
+	"github.com/brocaar/lora-gateway-bridge/internal/debug"
+	"github.com/brocaar/lora-gateway-bridge/internal/filters"
+	"github.com/brocaar/lora-gateway-bridge/internal/frequencycheck"
+	"github.com/brocaar/lora-gateway-bridge/internal/gpstime"
+	"github.com/brocaar/lora-gateway-bridge/internal/locations"
+	"github.com/brocaar/lora-gateway-bridge/internal/metrics"
+	"github.com/brocaar/lora-gateway-bridge/internal/overlay"
+	"github.com/brocaar/lora-gateway-bridge/internal/privacy"
+	"github.com/brocaar/lora-gateway-bridge/internal/quarantine"
+	"github.com/brocaar/lora-gateway-bridge/internal/rxtime"
+	"github.com/brocaar/lora-gateway-bridge/internal/sampling"
+	"github.com/brocaar/lora-gateway-bridge/internal/statsdelta"
+	"github.com/brocaar/lora-gateway-bridge/internal/statshistory"
+	"github.com/brocaar/lora-gateway-bridge/internal/uplinkset"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+// builtin contains the middlewares implemented by the internal packages.
+var builtin = map[string]Middleware{
+	"debug":         debugMiddleware,
+	"quarantine":    quarantineMiddleware,
+	"allowlist":     allowlistMiddleware,
+	"filters":       filtersMiddleware,
+	"dedup":         dedupMiddleware,
+	"metrics":       metricsMiddleware,
+	"sampling":      samplingMiddleware,
+	"rate_limit":    rateLimitMiddleware,
+	"enrich":        enrichMiddleware,
+	"overlay":       overlayMiddleware,
+	"archive":       archiveMiddleware,
+	"privacy":       privacyMiddleware,
+	"uplink_set":    uplinkSetMiddleware,
+	"stats_history": statsHistoryMiddleware,
+}
+
+// debugMiddleware dumps the event when debugging is enabled for the gateway.
+func debugMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		debug.DumpFrame(e.GatewayID, e.Type, e.Message)
+		return next(e)
+	}
+}
+
+// quarantineMiddleware drops the events of quarantined gateways.
+func quarantineMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if quarantine.Drop(e.GatewayID, e.Type) {
+			logFields(e).Debug("gateway is quarantined, dropping event")
+			return nil
+		}
+		return next(e)
+	}
+}
+
+// allowlistMiddleware drops the events of gateways not in the allowlist.
+func allowlistMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if !allowlist.Allowed(e.GatewayID, e.Type) {
+			logFields(e).Debug("gateway is not allowed, dropping event")
+			return nil
+		}
+		return next(e)
+	}
+}
+
+// filtersMiddleware drops the uplinks not matching the configured filters.
+func filtersMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if frame, ok := e.Message.(*gw.UplinkFrame); ok && !filters.MatchFilters(frame.PhyPayload) {
+			logFields(e).Debug("uplink does not match filters, dropping uplink")
+			return nil
+		}
+		return next(e)
+	}
+}
+
+// dedupMiddleware drops the uplinks which are received more than once from
+// the same gateway.
+func dedupMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if frame, ok := e.Message.(*gw.UplinkFrame); ok && dedup.duplicate(*frame, time.Now()) {
+			logFields(e).Debug("duplicate uplink, dropping uplink")
+			return nil
+		}
+		return next(e)
+	}
+}
+
+// metricsMiddleware updates the uplink metrics, the frequency check, the
+// channel-plan drift detection and the GPS time.
+func metricsMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if frame, ok := e.Message.(*gw.UplinkFrame); ok {
+			frequencycheck.Uplink(e.GatewayID, frame.GetTxInfo().GetFrequency())
+			configdrift.Uplink(e.GatewayID, frame.GetTxInfo().GetFrequency())
+			gpstime.Uplink(*frame)
+			metrics.Uplink(*frame)
+		}
+		return next(e)
+	}
+}
+
+// samplingMiddleware drops the events which are sampled out.
+func samplingMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if !sampling.Publish(e.GatewayID, e.Type, e.Message) {
+			logFields(e).Debug("event is sampled out, dropping event")
+			return nil
+		}
+		return next(e)
+	}
+}
+
+// rateLimitMiddleware drops the uplinks exceeding the accounting quota.
+func rateLimitMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if frame, ok := e.Message.(*gw.UplinkFrame); ok && !accounting.Uplink(e.GatewayID, len(frame.PhyPayload)) {
+			logFields(e).Debug("uplink quota exceeded, dropping uplink")
+			return nil
+		}
+		return next(e)
+	}
+}
+
+// enrichMiddleware adds the gateway location, the rx time, the antenna
+// mapping and the antenna gain compensation, and normalizes the stats
+// counters.
+func enrichMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		switch v := e.Message.(type) {
+		case *gw.UplinkFrame:
+			locations.SetUplinkFrameLocation(v)
+			antennamap.ApplyToUplinkFrame(v)
+			antennagain.ApplyToUplinkFrame(v)
+
+			if err := rxtime.ApplyToUplinkFrame(v); err != nil {
+				logFields(e).WithError(err).Error("apply rx time error")
+			}
+		case *gw.GatewayStats:
+			statsdelta.Apply(v)
+			locations.SetGatewayStatsLocation(v)
+		}
+		return next(e)
+	}
+}
+
+// overlayMiddleware applies the configured field overrides.
+func overlayMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if err := overlay.Apply(e.GatewayID, e.Type, e.Message); err != nil {
+			logFields(e).WithError(err).Error("apply overlay error")
+		}
+		return next(e)
+	}
+}
+
+// archiveMiddleware stores the event in the archive.
+func archiveMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if err := archive.Store(e.GatewayID, e.Type, e.ID, e.Message); err != nil {
+			logFields(e).WithError(err).Error("archive event error")
+		}
+		return next(e)
+	}
+}
+
+// privacyMiddleware anonymizes the uplinks and stats.
+func privacyMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		switch v := e.Message.(type) {
+		case *gw.UplinkFrame:
+			if err := privacy.ApplyToUplinkFrame(v); err != nil {
+				return errors.Wrap(err, "anonymize uplink frame error")
+			}
+		case *gw.GatewayStats:
+			if err := privacy.ApplyToGatewayStats(v); err != nil {
+				return errors.Wrap(err, "anonymize gateway stats error")
+			}
+		}
+		return next(e)
+	}
+}
+
+// uplinkSetMiddleware adds the uplinks to the uplink sets (when enabled).
+func uplinkSetMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if frame, ok := e.Message.(*gw.UplinkFrame); ok {
+			uplinkset.Uplink(*frame)
+		}
+		return next(e)
+	}
+}
+
+// statsHistoryMiddleware adds the stats to the stats history (when enabled).
+func statsHistoryMiddleware(next Handler) Handler {
+	return func(e *Event) error {
+		if stats, ok := e.Message.(*gw.GatewayStats); ok {
+			statshistory.Stats(*stats)
+		}
+		return next(e)
+	}
+}
+
+func logFields(e *Event) *log.Entry {
+	return log.WithFields(log.Fields{
+		"gateway_id": e.GatewayID,
+		"event_type": e.Type,
+		"event_id":   e.ID,
+	})
+}
@@ -0,0 +1,146 @@
+// Package pipeline implements the middleware pipeline which the gateway
+// events pass before they are published by the integration. The middlewares
+// are executed in the configured order and can modify or drop the event.
+// Additional middlewares can be registered using Register, before Setup is
+// called.
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+// Event is a gateway event passing the pipeline.
+type Event struct {
+	// GatewayID of the gateway.
+	GatewayID lorawan.EUI64
+
+	// Type of the event (e.g. integration.EventUp).
+	Type string
+
+	// ID of the event (e.g. the uplink ID).
+	ID uuid.UUID
+
+	// Message holds the event payload (e.g. *gw.UplinkFrame).
+	Message proto.Message
+}
+
+// Handler handles the given event.
+type Handler func(e *Event) error
+
+// Middleware wraps the given (next) handler. A middleware drops the event
+// by not calling the next handler.
+type Middleware func(next Handler) Handler
+
+var (
+	mux         sync.RWMutex
+	middlewares = make(map[string]Middleware)
+	chain       []Middleware
+)
+
+func init() {
+	for name, m := range builtin {
+		middlewares[name] = m
+	}
+}
+
+// Register registers the middleware under the given name. The middleware
+// is used when its name is configured in the pipeline.
+func Register(name string, m Middleware) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if _, ok := middlewares[name]; ok {
+		return fmt.Errorf("middleware %s is already registered", name)
+	}
+
+	middlewares[name] = m
+	return nil
+}
+
+// Setup configures the pipeline.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	var c []Middleware
+	for _, name := range conf.Pipeline.Middlewares {
+		m, ok := middlewares[name]
+		if !ok {
+			return fmt.Errorf("unknown middleware: %s", name)
+		}
+		c = append(c, m)
+	}
+
+	chain = c
+	dedupWindow = conf.Pipeline.DedupWindow
+
+	log.WithFields(log.Fields{
+		"middlewares": strings.Join(conf.Pipeline.Middlewares, ", "),
+	}).Info("pipeline: middlewares configured")
+
+	for _, name := range missingMiddlewares(conf) {
+		log.WithField("middleware", name).Warning("pipeline: feature is enabled, but its middleware is not configured, the feature has no effect")
+	}
+
+	return nil
+}
+
+// missingMiddlewares returns the names of the middlewares which are not
+// configured, while the feature implemented by the middleware is enabled.
+func missingMiddlewares(conf config.Config) []string {
+	prom := conf.Metrics.Prometheus
+	enabled := map[string]bool{
+		"quarantine":    conf.Quarantine.Enabled,
+		"allowlist":     conf.Allowlist.Mode != "",
+		"filters":       len(conf.Filters.NetIDs) != 0 || len(conf.Filters.JoinEUIs) != 0,
+		"dedup":         conf.Pipeline.DedupWindow != 0,
+		"metrics":       prom.EndpointEnabled || prom.Push.Type != "" || conf.FrequencyCheck.Enabled || conf.ConfigDrift.Enabled || conf.GPSTime.Enabled,
+		"sampling":      len(conf.Sampling.Rules) != 0,
+		"rate_limit":    conf.Accounting.Enabled,
+		"enrich":        len(conf.Locations) != 0 || len(conf.AntennaMap.Gateways) != 0 || len(conf.AntennaGain.Gateways) != 0 || conf.RXTime.Source != "" || conf.GatewayStats.CounterMode != "",
+		"overlay":       len(conf.Overlay.Rules) != 0,
+		"archive":       conf.Archive.Enabled,
+		"stats_history": conf.StatsHistory.Enabled,
+		"privacy":       conf.Privacy.Enabled,
+		"uplink_set":    conf.UplinkSet.Enabled,
+	}
+
+	configured := make(map[string]bool)
+	for _, name := range conf.Pipeline.Middlewares {
+		configured[name] = true
+	}
+
+	var out []string
+	for name, ok := range enabled {
+		if ok && !configured[name] {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+
+	return out
+}
+
+// Chain returns the handler executing the configured middlewares, followed
+// by the given (final) handler.
+func Chain(final Handler) Handler {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	h := final
+	for i := len(chain) - 1; i >= 0; i-- {
+		h = chain[i](h)
+	}
+
+	return h
+}
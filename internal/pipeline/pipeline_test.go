@@ -0,0 +1,174 @@
+package pipeline
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestPipeline(t *testing.T) {
+	assert := require.New(t)
+
+	var calls []string
+	record := func(name string, drop bool) Middleware {
+		return func(next Handler) Handler {
+			return func(e *Event) error {
+				calls = append(calls, name)
+				if drop {
+					return nil
+				}
+				return next(e)
+			}
+		}
+	}
+
+	assert.NoError(Register("test_a", record("a", false)))
+	assert.NoError(Register("test_b", record("b", false)))
+	assert.NoError(Register("test_drop", record("drop", true)))
+	assert.Error(Register("test_a", record("a", false)))
+	assert.Error(Register("debug", record("debug", false)))
+
+	final := func(e *Event) error {
+		calls = append(calls, "final")
+		return nil
+	}
+
+	tests := []struct {
+		Name        string
+		Middlewares []string
+		Calls       []string
+		Error       bool
+	}{
+		{
+			Name:  "no middlewares",
+			Calls: []string{"final"},
+		},
+		{
+			Name:        "configured order",
+			Middlewares: []string{"test_b", "test_a"},
+			Calls:       []string{"b", "a", "final"},
+		},
+		{
+			Name:        "dropped event",
+			Middlewares: []string{"test_a", "test_drop", "test_b"},
+			Calls:       []string{"a", "drop"},
+		},
+		{
+			Name:        "unknown middleware",
+			Middlewares: []string{"test_a", "foo"},
+			Error:       true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Pipeline.Middlewares = tst.Middlewares
+
+			err := Setup(conf)
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+
+			calls = nil
+			assert.NoError(Chain(final)(&Event{}))
+			assert.Equal(tst.Calls, calls)
+		})
+	}
+}
+
+func TestMissingMiddlewares(t *testing.T) {
+	defaults := []string{"debug", "quarantine", "allowlist", "filters", "dedup", "metrics", "sampling", "rate_limit", "enrich", "overlay", "archive", "stats_history", "privacy", "uplink_set"}
+
+	tests := []struct {
+		Name        string
+		Middlewares []string
+		Enable      bool
+		Expected    []string
+	}{
+		{
+			Name:        "features disabled",
+			Middlewares: []string{"debug"},
+		},
+		{
+			Name:        "features enabled, default middlewares",
+			Middlewares: defaults,
+			Enable:      true,
+		},
+		{
+			Name:        "features enabled, middlewares removed",
+			Middlewares: []string{"debug", "allowlist", "overlay", "enrich", "metrics"},
+			Enable:      true,
+			Expected:    []string{"archive", "dedup", "filters", "privacy", "quarantine", "rate_limit", "sampling", "stats_history", "uplink_set"},
+		},
+		{
+			Name:     "features enabled, no middlewares",
+			Enable:   true,
+			Expected: []string{"allowlist", "archive", "dedup", "enrich", "filters", "metrics", "overlay", "privacy", "quarantine", "rate_limit", "sampling", "stats_history", "uplink_set"},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Pipeline.Middlewares = tst.Middlewares
+			if tst.Enable {
+				conf.UplinkSet.Enabled = true
+				conf.Allowlist.Mode = "learning"
+				conf.StatsHistory.Enabled = true
+				conf.Overlay.Rules = []config.OverlayRule{{}}
+				conf.Sampling.Rules = []config.SamplingRule{{}}
+				conf.Quarantine.Enabled = true
+				conf.Filters.NetIDs = []string{"010203"}
+				conf.Pipeline.DedupWindow = time.Second
+				conf.Metrics.Prometheus.EndpointEnabled = true
+				conf.Accounting.Enabled = true
+				conf.RXTime.Source = "system"
+				conf.Archive.Enabled = true
+				conf.Privacy.Enabled = true
+			}
+
+			assert.Equal(tst.Expected, missingMiddlewares(conf))
+		})
+	}
+}
+
+func TestDedup(t *testing.T) {
+	assert := require.New(t)
+
+	dedupWindow = 10 * time.Second
+	d := uplinkDedup{seen: make(map[string]time.Time)}
+	now := time.Now()
+
+	frame := gw.UplinkFrame{
+		PhyPayload: []byte{1, 2, 3},
+		RxInfo: &gw.UplinkRXInfo{
+			GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Context:   []byte{1, 2, 3, 4},
+		},
+	}
+
+	otherContext := frame
+	otherContext.RxInfo = &gw.UplinkRXInfo{
+		GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		Context:   []byte{1, 2, 3, 5},
+	}
+
+	assert.False(d.duplicate(frame, now))
+	assert.True(d.duplicate(frame, now.Add(time.Second)))
+	assert.False(d.duplicate(otherContext, now.Add(time.Second)))
+	assert.False(d.duplicate(frame, now.Add(11*time.Second)))
+
+	dedupWindow = 0
+	assert.False(d.duplicate(frame, now.Add(12*time.Second)))
+}
@@ -0,0 +1,17 @@
+package pktfwdlog
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	lc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "packet_forwarder_log_line_count",
+		Help: "The number of warning and error lines logged by the packet-forwarder (per level).",
+	}, []string{"level"})
+)
+
+func lineCounter(level string) prometheus.Counter {
+	return lc.With(prometheus.Labels{"level": level})
+}
@@ -0,0 +1,282 @@
+// Package pktfwdlog implements the ingestion of the packet-forwarder log file,
+// for when the LoRa Gateway Bridge runs on the gateway. The warning and error
+// lines are published as notify event, the known concentrator and
+// packet-forwarder errors are classified using a code, so that these can be
+// troubleshooted remotely.
+package pktfwdlog
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lorawan"
+)
+
+// Levels.
+const (
+	LevelWarning = "warning"
+	LevelError   = "error"
+)
+
+// codes contains the codes of the known concentrator and packet-forwarder
+// errors, by the (case-insensitive) text identifying the error.
+var codes = []struct {
+	text string
+	code string
+}{
+	{"concentrator unconnected", "CONCENTRATOR_UNCONNECTED"},
+	{"failed to start the concentrator", "CONCENTRATOR_START_FAILED"},
+	{"failed packet fetch", "PACKET_FETCH_FAILED"},
+	{"lgw_send failed", "SEND_FAILED"},
+	{"gps out of sync", "GPS_OUT_OF_SYNC"},
+}
+
+var (
+	// levelRegexp matches the level and the message of a log line. The line
+	// may be prefixed, e.g. by the syslog timestamp and tag.
+	levelRegexp = regexp.MustCompile(`\b(ERROR|WARNING)\b:?\s*(.*)$`)
+
+	// gatewayIDRegexp matches the log line containing the gateway ID.
+	gatewayIDRegexp = regexp.MustCompile(`gateway MAC address is configured to ([0-9A-Fa-f]{16})`)
+)
+
+// line contains a warning or error line.
+type line struct {
+	level   string
+	code    string
+	message string
+}
+
+// suppression contains the time of the last published line and the number
+// of identical lines suppressed since.
+type suppression struct {
+	published time.Time
+	count     int
+}
+
+// maxSuppressions defines the max. number of distinct lines for which the
+// suppression is tracked.
+const maxSuppressions = 1000
+
+var (
+	mux sync.Mutex
+
+	gatewayID           lorawan.EUI64
+	gatewayIDSet        bool
+	gatewayIDConfigured bool
+	suppressInterval    time.Duration
+	suppressions        = make(map[line]*suppression)
+	suppressionsClean   time.Time
+)
+
+// Setup configures the packet-forwarder log ingestion.
+func Setup(conf config.Config) error {
+	if conf.PacketForwarderLog.Path == "" {
+		return nil
+	}
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if conf.PacketForwarderLog.GatewayID != "" {
+		if err := gatewayID.UnmarshalText([]byte(conf.PacketForwarderLog.GatewayID)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+		gatewayIDSet = true
+		gatewayIDConfigured = true
+	}
+
+	suppressInterval = conf.PacketForwarderLog.SuppressInterval
+
+	log.WithFields(log.Fields{
+		"path":       conf.PacketForwarderLog.Path,
+		"gateway_id": conf.PacketForwarderLog.GatewayID,
+	}).Info("pktfwdlog: starting packet-forwarder log ingestion")
+
+	go tailLoop(newTailer(conf.PacketForwarderLog.Path), conf.PacketForwarderLog.PollInterval)
+
+	return nil
+}
+
+func tailLoop(t *tailer, interval time.Duration) {
+	for {
+		lines, err := t.lines()
+		if err != nil {
+			log.WithError(err).Error("pktfwdlog: read packet-forwarder log error")
+		}
+
+		for _, s := range lines {
+			handleLine(s, time.Now())
+		}
+
+		time.Sleep(interval)
+	}
+}
+
+// handleLine handles a single line of the packet-forwarder log.
+func handleLine(s string, now time.Time) {
+	if m := gatewayIDRegexp.FindStringSubmatch(s); m != nil {
+		setGatewayID(m[1])
+		return
+	}
+
+	l, ok := parseLine(s)
+	if !ok {
+		return
+	}
+
+	lineCounter(l.level).Inc()
+
+	id, ok := getGatewayID()
+	if !ok {
+		log.WithFields(log.Fields{
+			"level":   l.level,
+			"message": l.message,
+		}).Warning("pktfwdlog: gateway id is unknown, configure the gateway_id")
+		return
+	}
+
+	suppressed, ok := suppress(l, now)
+	if !ok {
+		return
+	}
+
+	log.WithFields(log.Fields{
+		"gateway_id": id,
+		"level":      l.level,
+		"code":       l.code,
+		"message":    l.message,
+	}).Info("pktfwdlog: packet-forwarder logged warning or error")
+
+	if err := publishNotify(id, l, suppressed, now); err != nil {
+		log.WithError(err).WithField("gateway_id", id).Error("pktfwdlog: publish notify event error")
+	}
+}
+
+// parseLine returns the warning or error of the given log line. It returns
+// false for the other lines.
+func parseLine(s string) (line, bool) {
+	m := levelRegexp.FindStringSubmatch(s)
+	if m == nil {
+		return line{}, false
+	}
+
+	l := line{
+		level:   LevelWarning,
+		message: strings.TrimSpace(m[2]),
+	}
+	if m[1] == "ERROR" {
+		l.level = LevelError
+	}
+
+	lower := strings.ToLower(l.message)
+	for _, c := range codes {
+		if strings.Contains(lower, c.text) {
+			l.code = c.code
+			break
+		}
+	}
+
+	return l, true
+}
+
+// suppress returns false when an identical line was published within the
+// suppress interval. Else, it returns the number of identical lines that
+// were suppressed since the previous event.
+func suppress(l line, now time.Time) (int, bool) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if now.Sub(suppressionsClean) > suppressInterval {
+		for k, v := range suppressions {
+			if now.Sub(v.published) > suppressInterval && v.count == 0 {
+				delete(suppressions, k)
+			}
+		}
+		suppressionsClean = now
+	}
+
+	// lines containing variable data (e.g. counters) are never identical
+	if len(suppressions) >= maxSuppressions {
+		suppressions = make(map[line]*suppression)
+	}
+
+	s, ok := suppressions[l]
+	if !ok {
+		suppressions[l] = &suppression{published: now}
+		return 0, true
+	}
+
+	if now.Sub(s.published) < suppressInterval {
+		s.count++
+		return 0, false
+	}
+
+	count := s.count
+	s.published = now
+	s.count = 0
+
+	return count, true
+}
+
+func setGatewayID(s string) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	// the configured gateway ID takes precedence
+	if gatewayIDConfigured {
+		return
+	}
+
+	var id lorawan.EUI64
+	if err := id.UnmarshalText([]byte(s)); err != nil {
+		log.WithError(err).Error("pktfwdlog: unmarshal gateway id error")
+		return
+	}
+
+	gatewayID = id
+	gatewayIDSet = true
+
+	log.WithField("gateway_id", id).Info("pktfwdlog: gateway id read from packet-forwarder log")
+}
+
+func getGatewayID() (lorawan.EUI64, bool) {
+	mux.Lock()
+	defer mux.Unlock()
+	return gatewayID, gatewayIDSet
+}
+
+func publishNotify(id lorawan.EUI64, l line, suppressed int, now time.Time) error {
+	i := integration.GetIntegration()
+	if i == nil {
+		return nil
+	}
+
+	eventID, err := uuid.NewV4()
+	if err != nil {
+		return errors.Wrap(err, "new uuid error")
+	}
+
+	ts, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return errors.Wrap(err, "timestamp proto error")
+	}
+
+	return i.PublishEvent(id, integration.EventNotify, eventID, &integration.Notify{
+		GatewayId:       id[:],
+		Level:           l.level,
+		Code:            l.code,
+		Message:         l.message,
+		Time:            ts,
+		SuppressedCount: uint32(suppressed),
+	})
+}
@@ -0,0 +1,130 @@
+package pktfwdlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Line     string
+		Expected *line
+	}{
+		{
+			Name: "info line",
+			Line: "INFO: [up] PUSH_DATA acknowledged in 42.0 ms",
+		},
+		{
+			Name: "warning",
+			Line: "WARNING: [gps] GPS out of sync, keeping previous time reference",
+			Expected: &line{
+				level:   LevelWarning,
+				code:    "GPS_OUT_OF_SYNC",
+				message: "[gps] GPS out of sync, keeping previous time reference",
+			},
+		},
+		{
+			Name: "concentrator unconnected",
+			Line: "ERROR: CONCENTRATOR UNCONNECTED",
+			Expected: &line{
+				level:   LevelError,
+				code:    "CONCENTRATOR_UNCONNECTED",
+				message: "CONCENTRATOR UNCONNECTED",
+			},
+		},
+		{
+			Name: "syslog prefix",
+			Line: "Oct  1 12:00:00 gateway lora_pkt_fwd[123]: ERROR: [main] failed to start the concentrator",
+			Expected: &line{
+				level:   LevelError,
+				code:    "CONCENTRATOR_START_FAILED",
+				message: "[main] failed to start the concentrator",
+			},
+		},
+		{
+			Name: "unclassified error",
+			Line: "ERROR: [down] invalid JSON, TX aborted",
+			Expected: &line{
+				level:   LevelError,
+				message: "[down] invalid JSON, TX aborted",
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			l, ok := parseLine(tst.Line)
+			if tst.Expected == nil {
+				assert.False(ok)
+				return
+			}
+
+			assert.True(ok)
+			assert.Equal(*tst.Expected, l)
+		})
+	}
+}
+
+func TestSuppress(t *testing.T) {
+	assert := require.New(t)
+
+	suppressInterval = time.Minute
+	suppressions = make(map[line]*suppression)
+
+	now := time.Now()
+	l1 := line{level: LevelError, message: "foo"}
+	l2 := line{level: LevelError, message: "bar"}
+
+	count, ok := suppress(l1, now)
+	assert.True(ok)
+	assert.Equal(0, count)
+
+	// identical lines within the suppress interval are suppressed
+	for i := 0; i < 3; i++ {
+		_, ok = suppress(l1, now.Add(time.Second))
+		assert.False(ok)
+	}
+
+	// other lines are not affected
+	_, ok = suppress(l2, now.Add(time.Second))
+	assert.True(ok)
+
+	count, ok = suppress(l1, now.Add(time.Minute))
+	assert.True(ok)
+	assert.Equal(3, count)
+
+	count, ok = suppress(l1, now.Add(2*time.Minute))
+	assert.True(ok)
+	assert.Equal(0, count)
+}
+
+func TestGatewayID(t *testing.T) {
+	assert := require.New(t)
+
+	gatewayIDSet = false
+	gatewayIDConfigured = false
+
+	_, ok := getGatewayID()
+	assert.False(ok)
+
+	handleLine("INFO: gateway MAC address is configured to AA555A0000000101", time.Now())
+	id, ok := getGatewayID()
+	assert.True(ok)
+	assert.Equal(lorawan.EUI64{0xaa, 0x55, 0x5a, 0x00, 0x00, 0x00, 0x01, 0x01}, id)
+
+	// the configured gateway id takes precedence
+	gatewayID = lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	gatewayIDConfigured = true
+
+	handleLine("INFO: gateway MAC address is configured to AA555A0000000101", time.Now())
+	id, ok = getGatewayID()
+	assert.True(ok)
+	assert.Equal(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, id)
+}
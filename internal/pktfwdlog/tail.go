@@ -0,0 +1,147 @@
+package pktfwdlog
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// tailer reads the lines appended to a log file. It handles the truncation
+// and the rotation of the log file.
+type tailer struct {
+	path string
+
+	file   *os.File
+	reader *bufio.Reader
+	offset int64
+
+	// partial contains the last line when it was not yet terminated by a
+	// newline.
+	partial string
+
+	// opened is set after the file has been opened for the first time. The
+	// lines written before the file was opened for the first time are
+	// skipped, a rotated file is read from the start.
+	opened bool
+}
+
+func newTailer(path string) *tailer {
+	return &tailer{path: path}
+}
+
+// lines returns the lines appended to the log file since the previous call.
+// It returns no lines when the log file does not exist (yet).
+func (t *tailer) lines() ([]string, error) {
+	if t.file == nil {
+		if err := t.open(); err != nil {
+			if os.IsNotExist(errors.Cause(err)) {
+				return nil, nil
+			}
+			return nil, err
+		}
+	}
+
+	out, err := t.read()
+	if err != nil {
+		return nil, err
+	}
+
+	fi, err := os.Stat(t.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return out, nil
+		}
+		return out, errors.Wrap(err, "stat log file error")
+	}
+
+	cur, err := t.file.Stat()
+	if err != nil {
+		return out, errors.Wrap(err, "stat log file error")
+	}
+
+	switch {
+	case !os.SameFile(fi, cur):
+		// the log file has been rotated, the remaining lines of the old
+		// file have been read above
+		t.close()
+		if err := t.open(); err != nil {
+			return out, err
+		}
+
+		lines, err := t.read()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, lines...)
+	case fi.Size() < t.offset:
+		// the log file has been truncated
+		if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+			return out, errors.Wrap(err, "seek log file error")
+		}
+		t.reader.Reset(t.file)
+		t.offset = 0
+		t.partial = ""
+
+		lines, err := t.read()
+		if err != nil {
+			return out, err
+		}
+		out = append(out, lines...)
+	}
+
+	return out, nil
+}
+
+func (t *tailer) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return errors.Wrap(err, "open log file error")
+	}
+
+	var offset int64
+	if !t.opened {
+		offset, err = f.Seek(0, io.SeekEnd)
+		if err != nil {
+			f.Close()
+			return errors.Wrap(err, "seek log file error")
+		}
+	}
+
+	t.file = f
+	t.reader = bufio.NewReader(f)
+	t.offset = offset
+	t.partial = ""
+	t.opened = true
+
+	return nil
+}
+
+func (t *tailer) read() ([]string, error) {
+	var out []string
+
+	for {
+		s, err := t.reader.ReadString('\n')
+		t.offset += int64(len(s))
+
+		if err == io.EOF {
+			t.partial += s
+			return out, nil
+		}
+		if err != nil {
+			return out, errors.Wrap(err, "read log file error")
+		}
+
+		out = append(out, strings.TrimRight(t.partial+s, "\r\n"))
+		t.partial = ""
+	}
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
@@ -0,0 +1,92 @@
+package pktfwdlog
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTailer(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "pktfwdlog")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "pkt-fwd.log")
+	tl := newTailer(path)
+	defer tl.close()
+
+	appendLines := func(s string) {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		assert.NoError(err)
+		_, err = f.WriteString(s)
+		assert.NoError(err)
+		assert.NoError(f.Close())
+	}
+
+	t.Run("file does not exist", func(t *testing.T) {
+		assert := require.New(t)
+
+		lines, err := tl.lines()
+		assert.NoError(err)
+		assert.Len(lines, 0)
+	})
+
+	t.Run("existing lines are skipped", func(t *testing.T) {
+		assert := require.New(t)
+
+		appendLines("line 1\n")
+
+		lines, err := tl.lines()
+		assert.NoError(err)
+		assert.Len(lines, 0)
+	})
+
+	t.Run("appended lines", func(t *testing.T) {
+		assert := require.New(t)
+
+		appendLines("line 2\nline 3\r\nline ")
+
+		lines, err := tl.lines()
+		assert.NoError(err)
+		assert.Equal([]string{"line 2", "line 3"}, lines)
+
+		appendLines("4\n")
+
+		lines, err = tl.lines()
+		assert.NoError(err)
+		assert.Equal([]string{"line 4"}, lines)
+	})
+
+	t.Run("truncated", func(t *testing.T) {
+		assert := require.New(t)
+
+		assert.NoError(ioutil.WriteFile(path, []byte("line 5\n"), 0644))
+
+		lines, err := tl.lines()
+		assert.NoError(err)
+		assert.Equal([]string{"line 5"}, lines)
+	})
+
+	t.Run("rotated", func(t *testing.T) {
+		assert := require.New(t)
+
+		appendLines("line 6\n")
+		assert.NoError(os.Rename(path, path+".1"))
+		appendLines("line 7\n")
+
+		lines, err := tl.lines()
+		assert.NoError(err)
+		assert.Equal([]string{"line 6", "line 7"}, lines)
+
+		appendLines("line 8\n")
+
+		lines, err = tl.lines()
+		assert.NoError(err)
+		assert.Equal([]string{"line 8"}, lines)
+	})
+}
@@ -0,0 +1,176 @@
+// Package privacy implements the anonymization of the gateway meta-data
+// (gateway IP, location and fine timestamps) of the published events.
+package privacy
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	mux sync.RWMutex
+
+	all                bool
+	gateways           map[lorawan.EUI64]struct{}
+	stripIP            bool
+	stripLocation      bool
+	locationDecimals   int
+	stripFineTimestamp bool
+	timeTruncate       time.Duration
+)
+
+// Setup configures the privacy package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	all = conf.Privacy.Enabled
+	gateways = make(map[lorawan.EUI64]struct{})
+	stripIP = conf.Privacy.StripIP
+	stripLocation = conf.Privacy.StripLocation
+	locationDecimals = conf.Privacy.LocationDecimals
+	stripFineTimestamp = conf.Privacy.StripFineTimestamp
+	timeTruncate = conf.Privacy.TimeTruncate
+
+	for _, idStr := range conf.Privacy.GatewayIDs {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(idStr)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+		gateways[gatewayID] = struct{}{}
+	}
+
+	if all || len(gateways) != 0 {
+		log.WithFields(log.Fields{
+			"all_gateways":         all,
+			"gateways":             len(gateways),
+			"strip_ip":             stripIP,
+			"strip_location":       stripLocation,
+			"location_decimals":    locationDecimals,
+			"strip_fine_timestamp": stripFineTimestamp,
+			"time_truncate":        timeTruncate,
+		}).Info("privacy: meta-data anonymization configured")
+	}
+
+	return nil
+}
+
+// ApplyToGatewayStats anonymizes the given gateway stats, when enabled for
+// the gateway.
+func ApplyToGatewayStats(stats *gw.GatewayStats) error {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], stats.GetGatewayId())
+
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if !enabled(gatewayID) {
+		return nil
+	}
+
+	if stripIP {
+		stats.Ip = ""
+	}
+
+	stats.Location = anonymizeLocation(stats.Location)
+
+	if stats.Time != nil && timeTruncate > 0 {
+		t, err := truncateTimestamp(stats.Time)
+		if err != nil {
+			return errors.Wrap(err, "truncate time error")
+		}
+		stats.Time = t
+	}
+
+	return nil
+}
+
+// ApplyToUplinkFrame anonymizes the rx-info of the given uplink frame, when
+// enabled for the gateway.
+func ApplyToUplinkFrame(frame *gw.UplinkFrame) error {
+	if frame.RxInfo == nil {
+		return nil
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.RxInfo.GetGatewayId())
+
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if !enabled(gatewayID) {
+		return nil
+	}
+
+	rxInfo := frame.RxInfo
+	rxInfo.Location = anonymizeLocation(rxInfo.Location)
+
+	if stripFineTimestamp {
+		rxInfo.FineTimestampType = gw.FineTimestampType_NONE
+		rxInfo.FineTimestamp = nil
+	}
+
+	if timeTruncate > 0 {
+		if rxInfo.Time != nil {
+			t, err := truncateTimestamp(rxInfo.Time)
+			if err != nil {
+				return errors.Wrap(err, "truncate time error")
+			}
+			rxInfo.Time = t
+		}
+
+		if rxInfo.TimeSinceGpsEpoch != nil {
+			d, err := ptypes.Duration(rxInfo.TimeSinceGpsEpoch)
+			if err != nil {
+				return errors.Wrap(err, "get time since gps epoch error")
+			}
+			rxInfo.TimeSinceGpsEpoch = ptypes.DurationProto(d.Truncate(timeTruncate))
+		}
+	}
+
+	return nil
+}
+
+// enabled returns if anonymization is enabled for the given gateway. Note
+// that the caller must hold the lock.
+func enabled(gatewayID lorawan.EUI64) bool {
+	if all {
+		return true
+	}
+	_, ok := gateways[gatewayID]
+	return ok
+}
+
+func anonymizeLocation(loc *common.Location) *common.Location {
+	if loc == nil || stripLocation {
+		return nil
+	}
+
+	p := math.Pow(10, float64(locationDecimals))
+	return &common.Location{
+		Latitude:  math.Round(loc.Latitude*p) / p,
+		Longitude: math.Round(loc.Longitude*p) / p,
+		Altitude:  math.Round(loc.Altitude),
+		Source:    loc.Source,
+	}
+}
+
+func truncateTimestamp(ts *timestamp.Timestamp) (*timestamp.Timestamp, error) {
+	t, err := ptypes.Timestamp(ts)
+	if err != nil {
+		return nil, err
+	}
+
+	return ptypes.TimestampProto(t.Truncate(timeTruncate))
+}
@@ -0,0 +1,129 @@
+package privacy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestPrivacy(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Privacy.GatewayIDs = []string{"0102030405060708"}
+	conf.Privacy.StripIP = true
+	conf.Privacy.LocationDecimals = 2
+	conf.Privacy.StripFineTimestamp = true
+	conf.Privacy.TimeTruncate = time.Second
+	assert.NoError(Setup(conf))
+
+	ts, err := ptypes.TimestampProto(time.Date(2019, 1, 1, 12, 0, 0, 123456789, time.UTC))
+	assert.NoError(err)
+	tsTruncated, err := ptypes.TimestampProto(time.Date(2019, 1, 1, 12, 0, 0, 0, time.UTC))
+	assert.NoError(err)
+
+	location := common.Location{
+		Latitude:  52.123456,
+		Longitude: 4.987654,
+		Altitude:  10.4,
+		Source:    common.LocationSource_GPS,
+	}
+	locationAnonymized := common.Location{
+		Latitude:  52.12,
+		Longitude: 4.99,
+		Altitude:  10,
+		Source:    common.LocationSource_GPS,
+	}
+
+	t.Run("GatewayStats", func(t *testing.T) {
+		tests := []struct {
+			Name     string
+			In       gw.GatewayStats
+			Expected gw.GatewayStats
+		}{
+			{
+				Name: "anonymized",
+				In: gw.GatewayStats{
+					GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+					Ip:        "192.168.1.1",
+					Time:      ts,
+					Location:  &location,
+				},
+				Expected: gw.GatewayStats{
+					GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+					Time:      tsTruncated,
+					Location:  &locationAnonymized,
+				},
+			},
+			{
+				Name: "not enabled for gateway",
+				In: gw.GatewayStats{
+					GatewayId: []byte{8, 7, 6, 5, 4, 3, 2, 1},
+					Ip:        "192.168.1.1",
+					Time:      ts,
+					Location:  &location,
+				},
+				Expected: gw.GatewayStats{
+					GatewayId: []byte{8, 7, 6, 5, 4, 3, 2, 1},
+					Ip:        "192.168.1.1",
+					Time:      ts,
+					Location:  &location,
+				},
+			},
+		}
+
+		for _, tst := range tests {
+			t.Run(tst.Name, func(t *testing.T) {
+				assert := require.New(t)
+				assert.NoError(ApplyToGatewayStats(&tst.In))
+				assert.Equal(tst.Expected, tst.In)
+			})
+		}
+	})
+
+	t.Run("UplinkFrame", func(t *testing.T) {
+		assert := require.New(t)
+
+		frame := gw.UplinkFrame{
+			RxInfo: &gw.UplinkRXInfo{
+				GatewayId:         []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				Time:              ts,
+				TimeSinceGpsEpoch: ptypes.DurationProto(1500 * time.Millisecond),
+				Location:          &location,
+				FineTimestampType: gw.FineTimestampType_PLAIN,
+				FineTimestamp: &gw.UplinkRXInfo_PlainFineTimestamp{
+					PlainFineTimestamp: &gw.PlainFineTimestamp{
+						Time: ts,
+					},
+				},
+			},
+		}
+		assert.NoError(ApplyToUplinkFrame(&frame))
+		assert.Equal(&gw.UplinkRXInfo{
+			GatewayId:         []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Time:              tsTruncated,
+			TimeSinceGpsEpoch: ptypes.DurationProto(time.Second),
+			Location:          &locationAnonymized,
+		}, frame.RxInfo)
+	})
+
+	t.Run("Strip location", func(t *testing.T) {
+		assert := require.New(t)
+
+		conf.Privacy.StripLocation = true
+		assert.NoError(Setup(conf))
+
+		stats := gw.GatewayStats{
+			GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Location:  &location,
+		}
+		assert.NoError(ApplyToGatewayStats(&stats))
+		assert.Nil(stats.Location)
+	})
+}
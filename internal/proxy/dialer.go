@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/base64"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/pkg/errors"
+	netproxy "golang.org/x/net/proxy"
+)
+
+// connectDialer implements the golang.org/x/net/proxy Dialer interface for
+// HTTP(S) proxies, using the HTTP CONNECT method.
+type connectDialer struct {
+	proxyURL *url.URL
+	forward  netproxy.Dialer
+}
+
+func newConnectDialer(u *url.URL, forward netproxy.Dialer) (netproxy.Dialer, error) {
+	return &connectDialer{
+		proxyURL: u,
+		forward:  forward,
+	}, nil
+}
+
+// Dial connects to the given address through the proxy.
+func (d *connectDialer) Dial(network, addr string) (net.Conn, error) {
+	conn, err := d.forward.Dial("tcp", d.proxyURL.Host)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial proxy error")
+	}
+
+	if d.proxyURL.Scheme == "https" {
+		conn = tls.Client(conn, &tls.Config{
+			ServerName: d.proxyURL.Hostname(),
+		})
+	}
+
+	req := http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+
+	if u := d.proxyURL.User; u != nil {
+		password, _ := u.Password()
+		req.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(u.Username()+":"+password)))
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "write connect request error")
+	}
+
+	r := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(r, &req)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrap(err, "read connect response error")
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, errors.Errorf("proxy connect error: %s", resp.Status)
+	}
+
+	// the reader might contain data which was sent directly after the
+	// connect response
+	if r.Buffered() != 0 {
+		return &bufferedConn{Conn: conn, r: r}, nil
+	}
+
+	return conn, nil
+}
+
+// bufferedConn is a net.Conn which reads the buffered data before reading
+// from the underlying connection.
+type bufferedConn struct {
+	net.Conn
+	r *bufio.Reader
+}
+
+func (c *bufferedConn) Read(b []byte) (int, error) {
+	return c.r.Read(b)
+}
@@ -0,0 +1,200 @@
+// Package proxy implements the (HTTP, HTTPS or SOCKS5) proxy used for the
+// outbound connections of the LoRa Gateway Bridge: the MQTT integration and
+// the HTTP clients (e.g. webhooks, meta-data and uploads).
+package proxy
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	netproxy "golang.org/x/net/proxy"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+// defaultPorts contains the default port per proxy scheme.
+var defaultPorts = map[string]string{
+	"http":   "80",
+	"https":  "443",
+	"socks5": "1080",
+}
+
+var (
+	mux sync.RWMutex
+
+	proxyURL *url.URL
+	noProxy  []string
+
+	// transport is the HTTP transport shared by the HTTP clients.
+	transport = &http.Transport{
+		Proxy: proxyFunc,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			DualStack: true,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+)
+
+func init() {
+	netproxy.RegisterDialerType("http", newConnectDialer)
+	netproxy.RegisterDialerType("https", newConnectDialer)
+}
+
+// Setup configures the proxy.
+func Setup(conf config.Config) error {
+	if conf.Proxy.URL == "" {
+		return nil
+	}
+
+	u, err := parseURL(conf.Proxy.URL, conf.Proxy.Username, conf.Proxy.Password)
+	if err != nil {
+		return errors.Wrap(err, "parse proxy url error")
+	}
+
+	mux.Lock()
+	proxyURL = u
+	noProxy = conf.Proxy.NoProxy
+	mux.Unlock()
+
+	// The MQTT client only supports a proxy configured by the all_proxy
+	// environment variable (resolved using golang.org/x/net/proxy).
+	for k, v := range map[string]string{
+		"all_proxy": u.String(),
+		"no_proxy":  strings.Join(conf.Proxy.NoProxy, ","),
+	} {
+		for _, key := range []string{k, strings.ToUpper(k)} {
+			if err := os.Setenv(key, v); err != nil {
+				return errors.Wrapf(err, "set %s environment variable error", key)
+			}
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"proxy":    redacted(u),
+		"no_proxy": conf.Proxy.NoProxy,
+	}).Info("proxy: outbound connections are using proxy")
+
+	return nil
+}
+
+// Transport returns the HTTP transport which must be used by the HTTP
+// clients. When no proxy is configured, the proxy configured by the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables is used.
+func Transport() http.RoundTripper {
+	return transport
+}
+
+// parseURL parses the proxy URL. The given username and password take
+// precedence over the credentials of the URL.
+func parseURL(s, username, password string) (*url.URL, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+
+	port, ok := defaultPorts[u.Scheme]
+	if !ok {
+		return nil, errors.Errorf("unsupported proxy scheme: %s (expected http, https or socks5)", u.Scheme)
+	}
+
+	if u.Hostname() == "" {
+		return nil, errors.New("proxy host must be set")
+	}
+
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), port)
+	}
+
+	if username != "" {
+		u.User = url.UserPassword(username, password)
+	}
+
+	return u, nil
+}
+
+// redacted returns the given URL with the password replaced, for logging.
+func redacted(u *url.URL) string {
+	if u.User == nil {
+		return u.String()
+	}
+
+	if _, ok := u.User.Password(); !ok {
+		return u.String()
+	}
+
+	r := *u
+	r.User = url.UserPassword(u.User.Username(), "xxxxx")
+	return r.String()
+}
+
+// proxyFunc returns the proxy URL for the given request.
+func proxyFunc(req *http.Request) (*url.URL, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if proxyURL == nil {
+		return http.ProxyFromEnvironment(req)
+	}
+
+	if bypass(req.URL.Hostname()) {
+		return nil, nil
+	}
+
+	return proxyURL, nil
+}
+
+// bypass returns true when the given host matches one of the no_proxy
+// entries. An entry is a hostname, an IP address, a CIDR or a zone (e.g.
+// .example.com or *.example.com, matching the domain and its sub-domains).
+// These are matched like the MQTT client matches the no_proxy entries.
+func bypass(host string) bool {
+	host = strings.ToLower(host)
+	ip := net.ParseIP(host)
+
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+
+		if _, ipNet, err := net.ParseCIDR(entry); err == nil {
+			if ip != nil && ipNet.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if entryIP := net.ParseIP(entry); entryIP != nil {
+			if ip != nil && entryIP.Equal(ip) {
+				return true
+			}
+			continue
+		}
+
+		if strings.HasPrefix(entry, "*.") || strings.HasPrefix(entry, ".") {
+			zone := strings.TrimPrefix(entry, "*")
+			if strings.HasSuffix(host, zone) || host == zone[1:] {
+				return true
+			}
+			continue
+		}
+
+		if host == entry {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,37 @@
+package quarantine
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	ec = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quarantine_error_count",
+		Help: "The number of malformed packets or conversion errors (per gateway).",
+	}, []string{"gateway_id"})
+
+	qc = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "quarantine_count",
+		Help: "The number of times a gateway was quarantined.",
+	})
+
+	dc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "quarantine_dropped_count",
+		Help: "The number of events dropped because the gateway was quarantined (per event type).",
+	}, []string{"type"})
+)
+
+func errorCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return ec.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
+
+func quarantineCounter() prometheus.Counter {
+	return qc
+}
+
+func droppedCounter(typ string) prometheus.Counter {
+	return dc.With(prometheus.Labels{"type": typ})
+}
@@ -0,0 +1,327 @@
+// Package quarantine keeps track of the error-rate (malformed packets or
+// conversion errors) per gateway. Gateways exceeding the configured
+// error-rate are quarantined for the configured cooldown, during which their
+// traffic is not forwarded. This protects the downstream systems from log and
+// message floods.
+package quarantine
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lorawan"
+)
+
+// notifyCode is the code of the notify event published when a gateway is
+// quarantined or released.
+const notifyCode = "QUARANTINE"
+
+// Quarantine contains the quarantine of a single gateway.
+type Quarantine struct {
+	GatewayID  lorawan.EUI64 `json:"gatewayID"`
+	ErrorCount int           `json:"errorCount"`
+	Until      time.Time     `json:"until"`
+}
+
+type gateway struct {
+	windowStart time.Time
+	errorCount  int
+	until       time.Time
+}
+
+var (
+	mux sync.RWMutex
+
+	enabled   bool
+	maxErrors int
+	window    time.Duration
+	cooldown  time.Duration
+
+	gateways = make(map[lorawan.EUI64]*gateway)
+)
+
+// Setup configures the gateway quarantine.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !conf.Quarantine.Enabled {
+		return nil
+	}
+
+	if conf.Quarantine.MaxErrors < 1 {
+		return errors.New("quarantine max_errors must be greater than 0")
+	}
+	if conf.Quarantine.Window <= 0 {
+		return errors.New("quarantine window must be greater than 0")
+	}
+	if conf.Quarantine.Cooldown <= 0 {
+		return errors.New("quarantine cooldown must be greater than 0")
+	}
+
+	enabled = true
+	maxErrors = conf.Quarantine.MaxErrors
+	window = conf.Quarantine.Window
+	cooldown = conf.Quarantine.Cooldown
+
+	log.WithFields(log.Fields{
+		"max_errors": maxErrors,
+		"window":     window,
+		"cooldown":   cooldown,
+	}).Info("quarantine: gateway quarantine enabled")
+
+	admin.HandleFunc("/api/quarantine", handleHTTP)
+
+	go func() {
+		for {
+			time.Sleep(time.Second)
+			for _, q := range release(time.Now()) {
+				go released(q)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Error registers an error (e.g. a malformed packet) of the given gateway.
+func Error(gatewayID lorawan.EUI64) {
+	if q := registerError(gatewayID, time.Now()); q != nil {
+		go quarantined(*q)
+	}
+}
+
+// Drop returns true when the given gateway is quarantined, in which case the
+// event (of the given type) must be dropped.
+func Drop(gatewayID lorawan.EUI64, event string) bool {
+	if !isQuarantined(gatewayID, time.Now()) {
+		return false
+	}
+
+	droppedCounter(event).Inc()
+	return true
+}
+
+// Quarantined returns true when the given gateway is quarantined.
+func Quarantined(gatewayID lorawan.EUI64) bool {
+	return isQuarantined(gatewayID, time.Now())
+}
+
+// Get returns the quarantined gateways.
+func Get() []Quarantine {
+	return get(time.Now())
+}
+
+// registerError registers the error. It returns the quarantine in case the
+// gateway must be quarantined.
+func registerError(gatewayID lorawan.EUI64, now time.Time) *Quarantine {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return nil
+	}
+
+	gw, ok := gateways[gatewayID]
+	if !ok {
+		gw = &gateway{}
+		gateways[gatewayID] = gw
+	}
+
+	errorCounter(gatewayID).Inc()
+
+	if now.Before(gw.until) {
+		return nil
+	}
+
+	if now.Sub(gw.windowStart) >= window {
+		gw.windowStart = now
+		gw.errorCount = 0
+	}
+
+	gw.errorCount++
+	if gw.errorCount <= maxErrors {
+		return nil
+	}
+
+	gw.until = now.Add(cooldown)
+
+	return &Quarantine{
+		GatewayID:  gatewayID,
+		ErrorCount: gw.errorCount,
+		Until:      gw.until,
+	}
+}
+
+func isQuarantined(gatewayID lorawan.EUI64, now time.Time) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if !enabled {
+		return false
+	}
+
+	gw, ok := gateways[gatewayID]
+	return ok && now.Before(gw.until)
+}
+
+func get(now time.Time) []Quarantine {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	var out []Quarantine
+
+	for gatewayID, gw := range gateways {
+		if now.Before(gw.until) {
+			out = append(out, Quarantine{
+				GatewayID:  gatewayID,
+				ErrorCount: gw.errorCount,
+				Until:      gw.until,
+			})
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].GatewayID.String() < out[j].GatewayID.String()
+	})
+
+	return out
+}
+
+// release removes the gateways for which the cooldown has passed or which
+// did not generate errors within the window. It returns the released
+// quarantines.
+func release(now time.Time) []Quarantine {
+	mux.Lock()
+	defer mux.Unlock()
+
+	var out []Quarantine
+
+	for gatewayID, gw := range gateways {
+		if now.Before(gw.until) || now.Sub(gw.windowStart) < window {
+			continue
+		}
+
+		if !gw.until.IsZero() {
+			out = append(out, Quarantine{
+				GatewayID:  gatewayID,
+				ErrorCount: gw.errorCount,
+				Until:      gw.until,
+			})
+		}
+
+		delete(gateways, gatewayID)
+	}
+
+	return out
+}
+
+func quarantined(q Quarantine) {
+	quarantineCounter().Inc()
+
+	log.WithFields(log.Fields{
+		"gateway_id":  q.GatewayID,
+		"error_count": q.ErrorCount,
+		"until":       q.Until,
+	}).Warning("quarantine: gateway quarantined because of a high error-rate")
+
+	if err := publishQuarantine(q, integration.QuarantineStateQuarantined); err != nil {
+		log.WithError(err).WithField("gateway_id", q.GatewayID).Error("quarantine: publish notify event error")
+	}
+}
+
+func released(q Quarantine) {
+	log.WithField("gateway_id", q.GatewayID).Info("quarantine: gateway released from quarantine")
+
+	if err := publishQuarantine(q, integration.QuarantineStateReleased); err != nil {
+		log.WithError(err).WithField("gateway_id", q.GatewayID).Error("quarantine: publish notify event error")
+	}
+}
+
+func publishQuarantine(q Quarantine, state string) error {
+	i := integration.GetIntegration()
+	if i == nil {
+		return nil
+	}
+
+	pl, err := quarantineNotify(q, state, time.Now())
+	if err != nil {
+		return err
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return errors.Wrap(err, "new uuid error")
+	}
+
+	return i.PublishEvent(q.GatewayID, integration.EventNotify, id, pl)
+}
+
+// quarantineNotify returns the notify event payload for the given quarantine
+// state.
+func quarantineNotify(q Quarantine, state string, now time.Time) (*integration.Notify, error) {
+	until, err := ptypes.TimestampProto(q.Until)
+	if err != nil {
+		return nil, errors.Wrap(err, "timestamp proto error")
+	}
+
+	ts, err := ptypes.TimestampProto(now)
+	if err != nil {
+		return nil, errors.Wrap(err, "timestamp proto error")
+	}
+
+	pl := integration.Notify{
+		GatewayId: q.GatewayID[:],
+		Level:     "error",
+		Code:      notifyCode,
+		Message:   fmt.Sprintf("gateway quarantined after %d errors until %s", q.ErrorCount, q.Until.Format(time.RFC3339)),
+		Time:      ts,
+		Details: &integration.Notify_Quarantine{
+			Quarantine: &integration.Quarantine{
+				GatewayId:  q.GatewayID[:],
+				State:      state,
+				ErrorCount: uint32(q.ErrorCount),
+				Until:      until,
+			},
+		},
+	}
+
+	if state == integration.QuarantineStateReleased {
+		pl.Level = "info"
+		pl.Message = "gateway released from quarantine"
+	}
+
+	return &pl, nil
+}
+
+// handleHTTP implements the admin API handler. A GET request returns the
+// quarantined gateways.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	admin.WriteJSON(w, struct {
+		MaxErrors int          `json:"maxErrors"`
+		Window    string       `json:"window"`
+		Cooldown  string       `json:"cooldown"`
+		Gateways  []Quarantine `json:"gateways"`
+	}{
+		MaxErrors: maxErrors,
+		Window:    window.String(),
+		Cooldown:  cooldown.String(),
+		Gateways:  Get(),
+	})
+}
@@ -0,0 +1,190 @@
+package quarantine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lorawan"
+)
+
+func setup() {
+	enabled = true
+	maxErrors = 2
+	window = time.Minute
+	cooldown = 10 * time.Minute
+	gateways = make(map[lorawan.EUI64]*gateway)
+}
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		Name          string
+		MaxErrors     int
+		Window        time.Duration
+		Cooldown      time.Duration
+		ExpectedError string
+	}{
+		{
+			Name:          "invalid max_errors",
+			Window:        time.Minute,
+			Cooldown:      time.Minute,
+			ExpectedError: "quarantine max_errors must be greater than 0",
+		},
+		{
+			Name:          "invalid window",
+			MaxErrors:     10,
+			Cooldown:      time.Minute,
+			ExpectedError: "quarantine window must be greater than 0",
+		},
+		{
+			Name:          "invalid cooldown",
+			MaxErrors:     10,
+			Window:        time.Minute,
+			ExpectedError: "quarantine cooldown must be greater than 0",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			enabled = false
+
+			var conf config.Config
+			conf.Quarantine.Enabled = true
+			conf.Quarantine.MaxErrors = tst.MaxErrors
+			conf.Quarantine.Window = tst.Window
+			conf.Quarantine.Cooldown = tst.Cooldown
+
+			assert.EqualError(Setup(conf), tst.ExpectedError)
+			assert.False(enabled)
+		})
+	}
+}
+
+func TestQuarantine(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	t.Run("Disabled", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+		enabled = false
+
+		for i := 0; i < 5; i++ {
+			assert.Nil(registerError(gatewayID, now))
+		}
+		assert.False(isQuarantined(gatewayID, now))
+	})
+
+	t.Run("Below threshold", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		assert.Nil(registerError(gatewayID, now))
+		assert.Nil(registerError(gatewayID, now.Add(time.Second)))
+		assert.False(isQuarantined(gatewayID, now.Add(time.Second)))
+
+		// the error count is reset after the window
+		assert.Nil(registerError(gatewayID, now.Add(time.Minute)))
+		assert.False(isQuarantined(gatewayID, now.Add(time.Minute)))
+	})
+
+	t.Run("Quarantined and released", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		assert.Nil(registerError(gatewayID, now))
+		assert.Nil(registerError(gatewayID, now))
+		assert.Equal(&Quarantine{
+			GatewayID:  gatewayID,
+			ErrorCount: 3,
+			Until:      now.Add(10 * time.Minute),
+		}, registerError(gatewayID, now))
+		assert.True(isQuarantined(gatewayID, now))
+
+		// errors during the quarantine do not extend it
+		assert.Nil(registerError(gatewayID, now.Add(time.Minute)))
+
+		assert.Equal([]Quarantine{
+			{
+				GatewayID:  gatewayID,
+				ErrorCount: 3,
+				Until:      now.Add(10 * time.Minute),
+			},
+		}, get(now.Add(time.Minute)))
+
+		// not yet released within the cooldown
+		assert.Len(release(now.Add(5*time.Minute)), 0)
+		assert.True(isQuarantined(gatewayID, now.Add(5*time.Minute)))
+
+		// released after the cooldown
+		assert.Equal([]Quarantine{
+			{
+				GatewayID:  gatewayID,
+				ErrorCount: 3,
+				Until:      now.Add(10 * time.Minute),
+			},
+		}, release(now.Add(10*time.Minute)))
+		assert.False(isQuarantined(gatewayID, now.Add(10*time.Minute)))
+		assert.Len(get(now.Add(10*time.Minute)), 0)
+	})
+}
+
+func TestQuarantineNotify(t *testing.T) {
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	q := Quarantine{
+		GatewayID:  lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		ErrorCount: 101,
+		Until:      now.Add(10 * time.Minute),
+	}
+	nowPB, _ := ptypes.TimestampProto(now)
+	untilPB, _ := ptypes.TimestampProto(q.Until)
+
+	tests := []struct {
+		Name            string
+		State           string
+		ExpectedLevel   string
+		ExpectedMessage string
+	}{
+		{
+			Name:            "quarantined",
+			State:           integration.QuarantineStateQuarantined,
+			ExpectedLevel:   "error",
+			ExpectedMessage: "gateway quarantined after 101 errors until 2019-09-10T12:10:00Z",
+		},
+		{
+			Name:            "released",
+			State:           integration.QuarantineStateReleased,
+			ExpectedLevel:   "info",
+			ExpectedMessage: "gateway released from quarantine",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			pl, err := quarantineNotify(q, tst.State, now)
+			assert.NoError(err)
+			assert.Equal(&integration.Notify{
+				GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+				Level:     tst.ExpectedLevel,
+				Code:      "QUARANTINE",
+				Message:   tst.ExpectedMessage,
+				Time:      nowPB,
+				Details: &integration.Notify_Quarantine{
+					Quarantine: &integration.Quarantine{
+						GatewayId:  []byte{1, 2, 3, 4, 5, 6, 7, 8},
+						State:      tst.State,
+						ErrorCount: 101,
+						Until:      untilPB,
+					},
+				},
+			}, pl)
+		})
+	}
+}
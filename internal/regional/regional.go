@@ -0,0 +1,131 @@
+// Package regional implements loading the regional parameters (data-rate
+// table and channel-plan) from an external JSON or TOML file, so that new
+// regional parameters revisions can be used without a rebuild.
+package regional
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/viper"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan/band"
+)
+
+// Parameters holds the regional parameters.
+type Parameters struct {
+	// Region (e.g. EU868). When set, this overrides the configured region.
+	Region band.Name `mapstructure:"region"`
+
+	// DataRates contains the data-rate table.
+	DataRates []DataRate `mapstructure:"data_rates"`
+
+	// Concentrators contains the channel-plan. When set, this overrides
+	// the configured concentrators.
+	Concentrators []config.BasicStationConcentrator `mapstructure:"concentrators"`
+
+	// ModTime contains the modification time of the file.
+	ModTime time.Time `mapstructure:"-"`
+}
+
+// DataRate defines a single data-rate.
+type DataRate struct {
+	DataRate        int    `mapstructure:"data_rate"`
+	Modulation      string `mapstructure:"modulation"`
+	SpreadingFactor int    `mapstructure:"spreading_factor"`
+	Bandwidth       int    `mapstructure:"bandwidth"`
+	BitRate         int    `mapstructure:"bit_rate"`
+	Uplink          bool   `mapstructure:"uplink"`
+	Downlink        bool   `mapstructure:"downlink"`
+}
+
+// Load loads the regional parameters from the given file. The format is
+// derived from the file extension (.json or .toml).
+func Load(path string) (*Parameters, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "stat file error")
+	}
+
+	v := viper.New()
+	v.SetConfigFile(path)
+	if err := v.ReadInConfig(); err != nil {
+		return nil, errors.Wrap(err, "read file error")
+	}
+
+	var p Parameters
+	if err := v.Unmarshal(&p); err != nil {
+		return nil, errors.Wrap(err, "unmarshal error")
+	}
+
+	if len(p.DataRates) == 0 {
+		return nil, errors.New("data_rates must not be empty")
+	}
+
+	seen := make(map[int]bool)
+	for _, dr := range p.DataRates {
+		if seen[dr.DataRate] {
+			return nil, fmt.Errorf("data_rate %d is defined more than once", dr.DataRate)
+		}
+		seen[dr.DataRate] = true
+
+		switch band.Modulation(dr.Modulation) {
+		case band.LoRaModulation, band.FSKModulation:
+		default:
+			return nil, fmt.Errorf("data_rate %d: invalid modulation: %s", dr.DataRate, dr.Modulation)
+		}
+	}
+
+	p.ModTime = fi.ModTime()
+
+	return &p, nil
+}
+
+// GetDataRate returns the data-rate for the given index.
+func (p *Parameters) GetDataRate(dr int) (band.DataRate, error) {
+	for _, d := range p.DataRates {
+		if d.DataRate == dr {
+			return d.bandDataRate(), nil
+		}
+	}
+
+	return band.DataRate{}, errors.New("lorawan/band: invalid data-rate")
+}
+
+// GetDataRateIndex returns the index of the given uplink (or downlink)
+// data-rate.
+func (p *Parameters) GetDataRateIndex(uplink bool, dataRate band.DataRate) (int, error) {
+	for _, d := range p.DataRates {
+		if (uplink && !d.Uplink) || (!uplink && !d.Downlink) {
+			continue
+		}
+
+		if equalDataRate(d.bandDataRate(), dataRate) {
+			return d.DataRate, nil
+		}
+	}
+
+	return 0, errors.New("lorawan/band: data-rate not found")
+}
+
+func (d DataRate) bandDataRate() band.DataRate {
+	return band.DataRate{
+		Modulation:   band.Modulation(d.Modulation),
+		SpreadFactor: d.SpreadingFactor,
+		Bandwidth:    d.Bandwidth,
+		BitRate:      d.BitRate,
+	}
+}
+
+// equalDataRate compares the exported fields of the given data-rates. The
+// uplink / downlink flags of the band.DataRate are unexported, these are only
+// set for the data-rates of the compiled bands.
+func equalDataRate(a, b band.DataRate) bool {
+	return a.Modulation == b.Modulation &&
+		a.SpreadFactor == b.SpreadFactor &&
+		a.Bandwidth == b.Bandwidth &&
+		a.BitRate == b.BitRate
+}
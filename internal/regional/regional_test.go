@@ -0,0 +1,203 @@
+package regional
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+const testTOML = `
+region="EU868"
+
+[[data_rates]]
+data_rate=0
+modulation="LORA"
+spreading_factor=12
+bandwidth=125
+uplink=true
+downlink=true
+
+[[data_rates]]
+data_rate=5
+modulation="LORA"
+spreading_factor=7
+bandwidth=125
+uplink=true
+downlink=true
+
+[[data_rates]]
+data_rate=7
+modulation="FSK"
+bit_rate=50000
+uplink=true
+downlink=true
+
+[[data_rates]]
+data_rate=8
+modulation="LORA"
+spreading_factor=12
+bandwidth=500
+downlink=true
+
+[[concentrators]]
+  [concentrators.multi_sf]
+  frequencies=[868100000, 868300000, 868500000]
+`
+
+const testJSON = `{
+	"data_rates": [
+		{"data_rate": 0, "modulation": "LORA", "spreading_factor": 12, "bandwidth": 125, "uplink": true, "downlink": true}
+	]
+}`
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "regional")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		Name    string
+		File    string
+		Content string
+
+		ExpectedRegion        band.Name
+		ExpectedDataRates     int
+		ExpectedConcentrators int
+		ExpectedError         string
+	}{
+		{
+			Name:                  "toml",
+			File:                  "regional.toml",
+			Content:               testTOML,
+			ExpectedRegion:        band.EU868,
+			ExpectedDataRates:     4,
+			ExpectedConcentrators: 1,
+		},
+		{
+			Name:              "json",
+			File:              "regional.json",
+			Content:           testJSON,
+			ExpectedDataRates: 1,
+		},
+		{
+			Name:          "no data-rates",
+			File:          "empty.toml",
+			Content:       `region="EU868"`,
+			ExpectedError: "data_rates must not be empty",
+		},
+		{
+			Name: "duplicate data-rate",
+			File: "duplicate.toml",
+			Content: `
+[[data_rates]]
+data_rate=0
+modulation="LORA"
+
+[[data_rates]]
+data_rate=0
+modulation="LORA"
+`,
+			ExpectedError: "data_rate 0 is defined more than once",
+		},
+		{
+			Name: "invalid modulation",
+			File: "invalid.toml",
+			Content: `
+[[data_rates]]
+data_rate=0
+modulation="FOO"
+`,
+			ExpectedError: "data_rate 0: invalid modulation: FOO",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			path := filepath.Join(dir, tst.File)
+			assert.NoError(ioutil.WriteFile(path, []byte(tst.Content), 0644))
+
+			p, err := Load(path)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+
+			assert.Equal(tst.ExpectedRegion, p.Region)
+			assert.Len(p.DataRates, tst.ExpectedDataRates)
+			assert.Len(p.Concentrators, tst.ExpectedConcentrators)
+			assert.False(p.ModTime.IsZero())
+		})
+	}
+}
+
+func TestDataRates(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "regional")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "regional.toml")
+	assert.NoError(ioutil.WriteFile(path, []byte(testTOML), 0644))
+
+	p, err := Load(path)
+	assert.NoError(err)
+
+	b, err := band.GetConfig(band.EU868, false, lorawan.DwellTimeNoLimit)
+	assert.NoError(err)
+
+	t.Run("matches compiled band", func(t *testing.T) {
+		assert := require.New(t)
+
+		for _, i := range []int{0, 5, 7} {
+			expected, err := b.GetDataRate(i)
+			assert.NoError(err)
+
+			dr, err := p.GetDataRate(i)
+			assert.NoError(err)
+			assert.Equal(expected.Modulation, dr.Modulation)
+			assert.Equal(expected.SpreadFactor, dr.SpreadFactor)
+			assert.Equal(expected.Bandwidth, dr.Bandwidth)
+			assert.Equal(expected.BitRate, dr.BitRate)
+
+			idx, err := p.GetDataRateIndex(true, dr)
+			assert.NoError(err)
+			assert.Equal(i, idx)
+
+			// the data-rate of the compiled band must be found too
+			idx, err = p.GetDataRateIndex(true, expected)
+			assert.NoError(err)
+			assert.Equal(i, idx)
+		}
+	})
+
+	t.Run("invalid data-rate", func(t *testing.T) {
+		assert := require.New(t)
+
+		_, err := p.GetDataRate(1)
+		assert.Error(err)
+	})
+
+	t.Run("downlink only", func(t *testing.T) {
+		assert := require.New(t)
+
+		dr, err := p.GetDataRate(8)
+		assert.NoError(err)
+
+		_, err = p.GetDataRateIndex(true, dr)
+		assert.Error(err)
+
+		idx, err := p.GetDataRateIndex(false, dr)
+		assert.NoError(err)
+		assert.Equal(8, idx)
+	})
+}
@@ -0,0 +1,164 @@
+// Package registry keeps the registry of the gateways connected to the LoRa
+// Gateway Bridge, which can be retrieved using the list_gateways bridge
+// command.
+package registry
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+type gateway struct {
+	connectedAt time.Time
+	lastSeenAt  time.Time
+}
+
+var (
+	mux sync.RWMutex
+
+	backend  string
+	gateways = make(map[lorawan.EUI64]*gateway)
+	pinned   = make(map[lorawan.EUI64]struct{})
+)
+
+// Setup configures the registry.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	backend = conf.Backend.Type
+	gateways = make(map[lorawan.EUI64]*gateway)
+	pinned = make(map[lorawan.EUI64]struct{})
+
+	return nil
+}
+
+// Connected registers the given gateway as connected.
+func Connected(gatewayID lorawan.EUI64) {
+	connected(gatewayID, time.Now())
+}
+
+// Disconnected removes the given gateway from the registry.
+func Disconnected(gatewayID lorawan.EUI64) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	delete(gateways, gatewayID)
+}
+
+// Seen updates the last-seen timestamp of the given gateway.
+func Seen(gatewayID lorawan.EUI64) {
+	seen(gatewayID, time.Now())
+}
+
+// Pin registers the given gateway as always subscribed, independent of its
+// connection state (e.g. pre-registered gateways).
+func Pin(gatewayID lorawan.EUI64) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	pinned[gatewayID] = struct{}{}
+}
+
+// Subscriptions returns the IDs of the gateways for which the integration
+// must be subscribed, these are the connected and the pinned gateways.
+func Subscriptions() []lorawan.EUI64 {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	var out []lorawan.EUI64
+	for id := range gateways {
+		out = append(out, id)
+	}
+	for id := range pinned {
+		if _, ok := gateways[id]; !ok {
+			out = append(out, id)
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return string(out[i][:]) < string(out[j][:])
+	})
+
+	return out
+}
+
+// Gateways returns the IDs of the connected gateways, sorted by gateway ID.
+func Gateways() []lorawan.EUI64 {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	var out []lorawan.EUI64
+	for id := range gateways {
+		out = append(out, id)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return string(out[i][:]) < string(out[j][:])
+	})
+
+	return out
+}
+
+// ListGateways handles the list_gateways bridge command and returns the
+// connected gateways, sorted by gateway ID.
+func ListGateways(req ListGatewaysRequest) (ListGatewaysResponse, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	resp := ListGatewaysResponse{
+		RequestId: req.GetRequestId(),
+	}
+
+	for id, gw := range gateways {
+		connectedAt, err := ptypes.TimestampProto(gw.connectedAt)
+		if err != nil {
+			return resp, errors.Wrap(err, "timestamp proto error")
+		}
+
+		lastSeenAt, err := ptypes.TimestampProto(gw.lastSeenAt)
+		if err != nil {
+			return resp, errors.Wrap(err, "timestamp proto error")
+		}
+
+		gatewayID := id
+		resp.Gateways = append(resp.Gateways, &Gateway{
+			GatewayId:   gatewayID[:],
+			Backend:     backend,
+			ConnectedAt: connectedAt,
+			LastSeenAt:  lastSeenAt,
+		})
+	}
+
+	sort.Slice(resp.Gateways, func(i, j int) bool {
+		return string(resp.Gateways[i].GatewayId) < string(resp.Gateways[j].GatewayId)
+	})
+
+	return resp, nil
+}
+
+func connected(gatewayID lorawan.EUI64, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	gateways[gatewayID] = &gateway{
+		connectedAt: now,
+		lastSeenAt:  now,
+	}
+}
+
+func seen(gatewayID lorawan.EUI64, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if gw, ok := gateways[gatewayID]; ok {
+		gw.lastSeenAt = now
+	}
+}
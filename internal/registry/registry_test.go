@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lorawan"
+)
+
+func TestRegistry(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Backend.Type = "semtech_udp"
+	assert.NoError(Setup(conf))
+
+	gatewayID1 := lorawan.EUI64{1, 1, 1, 1, 1, 1, 1, 1}
+	gatewayID2 := lorawan.EUI64{2, 2, 2, 2, 2, 2, 2, 2}
+	now := time.Now().Truncate(time.Second)
+
+	connected(gatewayID2, now)
+	connected(gatewayID1, now)
+	seen(gatewayID1, now.Add(time.Second))
+
+	// not connected
+	seen(lorawan.EUI64{3, 3, 3, 3, 3, 3, 3, 3}, now)
+
+	t.Run("list gateways", func(t *testing.T) {
+		assert := require.New(t)
+
+		nowPB, err := ptypes.TimestampProto(now)
+		assert.NoError(err)
+		seenPB, err := ptypes.TimestampProto(now.Add(time.Second))
+		assert.NoError(err)
+
+		resp, err := ListGateways(ListGatewaysRequest{RequestId: []byte{1, 2, 3}})
+		assert.NoError(err)
+		assert.Equal(ListGatewaysResponse{
+			RequestId: []byte{1, 2, 3},
+			Gateways: []*Gateway{
+				{
+					GatewayId:   gatewayID1[:],
+					Backend:     "semtech_udp",
+					ConnectedAt: nowPB,
+					LastSeenAt:  seenPB,
+				},
+				{
+					GatewayId:   gatewayID2[:],
+					Backend:     "semtech_udp",
+					ConnectedAt: nowPB,
+					LastSeenAt:  nowPB,
+				},
+			},
+		}, resp)
+	})
+
+	t.Run("subscriptions", func(t *testing.T) {
+		assert := require.New(t)
+
+		gatewayID3 := lorawan.EUI64{3, 3, 3, 3, 3, 3, 3, 3}
+		Pin(gatewayID3)
+		Pin(gatewayID1)
+
+		assert.Equal([]lorawan.EUI64{gatewayID1, gatewayID2, gatewayID3}, Subscriptions())
+
+		// pinned gateways are not connected
+		assert.Equal([]lorawan.EUI64{gatewayID1, gatewayID2}, Gateways())
+	})
+
+	t.Run("disconnected", func(t *testing.T) {
+		assert := require.New(t)
+
+		Disconnected(gatewayID1)
+		resp, err := ListGateways(ListGatewaysRequest{})
+		assert.NoError(err)
+		assert.Len(resp.Gateways, 1)
+		assert.Equal(gatewayID2[:], resp.Gateways[0].GatewayId)
+
+		// pinned gateways are not removed
+		assert.Equal([]lorawan.EUI64{gatewayID1, gatewayID2, {3, 3, 3, 3, 3, 3, 3, 3}}, Subscriptions())
+	})
+}
@@ -0,0 +1,28 @@
+package reorder
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	rc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "reorder_uplink_reordered_count",
+		Help: "The number of uplinks which arrived out of order and were reordered by the buffer (per gateway).",
+	}, []string{"gateway_id"})
+
+	nmc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "reorder_uplink_non_monotonic_count",
+		Help: "The number of uplinks which arrived too late to be reordered (non-monotonic timestamp, per gateway).",
+	}, []string{"gateway_id"})
+)
+
+func reorderedCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return rc.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
+
+func nonMonotonicCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return nmc.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
@@ -0,0 +1,267 @@
+// Package reorder implements a per-gateway uplink reordering buffer. The
+// uplinks are held for the configured window and are released sorted by their
+// concentrator timestamp (the counter in the gateway context), so that
+// uplinks arriving out of order (e.g. because of the per-uplink goroutines or
+// UDP reordering) are published in order. Uplinks arriving after an uplink
+// with a later timestamp has already been released (non-monotonic) are
+// flagged using a warning and a metric, and are released immediately.
+package reorder
+
+import (
+	"encoding/binary"
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// HandlerFunc defines the function which is called with the released
+// uplinks.
+type HandlerFunc func(gw.UplinkFrame)
+
+// timestamp holds a concentrator counter value of the given bit width.
+type timestamp struct {
+	value uint64
+	bits  uint
+}
+
+// before returns true when t is before u, taking the counter wrap-around
+// into account. Timestamps of different widths are not comparable.
+func (t timestamp) before(u timestamp) bool {
+	if t.bits != u.bits {
+		return false
+	}
+
+	shift := 64 - t.bits
+	return int64((t.value-u.value)<<shift) < 0
+}
+
+type entry struct {
+	frame     gw.UplinkFrame
+	timestamp timestamp
+	deadline  time.Time
+}
+
+// bufferRetention defines after which period of inactivity the (empty)
+// buffer of a gateway is removed.
+const bufferRetention = 10 * time.Minute
+
+// buffer holds the buffered uplinks of a single gateway, sorted by
+// timestamp.
+type buffer struct {
+	sync.Mutex
+
+	// releaseMux is held while releasing (without holding the buffer lock),
+	// so that the uplinks of a gateway are handled in order without blocking
+	// the uplinks being added.
+	releaseMux sync.Mutex
+
+	entries  []entry
+	released *timestamp
+	lastUsed time.Time
+}
+
+var (
+	mux     sync.RWMutex
+	enabled bool
+	window  time.Duration
+	handler HandlerFunc
+	buffers map[lorawan.EUI64]*buffer
+	pruned  time.Time
+)
+
+// Setup configures the reorder package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	enabled = conf.Reorder.Enabled
+	window = conf.Reorder.Window
+	buffers = make(map[lorawan.EUI64]*buffer)
+
+	if enabled {
+		log.WithField("window", window).Info("reorder: uplink reordering enabled")
+	}
+
+	return nil
+}
+
+// SetHandler sets the function which is called with the released uplinks.
+func SetHandler(f HandlerFunc) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	handler = f
+}
+
+// Enabled returns true when the uplink reordering is enabled.
+func Enabled() bool {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	return enabled
+}
+
+// Add adds the given uplink to the reordering buffer of its gateway. Uplinks
+// without concentrator timestamp are released immediately.
+func Add(frame gw.UplinkFrame) {
+	now := time.Now()
+	if add(frame, now) {
+		time.AfterFunc(window, func() {
+			flush(frame.RxInfo.GetGatewayId(), time.Now())
+		})
+	}
+}
+
+// add adds the given uplink to the buffer. It returns true when the uplink
+// has been buffered, false when it has been released immediately.
+func add(frame gw.UplinkFrame, now time.Time) bool {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.RxInfo.GetGatewayId())
+
+	ts, ok := getTimestamp(frame)
+	if !ok {
+		release(frame)
+		return false
+	}
+
+	b := getBuffer(gatewayID, now)
+	b.Lock()
+
+	if b.released != nil && ts.before(*b.released) {
+		nonMonotonicCounter(gatewayID).Inc()
+		log.WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"timestamp":  ts.value,
+			"released":   b.released.value,
+		}).Warning("reorder: uplink with non-monotonic timestamp, releasing immediately")
+
+		b.Unlock()
+		release(frame)
+		return false
+	}
+
+	// insert after the entries with an equal or earlier timestamp
+	i := sort.Search(len(b.entries), func(i int) bool {
+		return ts.before(b.entries[i].timestamp)
+	})
+	if i != len(b.entries) {
+		reorderedCounter(gatewayID).Inc()
+	}
+
+	b.entries = append(b.entries, entry{})
+	copy(b.entries[i+1:], b.entries[i:])
+	b.entries[i] = entry{
+		frame:     frame,
+		timestamp: ts,
+		deadline:  now.Add(window),
+	}
+	b.Unlock()
+
+	return true
+}
+
+// flush releases the uplinks of the given gateway of which the deadline has
+// expired, including the uplinks with an earlier timestamp.
+func flush(gatewayIDB []byte, now time.Time) {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], gatewayIDB)
+
+	b := getBuffer(gatewayID, now)
+	b.releaseMux.Lock()
+	defer b.releaseMux.Unlock()
+
+	// the due entries are removed under the buffer lock, the (possibly slow)
+	// release happens without holding it
+	b.Lock()
+	n := 0
+	for i, e := range b.entries {
+		if !e.deadline.After(now) {
+			n = i + 1
+		}
+	}
+
+	due := b.entries[:n]
+	if n != 0 {
+		ts := due[n-1].timestamp
+		b.released = &ts
+	}
+	b.entries = append([]entry(nil), b.entries[n:]...)
+	b.Unlock()
+
+	for _, e := range due {
+		release(e.frame)
+	}
+}
+
+// getBuffer returns the buffer of the given gateway, it is created when it
+// does not exist.
+func getBuffer(gatewayID lorawan.EUI64, now time.Time) *buffer {
+	mux.Lock()
+	defer mux.Unlock()
+
+	prune(now)
+
+	b, ok := buffers[gatewayID]
+	if !ok {
+		b = &buffer{}
+		buffers[gatewayID] = b
+	}
+	b.lastUsed = now
+	return b
+}
+
+// prune removes the empty buffers of the gateways which have not been used
+// within the retention. A lock must be held by the caller.
+func prune(now time.Time) {
+	if now.Sub(pruned) < bufferRetention {
+		return
+	}
+
+	for gatewayID, b := range buffers {
+		b.Lock()
+		if len(b.entries) == 0 && now.Sub(b.lastUsed) > bufferRetention {
+			delete(buffers, gatewayID)
+		}
+		b.Unlock()
+	}
+	pruned = now
+}
+
+func release(frame gw.UplinkFrame) {
+	mux.RLock()
+	f := handler
+	mux.RUnlock()
+
+	if f != nil {
+		f(frame)
+	}
+}
+
+// getTimestamp returns the concentrator timestamp of the given uplink. For
+// 4 byte contexts (Semtech UDP, TTN), this is the 32 bit microsecond counter.
+// For 16 byte contexts (Basic Station), this is the 48 bit microsecond
+// counter of the xtime.
+func getTimestamp(frame gw.UplinkFrame) (timestamp, bool) {
+	ctx := frame.RxInfo.GetContext()
+
+	switch len(ctx) {
+	case 4:
+		return timestamp{
+			value: uint64(binary.BigEndian.Uint32(ctx)),
+			bits:  32,
+		}, true
+	case 16:
+		return timestamp{
+			value: binary.BigEndian.Uint64(ctx[8:16]) & (1<<48 - 1),
+			bits:  48,
+		}, true
+	default:
+		return timestamp{}, false
+	}
+}
@@ -0,0 +1,146 @@
+package reorder
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func uplinkFrame(tmst uint32) gw.UplinkFrame {
+	ctx := make([]byte, 4)
+	binary.BigEndian.PutUint32(ctx, tmst)
+
+	return gw.UplinkFrame{
+		RxInfo: &gw.UplinkRXInfo{
+			GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+			Context:   ctx,
+		},
+	}
+}
+
+func TestTimestampBefore(t *testing.T) {
+	tests := []struct {
+		Name     string
+		T        timestamp
+		U        timestamp
+		Expected bool
+	}{
+		{"before", timestamp{1, 32}, timestamp{2, 32}, true},
+		{"after", timestamp{2, 32}, timestamp{1, 32}, false},
+		{"equal", timestamp{1, 32}, timestamp{1, 32}, false},
+		{"wrap-around", timestamp{0xfffffff0, 32}, timestamp{0x10, 32}, true},
+		{"wrap-around 48 bit", timestamp{1<<48 - 1, 48}, timestamp{1, 48}, true},
+		{"different widths", timestamp{1, 32}, timestamp{2, 48}, false},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+			assert.Equal(tst.Expected, tst.T.before(tst.U))
+		})
+	}
+}
+
+func TestReorder(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Reorder.Enabled = true
+	conf.Reorder.Window = 100 * time.Millisecond
+	assert.NoError(Setup(conf))
+
+	var released []uint32
+	SetHandler(func(frame gw.UplinkFrame) {
+		released = append(released, binary.BigEndian.Uint32(frame.RxInfo.Context))
+	})
+	defer SetHandler(nil)
+
+	gatewayID := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Now()
+
+	t.Run("out of order uplinks are reordered", func(t *testing.T) {
+		assert := require.New(t)
+		released = nil
+
+		assert.True(add(uplinkFrame(3000), now))
+		assert.True(add(uplinkFrame(1000), now.Add(10*time.Millisecond)))
+		assert.True(add(uplinkFrame(2000), now.Add(20*time.Millisecond)))
+
+		flush(gatewayID, now.Add(50*time.Millisecond))
+		assert.Len(released, 0)
+
+		// the deadline of 3000 has expired, the earlier uplinks are released
+		// before it
+		flush(gatewayID, now.Add(100*time.Millisecond))
+		assert.Equal([]uint32{1000, 2000, 3000}, released)
+	})
+
+	t.Run("non-monotonic uplink is released immediately", func(t *testing.T) {
+		assert := require.New(t)
+		released = nil
+
+		assert.False(add(uplinkFrame(2500), now.Add(200*time.Millisecond)))
+		assert.Equal([]uint32{2500}, released)
+	})
+
+	t.Run("uplink without timestamp is released immediately", func(t *testing.T) {
+		assert := require.New(t)
+		released = nil
+
+		frame := uplinkFrame(0)
+		frame.RxInfo.Context = nil
+		var called bool
+		SetHandler(func(gw.UplinkFrame) { called = true })
+
+		assert.False(add(frame, now))
+		assert.True(called)
+	})
+	t.Run("add is not blocked by a release", func(t *testing.T) {
+		assert := require.New(t)
+
+		unblock := make(chan struct{})
+		releasing := make(chan struct{}, 1)
+		SetHandler(func(gw.UplinkFrame) {
+			releasing <- struct{}{}
+			<-unblock
+		})
+
+		assert.True(add(uplinkFrame(5000), now.Add(300*time.Millisecond)))
+		done := make(chan struct{})
+		go func() {
+			flush(gatewayID, now.Add(400*time.Millisecond))
+			close(done)
+		}()
+		<-releasing
+
+		assert.True(add(uplinkFrame(6000), now.Add(410*time.Millisecond)))
+		close(unblock)
+		<-done
+	})
+
+	t.Run("buffers of quiet gateways are removed", func(t *testing.T) {
+		assert := require.New(t)
+		SetHandler(nil)
+
+		flush(gatewayID, now.Add(time.Second))
+		mux.RLock()
+		assert.Len(buffers, 1)
+		mux.RUnlock()
+
+		frame := uplinkFrame(1000)
+		frame.RxInfo.GatewayId = []byte{8, 7, 6, 5, 4, 3, 2, 1}
+		assert.True(add(frame, now.Add(time.Second+bufferRetention+time.Millisecond)))
+
+		mux.RLock()
+		defer mux.RUnlock()
+		assert.Len(buffers, 1)
+		_, ok := buffers[lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}]
+		assert.True(ok)
+	})
+}
@@ -0,0 +1,194 @@
+// Package replay implements the replay of archived and dead-lettered events
+// into the integration, e.g. to backfill the events which were lost during a
+// network-server outage.
+package replay
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/archive"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/marshaler"
+	"github.com/brocaar/lorawan"
+)
+
+// Event contains an event to replay.
+type Event struct {
+	Time      time.Time
+	GatewayID lorawan.EUI64
+	EventType string
+	EventID   uuid.UUID
+	Message   proto.Message
+}
+
+// Publisher defines the interface for publishing the replayed events. This
+// is implemented by the integrations.
+type Publisher interface {
+	PublishEvent(lorawan.EUI64, string, uuid.UUID, proto.Message) error
+}
+
+// deadLetter contains the fields of a dead-letter file line which are needed
+// for the replay.
+type deadLetter struct {
+	Time      time.Time     `json:"time"`
+	GatewayID lorawan.EUI64 `json:"gatewayID"`
+	Event     string        `json:"event"`
+	Payload   []byte        `json:"payload"`
+}
+
+// sleep is used for rate limiting, it can be overwritten by the tests.
+var sleep = time.Sleep
+
+// FromArchive returns the archived events matching the given filters. The
+// archive must be opened.
+func FromArchive(filters archive.Filters) ([]Event, error) {
+	events, err := archive.Query(filters)
+	if err != nil {
+		return nil, errors.Wrap(err, "query archive error")
+	}
+
+	out := make([]Event, 0, len(events))
+	for _, e := range events {
+		msg, err := e.Message()
+		if err != nil {
+			return nil, errors.Wrapf(err, "get message of archived event %d error", e.ID)
+		}
+
+		out = append(out, Event{
+			Time:      e.CreatedAt,
+			GatewayID: e.GatewayID,
+			EventType: e.EventType,
+			EventID:   e.EventID,
+			Message:   msg,
+		})
+	}
+
+	return out, nil
+}
+
+// FromDeadLetterFile returns the events of the given MQTT dead-letter file
+// matching the given filters. The payloads are decoded using the unmarshal
+// function returned for the event type. As the event ID is not stored in the
+// dead-letter file, a new ID is generated for every event. Only the up,
+// stats and ack events can be replayed, other events are skipped.
+func FromDeadLetterFile(path string, filters archive.Filters, unmarshal func(eventType string) (marshaler.UnmarshalFunc, error)) ([]Event, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "open dead-letter file error")
+	}
+	defer f.Close()
+
+	var out []Event
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+
+	for line := 1; scanner.Scan(); line++ {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var dl deadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &dl); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal dead-letter error (line: %d)", line)
+		}
+
+		if !match(filters, dl.GatewayID, dl.Event, dl.Time) {
+			continue
+		}
+
+		msg, err := archive.NewMessage(dl.Event)
+		if err != nil {
+			log.WithFields(log.Fields{
+				"line":  line,
+				"event": dl.Event,
+			}).Warning("replay: skipping dead-letter of unsupported event type")
+			continue
+		}
+
+		unmarshalFunc, err := unmarshal(dl.Event)
+		if err != nil {
+			return nil, errors.Wrap(err, "get unmarshal function error")
+		}
+
+		if err := unmarshalFunc(dl.Payload, msg); err != nil {
+			return nil, errors.Wrapf(err, "unmarshal dead-letter payload error (line: %d)", line)
+		}
+
+		id, err := uuid.NewV4()
+		if err != nil {
+			return nil, errors.Wrap(err, "new uuid error")
+		}
+
+		out = append(out, Event{
+			Time:      dl.Time,
+			GatewayID: dl.GatewayID,
+			EventType: dl.Event,
+			EventID:   id,
+			Message:   msg,
+		})
+
+		if filters.Limit > 0 && len(out) >= filters.Limit {
+			break
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read dead-letter file error")
+	}
+
+	return out, nil
+}
+
+// Replay publishes the given events using the given publisher, at the given
+// rate (events per second, 0 = no limit). It stops at the first publish
+// error and returns the number of replayed events, so that a replay can be
+// resumed.
+func Replay(p Publisher, events []Event, rate float64) (int, error) {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+
+	for i, e := range events {
+		if i != 0 && interval != 0 {
+			sleep(interval)
+		}
+
+		if err := p.PublishEvent(e.GatewayID, e.EventType, e.EventID, e.Message); err != nil {
+			return i, errors.Wrapf(err, "publish event %s error", e.EventID)
+		}
+
+		log.WithFields(log.Fields{
+			"gateway_id": e.GatewayID,
+			"event":      e.EventType,
+			"event_id":   e.EventID,
+			"time":       e.Time,
+		}).Debug("replay: event replayed")
+	}
+
+	return len(events), nil
+}
+
+// match returns true when the given event matches the given filters.
+func match(filters archive.Filters, gatewayID lorawan.EUI64, eventType string, t time.Time) bool {
+	if filters.GatewayID != nil && *filters.GatewayID != gatewayID {
+		return false
+	}
+
+	if filters.EventType != "" && filters.EventType != eventType {
+		return false
+	}
+
+	if !filters.Since.IsZero() && t.Before(filters.Since) {
+		return false
+	}
+
+	return true
+}
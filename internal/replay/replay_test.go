@@ -0,0 +1,212 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/archive"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/marshaler"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+type publishedEvent struct {
+	GatewayID lorawan.EUI64
+	EventType string
+	EventID   uuid.UUID
+	Message   proto.Message
+}
+
+type testPublisher struct {
+	events []publishedEvent
+	err    error
+	failAt int
+}
+
+func (p *testPublisher) PublishEvent(gatewayID lorawan.EUI64, eventType string, id uuid.UUID, msg proto.Message) error {
+	if p.err != nil && len(p.events) == p.failAt {
+		return p.err
+	}
+
+	p.events = append(p.events, publishedEvent{gatewayID, eventType, id, msg})
+	return nil
+}
+
+func TestFromDeadLetterFile(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "replay")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	marshal, _, err := marshaler.Get("json")
+	assert.NoError(err)
+
+	gatewayID1 := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	gatewayID2 := lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+
+	uplink := gw.UplinkFrame{PhyPayload: []byte{1, 2, 3, 4}}
+	stats := gw.GatewayStats{GatewayId: gatewayID2[:], RxPacketsReceived: 10}
+
+	f, err := os.Create(filepath.Join(dir, "dead-letter.json"))
+	assert.NoError(err)
+	enc := json.NewEncoder(f)
+
+	for _, dl := range []struct {
+		time      time.Time
+		gatewayID lorawan.EUI64
+		event     string
+		msg       proto.Message
+	}{
+		{now, gatewayID1, "up", &uplink},
+		{now.Add(time.Minute), gatewayID2, "stats", &stats},
+		{now.Add(2 * time.Minute), gatewayID1, "conn", &uplink},
+	} {
+		b, err := marshal(dl.msg)
+		assert.NoError(err)
+
+		assert.NoError(enc.Encode(deadLetter{
+			Time:      dl.time,
+			GatewayID: dl.gatewayID,
+			Event:     dl.event,
+			Payload:   b,
+		}))
+	}
+	assert.NoError(f.Close())
+
+	unmarshal := func(string) (marshaler.UnmarshalFunc, error) {
+		_, unmarshal, err := marshaler.Get("json")
+		return unmarshal, err
+	}
+
+	tests := []struct {
+		Name           string
+		Filters        archive.Filters
+		ExpectedEvents []Event
+	}{
+		{
+			Name: "all supported events",
+			ExpectedEvents: []Event{
+				{Time: now, GatewayID: gatewayID1, EventType: "up", Message: &uplink},
+				{Time: now.Add(time.Minute), GatewayID: gatewayID2, EventType: "stats", Message: &stats},
+			},
+		},
+		{
+			Name:    "by gateway",
+			Filters: archive.Filters{GatewayID: &gatewayID2},
+			ExpectedEvents: []Event{
+				{Time: now.Add(time.Minute), GatewayID: gatewayID2, EventType: "stats", Message: &stats},
+			},
+		},
+		{
+			Name:    "by event type",
+			Filters: archive.Filters{EventType: "up"},
+			ExpectedEvents: []Event{
+				{Time: now, GatewayID: gatewayID1, EventType: "up", Message: &uplink},
+			},
+		},
+		{
+			Name:    "since",
+			Filters: archive.Filters{Since: now.Add(30 * time.Second)},
+			ExpectedEvents: []Event{
+				{Time: now.Add(time.Minute), GatewayID: gatewayID2, EventType: "stats", Message: &stats},
+			},
+		},
+		{
+			Name:    "limit",
+			Filters: archive.Filters{Limit: 1},
+			ExpectedEvents: []Event{
+				{Time: now, GatewayID: gatewayID1, EventType: "up", Message: &uplink},
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			events, err := FromDeadLetterFile(filepath.Join(dir, "dead-letter.json"), tst.Filters, unmarshal)
+			assert.NoError(err)
+			assert.Len(events, len(tst.ExpectedEvents))
+
+			for i := range events {
+				// a new event ID is generated
+				assert.NotEqual(uuid.Nil, events[i].EventID)
+				events[i].EventID = uuid.Nil
+
+				assert.True(events[i].Time.Equal(tst.ExpectedEvents[i].Time))
+				events[i].Time = tst.ExpectedEvents[i].Time
+
+				assert.True(proto.Equal(tst.ExpectedEvents[i].Message, events[i].Message))
+				events[i].Message = tst.ExpectedEvents[i].Message
+			}
+
+			assert.Equal(tst.ExpectedEvents, events)
+		})
+	}
+}
+
+func TestReplay(t *testing.T) {
+	var sleeps []time.Duration
+	sleep = func(d time.Duration) {
+		sleeps = append(sleeps, d)
+	}
+	defer func() {
+		sleep = time.Sleep
+	}()
+
+	events := []Event{
+		{GatewayID: lorawan.EUI64{1}, EventType: "up", EventID: uuid.Must(uuid.NewV4()), Message: &gw.UplinkFrame{}},
+		{GatewayID: lorawan.EUI64{2}, EventType: "stats", EventID: uuid.Must(uuid.NewV4()), Message: &gw.GatewayStats{}},
+		{GatewayID: lorawan.EUI64{3}, EventType: "ack", EventID: uuid.Must(uuid.NewV4()), Message: &gw.DownlinkTXAck{}},
+	}
+
+	t.Run("Rate", func(t *testing.T) {
+		assert := require.New(t)
+		sleeps = nil
+
+		var p testPublisher
+		count, err := Replay(&p, events, 4)
+		assert.NoError(err)
+		assert.Equal(3, count)
+		assert.Equal([]time.Duration{250 * time.Millisecond, 250 * time.Millisecond}, sleeps)
+
+		for i, e := range events {
+			assert.Equal(publishedEvent{e.GatewayID, e.EventType, e.EventID, e.Message}, p.events[i])
+		}
+	})
+
+	t.Run("No limit", func(t *testing.T) {
+		assert := require.New(t)
+		sleeps = nil
+
+		var p testPublisher
+		count, err := Replay(&p, events, 0)
+		assert.NoError(err)
+		assert.Equal(3, count)
+		assert.Len(sleeps, 0)
+	})
+
+	t.Run("Publish error", func(t *testing.T) {
+		assert := require.New(t)
+
+		p := testPublisher{
+			err:    errors.New("not connected"),
+			failAt: 1,
+		}
+		count, err := Replay(&p, events, 0)
+		assert.Error(err)
+		assert.Equal(1, count)
+		assert.Len(p.events, 1)
+	})
+}
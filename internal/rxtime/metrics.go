@@ -0,0 +1,19 @@
+package rxtime
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	dc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "rx_time_drift_count",
+		Help: "The number of uplinks with a RX time exceeding the configured max. drift (per gateway).",
+	}, []string{"gateway_id"})
+)
+
+func driftCounter(gatewayID lorawan.EUI64) prometheus.Counter {
+	return dc.With(prometheus.Labels{"gateway_id": gatewayID.String()})
+}
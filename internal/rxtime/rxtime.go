@@ -0,0 +1,150 @@
+// Package rxtime implements the selection of the authoritative RX time of
+// uplinks (gateway GPS time, gateway system time or bridge receive time),
+// including a drift sanity-check against the bridge receive time.
+package rxtime
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/gps"
+)
+
+// Clock sources.
+const (
+	SourceGPS    = "gps"
+	SourceSystem = "system"
+	SourceBridge = "bridge"
+)
+
+var (
+	mux      sync.RWMutex
+	source   = SourceSystem
+	maxDrift time.Duration
+	gateways map[lorawan.EUI64]string
+)
+
+// Setup configures the RX time clock source.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if err := validateSource(conf.RXTime.Source); err != nil {
+		return err
+	}
+
+	source = conf.RXTime.Source
+	maxDrift = conf.RXTime.MaxDrift
+	gateways = make(map[lorawan.EUI64]string)
+
+	for _, c := range conf.RXTime.Gateways {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(c.GatewayID)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+
+		if err := validateSource(c.Source); err != nil {
+			return err
+		}
+
+		gateways[gatewayID] = c.Source
+	}
+
+	log.WithFields(log.Fields{
+		"source":    source,
+		"max_drift": maxDrift,
+		"overrides": len(gateways),
+	}).Info("rxtime: rx time clock source configured")
+
+	return nil
+}
+
+// ApplyToUplinkFrame sets the RX time of the uplink frame, using the
+// configured clock source of the gateway.
+func ApplyToUplinkFrame(frame *gw.UplinkFrame) error {
+	return applyToUplinkFrame(frame, time.Now())
+}
+
+func applyToUplinkFrame(frame *gw.UplinkFrame, now time.Time) error {
+	if frame.RxInfo == nil {
+		return nil
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.RxInfo.GetGatewayId())
+
+	mux.RLock()
+	src := getSource(gatewayID)
+	drift := maxDrift
+	mux.RUnlock()
+
+	switch src {
+	case SourceGPS:
+		if frame.RxInfo.TimeSinceGpsEpoch != nil {
+			d, err := ptypes.Duration(frame.RxInfo.TimeSinceGpsEpoch)
+			if err != nil {
+				return errors.Wrap(err, "time since gps epoch error")
+			}
+
+			ts, err := ptypes.TimestampProto(time.Time(gps.NewTimeFromTimeSinceGPSEpoch(d)))
+			if err != nil {
+				return errors.Wrap(err, "timestamp proto error")
+			}
+			frame.RxInfo.Time = ts
+		}
+	case SourceBridge:
+		ts, err := ptypes.TimestampProto(now)
+		if err != nil {
+			return errors.Wrap(err, "timestamp proto error")
+		}
+		frame.RxInfo.Time = ts
+	}
+
+	if drift == 0 || frame.RxInfo.Time == nil {
+		return nil
+	}
+
+	ts, err := ptypes.Timestamp(frame.RxInfo.Time)
+	if err != nil {
+		return errors.Wrap(err, "timestamp error")
+	}
+
+	if d := ts.Sub(now); d > drift || d < -drift {
+		driftCounter(gatewayID).Inc()
+
+		log.WithFields(log.Fields{
+			"gateway_id": gatewayID,
+			"source":     src,
+			"rx_time":    ts,
+			"drift":      d,
+		}).Warning("rxtime: rx time exceeds max drift, check the gateway clock")
+	}
+
+	return nil
+}
+
+// getSource returns the clock source for the given gateway. This must be
+// called with the mutex locked.
+func getSource(gatewayID lorawan.EUI64) string {
+	if src, ok := gateways[gatewayID]; ok {
+		return src
+	}
+	return source
+}
+
+func validateSource(src string) error {
+	switch src {
+	case SourceGPS, SourceSystem, SourceBridge:
+		return nil
+	default:
+		return fmt.Errorf("invalid rx_time source: %s", src)
+	}
+}
@@ -0,0 +1,155 @@
+package rxtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/duration"
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/gps"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Source        string
+		GatewayID     string
+		GatewaySource string
+		ExpectedError string
+	}{
+		{
+			Name:   "valid source",
+			Source: SourceGPS,
+		},
+		{
+			Name:          "valid gateway source",
+			Source:        SourceSystem,
+			GatewayID:     "0102030405060708",
+			GatewaySource: SourceBridge,
+		},
+		{
+			Name:          "invalid source",
+			Source:        "foo",
+			ExpectedError: "invalid rx_time source: foo",
+		},
+		{
+			Name:          "invalid gateway source",
+			Source:        SourceSystem,
+			GatewayID:     "0102030405060708",
+			GatewaySource: "foo",
+			ExpectedError: "invalid rx_time source: foo",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.RXTime.Source = tst.Source
+			if tst.GatewayID != "" {
+				conf.RXTime.Gateways = append(conf.RXTime.Gateways, struct {
+					GatewayID string `mapstructure:"gateway_id"`
+					Source    string `mapstructure:"source"`
+				}{tst.GatewayID, tst.GatewaySource})
+			}
+
+			err := Setup(conf)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
+
+func TestApplyToUplinkFrame(t *testing.T) {
+	now := time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC)
+	systemTime := now.Add(-time.Second)
+	gpsTime := now.Add(-2 * time.Second)
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	overrideGatewayID := lorawan.EUI64{8, 7, 6, 5, 4, 3, 2, 1}
+
+	systemTimeProto, _ := ptypes.TimestampProto(systemTime)
+	gpsTimeProto, _ := ptypes.TimestampProto(gpsTime)
+	nowProto, _ := ptypes.TimestampProto(now)
+	timeSinceGPSEpoch := ptypes.DurationProto(gps.Time(gpsTime).TimeSinceGPSEpoch())
+
+	tests := []struct {
+		Name              string
+		Source            string
+		GatewayID         lorawan.EUI64
+		Time              *timestamp.Timestamp
+		TimeSinceGPSEpoch *duration.Duration
+		ExpectedTime      *timestamp.Timestamp
+	}{
+		{
+			Name:              "system",
+			Source:            SourceSystem,
+			GatewayID:         gatewayID,
+			Time:              systemTimeProto,
+			TimeSinceGPSEpoch: timeSinceGPSEpoch,
+			ExpectedTime:      systemTimeProto,
+		},
+		{
+			Name:              "gps",
+			Source:            SourceGPS,
+			GatewayID:         gatewayID,
+			Time:              systemTimeProto,
+			TimeSinceGPSEpoch: timeSinceGPSEpoch,
+			ExpectedTime:      gpsTimeProto,
+		},
+		{
+			Name:         "gps not available",
+			Source:       SourceGPS,
+			GatewayID:    gatewayID,
+			Time:         systemTimeProto,
+			ExpectedTime: systemTimeProto,
+		},
+		{
+			Name:         "bridge",
+			Source:       SourceBridge,
+			GatewayID:    gatewayID,
+			Time:         systemTimeProto,
+			ExpectedTime: nowProto,
+		},
+		{
+			Name:         "gateway override",
+			Source:       SourceSystem,
+			GatewayID:    overrideGatewayID,
+			Time:         systemTimeProto,
+			ExpectedTime: nowProto,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			source = tst.Source
+			maxDrift = time.Second
+			gateways = map[lorawan.EUI64]string{
+				overrideGatewayID: SourceBridge,
+			}
+
+			frame := gw.UplinkFrame{
+				RxInfo: &gw.UplinkRXInfo{
+					GatewayId:         tst.GatewayID[:],
+					Time:              tst.Time,
+					TimeSinceGpsEpoch: tst.TimeSinceGPSEpoch,
+				},
+			}
+
+			assert.NoError(applyToUplinkFrame(&frame, now))
+			assert.Equal(tst.ExpectedTime, frame.RxInfo.Time)
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package sampling
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/brocaar/lorawan"
+)
+
+var (
+	soc = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "sampling_sampled_out_count",
+		Help: "The number of events which were not published because of the sampling (per gateway and event).",
+	}, []string{"gateway_id", "event"})
+)
+
+func sampledOutCounter(gatewayID lorawan.EUI64, event string) prometheus.Counter {
+	return soc.With(prometheus.Labels{"gateway_id": gatewayID.String(), "event": event})
+}
@@ -0,0 +1,175 @@
+// Package sampling implements the sampling of the published events, to
+// reduce the load of the MQTT broker and network-server in deployments
+// receiving a high volume of (foreign) traffic. Per event type (and for
+// uplinks optionally per NetID), only 1 in N events is published. Join and
+// rejoin requests are always published. The events which are sampled out are
+// counted using a metric.
+package sampling
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// events contains the event types supporting sampling.
+var events = map[string]struct{}{
+	"up":    {},
+	"stats": {},
+	"ack":   {},
+}
+
+type rule struct {
+	event  string
+	netIDs []lorawan.NetID
+	rate   uint64
+
+	// count contains the number of matching events.
+	count uint64
+}
+
+var (
+	mux   sync.RWMutex
+	rules []*rule
+)
+
+// Setup configures the sampling rules.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	rules = nil
+
+	for i, c := range conf.Sampling.Rules {
+		r, err := newRule(c)
+		if err != nil {
+			return errors.Wrapf(err, "sampling rule %d error", i)
+		}
+		rules = append(rules, r)
+	}
+
+	if len(rules) != 0 {
+		log.WithField("rules", len(rules)).Info("sampling: event sampling configured")
+	}
+
+	return nil
+}
+
+// Publish returns true when the given event must be published. The first
+// matching rule is used, events without matching rule are always published.
+func Publish(gatewayID lorawan.EUI64, event string, msg proto.Message) bool {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if len(rules) == 0 {
+		return true
+	}
+
+	var devAddr *lorawan.DevAddr
+	if frame, ok := msg.(*gw.UplinkFrame); ok {
+		var alwaysPublish bool
+		devAddr, alwaysPublish = getDevAddr(frame.PhyPayload)
+		if alwaysPublish {
+			return true
+		}
+	}
+
+	for _, r := range rules {
+		if !r.match(event, devAddr) {
+			continue
+		}
+
+		// publish the first of every rate events
+		if (atomic.AddUint64(&r.count, 1)-1)%r.rate == 0 {
+			return true
+		}
+
+		sampledOutCounter(gatewayID, event).Inc()
+		return false
+	}
+
+	return true
+}
+
+func newRule(c config.SamplingRule) (*rule, error) {
+	if _, ok := events[c.Event]; !ok {
+		return nil, errors.Errorf("unsupported event: %s", c.Event)
+	}
+
+	if c.Rate < 1 {
+		return nil, errors.New("rate must be at least 1")
+	}
+
+	if len(c.NetIDs) != 0 && c.Event != "up" {
+		return nil, errors.New("net_ids are only supported for the up event")
+	}
+
+	r := rule{
+		event: c.Event,
+		rate:  uint64(c.Rate),
+	}
+
+	for _, s := range c.NetIDs {
+		var netID lorawan.NetID
+		if err := netID.UnmarshalText([]byte(s)); err != nil {
+			return nil, errors.Wrap(err, "unmarshal NetID error")
+		}
+		r.netIDs = append(r.netIDs, netID)
+	}
+
+	return &r, nil
+}
+
+// match returns true when the rule matches the given event. The devAddr is
+// set for data uplinks.
+func (r *rule) match(event string, devAddr *lorawan.DevAddr) bool {
+	if r.event != event {
+		return false
+	}
+
+	if len(r.netIDs) == 0 {
+		return true
+	}
+
+	if devAddr == nil {
+		return false
+	}
+
+	for _, netID := range r.netIDs {
+		if devAddr.IsNetID(netID) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getDevAddr returns the DevAddr of the given data uplink. It returns true
+// when the uplink must always be published (join and rejoin requests).
+// For other frames (e.g. proprietary or invalid frames) it returns nil.
+func getDevAddr(b []byte) (*lorawan.DevAddr, bool) {
+	var phy lorawan.PHYPayload
+	if err := phy.UnmarshalBinary(b); err != nil {
+		return nil, false
+	}
+
+	switch phy.MHDR.MType {
+	case lorawan.JoinRequest, lorawan.RejoinRequest:
+		return nil, true
+	case lorawan.UnconfirmedDataUp, lorawan.ConfirmedDataUp:
+		mac, ok := phy.MACPayload.(*lorawan.MACPayload)
+		if !ok {
+			return nil, false
+		}
+		return &mac.FHDR.DevAddr, false
+	default:
+		return nil, false
+	}
+}
@@ -0,0 +1,147 @@
+package sampling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func phyPayload(t *testing.T, phy lorawan.PHYPayload) []byte {
+	b, err := phy.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestPublish(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.Sampling.Rules = []config.SamplingRule{
+		{Event: "up", NetIDs: []string{"000013"}, Rate: 3},
+		{Event: "stats", Rate: 2},
+	}
+	assert.NoError(Setup(conf))
+	defer Setup(config.Config{})
+
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	var netID lorawan.NetID
+	assert.NoError(netID.UnmarshalText([]byte("000013")))
+	var devAddr lorawan.DevAddr
+	devAddr.SetAddrPrefix(netID)
+
+	dataUp := func(devAddr lorawan.DevAddr) *gw.UplinkFrame {
+		return &gw.UplinkFrame{
+			PhyPayload: phyPayload(t, lorawan.PHYPayload{
+				MHDR: lorawan.MHDR{MType: lorawan.UnconfirmedDataUp, Major: lorawan.LoRaWANR1},
+				MACPayload: &lorawan.MACPayload{
+					FHDR: lorawan.FHDR{DevAddr: devAddr},
+				},
+			}),
+		}
+	}
+
+	joinRequest := &gw.UplinkFrame{
+		PhyPayload: phyPayload(t, lorawan.PHYPayload{
+			MHDR:       lorawan.MHDR{MType: lorawan.JoinRequest, Major: lorawan.LoRaWANR1},
+			MACPayload: &lorawan.JoinRequestPayload{},
+		}),
+	}
+
+	tests := []struct {
+		Name     string
+		Event    string
+		Message  func() interface{}
+		Expected []bool
+	}{
+		{
+			Name:     "matching NetID is sampled",
+			Event:    "up",
+			Message:  func() interface{} { return dataUp(devAddr) },
+			Expected: []bool{true, false, false, true, false, false},
+		},
+		{
+			Name:     "other NetID is always published",
+			Event:    "up",
+			Message:  func() interface{} { return dataUp(lorawan.DevAddr{1, 2, 3, 4}) },
+			Expected: []bool{true, true, true},
+		},
+		{
+			Name:     "join-request is always published",
+			Event:    "up",
+			Message:  func() interface{} { return joinRequest },
+			Expected: []bool{true, true, true},
+		},
+		{
+			Name:     "stats are sampled",
+			Event:    "stats",
+			Message:  func() interface{} { return &gw.GatewayStats{} },
+			Expected: []bool{true, false, true, false},
+		},
+		{
+			Name:     "ack without rule is always published",
+			Event:    "ack",
+			Message:  func() interface{} { return &gw.DownlinkTXAck{} },
+			Expected: []bool{true, true},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var out []bool
+			for range tst.Expected {
+				switch v := tst.Message().(type) {
+				case *gw.UplinkFrame:
+					out = append(out, Publish(gatewayID, tst.Event, v))
+				case *gw.GatewayStats:
+					out = append(out, Publish(gatewayID, tst.Event, v))
+				case *gw.DownlinkTXAck:
+					out = append(out, Publish(gatewayID, tst.Event, v))
+				}
+			}
+			assert.Equal(tst.Expected, out)
+		})
+	}
+}
+
+func TestSetupValidation(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Rule          config.SamplingRule
+		ExpectedError string
+	}{
+		{
+			Name:          "unsupported event",
+			Rule:          config.SamplingRule{Event: "conn", Rate: 2},
+			ExpectedError: "sampling rule 0 error: unsupported event: conn",
+		},
+		{
+			Name:          "invalid rate",
+			Rule:          config.SamplingRule{Event: "up"},
+			ExpectedError: "sampling rule 0 error: rate must be at least 1",
+		},
+		{
+			Name:          "net_ids for stats",
+			Rule:          config.SamplingRule{Event: "stats", NetIDs: []string{"000013"}, Rate: 2},
+			ExpectedError: "sampling rule 0 error: net_ids are only supported for the up event",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.Sampling.Rules = []config.SamplingRule{tst.Rule}
+			assert.EqualError(Setup(conf), tst.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package secrets
+
+import (
+	"fmt"
+	"os"
+)
+
+// getEnvSecret returns the value of the given environment variable.
+func getEnvSecret(name string) ([]byte, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return nil, fmt.Errorf("environment variable %s is not set", name)
+	}
+	return []byte(v), nil
+}
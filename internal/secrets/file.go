@@ -0,0 +1,33 @@
+package secrets
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// getFileSecret returns the content of the given file, with the trailing
+// newline removed. The path is always absolute. The file must not be
+// accessible by group or others.
+func getFileSecret(path string) ([]byte, error) {
+	path = "/" + path
+
+	fi, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "stat file error")
+	}
+
+	if perm := fi.Mode().Perm(); perm&0077 != 0 {
+		return nil, fmt.Errorf("file %s must not be accessible by group or others (mode: %s)", path, perm)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "read file error")
+	}
+
+	return bytes.TrimRight(b, "\r\n"), nil
+}
@@ -0,0 +1,89 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// gcpTokenURL is the metadata server endpoint returning the access
+	// token of the default service account.
+	gcpTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+	// gcpSecretManagerURL is the Secret Manager API endpoint.
+	gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1/"
+)
+
+// getGCPSecret returns the given Google Cloud Secret Manager secret version
+// (e.g. projects/my-project/secrets/mqtt-password/versions/latest). The
+// access token of the default service account is requested from the
+// metadata server, thus this requires running on Google Cloud.
+func getGCPSecret(name string) ([]byte, error) {
+	token, err := getGCPAccessToken()
+	if err != nil {
+		return nil, errors.Wrap(err, "get access token error")
+	}
+
+	req, err := http.NewRequest("GET", gcpSecretManagerURL+name+":access", nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request error")
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("expected 200, got: %d", resp.StatusCode)
+	}
+
+	var pl struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pl); err != nil {
+		return nil, errors.Wrap(err, "decode response error")
+	}
+
+	b, err := base64.StdEncoding.DecodeString(pl.Payload.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "decode secret payload error")
+	}
+
+	return b, nil
+}
+
+func getGCPAccessToken() (string, error) {
+	req, err := http.NewRequest("GET", gcpTokenURL, nil)
+	if err != nil {
+		return "", errors.Wrap(err, "new request error")
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", errors.Wrap(err, "http request error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("expected 200, got: %d", resp.StatusCode)
+	}
+
+	var pl struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pl); err != nil {
+		return "", errors.Wrap(err, "decode response error")
+	}
+
+	return pl.AccessToken, nil
+}
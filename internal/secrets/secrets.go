@@ -0,0 +1,162 @@
+// Package secrets resolves the secret:// references in the configuration.
+// A reference has the format secret://<provider>/<path>[#<key>], where the
+// optional key selects a field when the secret is a JSON object.
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
+)
+
+// Prefix is the prefix of a secret reference.
+const Prefix = "secret://"
+
+// provider returns the secret for the given path.
+type provider func(path string) ([]byte, error)
+
+var providers = map[string]provider{
+	"env":   getEnvSecret,
+	"file":  getFileSecret,
+	"vault": getVaultSecret,
+	"aws":   getAWSSecret,
+	"gcp":   getGCPSecret,
+}
+
+// httpClient is used by the providers using a HTTP API.
+var httpClient = &http.Client{
+	Timeout:   10 * time.Second,
+	Transport: proxy.Transport(),
+}
+
+// Resolve replaces the secret references in the (string) fields, slices
+// and maps of the given struct pointer by the referenced secrets.
+func Resolve(v interface{}) error {
+	return resolveValue(reflect.ValueOf(v).Elem(), "")
+}
+
+// Get returns the secret for the given reference.
+func Get(ref string) (string, error) {
+	if !strings.HasPrefix(ref, Prefix) {
+		return "", fmt.Errorf("secret reference must start with %s", Prefix)
+	}
+
+	ref = strings.TrimPrefix(ref, Prefix)
+
+	var key string
+	if i := strings.LastIndex(ref, "#"); i != -1 {
+		ref, key = ref[:i], ref[i+1:]
+	}
+
+	parts := strings.SplitN(ref, "/", 2)
+	if len(parts) != 2 || parts[1] == "" {
+		return "", errors.New("secret reference must have the format secret://<provider>/<path>")
+	}
+
+	p, ok := providers[parts[0]]
+	if !ok {
+		return "", fmt.Errorf("unknown secret provider: %s", parts[0])
+	}
+
+	b, err := p(parts[1])
+	if err != nil {
+		return "", errors.Wrapf(err, "get %s secret error", parts[0])
+	}
+
+	if key == "" {
+		return string(b), nil
+	}
+
+	return getKey(b, key)
+}
+
+// getKey returns the value of the given key of the secret JSON object.
+func getKey(b []byte, key string) (string, error) {
+	var obj map[string]interface{}
+	if err := json.Unmarshal(b, &obj); err != nil {
+		return "", errors.Wrap(err, "unmarshal secret json error")
+	}
+
+	v, ok := obj[key]
+	if !ok {
+		return "", fmt.Errorf("secret key %s does not exist", key)
+	}
+
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+
+	out, err := json.Marshal(v)
+	if err != nil {
+		return "", errors.Wrap(err, "marshal secret value error")
+	}
+	return string(out), nil
+}
+
+func resolveValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if !v.Field(i).CanSet() {
+				continue
+			}
+
+			if err := resolveValue(v.Field(i), path+"."+v.Type().Field(i).Name); err != nil {
+				return err
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+
+		for _, k := range v.MapKeys() {
+			s := v.MapIndex(k).String()
+			if !strings.HasPrefix(s, Prefix) {
+				continue
+			}
+
+			secret, err := resolveString(s, fmt.Sprintf("%s[%v]", path, k))
+			if err != nil {
+				return err
+			}
+			v.SetMapIndex(k, reflect.ValueOf(secret).Convert(v.Type().Elem()))
+		}
+	case reflect.String:
+		if !strings.HasPrefix(v.String(), Prefix) {
+			return nil
+		}
+
+		secret, err := resolveString(v.String(), path)
+		if err != nil {
+			return err
+		}
+		v.SetString(secret)
+	}
+
+	return nil
+}
+
+func resolveString(ref, path string) (string, error) {
+	secret, err := Get(ref)
+	if err != nil {
+		return "", errors.Wrapf(err, "resolve secret error (field: %s)", strings.TrimPrefix(path, "."))
+	}
+
+	log.WithField("field", strings.TrimPrefix(path, ".")).Info("secrets: secret resolved")
+	return secret, nil
+}
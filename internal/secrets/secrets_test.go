@@ -0,0 +1,232 @@
+package secrets
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "secrets")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	privateFile := filepath.Join(dir, "private")
+	assert.NoError(ioutil.WriteFile(privateFile, []byte("file-secret\n"), 0600))
+
+	publicFile := filepath.Join(dir, "public")
+	assert.NoError(ioutil.WriteFile(publicFile, []byte("file-secret\n"), 0644))
+
+	os.Setenv("LGB_TEST_SECRET", "env-secret")
+	os.Setenv("LGB_TEST_JSON_SECRET", `{"username": "user", "password": "pass"}`)
+	defer os.Unsetenv("LGB_TEST_SECRET")
+	defer os.Unsetenv("LGB_TEST_JSON_SECRET")
+
+	tests := []struct {
+		Name     string
+		Ref      string
+		Expected string
+		Error    bool
+	}{
+		{
+			Name:     "environment variable",
+			Ref:      "secret://env/LGB_TEST_SECRET",
+			Expected: "env-secret",
+		},
+		{
+			Name:  "environment variable not set",
+			Ref:   "secret://env/LGB_TEST_DOES_NOT_EXIST",
+			Error: true,
+		},
+		{
+			Name:     "json key",
+			Ref:      "secret://env/LGB_TEST_JSON_SECRET#password",
+			Expected: "pass",
+		},
+		{
+			Name:  "json key does not exist",
+			Ref:   "secret://env/LGB_TEST_JSON_SECRET#token",
+			Error: true,
+		},
+		{
+			Name:     "file",
+			Ref:      "secret://file" + privateFile,
+			Expected: "file-secret",
+		},
+		{
+			Name:  "file readable by others",
+			Ref:   "secret://file" + publicFile,
+			Error: true,
+		},
+		{
+			Name:  "unknown provider",
+			Ref:   "secret://foo/bar",
+			Error: true,
+		},
+		{
+			Name:  "missing path",
+			Ref:   "secret://env",
+			Error: true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			secret, err := Get(tst.Ref)
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.Expected, secret)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	assert := require.New(t)
+
+	os.Setenv("LGB_TEST_SECRET", "env-secret")
+	defer os.Unsetenv("LGB_TEST_SECRET")
+
+	var conf struct {
+		Password string
+		Nested   struct {
+			Token string
+			Plain string
+		}
+		List []string
+		Map  map[string]string
+	}
+	conf.Password = "secret://env/LGB_TEST_SECRET"
+	conf.Nested.Token = "secret://env/LGB_TEST_SECRET"
+	conf.Nested.Plain = "plain"
+	conf.List = []string{"plain", "secret://env/LGB_TEST_SECRET"}
+	conf.Map = map[string]string{"key": "secret://env/LGB_TEST_SECRET"}
+
+	assert.NoError(Resolve(&conf))
+	assert.Equal("env-secret", conf.Password)
+	assert.Equal("env-secret", conf.Nested.Token)
+	assert.Equal("plain", conf.Nested.Plain)
+	assert.Equal([]string{"plain", "env-secret"}, conf.List)
+	assert.Equal(map[string]string{"key": "env-secret"}, conf.Map)
+
+	conf.Password = "secret://env/LGB_TEST_DOES_NOT_EXIST"
+	assert.Error(Resolve(&conf))
+}
+
+func TestVault(t *testing.T) {
+	assert := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		switch r.URL.Path {
+		case "/v1/secret/data/lgb":
+			fmt.Fprint(w, `{"data": {"data": {"password": "kv2-secret"}, "metadata": {"version": 1}}}`)
+		case "/v1/kv/lgb":
+			fmt.Fprint(w, `{"data": {"password": "kv1-secret"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	os.Setenv("VAULT_ADDR", server.URL)
+	os.Setenv("VAULT_TOKEN", "test-token")
+	defer os.Unsetenv("VAULT_ADDR")
+	defer os.Unsetenv("VAULT_TOKEN")
+
+	secret, err := Get("secret://vault/secret/data/lgb#password")
+	assert.NoError(err)
+	assert.Equal("kv2-secret", secret)
+
+	secret, err = Get("secret://vault/kv/lgb#password")
+	assert.NoError(err)
+	assert.Equal("kv1-secret", secret)
+
+	_, err = Get("secret://vault/kv/does-not-exist#password")
+	assert.Error(err)
+}
+
+func TestAWS(t *testing.T) {
+	assert := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Amz-Target") != "secretsmanager.GetSecretValue" ||
+			!strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") ||
+			!strings.Contains(r.Header.Get("Authorization"), "/eu-west-1/secretsmanager/aws4_request") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+
+		b, _ := ioutil.ReadAll(r.Body)
+		if string(b) != `{"SecretId":"lgb"}` {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		fmt.Fprint(w, `{"SecretString": "{\"password\": \"aws-secret\"}"}`)
+	}))
+	defer server.Close()
+
+	awsEndpoint = func(region string) string { return server.URL + "/" }
+
+	os.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "SECRET")
+	os.Setenv("AWS_REGION", "eu-west-1")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	defer os.Unsetenv("AWS_REGION")
+
+	secret, err := Get("secret://aws/lgb#password")
+	assert.NoError(err)
+	assert.Equal("aws-secret", secret)
+}
+
+func TestGCP(t *testing.T) {
+	assert := require.New(t)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprint(w, `{"access_token": "test-token"}`)
+		case "/v1/projects/p/secrets/s/versions/latest:access":
+			if r.Header.Get("Authorization") != "Bearer test-token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fmt.Fprintf(w, `{"payload": {"data": "%s"}}`, base64.StdEncoding.EncodeToString([]byte("gcp-secret")))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	gcpTokenURL = server.URL + "/token"
+	gcpSecretManagerURL = server.URL + "/v1/"
+
+	secret, err := Get("secret://gcp/projects/p/secrets/s/versions/latest")
+	assert.NoError(err)
+	assert.Equal("gcp-secret", secret)
+}
@@ -0,0 +1,57 @@
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// getVaultSecret returns the secret stored at the given path of the
+// HashiCorp Vault server configured by the VAULT_ADDR and VAULT_TOKEN
+// environment variables. For the KV version 2 secrets engine, the path must
+// include the data/ segment (e.g. secret/data/lora-gateway-bridge). The
+// secret data is returned as JSON object.
+func getVaultSecret(path string) ([]byte, error) {
+	addr := os.Getenv("VAULT_ADDR")
+	if addr == "" {
+		return nil, errors.New("VAULT_ADDR environment variable is not set")
+	}
+
+	req, err := http.NewRequest("GET", strings.TrimRight(addr, "/")+"/v1/"+path, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "new request error")
+	}
+	req.Header.Set("X-Vault-Token", os.Getenv("VAULT_TOKEN"))
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "http request error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("expected 200, got: %d", resp.StatusCode)
+	}
+
+	var pl struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&pl); err != nil {
+		return nil, errors.Wrap(err, "decode response error")
+	}
+
+	// the kv version 2 secrets engine nests the secret data
+	if data, ok := pl.Data["data"]; ok && pl.Data["metadata"] != nil {
+		return data, nil
+	}
+
+	b, err := json.Marshal(pl.Data)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal secret data error")
+	}
+	return b, nil
+}
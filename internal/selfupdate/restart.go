@@ -0,0 +1,20 @@
+// +build !windows
+
+package selfupdate
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+// restart replaces the running process by the (updated) executable, using
+// the same arguments and environment. The process ID does not change, so
+// that the service manager (e.g. systemd) keeps tracking the process.
+func restart(exe string) error {
+	if err := syscall.Exec(exe, os.Args, os.Environ()); err != nil {
+		return errors.Wrap(err, "exec error")
+	}
+	return nil
+}
@@ -0,0 +1,11 @@
+// +build windows
+
+package selfupdate
+
+import (
+	"github.com/pkg/errors"
+)
+
+func restart(exe string) error {
+	return errors.New("restart is not supported on windows")
+}
@@ -0,0 +1,426 @@
+// Package selfupdate implements the self-update of the LoRa Gateway Bridge
+// binary. The manifest of a release artifact (version, URL, size and
+// digest) is verified using the configured public key, after which the
+// artifact is downloaded, verified against the manifest and swapped with the
+// running binary. The LoRa Gateway Bridge then restarts itself.
+package selfupdate
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/proxy"
+)
+
+type updateRequest struct {
+	Manifest  string `json:"manifest"`
+	Signature string `json:"signature"`
+}
+
+// manifest describes a release artifact. As the manifest is signed, its
+// version, size and digest can be trusted.
+type manifest struct {
+	Version string `json:"version"`
+	URL     string `json:"url"`
+	Size    int64  `json:"size"`
+	SHA256  string `json:"sha256"`
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+var (
+	mux      sync.Mutex
+	updating bool
+
+	publicKey *ecdsa.PublicKey
+	client    *http.Client
+
+	// version contains the version of the running binary.
+	version string
+
+	// restartDelay gives the admin API the time to send the response,
+	// before the process is replaced.
+	restartDelay = time.Second
+)
+
+// Setup configures the self-update and registers the admin API handler. The
+// given version is the version of the running binary.
+func Setup(conf config.Config, v string) error {
+	if !conf.SelfUpdate.Enabled {
+		return nil
+	}
+
+	if _, err := parseVersion(v); err != nil {
+		return errors.Wrap(err, "parse running version error")
+	}
+	version = v
+
+	if runtime.GOOS == "windows" {
+		return errors.New("self-update is not supported on windows")
+	}
+
+	if conf.Admin.Bind == "" {
+		return errors.New("self-update requires the admin api to be enabled")
+	}
+
+	b, err := ioutil.ReadFile(conf.SelfUpdate.PublicKeyFile)
+	if err != nil {
+		return errors.Wrap(err, "read public key file error")
+	}
+
+	publicKey, err = parsePublicKey(b)
+	if err != nil {
+		return errors.Wrap(err, "parse public key error")
+	}
+
+	client = &http.Client{
+		Timeout:   conf.SelfUpdate.DownloadTimeout,
+		Transport: proxy.Transport(),
+	}
+
+	log.WithFields(log.Fields{
+		"public_key_file": conf.SelfUpdate.PublicKeyFile,
+	}).Info("selfupdate: self-update enabled")
+
+	admin.HandleFunc("/api/self-update", handleHTTP)
+
+	return nil
+}
+
+// Update verifies the given signature of the manifest, downloads the
+// release artifact described by the manifest and replaces the running
+// binary. The version of the manifest must be newer than the running
+// version. It does not restart the LoRa Gateway Bridge.
+func Update(manifestBytes, signature []byte) error {
+	mux.Lock()
+	if updating {
+		mux.Unlock()
+		return errors.New("update already in progress")
+	}
+	updating = true
+	mux.Unlock()
+
+	defer func() {
+		mux.Lock()
+		updating = false
+		mux.Unlock()
+	}()
+
+	m, err := verifyManifest(manifestBytes, signature)
+	if err != nil {
+		return err
+	}
+
+	exe, err := executable()
+	if err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"url":     m.URL,
+		"version": m.Version,
+		"exe":     exe,
+	}).Info("selfupdate: downloading release artifact")
+
+	if err := update(m, exe); err != nil {
+		return err
+	}
+
+	log.WithFields(log.Fields{
+		"exe":     exe,
+		"version": m.Version,
+	}).Info("selfupdate: binary updated")
+
+	return nil
+}
+
+// verifyManifest verifies the signature of the given manifest and returns
+// the decoded manifest, when its version is newer than the running version.
+func verifyManifest(b, signature []byte) (manifest, error) {
+	var m manifest
+
+	if err := verify(b, signature); err != nil {
+		return m, err
+	}
+
+	if err := json.Unmarshal(b, &m); err != nil {
+		return m, errors.Wrap(err, "unmarshal manifest error")
+	}
+
+	if m.URL == "" {
+		return m, errors.New("manifest url must be set")
+	}
+
+	if m.Size <= 0 {
+		return m, errors.New("manifest size must be greater than 0")
+	}
+
+	if digest, err := hex.DecodeString(m.SHA256); err != nil || len(digest) != sha256.Size {
+		return m, errors.New("manifest sha256 must be a hex encoded sha-256 digest")
+	}
+
+	newer, err := newerVersion(m.Version, version)
+	if err != nil {
+		return m, errors.Wrap(err, "parse manifest version error")
+	}
+	if !newer {
+		return m, fmt.Errorf("version %s is not newer than the running version %s", m.Version, version)
+	}
+
+	return m, nil
+}
+
+func update(m manifest, exe string) error {
+	tmp, err := download(m, filepath.Dir(exe))
+	if err != nil {
+		return err
+	}
+
+	if err := swap(tmp, exe); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+
+	return nil
+}
+
+// download downloads the artifact to a temporary file within the given
+// directory, so that it can be renamed atomically. The download is limited
+// to the size of the manifest and its digest must match the manifest.
+func download(m manifest, dir string) (string, error) {
+	resp, err := client.Get(m.URL)
+	if err != nil {
+		return "", errors.Wrap(err, "http get error")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("expected 200 response, got: %d", resp.StatusCode)
+	}
+
+	f, err := ioutil.TempFile(dir, ".lora-gateway-bridge-update-")
+	if err != nil {
+		return "", errors.Wrap(err, "create temporary file error")
+	}
+
+	h := sha256.New()
+
+	// read one byte more than expected, to detect a too large artifact
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(resp.Body, m.Size+1))
+	f.Close()
+	if err == nil {
+		err = checkArtifact(m, n, h.Sum(nil))
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		return "", errors.Wrap(err, "download error")
+	}
+
+	return f.Name(), nil
+}
+
+// checkArtifact checks the size and the SHA-256 digest of the downloaded
+// artifact against the manifest.
+func checkArtifact(m manifest, size int64, digest []byte) error {
+	if size > m.Size {
+		return fmt.Errorf("artifact exceeds the manifest size of %d bytes", m.Size)
+	}
+
+	if size < m.Size {
+		return fmt.Errorf("expected %d bytes, got: %d", m.Size, size)
+	}
+
+	if hex.EncodeToString(digest) != strings.ToLower(m.SHA256) {
+		return errors.New("sha256 does not match the manifest")
+	}
+
+	return nil
+}
+
+// swap renames the downloaded (and verified) artifact to the path of the
+// running binary.
+func swap(tmp, exe string) error {
+	if err := os.Chmod(tmp, 0755); err != nil {
+		return errors.Wrap(err, "chmod error")
+	}
+
+	if err := os.Rename(tmp, exe); err != nil {
+		return errors.Wrap(err, "rename error")
+	}
+
+	return nil
+}
+
+// verify verifies the ASN.1 encoded ECDSA signature of the SHA-256 digest
+// of the given manifest (e.g. created using openssl dgst -sha256 -sign).
+func verify(b, signature []byte) error {
+	var sig ecdsaSignature
+	if _, err := asn1.Unmarshal(signature, &sig); err != nil {
+		return errors.Wrap(err, "unmarshal signature error")
+	}
+
+	digest := sha256.Sum256(b)
+	if !ecdsa.Verify(publicKey, digest[:], sig.R, sig.S) {
+		return errors.New("invalid signature")
+	}
+
+	return nil
+}
+
+// parseVersion parses the MAJOR.MINOR.PATCH numbers of the given version. A
+// leading v and a suffix (e.g. -rc1 or the git describe suffix) are ignored.
+func parseVersion(v string) ([3]int, error) {
+	var out [3]int
+
+	s := strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(s, "-+"); i != -1 {
+		s = s[:i]
+	}
+
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return out, fmt.Errorf("expected MAJOR.MINOR.PATCH version, got: %s", v)
+	}
+
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return out, fmt.Errorf("expected MAJOR.MINOR.PATCH version, got: %s", v)
+		}
+		out[i] = n
+	}
+
+	return out, nil
+}
+
+// newerVersion returns true when version v is newer than the current
+// version.
+func newerVersion(v, current string) (bool, error) {
+	a, err := parseVersion(v)
+	if err != nil {
+		return false, err
+	}
+
+	b, err := parseVersion(current)
+	if err != nil {
+		return false, err
+	}
+
+	for i := range a {
+		if a[i] != b[i] {
+			return a[i] > b[i], nil
+		}
+	}
+
+	return false, nil
+}
+
+func parsePublicKey(b []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, errors.New("no pem block found")
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.Wrap(err, "parse pkix public key error")
+	}
+
+	key, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("expected ecdsa public key, got: %T", pub)
+	}
+
+	return key, nil
+}
+
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", errors.Wrap(err, "get executable path error")
+	}
+
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return "", errors.Wrap(err, "resolve executable path error")
+	}
+
+	return exe, nil
+}
+
+// handleHTTP implements the admin API handler. A POST request updates the
+// binary and restarts the LoRa Gateway Bridge.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req updateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+		return
+	}
+
+	manifestBytes, err := base64.StdEncoding.DecodeString(req.Manifest)
+	if err != nil {
+		admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode manifest error"))
+		return
+	}
+
+	signature, err := base64.StdEncoding.DecodeString(req.Signature)
+	if err != nil {
+		admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode signature error"))
+		return
+	}
+
+	if err := Update(manifestBytes, signature); err != nil {
+		log.WithError(err).Error("selfupdate: update error")
+		admin.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	admin.WriteJSON(w, struct {
+		Status string `json:"status"`
+	}{"restarting"})
+
+	go func() {
+		time.Sleep(restartDelay)
+
+		exe, err := executable()
+		if err != nil {
+			log.WithError(err).Error("selfupdate: restart error")
+			return
+		}
+
+		log.WithField("exe", exe).Warning("selfupdate: restarting")
+		if err := restart(exe); err != nil {
+			log.WithError(err).Error("selfupdate: restart error")
+		}
+	}()
+}
@@ -0,0 +1,311 @@
+package selfupdate
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePublicKey(t *testing.T) {
+	assert := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+
+	b, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	assert.NoError(err)
+
+	pub, err := parsePublicKey(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: b}))
+	assert.NoError(err)
+	assert.Equal(key.PublicKey.X, pub.X)
+	assert.Equal(key.PublicKey.Y, pub.Y)
+
+	_, err = parsePublicKey([]byte("foo"))
+	assert.EqualError(err, "no pem block found")
+}
+
+func TestNewerVersion(t *testing.T) {
+	tests := []struct {
+		Name     string
+		Version  string
+		Current  string
+		Expected bool
+		Error    bool
+	}{
+		{
+			Name:     "newer patch",
+			Version:  "3.0.2",
+			Current:  "3.0.1",
+			Expected: true,
+		},
+		{
+			Name:     "newer major",
+			Version:  "v4.0.0",
+			Current:  "3.10.1",
+			Expected: true,
+		},
+		{
+			Name:     "newer minor compared numerically",
+			Version:  "3.10.0",
+			Current:  "3.9.0",
+			Expected: true,
+		},
+		{
+			Name:    "same version",
+			Version: "3.0.1",
+			Current: "3.0.1-5-g0123abc",
+		},
+		{
+			Name:    "older version",
+			Version: "3.0.0",
+			Current: "3.0.1",
+		},
+		{
+			Name:    "invalid version",
+			Version: "latest",
+			Current: "3.0.1",
+			Error:   true,
+		},
+		{
+			Name:    "invalid current version",
+			Version: "3.0.1",
+			Current: "0123abc",
+			Error:   true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			newer, err := newerVersion(tst.Version, tst.Current)
+			if tst.Error {
+				assert.Error(err)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Expected, newer)
+		})
+	}
+}
+
+func TestVerifyManifest(t *testing.T) {
+	assert := require.New(t)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+	publicKey = &key.PublicKey
+	version = "3.0.1"
+
+	otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(err)
+
+	digest := sha256.Sum256([]byte("new binary"))
+	valid := manifest{
+		Version: "3.1.0",
+		URL:     "https://example.com/lora-gateway-bridge",
+		Size:    10,
+		SHA256:  hex.EncodeToString(digest[:]),
+	}
+
+	tests := []struct {
+		Name     string
+		Manifest manifest
+		Key      *ecdsa.PrivateKey
+		Error    string
+	}{
+		{
+			Name:     "valid manifest",
+			Manifest: valid,
+			Key:      key,
+		},
+		{
+			Name:     "invalid signature",
+			Manifest: valid,
+			Key:      otherKey,
+			Error:    "invalid signature",
+		},
+		{
+			Name: "same version",
+			Manifest: func() manifest {
+				m := valid
+				m.Version = "3.0.1"
+				return m
+			}(),
+			Key:   key,
+			Error: "version 3.0.1 is not newer than the running version 3.0.1",
+		},
+		{
+			Name: "older version",
+			Manifest: func() manifest {
+				m := valid
+				m.Version = "2.9.9"
+				return m
+			}(),
+			Key:   key,
+			Error: "version 2.9.9 is not newer than the running version 3.0.1",
+		},
+		{
+			Name: "no size",
+			Manifest: func() manifest {
+				m := valid
+				m.Size = 0
+				return m
+			}(),
+			Key:   key,
+			Error: "manifest size must be greater than 0",
+		},
+		{
+			Name: "invalid sha256",
+			Manifest: func() manifest {
+				m := valid
+				m.SHA256 = "0102"
+				return m
+			}(),
+			Key:   key,
+			Error: "manifest sha256 must be a hex encoded sha-256 digest",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			b, err := json.Marshal(tst.Manifest)
+			assert.NoError(err)
+
+			m, err := verifyManifest(b, sign(t, tst.Key, b))
+			if tst.Error != "" {
+				assert.EqualError(err, tst.Error)
+				return
+			}
+			assert.NoError(err)
+			assert.Equal(tst.Manifest, m)
+		})
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	artifact := []byte("new binary")
+	digest := sha256.Sum256(artifact)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/lora-gateway-bridge":
+			w.Write(artifact)
+		case "/large":
+			w.Write(append(artifact, artifact...))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	client = &http.Client{
+		Timeout: time.Second,
+	}
+
+	tests := []struct {
+		Name   string
+		Path   string
+		Size   int64
+		SHA256 string
+		Error  string
+	}{
+		{
+			Name:   "not found",
+			Path:   "/foo",
+			Size:   int64(len(artifact)),
+			SHA256: hex.EncodeToString(digest[:]),
+			Error:  "expected 200 response, got: 404",
+		},
+		{
+			Name:   "exceeds size",
+			Path:   "/large",
+			Size:   int64(len(artifact)),
+			SHA256: hex.EncodeToString(digest[:]),
+			Error:  "download error: artifact exceeds the manifest size of 10 bytes",
+		},
+		{
+			Name:   "incomplete",
+			Path:   "/lora-gateway-bridge",
+			Size:   int64(len(artifact)) + 1,
+			SHA256: hex.EncodeToString(digest[:]),
+			Error:  "download error: expected 11 bytes, got: 10",
+		},
+		{
+			Name:   "sha256 mismatch",
+			Path:   "/lora-gateway-bridge",
+			Size:   int64(len(artifact)),
+			SHA256: hex.EncodeToString(make([]byte, sha256.Size)),
+			Error:  "download error: sha256 does not match the manifest",
+		},
+		{
+			Name:   "valid artifact",
+			Path:   "/lora-gateway-bridge",
+			Size:   int64(len(artifact)),
+			SHA256: hex.EncodeToString(digest[:]),
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			dir, err := ioutil.TempDir("", "selfupdate")
+			assert.NoError(err)
+			defer os.RemoveAll(dir)
+
+			exe := filepath.Join(dir, "lora-gateway-bridge")
+			assert.NoError(ioutil.WriteFile(exe, []byte("old binary"), 0755))
+
+			err = update(manifest{
+				Version: "3.1.0",
+				URL:     server.URL + tst.Path,
+				Size:    tst.Size,
+				SHA256:  tst.SHA256,
+			}, exe)
+
+			b, readErr := ioutil.ReadFile(exe)
+			assert.NoError(readErr)
+
+			files, readErr := ioutil.ReadDir(dir)
+			assert.NoError(readErr)
+			assert.Len(files, 1)
+
+			if tst.Error != "" {
+				assert.EqualError(err, tst.Error)
+				assert.Equal([]byte("old binary"), b)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(artifact, b)
+		})
+	}
+}
+
+func sign(t *testing.T, key *ecdsa.PrivateKey, b []byte) []byte {
+	digest := sha256.Sum256(b)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+
+	sig, err := asn1.Marshal(ecdsaSignature{R: r, S: s})
+	require.NoError(t, err)
+	return sig
+}
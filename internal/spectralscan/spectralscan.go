@@ -0,0 +1,68 @@
+// Package spectralscan defines the spectral scan request (command) and result
+// (event) messages. It does not depend on the integration package, so that
+// it can be used by the integration implementations.
+package spectralscan
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Spectral scan statuses.
+const (
+	// StatusStarted is used when the scan has been started by the gateway,
+	// but the results are not returned to the LoRa Gateway Bridge.
+	StatusStarted = "STARTED"
+
+	// StatusDone is used when the scan has completed.
+	StatusDone = "DONE"
+
+	// StatusError is used when the scan failed.
+	StatusError = "ERROR"
+)
+
+// ParseMeasurements parses the output of a spectral scan helper. Each line
+// must contain the frequency (Hz) and RSSI (dBm), separated by a comma.
+// Empty lines and lines starting with # are ignored.
+func ParseMeasurements(b []byte) ([]*Measurement, error) {
+	var out []*Measurement
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for i := 1; scanner.Scan(); i++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		parts := strings.Split(line, ",")
+		if len(parts) != 2 {
+			return nil, fmt.Errorf("line %d: expected <frequency>,<rssi>", i)
+		}
+
+		freq, err := strconv.ParseUint(strings.TrimSpace(parts[0]), 10, 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: parse frequency error", i)
+		}
+
+		rssi, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 32)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d: parse rssi error", i)
+		}
+
+		out = append(out, &Measurement{
+			Frequency: uint32(freq),
+			Rssi:      float32(rssi),
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrap(err, "read output error")
+	}
+
+	return out, nil
+}
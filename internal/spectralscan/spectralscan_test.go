@@ -0,0 +1,81 @@
+package spectralscan
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/integration/marshaler"
+)
+
+func TestParseMeasurements(t *testing.T) {
+	tests := []struct {
+		Name          string
+		In            string
+		Expected      []*Measurement
+		ExpectedError error
+	}{
+		{
+			Name: "valid",
+			In:   "# frequency,rssi\n868100000,-120.5\n\n868300000, -118\n",
+			Expected: []*Measurement{
+				{Frequency: 868100000, Rssi: -120.5},
+				{Frequency: 868300000, Rssi: -118},
+			},
+		},
+		{
+			Name:          "missing rssi",
+			In:            "868100000\n",
+			ExpectedError: errors.New("line 1: expected <frequency>,<rssi>"),
+		},
+		{
+			Name:          "invalid frequency",
+			In:            "868100000,-120\nfoo,-120\n",
+			ExpectedError: errors.New(`line 2: parse frequency error: strconv.ParseUint: parsing "foo": invalid syntax`),
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			out, err := ParseMeasurements([]byte(tst.In))
+			if tst.ExpectedError != nil {
+				assert.EqualError(err, tst.ExpectedError.Error())
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.Expected, out)
+		})
+	}
+}
+
+func TestResult(t *testing.T) {
+	result := Result{
+		GatewayId: []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		ScanId:    []byte{1, 2, 3, 4, 5, 6, 7, 8, 1, 2, 3, 4, 5, 6, 7, 8},
+		Status:    StatusDone,
+		Measurements: []*Measurement{
+			{Frequency: 868100000, Rssi: -120.5},
+		},
+	}
+
+	for _, name := range []string{"json", "protobuf"} {
+		t.Run(name, func(t *testing.T) {
+			assert := require.New(t)
+
+			marshal, unmarshal, err := marshaler.Get(name)
+			assert.NoError(err)
+
+			b, err := marshal(&result)
+			assert.NoError(err)
+
+			var out Result
+			assert.NoError(unmarshal(b, &out))
+			assert.True(proto.Equal(&result, &out))
+		})
+	}
+}
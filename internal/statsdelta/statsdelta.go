@@ -0,0 +1,235 @@
+// Package statsdelta normalizes the packet counters of the gateway stats to
+// per-interval deltas. The Semtech packet-forwarder reports the counters per
+// stats interval, but some packet-forwarders report cumulative totals. The
+// counter semantics are detected per gateway, unless configured.
+package statsdelta
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// Counter modes.
+const (
+	ModeAuto       = "auto"
+	ModeInterval   = "interval"
+	ModeCumulative = "cumulative"
+)
+
+// detectionCount defines the number of consecutive increasing stats after
+// which the counters of a gateway are considered cumulative.
+const detectionCount = 3
+
+// Meta-data keys.
+const (
+	MetaDataCounterMode  = "counter_mode"
+	MetaDataRXPacketLoss = "rx_packet_loss_percent"
+	MetaDataTXPacketLoss = "tx_packet_loss_percent"
+)
+
+var (
+	mux       sync.Mutex
+	mode      = ModeAuto
+	overrides map[lorawan.EUI64]string
+	gateways  = make(map[lorawan.EUI64]*gateway)
+)
+
+type counters struct {
+	rxReceived   uint32
+	rxReceivedOK uint32
+	txReceived   uint32
+	txEmitted    uint32
+}
+
+// decreased returns true when any of the counters decreased compared to the
+// given previous counters.
+func (c counters) decreased(prev counters) bool {
+	return c.rxReceived < prev.rxReceived || c.rxReceivedOK < prev.rxReceivedOK ||
+		c.txReceived < prev.txReceived || c.txEmitted < prev.txEmitted
+}
+
+// sub returns the difference between the counters and the given previous
+// counters.
+func (c counters) sub(prev counters) counters {
+	return counters{
+		rxReceived:   c.rxReceived - prev.rxReceived,
+		rxReceivedOK: c.rxReceivedOK - prev.rxReceivedOK,
+		txReceived:   c.txReceived - prev.txReceived,
+		txEmitted:    c.txEmitted - prev.txEmitted,
+	}
+}
+
+type gateway struct {
+	mode      string
+	last      counters
+	increases int
+}
+
+// Setup configures the stats delta package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if err := validateMode(conf.GatewayStats.CounterMode); err != nil {
+		return err
+	}
+
+	mode = conf.GatewayStats.CounterMode
+	overrides = make(map[lorawan.EUI64]string)
+	gateways = make(map[lorawan.EUI64]*gateway)
+
+	for _, c := range conf.GatewayStats.Gateways {
+		var gatewayID lorawan.EUI64
+		if err := gatewayID.UnmarshalText([]byte(c.GatewayID)); err != nil {
+			return errors.Wrap(err, "unmarshal gateway_id error")
+		}
+
+		if err := validateMode(c.CounterMode); err != nil {
+			return err
+		}
+
+		overrides[gatewayID] = c.CounterMode
+	}
+
+	log.WithFields(log.Fields{
+		"counter_mode": mode,
+		"overrides":    len(overrides),
+	}).Info("statsdelta: gateway stats counter mode configured")
+
+	return nil
+}
+
+// Apply replaces the packet counters of the given stats by the per-interval
+// deltas and adds the counter mode and packet-loss percentages to the stats
+// meta-data.
+func Apply(stats *gw.GatewayStats) {
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], stats.GatewayId)
+
+	cur := counters{
+		rxReceived:   stats.RxPacketsReceived,
+		rxReceivedOK: stats.RxPacketsReceivedOk,
+		txReceived:   stats.TxPacketsReceived,
+		txEmitted:    stats.TxPacketsEmitted,
+	}
+
+	delta, m := normalize(gatewayID, cur)
+
+	stats.RxPacketsReceived = delta.rxReceived
+	stats.RxPacketsReceivedOk = delta.rxReceivedOK
+	stats.TxPacketsReceived = delta.txReceived
+	stats.TxPacketsEmitted = delta.txEmitted
+
+	// the meta-data map might be shared, e.g. with the metadata package
+	md := make(map[string]string)
+	for k, v := range stats.MetaData {
+		md[k] = v
+	}
+
+	md[MetaDataCounterMode] = m
+	if loss, ok := packetLoss(delta.rxReceived, delta.rxReceivedOK); ok {
+		md[MetaDataRXPacketLoss] = loss
+	}
+	if loss, ok := packetLoss(delta.txReceived, delta.txEmitted); ok {
+		md[MetaDataTXPacketLoss] = loss
+	}
+
+	stats.MetaData = md
+}
+
+// normalize returns the per-interval deltas of the given counters and the
+// (detected) counter mode of the gateway.
+func normalize(gatewayID lorawan.EUI64, cur counters) (counters, string) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	g, ok := gateways[gatewayID]
+	if !ok {
+		g = &gateway{mode: ModeAuto}
+		if m, ok := overrides[gatewayID]; ok {
+			g.mode = m
+		} else if mode != ModeAuto {
+			g.mode = mode
+		}
+		gateways[gatewayID] = g
+
+		g.last = cur
+
+		// without a previous value, the delta of cumulative counters is
+		// unknown
+		if g.mode == ModeCumulative {
+			return counters{}, g.mode
+		}
+		return cur, g.mode
+	}
+
+	prev := g.last
+	g.last = cur
+
+	switch g.mode {
+	case ModeInterval:
+		return cur, g.mode
+	case ModeCumulative:
+		// a decrease indicates a counter reset (e.g. a packet-forwarder
+		// restart)
+		if cur.decreased(prev) {
+			return cur, g.mode
+		}
+		return cur.sub(prev), g.mode
+	}
+
+	// auto detection: per-interval counters go up and down, cumulative
+	// counters only increase
+	if cur.decreased(prev) {
+		g.mode = ModeInterval
+		log.WithField("gateway_id", gatewayID).Info("statsdelta: per-interval stats counters detected")
+		return cur, g.mode
+	}
+
+	if cur.rxReceived > prev.rxReceived {
+		g.increases++
+	} else {
+		g.increases = 0
+	}
+
+	if g.increases >= detectionCount {
+		g.mode = ModeCumulative
+		log.WithField("gateway_id", gatewayID).Info("statsdelta: cumulative stats counters detected")
+		return cur.sub(prev), g.mode
+	}
+
+	// until detected, the counters are assumed to be per-interval
+	return cur, g.mode
+}
+
+// packetLoss returns the percentage of packets that were not ok. It returns
+// false when no packets were received.
+func packetLoss(received, ok uint32) (string, bool) {
+	if received == 0 {
+		return "", false
+	}
+
+	var lost uint32
+	if ok < received {
+		lost = received - ok
+	}
+
+	return strconv.FormatFloat(float64(lost)/float64(received)*100, 'f', 2, 64), true
+}
+
+func validateMode(m string) error {
+	switch m {
+	case ModeAuto, ModeInterval, ModeCumulative:
+		return nil
+	default:
+		return fmt.Errorf("invalid counter_mode: %s", m)
+	}
+}
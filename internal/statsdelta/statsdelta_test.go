@@ -0,0 +1,126 @@
+package statsdelta
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func TestApply(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+
+	stats := func(rx, rxOK, tx, txOK uint32) gw.GatewayStats {
+		return gw.GatewayStats{
+			GatewayId:           gatewayID[:],
+			RxPacketsReceived:   rx,
+			RxPacketsReceivedOk: rxOK,
+			TxPacketsReceived:   tx,
+			TxPacketsEmitted:    txOK,
+			MetaData:            map[string]string{"foo": "bar"},
+		}
+	}
+
+	type step struct {
+		In       gw.GatewayStats
+		Expected gw.GatewayStats
+	}
+
+	tests := []struct {
+		Name  string
+		Mode  string
+		Steps []step
+	}{
+		{
+			Name: "auto detects interval counters",
+			Mode: ModeAuto,
+			Steps: []step{
+				{In: stats(10, 8, 2, 2), Expected: stats(10, 8, 2, 2)},
+				{In: stats(20, 20, 0, 0), Expected: stats(20, 20, 0, 0)},
+				{In: stats(5, 5, 1, 1), Expected: stats(5, 5, 1, 1)},
+				{In: stats(6, 6, 1, 1), Expected: stats(6, 6, 1, 1)},
+				{In: stats(7, 7, 1, 1), Expected: stats(7, 7, 1, 1)},
+				{In: stats(8, 8, 1, 1), Expected: stats(8, 8, 1, 1)},
+			},
+		},
+		{
+			Name: "auto detects cumulative counters",
+			Mode: ModeAuto,
+			Steps: []step{
+				{In: stats(10, 10, 1, 1), Expected: stats(10, 10, 1, 1)},
+				{In: stats(20, 19, 2, 2), Expected: stats(20, 19, 2, 2)},
+				{In: stats(30, 29, 3, 3), Expected: stats(30, 29, 3, 3)},
+				{In: stats(40, 38, 4, 3), Expected: stats(10, 9, 1, 0)},
+				{In: stats(50, 48, 4, 3), Expected: stats(10, 10, 0, 0)},
+			},
+		},
+		{
+			Name: "cumulative counters with reset",
+			Mode: ModeCumulative,
+			Steps: []step{
+				{In: stats(100, 100, 10, 10), Expected: stats(0, 0, 0, 0)},
+				{In: stats(110, 108, 11, 11), Expected: stats(10, 8, 1, 1)},
+				{In: stats(5, 5, 0, 0), Expected: stats(5, 5, 0, 0)},
+				{In: stats(15, 14, 0, 0), Expected: stats(10, 9, 0, 0)},
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.GatewayStats.CounterMode = tst.Mode
+			assert.NoError(Setup(conf))
+
+			for _, s := range tst.Steps {
+				in := s.In
+				Apply(&in)
+
+				assert.Equal(s.Expected.RxPacketsReceived, in.RxPacketsReceived)
+				assert.Equal(s.Expected.RxPacketsReceivedOk, in.RxPacketsReceivedOk)
+				assert.Equal(s.Expected.TxPacketsReceived, in.TxPacketsReceived)
+				assert.Equal(s.Expected.TxPacketsEmitted, in.TxPacketsEmitted)
+				assert.Equal("bar", in.MetaData["foo"])
+			}
+		})
+	}
+}
+
+func TestApplyMetaData(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.GatewayStats.CounterMode = ModeInterval
+	assert.NoError(Setup(conf))
+
+	md := map[string]string{"foo": "bar"}
+	stats := gw.GatewayStats{
+		GatewayId:           []byte{1, 2, 3, 4, 5, 6, 7, 8},
+		RxPacketsReceived:   8,
+		RxPacketsReceivedOk: 6,
+		MetaData:            md,
+	}
+	Apply(&stats)
+
+	assert.Equal(map[string]string{
+		"foo":                "bar",
+		MetaDataCounterMode:  ModeInterval,
+		MetaDataRXPacketLoss: "25.00",
+	}, stats.MetaData)
+
+	// the original map is not modified
+	assert.Len(md, 1)
+}
+
+func TestSetup(t *testing.T) {
+	assert := require.New(t)
+
+	var conf config.Config
+	conf.GatewayStats.CounterMode = "foo"
+	assert.Error(Setup(conf))
+}
@@ -0,0 +1,300 @@
+// Package statshistory keeps a rolling in-memory history of the gateway
+// stats, which can be retrieved (downsampled) through the admin API. The
+// stats are written behind: they are queued by the pipeline and stored by a
+// separate go-routine, so that storing never blocks the event handling.
+package statshistory
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+// queueSize defines the number of stats which can be queued for storing.
+const queueSize = 100
+
+// Point contains the aggregated stats of a single interval.
+type Point struct {
+	Time                time.Time `json:"time"`
+	StatsCount          int       `json:"statsCount"`
+	RXPacketsReceived   uint64    `json:"rxPacketsReceived"`
+	RXPacketsReceivedOK uint64    `json:"rxPacketsReceivedOK"`
+	TXPacketsReceived   uint64    `json:"txPacketsReceived"`
+	TXPacketsEmitted    uint64    `json:"txPacketsEmitted"`
+}
+
+func (p *Point) add(other Point) {
+	p.StatsCount += other.StatsCount
+	p.RXPacketsReceived += other.RXPacketsReceived
+	p.RXPacketsReceivedOK += other.RXPacketsReceivedOK
+	p.TXPacketsReceived += other.TXPacketsReceived
+	p.TXPacketsEmitted += other.TXPacketsEmitted
+}
+
+// gateway contains the points of a gateway (at the base resolution), used
+// as a ring buffer.
+type gateway struct {
+	points []Point
+}
+
+var (
+	mux sync.RWMutex
+
+	enabled    bool
+	window     time.Duration
+	resolution time.Duration
+	queue      chan gw.GatewayStats
+
+	gateways = make(map[lorawan.EUI64]*gateway)
+)
+
+// Setup configures the stats history.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !conf.StatsHistory.Enabled {
+		return nil
+	}
+
+	if conf.StatsHistory.Resolution < time.Second {
+		return errors.New("stats history resolution must be at least 1 second")
+	}
+
+	if conf.StatsHistory.Window < conf.StatsHistory.Resolution {
+		return errors.New("stats history window must be at least the resolution")
+	}
+
+	enabled = true
+	resolution = conf.StatsHistory.Resolution.Truncate(time.Second)
+	window = conf.StatsHistory.Window.Truncate(resolution)
+	queue = make(chan gw.GatewayStats, queueSize)
+	gateways = make(map[lorawan.EUI64]*gateway)
+
+	log.WithFields(log.Fields{
+		"window":     window,
+		"resolution": resolution,
+	}).Info("stats_history: gateway stats history enabled")
+
+	admin.HandleFunc("/api/gateways/", handleHTTP)
+
+	go storeLoop(queue)
+	go func() {
+		for {
+			time.Sleep(time.Minute)
+			cleanup(time.Now())
+		}
+	}()
+
+	return nil
+}
+
+// Stats queues the given gateway stats for storing. When the queue is full,
+// the stats are not stored.
+func Stats(stats gw.GatewayStats) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if !enabled {
+		return
+	}
+
+	select {
+	case queue <- stats:
+	default:
+		var gatewayID lorawan.EUI64
+		copy(gatewayID[:], stats.GatewayId)
+		log.WithField("gateway_id", gatewayID).Warning("stats_history: queue is full, dropping stats")
+	}
+}
+
+// Get returns the stats history of the given gateway, downsampled to the
+// given resolution. The resolution must be a multiple of the configured
+// resolution. It returns false when there is no history for the gateway.
+func Get(gatewayID lorawan.EUI64, res time.Duration) ([]Point, bool, error) {
+	return get(gatewayID, res, time.Now())
+}
+
+func storeLoop(queue chan gw.GatewayStats) {
+	for stats := range queue {
+		var gatewayID lorawan.EUI64
+		copy(gatewayID[:], stats.GatewayId)
+		store(gatewayID, stats, time.Now())
+	}
+}
+
+func store(gatewayID lorawan.EUI64, stats gw.GatewayStats, now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	p := getPoint(gatewayID, now)
+	p.add(Point{
+		StatsCount:          1,
+		RXPacketsReceived:   uint64(stats.RxPacketsReceived),
+		RXPacketsReceivedOK: uint64(stats.RxPacketsReceivedOk),
+		TXPacketsReceived:   uint64(stats.TxPacketsReceived),
+		TXPacketsEmitted:    uint64(stats.TxPacketsEmitted),
+	})
+}
+
+// getPoint returns the point of the given gateway for the given time. When
+// the point contains the stats of an expired interval, it is reset. This
+// must be called with the mutex locked.
+func getPoint(gatewayID lorawan.EUI64, now time.Time) *Point {
+	gw, ok := gateways[gatewayID]
+	if !ok {
+		gw = &gateway{
+			points: make([]Point, int(window/resolution)),
+		}
+		gateways[gatewayID] = gw
+	}
+
+	t := now.Truncate(resolution)
+	i := int((t.Unix() / int64(resolution/time.Second)) % int64(len(gw.points)))
+
+	if !gw.points[i].Time.Equal(t) {
+		gw.points[i] = Point{Time: t}
+	}
+
+	return &gw.points[i]
+}
+
+func get(gatewayID lorawan.EUI64, res time.Duration, now time.Time) ([]Point, bool, error) {
+	mux.RLock()
+	defer mux.RUnlock()
+
+	if res == 0 {
+		res = resolution
+	}
+
+	if res < resolution || res%resolution != 0 {
+		return nil, false, fmt.Errorf("resolution must be a multiple of %s", resolution)
+	}
+
+	if res > window {
+		return nil, false, fmt.Errorf("resolution must not exceed the window of %s", window)
+	}
+
+	gw, ok := gateways[gatewayID]
+	if !ok {
+		return nil, false, nil
+	}
+
+	points := make(map[time.Time]*Point)
+	for _, p := range gw.points {
+		if !inWindow(p.Time, now) {
+			continue
+		}
+
+		t := p.Time.Truncate(res)
+		if _, ok := points[t]; !ok {
+			points[t] = &Point{Time: t}
+		}
+		points[t].add(p)
+	}
+
+	out := make([]Point, 0, len(points))
+	for _, p := range points {
+		out = append(out, *p)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Time.Before(out[j].Time)
+	})
+
+	return out, true, nil
+}
+
+// cleanup removes the gateways without stats within the window.
+func cleanup(now time.Time) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	for gatewayID, gw := range gateways {
+		var active bool
+		for _, p := range gw.points {
+			if inWindow(p.Time, now) {
+				active = true
+				break
+			}
+		}
+
+		if !active {
+			delete(gateways, gatewayID)
+		}
+	}
+}
+
+func inWindow(t, now time.Time) bool {
+	return !t.IsZero() && now.Sub(t) < window
+}
+
+// handleHTTP implements the admin API handler. A GET request to
+// /api/gateways/{gateway_id}/stats returns the stats history of the gateway,
+// downsampled to the resolution given by the resolution query parameter.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.TrimPrefix(r.URL.Path, "/api/gateways/"), "/")
+	if len(parts) != 2 || parts[1] != "stats" {
+		http.NotFound(w, r)
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var gatewayID lorawan.EUI64
+	if err := gatewayID.UnmarshalText([]byte(parts[0])); err != nil {
+		admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "unmarshal gateway_id error"))
+		return
+	}
+
+	var res time.Duration
+	if s := r.URL.Query().Get("resolution"); s != "" {
+		var err error
+		res, err = time.ParseDuration(s)
+		if err != nil {
+			admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "parse resolution error"))
+			return
+		}
+	}
+
+	points, ok, err := Get(gatewayID, res)
+	if err != nil {
+		admin.WriteError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if !ok {
+		admin.WriteError(w, http.StatusNotFound, errors.New("no stats history for gateway"))
+		return
+	}
+
+	if res == 0 {
+		res = resolution
+	}
+
+	admin.WriteJSON(w, struct {
+		GatewayID  lorawan.EUI64 `json:"gatewayID"`
+		Window     string        `json:"window"`
+		Resolution string        `json:"resolution"`
+		Points     []Point       `json:"points"`
+	}{
+		GatewayID:  gatewayID,
+		Window:     window.String(),
+		Resolution: res.String(),
+		Points:     points,
+	})
+}
@@ -0,0 +1,189 @@
+package statshistory
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func setup() {
+	enabled = true
+	window = time.Hour
+	resolution = time.Minute
+	gateways = make(map[lorawan.EUI64]*gateway)
+}
+
+func TestStatsHistory(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	now := time.Date(2019, 9, 10, 12, 0, 30, 0, time.UTC)
+
+	stats := gw.GatewayStats{
+		GatewayId:           gatewayID[:],
+		RxPacketsReceived:   10,
+		RxPacketsReceivedOk: 8,
+		TxPacketsReceived:   2,
+		TxPacketsEmitted:    1,
+	}
+
+	t.Run("Base resolution", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		store(gatewayID, stats, now)
+		store(gatewayID, stats, now.Add(10*time.Second))
+		store(gatewayID, stats, now.Add(time.Minute))
+
+		points, ok, err := get(gatewayID, 0, now.Add(time.Minute))
+		assert.NoError(err)
+		assert.True(ok)
+		assert.Equal([]Point{
+			{
+				Time:                time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC),
+				StatsCount:          2,
+				RXPacketsReceived:   20,
+				RXPacketsReceivedOK: 16,
+				TXPacketsReceived:   4,
+				TXPacketsEmitted:    2,
+			},
+			{
+				Time:                time.Date(2019, 9, 10, 12, 1, 0, 0, time.UTC),
+				StatsCount:          1,
+				RXPacketsReceived:   10,
+				RXPacketsReceivedOK: 8,
+				TXPacketsReceived:   2,
+				TXPacketsEmitted:    1,
+			},
+		}, points)
+	})
+
+	t.Run("Downsampled", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		for i := 0; i < 10; i++ {
+			store(gatewayID, stats, now.Add(time.Duration(i)*time.Minute))
+		}
+
+		points, ok, err := get(gatewayID, 5*time.Minute, now.Add(10*time.Minute))
+		assert.NoError(err)
+		assert.True(ok)
+		assert.Len(points, 2)
+		assert.Equal(time.Date(2019, 9, 10, 12, 0, 0, 0, time.UTC), points[0].Time)
+		assert.Equal(5, points[0].StatsCount)
+		assert.EqualValues(50, points[0].RXPacketsReceived)
+		assert.Equal(time.Date(2019, 9, 10, 12, 5, 0, 0, time.UTC), points[1].Time)
+		assert.Equal(5, points[1].StatsCount)
+
+		// points outside the window are excluded
+		points, _, err = get(gatewayID, 5*time.Minute, now.Add(time.Hour+3*time.Minute))
+		assert.NoError(err)
+		assert.Len(points, 2)
+		assert.Equal(1, points[0].StatsCount)
+		assert.Equal(5, points[1].StatsCount)
+	})
+
+	t.Run("Ring buffer", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		store(gatewayID, stats, now)
+		store(gatewayID, stats, now.Add(time.Hour))
+
+		points, _, err := get(gatewayID, 0, now.Add(time.Hour))
+		assert.NoError(err)
+		assert.Len(points, 1)
+		assert.Equal(1, points[0].StatsCount)
+		assert.Equal(time.Date(2019, 9, 10, 13, 0, 0, 0, time.UTC), points[0].Time)
+	})
+
+	t.Run("Invalid resolution", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		_, _, err := get(gatewayID, 90*time.Second, now)
+		assert.EqualError(err, "resolution must be a multiple of 1m0s")
+
+		_, _, err = get(gatewayID, 2*time.Hour, now)
+		assert.EqualError(err, "resolution must not exceed the window of 1h0m0s")
+	})
+
+	t.Run("Unknown gateway", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		_, ok, err := get(gatewayID, 0, now)
+		assert.NoError(err)
+		assert.False(ok)
+	})
+
+	t.Run("Cleanup", func(t *testing.T) {
+		assert := require.New(t)
+		setup()
+
+		store(gatewayID, stats, now)
+		cleanup(now.Add(30 * time.Minute))
+		assert.Len(gateways, 1)
+
+		cleanup(now.Add(time.Hour))
+		assert.Len(gateways, 0)
+	})
+}
+
+func TestHandleHTTP(t *testing.T) {
+	gatewayID := lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}
+	setup()
+	store(gatewayID, gw.GatewayStats{RxPacketsReceived: 10}, time.Now())
+
+	t.Run("Stats", func(t *testing.T) {
+		assert := require.New(t)
+
+		r := httptest.NewRequest(http.MethodGet, "/api/gateways/0102030405060708/stats?resolution=5m", nil)
+		w := httptest.NewRecorder()
+		handleHTTP(w, r)
+		assert.Equal(http.StatusOK, w.Code)
+
+		var resp struct {
+			GatewayID  lorawan.EUI64 `json:"gatewayID"`
+			Window     string        `json:"window"`
+			Resolution string        `json:"resolution"`
+			Points     []Point       `json:"points"`
+		}
+		assert.NoError(json.NewDecoder(w.Body).Decode(&resp))
+		assert.Equal(gatewayID, resp.GatewayID)
+		assert.Equal("1h0m0s", resp.Window)
+		assert.Equal("5m0s", resp.Resolution)
+		assert.Len(resp.Points, 1)
+		assert.EqualValues(10, resp.Points[0].RXPacketsReceived)
+	})
+
+	tests := []struct {
+		Name         string
+		Method       string
+		Path         string
+		ExpectedCode int
+	}{
+		{"invalid resolution", http.MethodGet, "/api/gateways/0102030405060708/stats?resolution=90s", http.StatusBadRequest},
+		{"invalid gateway id", http.MethodGet, "/api/gateways/0102/stats", http.StatusBadRequest},
+		{"unknown gateway", http.MethodGet, "/api/gateways/0807060504030201/stats", http.StatusNotFound},
+		{"unknown path", http.MethodGet, "/api/gateways/0102030405060708/foo", http.StatusNotFound},
+		{"invalid method", http.MethodPost, "/api/gateways/0102030405060708/stats", http.StatusMethodNotAllowed},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			r := httptest.NewRequest(tst.Method, tst.Path, nil)
+			w := httptest.NewRecorder()
+			handleHTTP(w, r)
+			assert.Equal(tst.ExpectedCode, w.Code)
+		})
+	}
+}
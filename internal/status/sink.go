@@ -0,0 +1,161 @@
+package status
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+// Sink types.
+const (
+	SinkDBus   = "dbus"
+	SinkGPIO   = "gpio"
+	SinkScript = "script"
+)
+
+// D-Bus defaults.
+const (
+	defaultDBusObjectPath = "/io/loraserver/LoRaGatewayBridge"
+	defaultDBusInterface  = "io.loraserver.LoRaGatewayBridge"
+)
+
+// scriptTimeout defines the max. execution duration of the script sink.
+const scriptTimeout = 10 * time.Second
+
+// sink defines the interface of a status sink.
+type sink interface {
+	// Emit emits the given state.
+	Emit(state string, active bool) error
+}
+
+// configuredSink holds a sink and the state it is configured for (empty
+// for all states).
+type configuredSink struct {
+	state string
+	sink  sink
+}
+
+func newSink(c config.StatusSink) (sink, error) {
+	switch c.State {
+	case "", StateBackend, StateIntegration, StateGateway:
+	default:
+		return nil, errors.Errorf("invalid state: %s", c.State)
+	}
+
+	switch c.Type {
+	case SinkDBus:
+		return newDBusSink(c)
+	case SinkGPIO:
+		if c.Path == "" {
+			return nil, errors.New("gpio sink requires a path")
+		}
+		if c.State == "" {
+			return nil, errors.New("gpio sink requires a state")
+		}
+		return &gpioSink{path: c.Path, activeLow: c.ActiveLow}, nil
+	case SinkScript:
+		cmdArgs := strings.Fields(c.Command)
+		if len(cmdArgs) == 0 {
+			return nil, errors.New("script sink requires a command")
+		}
+		return &scriptSink{command: cmdArgs}, nil
+	default:
+		return nil, errors.Errorf("invalid sink type: %s", c.Type)
+	}
+}
+
+// gpioSink writes the state to a GPIO sysfs value file
+// (e.g. /sys/class/gpio/gpio17/value).
+type gpioSink struct {
+	path      string
+	activeLow bool
+}
+
+func (s *gpioSink) Emit(state string, active bool) error {
+	value := "0"
+	if active != s.activeLow {
+		value = "1"
+	}
+
+	if err := ioutil.WriteFile(s.path, []byte(value), 0644); err != nil {
+		return errors.Wrap(err, "write gpio value error")
+	}
+
+	return nil
+}
+
+// scriptSink executes the configured command, with the state and active
+// flag appended as arguments.
+type scriptSink struct {
+	command []string
+}
+
+func (s *scriptSink) Emit(state string, active bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), scriptTimeout)
+	defer cancel()
+
+	args := append(append([]string{}, s.command[1:]...), state, strconv.FormatBool(active))
+
+	out, err := exec.CommandContext(ctx, s.command[0], args...).CombinedOutput()
+	if err != nil {
+		return errors.Wrapf(err, "execution error (output: %s)", out)
+	}
+
+	return nil
+}
+
+// dbusSink emits the StatusChanged signal, with the state and active flag
+// as arguments.
+type dbusSink struct {
+	conn       *dbus.Conn
+	objectPath dbus.ObjectPath
+	signal     string
+}
+
+func newDBusSink(c config.StatusSink) (sink, error) {
+	s := dbusSink{
+		objectPath: dbus.ObjectPath(c.ObjectPath),
+		signal:     c.Interface + ".StatusChanged",
+	}
+
+	if s.objectPath == "" {
+		s.objectPath = defaultDBusObjectPath
+	}
+	if c.Interface == "" {
+		s.signal = defaultDBusInterface + ".StatusChanged"
+	}
+	if !s.objectPath.IsValid() {
+		return nil, errors.Errorf("invalid d-bus object path: %s", s.objectPath)
+	}
+
+	var err error
+	switch c.Bus {
+	case "", "system":
+		s.conn, err = dbus.SystemBus()
+	case "session":
+		s.conn, err = dbus.SessionBus()
+	default:
+		return nil, errors.Errorf("invalid d-bus bus: %s", c.Bus)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "connect to d-bus error")
+	}
+
+	return &s, nil
+}
+
+func (s *dbusSink) Emit(state string, active bool) error {
+	if err := s.conn.Emit(s.objectPath, s.signal, state, active); err != nil {
+		return errors.Wrap(err, "emit d-bus signal error")
+	}
+
+	return nil
+}
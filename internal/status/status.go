@@ -0,0 +1,123 @@
+// Package status emits the connection-state changes of the LoRa Gateway
+// Bridge to the configured status sinks (D-Bus signal, GPIO sysfs value or
+// script), e.g. to drive the status LEDs of the gateway. The states are
+// polled using the backend and integration health checks and the gateway
+// registry.
+package status
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/registry"
+)
+
+// States.
+const (
+	StateBackend     = "backend"
+	StateIntegration = "integration"
+	StateGateway     = "gateway"
+)
+
+// states defines the order in which the states are checked and emitted.
+var states = []string{StateBackend, StateIntegration, StateGateway}
+
+// checks holds the function returning if the state is active, per state.
+var checks = map[string]func() bool{
+	StateBackend:     backendUp,
+	StateIntegration: integrationConnected,
+	StateGateway:     gatewayActive,
+}
+
+var (
+	mux     sync.Mutex
+	sinks   []configuredSink
+	current map[string]bool
+)
+
+// Setup configures the status sinks and starts the state polling loop.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	sinks = nil
+	current = make(map[string]bool)
+
+	if !conf.Status.Enabled {
+		return nil
+	}
+
+	for i, c := range conf.Status.Sinks {
+		s, err := newSink(c)
+		if err != nil {
+			return errors.Wrapf(err, "setup status sink %d error", i)
+		}
+		sinks = append(sinks, configuredSink{state: c.State, sink: s})
+	}
+
+	log.WithFields(log.Fields{
+		"interval": conf.Status.Interval,
+		"sinks":    len(sinks),
+	}).Info("status: status sinks enabled")
+
+	go loop(conf.Status.Interval)
+
+	return nil
+}
+
+func loop(interval time.Duration) {
+	for {
+		update()
+		time.Sleep(interval)
+	}
+}
+
+// update checks all states and emits the states which changed since the
+// previous check (or all states on the first check).
+func update() {
+	mux.Lock()
+	defer mux.Unlock()
+
+	for _, state := range states {
+		active := checks[state]()
+		if prev, ok := current[state]; ok && prev == active {
+			continue
+		}
+		current[state] = active
+
+		log.WithFields(log.Fields{
+			"state":  state,
+			"active": active,
+		}).Info("status: state changed")
+
+		for _, s := range sinks {
+			if s.state != "" && s.state != state {
+				continue
+			}
+
+			if err := s.sink.Emit(state, active); err != nil {
+				log.WithError(err).WithField("state", state).Error("status: emit state error")
+			}
+		}
+	}
+}
+
+func backendUp() bool {
+	b := backend.GetBackend()
+	return b != nil && b.HealthCheck() == nil
+}
+
+func integrationConnected() bool {
+	i := integration.GetIntegration()
+	return i != nil && i.HealthCheck() == nil
+}
+
+func gatewayActive() bool {
+	return len(registry.Gateways()) != 0
+}
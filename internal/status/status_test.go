@@ -0,0 +1,148 @@
+package status
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+)
+
+type testSink struct {
+	emitted []string
+}
+
+func (s *testSink) Emit(state string, active bool) error {
+	s.emitted = append(s.emitted, fmt.Sprintf("%s=%t", state, active))
+	return nil
+}
+
+func TestUpdate(t *testing.T) {
+	assert := require.New(t)
+
+	values := map[string]bool{
+		StateBackend:     true,
+		StateIntegration: false,
+		StateGateway:     false,
+	}
+
+	oldChecks := checks
+	defer func() { checks = oldChecks }()
+	checks = make(map[string]func() bool)
+	for _, state := range states {
+		state := state
+		checks[state] = func() bool { return values[state] }
+	}
+
+	assert.NoError(Setup(config.Config{}))
+
+	all := &testSink{}
+	gateway := &testSink{}
+	sinks = []configuredSink{
+		{sink: all},
+		{state: StateGateway, sink: gateway},
+	}
+
+	// all states are emitted on the first update
+	update()
+	assert.Equal([]string{"backend=true", "integration=false", "gateway=false"}, all.emitted)
+	assert.Equal([]string{"gateway=false"}, gateway.emitted)
+
+	// unchanged states are not emitted
+	all.emitted = nil
+	gateway.emitted = nil
+	update()
+	assert.Len(all.emitted, 0)
+	assert.Len(gateway.emitted, 0)
+
+	values[StateIntegration] = true
+	values[StateGateway] = true
+	update()
+	assert.Equal([]string{"integration=true", "gateway=true"}, all.emitted)
+	assert.Equal([]string{"gateway=true"}, gateway.emitted)
+}
+
+func TestGPIOSink(t *testing.T) {
+	dir, err := ioutil.TempDir("", "status")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	tests := []struct {
+		Name          string
+		ActiveLow     bool
+		Active        bool
+		ExpectedValue string
+	}{
+		{"active", false, true, "1"},
+		{"inactive", false, false, "0"},
+		{"active low - active", true, true, "0"},
+		{"active low - inactive", true, false, "1"},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			path := filepath.Join(dir, "value")
+			s, err := newSink(config.StatusSink{
+				Type:      SinkGPIO,
+				State:     StateIntegration,
+				Path:      path,
+				ActiveLow: tst.ActiveLow,
+			})
+			assert.NoError(err)
+			assert.NoError(s.Emit(StateIntegration, tst.Active))
+
+			b, err := ioutil.ReadFile(path)
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedValue, string(b))
+		})
+	}
+}
+
+func TestNewSink(t *testing.T) {
+	tests := []struct {
+		Name          string
+		Config        config.StatusSink
+		ExpectedError string
+	}{
+		{
+			Name:          "invalid type",
+			Config:        config.StatusSink{Type: "foo"},
+			ExpectedError: "invalid sink type: foo",
+		},
+		{
+			Name:          "invalid state",
+			Config:        config.StatusSink{Type: SinkGPIO, State: "foo"},
+			ExpectedError: "invalid state: foo",
+		},
+		{
+			Name:          "gpio without path",
+			Config:        config.StatusSink{Type: SinkGPIO, State: StateGateway},
+			ExpectedError: "gpio sink requires a path",
+		},
+		{
+			Name:          "gpio without state",
+			Config:        config.StatusSink{Type: SinkGPIO, Path: "/sys/class/gpio/gpio17/value"},
+			ExpectedError: "gpio sink requires a state",
+		},
+		{
+			Name:          "script without command",
+			Config:        config.StatusSink{Type: SinkScript},
+			ExpectedError: "script sink requires a command",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			_, err := newSink(tst.Config)
+			assert.EqualError(err, tst.ExpectedError)
+		})
+	}
+}
@@ -0,0 +1,163 @@
+// Package systemd implements the systemd notify protocol (sd_notify). When
+// started by systemd with Type=notify, READY=1 is sent once the LoRa Gateway
+// Bridge has been started. When the systemd watchdog is enabled
+// (WatchdogSec=), the watchdog is only pinged while the backend and
+// integration health checks pass, so that a hung MQTT client or a deadlocked
+// packet handler results in a restart of the service.
+package systemd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+)
+
+// Notify states.
+const (
+	StateReady    = "READY=1"
+	StateStopping = "STOPPING=1"
+	StateWatchdog = "WATCHDOG=1"
+)
+
+// Setup notifies systemd that the service is ready and starts the watchdog
+// loop in case the watchdog is enabled. It is a no-op when not started by
+// systemd.
+func Setup(conf config.Config) error {
+	ok, err := Notify(StateReady)
+	if err != nil {
+		return errors.Wrap(err, "notify ready error")
+	}
+	if !ok {
+		return nil
+	}
+
+	interval, err := WatchdogInterval()
+	if err != nil {
+		return errors.Wrap(err, "get watchdog interval error")
+	}
+	if interval == 0 {
+		return nil
+	}
+
+	log.WithField("interval", interval).Info("systemd: watchdog enabled")
+
+	go watchdogLoop(interval / 2)
+
+	return nil
+}
+
+// Notify sends the given state to systemd. It returns false when the
+// NOTIFY_SOCKET environment variable is not set.
+func Notify(state string) (bool, error) {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return false, nil
+	}
+
+	// abstract socket namespace
+	if socket[0] == '@' {
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{
+		Name: socket,
+		Net:  "unixgram",
+	})
+	if err != nil {
+		return false, errors.Wrap(err, "dial notify socket error")
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, errors.Wrap(err, "write to notify socket error")
+	}
+
+	return true, nil
+}
+
+// WatchdogInterval returns the watchdog interval configured by systemd. It
+// returns 0 when the watchdog is not enabled for this process.
+func WatchdogInterval() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+
+	i, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil {
+		return 0, errors.Wrap(err, "parse WATCHDOG_USEC error")
+	}
+	if i <= 0 {
+		return 0, fmt.Errorf("WATCHDOG_USEC must be greater than 0, got: %d", i)
+	}
+
+	return time.Duration(i) * time.Microsecond, nil
+}
+
+func watchdogLoop(interval time.Duration) {
+	for {
+		time.Sleep(interval)
+
+		if err := healthCheck(interval, backendHealthCheck, integrationHealthCheck); err != nil {
+			log.WithError(err).Error("systemd: health check failed, skipping watchdog ping")
+			continue
+		}
+
+		if _, err := Notify(StateWatchdog); err != nil {
+			log.WithError(err).Error("systemd: watchdog ping error")
+		}
+	}
+}
+
+// healthCheck runs the given health checks. It returns an error when one of
+// the checks fails or when the checks did not complete within the given
+// timeout (e.g. because of a deadlock).
+func healthCheck(timeout time.Duration, checks ...func() error) error {
+	errChan := make(chan error, 1)
+
+	go func() {
+		for _, check := range checks {
+			if err := check(); err != nil {
+				errChan <- err
+				return
+			}
+		}
+		errChan <- nil
+	}()
+
+	select {
+	case err := <-errChan:
+		return err
+	case <-time.After(timeout):
+		return fmt.Errorf("health check did not complete within %s", timeout)
+	}
+}
+
+func backendHealthCheck() error {
+	b := backend.GetBackend()
+	if b == nil {
+		return errors.New("backend is not set")
+	}
+	return errors.Wrap(b.HealthCheck(), "backend health check error")
+}
+
+func integrationHealthCheck() error {
+	i := integration.GetIntegration()
+	if i == nil {
+		return errors.New("integration is not set")
+	}
+	return errors.Wrap(i.HealthCheck(), "integration health check error")
+}
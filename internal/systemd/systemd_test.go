@@ -0,0 +1,147 @@
+package systemd
+
+import (
+	"errors"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNotify(t *testing.T) {
+	assert := require.New(t)
+
+	dir, err := ioutil.TempDir("", "systemd")
+	assert.NoError(err)
+	defer os.RemoveAll(dir)
+
+	socket := filepath.Join(dir, "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	assert.NoError(err)
+	defer conn.Close()
+
+	t.Run("NOTIFY_SOCKET not set", func(t *testing.T) {
+		assert := require.New(t)
+		os.Unsetenv("NOTIFY_SOCKET")
+
+		ok, err := Notify(StateReady)
+		assert.NoError(err)
+		assert.False(ok)
+	})
+
+	t.Run("NOTIFY_SOCKET set", func(t *testing.T) {
+		assert := require.New(t)
+		os.Setenv("NOTIFY_SOCKET", socket)
+		defer os.Unsetenv("NOTIFY_SOCKET")
+
+		ok, err := Notify(StateReady)
+		assert.NoError(err)
+		assert.True(ok)
+
+		buf := make([]byte, 64)
+		assert.NoError(conn.SetReadDeadline(time.Now().Add(time.Second)))
+		n, err := conn.Read(buf)
+		assert.NoError(err)
+		assert.Equal(StateReady, string(buf[:n]))
+	})
+}
+
+func TestWatchdogInterval(t *testing.T) {
+	tests := []struct {
+		Name             string
+		USec             string
+		PID              string
+		ExpectedInterval time.Duration
+		ExpectedError    bool
+	}{
+		{
+			Name: "not set",
+		},
+		{
+			Name:             "set",
+			USec:             "30000000",
+			ExpectedInterval: 30 * time.Second,
+		},
+		{
+			Name:             "set for this process",
+			USec:             "30000000",
+			PID:              strconv.Itoa(os.Getpid()),
+			ExpectedInterval: 30 * time.Second,
+		},
+		{
+			Name: "set for other process",
+			USec: "30000000",
+			PID:  "1",
+		},
+		{
+			Name:          "invalid",
+			USec:          "foo",
+			ExpectedError: true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			os.Setenv("WATCHDOG_USEC", tst.USec)
+			os.Setenv("WATCHDOG_PID", tst.PID)
+			defer os.Unsetenv("WATCHDOG_USEC")
+			defer os.Unsetenv("WATCHDOG_PID")
+
+			interval, err := WatchdogInterval()
+			if tst.ExpectedError {
+				assert.Error(err)
+				return
+			}
+
+			assert.NoError(err)
+			assert.Equal(tst.ExpectedInterval, interval)
+		})
+	}
+}
+
+func TestHealthCheck(t *testing.T) {
+	ok := func() error { return nil }
+	failed := func() error { return errors.New("not connected") }
+	blocked := func() error { select {} }
+
+	tests := []struct {
+		Name          string
+		Checks        []func() error
+		ExpectedError string
+	}{
+		{
+			Name:   "healthy",
+			Checks: []func() error{ok, ok},
+		},
+		{
+			Name:          "check failed",
+			Checks:        []func() error{ok, failed},
+			ExpectedError: "not connected",
+		},
+		{
+			Name:          "check blocked",
+			Checks:        []func() error{blocked, ok},
+			ExpectedError: "health check did not complete within 10ms",
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			err := healthCheck(10*time.Millisecond, tst.Checks...)
+			if tst.ExpectedError != "" {
+				assert.EqualError(err, tst.ExpectedError)
+				return
+			}
+			assert.NoError(err)
+		})
+	}
+}
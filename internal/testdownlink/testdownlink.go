@@ -0,0 +1,298 @@
+// Package testdownlink implements the transmission of test downlinks. A test
+// downlink is crafted by the LoRa Gateway Bridge itself, using a proprietary
+// MHDR so that it is ignored by LoRaWAN devices. Its ack is returned to the
+// caller instead of being published, which makes it possible to verify the
+// RF installation of a gateway without involving the network-server.
+package testdownlink
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/admin"
+	"github.com/brocaar/lora-gateway-bridge/internal/antennagain"
+	"github.com/brocaar/lora-gateway-bridge/internal/backend"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/downlinkswitch"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+	"github.com/brocaar/lorawan/band"
+)
+
+// ErrAckTimeout is returned when the gateway did not ack the test downlink
+// within the configured ack timeout.
+var ErrAckTimeout = errors.New("ack timeout")
+
+// Request contains a test downlink request.
+type Request struct {
+	GatewayID lorawan.EUI64 `json:"gatewayID"`
+	Frequency uint32        `json:"frequency"`
+	DataRate  int           `json:"dataRate"`
+
+	// Power (dBm, EIRP) of the test downlink. When not set, the configured
+	// power is used.
+	Power *int `json:"power"`
+
+	// Payload (HEX encoded) following the proprietary MHDR.
+	Payload string `json:"payload"`
+}
+
+// Result contains the result of a test downlink.
+type Result struct {
+	GatewayID  lorawan.EUI64 `json:"gatewayID"`
+	DownlinkID uuid.UUID     `json:"downlinkID"`
+	Token      uint32        `json:"token"`
+	PHYPayload string        `json:"phyPayload"`
+
+	// Error contains the error of the ack. It is empty when the gateway
+	// transmitted the test downlink.
+	Error string `json:"error"`
+
+	// AckDuration contains the duration between sending the test downlink
+	// and receiving its ack.
+	AckDuration string `json:"ackDuration"`
+}
+
+var (
+	mux sync.Mutex
+
+	enabled    bool
+	regionBand band.Band
+	power      int
+	ackTimeout time.Duration
+
+	// pending contains per downlink ID of the test downlinks waiting for
+	// their ack the channel to return the ack to.
+	pending = make(map[uuid.UUID]chan gw.DownlinkTXAck)
+
+	// sendDownlinkFrame sends the downlink frame to the gateway.
+	sendDownlinkFrame = func(df gw.DownlinkFrame) error {
+		return backend.GetBackend().SendDownlinkFrame(df)
+	}
+)
+
+// Setup configures the test downlink package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !conf.TestDownlink.Enabled {
+		return nil
+	}
+
+	b, err := band.GetConfig(band.Name(conf.TestDownlink.Region), false, lorawan.DwellTimeNoLimit)
+	if err != nil {
+		return errors.Wrap(err, "get band config error")
+	}
+
+	enabled = true
+	regionBand = b
+	power = conf.TestDownlink.Power
+	ackTimeout = conf.TestDownlink.AckTimeout
+
+	log.WithFields(log.Fields{
+		"region":      conf.TestDownlink.Region,
+		"ack_timeout": ackTimeout,
+	}).Info("testdownlink: test downlink transmission enabled")
+
+	admin.HandleFunc("/api/test-downlink", handleHTTP)
+
+	return nil
+}
+
+// Send sends the test downlink to the gateway and waits for its ack. It
+// returns ErrAckTimeout when the gateway did not ack the test downlink
+// within the configured ack timeout.
+func Send(req Request) (Result, error) {
+	df, err := newDownlinkFrame(req)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// the power is handled as EIRP, like the power of the other downlinks
+	antennagain.ApplyToDownlinkFrame(&df)
+
+	if downlinkswitch.Disabled(req.GatewayID) {
+		return Result{}, errors.New("downlinks of the gateway are disabled")
+	}
+
+	downID := uuid.FromBytesOrNil(df.DownlinkId)
+	ackChan := make(chan gw.DownlinkTXAck, 1)
+
+	mux.Lock()
+	pending[downID] = ackChan
+	timeout := ackTimeout
+	mux.Unlock()
+
+	defer func() {
+		mux.Lock()
+		delete(pending, downID)
+		mux.Unlock()
+	}()
+
+	log.WithFields(log.Fields{
+		"gateway_id":  req.GatewayID,
+		"downlink_id": downID,
+		"frequency":   df.TxInfo.Frequency,
+		"power":       df.TxInfo.Power,
+		"data_rate":   req.DataRate,
+	}).Info("testdownlink: sending test downlink")
+
+	start := time.Now()
+	if err := sendDownlinkFrame(df); err != nil {
+		return Result{}, errors.Wrap(err, "send downlink frame error")
+	}
+
+	select {
+	case txAck := <-ackChan:
+		return Result{
+			GatewayID:   req.GatewayID,
+			DownlinkID:  downID,
+			Token:       df.Token,
+			PHYPayload:  hex.EncodeToString(df.PhyPayload),
+			Error:       txAck.Error,
+			AckDuration: time.Since(start).String(),
+		}, nil
+	case <-time.After(timeout):
+		return Result{}, ErrAckTimeout
+	}
+}
+
+// Ack handles the ack of a test downlink. It returns true when the ack
+// belongs to a test downlink, in which case it must not be published.
+func Ack(txAck gw.DownlinkTXAck) bool {
+	mux.Lock()
+	defer mux.Unlock()
+
+	ackChan, ok := pending[uuid.FromBytesOrNil(txAck.DownlinkId)]
+	if !ok {
+		return false
+	}
+
+	select {
+	case ackChan <- txAck:
+	default:
+	}
+
+	return true
+}
+
+// newDownlinkFrame returns the downlink frame for the given request, to be
+// transmitted immediately.
+func newDownlinkFrame(req Request) (gw.DownlinkFrame, error) {
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled {
+		return gw.DownlinkFrame{}, errors.New("test downlinks are not enabled")
+	}
+
+	if req.Frequency == 0 {
+		return gw.DownlinkFrame{}, errors.New("frequency must be set")
+	}
+
+	dr, err := regionBand.GetDataRate(req.DataRate)
+	if err != nil {
+		return gw.DownlinkFrame{}, errors.Wrap(err, "get data-rate error")
+	}
+	if dr.Modulation != band.LoRaModulation {
+		return gw.DownlinkFrame{}, errors.New("only LoRa data-rates are supported")
+	}
+
+	payload, err := hex.DecodeString(req.Payload)
+	if err != nil {
+		return gw.DownlinkFrame{}, errors.Wrap(err, "decode payload error")
+	}
+
+	mhdr, err := lorawan.MHDR{
+		MType: lorawan.Proprietary,
+		Major: lorawan.LoRaWANR1,
+	}.MarshalBinary()
+	if err != nil {
+		return gw.DownlinkFrame{}, errors.Wrap(err, "marshal mhdr error")
+	}
+
+	txPower := power
+	if req.Power != nil {
+		txPower = *req.Power
+	}
+
+	downID, err := uuid.NewV4()
+	if err != nil {
+		return gw.DownlinkFrame{}, errors.Wrap(err, "new uuid error")
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return gw.DownlinkFrame{}, errors.Wrap(err, "new token error")
+	}
+
+	return gw.DownlinkFrame{
+		PhyPayload: append(mhdr, payload...),
+		TxInfo: &gw.DownlinkTXInfo{
+			GatewayId:  req.GatewayID[:],
+			Frequency:  req.Frequency,
+			Power:      int32(txPower),
+			Modulation: common.Modulation_LORA,
+			ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+				LoraModulationInfo: &gw.LoRaModulationInfo{
+					Bandwidth:             uint32(dr.Bandwidth),
+					SpreadingFactor:       uint32(dr.SpreadFactor),
+					CodeRate:              "4/5",
+					PolarizationInversion: true,
+				},
+			},
+			Timing: gw.DownlinkTiming_IMMEDIATELY,
+			TimingInfo: &gw.DownlinkTXInfo_ImmediatelyTimingInfo{
+				ImmediatelyTimingInfo: &gw.ImmediatelyTimingInfo{},
+			},
+		},
+		Token:      token,
+		DownlinkId: downID.Bytes(),
+	}, nil
+}
+
+func newToken() (uint32, error) {
+	b := make([]byte, 2)
+	if _, err := rand.Read(b); err != nil {
+		return 0, err
+	}
+	return uint32(binary.BigEndian.Uint16(b)), nil
+}
+
+// handleHTTP implements the admin API handler. A POST request sends the
+// test downlink and returns its result once the gateway acked it.
+func handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		admin.WriteError(w, http.StatusBadRequest, errors.Wrap(err, "decode request error"))
+		return
+	}
+
+	res, err := Send(req)
+	if err != nil {
+		code := http.StatusBadRequest
+		if err == ErrAckTimeout {
+			code = http.StatusGatewayTimeout
+		}
+		admin.WriteError(w, code, err)
+		return
+	}
+
+	admin.WriteJSON(w, res)
+}
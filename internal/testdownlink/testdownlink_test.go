@@ -0,0 +1,172 @@
+package testdownlink
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+func setup(t *testing.T) {
+	var conf config.Config
+	conf.TestDownlink.Enabled = true
+	conf.TestDownlink.Region = "EU868"
+	conf.TestDownlink.Power = 14
+	conf.TestDownlink.AckTimeout = 100 * time.Millisecond
+
+	require.NoError(t, Setup(conf))
+}
+
+func TestNewDownlinkFrame(t *testing.T) {
+	setup(t)
+
+	power := 20
+
+	tests := []struct {
+		Name          string
+		Request       Request
+		ExpectedError error
+
+		ExpectedPHYPayload []byte
+		ExpectedPower      int32
+		ExpectedSF         uint32
+	}{
+		{
+			Name: "configured power",
+			Request: Request{
+				GatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+				Frequency: 869525000,
+				DataRate:  0,
+				Payload:   "01020304",
+			},
+			ExpectedPHYPayload: []byte{0xe0, 0x01, 0x02, 0x03, 0x04},
+			ExpectedPower:      14,
+			ExpectedSF:         12,
+		},
+		{
+			Name: "request power",
+			Request: Request{
+				GatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+				Frequency: 868100000,
+				DataRate:  5,
+				Power:     &power,
+			},
+			ExpectedPHYPayload: []byte{0xe0},
+			ExpectedPower:      20,
+			ExpectedSF:         7,
+		},
+		{
+			Name: "no frequency",
+			Request: Request{
+				DataRate: 5,
+			},
+			ExpectedError: errors.New("frequency must be set"),
+		},
+		{
+			Name: "fsk data-rate",
+			Request: Request{
+				Frequency: 868800000,
+				DataRate:  7,
+			},
+			ExpectedError: errors.New("only LoRa data-rates are supported"),
+		},
+		{
+			Name: "invalid payload",
+			Request: Request{
+				Frequency: 868100000,
+				Payload:   "zz",
+			},
+			ExpectedError: errors.New("decode payload error: encoding/hex: invalid byte: U+007A 'z'"),
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			df, err := newDownlinkFrame(tst.Request)
+			if tst.ExpectedError != nil {
+				assert.EqualError(err, tst.ExpectedError.Error())
+				return
+			}
+			assert.NoError(err)
+
+			assert.Equal(tst.ExpectedPHYPayload, df.PhyPayload)
+			assert.Equal(tst.Request.GatewayID[:], df.TxInfo.GatewayId)
+			assert.Equal(tst.Request.Frequency, df.TxInfo.Frequency)
+			assert.Equal(tst.ExpectedPower, df.TxInfo.Power)
+			assert.Equal(common.Modulation_LORA, df.TxInfo.Modulation)
+			assert.Equal(gw.DownlinkTiming_IMMEDIATELY, df.TxInfo.Timing)
+			assert.Equal(tst.ExpectedSF, df.TxInfo.GetLoraModulationInfo().SpreadingFactor)
+			assert.Equal(uint32(125), df.TxInfo.GetLoraModulationInfo().Bandwidth)
+			assert.True(df.TxInfo.GetLoraModulationInfo().PolarizationInversion)
+			assert.Len(df.DownlinkId, 16)
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	setup(t)
+
+	req := Request{
+		GatewayID: lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8},
+		Frequency: 868100000,
+		DataRate:  5,
+	}
+
+	t.Run("acked", func(t *testing.T) {
+		assert := require.New(t)
+
+		sendDownlinkFrame = func(df gw.DownlinkFrame) error {
+			// an unrelated ack must be published
+			assert.False(Ack(gw.DownlinkTXAck{DownlinkId: make([]byte, 16)}))
+
+			go Ack(gw.DownlinkTXAck{
+				GatewayId:  df.TxInfo.GatewayId,
+				Token:      df.Token,
+				DownlinkId: df.DownlinkId,
+				Error:      "COLLISION_PACKET",
+			})
+			return nil
+		}
+
+		res, err := Send(req)
+		assert.NoError(err)
+		assert.Equal(req.GatewayID, res.GatewayID)
+		assert.NotEqual(uuid.Nil, res.DownlinkID)
+		assert.Equal("e0", res.PHYPayload)
+		assert.Equal("COLLISION_PACKET", res.Error)
+
+		// the test downlink is no longer pending
+		assert.False(Ack(gw.DownlinkTXAck{DownlinkId: res.DownlinkID.Bytes()}))
+	})
+
+	t.Run("ack timeout", func(t *testing.T) {
+		assert := require.New(t)
+
+		sendDownlinkFrame = func(df gw.DownlinkFrame) error {
+			return nil
+		}
+
+		_, err := Send(req)
+		assert.Equal(ErrAckTimeout, err)
+	})
+
+	t.Run("send error", func(t *testing.T) {
+		assert := require.New(t)
+
+		sendDownlinkFrame = func(df gw.DownlinkFrame) error {
+			return errors.New("gateway is not connected")
+		}
+
+		_, err := Send(req)
+		assert.EqualError(err, "send downlink frame error: gateway is not connected")
+	})
+}
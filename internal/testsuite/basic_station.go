@@ -0,0 +1,78 @@
+package testsuite
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/basicstation/structs"
+	"github.com/brocaar/lorawan"
+)
+
+// BasicStation implements a fake LoRa Basics Station.
+type BasicStation struct {
+	GatewayID lorawan.EUI64
+
+	conn *websocket.Conn
+}
+
+// NewBasicStation creates a new Basic Station which connects to the given
+// server (host:port).
+func NewBasicStation(gatewayID lorawan.EUI64, server string) (*BasicStation, error) {
+	d := &websocket.Dialer{}
+
+	conn, _, err := d.Dial(fmt.Sprintf("ws://%s/gateway/%s", server, gatewayID), nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial websocket error")
+	}
+
+	return &BasicStation{
+		GatewayID: gatewayID,
+		conn:      conn,
+	}, nil
+}
+
+// Close closes the websocket connection.
+func (s *BasicStation) Close() error {
+	return s.conn.Close()
+}
+
+// Send sends the given message (e.g. structs.UplinkDataFrame) to the bridge.
+func (s *BasicStation) Send(v interface{}) error {
+	return s.conn.WriteJSON(v)
+}
+
+// ReadDownlinkFrame reads messages until a downlink message is received or
+// the timeout expires. Other messages (e.g. router_config) are ignored.
+func (s *BasicStation) ReadDownlinkFrame(timeout time.Duration) (structs.DownlinkFrame, error) {
+	var df structs.DownlinkFrame
+
+	if err := s.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return df, errors.Wrap(err, "set read deadline error")
+	}
+
+	for {
+		_, b, err := s.conn.ReadMessage()
+		if err != nil {
+			return df, errors.Wrap(err, "read message error")
+		}
+
+		msgType, err := structs.GetMessageType(b)
+		if err != nil {
+			return df, errors.Wrap(err, "get message-type error")
+		}
+
+		if msgType != structs.DownlinkMessage {
+			continue
+		}
+
+		if err := json.Unmarshal(b, &df); err != nil {
+			return df, errors.Wrap(err, "unmarshal downlink message error")
+		}
+
+		return df, nil
+	}
+}
@@ -0,0 +1,146 @@
+package basicstation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/ptypes"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/basicstation/structs"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/testsuite"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+type BasicStationTestSuite struct {
+	suite.Suite
+
+	broker  *testsuite.Broker
+	client  *testsuite.MQTTClient
+	station *testsuite.BasicStation
+}
+
+func (ts *BasicStationTestSuite) SetupSuite() {
+	assert := require.New(ts.T())
+	log.SetLevel(log.ErrorLevel)
+
+	var err error
+	ts.broker, err = testsuite.NewBroker("127.0.0.1:0")
+	assert.NoError(err)
+
+	conf, err := testsuite.GetConfig("basic_station", ts.broker)
+	assert.NoError(err)
+	assert.NoError(testsuite.StartBridge(conf))
+
+	ts.client, err = testsuite.NewMQTTClient(ts.broker)
+	assert.NoError(err)
+
+	ts.station, err = testsuite.NewBasicStation(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, conf.Backend.BasicStation.Bind)
+	assert.NoError(err)
+
+	var connState integration.ConnState
+	assert.NoError(ts.client.ReadEvent(ts.station.GatewayID, integration.EventConn, &connState, time.Second))
+	assert.Equal(integration.ConnStateOnline, connState.State)
+
+	// give the integration some time to subscribe to the gateway commands
+	time.Sleep(100 * time.Millisecond)
+}
+
+func (ts *BasicStationTestSuite) TearDownSuite() {
+	ts.station.Close()
+	ts.client.Close()
+	ts.broker.Close()
+}
+
+func (ts *BasicStationTestSuite) TestUplink() {
+	assert := require.New(ts.T())
+
+	assert.NoError(ts.station.Send(structs.UplinkDataFrame{
+		RadioMetaData: structs.RadioMetaData{
+			DR:        5,
+			Frequency: 868100000,
+			UpInfo: structs.RadioMetaDataUpInfo{
+				RCtx:  1,
+				XTime: 2,
+				RSSI:  -50,
+				SNR:   5.5,
+			},
+		},
+		MessageType: structs.UplinkDataFrameMessage,
+		MHDR:        0x40,
+		DevAddr:     -10,
+		FCtrl:       0x80,
+		FCnt:        400,
+		FOpts:       "0102",
+		MIC:         -20,
+		FPort:       -1,
+	}))
+
+	var uplinkFrame gw.UplinkFrame
+	assert.NoError(ts.client.ReadEvent(ts.station.GatewayID, integration.EventUp, &uplinkFrame, time.Second))
+	assert.Equal([]byte{0x40, 0xf6, 0xff, 0xff, 0x0ff, 0x80, 0x90, 0x01, 0x01, 0x02, 0xec, 0xff, 0xff, 0xff}, uplinkFrame.PhyPayload)
+	assert.Equal(ts.station.GatewayID[:], uplinkFrame.RxInfo.GatewayId)
+	assert.EqualValues(868100000, uplinkFrame.TxInfo.Frequency)
+}
+
+func (ts *BasicStationTestSuite) TestDownlink() {
+	assert := require.New(ts.T())
+
+	downID, err := uuid.NewV4()
+	assert.NoError(err)
+
+	assert.NoError(ts.client.SendCommand(ts.station.GatewayID, "down", &gw.DownlinkFrame{
+		PhyPayload: []byte{1, 2, 3, 4},
+		Token:      1234,
+		DownlinkId: downID[:],
+		TxInfo: &gw.DownlinkTXInfo{
+			GatewayId:  ts.station.GatewayID[:],
+			Frequency:  868100000,
+			Power:      14,
+			Modulation: common.Modulation_LORA,
+			ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+				LoraModulationInfo: &gw.LoRaModulationInfo{
+					Bandwidth:             125,
+					SpreadingFactor:       10,
+					CodeRate:              "4/5",
+					PolarizationInversion: true,
+				},
+			},
+			Timing: gw.DownlinkTiming_DELAY,
+			TimingInfo: &gw.DownlinkTXInfo_DelayTimingInfo{
+				DelayTimingInfo: &gw.DelayTimingInfo{
+					Delay: ptypes.DurationProto(time.Second),
+				},
+			},
+			Context: []byte{0, 0, 0, 0, 0, 0, 0, 3, 0, 0, 0, 0, 0, 0, 0, 4},
+		},
+	}))
+
+	df, err := ts.station.ReadDownlinkFrame(time.Second)
+	assert.NoError(err)
+	assert.Equal(uint32(1234), df.DIID)
+	assert.Equal("01020304", df.PDU)
+
+	assert.NoError(ts.station.Send(structs.DownlinkTransmitted{
+		MessageType: structs.DownlinkTransmittedMessage,
+		DIID:        df.DIID,
+	}))
+
+	var txAck gw.DownlinkTXAck
+	assert.NoError(ts.client.ReadEvent(ts.station.GatewayID, integration.EventAck, &txAck, time.Second))
+	assert.Equal(gw.DownlinkTXAck{
+		GatewayId:  ts.station.GatewayID[:],
+		Token:      1234,
+		DownlinkId: downID[:],
+	}, txAck)
+}
+
+func TestBasicStation(t *testing.T) {
+	suite.Run(t, new(BasicStationTestSuite))
+}
@@ -0,0 +1,377 @@
+package testsuite
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// MQTT control packet types.
+const (
+	mqttConnect     = 1
+	mqttConnAck     = 2
+	mqttPublish     = 3
+	mqttPubAck      = 4
+	mqttPubRec      = 5
+	mqttPubRel      = 6
+	mqttPubComp     = 7
+	mqttSubscribe   = 8
+	mqttSubAck      = 9
+	mqttUnsubscribe = 10
+	mqttUnsubAck    = 11
+	mqttPingReq     = 12
+	mqttPingResp    = 13
+	mqttDisconnect  = 14
+)
+
+// Broker implements a minimal, in-memory MQTT 3.1.1 broker. It implements
+// just enough of the protocol to let the bridge and a test client exchange
+// messages: retained messages and persistent sessions are not supported and
+// all messages are delivered with QoS 0.
+type Broker struct {
+	sync.RWMutex
+
+	ln      net.Listener
+	wg      sync.WaitGroup
+	clients map[*brokerClient]struct{}
+}
+
+type brokerClient struct {
+	sync.Mutex
+
+	conn          net.Conn
+	subscriptions map[string]struct{}
+}
+
+// NewBroker creates and starts a new broker listening on the given bind
+// address (e.g. 127.0.0.1:0).
+func NewBroker(bind string) (*Broker, error) {
+	ln, err := net.Listen("tcp", bind)
+	if err != nil {
+		return nil, errors.Wrap(err, "listen error")
+	}
+
+	b := Broker{
+		ln:      ln,
+		clients: make(map[*brokerClient]struct{}),
+	}
+
+	b.wg.Add(1)
+	go func() {
+		defer b.wg.Done()
+		b.acceptLoop()
+	}()
+
+	return &b, nil
+}
+
+// Server returns the server URI that can be used by MQTT clients to connect
+// to the broker.
+func (b *Broker) Server() string {
+	return "tcp://" + b.ln.Addr().String()
+}
+
+// Close closes the broker and all its client connections.
+func (b *Broker) Close() error {
+	err := b.ln.Close()
+
+	b.RLock()
+	for c := range b.clients {
+		c.conn.Close()
+	}
+	b.RUnlock()
+
+	b.wg.Wait()
+	return err
+}
+
+func (b *Broker) acceptLoop() {
+	for {
+		conn, err := b.ln.Accept()
+		if err != nil {
+			return
+		}
+
+		c := brokerClient{
+			conn:          conn,
+			subscriptions: make(map[string]struct{}),
+		}
+
+		b.Lock()
+		b.clients[&c] = struct{}{}
+		b.Unlock()
+
+		b.wg.Add(1)
+		go func() {
+			defer b.wg.Done()
+
+			if err := b.handleClient(&c); err != nil && err != io.EOF {
+				log.WithError(err).Debug("testsuite: broker client error")
+			}
+
+			b.Lock()
+			delete(b.clients, &c)
+			b.Unlock()
+			c.conn.Close()
+		}()
+	}
+}
+
+func (b *Broker) handleClient(c *brokerClient) error {
+	r := bufio.NewReader(c.conn)
+
+	for {
+		header, body, err := readMQTTPacket(r)
+		if err != nil {
+			return err
+		}
+
+		switch header >> 4 {
+		case mqttConnect:
+			// session present = 0, return code = 0 (accepted)
+			if err := c.write(mqttConnAck<<4, []byte{0, 0}); err != nil {
+				return err
+			}
+		case mqttPublish:
+			if err := b.handlePublish(c, header, body); err != nil {
+				return err
+			}
+		case mqttPubRel:
+			if err := c.write(mqttPubComp<<4, body[:2]); err != nil {
+				return err
+			}
+		case mqttSubscribe:
+			if err := b.handleSubscribe(c, body); err != nil {
+				return err
+			}
+		case mqttUnsubscribe:
+			if err := b.handleUnsubscribe(c, body); err != nil {
+				return err
+			}
+		case mqttPingReq:
+			if err := c.write(mqttPingResp<<4, nil); err != nil {
+				return err
+			}
+		case mqttDisconnect:
+			return nil
+		}
+	}
+}
+
+func (b *Broker) handlePublish(c *brokerClient, header byte, body []byte) error {
+	topic, rest, err := readMQTTString(body)
+	if err != nil {
+		return err
+	}
+
+	qos := (header >> 1) & 0x03
+	if qos > 0 {
+		if len(rest) < 2 {
+			return errors.New("packet identifier expected")
+		}
+		packetID := rest[:2]
+		rest = rest[2:]
+
+		ack := byte(mqttPubAck)
+		if qos == 2 {
+			ack = mqttPubRec
+		}
+		if err := c.write(ack<<4, packetID); err != nil {
+			return err
+		}
+	}
+
+	// deliver with QoS 0
+	out := appendMQTTString(nil, topic)
+	out = append(out, rest...)
+
+	b.RLock()
+	defer b.RUnlock()
+
+	for client := range b.clients {
+		if !client.subscribed(topic) {
+			continue
+		}
+
+		if err := client.write(mqttPublish<<4, out); err != nil {
+			log.WithError(err).Debug("testsuite: broker deliver message error")
+		}
+	}
+
+	return nil
+}
+
+func (b *Broker) handleSubscribe(c *brokerClient, body []byte) error {
+	if len(body) < 2 {
+		return errors.New("packet identifier expected")
+	}
+
+	ack := append([]byte{}, body[:2]...)
+	rest := body[2:]
+
+	for len(rest) > 0 {
+		var filter string
+		var err error
+
+		filter, rest, err = readMQTTString(rest)
+		if err != nil {
+			return err
+		}
+		if len(rest) < 1 {
+			return errors.New("requested qos expected")
+		}
+		rest = rest[1:]
+
+		c.Lock()
+		c.subscriptions[filter] = struct{}{}
+		c.Unlock()
+
+		// granted QoS 0
+		ack = append(ack, 0)
+	}
+
+	return c.write(mqttSubAck<<4, ack)
+}
+
+func (b *Broker) handleUnsubscribe(c *brokerClient, body []byte) error {
+	if len(body) < 2 {
+		return errors.New("packet identifier expected")
+	}
+
+	packetID := body[:2]
+	rest := body[2:]
+
+	for len(rest) > 0 {
+		var filter string
+		var err error
+
+		filter, rest, err = readMQTTString(rest)
+		if err != nil {
+			return err
+		}
+
+		c.Lock()
+		delete(c.subscriptions, filter)
+		c.Unlock()
+	}
+
+	return c.write(mqttUnsubAck<<4, packetID)
+}
+
+func (c *brokerClient) subscribed(topic string) bool {
+	c.Lock()
+	defer c.Unlock()
+
+	for filter := range c.subscriptions {
+		if topicMatches(filter, topic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *brokerClient) write(header byte, body []byte) error {
+	c.Lock()
+	defer c.Unlock()
+
+	out := []byte{header}
+	out = appendMQTTLength(out, len(body))
+	out = append(out, body...)
+
+	_, err := c.conn.Write(out)
+	return err
+}
+
+// topicMatches returns true when the given topic matches the given
+// subscription filter, taking the + and # wildcards into account.
+func topicMatches(filter, topic string) bool {
+	filterParts := strings.Split(filter, "/")
+	topicParts := strings.Split(topic, "/")
+
+	for i, f := range filterParts {
+		if f == "#" {
+			return true
+		}
+
+		if i >= len(topicParts) {
+			return false
+		}
+
+		if f != "+" && f != topicParts[i] {
+			return false
+		}
+	}
+
+	return len(filterParts) == len(topicParts)
+}
+
+func readMQTTPacket(r *bufio.Reader) (byte, []byte, error) {
+	header, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var length int
+	for i := uint(0); ; i += 7 {
+		if i > 21 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		length |= int(b&0x7f) << i
+		if b&0x80 == 0 {
+			break
+		}
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+
+	return header, body, nil
+}
+
+func readMQTTString(b []byte) (string, []byte, error) {
+	if len(b) < 2 {
+		return "", nil, errors.New("string length expected")
+	}
+
+	l := int(binary.BigEndian.Uint16(b))
+	if len(b) < 2+l {
+		return "", nil, fmt.Errorf("string of %d bytes expected", l)
+	}
+
+	return string(b[2 : 2+l]), b[2+l:], nil
+}
+
+func appendMQTTString(b []byte, s string) []byte {
+	b = append(b, byte(len(s)>>8), byte(len(s)))
+	return append(b, s...)
+}
+
+func appendMQTTLength(b []byte, length int) []byte {
+	for {
+		d := byte(length % 128)
+		length /= 128
+		if length > 0 {
+			d |= 0x80
+		}
+		b = append(b, d)
+		if length == 0 {
+			return b
+		}
+	}
+}
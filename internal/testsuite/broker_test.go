@@ -0,0 +1,67 @@
+package testsuite
+
+import (
+	"testing"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopicMatches(t *testing.T) {
+	tests := []struct {
+		Filter   string
+		Topic    string
+		Expected bool
+	}{
+		{"gateway/0102030405060708/event/up", "gateway/0102030405060708/event/up", true},
+		{"gateway/+/event/+", "gateway/0102030405060708/event/up", true},
+		{"gateway/+/event/+", "gateway/0102030405060708/command/down", false},
+		{"gateway/0102030405060708/command/#", "gateway/0102030405060708/command/down", true},
+		{"gateway/0102030405060708/command/#", "gateway/0807060504030201/command/down", false},
+		{"gateway/+", "gateway/0102030405060708/event/up", false},
+		{"#", "gateway/0102030405060708/event/up", true},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Filter+" "+tst.Topic, func(t *testing.T) {
+			assert := require.New(t)
+			assert.Equal(tst.Expected, topicMatches(tst.Filter, tst.Topic))
+		})
+	}
+}
+
+func TestBroker(t *testing.T) {
+	assert := require.New(t)
+
+	broker, err := NewBroker("127.0.0.1:0")
+	assert.NoError(err)
+	defer broker.Close()
+
+	client := paho.NewClient(paho.NewClientOptions().AddBroker(broker.Server()))
+	token := client.Connect()
+	token.Wait()
+	assert.NoError(token.Error())
+	defer client.Disconnect(0)
+
+	received := make(chan paho.Message, 1)
+	token = client.Subscribe("test/+", 1, func(_ paho.Client, msg paho.Message) {
+		received <- msg
+	})
+	token.Wait()
+	assert.NoError(token.Error())
+
+	for _, qos := range []byte{0, 1, 2} {
+		token = client.Publish("test/topic", qos, false, []byte("hello"))
+		token.Wait()
+		assert.NoError(token.Error())
+
+		select {
+		case msg := <-received:
+			assert.Equal("test/topic", msg.Topic())
+			assert.Equal([]byte("hello"), msg.Payload())
+		case <-time.After(time.Second):
+			t.Fatalf("message with qos %d not received", qos)
+		}
+	}
+}
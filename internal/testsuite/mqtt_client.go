@@ -0,0 +1,85 @@
+package testsuite
+
+import (
+	"fmt"
+	"time"
+
+	paho "github.com/eclipse/paho.mqtt.golang"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lorawan"
+)
+
+// MQTTClient implements an MQTT client, taking the role of LoRa Server.
+type MQTTClient struct {
+	conn     paho.Client
+	messages chan paho.Message
+}
+
+// NewMQTTClient creates a new MQTT client, subscribed to all gateway events.
+func NewMQTTClient(broker *Broker) (*MQTTClient, error) {
+	c := MQTTClient{
+		messages: make(chan paho.Message, 100),
+	}
+
+	opts := paho.NewClientOptions().AddBroker(broker.Server())
+	c.conn = paho.NewClient(opts)
+
+	if token := c.conn.Connect(); token.Wait() && token.Error() != nil {
+		return nil, errors.Wrap(token.Error(), "connect error")
+	}
+
+	if token := c.conn.Subscribe("gateway/+/event/+", 0, func(_ paho.Client, msg paho.Message) {
+		c.messages <- msg
+	}); token.Wait() && token.Error() != nil {
+		return nil, errors.Wrap(token.Error(), "subscribe error")
+	}
+
+	return &c, nil
+}
+
+// Close closes the MQTT client.
+func (c *MQTTClient) Close() {
+	c.conn.Disconnect(0)
+}
+
+// ReadEvent reads events until an event of the given type is received for
+// the given gateway or the timeout expires. The payload is unmarshaled into
+// msg.
+func (c *MQTTClient) ReadEvent(gatewayID lorawan.EUI64, event string, msg proto.Message, timeout time.Duration) error {
+	topic := fmt.Sprintf("gateway/%s/event/%s", gatewayID, event)
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case m := <-c.messages:
+			if m.Topic() != topic {
+				continue
+			}
+
+			if err := proto.Unmarshal(m.Payload(), msg); err != nil {
+				return errors.Wrap(err, "unmarshal event error")
+			}
+			return nil
+		case <-timer.C:
+			return fmt.Errorf("timeout waiting for %s event", event)
+		}
+	}
+}
+
+// SendCommand sends the given command to the given gateway.
+func (c *MQTTClient) SendCommand(gatewayID lorawan.EUI64, command string, msg proto.Message) error {
+	b, err := proto.Marshal(msg)
+	if err != nil {
+		return errors.Wrap(err, "marshal command error")
+	}
+
+	topic := fmt.Sprintf("gateway/%s/command/%s", gatewayID, command)
+	if token := c.conn.Publish(topic, 0, false, b); token.Wait() && token.Error() != nil {
+		return errors.Wrap(token.Error(), "publish error")
+	}
+
+	return nil
+}
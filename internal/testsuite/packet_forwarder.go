@@ -0,0 +1,123 @@
+package testsuite
+
+import (
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+	"github.com/brocaar/lorawan"
+)
+
+// PacketForwarder implements a fake Semtech UDP packet-forwarder.
+type PacketForwarder struct {
+	GatewayID lorawan.EUI64
+
+	conn  *net.UDPConn
+	token uint16
+}
+
+// NewPacketForwarder creates a new packet-forwarder which sends its packets
+// to the given server (host:port).
+func NewPacketForwarder(gatewayID lorawan.EUI64, server string) (*PacketForwarder, error) {
+	addr, err := net.ResolveUDPAddr("udp", server)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve udp addr error")
+	}
+
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "dial udp error")
+	}
+
+	return &PacketForwarder{
+		GatewayID: gatewayID,
+		conn:      conn,
+	}, nil
+}
+
+// Close closes the packet-forwarder connection.
+func (p *PacketForwarder) Close() error {
+	return p.conn.Close()
+}
+
+// PullData sends a PULL_DATA packet, which registers the gateway at the
+// bridge.
+func (p *PacketForwarder) PullData() error {
+	p.token++
+	return p.send(packets.PullDataPacket{
+		ProtocolVersion: packets.ProtocolVersion2,
+		RandomToken:     p.token,
+		GatewayMAC:      p.GatewayID,
+	})
+}
+
+// PushData sends a PUSH_DATA packet with the given payload.
+func (p *PacketForwarder) PushData(pl packets.PushDataPayload) error {
+	p.token++
+	return p.send(packets.PushDataPacket{
+		ProtocolVersion: packets.ProtocolVersion2,
+		RandomToken:     p.token,
+		GatewayMAC:      p.GatewayID,
+		Payload:         pl,
+	})
+}
+
+// TXACK sends a TX_ACK packet for the given token.
+func (p *PacketForwarder) TXACK(token uint16, pl *packets.TXACKPayload) error {
+	return p.send(packets.TXACKPacket{
+		ProtocolVersion: packets.ProtocolVersion2,
+		RandomToken:     token,
+		GatewayMAC:      p.GatewayID,
+		Payload:         pl,
+	})
+}
+
+// ReadPullResp reads packets until a PULL_RESP packet is received or the
+// timeout expires. Acknowledgements received in the meantime are ignored.
+func (p *PacketForwarder) ReadPullResp(timeout time.Duration) (packets.PullRespPacket, error) {
+	var pullResp packets.PullRespPacket
+
+	if err := p.conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return pullResp, errors.Wrap(err, "set read deadline error")
+	}
+
+	buf := make([]byte, 65507)
+	for {
+		i, err := p.conn.Read(buf)
+		if err != nil {
+			return pullResp, errors.Wrap(err, "read error")
+		}
+
+		pt, err := packets.GetPacketType(buf[:i])
+		if err != nil {
+			return pullResp, errors.Wrap(err, "get packet-type error")
+		}
+
+		if pt != packets.PullResp {
+			continue
+		}
+
+		if err := pullResp.UnmarshalBinary(buf[:i]); err != nil {
+			return pullResp, errors.Wrap(err, "unmarshal pull_resp error")
+		}
+
+		return pullResp, nil
+	}
+}
+
+func (p *PacketForwarder) send(pkt interface {
+	MarshalBinary() ([]byte, error)
+}) error {
+	b, err := pkt.MarshalBinary()
+	if err != nil {
+		return errors.Wrap(err, "marshal packet error")
+	}
+
+	if _, err := p.conn.Write(b); err != nil {
+		return errors.Wrap(err, "write error")
+	}
+
+	return nil
+}
@@ -0,0 +1,145 @@
+package semtechudp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/ptypes"
+	log "github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/testsuite"
+	"github.com/brocaar/loraserver/api/common"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+type SemtechUDPTestSuite struct {
+	suite.Suite
+
+	broker *testsuite.Broker
+	client *testsuite.MQTTClient
+	pf     *testsuite.PacketForwarder
+}
+
+func (ts *SemtechUDPTestSuite) SetupSuite() {
+	assert := require.New(ts.T())
+	log.SetLevel(log.ErrorLevel)
+
+	var err error
+	ts.broker, err = testsuite.NewBroker("127.0.0.1:0")
+	assert.NoError(err)
+
+	conf, err := testsuite.GetConfig("semtech_udp", ts.broker)
+	assert.NoError(err)
+	assert.NoError(testsuite.StartBridge(conf))
+
+	ts.client, err = testsuite.NewMQTTClient(ts.broker)
+	assert.NoError(err)
+
+	ts.pf, err = testsuite.NewPacketForwarder(lorawan.EUI64{1, 2, 3, 4, 5, 6, 7, 8}, conf.Backend.SemtechUDP.UDPBind)
+	assert.NoError(err)
+
+	assert.NoError(ts.pf.PullData())
+
+	var connState integration.ConnState
+	assert.NoError(ts.client.ReadEvent(ts.pf.GatewayID, integration.EventConn, &connState, time.Second))
+	assert.Equal(integration.ConnStateOnline, connState.State)
+
+	// give the integration some time to subscribe to the gateway commands
+	time.Sleep(100 * time.Millisecond)
+}
+
+func (ts *SemtechUDPTestSuite) TearDownSuite() {
+	ts.pf.Close()
+	ts.client.Close()
+	ts.broker.Close()
+}
+
+func (ts *SemtechUDPTestSuite) TestUplink() {
+	assert := require.New(ts.T())
+
+	assert.NoError(ts.pf.PushData(packets.PushDataPayload{
+		RXPK: []packets.RXPK{
+			{
+				Tmst: 1000000,
+				Freq: 868.1,
+				Chan: 1,
+				RFCh: 0,
+				Stat: 1,
+				Modu: "LORA",
+				DatR: packets.DatR{LoRa: "SF7BW125"},
+				CodR: "4/5",
+				RSSI: -50,
+				LSNR: 7,
+				Size: 4,
+				Data: []byte{1, 2, 3, 4},
+			},
+		},
+	}))
+
+	var uplinkFrame gw.UplinkFrame
+	assert.NoError(ts.client.ReadEvent(ts.pf.GatewayID, integration.EventUp, &uplinkFrame, time.Second))
+	assert.Equal([]byte{1, 2, 3, 4}, uplinkFrame.PhyPayload)
+	assert.Equal(ts.pf.GatewayID[:], uplinkFrame.RxInfo.GatewayId)
+	assert.EqualValues(868100000, uplinkFrame.TxInfo.Frequency)
+	assert.EqualValues(-50, uplinkFrame.RxInfo.Rssi)
+}
+
+func (ts *SemtechUDPTestSuite) TestDownlink() {
+	assert := require.New(ts.T())
+
+	downID, err := uuid.NewV4()
+	assert.NoError(err)
+
+	assert.NoError(ts.client.SendCommand(ts.pf.GatewayID, "down", &gw.DownlinkFrame{
+		PhyPayload: []byte{4, 3, 2, 1},
+		Token:      1234,
+		DownlinkId: downID[:],
+		TxInfo: &gw.DownlinkTXInfo{
+			GatewayId:  ts.pf.GatewayID[:],
+			Frequency:  868100000,
+			Power:      14,
+			Modulation: common.Modulation_LORA,
+			ModulationInfo: &gw.DownlinkTXInfo_LoraModulationInfo{
+				LoraModulationInfo: &gw.LoRaModulationInfo{
+					Bandwidth:             125,
+					SpreadingFactor:       7,
+					CodeRate:              "4/5",
+					PolarizationInversion: true,
+				},
+			},
+			Timing: gw.DownlinkTiming_DELAY,
+			TimingInfo: &gw.DownlinkTXInfo_DelayTimingInfo{
+				DelayTimingInfo: &gw.DelayTimingInfo{
+					Delay: ptypes.DurationProto(time.Second),
+				},
+			},
+			Context: []byte{0x00, 0x0f, 0x42, 0x40},
+		},
+	}))
+
+	pullResp, err := ts.pf.ReadPullResp(time.Second)
+	assert.NoError(err)
+	assert.Equal(uint16(1234), pullResp.RandomToken)
+	assert.Equal([]byte{4, 3, 2, 1}, pullResp.Payload.TXPK.Data)
+	assert.Equal(868.1, pullResp.Payload.TXPK.Freq)
+
+	assert.NoError(ts.pf.TXACK(pullResp.RandomToken, nil))
+
+	var txAck gw.DownlinkTXAck
+	assert.NoError(ts.client.ReadEvent(ts.pf.GatewayID, integration.EventAck, &txAck, time.Second))
+	assert.Equal(gw.DownlinkTXAck{
+		GatewayId:  ts.pf.GatewayID[:],
+		Token:      1234,
+		DownlinkId: downID[:],
+	}, txAck)
+}
+
+func TestSemtechUDP(t *testing.T) {
+	suite.Run(t, new(SemtechUDPTestSuite))
+}
@@ -0,0 +1,96 @@
+// Package testsuite provides the building blocks for end-to-end tests of the
+// LoRa Gateway Bridge: an embedded MQTT broker, a fake Semtech UDP
+// packet-forwarder, a fake LoRa Basics Station and an MQTT client to observe
+// the published events and to send commands.
+//
+// As the forwarder can only be set up once per process, each backend has its
+// own test package (see the sub-directories of this package).
+package testsuite
+
+import (
+	"net"
+
+	"github.com/pkg/errors"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend"
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/filters"
+	"github.com/brocaar/lora-gateway-bridge/internal/forwarder"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/lora-gateway-bridge/internal/locations"
+	"github.com/brocaar/lora-gateway-bridge/internal/privacy"
+)
+
+// StartBridge sets up the bridge components, in the same order as the
+// lora-gateway-bridge command does.
+func StartBridge(conf config.Config) error {
+	tasks := []func(config.Config) error{
+		filters.Setup,
+		locations.Setup,
+		privacy.Setup,
+		backend.Setup,
+		integration.Setup,
+		forwarder.Setup,
+	}
+
+	for _, t := range tasks {
+		if err := t(conf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetConfig returns a configuration for the given backend type, using the
+// MQTT integration connected to the given broker.
+func GetConfig(backendType string, broker *Broker) (config.Config, error) {
+	var conf config.Config
+
+	udpBind, err := freeUDPAddr()
+	if err != nil {
+		return conf, err
+	}
+
+	tcpBind, err := freeTCPAddr()
+	if err != nil {
+		return conf, err
+	}
+
+	conf.Backend.Type = backendType
+	conf.Backend.SemtechUDP.UDPBind = udpBind
+	conf.Backend.BasicStation.Bind = tcpBind
+	conf.Backend.BasicStation.Region = "EU868"
+	conf.Backend.BasicStation.FrequencyMin = 863000000
+	conf.Backend.BasicStation.FrequencyMax = 870000000
+
+	conf.Integration.Type = "mqtt"
+	conf.Integration.Marshaler = "protobuf"
+	conf.Integration.MQTT.EventTopicTemplate = "gateway/{{ .GatewayID }}/event/{{ .EventType }}"
+	conf.Integration.MQTT.CommandTopicTemplate = "gateway/{{ .GatewayID }}/command/#"
+	conf.Integration.MQTT.Auth.Type = "generic"
+	conf.Integration.MQTT.Auth.Generic.Server = broker.Server()
+	conf.Integration.MQTT.Auth.Generic.CleanSession = true
+
+	return conf, nil
+}
+
+func freeUDPAddr() (string, error) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		return "", errors.Wrap(err, "listen udp error")
+	}
+	defer conn.Close()
+
+	return conn.LocalAddr().String(), nil
+}
+
+func freeTCPAddr() (string, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", errors.Wrap(err, "listen tcp error")
+	}
+	defer ln.Close()
+
+	return ln.Addr().String(), nil
+}
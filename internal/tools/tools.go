@@ -3,6 +3,7 @@
 package tools
 
 import (
+	_ "github.com/golang/protobuf/protoc-gen-go"
 	_ "github.com/goreleaser/goreleaser"
 	_ "golang.org/x/lint/golint"
 )
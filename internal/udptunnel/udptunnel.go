@@ -0,0 +1,292 @@
+// Package udptunnel implements the udp-tunnel agent, which runs next to the
+// packet-forwarder of a gateway of which the UDP return path is unreliable
+// (e.g. behind carrier-grade NAT). The agent receives the GWMP datagrams of
+// the packet-forwarder on a local UDP port and tunnels these over an outbound
+// websocket connection to the websocket listener of the Semtech UDP backend.
+// Each GWMP datagram is sent as a single binary websocket message.
+package udptunnel
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"io/ioutil"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+)
+
+const (
+	pingInterval = 30 * time.Second
+	readTimeout  = 2 * pingInterval
+	writeTimeout = 10 * time.Second
+)
+
+// Config holds the agent configuration.
+type Config struct {
+	// Bind holds the ip:port of the UDP listener to which the
+	// packet-forwarder forwards its data.
+	Bind string
+
+	// Server holds the websocket URL of the LoRa Gateway Bridge, e.g.
+	// wss://example.com:1701.
+	Server string
+
+	// CACert holds the (optional) CA certificate file used to validate the
+	// server certificate.
+	CACert string
+
+	// ReconnectInterval holds the interval between the connection attempts.
+	ReconnectInterval time.Duration
+}
+
+// Agent implements the udp-tunnel agent.
+type Agent struct {
+	sync.RWMutex
+
+	conf   Config
+	dialer websocket.Dialer
+	conn   *net.UDPConn
+	ws     *websocket.Conn
+	closed bool
+
+	// writeMux serializes the writes to the websocket connection.
+	writeMux sync.Mutex
+
+	// the packet-forwarder uses separate sockets for the upstream
+	// (PUSH_DATA) and downstream (PULL_DATA, TX_ACK) datagrams
+	upAddr   *net.UDPAddr
+	downAddr *net.UDPAddr
+}
+
+// NewAgent creates a new agent and starts the UDP listener.
+func NewAgent(conf Config) (*Agent, error) {
+	a := Agent{
+		conf: conf,
+		dialer: websocket.Dialer{
+			Proxy:            websocket.DefaultDialer.Proxy,
+			HandshakeTimeout: websocket.DefaultDialer.HandshakeTimeout,
+		},
+	}
+
+	if conf.CACert != "" {
+		b, err := ioutil.ReadFile(conf.CACert)
+		if err != nil {
+			return nil, errors.Wrap(err, "read ca certificate error")
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, errors.New("append ca certificate error")
+		}
+
+		a.dialer.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", conf.Bind)
+	if err != nil {
+		return nil, errors.Wrap(err, "resolve udp addr error")
+	}
+
+	log.WithField("addr", addr).Info("udptunnel: starting udp listener")
+	a.conn, err = net.ListenUDP("udp", addr)
+	if err != nil {
+		return nil, errors.Wrap(err, "listen udp error")
+	}
+
+	return &a, nil
+}
+
+// Run forwards the datagrams until the agent is closed.
+func (a *Agent) Run() error {
+	go a.connectLoop()
+
+	buf := make([]byte, 65507) // max udp data size
+	for {
+		i, addr, err := a.conn.ReadFromUDP(buf)
+		if err != nil {
+			if a.isClosed() {
+				return nil
+			}
+			return errors.Wrap(err, "read from udp error")
+		}
+
+		data := make([]byte, i)
+		copy(data, buf[:i])
+
+		if err := a.handleUplink(data, addr); err != nil {
+			log.WithError(err).WithField("addr", addr).Error("udptunnel: handle udp datagram error")
+		}
+	}
+}
+
+// Close closes the agent.
+func (a *Agent) Close() error {
+	a.Lock()
+	a.closed = true
+	ws := a.ws
+	a.Unlock()
+
+	if ws != nil {
+		ws.Close()
+	}
+
+	return a.conn.Close()
+}
+
+func (a *Agent) isClosed() bool {
+	a.RLock()
+	defer a.RUnlock()
+	return a.closed
+}
+
+// handleUplink forwards the given datagram received from the
+// packet-forwarder over the websocket connection.
+func (a *Agent) handleUplink(data []byte, addr *net.UDPAddr) error {
+	pt, err := packets.GetPacketType(data)
+	if err != nil {
+		return errors.Wrap(err, "get packet-type error")
+	}
+
+	a.Lock()
+	switch pt {
+	case packets.PushData:
+		a.upAddr = addr
+	case packets.PullData, packets.TXACK:
+		a.downAddr = addr
+	}
+	ws := a.ws
+	a.Unlock()
+
+	if ws == nil {
+		// the packet-forwarder retries, e.g. the PULL_DATA keep-alive
+		log.WithField("packet_type", pt).Debug("udptunnel: not connected, dropping datagram")
+		return nil
+	}
+
+	a.writeMux.Lock()
+	defer a.writeMux.Unlock()
+
+	ws.SetWriteDeadline(time.Now().Add(writeTimeout))
+	if err := ws.WriteMessage(websocket.BinaryMessage, data); err != nil {
+		return errors.Wrap(err, "write websocket message error")
+	}
+
+	return nil
+}
+
+// handleDownlink forwards the given message received over the websocket
+// connection to the packet-forwarder.
+func (a *Agent) handleDownlink(data []byte) error {
+	pt, err := packets.GetPacketType(data)
+	if err != nil {
+		return errors.Wrap(err, "get packet-type error")
+	}
+
+	a.RLock()
+	var addr *net.UDPAddr
+	switch pt {
+	case packets.PushACK:
+		addr = a.upAddr
+	case packets.PullACK, packets.PullResp:
+		addr = a.downAddr
+	}
+	a.RUnlock()
+
+	if addr == nil {
+		return errors.Errorf("no packet-forwarder address known for %s", pt)
+	}
+
+	if _, err := a.conn.WriteToUDP(data, addr); err != nil {
+		return errors.Wrap(err, "write to udp error")
+	}
+
+	return nil
+}
+
+func (a *Agent) connectLoop() {
+	for !a.isClosed() {
+		if err := a.connect(); err != nil && !a.isClosed() {
+			log.WithError(err).WithField("server", a.conf.Server).Error("udptunnel: websocket connection error")
+		}
+
+		if a.isClosed() {
+			return
+		}
+		time.Sleep(a.conf.ReconnectInterval)
+	}
+}
+
+// connect connects to the server and forwards the received messages until
+// the connection is closed.
+func (a *Agent) connect() error {
+	ws, _, err := a.dialer.Dial(a.conf.Server, nil)
+	if err != nil {
+		return errors.Wrap(err, "dial websocket error")
+	}
+	defer ws.Close()
+
+	a.Lock()
+	if a.closed {
+		a.Unlock()
+		return nil
+	}
+	a.ws = ws
+	a.Unlock()
+
+	defer func() {
+		a.Lock()
+		a.ws = nil
+		a.Unlock()
+	}()
+
+	log.WithField("server", a.conf.Server).Info("udptunnel: websocket connection established")
+
+	ws.SetReadDeadline(time.Now().Add(readTimeout))
+	ws.SetPongHandler(func(string) error {
+		ws.SetReadDeadline(time.Now().Add(readTimeout))
+		return nil
+	})
+
+	done := make(chan struct{})
+	defer close(done)
+
+	go func() {
+		ticker := time.NewTicker(pingInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				if err := ws.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeTimeout)); err != nil {
+					log.WithError(err).Error("udptunnel: send ping message error")
+					ws.Close()
+					return
+				}
+			}
+		}
+	}()
+
+	for {
+		msgType, data, err := ws.ReadMessage()
+		if err != nil {
+			return errors.Wrap(err, "read websocket message error")
+		}
+		ws.SetReadDeadline(time.Now().Add(readTimeout))
+
+		if msgType != websocket.BinaryMessage {
+			continue
+		}
+
+		if err := a.handleDownlink(data); err != nil {
+			log.WithError(err).Error("udptunnel: handle websocket message error")
+		}
+	}
+}
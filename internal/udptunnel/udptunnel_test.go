@@ -0,0 +1,101 @@
+package udptunnel
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/backend/semtechudp/packets"
+)
+
+func TestAgent(t *testing.T) {
+	assert := require.New(t)
+
+	// the server acks every PULL_DATA and PUSH_DATA message
+	upgrader := websocket.Upgrader{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		for {
+			_, b, err := conn.ReadMessage()
+			if err != nil {
+				return
+			}
+
+			ack := []byte{b[0], b[1], b[2], byte(packets.PullACK)}
+			if b[3] == byte(packets.PushData) {
+				ack[3] = byte(packets.PushACK)
+			}
+			if err := conn.WriteMessage(websocket.BinaryMessage, ack); err != nil {
+				return
+			}
+		}
+	}))
+	defer server.Close()
+
+	agent, err := NewAgent(Config{
+		Bind:              "127.0.0.1:0",
+		Server:            "ws" + strings.TrimPrefix(server.URL, "http"),
+		ReconnectInterval: 10 * time.Millisecond,
+	})
+	assert.NoError(err)
+	defer agent.Close()
+
+	go agent.Run()
+
+	// separate sockets, like the packet-forwarder
+	up, err := net.DialUDP("udp", nil, agent.conn.LocalAddr().(*net.UDPAddr))
+	assert.NoError(err)
+	defer up.Close()
+	down, err := net.DialUDP("udp", nil, agent.conn.LocalAddr().(*net.UDPAddr))
+	assert.NoError(err)
+	defer down.Close()
+
+	pullData := packets.PullDataPacket{
+		ProtocolVersion: packets.ProtocolVersion2,
+		RandomToken:     123,
+		GatewayMAC:      [8]byte{1, 2, 3, 4, 5, 6, 7, 8},
+	}
+	b, err := pullData.MarshalBinary()
+	assert.NoError(err)
+
+	// retry until the agent is connected, like the PULL_DATA keep-alive
+	buf := make([]byte, 65507)
+	var i int
+	for retry := 0; retry < 100; retry++ {
+		_, err = down.Write(b)
+		assert.NoError(err)
+
+		assert.NoError(down.SetReadDeadline(time.Now().Add(50 * time.Millisecond)))
+		if i, err = down.Read(buf); err == nil {
+			break
+		}
+	}
+	assert.NoError(err)
+
+	var pullACK packets.PullACKPacket
+	assert.NoError(pullACK.UnmarshalBinary(buf[:i]))
+	assert.Equal(pullData.RandomToken, pullACK.RandomToken)
+
+	// the PUSH_ACK must be sent to the upstream socket
+	_, err = up.Write([]byte{packets.ProtocolVersion2, 124, 0, byte(packets.PushData), 1, 2, 3, 4, 5, 6, 7, 8, '{', '}'})
+	assert.NoError(err)
+
+	assert.NoError(up.SetReadDeadline(time.Now().Add(time.Second)))
+	i, err = up.Read(buf)
+	assert.NoError(err)
+
+	var pushACK packets.PushACKPacket
+	assert.NoError(pushACK.UnmarshalBinary(buf[:i]))
+	assert.Equal(uint16(124), pushACK.RandomToken)
+}
@@ -0,0 +1,155 @@
+// Package uplinkset aggregates the same uplink (same PHYPayload) received by
+// multiple gateways connected to this LoRa Gateway Bridge within a window.
+// The aggregated rx-info is published as the uplink_set event, e.g. to be
+// used for (coarse) geolocation without a network-server dedup stage.
+package uplinkset
+
+import (
+	"crypto/sha256"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+	"github.com/golang/protobuf/proto"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/loraserver/api/gw"
+	"github.com/brocaar/lorawan"
+)
+
+type uplinkSet struct {
+	phyPayload []byte
+	txInfo     *gw.UplinkTXInfo
+	rxInfo     []*gw.UplinkRXInfo
+	gateways   map[lorawan.EUI64]struct{}
+}
+
+var (
+	mux sync.Mutex
+
+	enabled     bool
+	window      time.Duration
+	minGateways int
+
+	sets = make(map[[sha256.Size]byte]*uplinkSet)
+)
+
+// Setup configures the uplink set package.
+func Setup(conf config.Config) error {
+	mux.Lock()
+	defer mux.Unlock()
+
+	enabled = conf.UplinkSet.Enabled
+	window = conf.UplinkSet.Window
+	minGateways = conf.UplinkSet.MinGateways
+
+	if !enabled {
+		return nil
+	}
+
+	if minGateways < 2 {
+		return errors.New("min_gateways must be at least 2")
+	}
+
+	log.WithFields(log.Fields{
+		"window":       window,
+		"min_gateways": minGateways,
+	}).Info("uplinkset: uplink set aggregation enabled")
+
+	return nil
+}
+
+// Uplink adds the given uplink to the uplink set of its PHYPayload. The
+// uplink set is published once the window has passed, when it contains the
+// uplinks of at least the configured min. number of gateways.
+func Uplink(frame gw.UplinkFrame) {
+	key, created := add(frame)
+	if !created {
+		return
+	}
+
+	time.AfterFunc(window, func() {
+		if set := complete(key); set != nil {
+			if err := publish(*set); err != nil {
+				log.WithError(err).Error("uplinkset: publish uplink_set event error")
+			}
+		}
+	})
+}
+
+// add adds the uplink to the uplink set. It returns true when a new uplink
+// set was created.
+func add(frame gw.UplinkFrame) ([sha256.Size]byte, bool) {
+	key := sha256.Sum256(frame.PhyPayload)
+
+	mux.Lock()
+	defer mux.Unlock()
+
+	if !enabled || frame.RxInfo == nil {
+		return key, false
+	}
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], frame.RxInfo.GatewayId)
+
+	set, ok := sets[key]
+	if !ok {
+		set = &uplinkSet{
+			phyPayload: frame.PhyPayload,
+			txInfo:     frame.TxInfo,
+			gateways:   make(map[lorawan.EUI64]struct{}),
+		}
+		sets[key] = set
+	}
+
+	set.rxInfo = append(set.rxInfo, proto.Clone(frame.RxInfo).(*gw.UplinkRXInfo))
+	set.gateways[gatewayID] = struct{}{}
+
+	return key, !ok
+}
+
+// complete removes the uplink set. It returns the uplink set when it was
+// received by at least the min. number of gateways.
+func complete(key [sha256.Size]byte) *integration.UplinkSet {
+	mux.Lock()
+	defer mux.Unlock()
+
+	set, ok := sets[key]
+	if !ok {
+		return nil
+	}
+	delete(sets, key)
+
+	if len(set.gateways) < minGateways {
+		return nil
+	}
+
+	return &integration.UplinkSet{
+		PhyPayload: set.phyPayload,
+		TxInfo:     set.txInfo,
+		RxInfo:     set.rxInfo,
+	}
+}
+
+// publish publishes the uplink set. As events are published per gateway,
+// the event of the first gateway is used.
+func publish(set integration.UplinkSet) error {
+	i := integration.GetIntegration()
+	if i == nil {
+		return nil
+	}
+
+	id, err := uuid.NewV4()
+	if err != nil {
+		return errors.Wrap(err, "new uuid error")
+	}
+	set.SetId = id[:]
+
+	var gatewayID lorawan.EUI64
+	copy(gatewayID[:], set.RxInfo[0].GetGatewayId())
+
+	return i.PublishEvent(gatewayID, integration.EventUplinkSet, id, &set)
+}
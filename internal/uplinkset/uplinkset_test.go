@@ -0,0 +1,134 @@
+package uplinkset
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/brocaar/lora-gateway-bridge/internal/config"
+	"github.com/brocaar/lora-gateway-bridge/internal/integration"
+	"github.com/brocaar/loraserver/api/gw"
+)
+
+func TestSetup(t *testing.T) {
+	tests := []struct {
+		Name        string
+		Enabled     bool
+		MinGateways int
+		Error       bool
+	}{
+		{
+			Name: "disabled",
+		},
+		{
+			Name:        "enabled",
+			Enabled:     true,
+			MinGateways: 2,
+		},
+		{
+			Name:        "enabled, min gateways too low",
+			Enabled:     true,
+			MinGateways: 1,
+			Error:       true,
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.UplinkSet.Enabled = tst.Enabled
+			conf.UplinkSet.Window = 500 * time.Millisecond
+			conf.UplinkSet.MinGateways = tst.MinGateways
+
+			err := Setup(conf)
+			if tst.Error {
+				assert.Error(err)
+			} else {
+				assert.NoError(err)
+			}
+		})
+	}
+}
+
+func TestUplinkSet(t *testing.T) {
+	uplink := func(gatewayID byte, rssi int32) gw.UplinkFrame {
+		return gw.UplinkFrame{
+			PhyPayload: []byte{1, 2, 3, 4},
+			TxInfo: &gw.UplinkTXInfo{
+				Frequency: 868100000,
+			},
+			RxInfo: &gw.UplinkRXInfo{
+				GatewayId: []byte{gatewayID, gatewayID, gatewayID, gatewayID, gatewayID, gatewayID, gatewayID, gatewayID},
+				Rssi:      rssi,
+			},
+		}
+	}
+
+	tests := []struct {
+		Name        string
+		Enabled     bool
+		Uplinks     []gw.UplinkFrame
+		Created     []bool
+		ExpectedSet *integration.UplinkSet
+	}{
+		{
+			Name:    "disabled",
+			Uplinks: []gw.UplinkFrame{uplink(1, -50), uplink(2, -100)},
+			Created: []bool{false, false},
+		},
+		{
+			Name:    "single gateway",
+			Enabled: true,
+			Uplinks: []gw.UplinkFrame{uplink(1, -50)},
+			Created: []bool{true},
+		},
+		{
+			Name:    "same gateway twice",
+			Enabled: true,
+			Uplinks: []gw.UplinkFrame{uplink(1, -50), uplink(1, -52)},
+			Created: []bool{true, false},
+		},
+		{
+			Name:    "two gateways",
+			Enabled: true,
+			Uplinks: []gw.UplinkFrame{uplink(1, -50), uplink(2, -100)},
+			Created: []bool{true, false},
+			ExpectedSet: &integration.UplinkSet{
+				PhyPayload: []byte{1, 2, 3, 4},
+				TxInfo: &gw.UplinkTXInfo{
+					Frequency: 868100000,
+				},
+				RxInfo: []*gw.UplinkRXInfo{
+					uplink(1, -50).RxInfo,
+					uplink(2, -100).RxInfo,
+				},
+			},
+		},
+	}
+
+	for _, tst := range tests {
+		t.Run(tst.Name, func(t *testing.T) {
+			assert := require.New(t)
+
+			var conf config.Config
+			conf.UplinkSet.Enabled = tst.Enabled
+			conf.UplinkSet.Window = 500 * time.Millisecond
+			conf.UplinkSet.MinGateways = 2
+			assert.NoError(Setup(conf))
+
+			var key [sha256.Size]byte
+			for i, frame := range tst.Uplinks {
+				var created bool
+				key, created = add(frame)
+				assert.Equal(tst.Created[i], created)
+			}
+
+			assert.Equal(tst.ExpectedSet, complete(key))
+			assert.Len(sets, 0)
+		})
+	}
+}